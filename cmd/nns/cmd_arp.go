@@ -1,22 +1,51 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"net"
 	"os"
+	"os/signal"
+	"strings"
+	"time"
 
 	"github.com/JedizLaPulga/NNS/internal/arp"
+	"github.com/JedizLaPulga/NNS/internal/routes"
 )
 
-func runARP(args []string) {
+// arpFlags holds the flags of the arp command.
+type arpFlags struct {
+	interfaceFlag *string
+	vendorFlag    *bool
+	updateFlag    *bool
+	ouiURLFlag    *string
+	probeFlag     *string
+	detectFlag    *bool
+	watchFlag     *bool
+	jsonFlag      *bool
+	intervalFlag  *time.Duration
+}
+
+// newARPFlags defines the flags of the arp command.
+func newARPFlags() (*flag.FlagSet, *arpFlags) {
 	fs := flag.NewFlagSet("arp", flag.ExitOnError)
+	cli := &arpFlags{}
 
-	interfaceFlag := fs.String("interface", "", "Filter by interface")
-	vendorFlag := fs.Bool("vendor", true, "Show MAC vendor")
+	cli.interfaceFlag = fs.String("interface", "", "Filter by interface")
+	cli.vendorFlag = fs.Bool("vendor", true, "Show MAC vendor")
+	cli.updateFlag = fs.Bool("update-vendors", false, "Download the IEEE OUI registry for offline vendor lookup")
+	cli.ouiURLFlag = fs.String("oui-url", "", "Registry URL for --update-vendors (default: IEEE MA-L, MA-M, MA-S)")
+	cli.probeFlag = fs.String("probe", "", "Actively probe a local subnet (CIDR) before reading the table")
+	cli.detectFlag = fs.Bool("detect", false, "Detect ARP spoofing and duplicate mappings")
+	cli.watchFlag = fs.Bool("watch", false, "Keep re-reading the table and report changes")
+	cli.jsonFlag = fs.Bool("json", false, "Output in JSON format")
+	cli.intervalFlag = fs.Duration("interval", 5*time.Second, "Re-read interval for --watch")
 
 	// Short flags
-	fs.StringVar(interfaceFlag, "i", "", "Interface filter")
-	fs.BoolVar(vendorFlag, "v", true, "Show vendor")
+	fs.StringVar(cli.interfaceFlag, "i", "", "Interface filter")
+	fs.BoolVar(cli.vendorFlag, "v", true, "Show vendor")
 
 	fs.Usage = func() {
 		fmt.Println(`Usage: nns arp [OPTIONS]
@@ -26,26 +55,120 @@ View the system ARP table with MAC vendor lookup.
 OPTIONS:
   -i, --interface    Filter by network interface
   -v, --vendor       Show MAC vendor (default: true)
+      --update-vendors Download the IEEE OUI registry and cache it for
+                     offline vendor lookups
+      --oui-url      Registry CSV/oui.txt URL for --update-vendors
+      --probe        Probe every host in a local subnet (CIDR) so idle
+                     hosts appear, then show the entries in that range
+      --detect       Detect ARP spoofing indicators (one MAC with many IPs,
+                     one IP with many MACs, gateway MAC changes)
+      --watch        Re-read the table continuously and print added (+),
+                     removed (-) and changed (~) entries; with --detect,
+                     print new anomalies instead
+      --interval     Re-read interval for --watch (default: 5s)
+      --json         Output in JSON format (one event per line with --watch;
+                     a report object with --detect)
       --help         Show this help message
 
 EXAMPLES:
   nns arp
-  nns arp --interface eth0`)
+  nns arp --interface eth0
+  nns arp --update-vendors
+  nns arp --probe 192.168.1.0/24
+  nns arp --detect
+  nns arp --detect --watch --interval 2s
+  nns arp --watch
+  nns arp --json`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runARP(args []string) {
+	fs, cli := newARPFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		os.Exit(1)
 	}
 
-	entries, err := arp.GetTable()
+	if *cli.updateFlag {
+		fmt.Println("Downloading OUI registry...")
+		n, err := arp.UpdateOUIDatabase(context.Background(), *cli.ouiURLFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		path, _ := arp.OUICacheFile()
+		fmt.Printf("Cached %d vendor assignments to %s\n", n, path)
+		return
+	}
+
+	// probed limits every table read to the --probe range, so watch mode
+	// compares like with like.
+	var probed *net.IPNet
+	readTable := func() ([]arp.Entry, error) {
+		entries, err := arp.GetTable()
+		if err != nil {
+			return nil, err
+		}
+		if *cli.interfaceFlag != "" {
+			entries = arp.FilterByInterface(entries, *cli.interfaceFlag)
+		}
+		if probed != nil {
+			entries = arp.FilterBySubnet(entries, probed)
+		}
+		return entries, nil
+	}
+
+	var entries []arp.Entry
+	var err error
+	if *cli.probeFlag != "" {
+		if !*cli.jsonFlag {
+			fmt.Printf("Probing %s...\n\n", *cli.probeFlag)
+		}
+		var result *arp.ProbeResult
+		result, err = arp.Probe(context.Background(), *cli.probeFlag)
+		if err == nil {
+			if result.Fallback != "" {
+				fmt.Fprintf(os.Stderr, "Note: %s\n", result.Fallback)
+			}
+			probed = result.Subnet
+			entries = result.Entries
+			if *cli.interfaceFlag != "" {
+				entries = arp.FilterByInterface(entries, *cli.interfaceFlag)
+			}
+			// Watch the OS table itself: hosts that only answered the
+			// probe are not cached and would otherwise show as removed.
+			if *cli.watchFlag && !*cli.detectFlag {
+				entries, err = readTable()
+			}
+		}
+	} else {
+		entries, err = readTable()
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Filter by interface
-	if *interfaceFlag != "" {
-		entries = arp.FilterByInterface(entries, *interfaceFlag)
+	if *cli.detectFlag {
+		runARPDetect(entries, readTable, *cli.watchFlag, *cli.intervalFlag, *cli.jsonFlag)
+		return
+	}
+
+	if *cli.watchFlag {
+		runARPWatch(entries, readTable, *cli.intervalFlag, *cli.jsonFlag)
+		return
+	}
+
+	if *cli.jsonFlag {
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "JSON error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
 	}
 
 	if len(entries) == 0 {
@@ -54,7 +177,7 @@ EXAMPLES:
 	}
 
 	// Print header
-	if *vendorFlag {
+	if *cli.vendorFlag {
 		fmt.Printf("%-16s %-20s %-12s %-15s %s\n", "IP", "MAC", "INTERFACE", "TYPE", "VENDOR")
 	} else {
 		fmt.Printf("%-16s %-20s %-12s %s\n", "IP", "MAC", "INTERFACE", "TYPE")
@@ -62,7 +185,7 @@ EXAMPLES:
 	fmt.Println("────────────────────────────────────────────────────────────────────────────")
 
 	for _, e := range entries {
-		if *vendorFlag {
+		if *cli.vendorFlag {
 			vendor := e.Vendor
 			if vendor == "" {
 				vendor = "-"
@@ -75,3 +198,181 @@ EXAMPLES:
 
 	fmt.Printf("\nTotal: %d entries\n", len(entries))
 }
+
+// arpChange is a watch-mode event in JSON output.
+type arpChange struct {
+	Time   time.Time `json:"time"`
+	Change string    `json:"change"` // added, removed or changed
+	Entry  arp.Entry `json:"entry"`
+}
+
+// runARPWatch re-reads the table on every interval and prints the
+// differences from the previous snapshot.
+func runARPWatch(entries []arp.Entry, readTable func() ([]arp.Entry, error), interval time.Duration, jsonOut bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	go func() {
+		<-sigCh
+		if !jsonOut {
+			fmt.Println("\nStopping ARP watch...")
+		}
+		cancel()
+	}()
+
+	if !jsonOut {
+		fmt.Printf("Watching ARP table (%d entries) every %v (Ctrl+C to stop)...\n", len(entries), interval)
+		fmt.Println(strings.Repeat("─", 76))
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	report := func(change, marker string, e arp.Entry) {
+		if jsonOut {
+			enc.Encode(arpChange{Time: time.Now(), Change: change, Entry: e})
+			return
+		}
+		vendor := e.Vendor
+		if vendor == "" {
+			vendor = "-"
+		}
+		fmt.Printf("%s %s %-16s %-20s %-12s %s\n", time.Now().Format("15:04:05"), marker, e.IP, e.MAC, e.Interface, vendor)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	prev := entries
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			curr, err := readTable()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				continue
+			}
+			added, removed, changed := arp.Diff(prev, curr)
+			for _, e := range added {
+				report("added", "+", e)
+			}
+			for _, e := range removed {
+				report("removed", "-", e)
+			}
+			for _, e := range changed {
+				report("changed", "~", e)
+			}
+			prev = curr
+		}
+	}
+}
+
+// arpDetectReport is the JSON output of a one-shot --detect run.
+type arpDetectReport struct {
+	Gateway   string        `json:"gateway,omitempty"`
+	Entries   int           `json:"entries"`
+	Anomalies []arp.Anomaly `json:"anomalies"`
+}
+
+// arpAnomalyEvent is a watch-mode anomaly in JSON output.
+type arpAnomalyEvent struct {
+	Time time.Time `json:"time"`
+	arp.Anomaly
+}
+
+// runARPDetect reports ARP anomalies in the current table and, in watch
+// mode, anomalies that appear between successive snapshots.
+func runARPDetect(entries []arp.Entry, readTable func() ([]arp.Entry, error), watch bool, interval time.Duration, jsonOut bool) {
+	gateway, _ := routes.GetDefaultGateway()
+
+	anomalies := arp.DetectAnomalies(entries)
+	enc := json.NewEncoder(os.Stdout)
+	report := func(a arp.Anomaly) {
+		if jsonOut {
+			enc.Encode(arpAnomalyEvent{Time: time.Now(), Anomaly: a})
+			return
+		}
+		printARPAnomaly(a)
+	}
+
+	switch {
+	case jsonOut && !watch:
+		if anomalies == nil {
+			anomalies = []arp.Anomaly{}
+		}
+		data, err := json.MarshalIndent(arpDetectReport{Gateway: gateway, Entries: len(entries), Anomalies: anomalies}, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "JSON error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+	case jsonOut:
+		for _, a := range anomalies {
+			report(a)
+		}
+	default:
+		fmt.Printf("Analyzing %d ARP entries", len(entries))
+		if gateway != "" {
+			fmt.Printf(" (gateway %s)", gateway)
+		}
+		fmt.Println()
+		fmt.Println(strings.Repeat("─", 76))
+
+		if len(anomalies) == 0 {
+			fmt.Println("No ARP anomalies detected")
+		}
+		for _, a := range anomalies {
+			report(a)
+		}
+	}
+
+	if !watch {
+		if len(anomalies) > 0 {
+			os.Exit(2)
+		}
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	go func() {
+		<-sigCh
+		if !jsonOut {
+			fmt.Println("\nStopping ARP watch...")
+		}
+		cancel()
+	}()
+
+	if !jsonOut {
+		fmt.Printf("\nWatching for changes every %v (Ctrl+C to stop)...\n", interval)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	watcher := arp.NewWatcher(gateway, entries)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			curr, err := readTable()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				continue
+			}
+			for _, a := range watcher.Update(curr) {
+				report(a)
+			}
+		}
+	}
+}
+
+func printARPAnomaly(a arp.Anomaly) {
+	fmt.Printf("%s [%s] %s\n", time.Now().Format("15:04:05"), strings.ToUpper(a.Severity), a.Message)
+}
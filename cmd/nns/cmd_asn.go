@@ -11,13 +11,21 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/asn"
 )
 
-func runASN(args []string) {
+// asnFlags holds the flags of the asn command.
+type asnFlags struct {
+	timeout *time.Duration
+	noRDAP  *bool
+}
+
+// newASNFlags defines the flags of the asn command.
+func newASNFlags() (*flag.FlagSet, *asnFlags) {
 	fs := flag.NewFlagSet("asn", flag.ExitOnError)
-	timeout := fs.Duration("timeout", 10*time.Second, "Lookup timeout")
-	noRDAP := fs.Bool("no-rdap", false, "Skip RDAP lookup")
+	cli := &asnFlags{}
+	cli.timeout = fs.Duration("timeout", 10*time.Second, "Lookup timeout")
+	cli.noRDAP = fs.Bool("no-rdap", false, "Skip RDAP lookup")
 
 	// Short flags
-	fs.DurationVar(timeout, "t", 10*time.Second, "Lookup timeout")
+	fs.DurationVar(cli.timeout, "t", 10*time.Second, "Lookup timeout")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns asn [options] <ip-or-host> [...]
@@ -38,7 +46,13 @@ Examples:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runASN(args []string) {
+	fs, cli := newASNFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -60,8 +74,8 @@ Examples:
 	}()
 
 	opts := asn.LookupOptions{
-		Timeout:   *timeout,
-		FetchRDAP: !*noRDAP,
+		Timeout:   *cli.timeout,
+		FetchRDAP: !*cli.noRDAP,
 	}
 
 	targets := fs.Args()
@@ -10,23 +10,35 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/bench"
 )
 
-func runBench(args []string) {
+// benchFlags holds the flags of the bench command.
+type benchFlags struct {
+	requestsFlag    *int
+	concurrencyFlag *int
+	durationFlag    *time.Duration
+	timeoutFlag     *time.Duration
+	methodFlag      *string
+	keepAliveFlag   *bool
+}
+
+// newBenchFlags defines the flags of the bench command.
+func newBenchFlags() (*flag.FlagSet, *benchFlags) {
 	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	cli := &benchFlags{}
 
 	// Flags
-	requestsFlag := fs.Int("requests", 0, "Number of requests to perform")
-	concurrencyFlag := fs.Int("concurrent", 1, "Number of concurrent workers")
-	durationFlag := fs.Duration("duration", 0, "Duration of test (overrides requests)")
-	timeoutFlag := fs.Duration("timeout", 10*time.Second, "Request timeout")
-	methodFlag := fs.String("method", "GET", "HTTP method")
-	keepAliveFlag := fs.Bool("keepalive", true, "Use HTTP Keep-Alive")
+	cli.requestsFlag = fs.Int("requests", 0, "Number of requests to perform")
+	cli.concurrencyFlag = fs.Int("concurrent", 1, "Number of concurrent workers")
+	cli.durationFlag = fs.Duration("duration", 0, "Duration of test (overrides requests)")
+	cli.timeoutFlag = fs.Duration("timeout", 10*time.Second, "Request timeout")
+	cli.methodFlag = fs.String("method", "GET", "HTTP method")
+	cli.keepAliveFlag = fs.Bool("keepalive", true, "Use HTTP Keep-Alive")
 
 	// Short flags aliases
-	fs.IntVar(requestsFlag, "n", 0, "Number of requests")
-	fs.IntVar(concurrencyFlag, "c", 1, "Concurrency")
-	fs.DurationVar(durationFlag, "z", 0, "Duration")
-	fs.DurationVar(timeoutFlag, "t", 10*time.Second, "Timeout")
-	fs.StringVar(methodFlag, "m", "GET", "Method")
+	fs.IntVar(cli.requestsFlag, "n", 0, "Number of requests")
+	fs.IntVar(cli.concurrencyFlag, "c", 1, "Concurrency")
+	fs.DurationVar(cli.durationFlag, "z", 0, "Duration")
+	fs.DurationVar(cli.timeoutFlag, "t", 10*time.Second, "Timeout")
+	fs.StringVar(cli.methodFlag, "m", "GET", "Method")
 
 	fs.Usage = func() {
 		fmt.Println(`Usage: nns bench [OPTIONS] [URL]
@@ -48,7 +60,13 @@ EXAMPLES:
   nns bench -m POST -n 100 https://api.site.com`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runBench(args []string) {
+	fs, cli := newBenchFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		os.Exit(1)
 	}
 
@@ -61,19 +79,19 @@ EXAMPLES:
 	url := fs.Arg(0)
 
 	// Default to 1 requests if neither duration nor count specified
-	reqCount := *requestsFlag
-	if reqCount == 0 && *durationFlag == 0 {
+	reqCount := *cli.requestsFlag
+	if reqCount == 0 && *cli.durationFlag == 0 {
 		reqCount = 1
 	}
 
 	cfg := bench.Config{
 		URL:              url,
-		Method:           *methodFlag,
+		Method:           *cli.methodFlag,
 		RequestCount:     reqCount,
-		Duration:         *durationFlag,
-		Concurrency:      *concurrencyFlag,
-		Timeout:          *timeoutFlag,
-		DisableKeepAlive: !*keepAliveFlag,
+		Duration:         *cli.durationFlag,
+		Concurrency:      *cli.concurrencyFlag,
+		Timeout:          *cli.timeoutFlag,
+		DisableKeepAlive: !*cli.keepAliveFlag,
 	}
 
 	fmt.Printf("Benchmarking %s...\n", url)
@@ -4,23 +4,42 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
-	"strings"
 	"syscall"
 	"time"
 
 	"github.com/JedizLaPulga/NNS/internal/blacklist"
+	"github.com/JedizLaPulga/NNS/internal/output"
 )
 
-func runBlacklist(args []string) {
+// blacklistFlags holds the flags of the blacklist command.
+type blacklistFlags struct {
+	timeout     *time.Duration
+	concurrency *int
+	brief       *bool
+	noTXT       *bool
+	jsonOut     *bool
+	listFile    *string
+	targetFile  *string
+}
+
+// newBlacklistFlags defines the flags of the blacklist command.
+func newBlacklistFlags() (*flag.FlagSet, *blacklistFlags) {
 	fs := flag.NewFlagSet("blacklist", flag.ExitOnError)
-	timeout := fs.Duration("timeout", 5*time.Second, "Lookup timeout")
-	concurrency := fs.Int("concurrency", 10, "Parallel lookups")
-	brief := fs.Bool("brief", false, "Brief output")
-	noTXT := fs.Bool("no-txt", false, "Skip TXT record lookup")
+	cli := &blacklistFlags{}
+	cli.timeout = fs.Duration("timeout", 5*time.Second, "Lookup timeout")
+	cli.concurrency = fs.Int("concurrency", 10, "Parallel lookups")
+	fs.IntVar(cli.concurrency, "concurrent", 10, "Parallel lookups (alias of --concurrency)")
+	cli.brief = fs.Bool("brief", false, "Brief output")
+	cli.noTXT = fs.Bool("no-txt", false, "Skip TXT record lookup")
+	cli.jsonOut = fs.Bool("json", false, "Output in JSON format")
+	cli.targetFile = fs.String("file", "", "Check every IP or domain in a file, one per line")
+	cli.listFile = fs.String("blacklist-file", "", "Extra blacklists, one \"zone|type|weight|name|category|delist url\" per line")
 	fs.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: nns blacklist [options] <ip|domain>\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: nns blacklist [options] <ip|domain>\n")
+		fmt.Fprintf(os.Stderr, "       nns blacklist [options] --file targets.txt\n\n")
 		fmt.Fprintf(os.Stderr, "Check IP or domain against spam/malware blacklists.\n\n")
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		fs.PrintDefaults()
@@ -28,20 +47,35 @@ func runBlacklist(args []string) {
 		fmt.Fprintf(os.Stderr, "  nns blacklist 8.8.8.8\n")
 		fmt.Fprintf(os.Stderr, "  nns blacklist example.com\n")
 		fmt.Fprintf(os.Stderr, "  nns blacklist --brief 192.168.1.1\n")
+		fmt.Fprintf(os.Stderr, "  nns blacklist --json --no-txt 2001:db8::1\n")
+		fmt.Fprintf(os.Stderr, "  nns blacklist --blacklist-file lists.txt 192.0.2.1\n")
+		fmt.Fprintf(os.Stderr, "  nns blacklist --file sending-ips.txt --json\n")
 	}
-	fs.Parse(args)
 
-	if fs.NArg() < 1 {
+	return fs, cli
+}
+
+func runBlacklist(args []string) {
+	fs, cli := newBlacklistFlags()
+	parseFlags(fs, args)
+
+	if fs.NArg() < 1 && *cli.targetFile == "" {
 		fs.Usage()
 		os.Exit(1)
 	}
 
-	target := fs.Arg(0)
-
 	opts := blacklist.DefaultOptions()
-	opts.Timeout = *timeout
-	opts.Concurrency = *concurrency
-	opts.IncludeTXT = !*noTXT
+	opts.Timeout = *cli.timeout
+	opts.Concurrency = *cli.concurrency
+	opts.IncludeTXT = !*cli.noTXT
+	if *cli.listFile != "" {
+		extra, err := loadBlacklists(*cli.listFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts.Blacklists = blacklist.MergeBlacklists(opts.Blacklists, extra)
+	}
 
 	checker := blacklist.NewChecker(opts)
 
@@ -55,15 +89,21 @@ func runBlacklist(args []string) {
 		cancel()
 	}()
 
+	if *cli.targetFile != "" {
+		runBlacklistFile(ctx, checker, *cli.targetFile, *cli.jsonOut)
+		return
+	}
+
 	// Determine if IP or domain
+	target := fs.Arg(0)
 	var result *blacklist.CheckResult
 	var err error
 
-	if isIPAddress(target) {
-		fmt.Printf("Checking IP %s against %d blacklists...\n", target, len(opts.Blacklists))
+	if net.ParseIP(target) != nil {
+		fmt.Fprintf(os.Stderr, "Checking IP %s against %d blacklists...\n", target, len(opts.Blacklists))
 		result, err = checker.CheckIP(ctx, target)
 	} else {
-		fmt.Printf("Checking domain %s against URI blacklists...\n", target)
+		fmt.Fprintf(os.Stderr, "Checking domain %s against URI blacklists...\n", target)
 		result, err = checker.CheckDomain(ctx, target)
 	}
 
@@ -72,36 +112,92 @@ func runBlacklist(args []string) {
 		os.Exit(1)
 	}
 
-	if *brief {
+	switch {
+	case *cli.jsonOut:
+		out, err := result.ToJSON()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(out)
+	case *cli.brief:
 		fmt.Println(result.FormatCompact())
-	} else {
+	default:
 		fmt.Print(result.Format())
 	}
 
-	// Exit code based on listings
-	if result.IsClean() {
-		os.Exit(0)
-	} else if result.TotalListed <= 2 {
-		os.Exit(1) // Some listings
-	} else {
-		os.Exit(2) // Many listings
-	}
+	os.Exit(blacklistExitCode(result))
 }
 
-func isIPAddress(s string) bool {
-	parts := strings.Split(s, ".")
-	if len(parts) != 4 {
-		return false
+// runBlacklistFile checks every target listed in path and prints one
+// summary line per target, or a JSON object keyed by target. It exits
+// with the worst status of any target.
+func runBlacklistFile(ctx context.Context, checker *blacklist.Checker, path string, jsonOut bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
-	for _, p := range parts {
-		if len(p) == 0 || len(p) > 3 {
-			return false
+	targets, err := blacklist.ParseTargets(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s: %v\n", path, err)
+		os.Exit(1)
+	}
+	if len(targets) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: no targets in %s\n", path)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr, "Checking %d targets from %s...\n", len(targets), path)
+	results := checker.CheckTargets(ctx, targets)
+
+	if jsonOut {
+		if err := output.Stdout.JSON(results); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
 		}
-		for _, c := range p {
-			if c < '0' || c > '9' {
-				return false
+	} else {
+		for _, target := range targets {
+			if result, ok := results[target]; ok {
+				fmt.Println(result.FormatCompact())
+			} else {
+				fmt.Printf("? %s: check failed\n", target)
 			}
 		}
 	}
-	return true
+
+	code := 0
+	for _, result := range results {
+		code = max(code, blacklistExitCode(result))
+	}
+	os.Exit(code)
+}
+
+// blacklistExitCode maps a result to the command's exit status: 0 when
+// clean, 1 for a few listings and 2 for many.
+func blacklistExitCode(result *blacklist.CheckResult) int {
+	switch {
+	case result.IsClean():
+		return 0
+	case result.TotalListed <= 2:
+		return 1 // Some listings
+	default:
+		return 2 // Many listings
+	}
+}
+
+// loadBlacklists reads extra blacklists from path.
+func loadBlacklists(path string) ([]blacklist.Blacklist, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lists, err := blacklist.ParseBlacklists(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return lists, nil
 }
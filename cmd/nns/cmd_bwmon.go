@@ -12,19 +12,30 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/bwmon"
 )
 
-func runBWMon(args []string) {
+// bwMonFlags holds the flags of the bwmon command.
+type bwMonFlags struct {
+	intervalFlag *time.Duration
+	activeFlag   *bool
+	countFlag    *int
+	simulateFlag *bool
+	compactFlag  *bool
+}
+
+// newBWMonFlags defines the flags of the bwmon command.
+func newBWMonFlags() (*flag.FlagSet, *bwMonFlags) {
 	fs := flag.NewFlagSet("bwmon", flag.ExitOnError)
-	intervalFlag := fs.Duration("interval", 1*time.Second, "Update interval")
-	activeFlag := fs.Bool("active", false, "Only show interfaces with traffic")
-	countFlag := fs.Int("count", 0, "Number of updates (0 = infinite)")
-	simulateFlag := fs.Bool("simulate", false, "Use simulated data for demo")
-	compactFlag := fs.Bool("compact", false, "Compact single-line output")
+	cli := &bwMonFlags{}
+	cli.intervalFlag = fs.Duration("interval", 1*time.Second, "Update interval")
+	cli.activeFlag = fs.Bool("active", false, "Only show interfaces with traffic")
+	cli.countFlag = fs.Int("count", 0, "Number of updates (0 = infinite)")
+	cli.simulateFlag = fs.Bool("simulate", false, "Use simulated data for demo")
+	cli.compactFlag = fs.Bool("compact", false, "Compact single-line output")
 
 	// Short flags
-	fs.DurationVar(intervalFlag, "i", 1*time.Second, "Update interval")
-	fs.BoolVar(activeFlag, "a", false, "Only show interfaces with traffic")
-	fs.IntVar(countFlag, "c", 0, "Number of updates")
-	fs.BoolVar(simulateFlag, "s", false, "Simulate data")
+	fs.DurationVar(cli.intervalFlag, "i", 1*time.Second, "Update interval")
+	fs.BoolVar(cli.activeFlag, "a", false, "Only show interfaces with traffic")
+	fs.IntVar(cli.countFlag, "c", 0, "Number of updates")
+	fs.BoolVar(cli.simulateFlag, "s", false, "Simulate data")
 
 	fs.Usage = func() {
 		fmt.Println(`Usage: nns bwmon [OPTIONS]
@@ -46,14 +57,20 @@ EXAMPLES:
   nns bwmon --simulate -c 10`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runBWMon(args []string) {
+	fs, cli := newBWMonFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing flags: %v\n", err)
 		os.Exit(1)
 	}
 
 	monitor := bwmon.NewMonitor()
-	monitor.Interval = *intervalFlag
-	monitor.FilterActive = *activeFlag
+	monitor.Interval = *cli.intervalFlag
+	monitor.FilterActive = *cli.activeFlag
 
 	// Handle Ctrl+C
 	ctx, cancel := context.WithCancel(context.Background())
@@ -69,7 +86,7 @@ EXAMPLES:
 
 	fmt.Println("Bandwidth Monitor")
 	fmt.Printf("Interval: %v", monitor.Interval)
-	if *simulateFlag {
+	if *cli.simulateFlag {
 		fmt.Print(" (simulated)")
 	}
 	fmt.Println()
@@ -92,7 +109,7 @@ EXAMPLES:
 			var stats []bwmon.InterfaceStats
 			var err error
 
-			if *simulateFlag {
+			if *cli.simulateFlag {
 				stats = bwmon.SimulatedStats(iteration)
 			} else {
 				stats, err = monitor.GetStats()
@@ -116,20 +133,20 @@ EXAMPLES:
 			}
 
 			// Clear screen for updates (keep header visible)
-			if iteration > 1 && !*compactFlag {
+			if iteration > 1 && !*cli.compactFlag {
 				// Move cursor up and clear lines
 				for i := 0; i < len(rates)+2; i++ {
 					fmt.Print("\033[A\033[K")
 				}
 			}
 
-			if *compactFlag {
+			if *cli.compactFlag {
 				printCompactRates(rates, iteration)
 			} else {
 				printDetailedRates(rates, maxRx, maxTx)
 			}
 
-			if *countFlag > 0 && iteration >= *countFlag {
+			if *cli.countFlag > 0 && iteration >= *cli.countFlag {
 				fmt.Println("\nDone.")
 				return
 			}
@@ -4,36 +4,82 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/JedizLaPulga/NNS/internal/certhunt"
 )
 
-func runCerthunt(args []string) {
+// certhuntFlags holds the flags of the certhunt command.
+type certhuntFlags struct {
+	timeout      *time.Duration
+	noLive       *bool
+	maxResults   *int
+	brief        *bool
+	jsonOut      *bool
+	retries      *int
+	retryDelay   *time.Duration
+	since        *string
+	until        *string
+	wildcards    *bool
+	subdomains   *bool
+	output       *string
+	censysID     *string
+	censysSecret *string
+}
+
+// newCerthuntFlags defines the flags of the certhunt command.
+func newCerthuntFlags() (*flag.FlagSet, *certhuntFlags) {
 	fs := flag.NewFlagSet("certhunt", flag.ExitOnError)
-	timeout := fs.Duration("timeout", 15*time.Second, "Search timeout")
-	noLive := fs.Bool("no-live", false, "Skip live certificate check")
-	maxResults := fs.Int("max", 100, "Maximum CT log results")
-	brief := fs.Bool("brief", false, "Brief output")
+	cli := &certhuntFlags{}
+	cli.timeout = fs.Duration("timeout", 15*time.Second, "Search timeout")
+	cli.noLive = fs.Bool("no-live", false, "Skip live certificate check")
+	cli.maxResults = fs.Int("max", 100, "Maximum CT log results")
+	fs.IntVar(cli.maxResults, "max-results", 100, "Maximum CT log results (alias of --max)")
+	cli.brief = fs.Bool("brief", false, "Brief output")
+	cli.jsonOut = fs.Bool("json", false, "Output in JSON format")
+	cli.retries = fs.Int("retries", 2, "Retries for crt.sh rate limits and 5xx errors (-1 disables)")
+	cli.retryDelay = fs.Duration("retry-delay", 2*time.Second, "Initial delay between crt.sh retries (doubles each retry)")
+	cli.since = fs.String("since", "", "Only certificates issued on or after this date (YYYY-MM-DD or RFC 3339)")
+	cli.until = fs.String("until", "", "Only certificates issued on or before this date (YYYY-MM-DD or RFC 3339)")
+	cli.wildcards = fs.Bool("wildcards", false, "Only wildcard certificates")
+	cli.subdomains = fs.Bool("subdomains", false, "Print only the unique subdomains found")
+	cli.output = fs.String("output", "", "Write the subdomain list to a file")
+	cli.censysID = fs.String("censys-id", os.Getenv("CENSYS_API_ID"), "Censys API ID (enables the Censys source)")
+	cli.censysSecret = fs.String("censys-secret", os.Getenv("CENSYS_API_SECRET"), "Censys API secret")
 
 	// Short flags
-	fs.DurationVar(timeout, "t", 15*time.Second, "Search timeout")
-	fs.IntVar(maxResults, "n", 100, "Maximum CT log results")
+	fs.DurationVar(cli.timeout, "t", 15*time.Second, "Search timeout")
+	fs.IntVar(cli.maxResults, "n", 100, "Maximum CT log results")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns certhunt [options] <domain>
 
 Search Certificate Transparency logs for all certificates issued for a domain.
-Also checks the live TLS certificate for comparison.
+crt.sh and Cert Spotter are queried concurrently (plus Censys when credentials
+are given) and results are merged. Also checks the live TLS certificate for
+comparison.
 
 Options:
   --timeout, -t    Search timeout (default: 15s)
-  --max, -n        Maximum CT log results (default: 100)
+  --max-results, -n
+                   Maximum CT log results (default: 100; alias: --max)
   --no-live        Skip live certificate check
   --brief          Brief output
+  --json           Output in JSON format
+  --retries        crt.sh retries on 429/5xx, -1 disables (default: 2)
+  --retry-delay    Initial crt.sh retry delay, doubled each retry (default: 2s)
+  --since DATE     Only certificates issued on or after DATE (YYYY-MM-DD)
+  --until DATE     Only certificates issued on or before DATE (YYYY-MM-DD)
+  --wildcards      Only wildcard certificates
+  --subdomains     Print only the unique subdomains found, one per line
+  --output FILE    Write the subdomain list to FILE
+  --censys-id      Censys API ID (default: $CENSYS_API_ID)
+  --censys-secret  Censys API secret (default: $CENSYS_API_SECRET)
   --help           Show this help message
 
 Examples:
@@ -41,10 +87,19 @@ Examples:
   nns certhunt github.com -n 50
   nns certhunt internal.corp --no-live
   nns certhunt example.com --brief
+  nns certhunt example.com --since 2024-01-01 --wildcards
+  nns certhunt example.com --subdomains --output subs.txt
+  nns certhunt --json --max-results 500 example.com
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runCerthunt(args []string) {
+	fs, cli := newCerthuntFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -58,10 +113,38 @@ Examples:
 	domain := fs.Arg(0)
 
 	opts := certhunt.Options{
-		Domain:     domain,
-		Timeout:    *timeout,
-		CheckLive:  !*noLive,
-		MaxResults: *maxResults,
+		Domain:        domain,
+		Timeout:       *cli.timeout,
+		CheckLive:     !*cli.noLive,
+		MaxResults:    *cli.maxResults,
+		Retries:       *cli.retries,
+		RetryDelay:    *cli.retryDelay,
+		WildcardsOnly: *cli.wildcards,
+	}
+
+	var err error
+	if *cli.since != "" {
+		if opts.Since, _, err = parseCertDate(*cli.since); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --since: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *cli.until != "" {
+		var dateOnly bool
+		if opts.Until, dateOnly, err = parseCertDate(*cli.until); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --until: %v\n", err)
+			os.Exit(1)
+		}
+		if dateOnly {
+			opts.Until = opts.Until.AddDate(0, 0, 1) // include the whole day
+		}
+	}
+
+	if *cli.censysID != "" && *cli.censysSecret != "" {
+		opts.Sources = []certhunt.Source{
+			&certhunt.CertSpotter{Client: &http.Client{Timeout: *cli.timeout}},
+			&certhunt.Censys{Client: &http.Client{Timeout: *cli.timeout}, APIID: *cli.censysID, Secret: *cli.censysSecret},
+		}
 	}
 
 	searcher := certhunt.NewSearcher(opts)
@@ -76,7 +159,7 @@ Examples:
 		cancel()
 	}()
 
-	fmt.Printf("Searching CT logs for %s...\n", domain)
+	fmt.Fprintf(os.Stderr, "Searching CT logs for %s...\n", domain)
 
 	result, err := searcher.Search(ctx)
 	if err != nil {
@@ -84,9 +167,48 @@ Examples:
 		os.Exit(1)
 	}
 
-	if *brief {
+	if *cli.output != "" {
+		data := strings.Join(result.Subdomains(), "\n")
+		if data != "" {
+			data += "\n"
+		}
+		if err := os.WriteFile(*cli.output, []byte(data), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *cli.subdomains {
+		for _, name := range result.Subdomains() {
+			fmt.Println(name)
+		}
+		for _, e := range result.Errors {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", e)
+		}
+		return
+	}
+
+	switch {
+	case *cli.jsonOut:
+		out, err := result.ToJSON()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(out)
+	case *cli.brief:
 		fmt.Println(result.FormatCompact())
-	} else {
+	default:
 		fmt.Print(result.Format())
 	}
 }
+
+// parseCertDate parses a YYYY-MM-DD date or an RFC 3339 timestamp and
+// reports whether only a date was given.
+func parseCertDate(s string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	return t, false, err
+}
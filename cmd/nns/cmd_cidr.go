@@ -8,12 +8,21 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/cidr"
 )
 
-func runCIDR(args []string) {
+// cidrFlags holds the flags of the cidr command.
+type cidrFlags struct {
+	containsFlag *string
+	splitFlag    *int
+	rangeFlag    *bool
+}
+
+// newCIDRFlags defines the flags of the cidr command.
+func newCIDRFlags() (*flag.FlagSet, *cidrFlags) {
 	fs := flag.NewFlagSet("cidr", flag.ExitOnError)
+	cli := &cidrFlags{}
 
-	containsFlag := fs.String("contains", "", "Check if IP is in CIDR range")
-	splitFlag := fs.Int("split", 0, "Split into smaller subnets with this prefix")
-	rangeFlag := fs.Bool("range", false, "List all IPs in range")
+	cli.containsFlag = fs.String("contains", "", "Check if IP is in CIDR range")
+	cli.splitFlag = fs.Int("split", 0, "Split into smaller subnets with this prefix")
+	cli.rangeFlag = fs.Bool("range", false, "List all IPs in range")
 
 	fs.Usage = func() {
 		fmt.Println(`Usage: nns cidr [CIDR] [OPTIONS]
@@ -33,7 +42,13 @@ EXAMPLES:
   nns cidr 192.168.1.0/28 --range`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runCIDR(args []string) {
+	fs, cli := newCIDRFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		os.Exit(1)
 	}
 
@@ -46,28 +61,28 @@ EXAMPLES:
 	cidrStr := fs.Arg(0)
 
 	// Check if IP is in range
-	if *containsFlag != "" {
-		contains, err := cidr.Contains(cidrStr, *containsFlag)
+	if *cli.containsFlag != "" {
+		contains, err := cidr.Contains(cidrStr, *cli.containsFlag)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 		if contains {
-			fmt.Printf("✓ %s is within %s\n", *containsFlag, cidrStr)
+			fmt.Printf("✓ %s is within %s\n", *cli.containsFlag, cidrStr)
 		} else {
-			fmt.Printf("✗ %s is NOT within %s\n", *containsFlag, cidrStr)
+			fmt.Printf("✗ %s is NOT within %s\n", *cli.containsFlag, cidrStr)
 		}
 		return
 	}
 
 	// Split subnet
-	if *splitFlag > 0 {
-		subnets, err := cidr.Split(cidrStr, *splitFlag)
+	if *cli.splitFlag > 0 {
+		subnets, err := cidr.Split(cidrStr, *cli.splitFlag)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Printf("Splitting %s into /%d subnets:\n\n", cidrStr, *splitFlag)
+		fmt.Printf("Splitting %s into /%d subnets:\n\n", cidrStr, *cli.splitFlag)
 		for i, subnet := range subnets {
 			fmt.Printf("  %d. %s\n", i+1, subnet)
 		}
@@ -76,7 +91,7 @@ EXAMPLES:
 	}
 
 	// List all IPs
-	if *rangeFlag {
+	if *cli.rangeFlag {
 		ips, err := cidr.IPRange(cidrStr)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
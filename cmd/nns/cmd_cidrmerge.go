@@ -8,12 +8,22 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/cidrmerge"
 )
 
-func runCIDRMerge(args []string) {
+// cidrMergeFlags holds the flags of the cidrmerge command.
+type cidrMergeFlags struct {
+	checkContains *string
+	checkOverlap  *string
+	exclude       *string
+	hostCount     *string
+}
+
+// newCIDRMergeFlags defines the flags of the cidrmerge command.
+func newCIDRMergeFlags() (*flag.FlagSet, *cidrMergeFlags) {
 	fs := flag.NewFlagSet("cidrmerge", flag.ExitOnError)
-	checkContains := fs.String("contains", "", "Check if a CIDR contains an IP (format: cidr,ip)")
-	checkOverlap := fs.String("overlap", "", "Check if two CIDRs overlap (format: cidr1,cidr2)")
-	exclude := fs.String("exclude", "", "Exclude a range from a CIDR (format: base,exclude)")
-	hostCount := fs.String("hosts", "", "Count usable hosts in a CIDR")
+	cli := &cidrMergeFlags{}
+	cli.checkContains = fs.String("contains", "", "Check if a CIDR contains an IP (format: cidr,ip)")
+	cli.checkOverlap = fs.String("overlap", "", "Check if two CIDRs overlap (format: cidr1,cidr2)")
+	cli.exclude = fs.String("exclude", "", "Exclude a range from a CIDR (format: base,exclude)")
+	cli.hostCount = fs.String("hosts", "", "Count usable hosts in a CIDR")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns cidrmerge [options] <cidr> [cidr...]
@@ -38,14 +48,20 @@ Examples:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runCIDRMerge(args []string) {
+	fs, cli := newCIDRMergeFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Mode: contains
-	if *checkContains != "" {
-		parts := splitTwo(*checkContains)
+	if *cli.checkContains != "" {
+		parts := splitTwo(*cli.checkContains)
 		if parts == nil {
 			fmt.Fprintf(os.Stderr, "Error: --contains requires format: cidr,ip\n")
 			os.Exit(1)
@@ -64,8 +80,8 @@ Examples:
 	}
 
 	// Mode: overlap
-	if *checkOverlap != "" {
-		parts := splitTwo(*checkOverlap)
+	if *cli.checkOverlap != "" {
+		parts := splitTwo(*cli.checkOverlap)
 		if parts == nil {
 			fmt.Fprintf(os.Stderr, "Error: --overlap requires format: cidr1,cidr2\n")
 			os.Exit(1)
@@ -84,8 +100,8 @@ Examples:
 	}
 
 	// Mode: exclude
-	if *exclude != "" {
-		parts := splitTwo(*exclude)
+	if *cli.exclude != "" {
+		parts := splitTwo(*cli.exclude)
 		if parts == nil {
 			fmt.Fprintf(os.Stderr, "Error: --exclude requires format: base,exclude\n")
 			os.Exit(1)
@@ -109,13 +125,13 @@ Examples:
 	}
 
 	// Mode: host count
-	if *hostCount != "" {
-		count, err := cidrmerge.HostCount(*hostCount)
+	if *cli.hostCount != "" {
+		count, err := cidrmerge.HostCount(*cli.hostCount)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Printf("CIDR HOST COUNT %s\n\n", *hostCount)
+		fmt.Printf("CIDR HOST COUNT %s\n\n", *cli.hostCount)
 		fmt.Printf("  Usable hosts: %s\n", count.String())
 		return
 	}
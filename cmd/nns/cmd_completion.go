@@ -0,0 +1,297 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// completionCommand is a top-level command with its aliases, help
+// description and flags.
+type completionCommand struct {
+	Name        string
+	Aliases     []string
+	Description string
+	Flags       []completionFlag
+}
+
+// Names returns the command name followed by its aliases.
+func (c completionCommand) Names() []string {
+	return append([]string{c.Name}, c.Aliases...)
+}
+
+// completionFlag is a single flag of a command.
+type completionFlag struct {
+	Name       string
+	Usage      string
+	TakesValue bool
+}
+
+// Option returns the flag as typed on the command line: -x for single-letter
+// flags and --name otherwise.
+func (f completionFlag) Option() string {
+	if len(f.Name) == 1 {
+		return "-" + f.Name
+	}
+	return "--" + f.Name
+}
+
+// globalFlags are accepted before the command name.
+var globalFlags = []completionFlag{
+	{Name: "version", Usage: "Show version information"},
+	{Name: "help", Usage: "Show this help message"},
+	{Name: "json", Usage: "Output JSON"},
+	{Name: "no-color", Usage: "Disable colored output"},
+	{Name: "config", Usage: "Per-command flag defaults file", TakesValue: true},
+}
+
+// newCompletionFlags defines the flags of the completion command.
+func newCompletionFlags() *flag.FlagSet {
+	fs := flag.NewFlagSet("completion", flag.ExitOnError)
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: nns completion <bash|zsh|fish>
+
+Generate a shell completion script covering every command and its flags.
+
+Options:
+  --help    Show this help message
+
+Examples:
+  source <(nns completion bash)                      # current bash session
+  nns completion bash > /etc/bash_completion.d/nns   # all bash sessions
+  nns completion zsh > "${fpath[1]}/_nns"            # zsh
+  nns completion fish > ~/.config/fish/completions/nns.fish
+`)
+	}
+
+	return fs
+}
+
+func runCompletion(args []string) {
+	fs := newCompletionFlags()
+
+	if err := parseFlags(fs, args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if fs.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Error: shell required\n\n")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	commands := completionCommands()
+	switch shell := fs.Arg(0); shell {
+	case "bash":
+		fmt.Print(bashCompletion(commands))
+	case "zsh":
+		fmt.Print(zshCompletion(commands))
+	case "fish":
+		fmt.Print(fishCompletion(commands))
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unsupported shell %q (use bash, zsh, fish)\n", shell)
+		os.Exit(1)
+	}
+}
+
+// completionCommands lists the commands from the command table along with
+// the flags each one defines.
+func completionCommands() []completionCommand {
+	var list []completionCommand
+	for _, c := range commands() {
+		list = append(list, completionCommand{
+			Name:        c.Name,
+			Aliases:     c.Aliases,
+			Description: c.Description,
+			Flags:       flagsFrom(c.Flags()),
+		})
+	}
+	return list
+}
+
+// flagsFrom returns the flags defined on fs sorted by name.
+func flagsFrom(fs *flag.FlagSet) []completionFlag {
+	var flags []completionFlag
+	fs.VisitAll(func(f *flag.Flag) {
+		b, isBool := f.Value.(interface{ IsBoolFlag() bool })
+		flags = append(flags, completionFlag{
+			Name:       f.Name,
+			Usage:      f.Usage,
+			TakesValue: !isBool || !b.IsBoolFlag(),
+		})
+	})
+	return flags
+}
+
+func bashCompletion(commands []completionCommand) string {
+	var b strings.Builder
+	b.WriteString(`# bash completion for nns
+#
+# Load into the current shell:
+#   source <(nns completion bash)
+# Load for every session:
+#   nns completion bash > /etc/bash_completion.d/nns
+# or add the source line above to ~/.bashrc.
+
+_nns() {
+    local cur cmd opts i
+    cur="${COMP_WORDS[COMP_CWORD]}"
+
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case "${COMP_WORDS[i]}" in
+            --config|-config) ((i++)) ;;
+            -*) ;;
+            *) cmd="${COMP_WORDS[i]}"; break ;;
+        esac
+    done
+
+    if [[ -z "$cmd" ]]; then
+        if [[ "$cur" == -* ]]; then
+`)
+	fmt.Fprintf(&b, "            COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", optionList(globalFlags))
+	b.WriteString("        else\n")
+	var names []string
+	for _, c := range commands {
+		names = append(names, c.Names()...)
+	}
+	fmt.Fprintf(&b, "            COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(names, " "))
+	b.WriteString(`        fi
+        return
+    fi
+
+    [[ "$cur" == -* ]] || return
+
+    case "$cmd" in
+`)
+	for _, c := range commands {
+		fmt.Fprintf(&b, "        %s) opts=%q ;;\n", strings.Join(c.Names(), "|"), optionList(c.Flags))
+	}
+	b.WriteString(`    esac
+    COMPREPLY=($(compgen -W "$opts" -- "$cur"))
+}
+
+complete -o default -F _nns nns
+`)
+	return b.String()
+}
+
+func zshCompletion(commands []completionCommand) string {
+	var b strings.Builder
+	b.WriteString(`#compdef nns
+#
+# zsh completion for nns
+#
+# Load into the current shell:
+#   source <(nns completion zsh)
+# Load for every session (compinit must be enabled):
+#   nns completion zsh > "${fpath[1]}/_nns"
+
+_nns() {
+    local -a commands
+    commands=(
+`)
+	for _, c := range commands {
+		for _, name := range c.Names() {
+			fmt.Fprintf(&b, "        %s\n", zshQuote(name+":"+strings.ReplaceAll(c.Description, ":", `\:`)))
+		}
+	}
+	b.WriteString(`    )
+
+    if (( CURRENT == 2 )); then
+        _describe 'command' commands
+        return
+    fi
+
+    case "$words[2]" in
+`)
+	for _, c := range commands {
+		fmt.Fprintf(&b, "        %s)\n            _arguments \\\n", strings.Join(c.Names(), "|"))
+		for _, f := range c.Flags {
+			spec := f.Option() + "[" + zshEscape(f.Usage) + "]"
+			if f.TakesValue {
+				spec += ":" + f.Name + ": "
+			}
+			fmt.Fprintf(&b, "                %s \\\n", zshQuote(spec))
+		}
+		b.WriteString("                '*:file:_files'\n            ;;\n")
+	}
+	b.WriteString(`    esac
+}
+
+if [[ "$funcstack[1]" == "_nns" ]]; then
+    _nns "$@"
+else
+    compdef _nns nns
+fi
+`)
+	return b.String()
+}
+
+func fishCompletion(commands []completionCommand) string {
+	var b strings.Builder
+	b.WriteString(`# fish completion for nns
+#
+# Load into the current shell:
+#   nns completion fish | source
+# Load for every session:
+#   nns completion fish > ~/.config/fish/completions/nns.fish
+
+`)
+	for _, f := range globalFlags {
+		fmt.Fprintf(&b, "complete -c nns -n __fish_use_subcommand %s\n", fishFlag(f))
+	}
+	for _, c := range commands {
+		for _, name := range c.Names() {
+			fmt.Fprintf(&b, "complete -c nns -n __fish_use_subcommand -f -a %s -d %s\n", name, fishQuote(c.Description))
+		}
+	}
+	for _, c := range commands {
+		b.WriteString("\n")
+		for _, f := range c.Flags {
+			fmt.Fprintf(&b, "complete -c nns -n '__fish_seen_subcommand_from %s' %s\n", strings.Join(c.Names(), " "), fishFlag(f))
+		}
+	}
+	return b.String()
+}
+
+// optionList returns the options of flags as a sorted, space-separated list.
+func optionList(flags []completionFlag) string {
+	opts := make([]string, len(flags))
+	for i, f := range flags {
+		opts[i] = f.Option()
+	}
+	sort.Strings(opts)
+	return strings.Join(opts, " ")
+}
+
+// zshEscape escapes characters that are special inside an _arguments
+// description.
+func zshEscape(s string) string {
+	return strings.NewReplacer("[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+}
+
+// zshQuote single-quotes s for zsh.
+func zshQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishFlag returns the complete arguments describing f.
+func fishFlag(f completionFlag) string {
+	opt := "-l " + f.Name
+	if len(f.Name) == 1 {
+		opt = "-s " + f.Name
+	}
+	if f.TakesValue {
+		opt += " -r"
+	}
+	return opt + " -d " + fishQuote(f.Usage)
+}
+
+// fishQuote single-quotes s for fish.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
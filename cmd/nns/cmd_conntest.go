@@ -10,13 +10,24 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/conntest"
 )
 
-func runConnTest(args []string) {
+// connTestFlags holds the flags of the conntest command.
+type connTestFlags struct {
+	timeout     *time.Duration
+	concurrency *int
+	common      *bool
+	tls         *bool
+	sort        *bool
+}
+
+// newConnTestFlags defines the flags of the conntest command.
+func newConnTestFlags() (*flag.FlagSet, *connTestFlags) {
 	fs := flag.NewFlagSet("conntest", flag.ExitOnError)
-	timeout := fs.Duration("t", 5*time.Second, "Connection timeout")
-	concurrency := fs.Int("c", 10, "Concurrent connections")
-	common := fs.Bool("common", false, "Test common targets (DNS, HTTPS)")
-	tls := fs.Bool("tls", false, "Use TLS for all targets")
-	sort := fs.Bool("sort", false, "Sort results by latency")
+	cli := &connTestFlags{}
+	cli.timeout = fs.Duration("t", 5*time.Second, "Connection timeout")
+	cli.concurrency = fs.Int("c", 10, "Concurrent connections")
+	cli.common = fs.Bool("common", false, "Test common targets (DNS, HTTPS)")
+	cli.tls = fs.Bool("tls", false, "Use TLS for all targets")
+	cli.sort = fs.Bool("sort", false, "Sort results by latency")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: nns conntest [options] <host:port> [host:port...]\n\n")
@@ -29,13 +40,19 @@ func runConnTest(args []string) {
 		fmt.Fprintf(os.Stderr, "  nns conntest --tls example.com:443\n")
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runConnTest(args []string) {
+	fs, cli := newConnTestFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		os.Exit(1)
 	}
 
 	var targets []conntest.Target
 
-	if *common {
+	if *cli.common {
 		targets = conntest.CommonTargets()
 	}
 
@@ -45,7 +62,7 @@ func runConnTest(args []string) {
 			fmt.Fprintf(os.Stderr, "Invalid target %q: %v\n", arg, err)
 			os.Exit(1)
 		}
-		if *tls {
+		if *cli.tls {
 			target.Protocol = conntest.TLS
 		}
 		targets = append(targets, target)
@@ -56,18 +73,18 @@ func runConnTest(args []string) {
 		os.Exit(1)
 	}
 
-	fmt.Printf("Testing %d targets (concurrency=%d, timeout=%v)\n\n", len(targets), *concurrency, *timeout)
+	fmt.Printf("Testing %d targets (concurrency=%d, timeout=%v)\n\n", len(targets), *cli.concurrency, *cli.timeout)
 
 	cfg := conntest.Config{
-		Timeout:     *timeout,
-		Concurrency: *concurrency,
+		Timeout:     *cli.timeout,
+		Concurrency: *cli.concurrency,
 	}
 
 	tester := conntest.New(cfg)
 	ctx := context.Background()
 	results := tester.Test(ctx, targets)
 
-	if *sort {
+	if *cli.sort {
 		conntest.SortByLatency(results)
 	}
 
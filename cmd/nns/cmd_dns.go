@@ -4,28 +4,44 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net"
+	"net/netip"
 	"os"
+	"slices"
 	"time"
 
 	"github.com/JedizLaPulga/NNS/internal/dns"
 )
 
-func runDNS(args []string) {
+// dnsFlags holds the flags of the dns command.
+type dnsFlags struct {
+	typeFlag        *string
+	resolverFlag    *string
+	allFlag         *bool
+	shortFlag       *bool
+	propagationFlag *bool
+	ecsFlag         *string
+}
+
+// newDNSFlags defines the flags of the dns command.
+func newDNSFlags() (*flag.FlagSet, *dnsFlags) {
 	fs := flag.NewFlagSet("dns", flag.ExitOnError)
+	cli := &dnsFlags{}
 
-	typeFlag := fs.String("type", "A", "Record type (A, AAAA, MX, TXT, NS, CNAME, PTR, SOA)")
-	resolverFlag := fs.String("resolver", "", "Custom DNS server (e.g., 8.8.8.8)")
-	allFlag := fs.Bool("all", false, "Query all common record types")
-	shortFlag := fs.Bool("short", false, "Show only record values")
-	propagationFlag := fs.Bool("propagation", false, "Check DNS propagation across global resolvers")
+	cli.typeFlag = fs.String("type", "A", "Record type (A, AAAA, MX, TXT, NS, CNAME, PTR, SOA)")
+	cli.resolverFlag = fs.String("resolver", "", "Custom DNS server (e.g., 8.8.8.8)")
+	cli.allFlag = fs.Bool("all", false, "Query all common record types")
+	cli.shortFlag = fs.Bool("short", false, "Show only record values")
+	cli.propagationFlag = fs.Bool("propagation", false, "Check DNS propagation across global resolvers")
+	cli.ecsFlag = fs.String("ecs", "", "EDNS Client Subnet to send (e.g., 203.0.113.0/24)")
 
 	// Short flags
-	fs.StringVar(typeFlag, "t", "A", "Record type")
-	fs.StringVar(resolverFlag, "r", "", "Custom DNS server")
-	fs.BoolVar(propagationFlag, "p", false, "Check propagation")
+	fs.StringVar(cli.typeFlag, "t", "A", "Record type")
+	fs.StringVar(cli.resolverFlag, "r", "", "Custom DNS server")
+	fs.BoolVar(cli.propagationFlag, "p", false, "Check propagation")
 
 	fs.Usage = func() {
-		fmt.Println(`Usage: nns dns [HOST] [OPTIONS]
+		fmt.Println(`Usage: nns dns [OPTIONS] <HOST|IP|CIDR>
 
 Perform DNS lookups for various record types.
 
@@ -34,6 +50,9 @@ OPTIONS:
   -r, --resolver    Custom DNS server (e.g., 8.8.8.8, 1.1.1.1)
       --all         Query all common record types (A, AAAA, MX, TXT, NS, CNAME, SOA)
   -p, --propagation Check DNS propagation across global resolvers
+      --ecs SUBNET  Send an EDNS Client Subnet option, to see how GeoDNS/CDN
+                    resolvers answer for clients in SUBNET (a bare IP is
+                    truncated to /24 or /56)
       --short       Show only record values (for scripting)
       --help        Show this help message
 
@@ -43,12 +62,20 @@ EXAMPLES:
   nns dns google.com --type TXT       # TXT records (SPF, DKIM)
   nns dns google.com --type SOA       # Authoritative server info
   nns dns 8.8.8.8 --type PTR          # Reverse lookup
+  nns dns --type PTR 10.0.0.0/24      # Reverse lookup of a whole subnet
   nns dns google.com --all            # All record types
   nns dns google.com --propagation    # Check global DNS propagation
-  nns dns google.com --resolver 1.1.1.1`)
+  nns dns google.com --resolver 1.1.1.1
+  nns dns --resolver 8.8.8.8 --ecs 203.0.113.0/24 www.example.com`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runDNS(args []string) {
+	fs, cli := newDNSFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		os.Exit(1)
 	}
 
@@ -62,20 +89,39 @@ EXAMPLES:
 
 	// Create resolver
 	resolver := dns.NewResolver()
-	if *resolverFlag != "" {
-		resolver.SetServer(*resolverFlag)
+	if *cli.resolverFlag != "" {
+		resolver.SetServer(*cli.resolverFlag)
+	}
+	if err := resolver.SetECS(*cli.ecsFlag); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *cli.ecsFlag != "" && *cli.propagationFlag {
+		fmt.Fprintf(os.Stderr, "Error: --ecs is not supported with --propagation\n")
+		os.Exit(1)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Auto-detect PTR for IP addresses
-	recordType := *typeFlag
-	if dns.IsIPAddress(target) && recordType == "A" {
+	// Auto-detect PTR for IP addresses and CIDR ranges
+	recordType := *cli.typeFlag
+	_, _, cidrErr := net.ParseCIDR(target)
+	isCIDR := cidrErr == nil
+	if (dns.IsIPAddress(target) || isCIDR) && recordType == "A" {
 		recordType = "PTR"
 	}
 
-	if *propagationFlag {
+	if isCIDR && !*cli.propagationFlag && !*cli.allFlag {
+		if rt, _ := dns.ParseRecordType(recordType); rt != dns.TypePTR {
+			fmt.Fprintf(os.Stderr, "Error: CIDR ranges only support PTR lookups\n")
+			os.Exit(1)
+		}
+		runReverseCIDR(resolver, target, *cli.shortFlag)
+		return
+	}
+
+	if *cli.propagationFlag {
 		// Propagation check
 		rt, err := dns.ParseRecordType(recordType)
 		if err != nil {
@@ -116,17 +162,20 @@ EXAMPLES:
 		} else {
 			fmt.Println("✗ DNS is NOT fully propagated (results differ)")
 		}
-	} else if *allFlag {
+	} else if *cli.allFlag {
 		// Query all types
 		fmt.Printf("DNS lookup for %s (all types)\n", target)
-		if *resolverFlag != "" {
-			fmt.Printf("Using resolver: %s\n", *resolverFlag)
+		if *cli.resolverFlag != "" {
+			fmt.Printf("Using resolver: %s\n", *cli.resolverFlag)
+		}
+		if ecs := resolver.ECS(); ecs != "" {
+			fmt.Printf("Client subnet: %s\n", ecs)
 		}
 		fmt.Println()
 
 		results := resolver.LookupAll(ctx, target)
 		for _, result := range results {
-			printDNSResult(&result, *shortFlag)
+			printDNSResult(&result, *cli.shortFlag)
 		}
 	} else {
 		// Single type query
@@ -136,16 +185,54 @@ EXAMPLES:
 			os.Exit(1)
 		}
 
-		if !*shortFlag {
+		if !*cli.shortFlag {
 			fmt.Printf("DNS lookup for %s (type: %s)\n", target, rt)
-			if *resolverFlag != "" {
-				fmt.Printf("Using resolver: %s\n", *resolverFlag)
+			if *cli.resolverFlag != "" {
+				fmt.Printf("Using resolver: %s\n", *cli.resolverFlag)
+			}
+			if ecs := resolver.ECS(); ecs != "" {
+				fmt.Printf("Client subnet: %s\n", ecs)
 			}
 			fmt.Println()
 		}
 
 		result := resolver.Lookup(ctx, target, rt)
-		printDNSResult(result, *shortFlag)
+		printDNSResult(result, *cli.shortFlag)
+	}
+}
+
+// runReverseCIDR prints the PTR name of every address in cidr that has one.
+func runReverseCIDR(resolver *dns.Resolver, cidr string, short bool) {
+	if !short {
+		fmt.Fprintf(os.Stderr, "Reverse lookup for %s...\n", cidr)
+	}
+
+	start := time.Now()
+	names, err := resolver.ReverseCIDR(context.Background(), cidr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	ips := make([]netip.Addr, 0, len(names))
+	for ip := range names {
+		ips = append(ips, netip.MustParseAddr(ip))
+	}
+	slices.SortFunc(ips, netip.Addr.Compare)
+
+	if !short {
+		fmt.Printf("\n%-40s %s\n", "IP", "HOSTNAME")
+		fmt.Println("----------------------------------------------------------------")
+	}
+	for _, ip := range ips {
+		if short {
+			fmt.Printf("%s %s\n", ip, names[ip.String()])
+		} else {
+			fmt.Printf("%-40s %s\n", ip, names[ip.String()])
+		}
+	}
+	if !short {
+		fmt.Printf("\n%d addresses with PTR records (%v)\n", len(ips), time.Since(start).Round(time.Millisecond))
 	}
 }
 
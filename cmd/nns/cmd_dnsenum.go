@@ -10,17 +10,27 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/dnsenum"
 )
 
-func runDNSEnum(args []string) {
+// dnsEnumFlags holds the flags of the dnsenum command.
+type dnsEnumFlags struct {
+	concurrency *int
+	timeout     *int
+	noZoneXfer  *bool
+	resolver    *string
+}
+
+// newDNSEnumFlags defines the flags of the dnsenum command.
+func newDNSEnumFlags() (*flag.FlagSet, *dnsEnumFlags) {
 	fs := flag.NewFlagSet("dnsenum", flag.ExitOnError)
-	concurrency := fs.Int("concurrency", 10, "Number of concurrent lookups")
-	timeout := fs.Int("timeout", 3, "DNS query timeout in seconds")
-	noZoneXfer := fs.Bool("no-axfr", false, "Skip zone transfer attempts")
-	resolver := fs.String("resolver", "", "Custom DNS resolver (e.g., 8.8.8.8)")
+	cli := &dnsEnumFlags{}
+	cli.concurrency = fs.Int("concurrency", 10, "Number of concurrent lookups")
+	cli.timeout = fs.Int("timeout", 3, "DNS query timeout in seconds")
+	cli.noZoneXfer = fs.Bool("no-axfr", false, "Skip zone transfer attempts")
+	cli.resolver = fs.String("resolver", "", "Custom DNS resolver (e.g., 8.8.8.8)")
 
 	// Short flags
-	fs.IntVar(concurrency, "c", 10, "Concurrency")
-	fs.IntVar(timeout, "t", 3, "Timeout (s)")
-	fs.StringVar(resolver, "r", "", "Resolver")
+	fs.IntVar(cli.concurrency, "c", 10, "Concurrency")
+	fs.IntVar(cli.timeout, "t", 3, "Timeout (s)")
+	fs.StringVar(cli.resolver, "r", "", "Resolver")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns dnsenum [options] <domain>
@@ -44,7 +54,13 @@ Examples:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runDNSEnum(args []string) {
+	fs, cli := newDNSEnumFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -57,10 +73,10 @@ Examples:
 
 	domain := fs.Arg(0)
 	opts := dnsenum.DefaultOptions(domain)
-	opts.Concurrency = *concurrency
-	opts.Timeout = time.Duration(*timeout) * time.Second
-	opts.TryZoneXfer = !*noZoneXfer
-	opts.Resolver = *resolver
+	opts.Concurrency = *cli.concurrency
+	opts.Timeout = time.Duration(*cli.timeout) * time.Second
+	opts.TryZoneXfer = !*cli.noZoneXfer
+	opts.Resolver = *cli.resolver
 
 	fmt.Printf("DNS ENUMERATION\n\n")
 	fmt.Printf("  Domain:       %s\n", domain)
@@ -13,15 +13,28 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/dnsperf"
 )
 
-func runDNSPerf(args []string) {
+// dnsPerfFlags holds the flags of the dnsperf command.
+type dnsPerfFlags struct {
+	queries     *int
+	concurrency *int
+	timeout     *time.Duration
+	queryType   *string
+	resolvers   *string
+	all         *bool
+	compact     *bool
+}
+
+// newDNSPerfFlags defines the flags of the dnsperf command.
+func newDNSPerfFlags() (*flag.FlagSet, *dnsPerfFlags) {
 	fs := flag.NewFlagSet("dnsperf", flag.ExitOnError)
-	queries := fs.Int("queries", 10, "Number of queries per resolver")
-	concurrency := fs.Int("concurrency", 5, "Concurrent queries")
-	timeout := fs.Duration("timeout", 5*time.Second, "Query timeout")
-	queryType := fs.String("type", "A", "Query type (A, AAAA, MX, TXT, NS)")
-	resolvers := fs.String("resolvers", "", "Custom resolvers (comma-separated, e.g., 8.8.8.8:53,1.1.1.1:53)")
-	all := fs.Bool("all", false, "Test all common resolvers")
-	compact := fs.Bool("compact", false, "Compact output format")
+	cli := &dnsPerfFlags{}
+	cli.queries = fs.Int("queries", 10, "Number of queries per resolver")
+	cli.concurrency = fs.Int("concurrency", 5, "Concurrent queries")
+	cli.timeout = fs.Duration("timeout", 5*time.Second, "Query timeout")
+	cli.queryType = fs.String("type", "A", "Query type (A, AAAA, MX, TXT, NS)")
+	cli.resolvers = fs.String("resolvers", "", "Custom resolvers (comma-separated, e.g., 8.8.8.8:53,1.1.1.1:53)")
+	cli.all = fs.Bool("all", false, "Test all common resolvers")
+	cli.compact = fs.Bool("compact", false, "Compact output format")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns dnsperf [OPTIONS] <domain>
@@ -41,7 +54,13 @@ EXAMPLES:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runDNSPerf(args []string) {
+	fs, cli := newDNSPerfFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		return
 	}
 
@@ -53,15 +72,15 @@ EXAMPLES:
 	domain := fs.Arg(0)
 
 	opts := dnsperf.Options{
-		QueryCount:  *queries,
-		Concurrency: *concurrency,
-		Timeout:     *timeout,
-		QueryType:   *queryType,
+		QueryCount:  *cli.queries,
+		Concurrency: *cli.concurrency,
+		Timeout:     *cli.timeout,
+		QueryType:   *cli.queryType,
 	}
 
 	// Determine resolvers
-	if *resolvers != "" {
-		parts := strings.Split(*resolvers, ",")
+	if *cli.resolvers != "" {
+		parts := strings.Split(*cli.resolvers, ",")
 		for i, addr := range parts {
 			addr = strings.TrimSpace(addr)
 			if !strings.Contains(addr, ":") {
@@ -72,7 +91,7 @@ EXAMPLES:
 				Address: addr,
 			})
 		}
-	} else if *all {
+	} else if *cli.all {
 		opts.Resolvers = dnsperf.CommonResolvers
 	} else {
 		opts.Resolvers = dnsperf.CommonResolvers[:4] // Google + Cloudflare
@@ -92,8 +111,8 @@ EXAMPLES:
 		cancel()
 	}()
 
-	fmt.Printf("Benchmarking DNS resolvers for %s (%s)...\n", domain, *queryType)
-	fmt.Printf("Testing %d resolvers with %d queries each\n\n", len(opts.Resolvers), *queries)
+	fmt.Printf("Benchmarking DNS resolvers for %s (%s)...\n", domain, *cli.queryType)
+	fmt.Printf("Testing %d resolvers with %d queries each\n\n", len(opts.Resolvers), *cli.queries)
 
 	result, err := benchmark.Run(ctx, domain)
 	if err != nil {
@@ -101,7 +120,7 @@ EXAMPLES:
 		os.Exit(1)
 	}
 
-	if *compact {
+	if *cli.compact {
 		fmt.Print(result.FormatCompact())
 	} else {
 		fmt.Print(result.Format())
@@ -12,11 +12,26 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/dnssec"
 )
 
-func runDNSSEC(args []string) {
+// dnssecFlags holds the flags of the dnssec command.
+type dnssecFlags struct {
+	resolver      *string
+	timeout       *time.Duration
+	expiryWarning *time.Duration
+	brief         *bool
+	jsonOut       *bool
+	walk          *bool
+}
+
+// newDNSSECFlags defines the flags of the dnssec command.
+func newDNSSECFlags() (*flag.FlagSet, *dnssecFlags) {
 	fs := flag.NewFlagSet("dnssec", flag.ExitOnError)
-	resolver := fs.String("resolver", "8.8.8.8:53", "DNS resolver to use")
-	timeout := fs.Duration("timeout", 10*time.Second, "Query timeout")
-	brief := fs.Bool("brief", false, "Brief output")
+	cli := &dnssecFlags{}
+	cli.resolver = fs.String("resolver", "8.8.8.8:53", "DNS resolver to use")
+	cli.timeout = fs.Duration("timeout", 10*time.Second, "Query timeout")
+	cli.expiryWarning = fs.Duration("expiry-warning", 7*24*time.Hour, "Warn when a signature expires within this window (0 disables)")
+	cli.brief = fs.Bool("brief", false, "Brief output")
+	cli.jsonOut = fs.Bool("json", false, "Output in JSON format")
+	cli.walk = fs.Bool("walk", false, "List zone names by walking the NSEC chain")
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: nns dnssec [options] <domain>\n\n")
 		fmt.Fprintf(os.Stderr, "Validate DNSSEC chain of trust for a domain.\n\n")
@@ -26,8 +41,17 @@ func runDNSSEC(args []string) {
 		fmt.Fprintf(os.Stderr, "  nns dnssec example.com\n")
 		fmt.Fprintf(os.Stderr, "  nns dnssec --resolver 1.1.1.1:53 cloudflare.com\n")
 		fmt.Fprintf(os.Stderr, "  nns dnssec --brief google.com\n")
+		fmt.Fprintf(os.Stderr, "  nns dnssec --json cloudflare.com\n")
+		fmt.Fprintf(os.Stderr, "  nns dnssec --expiry-warning 72h example.com\n")
+		fmt.Fprintf(os.Stderr, "  nns dnssec --walk example.org\n")
 	}
-	fs.Parse(args)
+
+	return fs, cli
+}
+
+func runDNSSEC(args []string) {
+	fs, cli := newDNSSECFlags()
+	parseFlags(fs, args)
 
 	if fs.NArg() < 1 {
 		fs.Usage()
@@ -37,8 +61,10 @@ func runDNSSEC(args []string) {
 	domain := fs.Arg(0)
 
 	opts := dnssec.DefaultOptions()
-	opts.Resolver = *resolver
-	opts.Timeout = *timeout
+	opts.Resolver = *cli.resolver
+	opts.Timeout = *cli.timeout
+	opts.ExpiryWarning = *cli.expiryWarning
+	opts.CheckExpiry = *cli.expiryWarning > 0
 
 	validator := dnssec.NewValidator(opts)
 
@@ -52,13 +78,32 @@ func runDNSSEC(args []string) {
 		cancel()
 	}()
 
+	if *cli.walk {
+		names, err := validator.EnumerateNames(ctx, domain)
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	result, err := validator.Validate(ctx, domain)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	if *brief {
+	if *cli.jsonOut {
+		out, err := result.ToJSON()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(out)
+	} else if *cli.brief {
 		printDNSSECBrief(result)
 	} else {
 		fmt.Print(result.Format())
@@ -11,11 +11,20 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/dnstrace"
 )
 
-func runDNSTrace(args []string) {
+// dnsTraceFlags holds the flags of the dnstrace command.
+type dnsTraceFlags struct {
+	queryType   *string
+	startServer *string
+	maxDepth    *int
+}
+
+// newDNSTraceFlags defines the flags of the dnstrace command.
+func newDNSTraceFlags() (*flag.FlagSet, *dnsTraceFlags) {
 	fs := flag.NewFlagSet("dnstrace", flag.ExitOnError)
-	queryType := fs.String("type", "A", "Query type (A, AAAA, MX, NS, TXT, CNAME)")
-	startServer := fs.String("server", "", "Starting DNS server (default: root servers)")
-	maxDepth := fs.Int("depth", 10, "Maximum trace depth")
+	cli := &dnsTraceFlags{}
+	cli.queryType = fs.String("type", "A", "Query type (A, AAAA, MX, NS, TXT, CNAME)")
+	cli.startServer = fs.String("server", "", "Starting DNS server (default: root servers)")
+	cli.maxDepth = fs.Int("depth", 10, "Maximum trace depth")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: nns dnstrace [options] <domain>\n\n")
@@ -28,7 +37,13 @@ func runDNSTrace(args []string) {
 		fmt.Fprintf(os.Stderr, "  nns dnstrace --server 8.8.8.8 example.com\n")
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runDNSTrace(args []string) {
+	fs, cli := newDNSTraceFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		os.Exit(1)
 	}
 
@@ -40,9 +55,9 @@ func runDNSTrace(args []string) {
 	domain := fs.Arg(0)
 
 	cfg := dnstrace.Config{
-		QueryType:   strings.ToUpper(*queryType),
-		StartServer: *startServer,
-		MaxDepth:    *maxDepth,
+		QueryType:   strings.ToUpper(*cli.queryType),
+		StartServer: *cli.startServer,
+		MaxDepth:    *cli.maxDepth,
 	}
 
 	tracer := dnstrace.New(cfg)
@@ -10,16 +10,26 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/encdec"
 )
 
-func runEncDec(args []string) {
+// encDecFlags holds the flags of the encdec command.
+type encDecFlags struct {
+	format *string
+	decode *bool
+	detect *bool
+	all    *bool
+}
+
+// newEncDecFlags defines the flags of the encdec command.
+func newEncDecFlags() (*flag.FlagSet, *encDecFlags) {
 	fs := flag.NewFlagSet("encdec", flag.ExitOnError)
-	format := fs.String("format", "", "Encoding format: base64, base64url, hex, url, binary")
-	decode := fs.Bool("decode", false, "Decode instead of encode")
-	detect := fs.Bool("detect", false, "Auto-detect encoding format")
-	all := fs.Bool("all", false, "Encode in all formats")
+	cli := &encDecFlags{}
+	cli.format = fs.String("format", "", "Encoding format: base64, base64url, hex, url, binary")
+	cli.decode = fs.Bool("decode", false, "Decode instead of encode")
+	cli.detect = fs.Bool("detect", false, "Auto-detect encoding format")
+	cli.all = fs.Bool("all", false, "Encode in all formats")
 
 	// Short flags
-	fs.StringVar(format, "f", "", "Encoding format")
-	fs.BoolVar(decode, "d", false, "Decode mode")
+	fs.StringVar(cli.format, "f", "", "Encoding format")
+	fs.BoolVar(cli.decode, "d", false, "Decode mode")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns encdec [options] <input>
@@ -44,7 +54,13 @@ Examples:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runEncDec(args []string) {
+	fs, cli := newEncDecFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -69,7 +85,7 @@ Examples:
 	}
 
 	// Mode: detect
-	if *detect {
+	if *cli.detect {
 		fmt.Println("FORMAT DETECTION")
 		fmt.Println()
 		formats := encdec.DetectFormat(input)
@@ -78,7 +94,7 @@ Examples:
 	}
 
 	// Mode: all formats
-	if *all {
+	if *cli.all {
 		fmt.Println("ENCODE ALL FORMATS")
 		fmt.Println()
 		results := encdec.EncodeAll(input)
@@ -93,23 +109,23 @@ Examples:
 	}
 
 	// Mode: single encode/decode
-	if *format == "" {
+	if *cli.format == "" {
 		fmt.Fprintf(os.Stderr, "Error: --format is required (use --detect or --all for alternatives)\n\n")
 		fs.Usage()
 		os.Exit(1)
 	}
 
-	f := encdec.Format(*format)
+	f := encdec.Format(*cli.format)
 
-	if *decode {
-		fmt.Printf("DECODE %s\n\n", strings.ToUpper(*format))
+	if *cli.decode {
+		fmt.Printf("DECODE %s\n\n", strings.ToUpper(*cli.format))
 		r := encdec.Decode(input, f)
 		fmt.Print(encdec.FormatResult(r))
 		if !r.Valid {
 			os.Exit(1)
 		}
 	} else {
-		fmt.Printf("ENCODE %s\n\n", strings.ToUpper(*format))
+		fmt.Printf("ENCODE %s\n\n", strings.ToUpper(*cli.format))
 		r := encdec.Encode(input, f)
 		fmt.Print(encdec.FormatResult(r))
 		if !r.Valid {
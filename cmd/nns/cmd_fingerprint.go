@@ -14,13 +14,40 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/fingerprint"
 )
 
-func runFingerprint(args []string) {
+// fingerprintFlags holds the flags of the fingerprint command.
+type fingerprintFlags struct {
+	timeout      *time.Duration
+	ports        *string
+	concurrent   *int
+	osDetect     *bool
+	noOS         *bool
+	serviceScan  *bool
+	noServices   *bool
+	osOnly       *bool
+	servicesOnly *bool
+	brief        *bool
+	jsonOut      *bool
+	udp          *bool
+	signatures   *string
+}
+
+// newFingerprintFlags defines the flags of the fingerprint command.
+func newFingerprintFlags() (*flag.FlagSet, *fingerprintFlags) {
 	fs := flag.NewFlagSet("fingerprint", flag.ExitOnError)
-	timeout := fs.Duration("timeout", 5*time.Second, "Connection timeout")
-	ports := fs.String("ports", "", "Ports to scan (comma-separated, default: common ports)")
-	osOnly := fs.Bool("os-only", false, "Only perform OS detection")
-	servicesOnly := fs.Bool("services-only", false, "Only perform service detection")
-	brief := fs.Bool("brief", false, "Brief output")
+	cli := &fingerprintFlags{}
+	cli.timeout = fs.Duration("timeout", 5*time.Second, "Connection timeout")
+	cli.ports = fs.String("ports", "", "Ports to scan (comma-separated, default: common ports)")
+	cli.concurrent = fs.Int("concurrent", 20, "Number of ports probed in parallel")
+	cli.osDetect = fs.Bool("os", true, "Perform OS detection")
+	cli.noOS = fs.Bool("no-os", false, "Skip OS detection")
+	cli.serviceScan = fs.Bool("services", true, "Perform service detection")
+	cli.noServices = fs.Bool("no-services", false, "Skip service detection")
+	cli.osOnly = fs.Bool("os-only", false, "Only perform OS detection")
+	cli.servicesOnly = fs.Bool("services-only", false, "Only perform service detection")
+	cli.brief = fs.Bool("brief", false, "Brief output")
+	cli.jsonOut = fs.Bool("json", false, "Output in JSON format")
+	cli.udp = fs.Bool("udp", false, "Also probe UDP services (DNS, NTP, NetBIOS, SNMP)")
+	cli.signatures = fs.String("signatures", "", "File of extra service signatures (service|product|version group|pattern)")
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: nns fingerprint [options] <host>\n\n")
 		fmt.Fprintf(os.Stderr, "Fingerprint remote host for OS and service detection.\n\n")
@@ -30,8 +57,18 @@ func runFingerprint(args []string) {
 		fmt.Fprintf(os.Stderr, "  nns fingerprint example.com\n")
 		fmt.Fprintf(os.Stderr, "  nns fingerprint --ports 22,80,443 192.168.1.1\n")
 		fmt.Fprintf(os.Stderr, "  nns fingerprint --os-only 10.0.0.1\n")
+		fmt.Fprintf(os.Stderr, "  nns fingerprint --no-os --concurrent 50 --ports 1-1024 10.0.0.1\n")
+		fmt.Fprintf(os.Stderr, "  nns fingerprint --json 192.168.1.1\n")
+		fmt.Fprintf(os.Stderr, "  nns fingerprint --udp 192.168.1.1\n")
+		fmt.Fprintf(os.Stderr, "  nns fingerprint --signatures my-sigs.txt 10.0.0.1\n")
 	}
-	fs.Parse(args)
+
+	return fs, cli
+}
+
+func runFingerprint(args []string) {
+	fs, cli := newFingerprintFlags()
+	parseFlags(fs, args)
 
 	if fs.NArg() < 1 {
 		fs.Usage()
@@ -41,17 +78,41 @@ func runFingerprint(args []string) {
 	host := fs.Arg(0)
 
 	opts := fingerprint.DefaultOptions()
-	opts.Timeout = *timeout
+	opts.Timeout = *cli.timeout
 
-	if *ports != "" {
-		opts.Ports = parseFingerPorts(*ports)
+	if *cli.concurrent > 0 {
+		opts.Concurrency = *cli.concurrent
+	}
+
+	if *cli.ports != "" {
+		opts.Ports = parseFingerPorts(*cli.ports)
+		if len(opts.Ports) == 0 {
+			fmt.Fprintf(os.Stderr, "Error: no valid ports in %q\n", *cli.ports)
+			os.Exit(1)
+		}
 	}
 
-	if *osOnly {
-		opts.ServiceScan = false
+	opts.UDPScan = *cli.udp
+
+	if *cli.signatures != "" {
+		f, err := os.Open(*cli.signatures)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts.Signatures, err = fingerprint.ParseServiceSignatures(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", *cli.signatures, err)
+			os.Exit(1)
+		}
 	}
-	if *servicesOnly {
-		opts.OSDetect = false
+
+	opts.OSDetect = *cli.osDetect && !*cli.noOS && !*cli.servicesOnly
+	opts.ServiceScan = *cli.serviceScan && !*cli.noServices && !*cli.osOnly
+	if !opts.OSDetect && !opts.ServiceScan {
+		fmt.Fprintf(os.Stderr, "Error: OS and service detection are both disabled\n")
+		os.Exit(1)
 	}
 
 	scanner := fingerprint.NewScanner(opts)
@@ -63,11 +124,13 @@ func runFingerprint(args []string) {
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-sigChan
-		fmt.Println("\n⚠ Interrupted")
+		fmt.Fprintln(os.Stderr, "\n⚠ Interrupted")
 		cancel()
 	}()
 
-	fmt.Printf("Fingerprinting %s (%d ports)...\n", host, len(opts.Ports))
+	if !*cli.jsonOut {
+		fmt.Printf("Fingerprinting %s (%d ports)...\n", host, len(opts.Ports))
+	}
 
 	result, err := scanner.Scan(ctx, host)
 	if err != nil {
@@ -75,7 +138,14 @@ func runFingerprint(args []string) {
 		os.Exit(1)
 	}
 
-	if *brief {
+	if *cli.jsonOut {
+		out, err := result.ToJSON()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(out)
+	} else if *cli.brief {
 		printFingerprintBrief(result)
 	} else {
 		fmt.Print(result.Format())
@@ -112,7 +182,11 @@ func parseFingerPorts(s string) []int {
 func printFingerprintBrief(result *fingerprint.FingerprintResult) {
 	fmt.Printf("\n%s\n", result.Host)
 	fmt.Printf("  OS:       %s %s [%s confidence]\n", result.OSFamily, result.OSVersion, result.OSConfidence)
-	fmt.Printf("  TTL:      %d (%s)\n", result.TTL, result.TTLGuess)
+	if result.TTL > 0 {
+		fmt.Printf("  TTL:      %d (%s)\n", result.TTL, result.TTLGuess)
+	} else {
+		fmt.Printf("  TTL:      not measured\n")
+	}
 	fmt.Printf("  Open:     %v\n", result.OpenPorts)
 
 	if len(result.Services) > 0 {
@@ -126,7 +200,10 @@ func printFingerprintBrief(result *fingerprint.FingerprintResult) {
 				}
 				info += ")"
 			}
-			fmt.Printf("    %d: %s\n", svc.Port, info)
+			if svc.State == fingerprint.StateOpenFiltered {
+				info += " " + svc.State
+			}
+			fmt.Printf("    %d/%s: %s\n", svc.Port, svc.Protocol, info)
 		}
 	}
 	fmt.Printf("  Time:     %v\n", result.Duration.Round(time.Millisecond))
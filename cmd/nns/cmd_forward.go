@@ -11,11 +11,20 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/portforward"
 )
 
-func runPortForward(args []string) {
+// portForwardFlags holds the flags of the forward command.
+type portForwardFlags struct {
+	localAddr *string
+	timeout   *time.Duration
+	bufSize   *int
+}
+
+// newPortForwardFlags defines the flags of the forward command.
+func newPortForwardFlags() (*flag.FlagSet, *portForwardFlags) {
 	fs := flag.NewFlagSet("forward", flag.ExitOnError)
-	localAddr := fs.String("l", "127.0.0.1:8080", "Local address to listen on")
-	timeout := fs.Duration("t", 10*time.Second, "Connection timeout")
-	bufSize := fs.Int("buf", 32, "Buffer size in KB")
+	cli := &portForwardFlags{}
+	cli.localAddr = fs.String("l", "127.0.0.1:8080", "Local address to listen on")
+	cli.timeout = fs.Duration("t", 10*time.Second, "Connection timeout")
+	cli.bufSize = fs.Int("buf", 32, "Buffer size in KB")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: nns forward [options] <remote_host:port>\n\n")
@@ -27,7 +36,13 @@ func runPortForward(args []string) {
 		fmt.Fprintf(os.Stderr, "  nns forward -l :3000 localhost:3001\n")
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runPortForward(args []string) {
+	fs, cli := newPortForwardFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		os.Exit(1)
 	}
 
@@ -39,10 +54,10 @@ func runPortForward(args []string) {
 	remoteAddr := fs.Arg(0)
 
 	cfg := portforward.Config{
-		LocalAddr:   *localAddr,
+		LocalAddr:   *cli.localAddr,
 		RemoteAddr:  remoteAddr,
-		DialTimeout: *timeout,
-		BufferSize:  *bufSize * 1024,
+		DialTimeout: *cli.timeout,
+		BufferSize:  *cli.bufSize * 1024,
 	}
 
 	fwd, err := portforward.New(cfg)
@@ -62,7 +77,7 @@ func runPortForward(args []string) {
 		fmt.Fprintf(os.Stderr, "[!] %s: %v\n", client, err)
 	})
 
-	fmt.Printf("Forwarding %s -> %s\n", *localAddr, remoteAddr)
+	fmt.Printf("Forwarding %s -> %s\n", *cli.localAddr, remoteAddr)
 	fmt.Println("Press Ctrl+C to stop\n")
 
 	ctx, cancel := context.WithCancel(context.Background())
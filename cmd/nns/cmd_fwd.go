@@ -13,19 +13,31 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/reverseproxy"
 )
 
-func runFwd(args []string) {
+// fwdFlags holds the flags of the fwd command.
+type fwdFlags struct {
+	listen     *string
+	timeout    *time.Duration
+	skipVerify *bool
+	addHeader  *string
+	rmHeader   *string
+	brief      *bool
+}
+
+// newFwdFlags defines the flags of the fwd command.
+func newFwdFlags() (*flag.FlagSet, *fwdFlags) {
 	fs := flag.NewFlagSet("fwd", flag.ExitOnError)
-	listen := fs.String("listen", ":8080", "Listen address (host:port)")
-	timeout := fs.Duration("timeout", 30*time.Second, "Backend request timeout")
-	skipVerify := fs.Bool("insecure", false, "Skip TLS verification for backend")
-	addHeader := fs.String("header", "", "Header to inject (Name:Value)")
-	rmHeader := fs.String("rm-header", "", "Header to strip from requests")
-	brief := fs.Bool("brief", false, "Brief statistics on shutdown")
+	cli := &fwdFlags{}
+	cli.listen = fs.String("listen", ":8080", "Listen address (host:port)")
+	cli.timeout = fs.Duration("timeout", 30*time.Second, "Backend request timeout")
+	cli.skipVerify = fs.Bool("insecure", false, "Skip TLS verification for backend")
+	cli.addHeader = fs.String("header", "", "Header to inject (Name:Value)")
+	cli.rmHeader = fs.String("rm-header", "", "Header to strip from requests")
+	cli.brief = fs.Bool("brief", false, "Brief statistics on shutdown")
 
 	// Short flags
-	fs.StringVar(listen, "l", ":8080", "Listen address")
-	fs.DurationVar(timeout, "t", 30*time.Second, "Timeout")
-	fs.BoolVar(skipVerify, "k", false, "Skip TLS verification")
+	fs.StringVar(cli.listen, "l", ":8080", "Listen address")
+	fs.DurationVar(cli.timeout, "t", 30*time.Second, "Timeout")
+	fs.BoolVar(cli.skipVerify, "k", false, "Skip TLS verification")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns fwd [options] <backend_url>
@@ -52,7 +64,13 @@ Examples:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runFwd(args []string) {
+	fs, cli := newFwdFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -66,8 +84,8 @@ Examples:
 	backendURL := fs.Arg(0)
 
 	var headers []reverseproxy.HeaderRule
-	if *addHeader != "" {
-		parts := strings.SplitN(*addHeader, ":", 2)
+	if *cli.addHeader != "" {
+		parts := strings.SplitN(*cli.addHeader, ":", 2)
 		if len(parts) == 2 {
 			headers = append(headers, reverseproxy.HeaderRule{
 				Name:  strings.TrimSpace(parts[0]),
@@ -78,23 +96,23 @@ Examples:
 			os.Exit(1)
 		}
 	}
-	if *rmHeader != "" {
+	if *cli.rmHeader != "" {
 		headers = append(headers, reverseproxy.HeaderRule{
-			Name:   *rmHeader,
+			Name:   *cli.rmHeader,
 			Remove: true,
 		})
 	}
 
 	opts := reverseproxy.Options{
-		ListenAddr:  *listen,
+		ListenAddr:  *cli.listen,
 		BackendURL:  backendURL,
-		Timeout:     *timeout,
-		SkipVerify:  *skipVerify,
+		Timeout:     *cli.timeout,
+		SkipVerify:  *cli.skipVerify,
 		Headers:     headers,
-		LogRequests: !*brief,
+		LogRequests: !*cli.brief,
 	}
 
-	if !*brief {
+	if !*cli.brief {
 		opts.OnRequest = func(log reverseproxy.RequestLog) {
 			status := fmt.Sprintf("%d", log.StatusCode)
 			if log.Error != nil {
@@ -124,8 +142,8 @@ Examples:
 		cancel()
 	}()
 
-	fmt.Printf("Reverse proxy listening on %s → %s\n", *listen, backendURL)
-	if !*brief {
+	fmt.Printf("Reverse proxy listening on %s → %s\n", *cli.listen, backendURL)
+	if !*cli.brief {
 		fmt.Printf("%-8s  %-6s %-40s  %-6s  %s\n", "TIME", "METHOD", "PATH", "STATUS", "LATENCY")
 		fmt.Println(strings.Repeat("─", 80))
 	}
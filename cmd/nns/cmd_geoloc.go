@@ -11,11 +11,20 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/geoloc"
 )
 
-func runGeoloc(args []string) {
+// geolocFlags holds the flags of the geoloc command.
+type geolocFlags struct {
+	timeout *time.Duration
+	batch   *bool
+	json    *bool
+}
+
+// newGeolocFlags defines the flags of the geoloc command.
+func newGeolocFlags() (*flag.FlagSet, *geolocFlags) {
 	fs := flag.NewFlagSet("geoloc", flag.ExitOnError)
-	timeout := fs.Duration("timeout", 10*time.Second, "Lookup timeout")
-	batch := fs.Bool("batch", false, "Batch mode for multiple IPs")
-	json := fs.Bool("json", false, "Output as JSON")
+	cli := &geolocFlags{}
+	cli.timeout = fs.Duration("timeout", 10*time.Second, "Lookup timeout")
+	cli.batch = fs.Bool("batch", false, "Batch mode for multiple IPs")
+	cli.json = fs.Bool("json", false, "Output as JSON")
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: nns geoloc [options] <ip...>\n\n")
 		fmt.Fprintf(os.Stderr, "Geolocate IP addresses with city, country, ASN, and coordinates.\n\n")
@@ -26,7 +35,13 @@ func runGeoloc(args []string) {
 		fmt.Fprintf(os.Stderr, "  nns geoloc 1.1.1.1 8.8.4.4 9.9.9.9\n")
 		fmt.Fprintf(os.Stderr, "  nns geoloc --json 8.8.8.8\n")
 	}
-	fs.Parse(args)
+
+	return fs, cli
+}
+
+func runGeoloc(args []string) {
+	fs, cli := newGeolocFlags()
+	parseFlags(fs, args)
 
 	if fs.NArg() < 1 {
 		fs.Usage()
@@ -34,15 +49,15 @@ func runGeoloc(args []string) {
 	}
 
 	cfg := geoloc.DefaultConfig()
-	cfg.Timeout = *timeout
+	cfg.Timeout = *cli.timeout
 	client := geoloc.NewClient(cfg)
 	ctx := context.Background()
 
 	ips := fs.Args()
 
-	if *batch || len(ips) > 1 {
+	if *cli.batch || len(ips) > 1 {
 		results := client.LookupBatch(ctx, ips)
-		if *json {
+		if *cli.json {
 			printGeolocJSON(results)
 		} else {
 			printGeolocTable(results, ips)
@@ -53,7 +68,7 @@ func runGeoloc(args []string) {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
-		if *json {
+		if *cli.json {
 			printGeolocSingleJSON(info)
 		} else {
 			printGeolocSingle(info)
@@ -9,17 +9,27 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/hashcheck"
 )
 
-func runHashCheck(args []string) {
+// hashCheckFlags holds the flags of the hashcheck command.
+type hashCheckFlags struct {
+	algo    *string
+	file    *string
+	compare *string
+	all     *bool
+}
+
+// newHashCheckFlags defines the flags of the hashcheck command.
+func newHashCheckFlags() (*flag.FlagSet, *hashCheckFlags) {
 	fs := flag.NewFlagSet("hashcheck", flag.ExitOnError)
-	algo := fs.String("algo", "sha256", "Hash algorithm: md5, sha1, sha256, sha512")
-	file := fs.String("file", "", "File to hash (instead of string)")
-	compare := fs.String("compare", "", "Expected hash to compare against")
-	all := fs.Bool("all", false, "Compute all hash algorithms")
+	cli := &hashCheckFlags{}
+	cli.algo = fs.String("algo", "sha256", "Hash algorithm: md5, sha1, sha256, sha512")
+	cli.file = fs.String("file", "", "File to hash (instead of string)")
+	cli.compare = fs.String("compare", "", "Expected hash to compare against")
+	cli.all = fs.Bool("all", false, "Compute all hash algorithms")
 
 	// Short flags
-	fs.StringVar(algo, "a", "sha256", "Hash algorithm")
-	fs.StringVar(file, "f", "", "File to hash")
-	fs.StringVar(compare, "c", "", "Compare hash")
+	fs.StringVar(cli.algo, "a", "sha256", "Hash algorithm")
+	fs.StringVar(cli.file, "f", "", "File to hash")
+	fs.StringVar(cli.compare, "c", "", "Compare hash")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns hashcheck [options] <input>
@@ -43,33 +53,39 @@ Examples:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runHashCheck(args []string) {
+	fs, cli := newHashCheckFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	algorithm := hashcheck.Algorithm(strings.ToLower(*algo))
+	algorithm := hashcheck.Algorithm(strings.ToLower(*cli.algo))
 
 	// Mode: hash file
-	if *file != "" {
-		if *all {
-			fmt.Printf("HASH FILE (all algorithms)\n  %s\n\n", *file)
-			results := hashcheck.HashFileAll(*file)
+	if *cli.file != "" {
+		if *cli.all {
+			fmt.Printf("HASH FILE (all algorithms)\n  %s\n\n", *cli.file)
+			results := hashcheck.HashFileAll(*cli.file)
 			for _, r := range results {
 				fmt.Printf("── %s ──\n", strings.ToUpper(string(r.Algorithm)))
 				fmt.Print(hashcheck.FormatResult(r))
 				fmt.Println()
 			}
 		} else {
-			fmt.Printf("HASH FILE %s\n\n", strings.ToUpper(*algo))
-			r := hashcheck.HashFile(*file, algorithm)
+			fmt.Printf("HASH FILE %s\n\n", strings.ToUpper(*cli.algo))
+			r := hashcheck.HashFile(*cli.file, algorithm)
 			fmt.Print(hashcheck.FormatResult(r))
 			if !r.Valid {
 				os.Exit(1)
 			}
-			if *compare != "" {
+			if *cli.compare != "" {
 				fmt.Println()
-				cr := hashcheck.Compare(r, *compare)
+				cr := hashcheck.Compare(r, *cli.compare)
 				fmt.Print(hashcheck.FormatCompare(cr))
 				if !cr.Match {
 					os.Exit(1)
@@ -89,7 +105,7 @@ Examples:
 		os.Exit(1)
 	}
 
-	if *all {
+	if *cli.all {
 		fmt.Printf("HASH ALL ALGORITHMS\n\n")
 		results := hashcheck.HashAll(input)
 		for _, r := range results {
@@ -98,15 +114,15 @@ Examples:
 			fmt.Println()
 		}
 	} else {
-		fmt.Printf("HASH %s\n\n", strings.ToUpper(*algo))
+		fmt.Printf("HASH %s\n\n", strings.ToUpper(*cli.algo))
 		r := hashcheck.HashString(input, algorithm)
 		fmt.Print(hashcheck.FormatResult(r))
 		if !r.Valid {
 			os.Exit(1)
 		}
-		if *compare != "" {
+		if *cli.compare != "" {
 			fmt.Println()
-			cr := hashcheck.Compare(r, *compare)
+			cr := hashcheck.Compare(r, *cli.compare)
 			fmt.Print(hashcheck.FormatCompare(cr))
 			if !cr.Match {
 				os.Exit(1)
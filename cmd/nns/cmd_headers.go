@@ -8,12 +8,20 @@ import (
 	"time"
 
 	"github.com/JedizLaPulga/NNS/internal/headers"
+	"github.com/JedizLaPulga/NNS/internal/output"
 )
 
-func runHeaders(args []string) {
+// headersFlags holds the flags of the headers command.
+type headersFlags struct {
+	timeoutFlag *time.Duration
+}
+
+// newHeadersFlags defines the flags of the headers command.
+func newHeadersFlags() (*flag.FlagSet, *headersFlags) {
 	fs := flag.NewFlagSet("headers", flag.ExitOnError)
+	cli := &headersFlags{}
 
-	timeoutFlag := fs.Duration("timeout", 10*time.Second, "Request timeout")
+	cli.timeoutFlag = fs.Duration("timeout", 10*time.Second, "Request timeout")
 
 	fs.Usage = func() {
 		fmt.Println(`Usage: nns headers [URL] [OPTIONS]
@@ -29,7 +37,13 @@ EXAMPLES:
   nns headers https://example.com`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runHeaders(args []string) {
+	fs, cli := newHeadersFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		os.Exit(1)
 	}
 
@@ -42,9 +56,9 @@ EXAMPLES:
 	url := fs.Arg(0)
 
 	analyzer := headers.NewAnalyzer()
-	analyzer.Timeout = *timeoutFlag
+	analyzer.Timeout = *cli.timeoutFlag
 
-	ctx, cancel := context.WithTimeout(context.Background(), *timeoutFlag)
+	ctx, cancel := context.WithTimeout(context.Background(), *cli.timeoutFlag)
 	defer cancel()
 
 	result, err := analyzer.Analyze(ctx, url)
@@ -58,20 +72,7 @@ EXAMPLES:
 	fmt.Println("════════════════════════════════════════════════════════════════")
 
 	// Grade
-	gradeColor := ""
-	gradeReset := ""
-	switch result.Grade[0] {
-	case 'A':
-		gradeColor = "\033[32m"
-		gradeReset = "\033[0m"
-	case 'B':
-		gradeColor = "\033[33m"
-		gradeReset = "\033[0m"
-	default:
-		gradeColor = "\033[31m"
-		gradeReset = "\033[0m"
-	}
-	fmt.Printf("\n  Grade: %s%s%s (Score: %d/100)\n", gradeColor, result.Grade, gradeReset, result.Score)
+	fmt.Printf("\n  Grade: %s (Score: %d/100)\n", output.Stdout.Grade(result.Grade), result.Score)
 
 	// Present headers
 	fmt.Println("\n─── Present Headers ────────────────────────────────────────────")
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
@@ -8,29 +10,66 @@ import (
 	"time"
 
 	"github.com/JedizLaPulga/NNS/internal/httpclient"
+	"github.com/JedizLaPulga/NNS/internal/output"
 )
 
-func runHTTP(args []string) {
+// httpFlags holds the flags of the http command.
+type httpFlags struct {
+	methodFlag   *string
+	dataFlag     *string
+	timingFlag   *bool
+	headersFlag  *bool
+	outputFlag   *string
+	timeoutFlag  *time.Duration
+	jsonFlag     *bool
+	followFlag   *bool
+	silentFlag   *bool
+	proxyFlag    *string
+	prettyFlag   *bool
+	jqFlag       *string
+	dumpFileFlag *string
+	dumpReqFlag  *bool
+	repeatFlag   *int
+	http3Flag    *bool
+	headerFlag   *string
+	formFlags    stringList
+	fileFlags    stringList
+}
+
+// newHTTPFlags defines the flags of the http command.
+func newHTTPFlags() (*flag.FlagSet, *httpFlags) {
 	fs := flag.NewFlagSet("http", flag.ExitOnError)
+	cli := &httpFlags{}
 
-	methodFlag := fs.String("method", "GET", "HTTP method")
-	dataFlag := fs.String("data", "", "Request body data")
-	timingFlag := fs.Bool("timing", false, "Show detailed timing breakdown")
-	headersFlag := fs.Bool("headers", false, "Show response headers")
-	outputFlag := fs.String("output", "", "Save response body to file")
-	timeoutFlag := fs.Duration("timeout", 30*time.Second, "Request timeout")
-	jsonFlag := fs.Bool("json", false, "Output in JSON format")
-	followFlag := fs.Bool("follow", true, "Follow redirects")
-	silentFlag := fs.Bool("silent", false, "Don't print response body")
+	cli.methodFlag = fs.String("method", "GET", "HTTP method")
+	cli.dataFlag = fs.String("data", "", "Request body data")
+	cli.timingFlag = fs.Bool("timing", false, "Show detailed timing breakdown")
+	cli.headersFlag = fs.Bool("headers", false, "Show response headers")
+	cli.outputFlag = fs.String("output", "", "Save response body to file")
+	cli.timeoutFlag = fs.Duration("timeout", 30*time.Second, "Request timeout")
+	cli.jsonFlag = fs.Bool("json", false, "Output in JSON format")
+	cli.followFlag = fs.Bool("follow", true, "Follow redirects")
+	cli.silentFlag = fs.Bool("silent", false, "Don't print response body")
+	cli.proxyFlag = fs.String("proxy", "", "Upstream proxy URL (http, https, socks5)")
+	cli.prettyFlag = fs.Bool("pretty", false, "Pretty-print JSON response bodies")
+	cli.jqFlag = fs.String("jq", "", "Extract a dotted path from a JSON body (e.g. .data.items[0].id)")
+	cli.dumpFileFlag = fs.String("dump-file", "", "Save full raw response (status line, headers, body) to file")
+	cli.dumpReqFlag = fs.Bool("dump-request", false, "Also write the raw request to --dump-file")
+	cli.repeatFlag = fs.Int("repeat", 1, "Send the request N times and show aggregate timing")
+	cli.http3Flag = fs.Bool("http3", false, "Repeat the request over HTTP/3 (QUIC) if the server offers it via Alt-Svc")
 
 	// Short flags
-	fs.StringVar(methodFlag, "X", "GET", "HTTP method")
-	fs.StringVar(dataFlag, "d", "", "Request body")
-	fs.StringVar(outputFlag, "o", "", "Output file")
+	fs.StringVar(cli.methodFlag, "X", "GET", "HTTP method")
+	fs.StringVar(cli.dataFlag, "d", "", "Request body")
+	fs.StringVar(cli.outputFlag, "o", "", "Output file")
 
 	// Headers (simple implementation - one header)
-	headerFlag := fs.String("H", "", "Header in 'Name: Value' format")
-	fs.StringVar(headerFlag, "header", "", "Header")
+	cli.headerFlag = fs.String("H", "", "Header in 'Name: Value' format")
+	fs.StringVar(cli.headerFlag, "header", "", "Header")
+
+	// Multipart form fields (repeatable)
+	fs.Var(&cli.formFlags, "form", "Multipart form field 'key=value' (repeatable)")
+	fs.Var(&cli.fileFlags, "file", "Multipart file field 'field=@path' (repeatable)")
 
 	fs.Usage = func() {
 		fmt.Println(`Usage: nns http [URL] [OPTIONS]
@@ -41,13 +80,26 @@ OPTIONS:
   -X, --method    HTTP method (GET, POST, PUT, DELETE, etc.)
   -d, --data      Request body data
   -H, --header    Add header (format: "Name: Value")
+      --form      Multipart form field "key=value" (repeatable)
+      --file      Multipart file upload "field=@path" (repeatable)
       --timing    Show detailed timing breakdown
       --headers   Show response headers
   -o, --output    Save response body to file
+      --dump-file Save raw response (status line, headers, body) to file
+      --dump-request
+                  Also write the raw request sent to --dump-file
       --json      Output in JSON format
       --follow    Follow redirects (default: true)
       --silent    Don't print response body
+      --pretty    Pretty-print JSON response bodies
+      --jq        Print only the value at a JSON path (e.g. .data.items[0].id)
       --timeout   Request timeout (default: 30s)
+      --proxy     Upstream proxy (http://host:port, socks5://host:port)
+                  Defaults to HTTP_PROXY/HTTPS_PROXY/NO_PROXY from environment
+      --repeat    Send the request N times (keep-alive) and show
+                  min/avg/max/p95 for each timing phase
+      --http3     Use HTTP/3 (QUIC) for GET/HEAD when the server advertises
+                  h3 via Alt-Svc; falls back to HTTP/1.1 or HTTP/2 otherwise
       --help      Show this help message
 
 EXAMPLES:
@@ -56,10 +108,22 @@ EXAMPLES:
   nns http https://api.example.com -X POST -d '{"key":"value"}'
   nns http https://api.example.com -H "Authorization: Bearer token"
   nns http https://httpbin.org/get --headers
-  nns http https://example.com -o page.html`)
+  nns http https://example.com -o page.html
+  nns http https://api.example.com --dump-file resp.txt --dump-request
+  nns http https://example.com --proxy http://127.0.0.1:8080
+  nns http https://api.example.com --repeat 20
+  nns http https://cloudflare.com --http3 --timing
+  nns http https://api.github.com/repos/golang/go --jq .stargazers_count
+  nns http https://httpbin.org/post -X POST --form name=nns --file doc=@report.pdf`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runHTTP(args []string) {
+	fs, cli := newHTTPFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		os.Exit(1)
 	}
 
@@ -73,31 +137,86 @@ EXAMPLES:
 
 	// Build request
 	req := &httpclient.Request{
-		Method:       *methodFlag,
+		Method:       *cli.methodFlag,
 		URL:          url,
-		Body:         *dataFlag,
-		Timeout:      *timeoutFlag,
-		FollowRedirs: *followFlag,
+		Body:         *cli.dataFlag,
+		Timeout:      *cli.timeoutFlag,
+		FollowRedirs: *cli.followFlag,
 		Headers:      make(map[string]string),
 	}
 
 	// Parse header
-	if *headerFlag != "" {
-		parts := strings.SplitN(*headerFlag, ":", 2)
+	if *cli.headerFlag != "" {
+		parts := strings.SplitN(*cli.headerFlag, ":", 2)
 		if len(parts) == 2 {
 			req.Headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
 		}
 	}
 
+	// Multipart form fields and files
+	if len(cli.formFlags) > 0 || len(cli.fileFlags) > 0 {
+		if *cli.dataFlag != "" {
+			fmt.Fprintf(os.Stderr, "Error: --data cannot be combined with --form or --file\n")
+			os.Exit(1)
+		}
+		req.Form = make(map[string]string)
+		req.Files = make(map[string]string)
+		for _, f := range cli.formFlags {
+			k, v, ok := strings.Cut(f, "=")
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Error: invalid --form value %q (expected key=value)\n", f)
+				os.Exit(1)
+			}
+			req.Form[k] = v
+		}
+		for _, f := range cli.fileFlags {
+			k, v, ok := strings.Cut(f, "=")
+			if !ok || !strings.HasPrefix(v, "@") {
+				fmt.Fprintf(os.Stderr, "Error: invalid --file value %q (expected field=@path)\n", f)
+				os.Exit(1)
+			}
+			req.Files[k] = strings.TrimPrefix(v, "@")
+		}
+		if *cli.methodFlag == "GET" {
+			req.Method = "POST"
+		}
+	}
+
 	// Auto-detect JSON body
-	if *dataFlag != "" && strings.HasPrefix(strings.TrimSpace(*dataFlag), "{") {
+	if *cli.dataFlag != "" && strings.HasPrefix(strings.TrimSpace(*cli.dataFlag), "{") {
 		req.Headers["Content-Type"] = "application/json"
 	}
 
 	// Create client
 	client := httpclient.NewClient()
-	client.Timeout = *timeoutFlag
-	client.FollowRedirects = *followFlag
+	client.Timeout = *cli.timeoutFlag
+	client.FollowRedirects = *cli.followFlag
+	client.Proxy = *cli.proxyFlag
+	client.CaptureRequest = *cli.dumpReqFlag && *cli.dumpFileFlag != ""
+	client.HTTP3 = *cli.http3Flag
+
+	if *cli.http3Flag {
+		// The QUIC request neither goes through a proxy nor records the
+		// raw request
+		conflict := ""
+		switch {
+		case *cli.repeatFlag > 1:
+			conflict = "--repeat"
+		case *cli.proxyFlag != "":
+			conflict = "--proxy"
+		case *cli.dumpReqFlag:
+			conflict = "--dump-request"
+		}
+		if conflict != "" {
+			fmt.Fprintf(os.Stderr, "Error: --http3 cannot be combined with %s\n", conflict)
+			os.Exit(1)
+		}
+	}
+
+	if *cli.repeatFlag > 1 {
+		runHTTPRepeat(client, req, *cli.repeatFlag, *cli.jsonFlag)
+		return
+	}
 
 	// Execute request
 	resp, err := client.Do(req)
@@ -105,9 +224,48 @@ EXAMPLES:
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+	if resp.HTTP3Fallback != "" && !*cli.jsonFlag {
+		fmt.Fprintf(os.Stderr, "HTTP/3 not used: %s\n", resp.HTTP3Fallback)
+	}
+
+	// Save raw request/response dump
+	if *cli.dumpFileFlag != "" {
+		// RequestDump already ends with the blank line closing its headers;
+		// only a request body needs a line break before the response.
+		dump := append([]byte{}, resp.RequestDump...)
+		if len(dump) > 0 && !bytes.HasSuffix(dump, []byte("\r\n")) {
+			dump = append(dump, "\r\n"...)
+		}
+		dump = append(dump, resp.Dump()...)
+		if err := os.WriteFile(*cli.dumpFileFlag, dump, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing dump file: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Raw dump saved to %s\n", *cli.dumpFileFlag)
+	}
+
+	// Save body to file before any output mode returns early
+	if *cli.outputFlag != "" {
+		if err := os.WriteFile(*cli.outputFlag, resp.Body, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing file: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Response saved to %s\n", *cli.outputFlag)
+	}
+
+	// JSON path extraction
+	if *cli.jqFlag != "" {
+		value, err := httpclient.QueryJSON(resp.Body, *cli.jqFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(value)
+		return
+	}
 
 	// JSON output
-	if *jsonFlag {
+	if *cli.jsonFlag {
 		jsonOutput, err := resp.ToJSON()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "JSON error: %v\n", err)
@@ -118,34 +276,63 @@ EXAMPLES:
 	}
 
 	// Print results
-	printHTTPResult(resp, *timingFlag, *headersFlag, *silentFlag)
+	printHTTPResult(resp, *cli.timingFlag, *cli.headersFlag, *cli.silentFlag, *cli.prettyFlag)
 
-	// Save to file
-	if *outputFlag != "" {
-		if err := os.WriteFile(*outputFlag, resp.Body, 0644); err != nil {
-			fmt.Fprintf(os.Stderr, "Error writing file: %v\n", err)
+}
+
+func runHTTPRepeat(client *httpclient.Client, req *httpclient.Request, n int, jsonOut bool) {
+	resps, err := client.DoN(req, n)
+	if err != nil && len(resps) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	ts := httpclient.AggregateTiming(resps)
+
+	if jsonOut {
+		data, jerr := json.MarshalIndent(ts, "", "  ")
+		if jerr != nil {
+			fmt.Fprintf(os.Stderr, "JSON error: %v\n", jerr)
 			os.Exit(1)
 		}
-		fmt.Printf("Response saved to %s\n", *outputFlag)
+		fmt.Println(string(data))
+	} else {
+		statusCounts := make(map[string]int)
+		for _, r := range resps {
+			statusCounts[r.Status]++
+		}
+
+		fmt.Printf("%s %s × %d\n", req.Method, req.URL, len(resps))
+		for status, count := range statusCounts {
+			fmt.Printf("  %s: %d\n", status, count)
+		}
+		fmt.Printf("  Connections reused: %d/%d (reused requests skip DNS/connect/TLS)\n", ts.Reused, ts.Count)
+
+		fmt.Println("\n─── Timing ─────────────────────────────────────────────────────")
+		fmt.Printf("  %-14s %10s %10s %10s %10s\n", "Phase", "Min", "Avg", "Max", "P95")
+		printPhase := func(name string, ps httpclient.PhaseStats) {
+			fmt.Printf("  %-14s %10v %10v %10v %10v\n", name,
+				ps.Min.Round(time.Microsecond*100), ps.Avg.Round(time.Microsecond*100),
+				ps.Max.Round(time.Microsecond*100), ps.P95.Round(time.Microsecond*100))
+		}
+		printPhase("DNS Lookup", ts.DNSLookup)
+		printPhase("TCP Connect", ts.TCPConnect)
+		printPhase("TLS Handshake", ts.TLSHandshake)
+		printPhase("TTFB", ts.TTFB)
+		printPhase("Download", ts.Download)
+		printPhase("Total", ts.Total)
+		fmt.Println()
 	}
-}
 
-func printHTTPResult(r *httpclient.Response, showTiming, showHeaders, silent bool) {
-	// Status line
-	statusColor := ""
-	statusReset := ""
-	if r.StatusCode >= 200 && r.StatusCode < 300 {
-		statusColor = "\033[32m" // Green
-		statusReset = "\033[0m"
-	} else if r.StatusCode >= 300 && r.StatusCode < 400 {
-		statusColor = "\033[33m" // Yellow
-		statusReset = "\033[0m"
-	} else {
-		statusColor = "\033[31m" // Red
-		statusReset = "\033[0m"
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: stopped after %d/%d requests: %v\n", len(resps), n, err)
+		os.Exit(1)
 	}
+}
 
-	fmt.Printf("%s%s%s %s\n", statusColor, r.Proto, statusReset, r.Status)
+func printHTTPResult(r *httpclient.Response, showTiming, showHeaders, silent, pretty bool) {
+	// Status line
+	fmt.Printf("%s %s\n", output.Stdout.Status(r.StatusCode, r.Proto), r.Status)
 
 	// Quick info
 	fmt.Printf("Content-Type: %s\n", r.ContentType)
@@ -167,10 +354,16 @@ func printHTTPResult(r *httpclient.Response, showTiming, showHeaders, silent boo
 		if r.Timing.TLSHandshake > 0 {
 			fmt.Printf("  TLS Handshake: %v\n", r.Timing.TLSHandshake.Round(time.Millisecond))
 		}
+		if r.Timing.QUICHandshake > 0 {
+			fmt.Printf("  QUIC Handshake: %v\n", r.Timing.QUICHandshake.Round(time.Millisecond))
+		}
 		fmt.Printf("  TTFB:          %v\n", r.Timing.TTFB.Round(time.Millisecond))
 		fmt.Printf("  Download:      %v\n", r.Timing.Download.Round(time.Millisecond))
 		fmt.Printf("  ────────────────────\n")
 		fmt.Printf("  Total:         %v\n", r.Timing.Total.Round(time.Millisecond))
+		if r.Timing.Note != "" {
+			fmt.Printf("  Note: %s\n", r.Timing.Note)
+		}
 	} else {
 		fmt.Printf("Time: %v\n", r.Timing.Total.Round(time.Millisecond))
 	}
@@ -187,7 +380,15 @@ func printHTTPResult(r *httpclient.Response, showTiming, showHeaders, silent boo
 	if !silent && len(r.Body) > 0 {
 		fmt.Println("\n─── Body ───────────────────────────────────────────────────────")
 		body := string(r.Body)
-		if len(body) > 2000 {
+		formatted := false
+		if pretty && httpclient.IsJSON(r.ContentType, r.Body) {
+			if indented, err := httpclient.PrettyJSON(r.Body); err == nil {
+				body = string(indented)
+				formatted = true
+			}
+		}
+		// Only the raw preview is truncated; pretty output is shown in full
+		if !formatted && len(body) > 2000 {
 			fmt.Printf("%s\n... (truncated, %d bytes total)\n", body[:2000], len(body))
 		} else {
 			fmt.Println(body)
@@ -196,3 +397,15 @@ func printHTTPResult(r *httpclient.Response, showTiming, showHeaders, silent boo
 
 	fmt.Println()
 }
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ", ")
+}
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
@@ -12,20 +12,32 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/httphealth"
 )
 
-func runHTTPHealth(args []string) {
+// httpHealthFlags holds the flags of the httphealth command.
+type httpHealthFlags struct {
+	interval   *int
+	timeout    *int
+	method     *string
+	expect     *int
+	once       *bool
+	headerFlag *string
+}
+
+// newHTTPHealthFlags defines the flags of the httphealth command.
+func newHTTPHealthFlags() (*flag.FlagSet, *httpHealthFlags) {
 	fs := flag.NewFlagSet("httphealth", flag.ExitOnError)
-	interval := fs.Int("interval", 10, "Check interval in seconds")
-	timeout := fs.Int("timeout", 5, "Request timeout in seconds")
-	method := fs.String("method", "GET", "HTTP method to use")
-	expect := fs.Int("expect", 200, "Expected HTTP status code")
-	once := fs.Bool("once", false, "Run a single check and exit")
-	headerFlag := fs.String("header", "", "Custom header (key:value), comma-separated for multiple")
+	cli := &httpHealthFlags{}
+	cli.interval = fs.Int("interval", 10, "Check interval in seconds")
+	cli.timeout = fs.Int("timeout", 5, "Request timeout in seconds")
+	cli.method = fs.String("method", "GET", "HTTP method to use")
+	cli.expect = fs.Int("expect", 200, "Expected HTTP status code")
+	cli.once = fs.Bool("once", false, "Run a single check and exit")
+	cli.headerFlag = fs.String("header", "", "Custom header (key:value), comma-separated for multiple")
 
 	// Short flags
-	fs.IntVar(interval, "i", 10, "Interval (s)")
-	fs.IntVar(timeout, "t", 5, "Timeout (s)")
-	fs.StringVar(method, "m", "GET", "HTTP method")
-	fs.BoolVar(once, "1", false, "Single check")
+	fs.IntVar(cli.interval, "i", 10, "Interval (s)")
+	fs.IntVar(cli.timeout, "t", 5, "Timeout (s)")
+	fs.StringVar(cli.method, "m", "GET", "HTTP method")
+	fs.BoolVar(cli.once, "1", false, "Single check")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns httphealth [options] <url> [url...]
@@ -51,7 +63,13 @@ Examples:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runHTTPHealth(args []string) {
+	fs, cli := newHTTPHealthFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -64,14 +82,14 @@ Examples:
 
 	urls := fs.Args()
 	opts := httphealth.DefaultOptions(urls)
-	opts.Interval = time.Duration(*interval) * time.Second
-	opts.Timeout = time.Duration(*timeout) * time.Second
-	opts.Method = strings.ToUpper(*method)
-	opts.ExpectedStatus = *expect
+	opts.Interval = time.Duration(*cli.interval) * time.Second
+	opts.Timeout = time.Duration(*cli.timeout) * time.Second
+	opts.Method = strings.ToUpper(*cli.method)
+	opts.ExpectedStatus = *cli.expect
 
-	if *headerFlag != "" {
+	if *cli.headerFlag != "" {
 		opts.Headers = make(map[string]string)
-		for _, h := range strings.Split(*headerFlag, ",") {
+		for _, h := range strings.Split(*cli.headerFlag, ",") {
 			parts := strings.SplitN(strings.TrimSpace(h), ":", 2)
 			if len(parts) == 2 {
 				opts.Headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
@@ -81,11 +99,11 @@ Examples:
 
 	fmt.Printf("HTTP HEALTH MONITOR\n\n")
 	fmt.Printf("  Endpoints:  %d\n", len(urls))
-	fmt.Printf("  Interval:   %ds\n", *interval)
+	fmt.Printf("  Interval:   %ds\n", *cli.interval)
 	fmt.Printf("  Expected:   %d\n", opts.ExpectedStatus)
 	fmt.Println()
 
-	if *once {
+	if *cli.once {
 		results := httphealth.CheckAll(context.Background(), opts)
 		fmt.Println(httphealth.FormatRound(results))
 		return
@@ -13,17 +13,32 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/httpstress"
 )
 
-func runHTTPStress(args []string) {
+// httpStressFlags holds the flags of the httpstress command.
+type httpStressFlags struct {
+	method      *string
+	concurrency *int
+	requests    *int
+	duration    *time.Duration
+	timeout     *time.Duration
+	headers     *string
+	body        *string
+	insecure    *bool
+	noKeepAlive *bool
+}
+
+// newHTTPStressFlags defines the flags of the httpstress command.
+func newHTTPStressFlags() (*flag.FlagSet, *httpStressFlags) {
 	fs := flag.NewFlagSet("httpstress", flag.ExitOnError)
-	method := fs.String("method", "GET", "HTTP method")
-	concurrency := fs.Int("concurrency", 10, "Number of concurrent workers")
-	requests := fs.Int("requests", 100, "Total number of requests")
-	duration := fs.Duration("duration", 0, "Test duration (overrides -requests)")
-	timeout := fs.Duration("timeout", 30*time.Second, "Request timeout")
-	headers := fs.String("headers", "", "Custom headers (key:value,key2:value2)")
-	body := fs.String("body", "", "Request body")
-	insecure := fs.Bool("insecure", false, "Skip TLS verification")
-	noKeepAlive := fs.Bool("no-keepalive", false, "Disable keep-alive")
+	cli := &httpStressFlags{}
+	cli.method = fs.String("method", "GET", "HTTP method")
+	cli.concurrency = fs.Int("concurrency", 10, "Number of concurrent workers")
+	cli.requests = fs.Int("requests", 100, "Total number of requests")
+	cli.duration = fs.Duration("duration", 0, "Test duration (overrides -requests)")
+	cli.timeout = fs.Duration("timeout", 30*time.Second, "Request timeout")
+	cli.headers = fs.String("headers", "", "Custom headers (key:value,key2:value2)")
+	cli.body = fs.String("body", "", "Request body")
+	cli.insecure = fs.Bool("insecure", false, "Skip TLS verification")
+	cli.noKeepAlive = fs.Bool("no-keepalive", false, "Disable keep-alive")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns httpstress [OPTIONS] <url>
@@ -42,7 +57,13 @@ EXAMPLES:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runHTTPStress(args []string) {
+	fs, cli := newHTTPStressFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		return
 	}
 
@@ -55,20 +76,20 @@ EXAMPLES:
 
 	opts := httpstress.Options{
 		URL:           url,
-		Method:        *method,
-		Concurrency:   *concurrency,
-		TotalRequests: *requests,
-		Duration:      *duration,
-		Timeout:       *timeout,
-		Body:          *body,
-		InsecureSkip:  *insecure,
-		KeepAlive:     !*noKeepAlive,
+		Method:        *cli.method,
+		Concurrency:   *cli.concurrency,
+		TotalRequests: *cli.requests,
+		Duration:      *cli.duration,
+		Timeout:       *cli.timeout,
+		Body:          *cli.body,
+		InsecureSkip:  *cli.insecure,
+		KeepAlive:     !*cli.noKeepAlive,
 		Headers:       make(map[string]string),
 	}
 
 	// Parse headers
-	if *headers != "" {
-		pairs := strings.Split(*headers, ",")
+	if *cli.headers != "" {
+		pairs := strings.Split(*cli.headers, ",")
 		for _, pair := range pairs {
 			parts := strings.SplitN(pair, ":", 2)
 			if len(parts) == 2 {
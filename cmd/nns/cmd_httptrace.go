@@ -12,19 +12,31 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/httptrace"
 )
 
-func runHTTPTrace(args []string) {
+// httpTraceFlags holds the flags of the httptrace command.
+type httpTraceFlags struct {
+	method       *string
+	timeout      *time.Duration
+	maxRedirects *int
+	noFollow     *bool
+	insecure     *bool
+	headers      *string
+}
+
+// newHTTPTraceFlags defines the flags of the httptrace command.
+func newHTTPTraceFlags() (*flag.FlagSet, *httpTraceFlags) {
 	fs := flag.NewFlagSet("httptrace", flag.ExitOnError)
-	method := fs.String("method", "GET", "HTTP method")
-	timeout := fs.Duration("timeout", 30*time.Second, "Request timeout")
-	maxRedirects := fs.Int("max-redirects", 10, "Maximum redirects to follow")
-	noFollow := fs.Bool("no-follow", false, "Don't follow redirects")
-	insecure := fs.Bool("insecure", false, "Skip TLS verification")
-	headers := fs.String("headers", "", "Custom headers (key:value,key:value)")
+	cli := &httpTraceFlags{}
+	cli.method = fs.String("method", "GET", "HTTP method")
+	cli.timeout = fs.Duration("timeout", 30*time.Second, "Request timeout")
+	cli.maxRedirects = fs.Int("max-redirects", 10, "Maximum redirects to follow")
+	cli.noFollow = fs.Bool("no-follow", false, "Don't follow redirects")
+	cli.insecure = fs.Bool("insecure", false, "Skip TLS verification")
+	cli.headers = fs.String("headers", "", "Custom headers (key:value,key:value)")
 
 	// Short flags
-	fs.StringVar(method, "X", "GET", "HTTP method")
-	fs.DurationVar(timeout, "t", 30*time.Second, "Request timeout")
-	fs.BoolVar(insecure, "k", false, "Skip TLS verification")
+	fs.StringVar(cli.method, "X", "GET", "HTTP method")
+	fs.DurationVar(cli.timeout, "t", 30*time.Second, "Request timeout")
+	fs.BoolVar(cli.insecure, "k", false, "Skip TLS verification")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns httptrace [options] <url>
@@ -49,7 +61,13 @@ Examples:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runHTTPTrace(args []string) {
+	fs, cli := newHTTPTraceFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -72,16 +90,16 @@ Examples:
 
 	opts := httptrace.TraceOptions{
 		URL:            fs.Arg(0),
-		Method:         *method,
-		Timeout:        *timeout,
-		MaxRedirects:   *maxRedirects,
-		FollowRedirect: !*noFollow,
-		InsecureSkip:   *insecure,
+		Method:         *cli.method,
+		Timeout:        *cli.timeout,
+		MaxRedirects:   *cli.maxRedirects,
+		FollowRedirect: !*cli.noFollow,
+		InsecureSkip:   *cli.insecure,
 	}
 
-	if *headers != "" {
+	if *cli.headers != "" {
 		opts.Headers = make(map[string]string)
-		for _, h := range strings.Split(*headers, ",") {
+		for _, h := range strings.Split(*cli.headers, ",") {
 			parts := strings.SplitN(h, ":", 2)
 			if len(parts) == 2 {
 				opts.Headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
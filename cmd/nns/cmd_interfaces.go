@@ -9,14 +9,22 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/interfaces"
 )
 
-func runInterfaces(args []string) {
+// interfacesFlags holds the flags of the interfaces command.
+type interfacesFlags struct {
+	activeFlag *bool
+	nameFlag   *string
+}
+
+// newInterfacesFlags defines the flags of the interfaces command.
+func newInterfacesFlags() (*flag.FlagSet, *interfacesFlags) {
 	fs := flag.NewFlagSet("interfaces", flag.ExitOnError)
+	cli := &interfacesFlags{}
 
-	activeFlag := fs.Bool("active", false, "Show only active (up) interfaces")
-	nameFlag := fs.String("name", "", "Show specific interface by name")
+	cli.activeFlag = fs.Bool("active", false, "Show only active (up) interfaces")
+	cli.nameFlag = fs.String("name", "", "Show specific interface by name")
 
-	fs.BoolVar(activeFlag, "a", false, "Show only active interfaces")
-	fs.StringVar(nameFlag, "n", "", "Interface name")
+	fs.BoolVar(cli.activeFlag, "a", false, "Show only active interfaces")
+	fs.StringVar(cli.nameFlag, "n", "", "Interface name")
 
 	fs.Usage = func() {
 		fmt.Println(`Usage: nns interfaces [OPTIONS]
@@ -34,21 +42,27 @@ EXAMPLES:
   nns interfaces --name eth0`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runInterfaces(args []string) {
+	fs, cli := newInterfacesFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		os.Exit(1)
 	}
 
 	var ifaces []interfaces.Interface
 	var err error
 
-	if *nameFlag != "" {
-		iface, err := interfaces.GetByName(*nameFlag)
+	if *cli.nameFlag != "" {
+		iface, err := interfaces.GetByName(*cli.nameFlag)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 		ifaces = []interfaces.Interface{*iface}
-	} else if *activeFlag {
+	} else if *cli.activeFlag {
 		ifaces, err = interfaces.ListActive()
 	} else {
 		ifaces, err = interfaces.ListAll()
@@ -8,13 +8,21 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/ipconv"
 )
 
-func runIPConv(args []string) {
+// ipConvFlags holds the flags of the ipconv command.
+type ipConvFlags struct {
+	fromInt *bool
+	all     *bool
+}
+
+// newIPConvFlags defines the flags of the ipconv command.
+func newIPConvFlags() (*flag.FlagSet, *ipConvFlags) {
 	fs := flag.NewFlagSet("ipconv", flag.ExitOnError)
-	fromInt := fs.Bool("from-int", false, "Interpret input as decimal integer")
-	all := fs.Bool("all", false, "Convert multiple IPs (remaining args)")
+	cli := &ipConvFlags{}
+	cli.fromInt = fs.Bool("from-int", false, "Interpret input as decimal integer")
+	cli.all = fs.Bool("all", false, "Convert multiple IPs (remaining args)")
 
 	// Short flags
-	fs.BoolVar(fromInt, "i", false, "From integer")
+	fs.BoolVar(cli.fromInt, "i", false, "From integer")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns ipconv [options] <ip-or-integer>
@@ -38,7 +46,13 @@ Examples:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runIPConv(args []string) {
+	fs, cli := newIPConvFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -50,7 +64,7 @@ Examples:
 	}
 
 	inputs := fs.Args()
-	if !*all {
+	if !*cli.all {
 		inputs = inputs[:1]
 	}
 
@@ -62,7 +76,7 @@ Examples:
 		}
 
 		var c ipconv.Conversion
-		if *fromInt {
+		if *cli.fromInt {
 			c = ipconv.FromInteger(input)
 		} else {
 			c = ipconv.Convert(input)
@@ -10,7 +10,8 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/ipinfo"
 )
 
-func runIPInfo(args []string) {
+// newIPInfoFlags defines the flags of the ipinfo command.
+func newIPInfoFlags() *flag.FlagSet {
 	fs := flag.NewFlagSet("ipinfo", flag.ExitOnError)
 
 	fs.Usage = func() {
@@ -27,7 +28,13 @@ EXAMPLES:
   nns ipinfo 1.1.1.1`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs
+}
+
+func runIPInfo(args []string) {
+	fs := newIPInfoFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		os.Exit(1)
 	}
 
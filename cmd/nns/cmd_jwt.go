@@ -1,52 +1,119 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/JedizLaPulga/NNS/internal/jwtutil"
 )
 
-func runJWT(args []string) {
+// jwtFlags holds the flags of the jwt command.
+type jwtFlags struct {
+	secret      *string
+	keyFile     *string
+	jwksURL     *string
+	expectIss   *string
+	expectAud   *string
+	require     *string
+	maxLifetime *time.Duration
+	crack       *bool
+	wordlist    *string
+	fromStdin   *bool
+	jsonOut     *bool
+}
+
+// newJWTFlags defines the flags of the jwt command.
+func newJWTFlags() (*flag.FlagSet, *jwtFlags) {
 	fs := flag.NewFlagSet("jwt", flag.ExitOnError)
+	cli := &jwtFlags{}
+	cli.secret = fs.String("secret", "", "HMAC secret to verify HS* signatures")
+	cli.keyFile = fs.String("key", "", "PEM public key or certificate to verify RS/ES/PS signatures")
+	cli.jwksURL = fs.String("jwks", "", "JWKS URL to verify RS/ES/PS signatures against")
+	cli.expectIss = fs.String("expect-iss", "", "Required issuer (iss)")
+	cli.expectAud = fs.String("expect-aud", "", "Audience that must appear in aud")
+	cli.require = fs.String("require", "", "Comma-separated claims that must be present")
+	cli.maxLifetime = fs.Duration("max-lifetime", 0, "Maximum acceptable token lifetime (exp - iat)")
+	cli.crack = fs.Bool("crack", false, "Try common secrets against HS* signatures")
+	cli.wordlist = fs.String("wordlist", "", "File of candidate HMAC secrets (implies --crack)")
+	cli.fromStdin = fs.Bool("stdin", false, "Read the token from stdin")
+	cli.jsonOut = fs.Bool("json", false, "Output the analysis as JSON")
 
 	fs.Usage = func() {
-		fmt.Fprintf(os.Stderr, `Usage: nns jwt [options] <token>
+		fmt.Fprintf(os.Stderr, `Usage: nns jwt [options] <token | @file>
 
 Decode and analyze JWT (JSON Web Token) security.
 Parses header and claims, checks for weak algorithms, expiration,
 sensitive data in claims, and assigns a security grade (A-F).
 
-The token can include or omit the "Bearer " prefix.
-If no token argument is given, reads from stdin.
+The token can include or omit the "Bearer " prefix. Pass @file to
+read it from a file; with --stdin or no token argument, it is read
+from stdin.
 
 Options:
-  --help    Show this help message
+  --secret S     Verify an HS256/384/512 signature with shared secret S
+  --key FILE     Verify an RS/ES/PS signature with a PEM public key or certificate
+  --jwks URL     Verify against the issuer's JSON Web Key Set (key chosen by kid)
+  --expect-iss I Require issuer I
+  --expect-aud A Require audience A in aud
+  --require LIST Require the comma-separated claims to be present
+  --max-lifetime D
+                 Maximum acceptable lifetime, e.g. 1h
+  --crack        Try built-in common secrets against an HS* signature
+  --wordlist F   Try the secrets in file F (one per line) instead
+  --stdin        Read the token from stdin
+  --json         Output the analysis as JSON
+  --help         Show this help message
 
 Examples:
   nns jwt eyJhbGci...
   nns jwt "Bearer eyJhbGci..."
   echo "eyJhbGci..." | nns jwt
+  nns jwt --json @token.txt
+  nns jwt --secret my-secret eyJhbGci...
+  nns jwt --key pubkey.pem eyJhbGci...
+  nns jwt --jwks https://issuer.example.com/.well-known/jwks.json eyJhbGci...
+  nns jwt --expect-iss https://issuer.example.com --expect-aud api eyJhbGci...
+  nns jwt --crack eyJhbGci...
+  nns jwt --wordlist secrets.txt eyJhbGci...
   curl -s https://api.example.com/token | nns jwt
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runJWT(args []string) {
+	fs, cli := newJWTFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
 	var tokenStr string
 
-	if fs.NArg() >= 1 {
+	switch {
+	case *cli.fromStdin && fs.NArg() > 0:
+		fmt.Fprintf(os.Stderr, "Error: --stdin cannot be combined with a token argument\n")
+		os.Exit(1)
+	case fs.NArg() == 1 && strings.HasPrefix(fs.Arg(0), "@"):
+		data, err := os.ReadFile(fs.Arg(0)[1:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		tokenStr = strings.TrimSpace(string(data))
+	case fs.NArg() >= 1:
 		tokenStr = strings.Join(fs.Args(), "")
-	} else {
-		// Try reading from stdin
+	default:
+		// Read from stdin when asked to or when it is piped
 		stat, _ := os.Stdin.Stat()
-		if stat.Mode()&os.ModeCharDevice == 0 {
+		if *cli.fromStdin || stat.Mode()&os.ModeCharDevice == 0 {
 			data, err := io.ReadAll(os.Stdin)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
@@ -62,11 +129,82 @@ Examples:
 		os.Exit(1)
 	}
 
-	result, err := jwtutil.Decode(tokenStr)
+	verifiers := 0
+	for _, set := range []bool{*cli.secret != "", *cli.keyFile != "", *cli.jwksURL != ""} {
+		if set {
+			verifiers++
+		}
+	}
+	if verifiers > 1 {
+		fmt.Fprintf(os.Stderr, "Error: --secret, --key and --jwks are mutually exclusive\n")
+		os.Exit(1)
+	}
+
+	var result *jwtutil.AnalysisResult
+	var err error
+	switch {
+	case *cli.secret != "":
+		result, err = jwtutil.Verify(tokenStr, []byte(*cli.secret))
+	case *cli.keyFile != "":
+		var pemData []byte
+		if pemData, err = os.ReadFile(*cli.keyFile); err == nil {
+			result, err = jwtutil.VerifyPEM(tokenStr, pemData)
+		}
+	case *cli.jwksURL != "":
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		result, err = jwtutil.VerifyWithJWKS(ctx, tokenStr, *cli.jwksURL)
+		cancel()
+	default:
+		result, err = jwtutil.Decode(tokenStr)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
+	expected := jwtutil.Expectations{
+		Issuer:      *cli.expectIss,
+		Audience:    *cli.expectAud,
+		MaxLifetime: *cli.maxLifetime,
+	}
+	for _, claim := range strings.Split(*cli.require, ",") {
+		if claim = strings.TrimSpace(claim); claim != "" {
+			expected.RequiredClaims = append(expected.RequiredClaims, claim)
+		}
+	}
+	if expected.Issuer != "" || expected.Audience != "" || len(expected.RequiredClaims) > 0 || expected.MaxLifetime > 0 {
+		jwtutil.Validate(result, expected)
+	}
+
+	if (*cli.crack || *cli.wordlist != "") && jwtutil.IsHMAC(result) {
+		candidates := jwtutil.CommonSecrets()
+		if *cli.wordlist != "" {
+			f, err := os.Open(*cli.wordlist)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			candidates, err = jwtutil.ReadWordlist(f)
+			f.Close()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading wordlist: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if secret, found := jwtutil.CrackHMAC(tokenStr, candidates); found {
+			result.RecordCrackedSecret(secret)
+		}
+	}
+
+	if *cli.jsonOut {
+		out, err := result.ToJSON()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(out)
+		return
+	}
+
 	fmt.Print(jwtutil.FormatResult(result))
 }
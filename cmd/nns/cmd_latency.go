@@ -11,14 +11,26 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/latency"
 )
 
-func runLatency(args []string) {
+// latencyFlags holds the flags of the latency command.
+type latencyFlags struct {
+	port       *int
+	count      *int
+	interval   *time.Duration
+	timeout    *time.Duration
+	threshold  *time.Duration
+	sparkWidth *int
+}
+
+// newLatencyFlags defines the flags of the latency command.
+func newLatencyFlags() (*flag.FlagSet, *latencyFlags) {
 	fs := flag.NewFlagSet("latency", flag.ExitOnError)
-	port := fs.Int("p", 443, "Target port")
-	count := fs.Int("c", 0, "Number of probes (0 = infinite)")
-	interval := fs.Duration("i", time.Second, "Interval between probes")
-	timeout := fs.Duration("t", 5*time.Second, "Connection timeout")
-	threshold := fs.Duration("threshold", 0, "Alert threshold (e.g., 100ms)")
-	sparkWidth := fs.Int("spark", 40, "Sparkline width")
+	cli := &latencyFlags{}
+	cli.port = fs.Int("p", 443, "Target port")
+	cli.count = fs.Int("c", 0, "Number of probes (0 = infinite)")
+	cli.interval = fs.Duration("i", time.Second, "Interval between probes")
+	cli.timeout = fs.Duration("t", 5*time.Second, "Connection timeout")
+	cli.threshold = fs.Duration("threshold", 0, "Alert threshold (e.g., 100ms)")
+	cli.sparkWidth = fs.Int("spark", 40, "Sparkline width")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: nns latency [options] <host>\n\n")
@@ -31,7 +43,13 @@ func runLatency(args []string) {
 		fmt.Fprintf(os.Stderr, "  nns latency --threshold 50ms google.com\n")
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runLatency(args []string) {
+	fs, cli := newLatencyFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		os.Exit(1)
 	}
 
@@ -44,11 +62,11 @@ func runLatency(args []string) {
 
 	cfg := latency.Config{
 		Target:    target,
-		Port:      *port,
-		Interval:  *interval,
-		Timeout:   *timeout,
-		Threshold: *threshold,
-		Count:     *count,
+		Port:      *cli.port,
+		Interval:  *cli.interval,
+		Timeout:   *cli.timeout,
+		Threshold: *cli.threshold,
+		Count:     *cli.count,
 	}
 
 	mon, err := latency.New(cfg)
@@ -57,14 +75,14 @@ func runLatency(args []string) {
 		os.Exit(1)
 	}
 
-	fmt.Printf("LATENCY %s:%d\n", target, *port)
-	if *threshold > 0 {
-		fmt.Printf("Alert threshold: %v\n", *threshold)
+	fmt.Printf("LATENCY %s:%d\n", target, *cli.port)
+	if *cli.threshold > 0 {
+		fmt.Printf("Alert threshold: %v\n", *cli.threshold)
 	}
 	fmt.Println()
 
 	mon.OnResult(func(r latency.Result) {
-		spark := mon.Sparkline(*sparkWidth)
+		spark := mon.Sparkline(*cli.sparkWidth)
 		fmt.Printf("\r%s %s", latency.FormatResult(r, target), spark)
 	})
 
@@ -12,10 +12,18 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/leak"
 )
 
-func runLeak(args []string) {
+// leakFlags holds the flags of the leak command.
+type leakFlags struct {
+	timeout     *time.Duration
+	vpnExpected *bool
+}
+
+// newLeakFlags defines the flags of the leak command.
+func newLeakFlags() (*flag.FlagSet, *leakFlags) {
 	fs := flag.NewFlagSet("leak", flag.ExitOnError)
-	timeout := fs.Duration("timeout", 10*time.Second, "Test timeout")
-	vpnExpected := fs.Bool("vpn", false, "Expect VPN connection (warn if not detected)")
+	cli := &leakFlags{}
+	cli.timeout = fs.Duration("timeout", 10*time.Second, "Test timeout")
+	cli.vpnExpected = fs.Bool("vpn", false, "Expect VPN connection (warn if not detected)")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns leak [OPTIONS]
@@ -42,13 +50,19 @@ EXAMPLES:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runLeak(args []string) {
+	fs, cli := newLeakFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		return
 	}
 
 	cfg := leak.Config{
-		Timeout:     *timeout,
-		VPNExpected: *vpnExpected,
+		Timeout:     *cli.timeout,
+		VPNExpected: *cli.vpnExpected,
 	}
 
 	tester := leak.New(cfg)
@@ -66,7 +80,7 @@ EXAMPLES:
 	}()
 
 	fmt.Println("Running DNS/IP leak test...")
-	if *vpnExpected {
+	if *cli.vpnExpected {
 		fmt.Println("VPN mode: Will warn if VPN not detected")
 	}
 	fmt.Println()
@@ -10,13 +10,24 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/listen"
 )
 
-func runListen(args []string) {
+// listenFlags holds the flags of the listen command.
+type listenFlags struct {
+	port     *int
+	host     *string
+	udp      *bool
+	echo     *bool
+	maxConns *int
+}
+
+// newListenFlags defines the flags of the listen command.
+func newListenFlags() (*flag.FlagSet, *listenFlags) {
 	fs := flag.NewFlagSet("listen", flag.ExitOnError)
-	port := fs.Int("p", 8080, "Port to listen on")
-	host := fs.String("host", "0.0.0.0", "Host/IP to bind to")
-	udp := fs.Bool("udp", false, "Use UDP instead of TCP")
-	echo := fs.Bool("echo", false, "Echo received data back to client")
-	maxConns := fs.Int("max", 0, "Max concurrent connections (0=unlimited)")
+	cli := &listenFlags{}
+	cli.port = fs.Int("p", 8080, "Port to listen on")
+	cli.host = fs.String("host", "0.0.0.0", "Host/IP to bind to")
+	cli.udp = fs.Bool("udp", false, "Use UDP instead of TCP")
+	cli.echo = fs.Bool("echo", false, "Echo received data back to client")
+	cli.maxConns = fs.Int("max", 0, "Max concurrent connections (0=unlimited)")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: nns listen [options]\n\n")
@@ -30,21 +41,27 @@ func runListen(args []string) {
 		fmt.Fprintf(os.Stderr, "  nns listen --host 127.0.0.1 -p 8080\n")
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runListen(args []string) {
+	fs, cli := newListenFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		os.Exit(1)
 	}
 
 	protocol := listen.TCP
-	if *udp {
+	if *cli.udp {
 		protocol = listen.UDP
 	}
 
 	cfg := listen.Config{
-		Port:     *port,
-		Host:     *host,
+		Port:     *cli.port,
+		Host:     *cli.host,
 		Protocol: protocol,
-		Echo:     *echo,
-		MaxConns: *maxConns,
+		Echo:     *cli.echo,
+		MaxConns: *cli.maxConns,
 	}
 
 	listener := listen.New(cfg)
@@ -63,7 +80,7 @@ func runListen(args []string) {
 	}()
 
 	fmt.Printf("Listening on %s (%s)", listener.Address(), protocol)
-	if *echo {
+	if *cli.echo {
 		fmt.Print(" [ECHO MODE]")
 	}
 	fmt.Println("\nPress Ctrl+C to stop\n")
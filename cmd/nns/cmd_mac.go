@@ -8,16 +8,25 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/macutil"
 )
 
-func runMAC(args []string) {
+// macFlags holds the flags of the mac command.
+type macFlags struct {
+	generateFlag *bool
+	formatFlag   *string
+	ouiFlag      *string
+}
+
+// newMACFlags defines the flags of the mac command.
+func newMACFlags() (*flag.FlagSet, *macFlags) {
 	fs := flag.NewFlagSet("mac", flag.ExitOnError)
+	cli := &macFlags{}
 
-	generateFlag := fs.Bool("generate", false, "Generate random MAC address")
-	formatFlag := fs.String("format", "colon", "Output format: colon, dash, dot, bare, upper")
-	ouiFlag := fs.String("oui", "", "Generate MAC with specific OUI")
+	cli.generateFlag = fs.Bool("generate", false, "Generate random MAC address")
+	cli.formatFlag = fs.String("format", "colon", "Output format: colon, dash, dot, bare, upper")
+	cli.ouiFlag = fs.String("oui", "", "Generate MAC with specific OUI")
 
 	// Short flags
-	fs.BoolVar(generateFlag, "g", false, "Generate")
-	fs.StringVar(formatFlag, "f", "colon", "Format")
+	fs.BoolVar(cli.generateFlag, "g", false, "Generate")
+	fs.StringVar(cli.formatFlag, "f", "colon", "Format")
 
 	fs.Usage = func() {
 		fmt.Println(`Usage: nns mac [MAC] [OPTIONS]
@@ -37,16 +46,22 @@ EXAMPLES:
   nns mac aa-bb-cc-dd-ee-ff --format dot`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runMAC(args []string) {
+	fs, cli := newMACFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		os.Exit(1)
 	}
 
 	// Generate MAC
-	if *generateFlag {
+	if *cli.generateFlag {
 		var mac string
-		if *ouiFlag != "" {
+		if *cli.ouiFlag != "" {
 			var err error
-			mac, err = macutil.GenerateWithOUI(*ouiFlag)
+			mac, err = macutil.GenerateWithOUI(*cli.ouiFlag)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
@@ -55,7 +70,7 @@ EXAMPLES:
 			mac = macutil.Generate(true)
 		}
 
-		formatted := macutil.Format(mac, *formatFlag)
+		formatted := macutil.Format(mac, *cli.formatFlag)
 		info, _ := macutil.Parse(mac)
 
 		fmt.Printf("Generated MAC: %s\n", formatted)
@@ -87,7 +102,7 @@ EXAMPLES:
 		os.Exit(1)
 	}
 
-	formatted := macutil.Format(mac, *formatFlag)
+	formatted := macutil.Format(mac, *cli.formatFlag)
 
 	fmt.Printf("MAC Address Information\n")
 	fmt.Println("════════════════════════════════════════════════════════════════")
@@ -4,35 +4,72 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/JedizLaPulga/NNS/internal/mqtt"
 )
 
-func runMQTT(args []string) {
+// mqttFlags holds the flags of the mqtt command.
+type mqttFlags struct {
+	port       *int
+	useTLS     *bool
+	skipVerify *bool
+	useWS      *bool
+	wsPath     *string
+	username   *string
+	password   *string
+	clientID   *string
+	timeout    *time.Duration
+	pingCount  *int
+	brief      *bool
+	creds      *bool
+	credsFile  *string
+	credsDelay *time.Duration
+	publish    *string
+	payload    *string
+	jsonOut    *bool
+	topics     stringList
+}
+
+// newMQTTFlags defines the flags of the mqtt command.
+func newMQTTFlags() (*flag.FlagSet, *mqttFlags) {
 	fs := flag.NewFlagSet("mqtt", flag.ExitOnError)
-	port := fs.Int("port", 1883, "Broker port")
-	useTLS := fs.Bool("tls", false, "Use TLS (MQTTS)")
-	skipVerify := fs.Bool("insecure", false, "Skip TLS certificate verification")
-	username := fs.String("user", "", "Username for authentication")
-	password := fs.String("pass", "", "Password for authentication")
-	clientID := fs.String("client-id", "nns-mqtt-check", "MQTT client ID")
-	timeout := fs.Duration("timeout", 10*time.Second, "Connection timeout")
-	pingCount := fs.Int("pings", 5, "Number of PINGREQ probes")
-	brief := fs.Bool("brief", false, "Brief output")
+	cli := &mqttFlags{}
+	cli.port = fs.Int("port", 1883, "Broker port")
+	cli.useTLS = fs.Bool("tls", false, "Use TLS (MQTTS)")
+	cli.skipVerify = fs.Bool("insecure", false, "Skip TLS certificate verification")
+	cli.useWS = fs.Bool("ws", false, "Use MQTT over WebSocket")
+	cli.wsPath = fs.String("path", "/mqtt", "WebSocket endpoint path")
+	cli.username = fs.String("user", "", "Username for authentication")
+	cli.password = fs.String("pass", "", "Password for authentication")
+	cli.clientID = fs.String("client-id", "nns-mqtt-check", "MQTT client ID")
+	cli.timeout = fs.Duration("timeout", 10*time.Second, "Connection timeout")
+	cli.pingCount = fs.Int("pings", 5, "Number of PINGREQ probes")
+	cli.brief = fs.Bool("brief", false, "Brief output")
+	cli.creds = fs.Bool("creds", false, "Try built-in default credentials")
+	cli.credsFile = fs.String("creds-file", "", "File of user:pass pairs to try")
+	cli.credsDelay = fs.Duration("creds-delay", 500*time.Millisecond, "Delay between login attempts")
+	cli.publish = fs.String("publish", "", "Publish to this topic and confirm delivery")
+	cli.payload = fs.String("payload", "", "Payload for --publish")
+	cli.jsonOut = fs.Bool("json", false, "Output results as JSON")
+	fs.Var(&cli.topics, "topic", "Topic filter to probe (repeatable)")
 
 	// Short flags
-	fs.IntVar(port, "p", 1883, "Broker port")
-	fs.BoolVar(useTLS, "s", false, "Use TLS (MQTTS)")
-	fs.BoolVar(skipVerify, "k", false, "Skip TLS certificate verification")
-	fs.StringVar(username, "u", "", "Username for authentication")
-	fs.IntVar(pingCount, "c", 5, "Number of PINGREQ probes")
+	fs.IntVar(cli.port, "p", 1883, "Broker port")
+	fs.BoolVar(cli.useTLS, "s", false, "Use TLS (MQTTS)")
+	fs.BoolVar(cli.skipVerify, "k", false, "Skip TLS certificate verification")
+	fs.StringVar(cli.username, "u", "", "Username for authentication")
+	fs.IntVar(cli.pingCount, "c", 5, "Number of PINGREQ probes")
+	fs.IntVar(cli.pingCount, "ping-count", 5, "Number of PINGREQ probes")
 
 	fs.Usage = func() {
-		fmt.Fprintf(os.Stderr, `Usage: nns mqtt [options] <host>
+		fmt.Fprintf(os.Stderr, `Usage: nns mqtt [options] <host>[:port]
 
 Check MQTT broker connectivity, authentication, and latency.
 
@@ -40,23 +77,45 @@ Options:
   --port, -p         Broker port (default: 1883)
   --tls, -s          Use TLS (MQTTS, default port: 8883)
   --insecure, -k     Skip TLS certificate verification
+  --ws               Use MQTT over WebSocket (default port: 8083, 8084 with --tls)
+  --path             WebSocket endpoint path (default: /mqtt)
   --user, -u         Username for authentication
   --pass             Password for authentication
   --client-id        MQTT client ID (default: nns-mqtt-check)
   --timeout          Connection timeout (default: 10s)
-  --pings, -c        Number of PINGREQ probes (default: 5)
+  --ping-count, -c   Number of PINGREQ probes (default: 5; alias: --pings)
+  --topic FILTER     Topic filter to probe; repeatable (default: $SYS/#, #,
+                     test/nns)
+  --publish TOPIC    Publish a test message to TOPIC and confirm the broker
+                     delivers it back (measures publish-to-receive latency)
+  --payload TEXT     Payload for --publish (default: unique test string)
+  --creds            Try built-in default/weak credentials (admin/admin, ...)
+  --creds-file FILE  Try user:pass pairs from FILE (one per line)
+  --creds-delay      Delay between login attempts (default: 500ms)
   --brief            Brief output
+  --json             Output results as JSON
   --help             Show this help message
 
 Examples:
   nns mqtt test.mosquitto.org
-  nns mqtt broker.example.com -p 8883 --tls
+  nns mqtt broker.example.com:8883 --tls
   nns mqtt broker.example.com -u admin --pass secret
+  nns mqtt --ws --tls broker.emqx.io
+  nns mqtt --creds broker.example.com
   nns mqtt broker.example.com --brief
+  nns mqtt --publish nns/test broker.example.com
+  nns mqtt --topic 'sensors/#' --topic 'home/+/status' broker.example.com
+  nns mqtt broker.example.com --json
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runMQTT(args []string) {
+	fs, cli := newMQTTFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -68,23 +127,65 @@ Examples:
 	}
 
 	host := fs.Arg(0)
+	explicitPort := false
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 1 || n > 65535 {
+			fmt.Fprintf(os.Stderr, "Error: invalid port %q\n", p)
+			os.Exit(1)
+		}
+		host, *cli.port, explicitPort = h, n, true
+	}
 
-	// Auto-set TLS port
-	if *useTLS && *port == 1883 {
-		*port = 8883
+	// Auto-set TLS / WebSocket port
+	if *cli.port == 1883 && !explicitPort {
+		switch {
+		case *cli.useWS && *cli.useTLS:
+			*cli.port = 8084
+		case *cli.useWS:
+			*cli.port = 8083
+		case *cli.useTLS:
+			*cli.port = 8883
+		}
+	}
+	transport := mqtt.TransportTCP
+	if *cli.useWS {
+		transport = mqtt.TransportWS
 	}
 
 	opts := mqtt.Options{
 		Host:       host,
-		Port:       *port,
-		UseTLS:     *useTLS,
-		SkipVerify: *skipVerify,
-		Username:   *username,
-		Password:   *password,
-		ClientID:   *clientID,
-		Timeout:    *timeout,
-		PingCount:  *pingCount,
-		Topics:     []string{"$SYS/#", "#", "test/nns"},
+		Port:       *cli.port,
+		UseTLS:     *cli.useTLS,
+		SkipVerify: *cli.skipVerify,
+		Transport:  transport,
+		WSPath:     *cli.wsPath,
+		Username:   *cli.username,
+		Password:   *cli.password,
+		ClientID:   *cli.clientID,
+		Timeout:    *cli.timeout,
+		PingCount:  *cli.pingCount,
+		Topics:     mqtt.DefaultOptions().Topics,
+
+		TestPublish:    *cli.publish != "",
+		PublishTopic:   *cli.publish,
+		PublishPayload: *cli.payload,
+	}
+
+	if *cli.creds {
+		opts.CredentialList = append(opts.CredentialList, mqtt.DefaultCredentials...)
+	}
+	if *cli.credsFile != "" {
+		list, err := readMQTTCredentials(*cli.credsFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts.CredentialList = append(opts.CredentialList, list...)
+	}
+	opts.CredentialDelay = *cli.credsDelay
+	if len(cli.topics) > 0 {
+		opts.Topics = cli.topics
 	}
 
 	checker := mqtt.NewChecker(opts)
@@ -100,10 +201,13 @@ Examples:
 	}()
 
 	proto := "MQTT"
-	if *useTLS {
+	if *cli.useTLS {
 		proto = "MQTTS"
 	}
-	fmt.Printf("Checking %s broker %s:%d...\n", proto, host, *port)
+	if *cli.useWS {
+		proto += " over WebSocket"
+	}
+	fmt.Fprintf(os.Stderr, "Checking %s broker %s...\n", proto, net.JoinHostPort(host, strconv.Itoa(*cli.port)))
 
 	result, err := checker.Check(ctx)
 	if err != nil {
@@ -111,9 +215,40 @@ Examples:
 		os.Exit(1)
 	}
 
-	if *brief {
+	switch {
+	case *cli.jsonOut:
+		out, err := result.ToJSON()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(out)
+	case *cli.brief:
 		fmt.Println(result.FormatCompact())
-	} else {
+	default:
 		fmt.Print(result.Format())
 	}
 }
+
+// readMQTTCredentials reads user:pass pairs, one per line. Blank lines and
+// lines starting with # are skipped; a line without ':' is a username with
+// an empty password.
+func readMQTTCredentials(path string) ([]mqtt.Credential, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var list []mqtt.Credential
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		user, pass, _ := strings.Cut(line, ":")
+		list = append(list, mqtt.Credential{Username: user, Password: pass})
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("no credentials in %s", path)
+	}
+	return list, nil
+}
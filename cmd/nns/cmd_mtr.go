@@ -10,17 +10,28 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/mtr"
 )
 
-func runMTR(args []string) {
+// mtrFlags holds the flags of the mtr command.
+type mtrFlags struct {
+	countFlag     *int
+	maxHopsFlag   *int
+	timeoutFlag   *time.Duration
+	intervalFlag  *time.Duration
+	noResolveFlag *bool
+}
+
+// newMTRFlags defines the flags of the mtr command.
+func newMTRFlags() (*flag.FlagSet, *mtrFlags) {
 	fs := flag.NewFlagSet("mtr", flag.ExitOnError)
+	cli := &mtrFlags{}
 
-	countFlag := fs.Int("count", 10, "Number of cycles to run")
-	maxHopsFlag := fs.Int("max-hops", 30, "Maximum hops")
-	timeoutFlag := fs.Duration("timeout", 2*time.Second, "Timeout per probe")
-	intervalFlag := fs.Duration("interval", 1*time.Second, "Time between cycles")
-	noResolveFlag := fs.Bool("no-resolve", false, "Don't resolve hostnames")
+	cli.countFlag = fs.Int("count", 10, "Number of cycles to run")
+	cli.maxHopsFlag = fs.Int("max-hops", 30, "Maximum hops")
+	cli.timeoutFlag = fs.Duration("timeout", 2*time.Second, "Timeout per probe")
+	cli.intervalFlag = fs.Duration("interval", 1*time.Second, "Time between cycles")
+	cli.noResolveFlag = fs.Bool("no-resolve", false, "Don't resolve hostnames")
 
-	fs.IntVar(countFlag, "c", 10, "Number of cycles")
-	fs.IntVar(maxHopsFlag, "m", 30, "Maximum hops")
+	fs.IntVar(cli.countFlag, "c", 10, "Number of cycles")
+	fs.IntVar(cli.maxHopsFlag, "m", 30, "Maximum hops")
 
 	fs.Usage = func() {
 		fmt.Println(`Usage: nns mtr [HOST] [OPTIONS]
@@ -42,7 +53,13 @@ EXAMPLES:
   nns mtr --no-resolve 8.8.8.8`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runMTR(args []string) {
+	fs, cli := newMTRFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		os.Exit(1)
 	}
 
@@ -56,11 +73,11 @@ EXAMPLES:
 
 	cfg := mtr.Config{
 		Target:      target,
-		MaxHops:     *maxHopsFlag,
-		Timeout:     *timeoutFlag,
-		Interval:    *intervalFlag,
-		Count:       *countFlag,
-		ResolveHost: !*noResolveFlag,
+		MaxHops:     *cli.maxHopsFlag,
+		Timeout:     *cli.timeoutFlag,
+		Interval:    *cli.intervalFlag,
+		Count:       *cli.countFlag,
+		ResolveHost: !*cli.noResolveFlag,
 	}
 
 	m := mtr.New(cfg)
@@ -13,63 +13,127 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/neighbors"
 )
 
-func runNeighbors(args []string) {
+// neighborsFlags holds the flags of the neighbors command.
+type neighborsFlags struct {
+	timeout      *time.Duration
+	iface        *string
+	services     *string
+	serviceNames stringList
+	jsonOut      *bool
+	brief        *bool
+	watch        *bool
+	ssdp         *bool
+	ipv6         *bool
+}
+
+// newNeighborsFlags defines the flags of the neighbors command.
+func newNeighborsFlags() (*flag.FlagSet, *neighborsFlags) {
 	fs := flag.NewFlagSet("neighbors", flag.ExitOnError)
-	timeout := fs.Duration("timeout", 5*time.Second, "Discovery timeout")
-	iface := fs.String("iface", "", "Network interface to use")
-	services := fs.String("services", "", "Comma-separated service types to query")
-	brief := fs.Bool("brief", false, "Brief output")
+	cli := &neighborsFlags{}
+	cli.timeout = fs.Duration("timeout", 5*time.Second, "Discovery timeout")
+	cli.iface = fs.String("iface", "", "Network interface to use")
+	cli.services = fs.String("services", "", "Comma-separated service types to query")
+	fs.Var(&cli.serviceNames, "service", "Service name or type to query (repeatable)")
+	cli.jsonOut = fs.Bool("json", false, "Output results as JSON")
+	cli.brief = fs.Bool("brief", false, "Brief output")
+	cli.watch = fs.Bool("watch", false, "Keep listening and print neighbors as they appear")
+	cli.ssdp = fs.Bool("ssdp", false, "Also discover UPnP devices via SSDP")
+	cli.ipv6 = fs.Bool("ipv6", false, "Also discover over IPv6 mDNS (ff02::fb)")
 
 	// Short flags
-	fs.DurationVar(timeout, "t", 5*time.Second, "Discovery timeout")
-	fs.StringVar(iface, "i", "", "Network interface")
+	fs.DurationVar(cli.timeout, "t", 5*time.Second, "Discovery timeout")
+	fs.StringVar(cli.iface, "i", "", "Network interface")
+	fs.StringVar(cli.iface, "interface", "", "Network interface to use")
+	fs.BoolVar(cli.ipv6, "6", false, "Also discover over IPv6 mDNS")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns neighbors [options]
+       nns discover [options]
 
 Discover network neighbors via mDNS/DNS-SD (Bonjour).
 Finds local devices advertising services on the network.
+With --ssdp, UPnP devices (routers, smart TVs, media servers)
+are found as well.
 
-Built-in service types:
-  HTTP, HTTPS, SSH, SMB, FTP, Printer, AirPlay,
-  Chromecast, Spotify, HomeKit, MQTT, and more.
-
+Service types (for --service):
+`)
+		for _, svc := range neighbors.CommonServices() {
+			fmt.Fprintf(os.Stderr, "  %-12s %-32s %s\n", strings.ToLower(svc.Name), svc.Type, svc.Description)
+		}
+		fmt.Fprintf(os.Stderr, `
 Options:
   --timeout, -t    Discovery timeout (default: 5s)
-  --iface, -i      Network interface to use
-  --services       Comma-separated service types to query
+  --interface, -i  Network interface to use (alias: --iface)
+  --service NAME   Only query this service; a name from the list above or
+                   a DNS-SD type such as _ssh._tcp (repeatable)
+  --services       Comma-separated service types or names to query
                    (e.g. _http._tcp.local.,_ssh._tcp.local.)
+  --watch          Keep listening until Ctrl+C, printing neighbors live
+  --ssdp           Also send an SSDP M-SEARCH for UPnP devices
+  --ipv6, -6       Also query the IPv6 mDNS group (ff02::fb)
   --brief          Brief output
+  --json           Output results as JSON
   --help           Show this help message
 
 Examples:
   nns neighbors
   nns neighbors -t 10s
   nns neighbors -i eth0
+  nns neighbors -6 -i eth0
+  nns neighbors --watch
+  nns neighbors --ssdp -t 10s
   nns neighbors --services _http._tcp.local.,_ssh._tcp.local.
   nns neighbors --brief
+  nns discover --service printer --service http
+  nns discover --interface eth0 --ipv6 --json
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runNeighbors(args []string) {
+	fs, cli := newNeighborsFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
 	opts := neighbors.DefaultOptions()
-	opts.Timeout = *timeout
-	opts.Interface = *iface
-
-	if *services != "" {
-		types := strings.Split(*services, ",")
-		cleaned := make([]string, 0, len(types))
-		for _, t := range types {
-			t = strings.TrimSpace(t)
-			if t != "" {
-				cleaned = append(cleaned, t)
+	opts.Timeout = *cli.timeout
+	opts.Interface = *cli.iface
+	opts.UseIPv6 = *cli.ipv6
+	opts.Watch = *cli.watch
+	opts.SSDP = *cli.ssdp
+	if *cli.watch {
+		opts.OnDiscover = func(n neighbors.Neighbor) {
+			line := fmt.Sprintf("[%s] %-30s %s", n.FirstSeen.Format("15:04:05"), n.Hostname, n.Source)
+			if len(n.Addresses) > 0 {
+				line += "  " + strings.Join(n.Addresses, ", ")
 			}
+			fmt.Println(line)
 		}
-		opts.ServiceTypes = cleaned
+	}
+
+	var requested []string
+	for _, t := range strings.Split(*cli.services, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			requested = append(requested, t)
+		}
+	}
+	requested = append(requested, cli.serviceNames...)
+	if len(requested) > 0 {
+		types := make([]string, 0, len(requested))
+		for _, name := range requested {
+			t, ok := neighbors.ResolveServiceType(name)
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Error: unknown service %q (see nns discover --help)\n", name)
+				os.Exit(1)
+			}
+			types = append(types, t)
+		}
+		opts.ServiceTypes = types
 	}
 
 	scanner := neighbors.NewScanner(opts)
@@ -81,11 +145,15 @@ Examples:
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-sigChan
-		fmt.Println("\nStopping discovery...")
+		fmt.Fprintln(os.Stderr, "\nStopping discovery...")
 		cancel()
 	}()
 
-	fmt.Printf("Discovering neighbors via mDNS/DNS-SD (timeout: %v)...\n", *timeout)
+	if *cli.watch {
+		fmt.Println("Watching for neighbors via mDNS/DNS-SD (Ctrl+C to stop)...")
+	} else {
+		fmt.Fprintf(os.Stderr, "Discovering neighbors via mDNS/DNS-SD (timeout: %v)...\n", *cli.timeout)
+	}
 
 	result, err := scanner.Discover(ctx)
 	if err != nil {
@@ -93,9 +161,17 @@ Examples:
 		os.Exit(1)
 	}
 
-	if *brief {
+	switch {
+	case *cli.jsonOut:
+		out, err := result.ToJSON()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(out)
+	case *cli.brief || *cli.watch:
 		fmt.Println(result.FormatCompact())
-	} else {
+	default:
 		fmt.Print(result.Format())
 	}
 }
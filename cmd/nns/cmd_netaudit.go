@@ -6,32 +6,69 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/JedizLaPulga/NNS/internal/netaudit"
 )
 
-func runNetaudit(args []string) {
+// netauditFlags holds the flags of the netaudit command.
+type netauditFlags struct {
+	timeout     *time.Duration
+	concurrency *int
+	hosts       *int
+	brief       *bool
+	noDNS       *bool
+	noTelnet    *bool
+	noBanners   *bool
+	noSNMP      *bool
+	noSSH       *bool
+	noHTTP      *bool
+	noTLS       *bool
+	noPorts     *bool
+	noRedis     *bool
+	noMongo     *bool
+	noFTP       *bool
+	noRelay     *bool
+	jsonOut     *bool
+	sarifOut    *bool
+}
+
+// newNetauditFlags defines the flags of the netaudit command.
+func newNetauditFlags() (*flag.FlagSet, *netauditFlags) {
 	fs := flag.NewFlagSet("netaudit", flag.ExitOnError)
-	timeout := fs.Duration("timeout", 5*time.Second, "Check timeout")
-	concurrency := fs.Int("concurrency", 10, "Parallel checks")
-	brief := fs.Bool("brief", false, "Brief output")
-	noDNS := fs.Bool("no-dns", false, "Skip DNS resolver check")
-	noSNMP := fs.Bool("no-snmp", false, "Skip SNMP check")
-	noSSH := fs.Bool("no-ssh", false, "Skip SSH check")
-	noHTTP := fs.Bool("no-http", false, "Skip HTTP check")
-	noTLS := fs.Bool("no-tls", false, "Skip TLS check")
-	noPorts := fs.Bool("no-ports", false, "Skip port scan")
+	cli := &netauditFlags{}
+	cli.timeout = fs.Duration("timeout", 5*time.Second, "Check timeout")
+	cli.concurrency = fs.Int("concurrency", 10, "Parallel checks")
+	cli.hosts = fs.Int("hosts", 4, "Hosts audited in parallel for a CIDR target")
+	cli.brief = fs.Bool("brief", false, "Brief output")
+	cli.noDNS = fs.Bool("no-dns", false, "Skip DNS resolver check")
+	cli.noTelnet = fs.Bool("no-telnet", false, "Skip Telnet exposure check")
+	cli.noBanners = fs.Bool("no-banners", false, "Skip service banner leakage check")
+	cli.noSNMP = fs.Bool("no-snmp", false, "Skip SNMP check")
+	cli.noSSH = fs.Bool("no-ssh", false, "Skip SSH check")
+	cli.noHTTP = fs.Bool("no-http", false, "Skip HTTP check")
+	cli.noTLS = fs.Bool("no-tls", false, "Skip TLS check")
+	cli.noPorts = fs.Bool("no-ports", false, "Skip port scan")
+	cli.noRedis = fs.Bool("no-redis", false, "Skip unauthenticated Redis check")
+	cli.noMongo = fs.Bool("no-mongo", false, "Skip unauthenticated MongoDB check")
+	cli.noFTP = fs.Bool("no-ftp", false, "Skip anonymous FTP check")
+	cli.noRelay = fs.Bool("no-relay", false, "Skip SMTP open relay check")
+	cli.jsonOut = fs.Bool("json", false, "Output as JSON")
+	cli.sarifOut = fs.Bool("sarif", false, "Output as SARIF 2.1.0")
 
 	// Short flags
-	fs.DurationVar(timeout, "t", 5*time.Second, "Check timeout")
-	fs.IntVar(concurrency, "c", 10, "Parallel checks")
+	fs.DurationVar(cli.timeout, "t", 5*time.Second, "Check timeout")
+	fs.IntVar(cli.concurrency, "c", 10, "Parallel checks")
+	fs.IntVar(cli.concurrency, "concurrent", 10, "Parallel checks")
 
 	fs.Usage = func() {
-		fmt.Fprintf(os.Stderr, `Usage: nns netaudit [options] <host>
+		fmt.Fprintf(os.Stderr, `Usage: nns netaudit [options] <host|CIDR>
+       nns audit [options] <host|CIDR>
 
 Perform a network security audit checking for common misconfigurations.
+A CIDR target audits every host in the network.
 
 Checks:
   - Open DNS resolver (DDoS amplification risk)
@@ -42,28 +79,49 @@ Checks:
   - TLS configuration and certificate
   - Open/dangerous ports
   - Service banner leakage
+  - Redis and MongoDB reachable without authentication
+  - Anonymous FTP login
+  - SMTP open relay (stops before DATA; no mail is sent)
 
 Options:
   --timeout, -t      Check timeout (default: 5s)
-  --concurrency, -c  Parallel checks (default: 10)
+  --concurrency, -c  Parallel checks per host (default: 10; alias --concurrent)
+  --hosts            Hosts audited in parallel for a CIDR (default: 4)
   --brief            Brief output
   --no-dns           Skip DNS resolver check
+  --no-telnet        Skip Telnet exposure check
+  --no-banners       Skip service banner leakage check
   --no-snmp          Skip SNMP check
   --no-ssh           Skip SSH check
   --no-http          Skip HTTP check
   --no-tls           Skip TLS check
   --no-ports         Skip port scan
+  --no-redis         Skip unauthenticated Redis check
+  --no-mongo         Skip unauthenticated MongoDB check
+  --no-ftp           Skip anonymous FTP check
+  --no-relay         Skip SMTP open relay check
+  --json             Output results as JSON
+  --sarif            Output results as SARIF 2.1.0 (GitHub code scanning)
   --help             Show this help message
 
 Examples:
   nns netaudit 192.168.1.1
   nns netaudit example.com
   nns netaudit 10.0.0.1 --no-dns --no-snmp
+  nns audit example.com --json
   nns netaudit router.local --brief
+  nns netaudit 10.0.0.1 --sarif > netaudit.sarif
+  nns netaudit --hosts 16 --no-snmp 192.168.1.0/24
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runNetaudit(args []string) {
+	fs, cli := newNetauditFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -76,17 +134,29 @@ Examples:
 
 	target := fs.Arg(0)
 
+	if *cli.jsonOut && *cli.sarifOut {
+		fmt.Fprintf(os.Stderr, "Error: --json and --sarif are mutually exclusive\n")
+		os.Exit(1)
+	}
+	structured := *cli.jsonOut || *cli.sarifOut
+
 	opts := netaudit.DefaultOptions()
 	opts.Target = target
-	opts.Timeout = *timeout
-	opts.Concurrency = *concurrency
-	opts.CheckDNS = !*noDNS
-	opts.CheckSNMP = !*noSNMP
-	opts.CheckSSH = !*noSSH
-	opts.CheckTelnet = true
-	opts.CheckHTTP = !*noHTTP
-	opts.CheckTLS = !*noTLS
-	opts.CheckPorts = !*noPorts
+	opts.Timeout = *cli.timeout
+	opts.Concurrency = *cli.concurrency
+	opts.HostConcurrency = *cli.hosts
+	opts.CheckDNS = !*cli.noDNS
+	opts.CheckSNMP = !*cli.noSNMP
+	opts.CheckSSH = !*cli.noSSH
+	opts.CheckTelnet = !*cli.noTelnet
+	opts.CheckBanners = !*cli.noBanners
+	opts.CheckHTTP = !*cli.noHTTP
+	opts.CheckTLS = !*cli.noTLS
+	opts.CheckPorts = !*cli.noPorts
+	opts.CheckRedis = !*cli.noRedis
+	opts.CheckMongoDB = !*cli.noMongo
+	opts.CheckFTP = !*cli.noFTP
+	opts.CheckSMTPRelay = !*cli.noRelay
 
 	auditor := netaudit.NewAuditor(opts)
 
@@ -101,7 +171,16 @@ Examples:
 		cancel()
 	}()
 
-	fmt.Printf("Auditing %s...\n", target)
+	if strings.Contains(target, "/") {
+		runNetauditNetwork(ctx, auditor, target, *cli.jsonOut, *cli.sarifOut)
+		return
+	}
+
+	if structured {
+		fmt.Fprintf(os.Stderr, "Auditing %s...\n", target)
+	} else {
+		fmt.Printf("Auditing %s...\n", target)
+	}
 
 	result, err := auditor.Audit(ctx)
 	if err != nil {
@@ -109,9 +188,22 @@ Examples:
 		os.Exit(1)
 	}
 
-	if *brief {
+	switch {
+	case structured:
+		var out string
+		if *cli.sarifOut {
+			out, err = result.ToSARIF()
+		} else {
+			out, err = result.ToJSON()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(out)
+	case *cli.brief:
 		fmt.Println(result.FormatCompact())
-	} else {
+	default:
 		fmt.Print(result.Format())
 	}
 
@@ -122,3 +214,44 @@ Examples:
 		os.Exit(1)
 	}
 }
+
+// runNetauditNetwork audits every host in a CIDR and prints per-host grades
+// plus an aggregate summary.
+func runNetauditNetwork(ctx context.Context, auditor *netaudit.Auditor, cidr string, jsonOut, sarifOut bool) {
+	fmt.Fprintf(os.Stderr, "Auditing %s...\n", cidr)
+
+	results, err := auditor.AuditNetwork(ctx, cidr)
+	if err != nil && len(results) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	summary := netaudit.SummarizeNetwork(results)
+
+	switch {
+	case jsonOut || sarifOut:
+		var out string
+		var ferr error
+		if sarifOut {
+			out, ferr = netaudit.SARIF(results)
+		} else {
+			out, ferr = netaudit.NetworkToJSON(results, summary)
+		}
+		if ferr != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", ferr)
+			os.Exit(1)
+		}
+		fmt.Println(out)
+	default:
+		fmt.Print(netaudit.FormatNetwork(results, summary))
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Audit interrupted after %d hosts\n", len(results))
+	}
+
+	if summary.Critical > 0 {
+		os.Exit(2)
+	} else if summary.High > 0 {
+		os.Exit(1)
+	}
+}
@@ -8,15 +8,25 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/netcalc"
 )
 
-func runNetcalc(args []string) {
+// netcalcFlags holds the flags of the netcalc command.
+type netcalcFlags struct {
+	add      *int64
+	rangeEnd *string
+	maxRange *int
+	binary   *bool
+}
+
+// newNetcalcFlags defines the flags of the netcalc command.
+func newNetcalcFlags() (*flag.FlagSet, *netcalcFlags) {
 	fs := flag.NewFlagSet("netcalc", flag.ExitOnError)
-	add := fs.Int64("add", 0, "Add offset to IP address")
-	rangeEnd := fs.String("range", "", "End IP for range listing")
-	maxRange := fs.Int("max", 256, "Maximum IPs to list in range mode")
-	binary := fs.Bool("binary", false, "Show binary representation of IP")
+	cli := &netcalcFlags{}
+	cli.add = fs.Int64("add", 0, "Add offset to IP address")
+	cli.rangeEnd = fs.String("range", "", "End IP for range listing")
+	cli.maxRange = fs.Int("max", 256, "Maximum IPs to list in range mode")
+	cli.binary = fs.Bool("binary", false, "Show binary representation of IP")
 
 	// Short flags
-	fs.BoolVar(binary, "b", false, "Binary mode")
+	fs.BoolVar(cli.binary, "b", false, "Binary mode")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns netcalc [options] <cidr|ip>
@@ -39,7 +49,13 @@ Examples:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runNetcalc(args []string) {
+	fs, cli := newNetcalcFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -53,7 +69,7 @@ Examples:
 	target := fs.Arg(0)
 
 	// Mode: binary
-	if *binary {
+	if *cli.binary {
 		bin, err := netcalc.IPToBinary(target)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -66,27 +82,27 @@ Examples:
 	}
 
 	// Mode: add offset
-	if *add != 0 {
-		result, err := netcalc.AddToIP(target, *add)
+	if *cli.add != 0 {
+		result, err := netcalc.AddToIP(target, *cli.add)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 		fmt.Printf("IP ARITHMETIC\n\n")
 		fmt.Printf("  Base IP:  %s\n", target)
-		fmt.Printf("  Offset:   %+d\n", *add)
+		fmt.Printf("  Offset:   %+d\n", *cli.add)
 		fmt.Printf("  Result:   %s\n", result)
 		return
 	}
 
 	// Mode: range
-	if *rangeEnd != "" {
-		ips, err := netcalc.IPRange(target, *rangeEnd, *maxRange)
+	if *cli.rangeEnd != "" {
+		ips, err := netcalc.IPRange(target, *cli.rangeEnd, *cli.maxRange)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Printf("IP RANGE %s → %s\n\n", target, *rangeEnd)
+		fmt.Printf("IP RANGE %s → %s\n\n", target, *cli.rangeEnd)
 		fmt.Printf("  Count: %d\n\n", len(ips))
 		for _, ip := range ips {
 			fmt.Printf("  %s\n", ip)
@@ -12,13 +12,24 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/netpath"
 )
 
-func runNetpath(args []string) {
+// netpathFlags holds the flags of the netpath command.
+type netpathFlags struct {
+	maxHops *int
+	probes  *int
+	timeout *time.Duration
+	resolve *bool
+	worst   *int
+}
+
+// newNetpathFlags defines the flags of the netpath command.
+func newNetpathFlags() (*flag.FlagSet, *netpathFlags) {
 	fs := flag.NewFlagSet("netpath", flag.ExitOnError)
-	maxHops := fs.Int("max-hops", 30, "Maximum number of hops")
-	probes := fs.Int("probes", 5, "Number of probes per hop")
-	timeout := fs.Duration("timeout", 2*time.Second, "Timeout per probe")
-	resolve := fs.Bool("resolve", true, "Resolve hostnames")
-	worst := fs.Int("worst", 0, "Show N worst quality hops")
+	cli := &netpathFlags{}
+	cli.maxHops = fs.Int("max-hops", 30, "Maximum number of hops")
+	cli.probes = fs.Int("probes", 5, "Number of probes per hop")
+	cli.timeout = fs.Duration("timeout", 2*time.Second, "Timeout per probe")
+	cli.resolve = fs.Bool("resolve", true, "Resolve hostnames")
+	cli.worst = fs.Int("worst", 0, "Show N worst quality hops")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns netpath [OPTIONS] <target>
@@ -36,7 +47,13 @@ EXAMPLES:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runNetpath(args []string) {
+	fs, cli := newNetpathFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		return
 	}
 
@@ -48,10 +65,10 @@ EXAMPLES:
 	target := fs.Arg(0)
 
 	opts := netpath.Options{
-		MaxHops:      *maxHops,
-		ProbesPerHop: *probes,
-		Timeout:      *timeout,
-		ResolveHosts: *resolve,
+		MaxHops:      *cli.maxHops,
+		ProbesPerHop: *cli.probes,
+		Timeout:      *cli.timeout,
+		ResolveHosts: *cli.resolve,
 	}
 
 	analyzer := netpath.NewAnalyzer(opts)
@@ -78,9 +95,9 @@ EXAMPLES:
 
 	fmt.Print(result.Format())
 
-	if *worst > 0 {
-		fmt.Printf("\nWorst %d hops:\n", *worst)
-		worstHops := result.GetWorstHops(*worst)
+	if *cli.worst > 0 {
+		fmt.Printf("\nWorst %d hops:\n", *cli.worst)
+		worstHops := result.GetWorstHops(*cli.worst)
 		for _, hop := range worstHops {
 			host := "*"
 			if hop.IP != nil {
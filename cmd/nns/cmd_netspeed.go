@@ -12,13 +12,24 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/netspeed"
 )
 
-func runNetspeed(args []string) {
+// netspeedFlags holds the flags of the netspeed command.
+type netspeedFlags struct {
+	serverMode    *bool
+	port          *int
+	duration      *time.Duration
+	connections   *int
+	bidirectional *bool
+}
+
+// newNetspeedFlags defines the flags of the netspeed command.
+func newNetspeedFlags() (*flag.FlagSet, *netspeedFlags) {
 	fs := flag.NewFlagSet("netspeed", flag.ExitOnError)
-	serverMode := fs.Bool("server", false, "Run in server mode")
-	port := fs.Int("port", 5201, "Port for server/client")
-	duration := fs.Duration("duration", 10*time.Second, "Test duration")
-	connections := fs.Int("connections", 1, "Number of parallel connections")
-	bidirectional := fs.Bool("bidir", false, "Bidirectional test")
+	cli := &netspeedFlags{}
+	cli.serverMode = fs.Bool("server", false, "Run in server mode")
+	cli.port = fs.Int("port", 5201, "Port for server/client")
+	cli.duration = fs.Duration("duration", 10*time.Second, "Test duration")
+	cli.connections = fs.Int("connections", 1, "Number of parallel connections")
+	cli.bidirectional = fs.Bool("bidir", false, "Bidirectional test")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns netspeed [OPTIONS] [host]
@@ -51,7 +62,13 @@ EXAMPLES:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runNetspeed(args []string) {
+	fs, cli := newNetspeedFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		return
 	}
 
@@ -67,11 +84,11 @@ EXAMPLES:
 		cancel()
 	}()
 
-	if *serverMode {
+	if *cli.serverMode {
 		// Server mode
 		cfg := netspeed.Config{
-			Port:          *port,
-			Bidirectional: *bidirectional,
+			Port:          *cli.port,
+			Bidirectional: *cli.bidirectional,
 		}
 		server := netspeed.NewServer(cfg)
 
@@ -104,15 +121,15 @@ EXAMPLES:
 		host := fs.Arg(0)
 
 		cfg := netspeed.Config{
-			Port:          *port,
-			Duration:      *duration,
-			Connections:   *connections,
-			Bidirectional: *bidirectional,
+			Port:          *cli.port,
+			Duration:      *cli.duration,
+			Connections:   *cli.connections,
+			Bidirectional: *cli.bidirectional,
 		}
 		client := netspeed.NewClient(cfg)
 
-		fmt.Printf("Connecting to %s:%d...\n", host, *port)
-		fmt.Printf("Duration: %v, Connections: %d\n\n", *duration, *connections)
+		fmt.Printf("Connecting to %s:%d...\n", host, *cli.port)
+		fmt.Printf("Duration: %v, Connections: %d\n\n", *cli.duration, *cli.connections)
 
 		result, err := client.Test(ctx, host)
 		if err != nil {
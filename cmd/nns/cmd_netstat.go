@@ -1,30 +1,63 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"sort"
+	"time"
 
+	"github.com/JedizLaPulga/NNS/internal/bwmon"
 	"github.com/JedizLaPulga/NNS/internal/netstat"
 )
 
-func runNetstat(args []string) {
+// netstatFlags holds the flags of the netstat command.
+type netstatFlags struct {
+	tcpFlag      *bool
+	udpFlag      *bool
+	listenFlag   *bool
+	pidFlag      *bool
+	routingFlag  *bool
+	portFlag     *int
+	addrFlag     *string
+	stateFlag    *string
+	ifacesFlag   *bool
+	watchFlag    *bool
+	summaryFlag  *bool
+	intervalFlag *time.Duration
+}
+
+// newNetstatFlags defines the flags of the netstat command.
+func newNetstatFlags() (*flag.FlagSet, *netstatFlags) {
 	fs := flag.NewFlagSet("netstat", flag.ExitOnError)
+	cli := &netstatFlags{}
 
-	tcpFlag := fs.Bool("tcp", false, "Show TCP only")
-	udpFlag := fs.Bool("udp", false, "Show UDP only")
-	listenFlag := fs.Bool("listen", false, "Show listening only")
+	cli.tcpFlag = fs.Bool("tcp", false, "Show TCP only")
+	cli.udpFlag = fs.Bool("udp", false, "Show UDP only")
+	cli.listenFlag = fs.Bool("listen", false, "Show listening only")
 	allFlag := fs.Bool("all", false, "Show all connections")
-	pidFlag := fs.Bool("pid", false, "Show process IDs (requires admin)")
-	routingFlag := fs.Bool("routing", false, "Show routing table")
+	cli.pidFlag = fs.Bool("pid", false, "Show owning process ID and name (requires admin)")
+	cli.routingFlag = fs.Bool("routing", false, "Show routing table")
+	cli.portFlag = fs.Int("port", 0, "Only connections with this local or remote port")
+	cli.addrFlag = fs.String("addr", "", "Only connections with this local or remote IP/CIDR")
+	cli.stateFlag = fs.String("state", "", "Only connections in this state")
+	cli.ifacesFlag = fs.Bool("interfaces", false, "Show interface statistics")
+	cli.watchFlag = fs.Bool("watch", false, "Refresh continuously")
+	cli.summaryFlag = fs.Bool("summary", false, "Show connection counts per state")
+	cli.intervalFlag = fs.Duration("interval", time.Second, "Refresh interval for --watch, sampling interval for --interfaces")
 
 	// Short flags
-	fs.BoolVar(tcpFlag, "t", false, "TCP only")
-	fs.BoolVar(udpFlag, "u", false, "UDP only")
-	fs.BoolVar(listenFlag, "l", false, "Listening only")
+	fs.BoolVar(cli.tcpFlag, "t", false, "TCP only")
+	fs.BoolVar(cli.udpFlag, "u", false, "UDP only")
+	fs.BoolVar(cli.listenFlag, "l", false, "Listening only")
 	fs.BoolVar(allFlag, "a", false, "All connections")
-	fs.BoolVar(pidFlag, "p", false, "Show PIDs")
-	fs.BoolVar(routingFlag, "r", false, "Routing table")
+	fs.BoolVar(cli.pidFlag, "p", false, "Show PIDs")
+	fs.BoolVar(cli.routingFlag, "r", false, "Routing table")
+	fs.BoolVar(cli.ifacesFlag, "i", false, "Interface statistics")
+	fs.BoolVar(cli.watchFlag, "w", false, "Watch")
+	fs.BoolVar(cli.summaryFlag, "s", false, "State summary")
 
 	fs.Usage = func() {
 		fmt.Println(`Usage: nns netstat [OPTIONS]
@@ -36,23 +69,42 @@ OPTIONS:
   -u, --udp       Show UDP connections only
   -l, --listen    Show listening ports only
   -a, --all       Show all connections
-  -p, --pid       Show process IDs (requires admin)
+  -p, --pid       Show owning PID and process name (requires admin)
+      --port N    Only connections with local or remote port N
+      --addr IP   Only connections with local or remote IP (or CIDR)
+      --state S   Only connections in state S (e.g. ESTABLISHED, TIME_WAIT)
   -r, --routing   Show routing table instead of connections
+  -i, --interfaces  Show per-interface traffic counters and throughput
+  -w, --watch     Refresh continuously until Ctrl+C
+  -s, --summary   Show connection counts per state instead of the full list
+      --interval  Refresh interval for --watch and sampling interval for
+                  --interfaces (default: 1s)
       --help      Show this help message
 
 EXAMPLES:
   nns netstat
   nns netstat --listen
   nns netstat --tcp --pid
-  nns netstat --routing`)
+  nns netstat --port 5432 --state established
+  nns netstat --addr 10.0.0.0/8
+  nns netstat --routing
+  nns netstat --interfaces --interval 5s
+  nns netstat --summary --watch --interval 2s
+  nns netstat --watch --port 443 --state time_wait`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runNetstat(args []string) {
+	fs, cli := newNetstatFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		os.Exit(1)
 	}
 
 	// Show routing table
-	if *routingFlag {
+	if *cli.routingFlag {
 		routes, err := netstat.GetRoutingTable()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -74,32 +126,126 @@ EXAMPLES:
 		return
 	}
 
-	// Show connections
-	conns, err := netstat.GetConnections(*pidFlag)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	if *cli.ifacesFlag {
+		printInterfaceStats(*cli.intervalFlag)
+		return
+	}
+
+	filter := netstatFilter{
+		tcp:    *cli.tcpFlag,
+		udp:    *cli.udpFlag,
+		listen: *cli.listenFlag,
+		port:   *cli.portFlag,
+		addr:   *cli.addrFlag,
+		state:  *cli.stateFlag,
+	}
+	if filter.port != 0 && (filter.port < 1 || filter.port > 65535) {
+		fmt.Fprintf(os.Stderr, "Error: invalid port %d\n", filter.port)
 		os.Exit(1)
 	}
 
-	// Apply filters
-	if *tcpFlag {
+	if !*cli.watchFlag {
+		if _, err := showConnections(filter, *cli.pidFlag, *cli.summaryFlag, nil); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Handle Ctrl+C
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	go func() {
+		<-sigCh
+		cancel()
+	}()
+
+	ticker := time.NewTicker(*cli.intervalFlag)
+	defer ticker.Stop()
+
+	var prev map[string]int
+	for {
+		fmt.Print("\033[H\033[2J")
+		fmt.Printf("Every %v: nns netstat    %s    (Ctrl+C to stop)\n\n", *cli.intervalFlag, time.Now().Format("15:04:05"))
+		counts, err := showConnections(filter, *cli.pidFlag, *cli.summaryFlag, prev)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		} else {
+			prev = counts
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Println()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// netstatFilter holds the connection filters; all set filters must match.
+type netstatFilter struct {
+	tcp, udp, listen bool
+	port             int
+	addr, state      string
+}
+
+// apply narrows conns down to the connections matching every filter.
+func (f netstatFilter) apply(conns []netstat.Connection) ([]netstat.Connection, error) {
+	if f.tcp {
 		conns = netstat.FilterByProtocol(conns, "tcp")
-	} else if *udpFlag {
+	} else if f.udp {
 		conns = netstat.FilterByProtocol(conns, "udp")
 	}
-
-	if *listenFlag {
+	if f.listen {
 		conns = netstat.GetListening(conns)
 	}
+	if f.port != 0 {
+		conns = netstat.FilterByPort(conns, f.port)
+	}
+	if f.addr != "" {
+		var err error
+		conns, err = netstat.FilterByAddr(conns, f.addr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if f.state != "" {
+		conns = netstat.FilterByState(conns, f.state)
+	}
+	return conns, nil
+}
+
+// showConnections fetches, filters and prints connections, either in full
+// or as per-state counts, and returns the state counts. prev holds the
+// previous refresh's counts for the summary's CHANGE column.
+func showConnections(filter netstatFilter, showPID, summary bool, prev map[string]int) (map[string]int, error) {
+	conns, err := netstat.GetConnections(showPID)
+	if err != nil {
+		return nil, err
+	}
+	conns, err = filter.apply(conns)
+	if err != nil {
+		return nil, err
+	}
+
+	states := netstat.SummarizeStates(conns)
+	if summary {
+		printStateSummary(states, prev, len(conns))
+		return states, nil
+	}
 
 	if len(conns) == 0 {
 		fmt.Println("No connections found")
-		return
+		return states, nil
 	}
 
 	// Print header
-	if *pidFlag {
-		fmt.Printf("%-8s %-25s %-25s %-15s %s\n", "PROTO", "LOCAL", "REMOTE", "STATE", "PID")
+	if showPID {
+		fmt.Printf("%-8s %-25s %-25s %-15s %-8s %s\n", "PROTO", "LOCAL", "REMOTE", "STATE", "PID", "PROCESS")
 	} else {
 		fmt.Printf("%-8s %-25s %-25s %s\n", "PROTO", "LOCAL", "REMOTE", "STATE")
 	}
@@ -117,12 +263,102 @@ EXAMPLES:
 			state = "-"
 		}
 
-		if *pidFlag {
-			fmt.Printf("%-8s %-25s %-25s %-15s %d\n", c.Protocol, local, remote, state, c.PID)
+		if showPID {
+			pid, process := "-", c.Process
+			if c.PID > 0 {
+				pid = fmt.Sprintf("%d", c.PID)
+			}
+			if process == "" {
+				process = "-"
+			}
+			fmt.Printf("%-8s %-25s %-25s %-15s %-8s %s\n", c.Protocol, local, remote, state, pid, process)
 		} else {
 			fmt.Printf("%-8s %-25s %-25s %s\n", c.Protocol, local, remote, state)
 		}
 	}
 
 	fmt.Printf("\nTotal: %d connections\n", len(conns))
+	return states, nil
+}
+
+// printStateSummary prints connection counts per state, busiest first.
+// With prev set, a CHANGE column shows the difference since then.
+func printStateSummary(states, prev map[string]int, total int) {
+	names := make([]string, 0, len(states))
+	for name := range states {
+		names = append(names, name)
+	}
+	for name := range prev {
+		if _, ok := states[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if states[names[i]] != states[names[j]] {
+			return states[names[i]] > states[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	if prev != nil {
+		fmt.Printf("%-15s %8s %8s\n", "STATE", "COUNT", "CHANGE")
+	} else {
+		fmt.Printf("%-15s %8s\n", "STATE", "COUNT")
+	}
+	fmt.Println("────────────────────────────────")
+	for _, name := range names {
+		if prev != nil {
+			fmt.Printf("%-15s %8d %+8d\n", name, states[name], states[name]-prev[name])
+		} else {
+			fmt.Printf("%-15s %8d\n", name, states[name])
+		}
+	}
+	fmt.Println("────────────────────────────────")
+	fmt.Printf("%-15s %8d\n", "TOTAL", total)
+}
+
+// printInterfaceStats samples interface counters twice, interval apart, and
+// prints totals alongside the current throughput.
+func printInterfaceStats(interval time.Duration) {
+	before, err := netstat.GetInterfaces()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	start := time.Now()
+	time.Sleep(interval)
+	after, err := netstat.GetInterfaces()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	elapsed := time.Since(start).Seconds()
+
+	prev := make(map[string]netstat.Interface, len(before))
+	for _, iface := range before {
+		prev[iface.Name] = iface
+	}
+
+	fmt.Printf("%-12s %-16s %-6s %-12s %-12s %-12s %-12s %-12s %s\n",
+		"INTERFACE", "IP", "MTU", "RX", "TX", "RX PKTS", "TX PKTS", "RX/s", "TX/s")
+	fmt.Println("────────────────────────────────────────────────────────────────────────────────────────────────────────────")
+
+	for _, iface := range after {
+		ip := iface.IP
+		if ip == "" {
+			ip = "-"
+		}
+		var rxRate, txRate float64
+		if p, ok := prev[iface.Name]; ok && elapsed > 0 && iface.BytesRecv >= p.BytesRecv && iface.BytesSent >= p.BytesSent {
+			rxRate = float64(iface.BytesRecv-p.BytesRecv) / elapsed
+			txRate = float64(iface.BytesSent-p.BytesSent) / elapsed
+		}
+		fmt.Printf("%-12s %-16s %-6d %-12s %-12s %-12d %-12d %-12s %s\n",
+			truncateName(iface.Name, 12), truncateName(ip, 16), iface.MTU,
+			bwmon.FormatBytes(iface.BytesRecv), bwmon.FormatBytes(iface.BytesSent),
+			iface.PacketRecv, iface.PacketSent,
+			bwmon.FormatBytesPerSec(rxRate), bwmon.FormatBytesPerSec(txRate))
+	}
+
+	fmt.Printf("\nTotal: %d interfaces (throughput sampled over %v)\n", len(after), interval)
 }
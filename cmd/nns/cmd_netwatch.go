@@ -10,16 +10,25 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/netwatch"
 )
 
-func runNetwatch(args []string) {
+// netwatchFlags holds the flags of the netwatch command.
+type netwatchFlags struct {
+	intervalFlag *time.Duration
+	hostFlag     *string
+	durationFlag *time.Duration
+}
+
+// newNetwatchFlags defines the flags of the netwatch command.
+func newNetwatchFlags() (*flag.FlagSet, *netwatchFlags) {
 	fs := flag.NewFlagSet("netwatch", flag.ExitOnError)
+	cli := &netwatchFlags{}
 
-	intervalFlag := fs.Duration("interval", 5*time.Second, "Poll interval")
-	hostFlag := fs.String("host", "", "Additional host to monitor")
-	durationFlag := fs.Duration("duration", 0, "How long to monitor (0 = until Ctrl+C)")
+	cli.intervalFlag = fs.Duration("interval", 5*time.Second, "Poll interval")
+	cli.hostFlag = fs.String("host", "", "Additional host to monitor")
+	cli.durationFlag = fs.Duration("duration", 0, "How long to monitor (0 = until Ctrl+C)")
 
-	fs.DurationVar(intervalFlag, "i", 5*time.Second, "Poll interval")
-	fs.StringVar(hostFlag, "H", "", "Host to monitor")
-	fs.DurationVar(durationFlag, "d", 0, "Duration")
+	fs.DurationVar(cli.intervalFlag, "i", 5*time.Second, "Poll interval")
+	fs.StringVar(cli.hostFlag, "H", "", "Host to monitor")
+	fs.DurationVar(cli.durationFlag, "d", 0, "Duration")
 
 	fs.Usage = func() {
 		fmt.Println(`Usage: nns netwatch [OPTIONS]
@@ -38,26 +47,32 @@ EXAMPLES:
   nns netwatch --duration 5m`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runNetwatch(args []string) {
+	fs, cli := newNetwatchFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		os.Exit(1)
 	}
 
 	cfg := netwatch.Config{
-		PollInterval:          *intervalFlag,
+		PollInterval:          *cli.intervalFlag,
 		ConnectivityCheckHost: "8.8.8.8",
 		LatencyThreshold:      500 * time.Millisecond,
 	}
 
-	if *hostFlag != "" {
-		cfg.MonitoredHosts = []string{*hostFlag}
+	if *cli.hostFlag != "" {
+		cfg.MonitoredHosts = []string{*cli.hostFlag}
 	}
 
 	watcher := netwatch.NewWatcher(cfg)
 
 	ctx := context.Background()
-	if *durationFlag > 0 {
+	if *cli.durationFlag > 0 {
 		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, *durationFlag)
+		ctx, cancel = context.WithTimeout(ctx, *cli.durationFlag)
 		defer cancel()
 	}
 
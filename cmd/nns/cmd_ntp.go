@@ -13,11 +13,20 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/ntp"
 )
 
-func runNTP(args []string) {
+// ntpFlags holds the flags of the ntp command.
+type ntpFlags struct {
+	timeout *time.Duration
+	server  *string
+	all     *bool
+}
+
+// newNTPFlags defines the flags of the ntp command.
+func newNTPFlags() (*flag.FlagSet, *ntpFlags) {
 	fs := flag.NewFlagSet("ntp", flag.ExitOnError)
-	timeout := fs.Duration("timeout", 5*time.Second, "Query timeout")
-	server := fs.String("server", "", "Specific NTP server to query")
-	all := fs.Bool("all", false, "Test all known public NTP servers")
+	cli := &ntpFlags{}
+	cli.timeout = fs.Duration("timeout", 5*time.Second, "Query timeout")
+	cli.server = fs.String("server", "", "Specific NTP server to query")
+	cli.all = fs.Bool("all", false, "Test all known public NTP servers")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns ntp [OPTIONS] [server]
@@ -36,12 +45,18 @@ EXAMPLES:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runNTP(args []string) {
+	fs, cli := newNTPFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		return
 	}
 
 	cfg := ntp.Config{
-		Timeout: *timeout,
+		Timeout: *cli.timeout,
 	}
 
 	// Determine which servers to test
@@ -51,13 +66,13 @@ EXAMPLES:
 			serverAddr = serverAddr + ":123"
 		}
 		cfg.Servers = []ntp.Server{{Name: fs.Arg(0), Address: serverAddr}}
-	} else if *server != "" {
-		serverAddr := *server
+	} else if *cli.server != "" {
+		serverAddr := *cli.server
 		if !strings.Contains(serverAddr, ":") {
-			serverAddr = *server + ":123"
+			serverAddr = *cli.server + ":123"
 		}
-		cfg.Servers = []ntp.Server{{Name: *server, Address: serverAddr}}
-	} else if *all {
+		cfg.Servers = []ntp.Server{{Name: *cli.server, Address: serverAddr}}
+	} else if *cli.all {
 		cfg.Servers = ntp.PublicServers
 	} else {
 		cfg.Servers = ntp.PublicServers[:4]
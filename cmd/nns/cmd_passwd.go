@@ -10,21 +10,35 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/passwd"
 )
 
-func runPasswd(args []string) {
+// passwdFlags holds the flags of the passwd command.
+type passwdFlags struct {
+	generate  *bool
+	length    *int
+	count     *int
+	noUpper   *bool
+	noLower   *bool
+	noDigits  *bool
+	noSpecial *bool
+	exclude   *string
+}
+
+// newPasswdFlags defines the flags of the passwd command.
+func newPasswdFlags() (*flag.FlagSet, *passwdFlags) {
 	fs := flag.NewFlagSet("passwd", flag.ExitOnError)
-	generate := fs.Bool("generate", false, "Generate a secure password")
-	length := fs.Int("length", 16, "Password length for generation")
-	count := fs.Int("count", 1, "Number of passwords to generate")
-	noUpper := fs.Bool("no-upper", false, "Exclude uppercase letters")
-	noLower := fs.Bool("no-lower", false, "Exclude lowercase letters")
-	noDigits := fs.Bool("no-digits", false, "Exclude digits")
-	noSpecial := fs.Bool("no-special", false, "Exclude special characters")
-	exclude := fs.String("exclude", "", "Characters to exclude from generation")
+	cli := &passwdFlags{}
+	cli.generate = fs.Bool("generate", false, "Generate a secure password")
+	cli.length = fs.Int("length", 16, "Password length for generation")
+	cli.count = fs.Int("count", 1, "Number of passwords to generate")
+	cli.noUpper = fs.Bool("no-upper", false, "Exclude uppercase letters")
+	cli.noLower = fs.Bool("no-lower", false, "Exclude lowercase letters")
+	cli.noDigits = fs.Bool("no-digits", false, "Exclude digits")
+	cli.noSpecial = fs.Bool("no-special", false, "Exclude special characters")
+	cli.exclude = fs.String("exclude", "", "Characters to exclude from generation")
 
 	// Short flags
-	fs.BoolVar(generate, "g", false, "Generate mode")
-	fs.IntVar(length, "l", 16, "Password length")
-	fs.IntVar(count, "n", 1, "Count")
+	fs.BoolVar(cli.generate, "g", false, "Generate mode")
+	fs.IntVar(cli.length, "l", 16, "Password length")
+	fs.IntVar(cli.count, "n", 1, "Count")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns passwd [options] [password]
@@ -54,21 +68,27 @@ Examples:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runPasswd(args []string) {
+	fs, cli := newPasswdFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Mode: generate
-	if *generate {
+	if *cli.generate {
 		opts := passwd.GenerateOptions{
-			Length:  *length,
-			Upper:   !*noUpper,
-			Lower:   !*noLower,
-			Digits:  !*noDigits,
-			Special: !*noSpecial,
-			Exclude: *exclude,
-			Count:   *count,
+			Length:  *cli.length,
+			Upper:   !*cli.noUpper,
+			Lower:   !*cli.noLower,
+			Digits:  !*cli.noDigits,
+			Special: !*cli.noSpecial,
+			Exclude: *cli.exclude,
+			Count:   *cli.count,
 		}
 
 		fmt.Printf("PASSWORD GENERATOR\n\n")
@@ -80,14 +100,14 @@ Examples:
 		}
 
 		for i, pw := range passwords {
-			if *count > 1 {
+			if *cli.count > 1 {
 				fmt.Printf("  [%d] %s\n", i+1, pw)
 			} else {
 				fmt.Printf("  Password:  %s\n", pw)
 			}
 		}
 
-		if *count == 1 {
+		if *cli.count == 1 {
 			fmt.Println()
 			fmt.Println("  Strength analysis:")
 			analysis := passwd.Analyze(passwords[0])
@@ -12,16 +12,30 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/pcap"
 )
 
-func runPcap(args []string) {
+// pcapFlags holds the flags of the pcap command.
+type pcapFlags struct {
+	iface      *string
+	protocol   *string
+	port       *int
+	srcHost    *string
+	dstHost    *string
+	count      *int
+	duration   *time.Duration
+	listIfaces *bool
+}
+
+// newPcapFlags defines the flags of the pcap command.
+func newPcapFlags() (*flag.FlagSet, *pcapFlags) {
 	fs := flag.NewFlagSet("pcap", flag.ExitOnError)
-	iface := fs.String("interface", "", "Network interface to capture on")
-	protocol := fs.String("protocol", "", "Filter by protocol (tcp, udp, icmp)")
-	port := fs.Int("port", 0, "Filter by port number")
-	srcHost := fs.String("src", "", "Filter by source IP")
-	dstHost := fs.String("dst", "", "Filter by destination IP")
-	count := fs.Int("count", 0, "Max packets to capture (0=unlimited)")
-	duration := fs.Duration("duration", 0, "Max capture duration (0=unlimited)")
-	listIfaces := fs.Bool("list", false, "List available interfaces")
+	cli := &pcapFlags{}
+	cli.iface = fs.String("interface", "", "Network interface to capture on")
+	cli.protocol = fs.String("protocol", "", "Filter by protocol (tcp, udp, icmp)")
+	cli.port = fs.Int("port", 0, "Filter by port number")
+	cli.srcHost = fs.String("src", "", "Filter by source IP")
+	cli.dstHost = fs.String("dst", "", "Filter by destination IP")
+	cli.count = fs.Int("count", 0, "Max packets to capture (0=unlimited)")
+	cli.duration = fs.Duration("duration", 0, "Max capture duration (0=unlimited)")
+	cli.listIfaces = fs.Bool("list", false, "List available interfaces")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns pcap [OPTIONS]
@@ -40,12 +54,18 @@ EXAMPLES:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runPcap(args []string) {
+	fs, cli := newPcapFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		return
 	}
 
 	// List interfaces mode
-	if *listIfaces {
+	if *cli.listIfaces {
 		ifaces, err := pcap.ListInterfaces()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error listing interfaces: %v\n", err)
@@ -62,13 +82,13 @@ EXAMPLES:
 	}
 
 	opts := pcap.DefaultOptions()
-	opts.Interface = *iface
-	opts.Filter.Protocol = *protocol
-	opts.Filter.Port = *port
-	opts.Filter.SrcHost = *srcHost
-	opts.Filter.DstHost = *dstHost
-	opts.MaxPackets = *count
-	opts.MaxDuration = *duration
+	opts.Interface = *cli.iface
+	opts.Filter.Protocol = *cli.protocol
+	opts.Filter.Port = *cli.port
+	opts.Filter.SrcHost = *cli.srcHost
+	opts.Filter.DstHost = *cli.dstHost
+	opts.MaxPackets = *cli.count
+	opts.MaxDuration = *cli.duration
 
 	cap, err := pcap.NewCapture(opts)
 	if err != nil {
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
@@ -11,44 +12,66 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/pcping"
 )
 
-func runPCPing(args []string) {
+// pcPingFlags holds the flags of the pcping command.
+type pcPingFlags struct {
+	port     *int
+	proto    *string
+	count    *int
+	interval *time.Duration
+	timeout  *time.Duration
+	useTLS   *bool
+	httpPath *string
+	jsonOut  *bool
+}
+
+// newPCPingFlags defines the flags of the pcping command.
+func newPCPingFlags() (*flag.FlagSet, *pcPingFlags) {
 	fs := flag.NewFlagSet("pcping", flag.ExitOnError)
-	port := fs.Int("port", 0, "Target port (default: auto by protocol)")
-	proto := fs.String("proto", "tcp", "Protocol: tcp, udp, http, dns")
-	count := fs.Int("count", 5, "Number of probes")
-	interval := fs.Duration("interval", 1*time.Second, "Time between probes")
-	timeout := fs.Duration("timeout", 5*time.Second, "Probe timeout")
-	useTLS := fs.Bool("tls", false, "Use TLS (for TCP/HTTP probes)")
-	httpPath := fs.String("path", "/", "HTTP path to probe")
+	cli := &pcPingFlags{}
+	cli.port = fs.Int("port", 0, "Target port (default: auto by protocol)")
+	cli.proto = fs.String("proto", "tcp", "Protocol: tcp, udp, http, dns, tls, quic")
+	fs.StringVar(cli.proto, "protocol", "tcp", "Protocol (alias of --proto)")
+	cli.count = fs.Int("count", 5, "Number of probes (0 = until interrupted)")
+	cli.interval = fs.Duration("interval", 1*time.Second, "Time between probes")
+	cli.timeout = fs.Duration("timeout", 5*time.Second, "Probe timeout")
+	cli.useTLS = fs.Bool("tls", false, "Use TLS (for TCP/HTTP probes)")
+	cli.httpPath = fs.String("path", "/", "HTTP path to probe")
+	fs.StringVar(cli.httpPath, "http-path", "/", "HTTP path to probe (alias of --path)")
+	cli.jsonOut = fs.Bool("json", false, "Print final statistics as JSON")
 
 	// Short flags
-	fs.IntVar(port, "p", 0, "Target port")
-	fs.StringVar(proto, "P", "tcp", "Protocol: tcp, udp, http, dns")
-	fs.IntVar(count, "c", 5, "Number of probes")
-	fs.DurationVar(interval, "i", 1*time.Second, "Time between probes")
-	fs.DurationVar(timeout, "t", 5*time.Second, "Probe timeout")
-	fs.BoolVar(useTLS, "s", false, "Use TLS")
+	fs.IntVar(cli.port, "p", 0, "Target port")
+	fs.StringVar(cli.proto, "P", "tcp", "Protocol: tcp, udp, http, dns, tls, quic")
+	fs.IntVar(cli.count, "c", 5, "Number of probes")
+	fs.DurationVar(cli.interval, "i", 1*time.Second, "Time between probes")
+	fs.DurationVar(cli.timeout, "t", 5*time.Second, "Probe timeout")
+	fs.BoolVar(cli.useTLS, "s", false, "Use TLS")
 
 	fs.Usage = func() {
-		fmt.Fprintf(os.Stderr, `Usage: nns pcping [options] <host>
+		fmt.Fprintf(os.Stderr, `Usage: nns pcping [options] <host> [host...]
 
-Protocol-aware ping — probe hosts via TCP, UDP, HTTP, or DNS.
-Useful when ICMP ping is blocked by firewalls.
+Protocol-aware ping — probe hosts via TCP, UDP, HTTP, DNS, TLS, or QUIC.
+Useful when ICMP ping is blocked by firewalls. Several hosts are probed
+concurrently and summarized side by side.
 
 Protocols:
   tcp    TCP connect probe (default)
   udp    UDP probe with response detection
   http   HTTP GET request probe
   dns    DNS query probe
+  tls    TLS handshake probe (RTT is handshake time; port 443)
+  quic   QUIC handshake probe offering HTTP/3 (UDP port 443)
 
 Options:
-  --proto, -P       Protocol: tcp, udp, http, dns (default: tcp)
+  --protocol, -P    Protocol: tcp, udp, http, dns, tls, quic (default: tcp;
+                    alias: --proto)
   --port, -p        Target port (default: auto by protocol)
-  --count, -c       Number of probes (default: 5)
+  --count, -c       Number of probes, 0 = until interrupted (default: 5)
   --interval, -i    Time between probes (default: 1s)
   --timeout, -t     Probe timeout (default: 5s)
   --tls, -s         Use TLS (for TCP/HTTP probes)
-  --path            HTTP path to probe (default: /)
+  --http-path       HTTP path to probe (default: /; alias: --path)
+  --json            Print final statistics as JSON (suppresses per-probe output)
   --help            Show this help message
 
 Examples:
@@ -57,11 +80,21 @@ Examples:
   nns pcping google.com -P http             # HTTP ping
   nns pcping 8.8.8.8 -P dns                # DNS ping
   nns pcping example.com -P udp -p 53      # UDP ping
-  nns pcping api.example.com -P http --path /health
+  nns pcping example.com -P tls            # TLS handshake ping
+  nns pcping cloudflare.com -P quic        # QUIC/HTTP3 handshake ping
+  nns pcping --protocol http --http-path /health api.example.com
+  nns pcping 1.1.1.1 8.8.8.8 9.9.9.9 -P dns   # compare resolvers
+  nns pcping api.example.com -P http -c 0 -i 10s --json   # monitor until Ctrl+C
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runPCPing(args []string) {
+	fs, cli := newPCPingFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -74,30 +107,31 @@ Examples:
 
 	host := fs.Arg(0)
 
-	protocol := pcping.Protocol(*proto)
+	protocol := pcping.Protocol(*cli.proto)
 	switch protocol {
-	case pcping.ProtoTCP, pcping.ProtoUDP, pcping.ProtoHTTP, pcping.ProtoDNS:
+	case pcping.ProtoTCP, pcping.ProtoUDP, pcping.ProtoHTTP, pcping.ProtoDNS, pcping.ProtoTLS, pcping.ProtoQUIC:
 		// valid
 	default:
-		fmt.Fprintf(os.Stderr, "Error: unsupported protocol %q (use tcp, udp, http, dns)\n", *proto)
+		fmt.Fprintf(os.Stderr, "Error: unsupported protocol %q (use tcp, udp, http, dns, tls, quic)\n", *cli.proto)
 		os.Exit(1)
 	}
 
 	opts := pcping.Options{
-		Host:     host,
-		Port:     *port,
-		Protocol: protocol,
-		Count:    *count,
-		Interval: *interval,
-		Timeout:  *timeout,
-		UseTLS:   *useTLS,
-		HTTPPath: *httpPath,
+		Host:       host,
+		Port:       *cli.port,
+		Protocol:   protocol,
+		Count:      *cli.count,
+		Continuous: *cli.count == 0,
+		Interval:   *cli.interval,
+		Timeout:    *cli.timeout,
+		UseTLS:     *cli.useTLS,
+		HTTPPath:   *cli.httpPath,
 	}
 
-	pinger := pcping.NewPinger(opts)
-
-	fmt.Printf("PCPING %s via %s (port %d)\n", host, protocol, pinger.Port())
-	fmt.Printf("Count: %d, Interval: %v, Timeout: %v\n\n", *count, *interval, *timeout)
+	countStr := fmt.Sprint(*cli.count)
+	if *cli.count == 0 {
+		countStr = "continuous"
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -106,27 +140,82 @@ Examples:
 	signal.Notify(sigCh, os.Interrupt)
 	go func() {
 		<-sigCh
-		fmt.Println("\nInterrupted, calculating statistics...")
+		fmt.Fprintln(os.Stderr, "\nInterrupted, calculating statistics...")
 		cancel()
 	}()
 
-	err := pinger.Run(ctx, func(r pcping.ProbeResult) {
+	multi := fs.NArg() > 1
+	printProbe := func(r pcping.ProbeResult) {
+		if *cli.jsonOut {
+			return
+		}
+		target := ""
+		if multi {
+			target = fmt.Sprintf("%-16s ", r.Target)
+		}
 		if r.Success {
 			detail := ""
 			if r.Detail != "" {
 				detail = fmt.Sprintf("  [%s]", r.Detail)
 			}
-			fmt.Printf("seq=%d  proto=%s  rtt=%v  addr=%s%s\n",
-				r.Seq, r.Protocol, r.RTT.Round(time.Microsecond), r.Addr, detail)
+			fmt.Printf("%sseq=%d  proto=%s  rtt=%v  addr=%s%s\n",
+				target, r.Seq, r.Protocol, r.RTT.Round(time.Microsecond), r.Addr, detail)
 		} else {
-			fmt.Printf("seq=%d  proto=%s  FAILED: %v\n", r.Seq, r.Protocol, r.Error)
+			fmt.Printf("%sseq=%d  proto=%s  FAILED: %v\n", target, r.Seq, r.Protocol, r.Error)
+		}
+	}
+
+	if multi {
+		targets := make([]pcping.Options, fs.NArg())
+		for i, h := range fs.Args() {
+			targets[i] = opts
+			targets[i].Host = h
 		}
-	})
+
+		if !*cli.jsonOut {
+			fmt.Printf("PCPING %d targets via %s\n", len(targets), protocol)
+			fmt.Printf("Count: %s, Interval: %v, Timeout: %v\n\n", countStr, *cli.interval, *cli.timeout)
+		}
+
+		stats, _ := pcping.RunMulti(ctx, targets, printProbe)
+
+		if *cli.jsonOut {
+			data, err := json.MarshalIndent(stats, "", "  ")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+			return
+		}
+
+		fmt.Print(pcping.FormatComparison(stats))
+		return
+	}
+
+	pinger := pcping.NewPinger(opts)
+
+	if !*cli.jsonOut {
+		fmt.Printf("PCPING %s via %s (port %d)\n", host, protocol, pinger.Port())
+		fmt.Printf("Count: %s, Interval: %v, Timeout: %v\n\n", countStr, *cli.interval, *cli.timeout)
+	}
+
+	err := pinger.Run(ctx, printProbe)
 
 	if err != nil && ctx.Err() == nil {
 		fmt.Fprintf(os.Stderr, "\nError: %v\n", err)
 		os.Exit(1)
 	}
 
+	if *cli.jsonOut {
+		out, err := pinger.Stats.ToJSON()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(out)
+		return
+	}
+
 	fmt.Print(pinger.Stats.Format(host))
 }
@@ -10,19 +10,31 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/ping"
 )
 
-func runPing(args []string) {
+// pingFlags holds the flags of the ping command.
+type pingFlags struct {
+	countFlag    *int
+	intervalFlag *time.Duration
+	timeoutFlag  *time.Duration
+	sizeFlag     *int
+	source       func() string
+}
+
+// newPingFlags defines the flags of the ping command.
+func newPingFlags() (*flag.FlagSet, *pingFlags) {
 	// Create flagset for ping command
 	fs := flag.NewFlagSet("ping", flag.ExitOnError)
-	countFlag := fs.Int("count", 0, "Number of pings to send (0 = infinite)")
-	intervalFlag := fs.Duration("interval", 1*time.Second, "Time between pings")
-	timeoutFlag := fs.Duration("timeout", 4*time.Second, "Timeout per ping")
-	sizeFlag := fs.Int("size", 64, "Packet size in bytes")
+	cli := &pingFlags{}
+	cli.countFlag = fs.Int("count", 0, "Number of pings to send (0 = infinite)")
+	cli.intervalFlag = fs.Duration("interval", 1*time.Second, "Time between pings")
+	cli.timeoutFlag = fs.Duration("timeout", 4*time.Second, "Timeout per ping")
+	cli.sizeFlag = fs.Int("size", 64, "Packet size in bytes")
+	cli.source = sourceFlags(fs)
 
 	// Short flags
-	fs.IntVar(countFlag, "c", 0, "Number of pings to send")
-	fs.DurationVar(intervalFlag, "i", 1*time.Second, "Time between pings")
-	fs.DurationVar(timeoutFlag, "t", 4*time.Second, "Timeout per ping")
-	fs.IntVar(sizeFlag, "s", 64, "Packet size in bytes")
+	fs.IntVar(cli.countFlag, "c", 0, "Number of pings to send")
+	fs.DurationVar(cli.intervalFlag, "i", 1*time.Second, "Time between pings")
+	fs.DurationVar(cli.timeoutFlag, "t", 4*time.Second, "Timeout per ping")
+	fs.IntVar(cli.sizeFlag, "s", 64, "Packet size in bytes")
 
 	fs.Usage = func() {
 		fmt.Println(`Usage: nns ping [HOST] [OPTIONS]
@@ -34,15 +46,24 @@ OPTIONS:
   --interval, -i    Time between pings (default: 1s)
   --timeout, -t     Timeout per ping (default: 4s)
   --size, -s        Packet size in bytes (default: 64)
+  --interface, -I   Send from this interface's IPv4 address
+  --source-ip, -S   Send from this local IPv4 address
   --help            Show this help message
 
 EXAMPLES:
   nns ping google.com
   nns ping -c 5 example.com
-  nns ping -i 500ms 192.168.1.1`)
+  nns ping -i 500ms 192.168.1.1
+  nns ping -I eth1 -c 5 192.168.50.1`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runPing(args []string) {
+	fs, cli := newPingFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing flags: %v\n", err)
 		os.Exit(1)
 	}
@@ -55,10 +76,11 @@ EXAMPLES:
 
 	host := fs.Arg(0)
 	pinger := ping.NewPinger(host)
-	pinger.Count = *countFlag
-	pinger.Interval = *intervalFlag
-	pinger.Timeout = *timeoutFlag
-	pinger.PacketSize = *sizeFlag
+	pinger.Count = *cli.countFlag
+	pinger.Interval = *cli.intervalFlag
+	pinger.Timeout = *cli.timeoutFlag
+	pinger.PacketSize = *cli.sizeFlag
+	pinger.SourceIP = cli.source()
 
 	// Resolve hostname
 	fmt.Printf("Resolving %s...\n", host)
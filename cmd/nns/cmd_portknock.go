@@ -13,18 +13,28 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/portknock"
 )
 
-func runPortKnock(args []string) {
+// portKnockFlags holds the flags of the portknock command.
+type portKnockFlags struct {
+	delay   *time.Duration
+	timeout *time.Duration
+	proto   *string
+	verify  *int
+}
+
+// newPortKnockFlags defines the flags of the portknock command.
+func newPortKnockFlags() (*flag.FlagSet, *portKnockFlags) {
 	fs := flag.NewFlagSet("portknock", flag.ExitOnError)
-	delay := fs.Duration("delay", 500*time.Millisecond, "Delay between knocks")
-	timeout := fs.Duration("timeout", 2*time.Second, "Timeout per knock")
-	proto := fs.String("proto", "tcp", "Protocol: tcp, udp")
-	verify := fs.Int("verify", 0, "Port to verify after knocking")
+	cli := &portKnockFlags{}
+	cli.delay = fs.Duration("delay", 500*time.Millisecond, "Delay between knocks")
+	cli.timeout = fs.Duration("timeout", 2*time.Second, "Timeout per knock")
+	cli.proto = fs.String("proto", "tcp", "Protocol: tcp, udp")
+	cli.verify = fs.Int("verify", 0, "Port to verify after knocking")
 
 	// Short flags
-	fs.DurationVar(delay, "d", 500*time.Millisecond, "Delay between knocks")
-	fs.DurationVar(timeout, "t", 2*time.Second, "Timeout per knock")
-	fs.StringVar(proto, "P", "tcp", "Protocol: tcp, udp")
-	fs.IntVar(verify, "v", 0, "Port to verify after knocking")
+	fs.DurationVar(cli.delay, "d", 500*time.Millisecond, "Delay between knocks")
+	fs.DurationVar(cli.timeout, "t", 2*time.Second, "Timeout per knock")
+	fs.StringVar(cli.proto, "P", "tcp", "Protocol: tcp, udp")
+	fs.IntVar(cli.verify, "v", 0, "Port to verify after knocking")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns portknock [options] <host> <port1,port2,port3,...>
@@ -48,7 +58,13 @@ Examples:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runPortKnock(args []string) {
+	fs, cli := newPortKnockFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -89,14 +105,14 @@ Examples:
 	opts := portknock.Options{
 		Host:     host,
 		Ports:    ports,
-		Delay:    *delay,
-		Timeout:  *timeout,
-		Protocol: *proto,
-		Verify:   *verify,
+		Delay:    *cli.delay,
+		Timeout:  *cli.timeout,
+		Protocol: *cli.proto,
+		Verify:   *cli.verify,
 	}
 
-	fmt.Printf("PORT KNOCK %s via %s\n", host, *proto)
-	fmt.Printf("Sequence: %s  Delay: %v\n\n", portStr, *delay)
+	fmt.Printf("PORT KNOCK %s via %s\n", host, *cli.proto)
+	fmt.Printf("Sequence: %s  Delay: %v\n\n", portStr, *cli.delay)
 
 	result, err := portknock.Knock(ctx, opts)
 	if err != nil && ctx.Err() == nil {
@@ -4,19 +4,45 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/JedizLaPulga/NNS/internal/portscan"
 )
 
-func runPortScan(args []string) {
+// portScanFlags holds the flags of the portscan command.
+type portScanFlags struct {
+	portsFlag      *string
+	commonFlag     *bool
+	timeoutFlag    *time.Duration
+	concurrentFlag *int
+	formatFlag     *string
+	jsonFlag       *bool
+	saveFlag       *string
+	compareFlag    *string
+	source         func() string
+}
+
+// newPortScanFlags defines the flags of the portscan command.
+func newPortScanFlags() (*flag.FlagSet, *portScanFlags) {
 	// Create flagset for portscan command
 	fs := flag.NewFlagSet("portscan", flag.ExitOnError)
-	portsFlag := fs.String("ports", "", "Comma-separated ports or ranges (e.g., 80,443,8000-9000)")
-	commonFlag := fs.Bool("common", false, "Scan common ports")
-	timeoutFlag := fs.Duration("timeout", 2*time.Second, "Connection timeout per port")
-	concurrentFlag := fs.Int("concurrent", 100, "Number of concurrent workers")
+	cli := &portScanFlags{}
+	cli.portsFlag = fs.String("ports", "", "Comma-separated ports or ranges (e.g., 80,443,8000-9000)")
+	cli.commonFlag = fs.Bool("common", false, "Scan common ports")
+	cli.timeoutFlag = fs.Duration("timeout", 2*time.Second, "Connection timeout per port")
+	cli.concurrentFlag = fs.Int("concurrent", 100, "Number of concurrent workers")
+	cli.formatFlag = fs.String("output-format", "text", "Output format: text, json, xml (nmap -oX)")
+	cli.jsonFlag = fs.Bool("json", false, "Output in JSON format (same as --output-format json)")
+	cli.saveFlag = fs.String("save", "", "Save results to a baseline file")
+	cli.compareFlag = fs.String("compare", "", "Report ports opened or closed since a baseline file")
+
+	cli.source = sourceFlags(fs)
+
+	// Short flags
+	fs.StringVar(cli.portsFlag, "p", "", "Comma-separated ports or ranges")
 
 	fs.Usage = func() {
 		fmt.Println(`Usage: nns portscan [HOST] [OPTIONS]
@@ -28,17 +54,33 @@ OPTIONS:
   --common          Use common ports preset
   --timeout         Connection timeout per port (default: 2s)
   --concurrent      Number of concurrent workers (default: 100)
+  --interface, -I   Connect from this interface's IPv4 address
+  --source-ip, -S   Connect from this local IPv4 address
+  --output-format   Output format: text, json, xml (default: text)
+                    xml follows nmap's -oX schema for nmap parsers and ndiff
+  --json            Same as --output-format json
+  --save FILE       Save results as a baseline for later --compare
+  --compare FILE    Report ports opened or closed since the baseline in FILE
   --help            Show this help message
 
 EXAMPLES:
   nns portscan 192.168.1.1 --ports 80,443
   nns portscan example.com --ports 1-1024
   nns portscan 192.168.1.1 --common
-  nns portscan 10.0.0.1 --ports 8000-9000 --timeout 5s`)
+  nns portscan 10.0.0.1 --ports 8000-9000 --timeout 5s
+  nns portscan --common --output-format xml 192.168.1.0/24 > scan.xml
+  nns portscan --ports 1-1024 --save baseline.json 10.0.0.1
+  nns portscan --ports 1-1024 --compare baseline.json 10.0.0.1`)
 	}
 
 	// Parse flags
-	if err := fs.Parse(args); err != nil {
+
+	return fs, cli
+}
+
+func runPortScan(args []string) {
+	fs, cli := newPortScanFlags()
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing flags: %v\n", err)
 		os.Exit(1)
 	}
@@ -51,14 +93,25 @@ EXAMPLES:
 	}
 	target := fs.Arg(0)
 
+	format := strings.ToLower(*cli.formatFlag)
+	if *cli.jsonFlag {
+		format = "json"
+	}
+	switch format {
+	case "text", "json", "xml":
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unsupported output format %q (use text, json, xml)\n", *cli.formatFlag)
+		os.Exit(1)
+	}
+
 	// Determine which ports to scan
 	var ports []int
 	var err error
 
-	if *commonFlag {
+	if *cli.commonFlag {
 		ports = portscan.CommonPorts()
-	} else if *portsFlag != "" {
-		ports, err = portscan.ParsePortRange(*portsFlag)
+	} else if *cli.portsFlag != "" {
+		ports, err = portscan.ParsePortRange(*cli.portsFlag)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error parsing ports: %v\n", err)
 			os.Exit(1)
@@ -78,21 +131,49 @@ EXAMPLES:
 
 	// Create scanner
 	scanner := portscan.NewScanner()
-	scanner.Timeout = *timeoutFlag
-	scanner.Concurrency = *concurrentFlag
+	scanner.Timeout = *cli.timeoutFlag
+	scanner.Concurrency = *cli.concurrentFlag
+	scanner.SourceIP = cli.source()
+
+	result := &portscan.Result{
+		Args:      strings.Join(append([]string{"nns"}, os.Args[1:]...), " "),
+		Ports:     ports,
+		StartTime: time.Now(),
+	}
+
+	// Load the baseline up front so a bad file fails before scanning
+	var baseline []portscan.ScanResult
+	if *cli.compareFlag != "" {
+		baseline, err = portscan.LoadResults(*cli.compareFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
 	// Scan each host
 	for _, host := range hosts {
+		if format != "text" {
+			fmt.Fprintf(os.Stderr, "Scanning %s...\n", host)
+			result.Hosts = append(result.Hosts, scanner.ScanHost(context.Background(), host, ports))
+			continue
+		}
+
 		fmt.Printf("\nScanning %s...\n", host)
 
-		results := scanner.ScanPorts(context.Background(), host, ports)
+		hostResult := scanner.ScanHost(context.Background(), host, ports)
+		result.Hosts = append(result.Hosts, hostResult)
+		if hostResult.Error != nil {
+			fmt.Printf("Error: %v\n", hostResult.Error)
+			continue
+		}
 
 		// Display results
 		fmt.Printf("\n%-10s %-10s %s\n", "PORT", "STATE", "BANNER")
 		fmt.Println("--------------------------------------------")
 
 		openCount := 0
-		for _, result := range results {
+		for _, result := range hostResult.Ports {
 			if result.Open {
 				openCount++
 				banner := result.Banner
@@ -112,4 +193,74 @@ EXAMPLES:
 		}
 
 	}
+	result.EndTime = time.Now()
+
+	var scanned []portscan.ScanResult
+	for _, h := range result.Hosts {
+		scanned = append(scanned, h.Ports...)
+	}
+
+	if *cli.compareFlag != "" {
+		// Keep stdout machine-readable in json/xml mode
+		w := os.Stdout
+		if format != "text" {
+			w = os.Stderr
+		}
+		printPortDiff(w, *cli.compareFlag, baseline, result.Hosts)
+	}
+
+	if *cli.saveFlag != "" {
+		if err := portscan.SaveResults(*cli.saveFlag, scanned); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Saved %d results to %s\n", len(scanned), *cli.saveFlag)
+	}
+
+	if format == "text" {
+		return
+	}
+
+	var out string
+	if format == "xml" {
+		out, err = result.ToNmapXML()
+	} else {
+		out, err = result.ToJSON()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(out)
+}
+
+// printPortDiff reports ports opened or closed on each host since the
+// baseline scan.
+func printPortDiff(w io.Writer, path string, baseline []portscan.ScanResult, hosts []portscan.HostResult) {
+	byHost := make(map[string][]portscan.ScanResult)
+	for _, r := range baseline {
+		byHost[r.Host] = append(byHost[r.Host], r)
+	}
+
+	fmt.Fprintf(w, "\nChanges since %s:\n", path)
+	compared, changes := 0, 0
+	for _, h := range hosts {
+		old, ok := byHost[h.Host]
+		if !ok {
+			fmt.Fprintf(w, "  %s: not in baseline\n", h.Host)
+			continue
+		}
+		compared++
+		opened, closed := portscan.Diff(old, h.Ports)
+		for _, p := range opened {
+			fmt.Fprintf(w, "  + %s %d/tcp opened\n", h.Host, p)
+		}
+		for _, p := range closed {
+			fmt.Fprintf(w, "  - %s %d/tcp closed\n", h.Host, p)
+		}
+		changes += len(opened) + len(closed)
+	}
+	if compared > 0 && changes == 0 {
+		fmt.Fprintln(w, "  No changes")
+	}
 }
@@ -1,53 +1,267 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/JedizLaPulga/NNS/internal/proxy"
 )
 
-func runProxy(args []string) {
+// proxyFlags holds the flags of the proxy command.
+type proxyFlags struct {
+	portFlag        *int
+	verboseFlag     *bool
+	filterFlag      *string
+	mitmFlag        *bool
+	caFileFlag      *string
+	rulesFlag       *string
+	delayFlag       *time.Duration
+	jitterFlag      *time.Duration
+	bandwidthFlag   *string
+	replayFlag      *string
+	targetFlag      *string
+	concurrencyFlag *int
+	harFlag         *string
+	harMaxBodyFlag  *int64
+}
+
+// newProxyFlags defines the flags of the proxy command.
+func newProxyFlags() (*flag.FlagSet, *proxyFlags) {
 	fs := flag.NewFlagSet("proxy", flag.ExitOnError)
-	portFlag := fs.Int("port", 8080, "Port to listen on")
-	verboseFlag := fs.Bool("verbose", false, "Log full request/response details")
-	filterFlag := fs.String("filter", "", "Filter logs by domain/keyword")
+	cli := &proxyFlags{}
+	cli.portFlag = fs.Int("port", 8080, "Port to listen on")
+	cli.verboseFlag = fs.Bool("verbose", false, "Log full request/response details")
+	cli.filterFlag = fs.String("filter", "", "Filter logs by domain/keyword")
+	cli.mitmFlag = fs.Bool("mitm", false, "Intercept HTTPS traffic (requires trusting the CA)")
+	cli.caFileFlag = fs.String("ca-file", "nns-ca.pem", "CA certificate for --mitm (created if missing)")
+	cli.rulesFlag = fs.String("rules", "", "Request/response rewrite rules file (YAML or JSON)")
+	cli.delayFlag = fs.Duration("delay", 0, "Added latency before forwarding each request")
+	cli.jitterFlag = fs.Duration("jitter", 0, "Random +/- variation applied to --delay")
+	cli.bandwidthFlag = fs.String("bandwidth", "", "Per-connection bandwidth limit (e.g. 1mbps, 512kbps)")
+	cli.replayFlag = fs.String("replay", "", "Replay requests from a HAR capture instead of proxying")
+	cli.targetFlag = fs.String("target", "", "Replay against this base URL instead of the captured hosts")
+	cli.concurrencyFlag = fs.Int("concurrency", 1, "Parallel requests during --replay")
+	cli.harFlag = fs.String("har", "", "Record traffic and write a HAR file on shutdown")
+	cli.harMaxBodyFlag = fs.Int64("har-max-body", proxy.DefaultHARMaxBody, "Max bytes of each body to capture in the HAR")
 
 	// Short flags
-	fs.IntVar(portFlag, "p", 8080, "Port to listen on")
-	fs.BoolVar(verboseFlag, "v", false, "Log full request/response details")
+	fs.IntVar(cli.portFlag, "p", 8080, "Port to listen on")
+	fs.BoolVar(cli.verboseFlag, "v", false, "Log full request/response details")
 
 	fs.Usage = func() {
 		fmt.Println(`Usage: nns proxy [OPTIONS]
 
 Start a HTTP/HTTPS debug proxy server.
+WebSocket upgrades are relayed and each frame is logged.
 
 OPTIONS:
   -p, --port        Port to listen on (default: 8080)
-  -v, --verbose     Log verbose details
+  -v, --verbose     Log verbose details (includes WebSocket payloads)
       --filter      Filter logs by domain/keyword
+      --mitm        Intercept and log decrypted HTTPS traffic
+      --ca-file     CA certificate path for --mitm (default: nns-ca.pem)
+                    The key is stored next to it with a .key extension.
+                    A new CA is generated if the files do not exist.
+      --rules       Rewrite rules file (YAML, or JSON if it ends in .json)
+      --delay       Added latency per request (e.g. 200ms)
+      --jitter      Random +/- variation for --delay (e.g. 50ms)
+      --bandwidth   Per-connection bandwidth cap (e.g. 1mbps, 512kbps)
+      --har         Record traffic and write a HAR 1.2 file on shutdown
+      --har-max-body
+                    Max bytes captured per body in the HAR (default: 1048576)
+
+REPLAY:
+      --replay      Re-issue the requests in a HAR capture and compare
+                    status codes and timings with the original
+      --target      Base URL to replay against; its path prefixes each
+                    captured path (default: captured hosts)
+      --concurrency Parallel requests during replay (default: 1)
+
       --help        Show this help message
 
 EXAMPLES:
   nns proxy
   nns proxy -p 9090 -v
-  nns proxy --filter google.com`)
+  nns proxy --filter google.com
+  nns proxy --mitm --ca-file ~/.nns/ca.pem
+  nns proxy --mitm --har session.har
+  nns proxy --rules faults.yaml
+  nns proxy --delay 200ms --jitter 50ms --bandwidth 1mbps
+  nns proxy --replay session.har --target http://localhost:3000`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runProxy(args []string) {
+	fs, cli := newProxyFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		os.Exit(1)
 	}
 
+	if *cli.replayFlag != "" {
+		runProxyReplay(*cli.replayFlag, *cli.targetFlag, *cli.concurrencyFlag)
+		return
+	}
+
 	cfg := proxy.Config{
-		Port:    *portFlag,
-		Verbose: *verboseFlag,
-		Filter:  *filterFlag,
+		Port:    *cli.portFlag,
+		Verbose: *cli.verboseFlag,
+		Filter:  *cli.filterFlag,
+		MITM:    *cli.mitmFlag,
+		Delay:   *cli.delayFlag,
+		Jitter:  *cli.jitterFlag,
 	}
 
+	if *cli.bandwidthFlag != "" {
+		bps, err := proxy.ParseBandwidth(*cli.bandwidthFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		cfg.Bandwidth = bps
+	}
+
+	if *cli.mitmFlag {
+		ca, err := loadOrCreateCA(*cli.caFileFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		cfg.CA = ca
+	}
+
+	if *cli.rulesFlag != "" {
+		rf, err := proxy.LoadRules(*cli.rulesFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		cfg.Rules = rf.Rules
+		cfg.MatchAllRules = rf.MatchAll
+		fmt.Printf("Loaded %d rules from %s\n", len(rf.Rules), *cli.rulesFlag)
+	}
+
+	if *cli.harFlag != "" {
+		cfg.HAR = proxy.NewHARRecorder(*cli.harMaxBodyFlag)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		fmt.Println("\nShutting down...")
+		cancel()
+	}()
+
 	p := proxy.NewProxy(cfg)
-	if err := p.Start(); err != nil {
-		fmt.Fprintf(os.Stderr, "Proxy error: %v\n", err)
+	runErr := p.Run(ctx)
+
+	if cfg.HAR != nil {
+		if err := cfg.HAR.WriteFile(*cli.harFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing HAR: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Saved %d entries to %s\n", len(cfg.HAR.Entries()), *cli.harFlag)
+	}
+
+	if runErr != nil {
+		fmt.Fprintf(os.Stderr, "Proxy error: %v\n", runErr)
+		os.Exit(1)
+	}
+}
+
+func runProxyReplay(captureFile, target string, concurrency int) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		cancel()
+	}()
+
+	results, err := proxy.ReplayWithOptions(ctx, captureFile, target, proxy.ReplayOptions{Concurrency: concurrency})
+	if err != nil && results == nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+
+	fmt.Printf("%-4s %-6s %-50s %-11s %10s %10s %10s\n", "#", "METHOD", "URL", "STATUS", "ORIG", "REPLAY", "DIFF")
+	fmt.Println(strings.Repeat("─", 106))
+
+	var changed, failed, skipped int
+	for _, r := range results {
+		u := r.URL
+		if len(u) > 50 {
+			u = u[:47] + "..."
+		}
+		if r.Skipped {
+			skipped++
+			fmt.Printf("%-4d %-6s %-50s SKIPPED\n", r.Index+1, r.Method, u)
+			continue
+		}
+		if r.Error != nil {
+			failed++
+			fmt.Printf("%-4d %-6s %-50s ERROR: %v\n", r.Index+1, r.Method, u, r.Error)
+			continue
+		}
+
+		status := fmt.Sprintf("%d", r.Status)
+		if r.StatusChanged() {
+			changed++
+			status = fmt.Sprintf("%d→%d", r.OriginalStatus, r.Status)
+		}
+		diff := r.TimeDiff().Round(time.Millisecond)
+		sign := "+"
+		if diff < 0 {
+			sign = ""
+		}
+		fmt.Printf("%-4d %-6s %-50s %-11s %10v %10v %9s%v\n", r.Index+1, r.Method, u, status,
+			r.OriginalTime.Round(time.Millisecond), r.Time.Round(time.Millisecond), sign, diff)
+	}
+
+	fmt.Printf("\nReplayed %d requests: %d status changes, %d errors\n", len(results)-skipped, changed, failed)
+	if skipped > 0 {
+		fmt.Printf("Skipped %d requests\n", skipped)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Replay interrupted: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// loadOrCreateCA loads the MITM CA from certFile, generating and saving a
+// new one if it does not exist yet.
+func loadOrCreateCA(certFile string) (*proxy.CA, error) {
+	keyFile := strings.TrimSuffix(certFile, filepath.Ext(certFile)) + ".key"
+
+	if _, err := os.Stat(certFile); err == nil {
+		return proxy.LoadCA(certFile, keyFile)
+	}
+
+	ca, err := proxy.GenerateCA()
+	if err != nil {
+		return nil, err
+	}
+	if err := ca.WriteFiles(certFile, keyFile); err != nil {
+		return nil, fmt.Errorf("failed to save CA: %w", err)
+	}
+
+	fmt.Printf("Generated new CA certificate: %s\n", certFile)
+	fmt.Printf("Add it to your browser or OS trust store to intercept HTTPS without warnings.\n")
+	fmt.Printf("Keep %s private; anyone holding it can impersonate any site to you.\n\n", keyFile)
+	return ca, nil
 }
@@ -11,21 +11,34 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/ratelimit"
 )
 
-func runRatelimit(args []string) {
+// ratelimitFlags holds the flags of the ratelimit command.
+type ratelimitFlags struct {
+	count      *int
+	delay      *int
+	method     *string
+	timeout    *int
+	concurrent *int
+	verbose    *bool
+	headerFlag *string
+}
+
+// newRatelimitFlags defines the flags of the ratelimit command.
+func newRatelimitFlags() (*flag.FlagSet, *ratelimitFlags) {
 	fs := flag.NewFlagSet("ratelimit", flag.ExitOnError)
-	count := fs.Int("count", 30, "Number of requests to send")
-	delay := fs.Int("delay", 100, "Delay between requests in ms")
-	method := fs.String("method", "GET", "HTTP method to use")
-	timeout := fs.Int("timeout", 10, "Request timeout in seconds")
-	concurrent := fs.Int("concurrent", 1, "Number of concurrent requests")
-	verbose := fs.Bool("verbose", false, "Show per-request details")
-	headerFlag := fs.String("header", "", "Custom header (key:value), repeatable with comma")
+	cli := &ratelimitFlags{}
+	cli.count = fs.Int("count", 30, "Number of requests to send")
+	cli.delay = fs.Int("delay", 100, "Delay between requests in ms")
+	cli.method = fs.String("method", "GET", "HTTP method to use")
+	cli.timeout = fs.Int("timeout", 10, "Request timeout in seconds")
+	cli.concurrent = fs.Int("concurrent", 1, "Number of concurrent requests")
+	cli.verbose = fs.Bool("verbose", false, "Show per-request details")
+	cli.headerFlag = fs.String("header", "", "Custom header (key:value), repeatable with comma")
 
 	// Short flags
-	fs.IntVar(count, "n", 30, "Number of requests")
-	fs.IntVar(delay, "d", 100, "Delay (ms)")
-	fs.StringVar(method, "m", "GET", "HTTP method")
-	fs.BoolVar(verbose, "V", false, "Verbose")
+	fs.IntVar(cli.count, "n", 30, "Number of requests")
+	fs.IntVar(cli.delay, "d", 100, "Delay (ms)")
+	fs.StringVar(cli.method, "m", "GET", "HTTP method")
+	fs.BoolVar(cli.verbose, "V", false, "Verbose")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns ratelimit [options] <url>
@@ -52,7 +65,13 @@ Examples:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runRatelimit(args []string) {
+	fs, cli := newRatelimitFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -66,16 +85,16 @@ Examples:
 	url := fs.Arg(0)
 	opts := ratelimit.Options{
 		URL:        url,
-		Method:     strings.ToUpper(*method),
-		Count:      *count,
-		Delay:      time.Duration(*delay) * time.Millisecond,
-		Timeout:    time.Duration(*timeout) * time.Second,
-		Concurrent: *concurrent,
+		Method:     strings.ToUpper(*cli.method),
+		Count:      *cli.count,
+		Delay:      time.Duration(*cli.delay) * time.Millisecond,
+		Timeout:    time.Duration(*cli.timeout) * time.Second,
+		Concurrent: *cli.concurrent,
 	}
 
-	if *headerFlag != "" {
+	if *cli.headerFlag != "" {
 		opts.Headers = make(map[string]string)
-		for _, h := range strings.Split(*headerFlag, ",") {
+		for _, h := range strings.Split(*cli.headerFlag, ",") {
 			parts := strings.SplitN(strings.TrimSpace(h), ":", 2)
 			if len(parts) == 2 {
 				opts.Headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
@@ -87,16 +106,16 @@ Examples:
 	fmt.Printf("  Target:     %s\n", url)
 	fmt.Printf("  Method:     %s\n", opts.Method)
 	fmt.Printf("  Requests:   %d\n", opts.Count)
-	fmt.Printf("  Delay:      %dms\n", *delay)
-	if *concurrent > 1 {
-		fmt.Printf("  Concurrent: %d\n", *concurrent)
+	fmt.Printf("  Delay:      %dms\n", *cli.delay)
+	if *cli.concurrent > 1 {
+		fmt.Printf("  Concurrent: %d\n", *cli.concurrent)
 	}
 	fmt.Println()
 
 	ctx := context.Background()
 	summary := ratelimit.Probe(ctx, opts)
 
-	if *verbose {
+	if *cli.verbose {
 		fmt.Printf("── Request Details ──\n")
 		fmt.Print(ratelimit.FormatResults(summary.Results))
 		fmt.Println()
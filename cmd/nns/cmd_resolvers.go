@@ -13,14 +13,26 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/resolvers"
 )
 
-func runResolvers(args []string) {
+// resolversFlags holds the flags of the resolvers command.
+type resolversFlags struct {
+	queries  *int
+	timeout  *time.Duration
+	domain   *string
+	all      *bool
+	noSystem *bool
+	category *string
+}
+
+// newResolversFlags defines the flags of the resolvers command.
+func newResolversFlags() (*flag.FlagSet, *resolversFlags) {
 	fs := flag.NewFlagSet("resolvers", flag.ExitOnError)
-	queries := fs.Int("queries", 5, "Number of queries per resolver")
-	timeout := fs.Duration("timeout", 5*time.Second, "Query timeout")
-	domain := fs.String("domain", "google.com", "Test domain")
-	all := fs.Bool("all", false, "Test all known public resolvers")
-	noSystem := fs.Bool("no-system", false, "Skip system DNS test")
-	category := fs.String("category", "", "Filter by category (speed, privacy, security, family, adblock)")
+	cli := &resolversFlags{}
+	cli.queries = fs.Int("queries", 5, "Number of queries per resolver")
+	cli.timeout = fs.Duration("timeout", 5*time.Second, "Query timeout")
+	cli.domain = fs.String("domain", "google.com", "Test domain")
+	cli.all = fs.Bool("all", false, "Test all known public resolvers")
+	cli.noSystem = fs.Bool("no-system", false, "Skip system DNS test")
+	cli.category = fs.String("category", "", "Filter by category (speed, privacy, security, family, adblock)")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns resolvers [OPTIONS]
@@ -46,24 +58,30 @@ EXAMPLES:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runResolvers(args []string) {
+	fs, cli := newResolversFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		return
 	}
 
 	cfg := resolvers.Config{
-		QueryCount:    *queries,
-		Timeout:       *timeout,
-		TestDomain:    *domain,
-		IncludeSystem: !*noSystem,
+		QueryCount:    *cli.queries,
+		Timeout:       *cli.timeout,
+		TestDomain:    *cli.domain,
+		IncludeSystem: !*cli.noSystem,
 	}
 
 	// Determine which resolvers to test
-	if *all {
+	if *cli.all {
 		cfg.Resolvers = resolvers.PublicResolvers
-	} else if *category != "" {
-		cfg.Resolvers = resolvers.GetByCategory(*category)
+	} else if *cli.category != "" {
+		cfg.Resolvers = resolvers.GetByCategory(*cli.category)
 		if len(cfg.Resolvers) == 0 {
-			fmt.Fprintf(os.Stderr, "Unknown category: %s\n", *category)
+			fmt.Fprintf(os.Stderr, "Unknown category: %s\n", *cli.category)
 			fmt.Fprintf(os.Stderr, "Valid categories: speed, privacy, security, family, adblock\n")
 			os.Exit(1)
 		}
@@ -91,7 +109,7 @@ EXAMPLES:
 	}
 
 	fmt.Printf("Comparing DNS resolvers: %s\n", strings.Join(resolverNames, ", "))
-	fmt.Printf("Test domain: %s, Queries per resolver: %d\n\n", *domain, *queries)
+	fmt.Printf("Test domain: %s, Queries per resolver: %d\n\n", *cli.domain, *cli.queries)
 
 	result, err := comparator.Compare(ctx)
 	if err != nil && err != context.Canceled {
@@ -10,20 +10,33 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/routes"
 )
 
-func runRoutes(args []string) {
+// routesFlags holds the flags of the routes command.
+type routesFlags struct {
+	filterFlag      *string
+	ifaceFlag       *string
+	defaultOnlyFlag *bool
+	ipv4OnlyFlag    *bool
+	ipv6OnlyFlag    *bool
+	testGatewayFlag *bool
+	jsonFlag        *bool
+}
+
+// newRoutesFlags defines the flags of the routes command.
+func newRoutesFlags() (*flag.FlagSet, *routesFlags) {
 	fs := flag.NewFlagSet("routes", flag.ExitOnError)
-	filterFlag := fs.String("filter", "", "Filter routes by destination/gateway/interface")
-	ifaceFlag := fs.String("interface", "", "Show routes for specific interface")
-	defaultOnlyFlag := fs.Bool("default", false, "Show only default routes")
-	ipv4OnlyFlag := fs.Bool("4", false, "Show IPv4 routes only")
-	ipv6OnlyFlag := fs.Bool("6", false, "Show IPv6 routes only")
-	testGatewayFlag := fs.Bool("test", false, "Test default gateway connectivity")
-	jsonFlag := fs.Bool("json", false, "Output in JSON format")
+	cli := &routesFlags{}
+	cli.filterFlag = fs.String("filter", "", "Filter routes by destination/gateway/interface")
+	cli.ifaceFlag = fs.String("interface", "", "Show routes for specific interface")
+	cli.defaultOnlyFlag = fs.Bool("default", false, "Show only default routes")
+	cli.ipv4OnlyFlag = fs.Bool("4", false, "Show IPv4 routes only")
+	cli.ipv6OnlyFlag = fs.Bool("6", false, "Show IPv6 routes only")
+	cli.testGatewayFlag = fs.Bool("test", false, "Test default gateway connectivity")
+	cli.jsonFlag = fs.Bool("json", false, "Output in JSON format")
 
 	// Short flags
-	fs.StringVar(filterFlag, "f", "", "Filter routes")
-	fs.StringVar(ifaceFlag, "i", "", "Interface filter")
-	fs.BoolVar(defaultOnlyFlag, "d", false, "Default routes only")
+	fs.StringVar(cli.filterFlag, "f", "", "Filter routes")
+	fs.StringVar(cli.ifaceFlag, "i", "", "Interface filter")
+	fs.BoolVar(cli.defaultOnlyFlag, "d", false, "Default routes only")
 
 	fs.Usage = func() {
 		fmt.Println(`Usage: nns routes [OPTIONS]
@@ -48,7 +61,13 @@ EXAMPLES:
   nns routes --test`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runRoutes(args []string) {
+	fs, cli := newRoutesFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing flags: %v\n", err)
 		os.Exit(1)
 	}
@@ -65,15 +84,15 @@ EXAMPLES:
 	// Apply filters
 	displayRoutes := table.Routes
 
-	if *filterFlag != "" {
-		displayRoutes = table.Filter(*filterFlag)
+	if *cli.filterFlag != "" {
+		displayRoutes = table.Filter(*cli.filterFlag)
 	}
 
-	if *ifaceFlag != "" {
-		displayRoutes = table.GetInterfaceRoutes(*ifaceFlag)
+	if *cli.ifaceFlag != "" {
+		displayRoutes = table.GetInterfaceRoutes(*cli.ifaceFlag)
 	}
 
-	if *defaultOnlyFlag {
+	if *cli.defaultOnlyFlag {
 		var defaultRoutes []routes.Route
 		for _, r := range displayRoutes {
 			if r.IsDefault {
@@ -83,7 +102,7 @@ EXAMPLES:
 		displayRoutes = defaultRoutes
 	}
 
-	if *jsonFlag {
+	if *cli.jsonFlag {
 		printRoutesJSON(table, displayRoutes)
 		return
 	}
@@ -95,7 +114,7 @@ EXAMPLES:
 	if table.DefaultGateway != "" {
 		fmt.Printf("🌐 Default Gateway: %s via %s\n", table.DefaultGateway, table.DefaultIface)
 
-		if *testGatewayFlag {
+		if *cli.testGatewayFlag {
 			fmt.Printf("\n🔍 Testing gateway connectivity...\n")
 			gwInfo := routes.TestGateway(table.DefaultGateway, 5*time.Second)
 			if gwInfo.Reachable {
@@ -119,10 +138,10 @@ EXAMPLES:
 
 	for _, r := range displayRoutes {
 		// Skip based on IP version filter
-		if *ipv4OnlyFlag && strings.Contains(r.Destination, ":") {
+		if *cli.ipv4OnlyFlag && strings.Contains(r.Destination, ":") {
 			continue
 		}
-		if *ipv6OnlyFlag && !strings.Contains(r.Destination, ":") {
+		if *cli.ipv6OnlyFlag && !strings.Contains(r.Destination, ":") {
 			continue
 		}
 
@@ -14,23 +14,37 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/services"
 )
 
-func runServices(args []string) {
+// servicesFlags holds the flags of the services command.
+type servicesFlags struct {
+	portsFlag       *string
+	topFlag         *int
+	timeoutFlag     *time.Duration
+	concurrencyFlag *int
+	noTLSFlag       *bool
+	noProbesFlag    *bool
+	jsonFlag        *bool
+	openOnlyFlag    *bool
+}
+
+// newServicesFlags defines the flags of the services command.
+func newServicesFlags() (*flag.FlagSet, *servicesFlags) {
 	fs := flag.NewFlagSet("services", flag.ExitOnError)
-	portsFlag := fs.String("ports", "", "Ports to scan (e.g., '22,80,443' or '1-1000')")
-	topFlag := fs.Int("top", 0, "Scan top N common ports")
-	timeoutFlag := fs.Duration("timeout", 5*time.Second, "Connection timeout")
-	concurrencyFlag := fs.Int("concurrency", 10, "Number of parallel scans")
-	noTLSFlag := fs.Bool("no-tls", false, "Don't try TLS connections")
-	noProbesFlag := fs.Bool("no-probes", false, "Don't send protocol probes")
-	jsonFlag := fs.Bool("json", false, "Output JSON format")
-	openOnlyFlag := fs.Bool("open", false, "Show only open ports")
+	cli := &servicesFlags{}
+	cli.portsFlag = fs.String("ports", "", "Ports to scan (e.g., '22,80,443' or '1-1000')")
+	cli.topFlag = fs.Int("top", 0, "Scan top N common ports")
+	cli.timeoutFlag = fs.Duration("timeout", 5*time.Second, "Connection timeout")
+	cli.concurrencyFlag = fs.Int("concurrency", 10, "Number of parallel scans")
+	cli.noTLSFlag = fs.Bool("no-tls", false, "Don't try TLS connections")
+	cli.noProbesFlag = fs.Bool("no-probes", false, "Don't send protocol probes")
+	cli.jsonFlag = fs.Bool("json", false, "Output JSON format")
+	cli.openOnlyFlag = fs.Bool("open", false, "Show only open ports")
 
 	// Short flags
-	fs.StringVar(portsFlag, "p", "", "Ports to scan")
-	fs.IntVar(topFlag, "n", 0, "Scan top N common ports")
-	fs.DurationVar(timeoutFlag, "t", 5*time.Second, "Timeout")
-	fs.IntVar(concurrencyFlag, "c", 10, "Concurrency")
-	fs.BoolVar(openOnlyFlag, "o", false, "Show only open ports")
+	fs.StringVar(cli.portsFlag, "p", "", "Ports to scan")
+	fs.IntVar(cli.topFlag, "n", 0, "Scan top N common ports")
+	fs.DurationVar(cli.timeoutFlag, "t", 5*time.Second, "Timeout")
+	fs.IntVar(cli.concurrencyFlag, "c", 10, "Concurrency")
+	fs.BoolVar(cli.openOnlyFlag, "o", false, "Show only open ports")
 
 	fs.Usage = func() {
 		fmt.Println(`Usage: nns services [HOST] [OPTIONS]
@@ -55,7 +69,13 @@ EXAMPLES:
   nns services example.com -p 1-100 --open`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runServices(args []string) {
+	fs, cli := newServicesFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing flags: %v\n", err)
 		os.Exit(1)
 	}
@@ -70,24 +90,24 @@ EXAMPLES:
 
 	// Determine ports to scan
 	var ports []int
-	if *portsFlag != "" {
+	if *cli.portsFlag != "" {
 		var err error
-		ports, err = parsePorts(*portsFlag)
+		ports, err = parsePorts(*cli.portsFlag)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error parsing ports: %v\n", err)
 			os.Exit(1)
 		}
-	} else if *topFlag > 0 {
-		ports = services.TopPorts(*topFlag)
+	} else if *cli.topFlag > 0 {
+		ports = services.TopPorts(*cli.topFlag)
 	} else {
 		ports = services.CommonPorts()
 	}
 
 	scanner := services.NewScanner(host, ports)
-	scanner.Timeout = *timeoutFlag
-	scanner.Concurrency = *concurrencyFlag
-	scanner.TryTLS = !*noTLSFlag
-	scanner.SendProbes = !*noProbesFlag
+	scanner.Timeout = *cli.timeoutFlag
+	scanner.Concurrency = *cli.concurrencyFlag
+	scanner.TryTLS = !*cli.noTLSFlag
+	scanner.SendProbes = !*cli.noProbesFlag
 
 	// Handle Ctrl+C
 	ctx, cancel := context.WithCancel(context.Background())
@@ -114,7 +134,7 @@ EXAMPLES:
 	})
 
 	// Filter if needed
-	if *openOnlyFlag {
+	if *cli.openOnlyFlag {
 		filtered := make([]services.ServiceInfo, 0)
 		for _, r := range results {
 			if r.State == "open" {
@@ -124,7 +144,7 @@ EXAMPLES:
 		results = filtered
 	}
 
-	if *jsonFlag {
+	if *cli.jsonFlag {
 		printServicesJSON(results)
 	} else {
 		printServicesTable(results)
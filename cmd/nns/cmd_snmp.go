@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
@@ -13,15 +14,46 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/snmp"
 )
 
-func runSNMP(args []string) {
+// snmpFlags holds the flags of the snmp command.
+type snmpFlags struct {
+	community   stringList
+	communities *string
+	port        *int
+	timeout     *time.Duration
+	walk        *bool
+	audit       *bool
+	concurrency *int
+	version     *string
+	user        *string
+	authProto   *string
+	authPass    *string
+	privProto   *string
+	privPass    *string
+	contextName *string
+	interfaces  *bool
+	jsonOut     *bool
+}
+
+// newSNMPFlags defines the flags of the snmp command.
+func newSNMPFlags() (*flag.FlagSet, *snmpFlags) {
 	fs := flag.NewFlagSet("snmp", flag.ExitOnError)
-	community := fs.String("community", "public", "SNMP community string")
-	communities := fs.String("communities", "", "Test multiple communities (comma-separated)")
-	port := fs.Int("port", 161, "SNMP port")
-	timeout := fs.Duration("timeout", 3*time.Second, "Query timeout")
-	walk := fs.Bool("walk", true, "Walk common OIDs")
-	audit := fs.Bool("audit", true, "Security audit (test common community strings)")
-	concurrency := fs.Int("concurrency", 10, "Concurrent scans")
+	cli := &snmpFlags{}
+	fs.Var(&cli.community, "community", "SNMP community string, repeatable (default: public)")
+	cli.communities = fs.String("communities", "", "Test multiple communities (comma-separated)")
+	cli.port = fs.Int("port", 161, "SNMP port")
+	cli.timeout = fs.Duration("timeout", 3*time.Second, "Query timeout")
+	cli.walk = fs.Bool("walk", true, "Walk common OIDs")
+	cli.audit = fs.Bool("audit", true, "Security audit (test common community strings)")
+	cli.concurrency = fs.Int("concurrency", 10, "Concurrent scans")
+	cli.version = fs.String("version", "2c", "SNMP version: 1, 2c or 3")
+	cli.user = fs.String("user", "", "SNMPv3 username")
+	cli.authProto = fs.String("auth-proto", "", "SNMPv3 authentication protocol: MD5 or SHA")
+	cli.authPass = fs.String("auth-pass", "", "SNMPv3 authentication passphrase")
+	cli.privProto = fs.String("priv-proto", "", "SNMPv3 privacy protocol: DES or AES")
+	cli.privPass = fs.String("priv-pass", "", "SNMPv3 privacy passphrase")
+	cli.contextName = fs.String("context", "", "SNMPv3 context name")
+	cli.interfaces = fs.Bool("interfaces", false, "List interfaces and traffic counters (ifTable) of a single host")
+	cli.jsonOut = fs.Bool("json", false, "Output in JSON format")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns snmp [OPTIONS] <target>
@@ -39,10 +71,20 @@ EXAMPLES:
     nns snmp 192.168.1.1 --community private
     nns snmp 192.168.1.0/24 --audit
     nns snmp router.local --communities public,private,admin
+    nns snmp router.local --community public --community s3cret
+    nns snmp 192.168.1.1 --interfaces
+    nns snmp 192.168.1.0/24 --json
+    nns snmp 10.0.0.1 --version 3 --user monitor --auth-proto SHA --auth-pass secret123 --priv-proto AES --priv-pass secret456
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runSNMP(args []string) {
+	fs, cli := newSNMPFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		return
 	}
 
@@ -54,18 +96,40 @@ EXAMPLES:
 	target := fs.Arg(0)
 
 	cfg := snmp.Config{
-		Port:          *port,
-		Timeout:       *timeout,
-		Concurrency:   *concurrency,
-		WalkOIDs:      *walk,
-		SecurityAudit: *audit,
+		Port:          *cli.port,
+		Timeout:       *cli.timeout,
+		Concurrency:   *cli.concurrency,
+		WalkOIDs:      *cli.walk,
+		SecurityAudit: *cli.audit,
+	}
+
+	switch *cli.version {
+	case "1":
+		cfg.Version = snmp.Version1
+	case "2c", "2":
+		cfg.Version = snmp.Version2c
+	case "3":
+		cfg.Version = snmp.Version3
+		cfg.Username = *cli.user
+		cfg.AuthProtocol = snmp.AuthProtocol(strings.ToUpper(*cli.authProto))
+		cfg.AuthPassphrase = *cli.authPass
+		cfg.PrivProtocol = snmp.PrivProtocol(strings.ToUpper(*cli.privProto))
+		cfg.PrivPassphrase = *cli.privPass
+		cfg.ContextName = *cli.contextName
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unsupported SNMP version %q (use 1, 2c or 3)\n", *cli.version)
+		os.Exit(1)
 	}
 
 	// Determine communities to test
-	if *communities != "" {
-		cfg.Communities = strings.Split(*communities, ",")
-	} else {
-		cfg.Communities = []string{*community}
+	cfg.Communities = append(cfg.Communities, cli.community...)
+	for _, c := range strings.Split(*cli.communities, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			cfg.Communities = append(cfg.Communities, c)
+		}
+	}
+	if len(cfg.Communities) == 0 {
+		cfg.Communities = []string{"public"}
 	}
 
 	scanner := snmp.New(cfg)
@@ -82,11 +146,42 @@ EXAMPLES:
 		cancel()
 	}()
 
-	fmt.Printf("Scanning %s for SNMP devices...\n", target)
-	if *audit {
-		fmt.Println("Security audit enabled (testing common community strings)")
+	if *cli.interfaces {
+		if strings.Contains(target, "/") {
+			fmt.Fprintf(os.Stderr, "Error: --interfaces needs a single host, not a range\n")
+			os.Exit(1)
+		}
+		ifaces, err := scanner.GetInterfaces(ctx, target, cfg.Communities[0])
+		if err != nil && len(ifaces) == 0 {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if *cli.jsonOut {
+			if ifaces == nil {
+				ifaces = []snmp.SNMPInterface{}
+			}
+			data, jerr := json.MarshalIndent(ifaces, "", "  ")
+			if jerr != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", jerr)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+		} else {
+			fmt.Print(snmp.FormatInterfaces(target, ifaces))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: interface table incomplete: %v\n", err)
+		}
+		return
+	}
+
+	if !*cli.jsonOut {
+		fmt.Printf("Scanning %s for SNMP devices...\n", target)
+		if *cli.audit {
+			fmt.Println("Security audit enabled (testing common community strings)")
+		}
+		fmt.Println()
 	}
-	fmt.Println()
 
 	result, err := scanner.ScanNetwork(ctx, target)
 	if err != nil && err != context.Canceled {
@@ -94,5 +189,15 @@ EXAMPLES:
 		os.Exit(1)
 	}
 
+	if *cli.jsonOut {
+		out, err := result.ToJSON()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(out)
+		return
+	}
+
 	fmt.Print(result.Format())
 }
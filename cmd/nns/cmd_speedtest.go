@@ -10,11 +10,19 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/speedtest"
 )
 
-func runSpeedtest(args []string) {
+// speedtestFlags holds the flags of the speedtest command.
+type speedtestFlags struct {
+	urlFlag     *string
+	timeoutFlag *time.Duration
+}
+
+// newSpeedtestFlags defines the flags of the speedtest command.
+func newSpeedtestFlags() (*flag.FlagSet, *speedtestFlags) {
 	fs := flag.NewFlagSet("speedtest", flag.ExitOnError)
+	cli := &speedtestFlags{}
 
-	urlFlag := fs.String("url", "", "Custom download URL for testing")
-	timeoutFlag := fs.Duration("timeout", 60*time.Second, "Test timeout")
+	cli.urlFlag = fs.String("url", "", "Custom download URL for testing")
+	cli.timeoutFlag = fs.Duration("timeout", 60*time.Second, "Test timeout")
 
 	fs.Usage = func() {
 		fmt.Println(`Usage: nns speedtest [OPTIONS]
@@ -31,15 +39,21 @@ EXAMPLES:
   nns speedtest --url http://speedtest.example.com/100MB.bin`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runSpeedtest(args []string) {
+	fs, cli := newSpeedtestFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		os.Exit(1)
 	}
 
 	cfg := speedtest.DefaultConfig()
-	if *urlFlag != "" {
-		cfg.DownloadURL = *urlFlag
+	if *cli.urlFlag != "" {
+		cfg.DownloadURL = *cli.urlFlag
 	}
-	cfg.Timeout = *timeoutFlag
+	cfg.Timeout = *cli.timeoutFlag
 
 	tester := speedtest.NewTester(cfg)
 
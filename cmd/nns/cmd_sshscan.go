@@ -12,11 +12,20 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/sshscan"
 )
 
-func runSSHScan(args []string) {
+// sshScanFlags holds the flags of the sshscan command.
+type sshScanFlags struct {
+	port      *int
+	timeout   *time.Duration
+	vulnsOnly *bool
+}
+
+// newSSHScanFlags defines the flags of the sshscan command.
+func newSSHScanFlags() (*flag.FlagSet, *sshScanFlags) {
 	fs := flag.NewFlagSet("sshscan", flag.ExitOnError)
-	port := fs.Int("port", 22, "SSH port")
-	timeout := fs.Duration("timeout", 10*time.Second, "Connection timeout")
-	vulnsOnly := fs.Bool("vulns", false, "Show only vulnerabilities")
+	cli := &sshScanFlags{}
+	cli.port = fs.Int("port", 22, "SSH port")
+	cli.timeout = fs.Duration("timeout", 10*time.Second, "Connection timeout")
+	cli.vulnsOnly = fs.Bool("vulns", false, "Show only vulnerabilities")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns sshscan [OPTIONS] <host>
@@ -34,7 +43,13 @@ EXAMPLES:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runSSHScan(args []string) {
+	fs, cli := newSSHScanFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		return
 	}
 
@@ -46,8 +61,8 @@ EXAMPLES:
 	host := fs.Arg(0)
 
 	opts := sshscan.Options{
-		Port:    *port,
-		Timeout: *timeout,
+		Port:    *cli.port,
+		Timeout: *cli.timeout,
 	}
 
 	scanner := sshscan.NewScanner(opts)
@@ -64,7 +79,7 @@ EXAMPLES:
 		cancel()
 	}()
 
-	fmt.Printf("Scanning SSH server %s:%d...\n\n", host, *port)
+	fmt.Printf("Scanning SSH server %s:%d...\n\n", host, *cli.port)
 
 	result, err := scanner.Scan(ctx, host)
 	if err != nil {
@@ -72,8 +87,8 @@ EXAMPLES:
 		os.Exit(1)
 	}
 
-	if *vulnsOnly {
-		fmt.Printf("SSH Security Audit: %s:%d\n", host, *port)
+	if *cli.vulnsOnly {
+		fmt.Printf("SSH Security Audit: %s:%d\n", host, *cli.port)
 		fmt.Printf("Grade: %s (%d/100)\n\n", result.Grade, result.Score)
 
 		if len(result.Vulnerabilities) == 0 {
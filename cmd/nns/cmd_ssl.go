@@ -6,17 +6,33 @@ import (
 	"os"
 	"time"
 
+	"github.com/JedizLaPulga/NNS/internal/output"
 	"github.com/JedizLaPulga/NNS/internal/ssl"
 )
 
-func runSSL(args []string) {
+// sslFlags holds the flags of the ssl command.
+type sslFlags struct {
+	chainFlag   *bool
+	jsonFlag    *bool
+	expiryFlag  *bool
+	gradeFlag   *bool
+	timeoutFlag *time.Duration
+	noAIAFlag   *bool
+	pinFlags    stringList
+}
+
+// newSSLFlags defines the flags of the ssl command.
+func newSSLFlags() (*flag.FlagSet, *sslFlags) {
 	fs := flag.NewFlagSet("ssl", flag.ExitOnError)
+	cli := &sslFlags{}
 
-	chainFlag := fs.Bool("chain", false, "Show full certificate chain")
-	jsonFlag := fs.Bool("json", false, "Output in JSON format")
-	expiryFlag := fs.Bool("expiry", false, "Show only expiry information")
-	gradeFlag := fs.Bool("grade", false, "Show only security grade")
-	timeoutFlag := fs.Duration("timeout", 10*time.Second, "Connection timeout")
+	cli.chainFlag = fs.Bool("chain", false, "Show full certificate chain")
+	cli.jsonFlag = fs.Bool("json", false, "Output in JSON format")
+	cli.expiryFlag = fs.Bool("expiry", false, "Show only expiry information")
+	cli.gradeFlag = fs.Bool("grade", false, "Show only security grade")
+	cli.timeoutFlag = fs.Duration("timeout", 10*time.Second, "Connection timeout")
+	cli.noAIAFlag = fs.Bool("no-aia", false, "Don't download missing intermediates via AIA")
+	fs.Var(&cli.pinFlags, "pin", "Expected public key pin sha256//BASE64 (repeatable)")
 
 	fs.Usage = func() {
 		fmt.Println(`Usage: nns ssl [HOST[:PORT]] [OPTIONS]
@@ -29,6 +45,10 @@ OPTIONS:
       --expiry      Show only expiry information
       --grade       Show only security grade
       --timeout     Connection timeout (default: 10s)
+      --no-aia      Don't download missing intermediates from the AIA URL
+                    when diagnosing an incomplete chain
+      --pin PIN     Expected public key pin, sha256//BASE64 (repeatable);
+                    passes if any certificate in the chain matches
       --help        Show this help message
 
 EXAMPLES:
@@ -38,6 +58,11 @@ EXAMPLES:
   nns ssl example.com --json         # JSON output
   nns ssl example.com --expiry       # Just expiry status
   nns ssl example.com --grade        # Just security grade
+  nns ssl --pin sha256//AbC...= --pin sha256//XyZ...= api.example.com
+
+Chain problems (missing intermediates, expired certificates in the chain,
+name mismatches, untrusted roots) are always reported. A pin mismatch is
+a critical issue that lowers the grade and makes nns exit with status 1.
 
 SECURITY GRADES:
   A+ : Excellent - No issues, TLS 1.2+, strong cipher
@@ -48,7 +73,13 @@ SECURITY GRADES:
   F  : Fail - Expired, weak crypto, or self-signed`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runSSL(args []string) {
+	fs, cli := newSSLFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		os.Exit(1)
 	}
 
@@ -60,9 +91,20 @@ SECURITY GRADES:
 
 	host, port := ssl.ParseHostPort(fs.Arg(0))
 
+	pins := make([]string, len(cli.pinFlags))
+	for i, p := range cli.pinFlags {
+		pin, err := ssl.ParsePin(p)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		pins[i] = pin
+	}
+
 	// Create analyzer
 	analyzer := ssl.NewAnalyzer()
-	analyzer.Timeout = *timeoutFlag
+	analyzer.Timeout = *cli.timeoutFlag
+	analyzer.FetchAIA = !*cli.noAIAFlag
 
 	result := analyzer.Analyze(host, port)
 
@@ -71,32 +113,49 @@ SECURITY GRADES:
 		os.Exit(1)
 	}
 
+	pinned := true
+	if len(pins) > 0 {
+		pinned = result.VerifyPins(pins)
+	}
+
 	// JSON output
-	if *jsonFlag {
+	if *cli.jsonFlag {
 		jsonOutput, err := result.ToJSON()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "JSON error: %v\n", err)
 			os.Exit(1)
 		}
 		fmt.Println(jsonOutput)
+		exitOnPinMismatch(pinned)
 		return
 	}
 
 	// Expiry only
-	if *expiryFlag {
+	if *cli.expiryFlag {
 		fmt.Printf("%s:%d — %s\n", host, port, result.ExpiryStatus())
+		exitOnPinMismatch(pinned)
 		return
 	}
 
 	// Grade only
-	if *gradeFlag {
+	if *cli.gradeFlag {
 		fmt.Printf("%s:%d — Grade: %s (Score: %d/100)\n",
 			host, port, result.Security.Grade, result.Security.Score)
+		exitOnPinMismatch(pinned)
 		return
 	}
 
 	// Full output
-	printSSLResult(result, *chainFlag)
+	printSSLResult(result, *cli.chainFlag)
+	exitOnPinMismatch(pinned)
+}
+
+// exitOnPinMismatch exits with status 1 when pin verification failed, so
+// monitoring scripts can alert on a changed key.
+func exitOnPinMismatch(pinned bool) {
+	if !pinned {
+		os.Exit(1)
+	}
 }
 
 func printSSLResult(r *ssl.Result, showChain bool) {
@@ -104,21 +163,8 @@ func printSSLResult(r *ssl.Result, showChain bool) {
 	fmt.Println("═══════════════════════════════════════════════════════════════")
 
 	// Security Grade
-	gradeColor := ""
-	gradeReset := ""
-	switch r.Security.Grade[0] {
-	case 'A':
-		gradeColor = "\033[32m" // Green
-		gradeReset = "\033[0m"
-	case 'B':
-		gradeColor = "\033[33m" // Yellow
-		gradeReset = "\033[0m"
-	default:
-		gradeColor = "\033[31m" // Red
-		gradeReset = "\033[0m"
-	}
-	fmt.Printf("\n  Security Grade: %s%s%s (Score: %d/100)\n",
-		gradeColor, r.Security.Grade, gradeReset, r.Security.Score)
+	fmt.Printf("\n  Security Grade: %s (Score: %d/100)\n",
+		output.Stdout.Grade(r.Security.Grade), r.Security.Score)
 
 	// Certificate info
 	fmt.Println("\n─── Certificate ────────────────────────────────────────────────")
@@ -150,6 +196,7 @@ func printSSLResult(r *ssl.Result, showChain bool) {
 	fmt.Printf("  Signature:    %s\n", r.Certificate.SignatureAlg)
 	fmt.Printf("  Public Key:   %s (%d bits)\n", r.Certificate.PublicKeyAlg, r.Certificate.PublicKeySize)
 	fmt.Printf("  Fingerprint:  %s\n", truncate(r.Certificate.Fingerprint, 32)+"...")
+	fmt.Printf("  SPKI Pin:     %s\n", r.Certificate.SPKIPin)
 
 	// Connection
 	fmt.Println("\n─── Connection ─────────────────────────────────────────────────")
@@ -171,6 +218,20 @@ func printSSLResult(r *ssl.Result, showChain bool) {
 		}
 	}
 
+	// Pinning
+	if r.Pins != nil {
+		fmt.Println("\n─── Public Key Pinning ─────────────────────────────────────────")
+		if r.Pins.Matched {
+			fmt.Printf("  ✓ Pin matched: %s\n", r.Pins.MatchedPin)
+			fmt.Printf("    Certificate: %s\n", truncate(r.Pins.MatchedCert, 50))
+		} else {
+			fmt.Printf("  ✗ Pin mismatch: none of %d pin(s) match the chain\n", len(r.Pins.Pins))
+			for i, cert := range r.Chain.Certificates {
+				fmt.Printf("    [%d] %s\n", i, cert.SPKIPin)
+			}
+		}
+	}
+
 	// Chain
 	if showChain && len(r.Chain.Certificates) > 1 {
 		fmt.Println("\n─── Certificate Chain ──────────────────────────────────────────")
@@ -189,6 +250,15 @@ func printSSLResult(r *ssl.Result, showChain bool) {
 			r.Chain.IsComplete, r.Chain.HasTrustedRoot)
 	}
 
+	// Chain problems are shown even without --chain, since they explain
+	// why clients reject the certificate.
+	if len(r.Chain.Problems) > 0 {
+		fmt.Println("\n─── Chain Problems ─────────────────────────────────────────────")
+		for _, p := range r.Chain.Problems {
+			fmt.Printf("  ✗ %s\n", p)
+		}
+	}
+
 	fmt.Println()
 }
 
@@ -9,13 +9,24 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/subnet"
 )
 
-func runSubnet(args []string) {
+// subnetFlags holds the flags of the subnet command.
+type subnetFlags struct {
+	split     *int
+	listHosts *bool
+	limit     *int
+	contains  *string
+	overlap   *string
+}
+
+// newSubnetFlags defines the flags of the subnet command.
+func newSubnetFlags() (*flag.FlagSet, *subnetFlags) {
 	fs := flag.NewFlagSet("subnet", flag.ExitOnError)
-	split := fs.Int("split", 0, "Split into smaller subnets with this prefix")
-	listHosts := fs.Bool("hosts", false, "List usable host IPs")
-	limit := fs.Int("limit", 100, "Max hosts to list")
-	contains := fs.String("contains", "", "Check if subnet contains this IP")
-	overlap := fs.String("overlap", "", "Check if subnet overlaps with another CIDR")
+	cli := &subnetFlags{}
+	cli.split = fs.Int("split", 0, "Split into smaller subnets with this prefix")
+	cli.listHosts = fs.Bool("hosts", false, "List usable host IPs")
+	cli.limit = fs.Int("limit", 100, "Max hosts to list")
+	cli.contains = fs.String("contains", "", "Check if subnet contains this IP")
+	cli.overlap = fs.String("overlap", "", "Check if subnet overlaps with another CIDR")
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: nns subnet [options] <cidr>\n\n")
 		fmt.Fprintf(os.Stderr, "Subnet calculator for network planning and analysis.\n\n")
@@ -27,7 +38,13 @@ func runSubnet(args []string) {
 		fmt.Fprintf(os.Stderr, "  nns subnet --hosts 192.168.1.0/28     # List usable hosts\n")
 		fmt.Fprintf(os.Stderr, "  nns subnet --contains 192.168.1.50 192.168.1.0/24\n")
 	}
-	fs.Parse(args)
+
+	return fs, cli
+}
+
+func runSubnet(args []string) {
+	fs, cli := newSubnetFlags()
+	parseFlags(fs, args)
 
 	if fs.NArg() < 1 {
 		fs.Usage()
@@ -37,44 +54,44 @@ func runSubnet(args []string) {
 	cidr := fs.Arg(0)
 
 	// Handle contains check
-	if *contains != "" {
-		result, err := subnet.Contains(cidr, *contains)
+	if *cli.contains != "" {
+		result, err := subnet.Contains(cidr, *cli.contains)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 		if result {
-			fmt.Printf("✓ %s contains %s\n", cidr, *contains)
+			fmt.Printf("✓ %s contains %s\n", cidr, *cli.contains)
 		} else {
-			fmt.Printf("✗ %s does NOT contain %s\n", cidr, *contains)
+			fmt.Printf("✗ %s does NOT contain %s\n", cidr, *cli.contains)
 			os.Exit(1)
 		}
 		return
 	}
 
 	// Handle overlap check
-	if *overlap != "" {
-		result, err := subnet.Overlaps(cidr, *overlap)
+	if *cli.overlap != "" {
+		result, err := subnet.Overlaps(cidr, *cli.overlap)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 		if result {
-			fmt.Printf("⚠ %s OVERLAPS with %s\n", cidr, *overlap)
+			fmt.Printf("⚠ %s OVERLAPS with %s\n", cidr, *cli.overlap)
 		} else {
-			fmt.Printf("✓ %s does NOT overlap with %s\n", cidr, *overlap)
+			fmt.Printf("✓ %s does NOT overlap with %s\n", cidr, *cli.overlap)
 		}
 		return
 	}
 
 	// Handle split
-	if *split > 0 {
-		subnets, err := subnet.Split(cidr, *split)
+	if *cli.split > 0 {
+		subnets, err := subnet.Split(cidr, *cli.split)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Printf("\nSplitting %s into /%d subnets:\n\n", cidr, *split)
+		fmt.Printf("\nSplitting %s into /%d subnets:\n\n", cidr, *cli.split)
 		for i, s := range subnets {
 			info, _ := subnet.Calculate(s)
 			fmt.Printf("  %2d. %-20s  Hosts: %-6d  Range: %s - %s\n",
@@ -85,8 +102,8 @@ func runSubnet(args []string) {
 	}
 
 	// Handle list hosts
-	if *listHosts {
-		hosts, err := subnet.ListHosts(cidr, *limit)
+	if *cli.listHosts {
+		hosts, err := subnet.ListHosts(cidr, *cli.limit)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
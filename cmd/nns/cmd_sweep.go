@@ -5,45 +5,91 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/JedizLaPulga/NNS/internal/portscan"
 	"github.com/JedizLaPulga/NNS/internal/sweep"
 )
 
-func runSweep(args []string) {
-	fs := flag.NewFlagSet("sweep", flag.ExitOnError)
+// sweepFlags holds the flags of the sweep command.
+type sweepFlags struct {
+	timeoutFlag    *time.Duration
+	concurrentFlag *int
+	portsFlag      *string
+	resolveFlag    *bool
+	methodFlag     *string
+	jsonFlag       *bool
+	csvFlag        *bool
+	macFlag        *bool
+	excludes       stringList
+	source         func() string
+}
 
-	timeoutFlag := fs.Duration("timeout", 1*time.Second, "Timeout per host")
-	concurrentFlag := fs.Int("concurrent", 256, "Number of concurrent workers")
-	portsFlag := fs.String("ports", "80,443,22,445,3389", "Ports to check for TCP method")
-	resolveFlag := fs.Bool("resolve", true, "Resolve hostnames")
+// newSweepFlags defines the flags of the sweep command.
+func newSweepFlags() (*flag.FlagSet, *sweepFlags) {
+	fs := flag.NewFlagSet("sweep", flag.ExitOnError)
+	cli := &sweepFlags{}
+
+	cli.timeoutFlag = fs.Duration("timeout", 1*time.Second, "Timeout per host")
+	cli.concurrentFlag = fs.Int("concurrent", 256, "Number of concurrent workers")
+	cli.portsFlag = fs.String("ports", "80,443,22,445,3389", "Ports to check for TCP method")
+	cli.resolveFlag = fs.Bool("resolve", true, "Resolve hostnames")
+	cli.methodFlag = fs.String("method", "tcp", "Discovery method: tcp, icmp, arp")
+	cli.jsonFlag = fs.Bool("json", false, "Output results as JSON")
+	cli.csvFlag = fs.Bool("csv", false, "Output results as CSV")
+	cli.macFlag = fs.Bool("mac", false, "Show MAC address and vendor of local hosts")
+	fs.Var(&cli.excludes, "exclude", "CIDR or IP to skip (repeatable)")
+	cli.source = sourceFlags(fs)
 
 	// Short flags
-	fs.DurationVar(timeoutFlag, "t", 1*time.Second, "Timeout")
-	fs.IntVar(concurrentFlag, "c", 256, "Concurrent workers")
-	fs.StringVar(portsFlag, "p", "80,443,22,445,3389", "Ports")
-	fs.BoolVar(resolveFlag, "r", true, "Resolve hostnames")
+	fs.DurationVar(cli.timeoutFlag, "t", 1*time.Second, "Timeout")
+	fs.IntVar(cli.concurrentFlag, "c", 256, "Concurrent workers")
+	fs.StringVar(cli.portsFlag, "p", "80,443,22,445,3389", "Ports")
+	fs.BoolVar(cli.resolveFlag, "r", true, "Resolve hostnames")
+	fs.StringVar(cli.methodFlag, "m", "tcp", "Discovery method")
 
 	fs.Usage = func() {
-		fmt.Println(`Usage: nns sweep [CIDR] [OPTIONS]
+		fmt.Println(`Usage: nns sweep [OPTIONS] <CIDR>...
 
-Discover live hosts on a network using TCP probes.
+Discover live hosts on a network using TCP, ICMP or ARP probes.
 
 OPTIONS:
+  -m, --method       Discovery method: tcp, icmp, arp (default: tcp)
+                     icmp falls back to tcp without the required privileges
+                     arp works on directly connected subnets and needs root
   -t, --timeout      Timeout per host (default: 1s)
   -c, --concurrent   Number of concurrent workers (default: 256)
   -p, --ports        Ports to check (default: 80,443,22,445,3389)
   -r, --resolve      Resolve hostnames (default: true)
+      --exclude      CIDR or IP to skip (repeatable)
+  -I, --interface    Send TCP/ICMP probes from this interface's IPv4 address
+  -S, --source-ip    Send TCP/ICMP probes from this local IPv4 address
+      --mac          Show MAC and VENDOR columns for hosts on the local subnet
+      --json         Output live hosts as JSON after the scan
+      --csv          Output live hosts as CSV after the scan
       --help         Show this help message
 
 EXAMPLES:
   nns sweep 192.168.1.0/24
   nns sweep 10.0.0.0/16 --timeout 2s
-  nns sweep 172.16.0.0/24 --ports 22,80,443,8080`)
+  nns sweep 10.0.0.0/24 10.0.5.0/24
+  nns sweep --exclude 192.168.1.0/28 --exclude 192.168.1.200 192.168.1.0/24
+  nns sweep 172.16.0.0/24 --ports 22,80,443,8080
+  sudo nns sweep 192.168.1.0/24 --method icmp
+  sudo nns sweep 192.168.1.0/24 --method arp
+  nns sweep 192.168.1.0/24 --mac
+  nns sweep 192.168.1.0/24 --csv > hosts.csv
+  nns sweep -I eth1 10.20.0.0/24`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runSweep(args []string) {
+	fs, cli := newSweepFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		os.Exit(1)
 	}
 
@@ -53,31 +99,89 @@ EXAMPLES:
 		os.Exit(1)
 	}
 
-	cidr := fs.Arg(0)
+	targets := fs.Args()
+
+	switch *cli.methodFlag {
+	case "tcp", "icmp", "arp":
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown method %q (use tcp, icmp or arp)\n", *cli.methodFlag)
+		os.Exit(1)
+	}
+
+	if *cli.jsonFlag && *cli.csvFlag {
+		fmt.Fprintf(os.Stderr, "Error: --json and --csv are mutually exclusive\n")
+		os.Exit(1)
+	}
+	structured := *cli.jsonFlag || *cli.csvFlag
 
 	// Parse ports
-	ports, err := portscan.ParsePortRange(*portsFlag)
+	ports, err := portscan.ParsePortRange(*cli.portsFlag)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing ports: %v\n", err)
 		os.Exit(1)
 	}
 
+	hosts, err := sweep.ExpandTargets(targets, cli.excludes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	cfg := sweep.Config{
-		CIDR:        cidr,
-		Timeout:     *timeoutFlag,
-		Concurrency: *concurrentFlag,
-		Method:      "tcp",
+		CIDR:        targets[0],
+		Targets:     targets[1:],
+		Exclude:     cli.excludes,
+		Timeout:     *cli.timeoutFlag,
+		Concurrency: *cli.concurrentFlag,
+		Method:      *cli.methodFlag,
 		Ports:       ports,
-		Resolve:     *resolveFlag,
+		Resolve:     *cli.resolveFlag,
+		LookupMAC:   *cli.macFlag,
+		SourceIP:    cli.source(),
 	}
 
 	sweeper := sweep.NewSweeper(cfg)
 
-	// Count hosts
-	hostCount, _ := sweep.CountHosts(cidr)
-	fmt.Printf("Sweeping %s (%d hosts)...\n\n", cidr, hostCount)
+	if structured {
+		results, err := sweeper.Sweep(context.Background(), nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if sweeper.Fallback != "" {
+			fmt.Fprintf(os.Stderr, "Note: %s\n", sweeper.Fallback)
+		}
 
-	fmt.Printf("%-16s %-8s %-30s %s\n", "IP", "PORT", "HOSTNAME", "LATENCY")
+		result := sweep.NewResult(cfg, results)
+		var out string
+		if *cli.jsonFlag {
+			out, err = result.ToJSON()
+		} else {
+			out, err = result.ToCSV()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(out)
+		if *cli.jsonFlag {
+			fmt.Println()
+		}
+		return
+	}
+
+	if len(cli.excludes) > 0 {
+		fmt.Printf("Sweeping %s excluding %s (%d hosts)...\n\n", strings.Join(targets, ", "), strings.Join(cli.excludes, ", "), len(hosts))
+	} else {
+		fmt.Printf("Sweeping %s (%d hosts)...\n\n", strings.Join(targets, ", "), len(hosts))
+	}
+
+	showMAC := *cli.macFlag || *cli.methodFlag == "arp"
+	if showMAC {
+		fmt.Printf("%-16s %-6s %-8s %-30s %-10s %-18s %s\n", "IP", "METHOD", "PORT", "HOSTNAME", "LATENCY", "MAC", "VENDOR")
+	} else {
+		fmt.Printf("%-16s %-6s %-8s %-30s %s\n", "IP", "METHOD", "PORT", "HOSTNAME", "LATENCY")
+	}
 	fmt.Println("────────────────────────────────────────────────────────────────")
 
 	ctx := context.Background()
@@ -92,7 +196,23 @@ EXAMPLES:
 		if len(hostname) > 28 {
 			hostname = hostname[:25] + "..."
 		}
-		fmt.Printf("%-16s %-8d %-30s %v\n", r.IP, r.Port, hostname, r.Latency.Round(time.Millisecond))
+		port := "-"
+		if r.Port > 0 {
+			port = fmt.Sprintf("%d", r.Port)
+		}
+		latency := r.Latency.Round(time.Millisecond)
+		if showMAC {
+			mac, vendor := r.MAC, r.Vendor
+			if mac == "" {
+				mac = "-"
+			}
+			if vendor == "" {
+				vendor = "-"
+			}
+			fmt.Printf("%-16s %-6s %-8s %-30s %-10v %-18s %s\n", r.IP, r.Method, port, hostname, latency, mac, vendor)
+		} else {
+			fmt.Printf("%-16s %-6s %-8s %-30s %v\n", r.IP, r.Method, port, hostname, latency)
+		}
 	})
 
 	if err != nil {
@@ -101,5 +221,8 @@ EXAMPLES:
 	}
 
 	fmt.Printf("\n────────────────────────────────────────────────────────────────\n")
+	if sweeper.Fallback != "" {
+		fmt.Printf("Note: %s\n", sweeper.Fallback)
+	}
 	fmt.Printf("Scan complete: %d/%d hosts alive\n", aliveCount, len(results))
 }
@@ -8,14 +8,22 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/sysinfo"
 )
 
-func runSysinfo(args []string) {
+// sysinfoFlags holds the flags of the sysinfo command.
+type sysinfoFlags struct {
+	publicIP   *bool
+	activeOnly *bool
+}
+
+// newSysinfoFlags defines the flags of the sysinfo command.
+func newSysinfoFlags() (*flag.FlagSet, *sysinfoFlags) {
 	fs := flag.NewFlagSet("sysinfo", flag.ExitOnError)
-	publicIP := fs.Bool("public", false, "Resolve public IP via OpenDNS")
-	activeOnly := fs.Bool("active", false, "Show only active (up, non-loopback) interfaces")
+	cli := &sysinfoFlags{}
+	cli.publicIP = fs.Bool("public", false, "Resolve public IP via OpenDNS")
+	cli.activeOnly = fs.Bool("active", false, "Show only active (up, non-loopback) interfaces")
 
 	// Short flags
-	fs.BoolVar(publicIP, "p", false, "Resolve public IP")
-	fs.BoolVar(activeOnly, "a", false, "Active only")
+	fs.BoolVar(cli.publicIP, "p", false, "Resolve public IP")
+	fs.BoolVar(cli.activeOnly, "a", false, "Active only")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns sysinfo [options]
@@ -35,19 +43,25 @@ Examples:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runSysinfo(args []string) {
+	fs, cli := newSysinfoFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
 	opts := sysinfo.DefaultOptions()
-	opts.ResolvePublic = *publicIP
+	opts.ResolvePublic = *cli.publicIP
 
 	fmt.Printf("SYSTEM INFO\n\n")
 
 	info := sysinfo.Collect(opts)
 
-	if *activeOnly {
+	if *cli.activeOnly {
 		var filtered []sysinfo.IfaceInfo
 		for _, iface := range info.Interfaces {
 			if iface.IsUp && !iface.IsLoopback {
@@ -11,17 +11,27 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/tcpdump"
 )
 
-func runTCPDump(args []string) {
+// tcpDumpFlags holds the flags of the tcpdump command.
+type tcpDumpFlags struct {
+	ports   *string
+	useTLS  *bool
+	timeout *int
+	verbose *bool
+}
+
+// newTCPDumpFlags defines the flags of the tcpdump command.
+func newTCPDumpFlags() (*flag.FlagSet, *tcpDumpFlags) {
 	fs := flag.NewFlagSet("tcpdump", flag.ExitOnError)
-	ports := fs.String("ports", "80", "Comma-separated list of ports to probe")
-	useTLS := fs.Bool("tls", false, "Attempt TLS handshake on each port")
-	timeout := fs.Int("timeout", 10, "Connection timeout in seconds")
-	verbose := fs.Bool("verbose", false, "Show detailed per-port breakdown")
+	cli := &tcpDumpFlags{}
+	cli.ports = fs.String("ports", "80", "Comma-separated list of ports to probe")
+	cli.useTLS = fs.Bool("tls", false, "Attempt TLS handshake on each port")
+	cli.timeout = fs.Int("timeout", 10, "Connection timeout in seconds")
+	cli.verbose = fs.Bool("verbose", false, "Show detailed per-port breakdown")
 
 	// Short flags
-	fs.StringVar(ports, "p", "80", "Ports")
-	fs.BoolVar(useTLS, "s", false, "TLS")
-	fs.BoolVar(verbose, "V", false, "Verbose")
+	fs.StringVar(cli.ports, "p", "80", "Ports")
+	fs.BoolVar(cli.useTLS, "s", false, "TLS")
+	fs.BoolVar(cli.verbose, "V", false, "Verbose")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns tcpdump [options] <host>
@@ -44,7 +54,13 @@ Examples:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runTCPDump(args []string) {
+	fs, cli := newTCPDumpFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -56,7 +72,7 @@ Examples:
 	}
 
 	host := fs.Arg(0)
-	portList := strings.Split(*ports, ",")
+	portList := strings.Split(*cli.ports, ",")
 	for i, p := range portList {
 		portList[i] = strings.TrimSpace(p)
 	}
@@ -64,16 +80,16 @@ Examples:
 	opts := tcpdump.Options{
 		Host:    host,
 		Ports:   portList,
-		TLS:     *useTLS,
-		Timeout: time.Duration(*timeout) * time.Second,
+		TLS:     *cli.useTLS,
+		Timeout: time.Duration(*cli.timeout) * time.Second,
 	}
 
 	fmt.Printf("TCP CONNECTION ANALYSIS\n\n")
 
 	ctx := context.Background()
 
-	if len(portList) == 1 && !*verbose {
-		ci := tcpdump.Analyze(ctx, host, portList[0], *useTLS, opts.Timeout)
+	if len(portList) == 1 && !*cli.verbose {
+		ci := tcpdump.Analyze(ctx, host, portList[0], *cli.useTLS, opts.Timeout)
 		fmt.Print(tcpdump.FormatConnInfo(ci))
 		if !ci.Reachable {
 			os.Exit(1)
@@ -83,7 +99,7 @@ Examples:
 
 	mr := tcpdump.AnalyzeMulti(ctx, opts)
 
-	if *verbose {
+	if *cli.verbose {
 		for i, ci := range mr.Results {
 			if i > 0 {
 				fmt.Println()
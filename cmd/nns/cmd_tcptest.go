@@ -11,22 +11,34 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/tcptest"
 )
 
-func runTCPTest(args []string) {
+// tcpTestFlags holds the flags of the tcptest command.
+type tcpTestFlags struct {
+	portFlag     *int
+	countFlag    *int
+	intervalFlag *time.Duration
+	timeoutFlag  *time.Duration
+	tlsFlag      *bool
+	insecureFlag *bool
+}
+
+// newTCPTestFlags defines the flags of the tcptest command.
+func newTCPTestFlags() (*flag.FlagSet, *tcpTestFlags) {
 	fs := flag.NewFlagSet("tcptest", flag.ExitOnError)
-	portFlag := fs.Int("port", 80, "Target port")
-	countFlag := fs.Int("count", 4, "Number of tests")
-	intervalFlag := fs.Duration("interval", 1*time.Second, "Time between tests")
-	timeoutFlag := fs.Duration("timeout", 10*time.Second, "Connection timeout")
-	tlsFlag := fs.Bool("tls", false, "Enable TLS handshake")
-	insecureFlag := fs.Bool("insecure", false, "Skip TLS certificate verification")
+	cli := &tcpTestFlags{}
+	cli.portFlag = fs.Int("port", 80, "Target port")
+	cli.countFlag = fs.Int("count", 4, "Number of tests")
+	cli.intervalFlag = fs.Duration("interval", 1*time.Second, "Time between tests")
+	cli.timeoutFlag = fs.Duration("timeout", 10*time.Second, "Connection timeout")
+	cli.tlsFlag = fs.Bool("tls", false, "Enable TLS handshake")
+	cli.insecureFlag = fs.Bool("insecure", false, "Skip TLS certificate verification")
 
 	// Short flags
-	fs.IntVar(portFlag, "p", 80, "Target port")
-	fs.IntVar(countFlag, "c", 4, "Number of tests")
-	fs.DurationVar(intervalFlag, "i", 1*time.Second, "Time between tests")
-	fs.DurationVar(timeoutFlag, "t", 10*time.Second, "Connection timeout")
-	fs.BoolVar(tlsFlag, "s", false, "Enable TLS handshake")
-	fs.BoolVar(insecureFlag, "k", false, "Skip TLS certificate verification")
+	fs.IntVar(cli.portFlag, "p", 80, "Target port")
+	fs.IntVar(cli.countFlag, "c", 4, "Number of tests")
+	fs.DurationVar(cli.intervalFlag, "i", 1*time.Second, "Time between tests")
+	fs.DurationVar(cli.timeoutFlag, "t", 10*time.Second, "Connection timeout")
+	fs.BoolVar(cli.tlsFlag, "s", false, "Enable TLS handshake")
+	fs.BoolVar(cli.insecureFlag, "k", false, "Skip TLS certificate verification")
 
 	fs.Usage = func() {
 		fmt.Println(`Usage: nns tcptest [HOST] [OPTIONS]
@@ -49,7 +61,13 @@ EXAMPLES:
   nns tcptest api.example.com -p 443 --tls --insecure`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runTCPTest(args []string) {
+	fs, cli := newTCPTestFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing flags: %v\n", err)
 		os.Exit(1)
 	}
@@ -63,19 +81,19 @@ EXAMPLES:
 	host := fs.Arg(0)
 
 	// Auto-enable TLS for port 443
-	if *portFlag == 443 && !*tlsFlag {
-		*tlsFlag = true
+	if *cli.portFlag == 443 && !*cli.tlsFlag {
+		*cli.tlsFlag = true
 	}
 
-	tester := tcptest.NewTester(host, *portFlag)
-	tester.Count = *countFlag
-	tester.Interval = *intervalFlag
-	tester.Timeout = *timeoutFlag
-	tester.UseTLS = *tlsFlag
-	tester.SkipVerify = *insecureFlag
+	tester := tcptest.NewTester(host, *cli.portFlag)
+	tester.Count = *cli.countFlag
+	tester.Interval = *cli.intervalFlag
+	tester.Timeout = *cli.timeoutFlag
+	tester.UseTLS = *cli.tlsFlag
+	tester.SkipVerify = *cli.insecureFlag
 
 	// Print header
-	fmt.Printf("TCP Test to %s (port %d)\n", host, *portFlag)
+	fmt.Printf("TCP Test to %s (port %d)\n", host, *cli.portFlag)
 	if tester.UseTLS {
 		fmt.Println("TLS: enabled")
 	}
@@ -119,7 +137,7 @@ EXAMPLES:
 
 	// Print statistics
 	stats := tester.Stats
-	fmt.Printf("\n--- %s:%d TCP test statistics ---\n", host, *portFlag)
+	fmt.Printf("\n--- %s:%d TCP test statistics ---\n", host, *cli.portFlag)
 	fmt.Printf("%d connections, %d succeeded, %d failed (%.1f%% success)\n",
 		stats.Sent, stats.Successful, stats.Failed, stats.SuccessRate)
 
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JedizLaPulga/NNS/internal/output"
+	"github.com/JedizLaPulga/NNS/internal/starttls"
+	"github.com/JedizLaPulga/NNS/internal/tlsaudit"
+)
+
+// tlsAuditFlags holds the flags of the tlsaudit command.
+type tlsAuditFlags struct {
+	timeout      *time.Duration
+	noProtocols  *bool
+	noVulns      *bool
+	noCiphers    *bool
+	noRevocation *bool
+	noALPN       *bool
+	noResumption *bool
+	startTLS     *string
+	file         *string
+	concurrency  *int
+	jsonOut      *bool
+}
+
+// newTLSAuditFlags defines the flags of the tlsaudit command.
+func newTLSAuditFlags() (*flag.FlagSet, *tlsAuditFlags) {
+	fs := flag.NewFlagSet("tlsaudit", flag.ExitOnError)
+	cli := &tlsAuditFlags{}
+	cli.timeout = fs.Duration("timeout", 10*time.Second, "Connection timeout per handshake")
+	cli.noProtocols = fs.Bool("no-protocols", false, "Skip the protocol version matrix")
+	cli.noVulns = fs.Bool("no-vulns", false, "Skip vulnerability checks")
+	cli.noCiphers = fs.Bool("no-ciphers", false, "Skip cipher suite enumeration")
+	cli.noRevocation = fs.Bool("no-revocation", false, "Skip OCSP/CRL revocation checks")
+	cli.noALPN = fs.Bool("no-alpn", false, "Skip ALPN negotiation check")
+	cli.noResumption = fs.Bool("no-resumption", false, "Skip session resumption tests")
+	cli.startTLS = fs.String("starttls", "", "Upgrade via STARTTLS first: "+strings.Join(starttls.Protocols(), ", "))
+	cli.file = fs.String("file", "", "Read targets from file (one per line)")
+	cli.concurrency = fs.Int("concurrency", 5, "Hosts audited in parallel")
+	cli.jsonOut = fs.Bool("json", false, "Output in JSON format")
+
+	// Short flags
+	fs.DurationVar(cli.timeout, "t", 10*time.Second, "Connection timeout")
+	fs.StringVar(cli.file, "f", "", "Read targets from file")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: nns tlsaudit [options] <host[:port]> [host[:port]...]
+
+Deep TLS security audit: protocol version matrix, cipher suite
+enumeration, certificate and revocation checks, known vulnerabilities,
+and an overall A+ to F grade. The port defaults to 443, or to the
+protocol's usual port with --starttls.
+
+Options:
+  --timeout, -t      Connection timeout per handshake (default: 10s)
+  --no-protocols     Skip the SSLv3-TLS 1.3 protocol matrix
+  --no-ciphers       Skip cipher suite enumeration
+  --no-vulns         Skip vulnerability checks
+  --no-revocation    Skip OCSP/CRL revocation checks
+  --no-alpn          Skip ALPN (HTTP/2) negotiation check
+  --no-resumption    Skip session ticket / PSK resumption tests
+  --starttls PROTO   Upgrade via STARTTLS first (%s)
+  --file, -f FILE    Read targets from FILE (one per line, # comments)
+  --concurrency      Hosts audited in parallel (default: 5)
+  --json             Output in JSON format
+  --help             Show this help message
+
+Examples:
+  nns tlsaudit example.com
+  nns tlsaudit mail.example.com:465
+  nns tlsaudit --starttls smtp mail.example.com
+  nns tlsaudit --no-vulns --no-ciphers example.com
+  nns tlsaudit -f hosts.txt --json
+`, strings.Join(starttls.Protocols(), ", "))
+	}
+
+	return fs, cli
+}
+
+func runTLSAudit(args []string) {
+	fs, cli := newTLSAuditFlags()
+
+	if err := parseFlags(fs, args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	targets := fs.Args()
+	if *cli.file != "" {
+		list, err := readWhoisTargets(*cli.file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		targets = append(targets, list...)
+	}
+	if len(targets) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: target host required\n\n")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	if *cli.startTLS != "" {
+		*cli.startTLS = strings.ToLower(*cli.startTLS)
+		if _, ok := starttls.DefaultPorts[*cli.startTLS]; !ok {
+			fmt.Fprintf(os.Stderr, "Error: unsupported STARTTLS protocol %q (use %s)\n", *cli.startTLS, strings.Join(starttls.Protocols(), ", "))
+			os.Exit(1)
+		}
+	}
+
+	cfg := tlsaudit.DefaultConfig()
+	cfg.Timeout = *cli.timeout
+	cfg.CheckProtocols = !*cli.noProtocols
+	cfg.CheckVulns = !*cli.noVulns
+	cfg.CheckCiphers = !*cli.noCiphers
+	cfg.CheckRevocation = !*cli.noRevocation
+	cfg.CheckALPN = !*cli.noALPN
+	cfg.CheckResumption = !*cli.noResumption
+	cfg.StartTLS = *cli.startTLS
+	cfg.Concurrency = *cli.concurrency
+
+	// Normalize every target to host:port so results key consistently.
+	for i, t := range targets {
+		host, port := tlsaudit.ParseHostPort(t)
+		if *cli.startTLS != "" && !strings.Contains(t, ":") {
+			port = starttls.DefaultPorts[*cli.startTLS]
+		}
+		targets[i] = net.JoinHostPort(host, strconv.Itoa(port))
+	}
+
+	auditor := tlsaudit.New(cfg)
+
+	if len(targets) == 1 {
+		host, port := tlsaudit.ParseHostPort(targets[0])
+		if !*cli.jsonOut {
+			fmt.Fprintf(os.Stderr, "Auditing TLS on %s...\n", targets[0])
+		}
+		result := auditor.Audit(host, port)
+
+		if *cli.jsonOut {
+			out, err := result.ToJSON()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(out)
+		} else {
+			printTLSAudit(result)
+		}
+		if !result.Connected {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if !*cli.jsonOut {
+		fmt.Fprintf(os.Stderr, "Auditing TLS on %d hosts...\n", len(targets))
+	}
+	results := auditor.AuditMany(targets)
+
+	keys := make([]string, 0, len(results))
+	for k := range results {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if *cli.jsonOut {
+		list := make([]*tlsaudit.Result, 0, len(keys))
+		for _, k := range keys {
+			list = append(list, results[k])
+		}
+		if err := output.Stdout.JSON(list); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	for _, k := range keys {
+		printTLSAudit(results[k])
+	}
+
+	fmt.Printf("\n%-32s %-6s %-6s %s\n", "TARGET", "GRADE", "SCORE", "ISSUES")
+	for _, k := range keys {
+		r := results[k]
+		if !r.Connected {
+			fmt.Printf("%-32s %-6s %-6s %v\n", truncate(k, 32), "-", "-", r.Error)
+			continue
+		}
+		grade := fmt.Sprintf("%-6s", r.Grade)
+		fmt.Printf("%-32s %s %-6d %d\n", truncate(k, 32), output.Stdout.Paint(output.GradeColor(r.Grade), grade), r.Score, len(r.Issues))
+	}
+}
+
+func printTLSAudit(r *tlsaudit.Result) {
+	target := net.JoinHostPort(r.Host, strconv.Itoa(r.Port))
+	fmt.Printf("\n═══ TLS Audit: %s ═══\n", target)
+
+	if !r.Connected {
+		fmt.Printf("  ❌ Connection failed: %v\n", r.Error)
+		return
+	}
+
+	fmt.Printf("  Grade:        %s (%d/100)\n", output.Stdout.Grade(r.Grade), r.Score)
+	fmt.Printf("  Negotiated:   %s, %s\n", r.Protocol.Current, r.Cipher.Current)
+	fmt.Printf("  Handshake:    %v\n", r.ConnectTime.Round(time.Millisecond))
+	if r.ALPN != "" {
+		fmt.Printf("  ALPN:         %s\n", r.ALPN)
+	}
+
+	fmt.Println("\n  Protocols:")
+	for _, p := range []struct {
+		name      string
+		supported bool
+		insecure  bool
+	}{
+		{"SSLv3", r.Protocol.SSLv3, true},
+		{"TLS 1.0", r.Protocol.TLS10, true},
+		{"TLS 1.1", r.Protocol.TLS11, true},
+		{"TLS 1.2", r.Protocol.TLS12, false},
+		{"TLS 1.3", r.Protocol.TLS13, false},
+	} {
+		var status string
+		switch {
+		case p.supported && p.insecure:
+			status = "⚠️  yes (insecure)"
+		case p.supported:
+			status = "✅ yes"
+		case p.insecure:
+			status = "✅ no"
+		default:
+			status = "❌ no"
+		}
+		fmt.Printf("    %-10s %s\n", p.name, status)
+	}
+
+	if len(r.Cipher.Weak) > 0 {
+		fmt.Printf("\n  Weak ciphers (%d):\n", len(r.Cipher.Weak))
+		for _, c := range r.Cipher.Weak {
+			fmt.Printf("    %s\n", c)
+		}
+	}
+
+	c := r.Certificate
+	fmt.Println("\n  Certificate:")
+	fmt.Printf("    Subject:    %s\n", c.Subject)
+	fmt.Printf("    Issuer:     %s\n", c.Issuer)
+	fmt.Printf("    Expires:    %s (%d days)\n", c.NotAfter.Format("2006-01-02"), c.DaysRemaining)
+	fmt.Printf("    Key:        %s %d\n", c.KeyType, c.KeySize)
+	chain := "✅ trusted"
+	if !r.ChainValid {
+		chain = "⚠️  not verified"
+	}
+	fmt.Printf("    Chain:      %d certs, %s\n", r.ChainLength, chain)
+	if r.Revocation.Checked {
+		rev := r.Revocation.Status
+		if r.Revocation.Method != "" {
+			rev += " (" + r.Revocation.Method + ")"
+		}
+		if r.Revocation.Error != "" {
+			rev += ": " + r.Revocation.Error
+		}
+		fmt.Printf("    Revocation: %s\n", rev)
+	}
+
+	if len(r.Vulnerabilities) > 0 {
+		fmt.Println("\n  Vulnerabilities:")
+		for _, v := range r.Vulnerabilities {
+			fmt.Println("  " + tlsaudit.FormatVuln(v))
+		}
+	}
+
+	if len(r.Issues) > 0 {
+		sorted := append([]tlsaudit.Issue(nil), r.Issues...)
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Severity > sorted[j].Severity })
+		fmt.Printf("\n  Issues (%d):\n", len(sorted))
+		for _, issue := range sorted {
+			fmt.Println("    " + tlsaudit.FormatIssue(issue))
+			if issue.Remediation != "" {
+				fmt.Printf("       → %s\n", issue.Remediation)
+			}
+		}
+	} else {
+		fmt.Println("\n  ✅ No issues found")
+	}
+}
@@ -10,22 +10,36 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/tlscheck"
 )
 
-func runTLSCheck(args []string) {
+// tlsCheckFlags holds the flags of the tlscheck command.
+type tlsCheckFlags struct {
+	portFlag         *int
+	timeoutFlag      *time.Duration
+	skipVerifyFlag   *bool
+	warningDaysFlag  *int
+	criticalDaysFlag *int
+	serverNameFlag   *string
+	chainFlag        *bool
+	jsonFlag         *bool
+}
+
+// newTLSCheckFlags defines the flags of the tlscheck command.
+func newTLSCheckFlags() (*flag.FlagSet, *tlsCheckFlags) {
 	fs := flag.NewFlagSet("tlscheck", flag.ExitOnError)
-	portFlag := fs.Int("port", 443, "Target port")
-	timeoutFlag := fs.Duration("timeout", 10*time.Second, "Connection timeout")
-	skipVerifyFlag := fs.Bool("skip-verify", false, "Skip certificate chain verification")
-	warningDaysFlag := fs.Int("warning", 30, "Days before expiry for warning")
-	criticalDaysFlag := fs.Int("critical", 7, "Days before expiry for critical alert")
-	serverNameFlag := fs.String("servername", "", "Server name for SNI (default: host)")
-	chainFlag := fs.Bool("chain", false, "Show full certificate chain")
-	jsonFlag := fs.Bool("json", false, "Output in JSON format")
+	cli := &tlsCheckFlags{}
+	cli.portFlag = fs.Int("port", 443, "Target port")
+	cli.timeoutFlag = fs.Duration("timeout", 10*time.Second, "Connection timeout")
+	cli.skipVerifyFlag = fs.Bool("skip-verify", false, "Skip certificate chain verification")
+	cli.warningDaysFlag = fs.Int("warning", 30, "Days before expiry for warning")
+	cli.criticalDaysFlag = fs.Int("critical", 7, "Days before expiry for critical alert")
+	cli.serverNameFlag = fs.String("servername", "", "Server name for SNI (default: host)")
+	cli.chainFlag = fs.Bool("chain", false, "Show full certificate chain")
+	cli.jsonFlag = fs.Bool("json", false, "Output in JSON format")
 
 	// Short flags
-	fs.IntVar(portFlag, "p", 443, "Target port")
-	fs.DurationVar(timeoutFlag, "t", 10*time.Second, "Connection timeout")
-	fs.BoolVar(skipVerifyFlag, "k", false, "Skip certificate chain verification")
-	fs.BoolVar(chainFlag, "c", false, "Show full certificate chain")
+	fs.IntVar(cli.portFlag, "p", 443, "Target port")
+	fs.DurationVar(cli.timeoutFlag, "t", 10*time.Second, "Connection timeout")
+	fs.BoolVar(cli.skipVerifyFlag, "k", false, "Skip certificate chain verification")
+	fs.BoolVar(cli.chainFlag, "c", false, "Show full certificate chain")
 
 	fs.Usage = func() {
 		fmt.Println(`Usage: nns tlscheck [HOST] [OPTIONS]
@@ -50,7 +64,13 @@ EXAMPLES:
   nns tlscheck internal.local --skip-verify`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runTLSCheck(args []string) {
+	fs, cli := newTLSCheckFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing flags: %v\n", err)
 		os.Exit(1)
 	}
@@ -63,16 +83,16 @@ EXAMPLES:
 
 	host := fs.Arg(0)
 
-	checker := tlscheck.NewChecker(host, *portFlag)
-	checker.Timeout = *timeoutFlag
-	checker.SkipVerify = *skipVerifyFlag
-	checker.WarningDays = *warningDaysFlag
-	checker.CriticalDays = *criticalDaysFlag
-	if *serverNameFlag != "" {
-		checker.ServerName = *serverNameFlag
+	checker := tlscheck.NewChecker(host, *cli.portFlag)
+	checker.Timeout = *cli.timeoutFlag
+	checker.SkipVerify = *cli.skipVerifyFlag
+	checker.WarningDays = *cli.warningDaysFlag
+	checker.CriticalDays = *cli.criticalDaysFlag
+	if *cli.serverNameFlag != "" {
+		checker.ServerName = *cli.serverNameFlag
 	}
 
-	fmt.Printf("TLS Certificate Check: %s:%d\n", host, *portFlag)
+	fmt.Printf("TLS Certificate Check: %s:%d\n", host, *cli.portFlag)
 	fmt.Println(strings.Repeat("─", 50))
 
 	result, err := checker.Check()
@@ -81,7 +101,7 @@ EXAMPLES:
 		os.Exit(1)
 	}
 
-	if *jsonFlag {
+	if *cli.jsonFlag {
 		printTLSCheckJSON(result)
 		return
 	}
@@ -110,7 +130,7 @@ EXAMPLES:
 	}
 
 	// Chain details
-	if *chainFlag && len(result.Certificates) > 1 {
+	if *cli.chainFlag && len(result.Certificates) > 1 {
 		fmt.Printf("\n📜 Certificate Chain (%d certificates):\n", len(result.Certificates))
 		for i, cert := range result.Certificates {
 			fmt.Printf("\n  [%d] %s\n", i+1, cert.Subject)
@@ -10,18 +10,30 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/traceroute"
 )
 
-func runTraceroute(args []string) {
+// tracerouteFlags holds the flags of the traceroute command.
+type tracerouteFlags struct {
+	maxHopsFlag *int
+	queriesFlag *int
+	timeoutFlag *time.Duration
+	asFlag      *bool
+	source      func() string
+}
+
+// newTracerouteFlags defines the flags of the traceroute command.
+func newTracerouteFlags() (*flag.FlagSet, *tracerouteFlags) {
 	fs := flag.NewFlagSet("traceroute", flag.ExitOnError)
+	cli := &tracerouteFlags{}
 
-	maxHopsFlag := fs.Int("max-hops", 30, "Maximum hops")
-	queriesFlag := fs.Int("queries", 3, "Probes per hop")
-	timeoutFlag := fs.Duration("timeout", 2*time.Second, "Timeout per hop")
-	asFlag := fs.Bool("as", true, "Resolve AS number")
+	cli.maxHopsFlag = fs.Int("max-hops", 30, "Maximum hops")
+	cli.queriesFlag = fs.Int("queries", 3, "Probes per hop")
+	cli.timeoutFlag = fs.Duration("timeout", 2*time.Second, "Timeout per hop")
+	cli.asFlag = fs.Bool("as", true, "Resolve AS number")
+	cli.source = sourceFlags(fs)
 
 	// Short flags
-	fs.IntVar(maxHopsFlag, "m", 30, "Maximum hops")
-	fs.IntVar(queriesFlag, "q", 3, "Probes per hop")
-	fs.BoolVar(asFlag, "a", true, "Resolve AS number")
+	fs.IntVar(cli.maxHopsFlag, "m", 30, "Maximum hops")
+	fs.IntVar(cli.queriesFlag, "q", 3, "Probes per hop")
+	fs.BoolVar(cli.asFlag, "a", true, "Resolve AS number")
 
 	fs.Usage = func() {
 		fmt.Println(`Usage: nns traceroute [OPTIONS] [HOST]
@@ -33,16 +45,25 @@ OPTIONS:
   -q, --queries     Probes per hop (default: 3)
   --timeout         Timeout per hop (default: 2s)
   -a, --as          Resolve AS numbers (default: true)
+  -I, --interface   Send from this interface's IPv4 address
+  -S, --source-ip   Send from this local IPv4 address
   --help            Show this help message
 
 > **Windows Note**: You may need to allow "File and Printer Sharing (Echo Request - ICMPv4-In)" and "ICMPv4 Time Exceeded" in Windows Firewall to receive replies.
 
 EXAMPLES:
   nns traceroute google.com
-  nns traceroute -m 64 example.com`)
+  nns traceroute -m 64 example.com
+  nns traceroute -S 10.0.2.15 example.com`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runTraceroute(args []string) {
+	fs, cli := newTracerouteFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		os.Exit(1)
 	}
 
@@ -56,10 +77,11 @@ EXAMPLES:
 
 	cfg := traceroute.Config{
 		Target:    host,
-		MaxHops:   *maxHopsFlag,
-		Queries:   *queriesFlag,
-		Timeout:   *timeoutFlag,
-		ResolveAS: *asFlag,
+		MaxHops:   *cli.maxHopsFlag,
+		Queries:   *cli.queriesFlag,
+		Timeout:   *cli.timeoutFlag,
+		ResolveAS: *cli.asFlag,
+		SourceIP:  cli.source(),
 	}
 
 	tracer := traceroute.NewTracer(cfg)
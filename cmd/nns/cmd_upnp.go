@@ -12,11 +12,20 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/upnp"
 )
 
-func runUPnP(args []string) {
+// upnpFlags holds the flags of the upnp command.
+type upnpFlags struct {
+	timeout   *time.Duration
+	target    *string
+	noDetails *bool
+}
+
+// newUPnPFlags defines the flags of the upnp command.
+func newUPnPFlags() (*flag.FlagSet, *upnpFlags) {
 	fs := flag.NewFlagSet("upnp", flag.ExitOnError)
-	timeout := fs.Duration("timeout", 3*time.Second, "Discovery timeout")
-	target := fs.String("target", "ssdp:all", "Search target (ssdp:all, upnp:rootdevice, etc.)")
-	noDetails := fs.Bool("no-details", false, "Skip fetching device details via HTTP")
+	cli := &upnpFlags{}
+	cli.timeout = fs.Duration("timeout", 3*time.Second, "Discovery timeout")
+	cli.target = fs.String("target", "ssdp:all", "Search target (ssdp:all, upnp:rootdevice, etc.)")
+	cli.noDetails = fs.Bool("no-details", false, "Skip fetching device details via HTTP")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, `Usage: nns upnp [OPTIONS]
@@ -42,14 +51,20 @@ EXAMPLES:
 `)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runUPnP(args []string) {
+	fs, cli := newUPnPFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		return
 	}
 
 	cfg := upnp.Config{
-		Timeout:      *timeout,
-		SearchTarget: *target,
-		FetchDetails: !*noDetails,
+		Timeout:      *cli.timeout,
+		SearchTarget: *cli.target,
+		FetchDetails: !*cli.noDetails,
 	}
 
 	scanner := upnp.New(cfg)
@@ -66,7 +81,7 @@ EXAMPLES:
 		cancel()
 	}()
 
-	fmt.Printf("Discovering UPnP devices (target: %s)...\n\n", *target)
+	fmt.Printf("Discovering UPnP devices (target: %s)...\n\n", *cli.target)
 
 	result, err := scanner.Scan(ctx)
 	if err != nil && err != context.Canceled {
@@ -11,14 +11,26 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/urlcheck"
 )
 
-func runURLCheck(args []string) {
+// urlCheckFlags holds the flags of the urlcheck command.
+type urlCheckFlags struct {
+	timeout     *time.Duration
+	concurrency *int
+	noFollow    *bool
+	insecure    *bool
+	sort        *bool
+	common      *bool
+}
+
+// newURLCheckFlags defines the flags of the urlcheck command.
+func newURLCheckFlags() (*flag.FlagSet, *urlCheckFlags) {
 	fs := flag.NewFlagSet("urlcheck", flag.ExitOnError)
-	timeout := fs.Duration("t", 10*time.Second, "Request timeout")
-	concurrency := fs.Int("c", 10, "Concurrent requests")
-	noFollow := fs.Bool("no-follow", false, "Don't follow redirects")
-	insecure := fs.Bool("insecure", false, "Skip TLS certificate verification")
-	sort := fs.Bool("sort", false, "Sort results by response time")
-	common := fs.Bool("common", false, "Test common endpoints")
+	cli := &urlCheckFlags{}
+	cli.timeout = fs.Duration("t", 10*time.Second, "Request timeout")
+	cli.concurrency = fs.Int("c", 10, "Concurrent requests")
+	cli.noFollow = fs.Bool("no-follow", false, "Don't follow redirects")
+	cli.insecure = fs.Bool("insecure", false, "Skip TLS certificate verification")
+	cli.sort = fs.Bool("sort", false, "Sort results by response time")
+	cli.common = fs.Bool("common", false, "Test common endpoints")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: nns urlcheck [options] <url> [url...]\n\n")
@@ -31,13 +43,19 @@ func runURLCheck(args []string) {
 		fmt.Fprintf(os.Stderr, "  nns urlcheck --sort api.example.com/health\n")
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runURLCheck(args []string) {
+	fs, cli := newURLCheckFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		os.Exit(1)
 	}
 
 	var targets []urlcheck.Target
 
-	if *common {
+	if *cli.common {
 		targets = urlcheck.CommonEndpoints()
 	}
 
@@ -52,10 +70,10 @@ func runURLCheck(args []string) {
 	}
 
 	cfg := urlcheck.Config{
-		Timeout:      *timeout,
-		Concurrency:  *concurrency,
-		FollowRedir:  !*noFollow,
-		SkipTLSCheck: *insecure,
+		Timeout:      *cli.timeout,
+		Concurrency:  *cli.concurrency,
+		FollowRedir:  !*cli.noFollow,
+		SkipTLSCheck: *cli.insecure,
 	}
 
 	checker := urlcheck.New(cfg)
@@ -71,11 +89,11 @@ func runURLCheck(args []string) {
 		cancel()
 	}()
 
-	fmt.Printf("Checking %d URLs (concurrency=%d, timeout=%v)...\n\n", len(targets), *concurrency, *timeout)
+	fmt.Printf("Checking %d URLs (concurrency=%d, timeout=%v)...\n\n", len(targets), *cli.concurrency, *cli.timeout)
 
 	results := checker.CheckMultiple(ctx, targets)
 
-	if *sort {
+	if *cli.sort {
 		urlcheck.SortByResponseTime(results)
 	}
 
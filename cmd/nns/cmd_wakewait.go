@@ -12,14 +12,26 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/wakewait"
 )
 
-func runWakeWait(args []string) {
+// wakeWaitFlags holds the flags of the wakewait command.
+type wakeWaitFlags struct {
+	timeout   *time.Duration
+	port      *int
+	interval  *time.Duration
+	broadcast *string
+	retries   *int
+	nowait    *bool
+}
+
+// newWakeWaitFlags defines the flags of the wakewait command.
+func newWakeWaitFlags() (*flag.FlagSet, *wakeWaitFlags) {
 	fs := flag.NewFlagSet("wakewait", flag.ExitOnError)
-	timeout := fs.Duration("timeout", 5*time.Minute, "Max time to wait for host")
-	port := fs.Int("port", 22, "TCP port to check for connectivity")
-	interval := fs.Duration("interval", 2*time.Second, "Check interval")
-	broadcast := fs.String("broadcast", "255.255.255.255", "Broadcast address for WoL")
-	retries := fs.Int("retries", 3, "Number of WoL packet retries")
-	nowait := fs.Bool("nowait", false, "Send WoL and exit immediately (don't wait)")
+	cli := &wakeWaitFlags{}
+	cli.timeout = fs.Duration("timeout", 5*time.Minute, "Max time to wait for host")
+	cli.port = fs.Int("port", 22, "TCP port to check for connectivity")
+	cli.interval = fs.Duration("interval", 2*time.Second, "Check interval")
+	cli.broadcast = fs.String("broadcast", "255.255.255.255", "Broadcast address for WoL")
+	cli.retries = fs.Int("retries", 3, "Number of WoL packet retries")
+	cli.nowait = fs.Bool("nowait", false, "Send WoL and exit immediately (don't wait)")
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: nns wakewait [options] <mac> [ip]\n\n")
 		fmt.Fprintf(os.Stderr, "Send Wake-on-LAN packet and wait for host to come online.\n\n")
@@ -31,7 +43,13 @@ func runWakeWait(args []string) {
 		fmt.Fprintf(os.Stderr, "  nns wakewait --nowait AA:BB:CC:DD:EE:FF\n")
 		fmt.Fprintf(os.Stderr, "  nns wakewait --timeout 10m AA:BB:CC:DD:EE:FF 192.168.1.100\n")
 	}
-	fs.Parse(args)
+
+	return fs, cli
+}
+
+func runWakeWait(args []string) {
+	fs, cli := newWakeWaitFlags()
+	parseFlags(fs, args)
 
 	if fs.NArg() < 1 {
 		fs.Usage()
@@ -48,9 +66,9 @@ func runWakeWait(args []string) {
 	}
 
 	// Quick wake mode
-	if *nowait {
+	if *cli.nowait {
 		fmt.Printf("⚡ Sending Wake-on-LAN to %s...\n", mac)
-		if err := wakewait.QuickWake(mac, *broadcast); err != nil {
+		if err := wakewait.QuickWake(mac, *cli.broadcast); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
@@ -65,11 +83,11 @@ func runWakeWait(args []string) {
 	}
 
 	cfg := wakewait.DefaultConfig()
-	cfg.Timeout = *timeout
-	cfg.TCPPort = *port
-	cfg.CheckInterval = *interval
-	cfg.BroadcastAddr = *broadcast
-	cfg.RetryWol = *retries
+	cfg.Timeout = *cli.timeout
+	cfg.TCPPort = *cli.port
+	cfg.CheckInterval = *cli.interval
+	cfg.BroadcastAddr = *cli.broadcast
+	cfg.RetryWol = *cli.retries
 
 	client := wakewait.NewClient(cfg)
 
@@ -78,12 +96,12 @@ func runWakeWait(args []string) {
 		switch r.Status {
 		case wakewait.StatusWaking:
 			fmt.Printf("⚡ [%s] WoL packet #%d sent, waiting for %s:%d...\n",
-				time.Now().Format("15:04:05"), r.Attempts, ip, *port)
+				time.Now().Format("15:04:05"), r.Attempts, ip, *cli.port)
 		case wakewait.StatusOnline:
 			fmt.Printf("✓ [%s] Host is ONLINE! Wake time: %v\n",
 				r.OnlineAt.Format("15:04:05"), r.WakeTime.Round(time.Millisecond))
 		case wakewait.StatusTimeout:
-			fmt.Printf("✗ [%s] Timeout after %v\n", time.Now().Format("15:04:05"), *timeout)
+			fmt.Printf("✗ [%s] Timeout after %v\n", time.Now().Format("15:04:05"), *cli.timeout)
 		}
 	})
 
@@ -101,9 +119,9 @@ func runWakeWait(args []string) {
 
 	fmt.Printf("\n🔌 Wake and Wait\n")
 	fmt.Printf("   MAC:       %s\n", mac)
-	fmt.Printf("   Target:    %s:%d\n", ip, *port)
-	fmt.Printf("   Timeout:   %v\n", *timeout)
-	fmt.Printf("   Broadcast: %s\n\n", *broadcast)
+	fmt.Printf("   Target:    %s:%d\n", ip, *cli.port)
+	fmt.Printf("   Timeout:   %v\n", *cli.timeout)
+	fmt.Printf("   Broadcast: %s\n\n", *cli.broadcast)
 
 	result, err := client.WakeAndWait(ctx, mac, ip)
 
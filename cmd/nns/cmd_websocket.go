@@ -11,22 +11,35 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/websocket"
 )
 
-func runWebSocket(args []string) {
+// webSocketFlags holds the flags of the websocket command.
+type webSocketFlags struct {
+	countFlag    *int
+	intervalFlag *time.Duration
+	timeoutFlag  *time.Duration
+	insecureFlag *bool
+	sizeFlag     *int
+	protocolFlag *string
+	originFlag   *string
+}
+
+// newWebSocketFlags defines the flags of the websocket command.
+func newWebSocketFlags() (*flag.FlagSet, *webSocketFlags) {
 	fs := flag.NewFlagSet("websocket", flag.ExitOnError)
-	countFlag := fs.Int("count", 4, "Number of tests")
-	intervalFlag := fs.Duration("interval", 1*time.Second, "Time between tests")
-	timeoutFlag := fs.Duration("timeout", 10*time.Second, "Connection timeout")
-	insecureFlag := fs.Bool("insecure", false, "Skip TLS certificate verification")
-	sizeFlag := fs.Int("size", 32, "Message size in bytes")
-	protocolFlag := fs.String("protocol", "", "WebSocket sub-protocol")
-	originFlag := fs.String("origin", "", "Custom origin header")
+	cli := &webSocketFlags{}
+	cli.countFlag = fs.Int("count", 4, "Number of tests")
+	cli.intervalFlag = fs.Duration("interval", 1*time.Second, "Time between tests")
+	cli.timeoutFlag = fs.Duration("timeout", 10*time.Second, "Connection timeout")
+	cli.insecureFlag = fs.Bool("insecure", false, "Skip TLS certificate verification")
+	cli.sizeFlag = fs.Int("size", 32, "Message size in bytes")
+	cli.protocolFlag = fs.String("protocol", "", "WebSocket sub-protocol")
+	cli.originFlag = fs.String("origin", "", "Custom origin header")
 
 	// Short flags
-	fs.IntVar(countFlag, "c", 4, "Number of tests")
-	fs.DurationVar(intervalFlag, "i", 1*time.Second, "Time between tests")
-	fs.DurationVar(timeoutFlag, "t", 10*time.Second, "Connection timeout")
-	fs.BoolVar(insecureFlag, "k", false, "Skip TLS certificate verification")
-	fs.IntVar(sizeFlag, "s", 32, "Message size in bytes")
+	fs.IntVar(cli.countFlag, "c", 4, "Number of tests")
+	fs.DurationVar(cli.intervalFlag, "i", 1*time.Second, "Time between tests")
+	fs.DurationVar(cli.timeoutFlag, "t", 10*time.Second, "Connection timeout")
+	fs.BoolVar(cli.insecureFlag, "k", false, "Skip TLS certificate verification")
+	fs.IntVar(cli.sizeFlag, "s", 32, "Message size in bytes")
 
 	fs.Usage = func() {
 		fmt.Println(`Usage: nns websocket [URL] [OPTIONS]
@@ -49,7 +62,13 @@ EXAMPLES:
   nns websocket ws://localhost:8080/ws --protocol json`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runWebSocket(args []string) {
+	fs, cli := newWebSocketFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing flags: %v\n", err)
 		os.Exit(1)
 	}
@@ -63,16 +82,16 @@ EXAMPLES:
 	url := fs.Arg(0)
 
 	tester := websocket.NewTester(url)
-	tester.Count = *countFlag
-	tester.Interval = *intervalFlag
-	tester.Timeout = *timeoutFlag
-	tester.SkipVerify = *insecureFlag
-	tester.MessageSize = *sizeFlag
-	if *protocolFlag != "" {
-		tester.Protocol = *protocolFlag
+	tester.Count = *cli.countFlag
+	tester.Interval = *cli.intervalFlag
+	tester.Timeout = *cli.timeoutFlag
+	tester.SkipVerify = *cli.insecureFlag
+	tester.MessageSize = *cli.sizeFlag
+	if *cli.protocolFlag != "" {
+		tester.Protocol = *cli.protocolFlag
 	}
-	if *originFlag != "" {
-		tester.Origin = *originFlag
+	if *cli.originFlag != "" {
+		tester.Origin = *cli.originFlag
 	}
 
 	// Print header
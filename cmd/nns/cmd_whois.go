@@ -1,47 +1,122 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
 	"time"
 
 	"github.com/JedizLaPulga/NNS/internal/whois"
 )
 
-func runWhois(args []string) {
+// whoisFlags holds the flags of the whois command.
+type whoisFlags struct {
+	rawFlag         *bool
+	serverFlag      *string
+	timeoutFlag     *time.Duration
+	rdapFlag        *bool
+	whoisFlag       *bool
+	referralFlag    *bool
+	asnFlag         *bool
+	jsonFlag        *bool
+	fileFlag        *string
+	concurrencyFlag *int
+	delayFlag       *time.Duration
+}
+
+// newWhoisFlags defines the flags of the whois command.
+func newWhoisFlags() (*flag.FlagSet, *whoisFlags) {
 	fs := flag.NewFlagSet("whois", flag.ExitOnError)
+	cli := &whoisFlags{}
 
-	rawFlag := fs.Bool("raw", false, "Show raw WHOIS response")
-	serverFlag := fs.String("server", "", "Custom WHOIS server")
-	timeoutFlag := fs.Duration("timeout", 10*time.Second, "Query timeout")
+	cli.rawFlag = fs.Bool("raw", false, "Show raw WHOIS response")
+	cli.serverFlag = fs.String("server", "", "Custom WHOIS server")
+	cli.timeoutFlag = fs.Duration("timeout", 10*time.Second, "Query timeout")
+	cli.rdapFlag = fs.Bool("rdap", false, "Use RDAP only (no WHOIS fallback)")
+	cli.whoisFlag = fs.Bool("whois", false, "Use legacy WHOIS (port 43) only")
+	cli.referralFlag = fs.Bool("referral", true, "Follow referrals to the registrar/RIR WHOIS server")
+	cli.asnFlag = fs.Bool("asn", true, "Resolve the origin ASN and prefix for IP addresses")
+	cli.jsonFlag = fs.Bool("json", false, "Output as JSON")
+	cli.fileFlag = fs.String("file", "", "Read targets from file (one per line)")
+	cli.concurrencyFlag = fs.Int("concurrency", 4, "Parallel lookups in --file mode")
+	cli.delayFlag = fs.Duration("delay", time.Second, "Minimum delay between queries to the same server")
 
 	// Short flags
-	fs.StringVar(serverFlag, "s", "", "WHOIS server")
-	fs.DurationVar(timeoutFlag, "t", 10*time.Second, "Timeout")
+	fs.StringVar(cli.serverFlag, "s", "", "WHOIS server")
+	fs.DurationVar(cli.timeoutFlag, "t", 10*time.Second, "Timeout")
 
 	fs.Usage = func() {
-		fmt.Println(`Usage: nns whois [TARGET] [OPTIONS]
+		fmt.Println(`Usage: nns whois [OPTIONS] TARGET
+       nns whois [OPTIONS] --file targets.txt
 
-WHOIS lookup for domains and IP addresses.
+WHOIS lookup for domains and IP addresses. RDAP (structured JSON) is
+tried first, falling back to legacy WHOIS if no RDAP service is available.
 
 OPTIONS:
-  -s, --server    Custom WHOIS server
+  -s, --server    Custom WHOIS server (implies --whois)
   -t, --timeout   Query timeout (default: 10s)
-      --raw       Show raw WHOIS response
+      --rdap      Use RDAP only (no WHOIS fallback)
+      --whois     Use legacy WHOIS (port 43) only
+      --referral  Follow WHOIS referrals to the registrar/RIR (default: true)
+      --asn       Resolve origin ASN/prefix for IPs via Team Cymru (default: true)
+      --raw       Show raw WHOIS text or RDAP JSON response
+      --json      Output as JSON (an array in --file mode)
+      --file      Look up every domain/IP in a file (one per line, # comments)
+      --concurrency  Parallel lookups in --file mode (default: 4)
+      --delay     Minimum delay between queries to the same server (default: 1s)
       --help      Show this help message
 
 EXAMPLES:
   nns whois google.com
   nns whois 8.8.8.8
-  nns whois amazon.com --raw`)
+  nns whois amazon.com --raw
+  nns whois --whois example.org
+  nns whois --json github.com
+  nns whois --file domains.txt --json > expiry.json`)
+	}
+
+	return fs, cli
+}
+
+func runWhois(args []string) {
+	fs, cli := newWhoisFlags()
+
+	if err := parseFlags(fs, args); err != nil {
+		os.Exit(1)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	if *cli.rdapFlag && *cli.whoisFlag {
+		fmt.Fprintf(os.Stderr, "Error: --rdap and --whois are mutually exclusive\n")
 		os.Exit(1)
 	}
 
+	client := whois.NewClient()
+	client.Timeout = *cli.timeoutFlag
+	client.FollowReferral = *cli.referralFlag
+	client.ResolveASN = *cli.asnFlag
+	client.ServerDelay = *cli.delayFlag
+	if *cli.serverFlag != "" {
+		client.Server = *cli.serverFlag
+	}
+
+	method := whois.MethodAuto
+	switch {
+	case *cli.whoisFlag || *cli.serverFlag != "":
+		method = whois.MethodWHOIS
+	case *cli.rdapFlag:
+		method = whois.MethodRDAP
+	}
+
+	if *cli.fileFlag != "" {
+		runWhoisBulk(client, *cli.fileFlag, method, *cli.concurrencyFlag, *cli.jsonFlag)
+		return
+	}
+
 	if fs.NArg() < 1 {
 		fmt.Fprintf(os.Stderr, "Error: domain or IP required\n\n")
 		fs.Usage()
@@ -50,22 +125,23 @@ EXAMPLES:
 
 	target := fs.Arg(0)
 
-	client := whois.NewClient()
-	client.Timeout = *timeoutFlag
-	if *serverFlag != "" {
-		client.Server = *serverFlag
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), *timeoutFlag)
-	defer cancel()
-
-	result, err := client.Lookup(ctx, target)
+	result, err := client.LookupWith(context.Background(), target, method)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	if *rawFlag {
+	if *cli.jsonFlag {
+		out, err := result.ToJSON()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(out)
+		return
+	}
+
+	if *cli.rawFlag {
 		fmt.Println(result.Raw)
 		return
 	}
@@ -107,6 +183,16 @@ EXAMPLES:
 				fmt.Printf("                  %s\n", ns)
 			}
 		}
+		if len(result.Status) > 0 {
+			fmt.Printf("  Status:\n")
+			for _, st := range result.Status {
+				fmt.Printf("                  %s\n", st)
+			}
+		}
+		if result.DNSSEC != "" {
+			fmt.Printf("  DNSSEC:         %s\n", result.DNSSEC)
+		}
+		printWhoisContacts(result.Contacts)
 	} else {
 		// IP WHOIS
 		if result.Organization != "" {
@@ -121,11 +207,136 @@ EXAMPLES:
 		if result.CIDR != "" {
 			fmt.Printf("  CIDR:           %s\n", result.CIDR)
 		}
+		if result.ASN != 0 {
+			if result.ASName != "" {
+				fmt.Printf("  Origin AS:      AS%d (%s)\n", result.ASN, result.ASName)
+			} else {
+				fmt.Printf("  Origin AS:      AS%d\n", result.ASN)
+			}
+		}
+		if result.Prefix != "" {
+			fmt.Printf("  BGP Prefix:     %s\n", result.Prefix)
+		}
 		if result.Country != "" {
 			fmt.Printf("  Country:        %s\n", result.Country)
 		}
 	}
 
-	fmt.Printf("\n  Server:         %s\n", result.Server)
+	if result.AbuseEmail != "" {
+		fmt.Printf("  Abuse Email:    %s\n", result.AbuseEmail)
+	}
+	if result.AbusePhone != "" {
+		fmt.Printf("  Abuse Phone:    %s\n", result.AbusePhone)
+	}
+
+	fmt.Printf("\n  Server:         %s (%s)\n", result.Server, strings.ToUpper(result.Protocol))
+	if result.Referral != "" && result.Referral != result.Server {
+		fmt.Printf("  Referral:       %s\n", result.Referral)
+	}
 	fmt.Printf("  Query Time:     %v\n", result.Duration.Round(time.Millisecond))
 }
+
+// printWhoisContacts prints registrant/admin/tech contacts, skipping
+// empty fields.
+func printWhoisContacts(contacts []whois.Contact) {
+	titles := map[string]string{"registrant": "Registrant", "admin": "Admin", "tech": "Tech"}
+	for _, c := range contacts {
+		fmt.Printf("  %s Contact:\n", titles[c.Role])
+		for _, f := range []struct{ label, value string }{
+			{"Name", c.Name},
+			{"Organization", c.Organization},
+			{"Email", c.Email},
+			{"Phone", c.Phone},
+			{"Country", c.Country},
+		} {
+			if f.value != "" {
+				fmt.Printf("    %-14s%s\n", f.label+":", f.value)
+			}
+		}
+	}
+}
+
+// runWhoisBulk looks up every target listed in path and prints a JSON array
+// or a summary table.
+func runWhoisBulk(client *whois.Client, path string, method whois.Method, concurrency int, asJSON bool) {
+	targets, err := readWhoisTargets(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(targets) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: no targets in %s\n", path)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	go func() {
+		<-sigCh
+		cancel()
+	}()
+
+	if !asJSON {
+		fmt.Printf("Looking up %d targets (%d parallel)...\n\n", len(targets), concurrency)
+	}
+
+	results := client.LookupMany(ctx, targets, method, concurrency)
+
+	if asJSON {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Printf("%-30s %-30s %-22s %s\n", "TARGET", "REGISTRAR / ORG", "EXPIRES", "DAYS")
+	fmt.Println(strings.Repeat("─", 92))
+	failed := 0
+	for _, br := range results {
+		if br.Error != "" {
+			failed++
+			fmt.Printf("%-30s Error: %s\n", truncate(br.Query, 30), br.Error)
+			continue
+		}
+		r := br.Result
+		owner := r.Registrar
+		if owner == "" {
+			owner = r.Organization
+		}
+		days := "-"
+		if d := r.DaysUntilExpiry(); d >= 0 {
+			days = fmt.Sprintf("%d", d)
+			if d < 30 {
+				days += " ⚠"
+			}
+		}
+		fmt.Printf("%-30s %-30s %-22s %s\n", truncate(br.Query, 30), truncate(owner, 30), truncate(r.ExpiresDate, 22), days)
+	}
+	fmt.Printf("\n%d looked up, %d failed\n", len(results)-failed, failed)
+}
+
+// readWhoisTargets reads one target per line, skipping blank lines and
+// # comments.
+func readWhoisTargets(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	targets := make([]string, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		targets = append(targets, strings.Fields(line)[0])
+	}
+	return targets, scanner.Err()
+}
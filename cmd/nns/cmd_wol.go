@@ -8,17 +8,26 @@ import (
 	"github.com/JedizLaPulga/NNS/internal/wol"
 )
 
-func runWOL(args []string) {
+// wolFlags holds the flags of the wol command.
+type wolFlags struct {
+	broadcastFlag *string
+	portFlag      *int
+	interfaceFlag *string
+}
+
+// newWOLFlags defines the flags of the wol command.
+func newWOLFlags() (*flag.FlagSet, *wolFlags) {
 	fs := flag.NewFlagSet("wol", flag.ExitOnError)
+	cli := &wolFlags{}
 
-	broadcastFlag := fs.String("broadcast", "255.255.255.255", "Broadcast address")
-	portFlag := fs.Int("port", 9, "UDP port")
-	interfaceFlag := fs.String("interface", "", "Network interface")
+	cli.broadcastFlag = fs.String("broadcast", "255.255.255.255", "Broadcast address")
+	cli.portFlag = fs.Int("port", 9, "UDP port")
+	cli.interfaceFlag = fs.String("interface", "", "Network interface")
 
 	// Short flags
-	fs.StringVar(broadcastFlag, "b", "255.255.255.255", "Broadcast")
-	fs.IntVar(portFlag, "p", 9, "Port")
-	fs.StringVar(interfaceFlag, "i", "", "Interface")
+	fs.StringVar(cli.broadcastFlag, "b", "255.255.255.255", "Broadcast")
+	fs.IntVar(cli.portFlag, "p", 9, "Port")
+	fs.StringVar(cli.interfaceFlag, "i", "", "Interface")
 
 	fs.Usage = func() {
 		fmt.Println(`Usage: nns wol [MAC] [OPTIONS]
@@ -37,7 +46,13 @@ EXAMPLES:
   nns wol aa:bb:cc:dd:ee:ff --interface eth0`)
 	}
 
-	if err := fs.Parse(args); err != nil {
+	return fs, cli
+}
+
+func runWOL(args []string) {
+	fs, cli := newWOLFlags()
+
+	if err := parseFlags(fs, args); err != nil {
 		os.Exit(1)
 	}
 
@@ -53,10 +68,10 @@ EXAMPLES:
 	fmt.Printf("Sending Wake-on-LAN magic packet to %s...\n", formattedMAC)
 
 	var err error
-	if *interfaceFlag != "" {
-		err = wol.WakeWithInterface(mac, *interfaceFlag, *portFlag)
+	if *cli.interfaceFlag != "" {
+		err = wol.WakeWithInterface(mac, *cli.interfaceFlag, *cli.portFlag)
 	} else {
-		err = wol.Wake(mac, *broadcastFlag, *portFlag)
+		err = wol.Wake(mac, *cli.broadcastFlag, *cli.portFlag)
 	}
 
 	if err != nil {
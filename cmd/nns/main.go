@@ -1,183 +1,184 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"runtime"
+	"runtime/debug"
+	"slices"
+
+	"github.com/JedizLaPulga/NNS/internal/config"
+	"github.com/JedizLaPulga/NNS/internal/output"
+	"github.com/JedizLaPulga/NNS/internal/srcaddr"
 )
 
 // Build-time variables (injected via -ldflags)
 var (
-	version = "dev"     // -X main.version=v1.0.0
-	commit  = "none"    // -X main.commit=$(git rev-parse --short HEAD)
-	date    = "unknown" // -X main.date=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+	version   = "dev"     // -X main.version=v1.0.0
+	commit    = "none"    // -X main.commit=$(git rev-parse --short HEAD)
+	date      = "unknown" // -X main.date=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+	goVersion = ""        // -X main.goVersion=$(go env GOVERSION); defaults to the running toolchain
 )
 
+// userConfig holds per-command flag defaults from ~/.nns.yaml or --config.
+var userConfig *config.Config
+
 func main() {
-	if len(os.Args) < 2 {
+	cliArgs, configPath, err := config.SplitFlag(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if userConfig, err = config.Load(configPath); err != nil {
+		if configPath != "" {
+			fmt.Fprintf(os.Stderr, "Error: config: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: ignoring config: %v\n", err)
+	}
+
+	cliArgs, global := output.ParseGlobalFlags(cliArgs)
+	output.Configure(global)
+
+	if len(cliArgs) < 1 {
 		printHelp()
 		os.Exit(0)
 	}
 
-	command := os.Args[1]
+	runCommand(cliArgs[0], cliArgs[1:])
+}
 
-	switch command {
-	case "--version", "-v":
-		printVersion()
+// command is a top-level nns command.
+type command struct {
+	Name        string
+	Aliases     []string
+	Description string               // One line for the help text
+	Flags       func() *flag.FlagSet // Defines the command's flags without running it
+	Run         func(args []string)
+}
+
+// commands returns every command in help order. It is a function rather
+// than a variable because completion refers back to the table.
+func commands() []command {
+	return []command{
+		{Name: "ping", Description: "Send ICMP echo requests to a host", Flags: flagsOf(newPingFlags), Run: runPing},
+		{Name: "traceroute", Description: "Trace the network path to a host", Flags: flagsOf(newTracerouteFlags), Run: runTraceroute},
+		{Name: "mtr", Description: "My TraceRoute - continuous ping + traceroute", Flags: flagsOf(newMTRFlags), Run: runMTR},
+		{Name: "portscan", Description: "Scan ports on a target host or network", Flags: flagsOf(newPortScanFlags), Run: runPortScan},
+		{Name: "bench", Description: "Benchmark HTTP endpoints", Flags: flagsOf(newBenchFlags), Run: runBench},
+		{Name: "dns", Description: "Perform DNS lookups (A, MX, TXT, etc.)", Flags: flagsOf(newDNSFlags), Run: runDNS},
+		{Name: "ssl", Description: "Analyze SSL/TLS certificates", Flags: flagsOf(newSSLFlags), Run: runSSL},
+		{Name: "http", Description: "HTTP client with timing breakdown", Flags: flagsOf(newHTTPFlags), Run: runHTTP},
+		{Name: "proxy", Description: "Start a local debugging proxy server", Flags: flagsOf(newProxyFlags), Run: runProxy},
+		{Name: "sweep", Description: "Discover live hosts on a network (CIDR scan)", Flags: flagsOf(newSweepFlags), Run: runSweep},
+		{Name: "arp", Description: "View ARP table with MAC vendor lookup", Flags: flagsOf(newARPFlags), Run: runARP},
+		{Name: "whois", Description: "WHOIS lookup for domains and IPs", Flags: flagsOf(newWhoisFlags), Run: runWhois},
+		{Name: "netstat", Description: "Show network connections and routing", Flags: flagsOf(newNetstatFlags), Run: runNetstat},
+		{Name: "wol", Description: "Wake-on-LAN - power on remote machines", Flags: flagsOf(newWOLFlags), Run: runWOL},
+		{Name: "headers", Description: "Analyze HTTP security headers", Flags: flagsOf(newHeadersFlags), Run: runHeaders},
+		{Name: "ipinfo", Description: "IP geolocation and ASN lookup", Flags: newIPInfoFlags, Run: runIPInfo},
+		{Name: "cidr", Description: "CIDR/subnet calculator", Flags: flagsOf(newCIDRFlags), Run: runCIDR},
+		{Name: "mac", Description: "MAC address utilities", Flags: flagsOf(newMACFlags), Run: runMAC},
+		{Name: "interfaces", Aliases: []string{"ifaces"}, Description: "List network interfaces with details", Flags: flagsOf(newInterfacesFlags), Run: runInterfaces},
+		{Name: "speedtest", Description: "Bandwidth speed test", Flags: flagsOf(newSpeedtestFlags), Run: runSpeedtest},
+		{Name: "netwatch", Description: "Monitor network changes in real-time", Flags: flagsOf(newNetwatchFlags), Run: runNetwatch},
+		{Name: "tcptest", Description: "TCP connectivity test with timing breakdown", Flags: flagsOf(newTCPTestFlags), Run: runTCPTest},
+		{Name: "bwmon", Description: "Real-time bandwidth monitor", Flags: flagsOf(newBWMonFlags), Run: runBWMon},
+		{Name: "services", Description: "Service detection via banner grabbing", Flags: flagsOf(newServicesFlags), Run: runServices},
+		{Name: "latency", Description: "Continuous latency monitoring with sparkline", Flags: flagsOf(newLatencyFlags), Run: runLatency},
+		{Name: "forward", Description: "TCP port forwarding / tunnel", Flags: flagsOf(newPortForwardFlags), Run: runPortForward},
+		{Name: "conntest", Description: "Test connectivity to multiple hosts", Flags: flagsOf(newConnTestFlags), Run: runConnTest},
+		{Name: "dnstrace", Description: "Trace DNS resolution chain", Flags: flagsOf(newDNSTraceFlags), Run: runDNSTrace},
+		{Name: "listen", Description: "TCP/UDP listener for connectivity testing", Flags: flagsOf(newListenFlags), Run: runListen},
+		{Name: "urlcheck", Description: "Check health of multiple URLs", Flags: flagsOf(newURLCheckFlags), Run: runURLCheck},
+		{Name: "pcap", Description: "Packet capture and analysis", Flags: flagsOf(newPcapFlags), Run: runPcap},
+		{Name: "netpath", Description: "Network path quality analysis", Flags: flagsOf(newNetpathFlags), Run: runNetpath},
+		{Name: "httpstress", Description: "HTTP load/stress testing", Flags: flagsOf(newHTTPStressFlags), Run: runHTTPStress},
+		{Name: "sshscan", Description: "SSH server security audit", Flags: flagsOf(newSSHScanFlags), Run: runSSHScan},
+		{Name: "dnsperf", Description: "DNS resolver performance benchmark", Flags: flagsOf(newDNSPerfFlags), Run: runDNSPerf},
+		{Name: "websocket", Aliases: []string{"ws"}, Description: "WebSocket connectivity and latency tester", Flags: flagsOf(newWebSocketFlags), Run: runWebSocket},
+		{Name: "tlscheck", Description: "TLS certificate chain validator", Flags: flagsOf(newTLSCheckFlags), Run: runTLSCheck},
+		{Name: "tlsaudit", Description: "Deep TLS audit: protocols, ciphers, vulnerabilities, grade", Flags: flagsOf(newTLSAuditFlags), Run: runTLSAudit},
+		{Name: "routes", Description: "View and analyze routing table", Flags: flagsOf(newRoutesFlags), Run: runRoutes},
+		{Name: "geoloc", Aliases: []string{"geo"}, Description: "IP geolocation with city, country, ASN info", Flags: flagsOf(newGeolocFlags), Run: runGeoloc},
+		{Name: "subnet", Description: "Subnet calculator (split, merge, contains)", Flags: flagsOf(newSubnetFlags), Run: runSubnet},
+		{Name: "wakewait", Aliases: []string{"ww"}, Description: "Wake-on-LAN + wait for host to come online", Flags: flagsOf(newWakeWaitFlags), Run: runWakeWait},
+		{Name: "dnssec", Description: "DNSSEC chain validation and security audit", Flags: flagsOf(newDNSSECFlags), Run: runDNSSEC},
+		{Name: "blacklist", Aliases: []string{"bl"}, Description: "Check IP/domain against spam blacklists", Flags: flagsOf(newBlacklistFlags), Run: runBlacklist},
+		{Name: "fingerprint", Aliases: []string{"fp"}, Description: "OS and service fingerprinting", Flags: flagsOf(newFingerprintFlags), Run: runFingerprint},
+		{Name: "snmp", Description: "SNMP device discovery and OID walking", Flags: flagsOf(newSNMPFlags), Run: runSNMP},
+		{Name: "resolvers", Description: "Compare DNS resolvers for speed/privacy", Flags: flagsOf(newResolversFlags), Run: runResolvers},
+		{Name: "ntp", Description: "NTP server checker with time offset analysis", Flags: flagsOf(newNTPFlags), Run: runNTP},
+		{Name: "upnp", Description: "UPnP device discovery on local network", Flags: flagsOf(newUPnPFlags), Run: runUPnP},
+		{Name: "leak", Description: "DNS/IP leak testing for VPN privacy audit", Flags: flagsOf(newLeakFlags), Run: runLeak},
+		{Name: "netspeed", Description: "Internal network speed test (iperf-like)", Flags: flagsOf(newNetspeedFlags), Run: runNetspeed},
+		{Name: "mqtt", Description: "MQTT broker connectivity and security check", Flags: flagsOf(newMQTTFlags), Run: runMQTT},
+		{Name: "netaudit", Aliases: []string{"audit"}, Description: "Network security audit (misconfigurations), alias: audit", Flags: flagsOf(newNetauditFlags), Run: runNetaudit},
+		{Name: "pcping", Aliases: []string{"pcp"}, Description: "Protocol-aware ping (TCP/UDP/HTTP/DNS)", Flags: flagsOf(newPCPingFlags), Run: runPCPing},
+		{Name: "fwd", Aliases: []string{"revproxy"}, Description: "HTTP/HTTPS reverse proxy with logging", Flags: flagsOf(newFwdFlags), Run: runFwd},
+		{Name: "certhunt", Aliases: []string{"ct"}, Description: "Certificate transparency log search", Flags: flagsOf(newCerthuntFlags), Run: runCerthunt},
+		{Name: "neighbors", Aliases: []string{"nb", "discover"}, Description: "mDNS/DNS-SD neighbor discovery, alias: discover", Flags: flagsOf(newNeighborsFlags), Run: runNeighbors},
+		{Name: "asn", Description: "BGP/ASN lookup via Team Cymru and RDAP", Flags: flagsOf(newASNFlags), Run: runASN},
+		{Name: "portknock", Aliases: []string{"knock"}, Description: "TCP/UDP port knock sequence sender", Flags: flagsOf(newPortKnockFlags), Run: runPortKnock},
+		{Name: "jwt", Description: "JWT token decoder and security analyzer", Flags: flagsOf(newJWTFlags), Run: runJWT},
+		{Name: "encdec", Aliases: []string{"encode", "decode"}, Description: "Encode/decode (Base64, Hex, URL, Binary)", Flags: flagsOf(newEncDecFlags), Run: runEncDec},
+		{Name: "httptrace", Aliases: []string{"htrace"}, Description: "HTTP request trace with redirect chain", Flags: flagsOf(newHTTPTraceFlags), Run: runHTTPTrace},
+		{Name: "cidrmerge", Aliases: []string{"cmerge"}, Description: "Merge and consolidate CIDR ranges", Flags: flagsOf(newCIDRMergeFlags), Run: runCIDRMerge},
+		{Name: "hashcheck", Aliases: []string{"hash"}, Description: "Compute and verify cryptographic hashes", Flags: flagsOf(newHashCheckFlags), Run: runHashCheck},
+		{Name: "netcalc", Aliases: []string{"ipcalc"}, Description: "Network/IP calculator and arithmetic", Flags: flagsOf(newNetcalcFlags), Run: runNetcalc},
+		{Name: "passwd", Aliases: []string{"pwgen"}, Description: "Password strength analyzer and generator", Flags: flagsOf(newPasswdFlags), Run: runPasswd},
+		{Name: "ratelimit", Aliases: []string{"rl"}, Description: "HTTP rate limit detection and policy probe", Flags: flagsOf(newRatelimitFlags), Run: runRatelimit},
+		{Name: "ipconv", Aliases: []string{"ip2"}, Description: "IP address format converter (hex/oct/bin/int)", Flags: flagsOf(newIPConvFlags), Run: runIPConv},
+		{Name: "tcpdump", Aliases: []string{"td"}, Description: "TCP connection analyzer with TLS inspection", Flags: flagsOf(newTCPDumpFlags), Run: runTCPDump},
+		{Name: "sysinfo", Aliases: []string{"sys"}, Description: "System and network environment info", Flags: flagsOf(newSysinfoFlags), Run: runSysinfo},
+		{Name: "httphealth", Aliases: []string{"hh"}, Description: "HTTP endpoint health monitor with uptime tracking", Flags: flagsOf(newHTTPHealthFlags), Run: runHTTPHealth},
+		{Name: "dnsenum", Aliases: []string{"enumdns"}, Description: "DNS subdomain enumeration via wordlist", Flags: flagsOf(newDNSEnumFlags), Run: runDNSEnum},
+		{Name: "completion", Description: "Generate shell completion (bash, zsh, fish)", Flags: newCompletionFlags, Run: runCompletion},
+	}
+}
 
+// flagsOf adapts a flag constructor that also returns the parsed values
+// for the command table.
+func flagsOf[T any](newFlags func() (*flag.FlagSet, T)) func() *flag.FlagSet {
+	return func() *flag.FlagSet {
+		fs, _ := newFlags()
+		return fs
+	}
+}
+
+// lookupCommand finds a command by name or alias.
+func lookupCommand(name string) (command, bool) {
+	for _, c := range commands() {
+		if c.Name == name || slices.Contains(c.Aliases, name) {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// runCommand dispatches a command name or alias to its implementation.
+func runCommand(name string, args []string) {
+	switch name {
+	case "--version", "-v":
+		printVersion(args)
+		return
 	case "--help", "-h", "help":
 		printHelp()
-	case "ping":
-		runPing(os.Args[2:])
-	case "traceroute":
-		runTraceroute(os.Args[2:])
-	case "portscan":
-		runPortScan(os.Args[2:])
-	case "bench":
-		runBench(os.Args[2:])
-	case "dns":
-		runDNS(os.Args[2:])
-	case "ssl":
-		runSSL(os.Args[2:])
-	case "http":
-		runHTTP(os.Args[2:])
-	case "proxy":
-		runProxy(os.Args[2:])
-	case "sweep":
-		runSweep(os.Args[2:])
-	case "arp":
-		runARP(os.Args[2:])
-	case "whois":
-		runWhois(os.Args[2:])
-	case "netstat":
-		runNetstat(os.Args[2:])
-	case "wol":
-		runWOL(os.Args[2:])
-	case "headers":
-		runHeaders(os.Args[2:])
-	case "ipinfo":
-		runIPInfo(os.Args[2:])
-	case "cidr":
-		runCIDR(os.Args[2:])
-	case "mac":
-		runMAC(os.Args[2:])
-	case "mtr":
-		runMTR(os.Args[2:])
-	case "interfaces", "ifaces":
-		runInterfaces(os.Args[2:])
-	case "speedtest":
-		runSpeedtest(os.Args[2:])
-	case "netwatch":
-		runNetwatch(os.Args[2:])
-	case "tcptest":
-		runTCPTest(os.Args[2:])
-	case "bwmon":
-		runBWMon(os.Args[2:])
-	case "services":
-		runServices(os.Args[2:])
-	case "latency":
-		runLatency(os.Args[2:])
-	case "forward":
-		runPortForward(os.Args[2:])
-	case "conntest":
-		runConnTest(os.Args[2:])
-	case "dnstrace":
-		runDNSTrace(os.Args[2:])
-	case "listen":
-		runListen(os.Args[2:])
-	case "urlcheck":
-		runURLCheck(os.Args[2:])
-	case "pcap":
-		runPcap(os.Args[2:])
-	case "netpath":
-		runNetpath(os.Args[2:])
-	case "httpstress":
-		runHTTPStress(os.Args[2:])
-	case "sshscan":
-		runSSHScan(os.Args[2:])
-	case "dnsperf":
-		runDNSPerf(os.Args[2:])
-	case "websocket", "ws":
-		runWebSocket(os.Args[2:])
-	case "tlscheck":
-		runTLSCheck(os.Args[2:])
-	case "routes":
-		runRoutes(os.Args[2:])
-	case "geoloc", "geo":
-		runGeoloc(os.Args[2:])
-	case "subnet":
-		runSubnet(os.Args[2:])
-	case "wakewait", "ww":
-		runWakeWait(os.Args[2:])
-	case "dnssec":
-		runDNSSEC(os.Args[2:])
-	case "blacklist", "bl":
-		runBlacklist(os.Args[2:])
-	case "fingerprint", "fp":
-		runFingerprint(os.Args[2:])
-	case "snmp":
-		runSNMP(os.Args[2:])
-	case "resolvers":
-		runResolvers(os.Args[2:])
-	case "ntp":
-		runNTP(os.Args[2:])
-	case "upnp":
-		runUPnP(os.Args[2:])
-	case "leak":
-		runLeak(os.Args[2:])
-	case "netspeed":
-		runNetspeed(os.Args[2:])
-	case "mqtt":
-		runMQTT(os.Args[2:])
-	case "netaudit", "audit":
-		runNetaudit(os.Args[2:])
-	case "pcping", "pcp":
-		runPCPing(os.Args[2:])
-	case "fwd", "revproxy":
-		runFwd(os.Args[2:])
-	case "certhunt", "ct":
-		runCerthunt(os.Args[2:])
-	case "neighbors", "nb":
-		runNeighbors(os.Args[2:])
-	case "asn":
-		runASN(os.Args[2:])
-	case "portknock", "knock":
-		runPortKnock(os.Args[2:])
-	case "jwt":
-		runJWT(os.Args[2:])
-	case "encdec", "encode", "decode":
-		runEncDec(os.Args[2:])
-	case "httptrace", "htrace":
-		runHTTPTrace(os.Args[2:])
-	case "cidrmerge", "cmerge":
-		runCIDRMerge(os.Args[2:])
-	case "hashcheck", "hash":
-		runHashCheck(os.Args[2:])
-	case "netcalc", "ipcalc":
-		runNetcalc(os.Args[2:])
-	case "passwd", "pwgen":
-		runPasswd(os.Args[2:])
-	case "ratelimit", "rl":
-		runRatelimit(os.Args[2:])
-	case "ipconv", "ip2":
-		runIPConv(os.Args[2:])
-	case "tcpdump", "td":
-		runTCPDump(os.Args[2:])
-	case "sysinfo", "sys":
-		runSysinfo(os.Args[2:])
-	case "httphealth", "hh":
-		runHTTPHealth(os.Args[2:])
-	case "dnsenum", "enumdns":
-		runDNSEnum(os.Args[2:])
-	default:
-		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", command)
+		return
+	}
+
+	c, ok := lookupCommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", name)
 		printHelp()
 		os.Exit(1)
 	}
+	c.Run(args)
 }
 
-func printHelp() {
-	help := `NNS - Network Swiss Army Knife
+// helpHeader and helpFooter surround the command list printed by printHelp.
+const helpHeader = `NNS - Network Swiss Army Knife
 
 A powerful networking toolkit for sysadmins and developers.
 
@@ -185,81 +186,15 @@ USAGE:
     nns [COMMAND] [OPTIONS]
 
 COMMANDS:
-    ping         Send ICMP echo requests to a host
-    traceroute   Trace the network path to a host
-    mtr          My TraceRoute - continuous ping + traceroute
-    portscan     Scan ports on a target host or network
-    bench        Benchmark HTTP endpoints
-    dns          Perform DNS lookups (A, MX, TXT, etc.)
-    ssl          Analyze SSL/TLS certificates
-    http         HTTP client with timing breakdown
-    proxy        Start a local debugging proxy server
-    sweep        Discover live hosts on a network (CIDR scan)
-    arp          View ARP table with MAC vendor lookup
-    whois        WHOIS lookup for domains and IPs
-    netstat      Show network connections and routing
-    wol          Wake-on-LAN - power on remote machines
-    headers      Analyze HTTP security headers
-    ipinfo       IP geolocation and ASN lookup
-    cidr         CIDR/subnet calculator
-    mac          MAC address utilities
-    interfaces   List network interfaces with details
-    speedtest    Bandwidth speed test
-    netwatch     Monitor network changes in real-time
-    tcptest      TCP connectivity test with timing breakdown
-    bwmon        Real-time bandwidth monitor
-    services     Service detection via banner grabbing
-    latency      Continuous latency monitoring with sparkline
-    forward      TCP port forwarding / tunnel
-    conntest     Test connectivity to multiple hosts
-    dnstrace     Trace DNS resolution chain
-    listen       TCP/UDP listener for connectivity testing
-    urlcheck     Check health of multiple URLs
-    pcap         Packet capture and analysis
-    netpath      Network path quality analysis
-    httpstress   HTTP load/stress testing
-    sshscan      SSH server security audit
-    dnsperf      DNS resolver performance benchmark
-    websocket    WebSocket connectivity and latency tester
-    tlscheck     TLS certificate chain validator
-    routes       View and analyze routing table
-    geoloc       IP geolocation with city, country, ASN info
-    subnet       Subnet calculator (split, merge, contains)
-    wakewait     Wake-on-LAN + wait for host to come online
-    dnssec       DNSSEC chain validation and security audit
-    blacklist    Check IP/domain against spam blacklists
-    fingerprint  OS and service fingerprinting
-    snmp         SNMP device discovery and OID walking
-    resolvers    Compare DNS resolvers for speed/privacy
-    ntp          NTP server checker with time offset analysis
-    upnp         UPnP device discovery on local network
-    leak         DNS/IP leak testing for VPN privacy audit
-    netspeed     Internal network speed test (iperf-like)
-    mqtt         MQTT broker connectivity and security check
-    netaudit     Network security audit (misconfigurations)
-    pcping       Protocol-aware ping (TCP/UDP/HTTP/DNS)
-    fwd          HTTP/HTTPS reverse proxy with logging
-    certhunt     Certificate transparency log search
-    neighbors    mDNS/DNS-SD network neighbor discovery
-    asn          BGP/ASN lookup via Team Cymru and RDAP
-    portknock    TCP/UDP port knock sequence sender
-    jwt          JWT token decoder and security analyzer
-    encdec       Encode/decode (Base64, Hex, URL, Binary)
-    httptrace    HTTP request trace with redirect chain
-    cidrmerge    Merge and consolidate CIDR ranges
-    hashcheck    Compute and verify cryptographic hashes
-    netcalc      Network/IP calculator and arithmetic
-    passwd       Password strength analyzer and generator
-    ratelimit    HTTP rate limit detection and policy probe
-    ipconv       IP address format converter (hex/oct/bin/int)
-    tcpdump      TCP connection analyzer with TLS inspection
-    sysinfo      System and network environment info
-    httphealth   HTTP endpoint health monitor with uptime tracking
-    dnsenum      DNS subdomain enumeration via wordlist
+`
 
+const helpFooter = `
 OPTIONS:
-    --version, -v    Show version information
+    --version, -v    Show version information (add --json for scripts)
     --help, -h       Show this help message
+    --json           Output JSON (before the command; for commands with --json)
+    --no-color       Disable colored output (also honors NO_COLOR)
+    --config FILE    Per-command flag defaults (default: ~/.nns.yaml)
 
 Use "nns [COMMAND] --help" for more information about a command.
 
@@ -274,17 +209,115 @@ EXAMPLES:
     nns interfaces --active
     nns speedtest
 `
-	fmt.Print(help)
+
+func printHelp() {
+	fmt.Print(helpHeader)
+	for _, c := range commands() {
+		fmt.Printf("    %-12s %s\n", c.Name, c.Description)
+	}
+	fmt.Print(helpFooter)
 }
 
-func printVersion() {
-	fmt.Printf("nns version %s\n", version)
-	if commit != "none" {
-		fmt.Printf("  commit:  %s\n", commit)
+// parseFlags parses args and then fills in the config file defaults for
+// fs's command, so flags given on the command line override the config.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	// Commands define their own --json; forward the global one.
+	if err := output.ApplyJSON(fs, output.Stdout.JSONMode()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	for _, w := range userConfig.Apply(fs) {
+		fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", userConfig.Path, w)
+	}
+	return nil
+}
+
+// buildInfo describes the running binary for --version.
+type buildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+}
+
+// currentBuild returns the build metadata, filling the version and commit from
+// the module and VCS information embedded by the go command when they were
+// not injected via -ldflags.
+func currentBuild() buildInfo {
+	b := buildInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: date,
+		GoVersion: goVersion,
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+	}
+	if b.GoVersion == "" {
+		b.GoVersion = runtime.Version()
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return b
+	}
+	if b.Version == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		b.Version = info.Main.Version
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" && b.Commit == "none" {
+			b.Commit = s.Value
+			if len(b.Commit) > 7 {
+				b.Commit = b.Commit[:7]
+			}
+		}
+	}
+	return b
+}
+
+// sourceFlags registers --interface/-I and --source-ip/-S on fs. The
+// returned function resolves them to a source address after parsing,
+// exiting on an invalid selection; it returns "" when neither was given.
+func sourceFlags(fs *flag.FlagSet) func() string {
+	iface := fs.String("interface", "", "Send probes from this interface's IPv4 address")
+	sourceIP := fs.String("source-ip", "", "Send probes from this local IPv4 address")
+	fs.StringVar(iface, "I", "", "Source interface")
+	fs.StringVar(sourceIP, "S", "", "Source IP address")
+
+	return func() string {
+		source, err := srcaddr.Resolve(*iface, *sourceIP)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return source
+	}
+}
+
+// printVersion prints the build metadata, as JSON with the global --json or
+// when args contain --json.
+func printVersion(args []string) {
+	b := currentBuild()
+
+	if output.Stdout.JSONMode() || slices.Contains(args, "--json") || slices.Contains(args, "-json") {
+		if err := output.Stdout.JSON(b); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Printf("nns version %s\n", b.Version)
+	if b.Commit != "none" {
+		fmt.Printf("  commit:  %s\n", b.Commit)
 	}
-	if date != "unknown" {
-		fmt.Printf("  built:   %s\n", date)
+	if b.BuildDate != "unknown" {
+		fmt.Printf("  built:   %s\n", b.BuildDate)
 	}
-	fmt.Printf("  go:      %s\n", runtime.Version())
-	fmt.Printf("  os/arch: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Printf("  go:      %s\n", b.GoVersion)
+	fmt.Printf("  os/arch: %s/%s\n", b.OS, b.Arch)
 }
@@ -0,0 +1,207 @@
+package arp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AnomalyKind identifies the type of ARP anomaly.
+type AnomalyKind string
+
+// Anomaly kinds reported by DetectAnomalies and CompareSnapshots.
+const (
+	AnomalyMACMultipleIPs    AnomalyKind = "mac-multiple-ips"
+	AnomalyIPMultipleMACs    AnomalyKind = "ip-multiple-macs"
+	AnomalyGatewayMACChanged AnomalyKind = "gateway-mac-changed"
+)
+
+// Severity levels for anomalies.
+const (
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Anomaly is a suspicious ARP mapping, typically an ARP-poisoning indicator.
+type Anomaly struct {
+	Kind     AnomalyKind `json:"kind"`
+	Severity string      `json:"severity"`
+	IP       string      `json:"ip,omitempty"`
+	MAC      string      `json:"mac,omitempty"`
+	IPs      []string    `json:"ips,omitempty"`
+	MACs     []string    `json:"macs,omitempty"`
+	Message  string      `json:"message"`
+}
+
+// DetectAnomalies analyses a single ARP table snapshot and flags one MAC
+// claiming multiple IPs and one IP mapped to multiple MACs (e.g. across
+// interfaces). A MAC answering for several IPs is also normal for routers
+// doing proxy ARP or multi-homed hosts, so it is reported as a warning.
+func DetectAnomalies(entries []Entry) []Anomaly {
+	ipsByMAC := make(map[string][]string)
+	macsByIP := make(map[string][]string)
+
+	for _, e := range entries {
+		if e.IP == "" || !isUnicastMAC(e.MAC) {
+			continue
+		}
+		ipsByMAC[e.MAC] = appendUnique(ipsByMAC[e.MAC], e.IP)
+		macsByIP[e.IP] = appendUnique(macsByIP[e.IP], e.MAC)
+	}
+
+	anomalies := make([]Anomaly, 0)
+
+	for mac, ips := range ipsByMAC {
+		if len(ips) < 2 {
+			continue
+		}
+		sort.Strings(ips)
+		anomalies = append(anomalies, Anomaly{
+			Kind:     AnomalyMACMultipleIPs,
+			Severity: SeverityWarning,
+			MAC:      mac,
+			IPs:      ips,
+			Message:  fmt.Sprintf("MAC %s claims %d IPs: %s", mac, len(ips), strings.Join(ips, ", ")),
+		})
+	}
+
+	for ip, macs := range macsByIP {
+		if len(macs) < 2 {
+			continue
+		}
+		sort.Strings(macs)
+		anomalies = append(anomalies, Anomaly{
+			Kind:     AnomalyIPMultipleMACs,
+			Severity: SeverityCritical,
+			IP:       ip,
+			MACs:     macs,
+			Message:  fmt.Sprintf("IP %s is mapped to %d MACs: %s", ip, len(macs), strings.Join(macs, ", ")),
+		})
+	}
+
+	sortAnomalies(anomalies)
+	return anomalies
+}
+
+// CompareSnapshots compares two ARP table snapshots taken over time. It
+// reports the gateway's MAC changing, the classic sign of a machine-in-the-
+// middle poisoning the gateway entry, plus any anomalies in curr that were
+// not already present in prev. Use a Watcher to follow more than two
+// snapshots, since the gateway entry can age out between them.
+func CompareSnapshots(prev, curr []Entry, gateway string) []Anomaly {
+	return compareSnapshots(prev, curr, gateway, macsFor(prev, gateway))
+}
+
+// Watcher compares a series of ARP table snapshots. It remembers the last
+// MACs seen for the gateway, so a change is caught even when the gateway
+// entry was missing from the snapshots in between.
+type Watcher struct {
+	gateway    string
+	prev       []Entry
+	gatewayMAC []string
+}
+
+// NewWatcher returns a Watcher for gateway ("" to skip the gateway check)
+// starting from the snapshot initial.
+func NewWatcher(gateway string, initial []Entry) *Watcher {
+	return &Watcher{gateway: gateway, prev: initial, gatewayMAC: macsFor(initial, gateway)}
+}
+
+// Update compares curr with the previous snapshot and the gateway's last
+// known MACs, then makes curr the new baseline.
+func (w *Watcher) Update(curr []Entry) []Anomaly {
+	anomalies := compareSnapshots(w.prev, curr, w.gateway, w.gatewayMAC)
+	w.prev = curr
+	if macs := macsFor(curr, w.gateway); len(macs) > 0 {
+		w.gatewayMAC = macs
+	}
+	return anomalies
+}
+
+// compareSnapshots implements CompareSnapshots, checking the gateway in
+// curr against the MACs in before.
+func compareSnapshots(prev, curr []Entry, gateway string, before []string) []Anomaly {
+	anomalies := make([]Anomaly, 0)
+
+	if gateway != "" {
+		after := macsFor(curr, gateway)
+		if len(before) > 0 && len(after) > 0 && !sameStrings(before, after) {
+			anomalies = append(anomalies, Anomaly{
+				Kind:     AnomalyGatewayMACChanged,
+				Severity: SeverityCritical,
+				IP:       gateway,
+				MACs:     append(append([]string{}, before...), after...),
+				Message: fmt.Sprintf("Gateway %s MAC changed from %s to %s",
+					gateway, strings.Join(before, ", "), strings.Join(after, ", ")),
+			})
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, a := range DetectAnomalies(prev) {
+		seen[a.Message] = true
+	}
+	for _, a := range DetectAnomalies(curr) {
+		if !seen[a.Message] {
+			anomalies = append(anomalies, a)
+		}
+	}
+
+	sortAnomalies(anomalies)
+	return anomalies
+}
+
+// macsFor returns the sorted unique MACs recorded for ip.
+func macsFor(entries []Entry, ip string) []string {
+	macs := make([]string, 0)
+	for _, e := range entries {
+		if e.IP == ip && isUnicastMAC(e.MAC) {
+			macs = appendUnique(macs, e.MAC)
+		}
+	}
+	sort.Strings(macs)
+	return macs
+}
+
+// isUnicastMAC reports whether mac is a usable unicast hardware address.
+// Broadcast, multicast and all-zero addresses are ignored.
+func isUnicastMAC(mac string) bool {
+	if mac == "" || mac == "00:00:00:00:00:00" {
+		return false
+	}
+	var first byte
+	if _, err := fmt.Sscanf(mac, "%02x", &first); err != nil {
+		return false
+	}
+	return first&0x01 == 0
+}
+
+func sortAnomalies(anomalies []Anomaly) {
+	sort.Slice(anomalies, func(i, j int) bool {
+		if anomalies[i].Severity != anomalies[j].Severity {
+			return anomalies[i].Severity == SeverityCritical
+		}
+		return anomalies[i].Message < anomalies[j].Message
+	})
+}
+
+func appendUnique(list []string, v string) []string {
+	for _, s := range list {
+		if s == v {
+			return list
+		}
+	}
+	return append(list, v)
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
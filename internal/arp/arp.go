@@ -3,20 +3,23 @@ package arp
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"net"
 	"os/exec"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 )
 
 // Entry represents an ARP table entry.
 type Entry struct {
-	IP        string
-	MAC       string
-	Vendor    string
-	Interface string
-	Type      string // dynamic, static, etc.
+	IP        string `json:"ip"`
+	MAC       string `json:"mac"`
+	Vendor    string `json:"vendor,omitempty"`
+	Interface string `json:"interface"`
+	Type      string `json:"type"` // dynamic, static, etc.
 }
 
 // GetTable reads the system ARP table.
@@ -243,6 +246,17 @@ func FilterByInterface(entries []Entry, iface string) []Entry {
 	return filtered
 }
 
+// FilterBySubnet returns entries whose IP is inside network.
+func FilterBySubnet(entries []Entry, network *net.IPNet) []Entry {
+	filtered := make([]Entry, 0)
+	for _, e := range entries {
+		if ip := net.ParseIP(e.IP); ip != nil && network.Contains(ip) {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
 // GetInterfaces returns unique interface names from entries.
 func GetInterfaces(entries []Entry) []string {
 	seen := make(map[string]bool)
@@ -257,3 +271,54 @@ func GetInterfaces(entries []Entry) []string {
 
 	return ifaces
 }
+
+// Diff compares two ARP table snapshots. Entries are matched by IP and
+// interface; changed holds the new version of entries whose MAC or type
+// differs. Results are sorted by IP.
+func Diff(prev, curr []Entry) (added, removed, changed []Entry) {
+	key := func(e Entry) string { return e.IP + "%" + e.Interface }
+
+	before := make(map[string]Entry, len(prev))
+	for _, e := range prev {
+		before[key(e)] = e
+	}
+	after := make(map[string]Entry, len(curr))
+	for _, e := range curr {
+		after[key(e)] = e
+	}
+
+	added, removed, changed = make([]Entry, 0), make([]Entry, 0), make([]Entry, 0)
+	for k, e := range after {
+		old, ok := before[k]
+		switch {
+		case !ok:
+			added = append(added, e)
+		case old.MAC != e.MAC || old.Type != e.Type:
+			changed = append(changed, e)
+		}
+	}
+	for k, e := range before {
+		if _, ok := after[k]; !ok {
+			removed = append(removed, e)
+		}
+	}
+
+	sortEntries(added)
+	sortEntries(removed)
+	sortEntries(changed)
+	return added, removed, changed
+}
+
+// sortEntries orders entries numerically by IP, then by interface.
+func sortEntries(entries []Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := net.ParseIP(entries[i].IP), net.ParseIP(entries[j].IP)
+		if a != nil && b != nil && !a.Equal(b) {
+			return bytes.Compare(a.To16(), b.To16()) < 0
+		}
+		if entries[i].IP != entries[j].IP {
+			return entries[i].IP < entries[j].IP
+		}
+		return entries[i].Interface < entries[j].Interface
+	})
+}
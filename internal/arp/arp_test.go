@@ -1,6 +1,12 @@
 package arp
 
 import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 )
 
@@ -25,6 +31,8 @@ func TestNormalizeMACAddress(t *testing.T) {
 }
 
 func TestLookupVendor(t *testing.T) {
+	useRegistry(t, nil)
+
 	tests := []struct {
 		mac  string
 		want string
@@ -118,3 +126,315 @@ func TestParseDarwinARP(t *testing.T) {
 		t.Errorf("parseDarwinARP() returned %d entries, want 2", len(entries))
 	}
 }
+
+// useRegistry replaces the cached OUI registry for the duration of a test.
+func useRegistry(t *testing.T, vendors map[string]string) {
+	t.Helper()
+	registry.mu.Lock()
+	oldLoaded, oldVendors := registry.loaded, registry.vendors
+	registry.loaded, registry.vendors = true, vendors
+	registry.mu.Unlock()
+
+	t.Cleanup(func() {
+		registry.mu.Lock()
+		registry.loaded, registry.vendors = oldLoaded, oldVendors
+		registry.mu.Unlock()
+	})
+}
+
+const testRegistryCSV = `Registry,Assignment,Organization Name,Organization Address
+MA-L,70B3D5,IEEE Registration Authority,445 Hoes Lane Piscataway NJ US 08554
+MA-L,000C29,"VMware, Inc.",3401 Hillview Avenue PALO ALTO CA US 94304
+MA-M,70B3D51,Small Block Co,Somewhere
+MA-S,70B3D5123,Tiny Block Ltd,Elsewhere
+`
+
+func TestParseOUIRegistry(t *testing.T) {
+	vendors := make(map[string]string)
+	if err := parseOUIRegistry(strings.NewReader(testRegistryCSV), vendors); err != nil {
+		t.Fatalf("parseOUIRegistry() CSV error = %v", err)
+	}
+	want := map[string]string{
+		"70B3D5":    "IEEE Registration Authority",
+		"000C29":    "VMware, Inc.",
+		"70B3D51":   "Small Block Co",
+		"70B3D5123": "Tiny Block Ltd",
+	}
+	for prefix, vendor := range want {
+		if vendors[prefix] != vendor {
+			t.Errorf("vendors[%s] = %q, want %q", prefix, vendors[prefix], vendor)
+		}
+	}
+
+	txt := `OUI/MA-L                                                    Organization
+company_id                                                  Organization
+                                                            Address
+
+00-22-72   (hex)		American Micro-Fuel Device Corp.
+002272     (base 16)		American Micro-Fuel Device Corp.
+`
+	vendors = make(map[string]string)
+	if err := parseOUIRegistry(strings.NewReader(txt), vendors); err != nil {
+		t.Fatalf("parseOUIRegistry() txt error = %v", err)
+	}
+	if len(vendors) != 1 || vendors["002272"] != "American Micro-Fuel Device Corp." {
+		t.Errorf("parseOUIRegistry() txt = %v", vendors)
+	}
+}
+
+func TestLookupVendorRegistry(t *testing.T) {
+	vendors := make(map[string]string)
+	if err := parseOUIRegistry(strings.NewReader(testRegistryCSV), vendors); err != nil {
+		t.Fatal(err)
+	}
+	useRegistry(t, vendors)
+
+	tests := []struct {
+		mac  string
+		want string
+	}{
+		{"70:b3:d5:12:34:56", "Tiny Block Ltd"},              // 36-bit MA-S
+		{"70:b3:d5:1f:00:00", "Small Block Co"},              // 28-bit MA-M
+		{"70:b3:d5:ff:00:00", "IEEE Registration Authority"}, // 24-bit MA-L
+		{"00-0c-29-aa-bb-cc", "VMware, Inc."},
+		{"b8:27:eb:11:22:33", "Raspberry Pi"}, // built-in fallback
+		{"invalid", ""},
+	}
+	for _, tt := range tests {
+		if got := LookupVendor(tt.mac); got != tt.want {
+			t.Errorf("LookupVendor(%q) = %q, want %q", tt.mac, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateOUIDatabase(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	useRegistry(t, nil)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testRegistryCSV))
+	}))
+	defer srv.Close()
+
+	n, err := UpdateOUIDatabase(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("UpdateOUIDatabase() error = %v", err)
+	}
+	if n != 4 {
+		t.Errorf("UpdateOUIDatabase() cached %d assignments, want 4", n)
+	}
+
+	path, err := OUICacheFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("cache file not written: %v", err)
+	}
+
+	// Force a reload from disk.
+	registry.mu.Lock()
+	registry.loaded, registry.vendors = false, nil
+	registry.mu.Unlock()
+
+	if got := LookupVendor("70:b3:d5:12:34:56"); got != "Tiny Block Ltd" {
+		t.Errorf("LookupVendor() after update = %q, want Tiny Block Ltd", got)
+	}
+}
+
+func TestDetectAnomalies(t *testing.T) {
+	entries := []Entry{
+		{IP: "192.168.1.1", MAC: "aa:aa:aa:aa:aa:01", Interface: "eth0"},
+		{IP: "192.168.1.1", MAC: "0a:bb:bb:bb:bb:02", Interface: "wlan0"},
+		{IP: "192.168.1.10", MAC: "cc:cc:cc:cc:cc:03", Interface: "eth0"},
+		{IP: "192.168.1.11", MAC: "cc:cc:cc:cc:cc:03", Interface: "eth0"},
+		{IP: "192.168.1.20", MAC: "0c:dd:dd:dd:dd:04", Interface: "eth0"},
+		{IP: "224.0.0.251", MAC: "01:00:5e:00:00:fb", Interface: "eth0"},
+		{IP: "224.0.0.252", MAC: "01:00:5e:00:00:fb", Interface: "eth0"},
+	}
+
+	anomalies := DetectAnomalies(entries)
+	if len(anomalies) != 2 {
+		t.Fatalf("DetectAnomalies() returned %d anomalies, want 2: %+v", len(anomalies), anomalies)
+	}
+
+	// Critical findings sort first.
+	if a := anomalies[0]; a.Kind != AnomalyIPMultipleMACs || a.IP != "192.168.1.1" || len(a.MACs) != 2 {
+		t.Errorf("anomalies[0] = %+v, want IP 192.168.1.1 with 2 MACs", a)
+	}
+	if a := anomalies[1]; a.Kind != AnomalyMACMultipleIPs || a.MAC != "cc:cc:cc:cc:cc:03" || len(a.IPs) != 2 {
+		t.Errorf("anomalies[1] = %+v, want MAC cc:cc:cc:cc:cc:03 with 2 IPs", a)
+	}
+
+	if got := DetectAnomalies(entries[4:5]); len(got) != 0 {
+		t.Errorf("DetectAnomalies() on clean table = %+v, want none", got)
+	}
+}
+
+func TestCompareSnapshots(t *testing.T) {
+	prev := []Entry{
+		{IP: "192.168.1.1", MAC: "aa:aa:aa:aa:aa:01"},
+		{IP: "192.168.1.20", MAC: "0c:dd:dd:dd:dd:04"},
+	}
+	curr := []Entry{
+		{IP: "192.168.1.1", MAC: "0c:dd:dd:dd:dd:04"},
+		{IP: "192.168.1.20", MAC: "0c:dd:dd:dd:dd:04"},
+	}
+
+	anomalies := CompareSnapshots(prev, curr, "192.168.1.1")
+	if len(anomalies) != 2 {
+		t.Fatalf("CompareSnapshots() returned %d anomalies, want 2: %+v", len(anomalies), anomalies)
+	}
+	if anomalies[0].Kind != AnomalyGatewayMACChanged {
+		t.Errorf("anomalies[0].Kind = %s, want %s", anomalies[0].Kind, AnomalyGatewayMACChanged)
+	}
+	if anomalies[1].Kind != AnomalyMACMultipleIPs {
+		t.Errorf("anomalies[1].Kind = %s, want %s", anomalies[1].Kind, AnomalyMACMultipleIPs)
+	}
+
+	if got := CompareSnapshots(curr, curr, "192.168.1.1"); len(got) != 0 {
+		t.Errorf("CompareSnapshots() with no change = %+v, want none", got)
+	}
+}
+
+func TestWatcherGatewayGap(t *testing.T) {
+	w := NewWatcher("192.168.1.1", []Entry{{IP: "192.168.1.1", MAC: "aa:aa:aa:aa:aa:01"}})
+
+	if got := w.Update([]Entry{{IP: "192.168.1.20", MAC: "0c:dd:dd:dd:dd:04"}}); len(got) != 0 {
+		t.Fatalf("Update() without gateway = %+v, want none", got)
+	}
+
+	got := w.Update([]Entry{{IP: "192.168.1.1", MAC: "0c:dd:dd:dd:dd:04"}})
+	if len(got) != 1 || got[0].Kind != AnomalyGatewayMACChanged {
+		t.Fatalf("Update() after gap = %+v, want a gateway MAC change", got)
+	}
+
+	if got := w.Update([]Entry{{IP: "192.168.1.1", MAC: "0c:dd:dd:dd:dd:04"}}); len(got) != 0 {
+		t.Errorf("Update() with same gateway MAC = %+v, want none", got)
+	}
+}
+
+func TestProbeTargets(t *testing.T) {
+	_, network, _ := net.ParseCIDR("192.168.1.0/29")
+	local := &net.IPNet{IP: net.ParseIP("192.168.1.2").To4(), Mask: net.CIDRMask(24, 32)}
+
+	got := probeTargets(network, local)
+	want := []string{"192.168.1.1", "192.168.1.3", "192.168.1.4", "192.168.1.5", "192.168.1.6", "192.168.1.7"}
+	if len(got) != len(want) {
+		t.Fatalf("probeTargets() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i].String() != want[i] {
+			t.Errorf("probeTargets()[%d] = %s, want %s", i, got[i], want[i])
+		}
+	}
+
+	// A range larger than the local subnet is clipped to it.
+	_, wide, _ := net.ParseCIDR("10.0.0.0/16")
+	small := &net.IPNet{IP: net.ParseIP("10.0.0.1").To4(), Mask: net.CIDRMask(30, 32)}
+	if got := probeTargets(wide, small); len(got) != 1 || got[0].String() != "10.0.0.2" {
+		t.Errorf("probeTargets() clipped = %v, want [10.0.0.2]", got)
+	}
+}
+
+func TestProbeNotOnLink(t *testing.T) {
+	if _, err := Probe(context.Background(), "198.51.100.0/24"); err == nil {
+		t.Error("Probe() expected error for a subnet with no local interface")
+	}
+	if _, err := Probe(context.Background(), "not-a-cidr"); err == nil {
+		t.Error("Probe() expected error for an invalid CIDR")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := []Entry{
+		{IP: "192.168.1.1", MAC: "aa:aa:aa:aa:aa:01", Interface: "eth0", Type: "dynamic"},
+		{IP: "192.168.1.10", MAC: "aa:aa:aa:aa:aa:10", Interface: "eth0", Type: "dynamic"},
+		{IP: "192.168.1.9", MAC: "aa:aa:aa:aa:aa:09", Interface: "eth0", Type: "dynamic"},
+	}
+	curr := []Entry{
+		{IP: "192.168.1.1", MAC: "aa:aa:aa:aa:aa:01", Interface: "eth0", Type: "dynamic"},
+		{IP: "192.168.1.10", MAC: "aa:aa:aa:aa:aa:99", Interface: "eth0", Type: "dynamic"},
+		{IP: "192.168.1.20", MAC: "aa:aa:aa:aa:aa:20", Interface: "eth0", Type: "dynamic"},
+		{IP: "192.168.1.3", MAC: "aa:aa:aa:aa:aa:03", Interface: "eth0", Type: "dynamic"},
+	}
+
+	added, removed, changed := Diff(old, curr)
+	if len(added) != 2 || added[0].IP != "192.168.1.3" || added[1].IP != "192.168.1.20" {
+		t.Errorf("Diff() added = %+v, want 192.168.1.3 and 192.168.1.20", added)
+	}
+	if len(removed) != 1 || removed[0].IP != "192.168.1.9" {
+		t.Errorf("Diff() removed = %+v, want 192.168.1.9", removed)
+	}
+	if len(changed) != 1 || changed[0].MAC != "aa:aa:aa:aa:aa:99" {
+		t.Errorf("Diff() changed = %+v, want new MAC for 192.168.1.10", changed)
+	}
+
+	added, removed, changed = Diff(curr, curr)
+	if len(added)+len(removed)+len(changed) != 0 {
+		t.Errorf("Diff() of identical tables = %v %v %v, want empty", added, removed, changed)
+	}
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	srcMAC := net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x01}
+	srcIP := net.ParseIP("192.168.1.10")
+	targetIP := net.ParseIP("192.168.1.20")
+
+	req := BuildRequest(srcMAC, srcIP, targetIP)
+	if len(req) != minEtherFrame {
+		t.Fatalf("request length = %d, want %d", len(req), minEtherFrame)
+	}
+	if _, _, ok := ParseReply(req); ok {
+		t.Error("ParseReply accepted a request frame")
+	}
+
+	// Turn the request into the reply the target would send.
+	replyMAC := net.HardwareAddr{0xb8, 0x27, 0xeb, 0x12, 0x34, 0x56}
+	reply := make([]byte, len(req))
+	copy(reply, req)
+	copy(reply[0:6], srcMAC)
+	copy(reply[6:12], replyMAC)
+	reply[21] = opReply
+	copy(reply[22:28], replyMAC)
+	copy(reply[28:32], targetIP.To4())
+	copy(reply[32:38], srcMAC)
+	copy(reply[38:42], srcIP.To4())
+
+	ip, mac, ok := ParseReply(reply)
+	if !ok {
+		t.Fatal("ParseReply rejected a valid reply")
+	}
+	if ip != "192.168.1.20" {
+		t.Errorf("ip = %s, want 192.168.1.20", ip)
+	}
+	if mac.String() != replyMAC.String() {
+		t.Errorf("mac = %s, want %s", mac, replyMAC)
+	}
+
+	if _, _, ok := ParseReply(reply[:20]); ok {
+		t.Error("ParseReply accepted a truncated frame")
+	}
+}
+
+func TestMergeReplies(t *testing.T) {
+	table := []Entry{
+		{IP: "192.168.1.20", MAC: "aa:bb:cc:dd:ee:01", Interface: "eth0", Type: "dynamic"},
+	}
+	replies := map[string]net.HardwareAddr{
+		"192.168.1.20": {0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0x01},
+		"192.168.1.3":  {0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0x02},
+	}
+
+	got := mergeReplies(table, replies, "eth0")
+	if len(got) != 2 {
+		t.Fatalf("mergeReplies() = %v, want 2 entries", got)
+	}
+	if got[0].IP != "192.168.1.3" || got[0].Type != "probed" || got[0].MAC != "aa:bb:cc:dd:ee:02" || got[0].Interface != "eth0" {
+		t.Errorf("first entry = %+v, want probed 192.168.1.3", got[0])
+	}
+	if got[1].Type != "dynamic" {
+		t.Errorf("table entry should be kept as is, got %+v", got[1])
+	}
+}
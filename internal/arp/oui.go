@@ -1,8 +1,35 @@
 package arp
 
-import "strings"
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
 
-// ouiDatabase contains common MAC address prefixes and their vendors.
+// DefaultOUIURLs are the IEEE registries for MA-L (24-bit), MA-M (28-bit)
+// and MA-S (36-bit) assignments.
+var DefaultOUIURLs = []string{
+	"https://standards-oui.ieee.org/oui/oui.csv",
+	"https://standards-oui.ieee.org/oui28/mam.csv",
+	"https://standards-oui.ieee.org/oui36/oui36.csv",
+}
+
+// prefixLengths lists assignment sizes in hex digits, longest first, so
+// smaller vendor blocks win over the MA-L block they are carved from.
+var prefixLengths = []int{9, 7, 6}
+
+// ouiDatabase contains common MAC address prefixes and their vendors. It is
+// used when no downloaded registry is cached.
 var ouiDatabase = map[string]string{
 	// Apple
 	"00:03:93": "Apple", "00:1b:63": "Apple", "28:cf:da": "Apple",
@@ -55,19 +82,225 @@ var ouiDatabase = map[string]string{
 	"00:1c:62": "LG", "10:68:3f": "LG", "64:99:5d": "LG",
 	// Xiaomi
 	"00:9e:c8": "Xiaomi", "28:6c:07": "Xiaomi", "64:b4:73": "Xiaomi",
+	// IEEE blocks subdivided into MA-M/MA-S assignments
+	"00:1b:c5": "IEEE Registration Authority", "40:d8:55": "IEEE Registration Authority",
+	"70:b3:d5": "IEEE Registration Authority", "8c:1f:64": "IEEE Registration Authority",
 }
 
-// LookupVendor looks up the vendor for a MAC address.
+// registry holds the cached IEEE assignments, keyed by uppercase hex prefix
+// of 6, 7 or 9 digits.
+var registry struct {
+	mu      sync.Mutex
+	loaded  bool
+	vendors map[string]string
+}
+
+// LookupVendor looks up the vendor for a MAC address. The cached IEEE
+// registry is consulted first, matching 36-bit and 28-bit assignments
+// before 24-bit ones; the built-in table is the fallback.
 func LookupVendor(mac string) string {
-	mac = strings.ToLower(mac)
-	mac = strings.ReplaceAll(mac, "-", ":")
-	parts := strings.Split(mac, ":")
-	if len(parts) < 3 {
+	hex := strings.ToUpper(strings.NewReplacer(":", "", "-", "", ".", "").Replace(mac))
+	if len(hex) < 6 {
 		return ""
 	}
-	oui := strings.Join(parts[:3], ":")
-	if vendor, ok := ouiDatabase[oui]; ok {
-		return vendor
+
+	if vendors := cachedRegistry(); vendors != nil {
+		for _, n := range prefixLengths {
+			if len(hex) < n {
+				continue
+			}
+			if vendor, ok := vendors[hex[:n]]; ok {
+				return vendor
+			}
+		}
+	}
+
+	oui := strings.ToLower(hex[0:2] + ":" + hex[2:4] + ":" + hex[4:6])
+	return ouiDatabase[oui]
+}
+
+// OUICacheFile returns the path where the downloaded registry is cached.
+func OUICacheFile() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine cache directory: %w", err)
+	}
+	return filepath.Join(dir, "nns", "oui.txt"), nil
+}
+
+// cachedRegistry loads the cached registry once. It returns nil if no
+// cache exists.
+func cachedRegistry() map[string]string {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if !registry.loaded {
+		registry.loaded = true
+		if path, err := OUICacheFile(); err == nil {
+			if f, err := os.Open(path); err == nil {
+				registry.vendors, _ = parseOUICache(f)
+				f.Close()
+			}
+		}
+	}
+	return registry.vendors
+}
+
+// UpdateOUIDatabase downloads the IEEE OUI registry and caches it to disk
+// for offline vendor lookups. An empty url fetches all DefaultOUIURLs;
+// otherwise url may point to a single registry CSV or oui.txt file.
+// It returns the number of cached assignments.
+func UpdateOUIDatabase(ctx context.Context, url string) (int, error) {
+	urls := DefaultOUIURLs
+	if url != "" {
+		urls = []string{url}
+	}
+
+	client := &http.Client{Timeout: 2 * time.Minute}
+	vendors := make(map[string]string)
+	for _, u := range urls {
+		if err := fetchRegistry(ctx, client, u, vendors); err != nil {
+			return 0, err
+		}
+	}
+	if len(vendors) == 0 {
+		return 0, errors.New("no OUI assignments found in registry")
+	}
+
+	path, err := OUICacheFile()
+	if err != nil {
+		return 0, err
+	}
+	if err := writeOUICache(path, vendors); err != nil {
+		return 0, err
+	}
+
+	registry.mu.Lock()
+	registry.loaded = true
+	registry.vendors = vendors
+	registry.mu.Unlock()
+
+	return len(vendors), nil
+}
+
+// fetchRegistry downloads one registry file and merges it into vendors.
+func fetchRegistry(ctx context.Context, client *http.Client, url string, vendors map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+
+	if err := parseOUIRegistry(resp.Body, vendors); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", url, err)
+	}
+	return nil
+}
+
+// parseOUIRegistry reads an IEEE registry in CSV form
+// ("Registry,Assignment,Organization Name,...") or the classic oui.txt
+// form ("00-22-72   (hex)\t\tVendor") into vendors.
+func parseOUIRegistry(r io.Reader, vendors map[string]string) error {
+	br := bufio.NewReader(r)
+	peek, _ := br.Peek(64)
+	if strings.HasPrefix(strings.TrimPrefix(string(peek), "\ufeff"), "Registry,") {
+		return parseOUICSV(br, vendors)
+	}
+
+	scanner := bufio.NewScanner(br)
+	for scanner.Scan() {
+		line := scanner.Text()
+		idx := strings.Index(line, "(hex)")
+		if idx < 0 {
+			continue
+		}
+		prefix := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(line[:idx]), "-", ""))
+		vendor := strings.TrimSpace(line[idx+len("(hex)"):])
+		if len(prefix) == 6 && vendor != "" {
+			vendors[prefix] = vendor
+		}
+	}
+	return scanner.Err()
+}
+
+// parseOUICSV reads the IEEE CSV registries, where the assignment length
+// (6, 7 or 9 hex digits) identifies MA-L, MA-M or MA-S blocks.
+func parseOUICSV(r io.Reader, vendors map[string]string) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	first := true
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if first {
+			first = false
+			continue
+		}
+		if len(record) < 3 {
+			continue
+		}
+		prefix := strings.ToUpper(strings.TrimSpace(record[1]))
+		vendor := strings.TrimSpace(record[2])
+		switch len(prefix) {
+		case 6, 7, 9:
+			if vendor != "" {
+				vendors[prefix] = vendor
+			}
+		}
+	}
+}
+
+// writeOUICache stores vendors as "PREFIX<TAB>Vendor" lines.
+func writeOUICache(path string, vendors map[string]string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	prefixes := make([]string, 0, len(vendors))
+	for p := range vendors {
+		prefixes = append(prefixes, p)
+	}
+	sort.Strings(prefixes)
+
+	var b strings.Builder
+	for _, p := range prefixes {
+		b.WriteString(p)
+		b.WriteByte('\t')
+		b.WriteString(vendors[p])
+		b.WriteByte('\n')
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write OUI cache: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// parseOUICache reads the cache written by writeOUICache.
+func parseOUICache(r io.Reader) (map[string]string, error) {
+	vendors := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		prefix, vendor, ok := strings.Cut(scanner.Text(), "\t")
+		if ok && prefix != "" && vendor != "" {
+			vendors[prefix] = vendor
+		}
 	}
-	return ""
+	return vendors, scanner.Err()
 }
@@ -0,0 +1,284 @@
+package arp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// probeSettle is how long to wait after the last probe for ARP replies to
+// land in the OS cache before re-reading it.
+const probeSettle = 1500 * time.Millisecond
+
+// ProbeResult holds the entries found by Probe.
+type ProbeResult struct {
+	Entries []Entry
+	Subnet  *net.IPNet // the probed range, for filtering later table reads
+
+	// Fallback explains why ARP requests were not sent directly and UDP
+	// datagrams were used instead (e.g. no root privileges). Empty if no
+	// fallback occurred.
+	Fallback string
+}
+
+// Probe actively resolves every host of a directly connected subnet, then
+// re-reads the table and returns the entries inside cidr.
+//
+// When a raw link-layer socket is available, each host is sent an ARP
+// request and the replies are recorded directly, since the OS does not cache
+// replies to requests it did not send. Without the privileges for that, each
+// host is sent a single UDP datagram to the discard port instead: the OS must
+// resolve the address before it can transmit, so it sends the ARP request
+// and caches the reply itself.
+func Probe(ctx context.Context, cidr string) (*ProbeResult, error) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		if ip := net.ParseIP(cidr); ip != nil {
+			_, network, err = net.ParseCIDR(cidr + "/32")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR: %w", err)
+		}
+	}
+	if network.IP.To4() == nil {
+		return nil, errors.New("ARP probing only supports IPv4 subnets (IPv6 uses NDP)")
+	}
+
+	ifi, local, err := localSubnet(network)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := probeTargets(network, local)
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no probe targets in %s", cidr)
+	}
+
+	result := &ProbeResult{}
+	var replies map[string]net.HardwareAddr
+	conn, err := OpenConn(ifi)
+	switch {
+	case err == nil:
+		replies, err = probeARP(ctx, conn, ifi.HardwareAddr, local.IP, targets)
+		conn.Close()
+	case errors.Is(err, ErrNotPermitted) || errors.Is(err, ErrUnsupported):
+		result.Fallback = fmt.Sprintf("%v; falling back to UDP probes", err)
+		err = probeUDP(ctx, ifi.Name, targets)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := GetTable()
+	if err != nil {
+		return nil, err
+	}
+
+	result.Subnet = network
+	result.Entries = mergeReplies(FilterBySubnet(entries, network), replies, ifi.Name)
+	return result, nil
+}
+
+// probeARP broadcasts an ARP request for every target and collects the
+// replies until probeSettle after the last request.
+func probeARP(ctx context.Context, conn Conn, srcMAC net.HardwareAddr, srcIP net.IP, targets []net.IP) (map[string]net.HardwareAddr, error) {
+	wanted := make(map[string]bool, len(targets))
+	for _, ip := range targets {
+		wanted[ip.String()] = true
+	}
+
+	var mu sync.Mutex
+	replies := make(map[string]net.HardwareAddr)
+	stop := make(chan struct{})
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		buf := make([]byte, 1500)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			n, err := conn.ReadFrame(buf)
+			if err != nil {
+				if errors.Is(err, ErrTimeout) {
+					continue
+				}
+				return
+			}
+			if ip, mac, ok := ParseReply(buf[:n]); ok && wanted[ip] {
+				mu.Lock()
+				replies[ip] = mac
+				mu.Unlock()
+			}
+		}
+	}()
+	defer func() {
+		close(stop)
+		<-readDone
+	}()
+
+	for i, ip := range targets {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		if err := conn.WriteFrame(BuildRequest(srcMAC, srcIP, ip)); err != nil {
+			return nil, fmt.Errorf("failed to send ARP request: %w", err)
+		}
+		// Pace bursts so the socket buffer and the LAN are not flooded.
+		if i%64 == 63 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(probeSettle):
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return maps.Clone(replies), nil
+}
+
+// probeUDP sends every target a datagram so the OS resolves it with ARP,
+// then waits probeSettle for the replies to reach the cache.
+func probeUDP(ctx context.Context, ifaceName string, targets []net.IP) error {
+	for i, ip := range targets {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if err := sendProbe(ip); err != nil {
+			if errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EACCES) {
+				return fmt.Errorf("probing %s on %s was denied (firewall): %w", ip, ifaceName, err)
+			}
+			// Unreachable hosts and full buffers are expected; keep going.
+		}
+		// Pace the burst so the OS neighbor queue is not overrun.
+		if i%32 == 31 {
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(probeSettle):
+	}
+	return nil
+}
+
+// mergeReplies adds hosts that answered a raw ARP request but are missing
+// from the OS table, and returns the combined entries sorted by IP.
+func mergeReplies(entries []Entry, replies map[string]net.HardwareAddr, ifaceName string) []Entry {
+	known := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		known[e.IP] = true
+	}
+	for ip, hw := range replies {
+		if known[ip] {
+			continue
+		}
+		mac := hw.String()
+		entries = append(entries, Entry{
+			IP:        ip,
+			MAC:       mac,
+			Vendor:    LookupVendor(mac),
+			Interface: ifaceName,
+			Type:      "probed",
+		})
+	}
+	sortEntries(entries)
+	return entries
+}
+
+// localSubnet finds the interface directly attached to network and returns
+// it with its IPv4 subnet (with the interface's own address as IP).
+func localSubnet(network *net.IPNet) (*net.Interface, *net.IPNet, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list interfaces: %w", err)
+	}
+
+	for i := range ifaces {
+		ifi := &ifaces[i]
+		if ifi.Flags&net.FlagUp == 0 || ifi.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := ifi.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.To4() == nil {
+				continue
+			}
+			if ipNet.Contains(network.IP) || network.Contains(ipNet.IP) {
+				return ifi, &net.IPNet{IP: ipNet.IP.To4(), Mask: ipNet.Mask}, nil
+			}
+		}
+	}
+
+	return nil, nil, fmt.Errorf("cannot determine interface for %s: no directly connected subnet (ARP only works on the local network)", network)
+}
+
+// probeTargets lists the host addresses that are both in network and on the
+// local subnet, excluding the local address and the subnet's network and
+// broadcast addresses.
+func probeTargets(network, local *net.IPNet) []net.IP {
+	localNet := &net.IPNet{IP: local.IP.Mask(local.Mask), Mask: local.Mask}
+	broadcast := make(net.IP, 4)
+	for i := range broadcast {
+		broadcast[i] = localNet.IP[i] | ^localNet.Mask[i]
+	}
+
+	targets := make([]net.IP, 0)
+	ip := network.IP.To4().Mask(network.Mask)
+	for ; network.Contains(ip); ip = nextIP(ip) {
+		if !localNet.Contains(ip) || ip.Equal(local.IP) {
+			continue
+		}
+		if ones, bits := localNet.Mask.Size(); bits-ones > 1 && (ip.Equal(localNet.IP) || ip.Equal(broadcast)) {
+			continue
+		}
+		targets = append(targets, ip)
+	}
+	return targets
+}
+
+// nextIP returns ip+1, or nil after the last IPv4 address.
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] > 0 {
+			return next
+		}
+	}
+	return nil
+}
+
+// sendProbe sends one datagram to ip's discard port so the OS resolves it
+// with ARP.
+func sendProbe(ip net.IP) error {
+	conn, err := net.DialUDP("udp4", nil, &net.UDPAddr{IP: ip, Port: 9})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte{0})
+	return err
+}
@@ -0,0 +1,89 @@
+package arp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+)
+
+const (
+	etherTypeARP  = 0x0806
+	opRequest     = 1
+	opReply       = 2
+	frameLen      = 42 // Ethernet header (14) + ARP payload (28)
+	minEtherFrame = 60 // minimum Ethernet frame size without FCS
+)
+
+var broadcastMAC = net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+var (
+	// ErrTimeout is returned by Conn.ReadFrame when no frame arrived in time.
+	ErrTimeout = errors.New("arp read timeout")
+
+	// ErrNotPermitted is returned by OpenConn when the process lacks the
+	// privileges needed for a raw link-layer socket.
+	ErrNotPermitted = errors.New("raw ARP requires administrator/root privileges")
+
+	// ErrUnsupported is returned by OpenConn on platforms without a raw
+	// link-layer socket implementation.
+	ErrUnsupported = errors.New("raw ARP is not supported on this platform")
+)
+
+// Conn is a raw link-layer socket bound to one interface, sending and
+// receiving Ethernet frames carrying ARP.
+type Conn interface {
+	// ReadFrame reads one Ethernet frame. It returns ErrTimeout
+	// periodically so callers can check for cancellation.
+	ReadFrame(buf []byte) (int, error)
+	// WriteFrame broadcasts one Ethernet frame.
+	WriteFrame(frame []byte) error
+	Close() error
+}
+
+// BuildRequest crafts a broadcast Ethernet frame carrying an ARP who-has
+// request for targetIP.
+func BuildRequest(srcMAC net.HardwareAddr, srcIP, targetIP net.IP) []byte {
+	frame := make([]byte, minEtherFrame)
+
+	// Ethernet header
+	copy(frame[0:6], broadcastMAC)
+	copy(frame[6:12], srcMAC)
+	binary.BigEndian.PutUint16(frame[12:14], etherTypeARP)
+
+	// ARP payload
+	arpPkt := frame[14:]
+	binary.BigEndian.PutUint16(arpPkt[0:2], 1)      // hardware type: Ethernet
+	binary.BigEndian.PutUint16(arpPkt[2:4], 0x0800) // protocol type: IPv4
+	arpPkt[4] = 6                                   // hardware address length
+	arpPkt[5] = 4                                   // protocol address length
+	binary.BigEndian.PutUint16(arpPkt[6:8], opRequest)
+	copy(arpPkt[8:14], srcMAC)
+	copy(arpPkt[14:18], srcIP.To4())
+	// target hardware address left zeroed
+	copy(arpPkt[24:28], targetIP.To4())
+
+	return frame
+}
+
+// ParseReply extracts the sender IP and MAC from an ARP reply frame.
+func ParseReply(frame []byte) (string, net.HardwareAddr, bool) {
+	if len(frame) < frameLen {
+		return "", nil, false
+	}
+	if binary.BigEndian.Uint16(frame[12:14]) != etherTypeARP {
+		return "", nil, false
+	}
+	arpPkt := frame[14:]
+	if binary.BigEndian.Uint16(arpPkt[0:2]) != 1 || binary.BigEndian.Uint16(arpPkt[2:4]) != 0x0800 ||
+		arpPkt[4] != 6 || arpPkt[5] != 4 {
+		return "", nil, false
+	}
+	if binary.BigEndian.Uint16(arpPkt[6:8]) != opReply {
+		return "", nil, false
+	}
+
+	mac := net.HardwareAddr(bytes.Clone(arpPkt[8:14]))
+	ip := net.IP(bytes.Clone(arpPkt[14:18]))
+	return ip.String(), mac, true
+}
@@ -0,0 +1,74 @@
+package arp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// packetConn is an AF_PACKET socket receiving ARP frames on one interface.
+type packetConn struct {
+	fd      int
+	ifindex int
+}
+
+// OpenConn opens a raw AF_PACKET socket bound to ifi.
+func OpenConn(ifi *net.Interface) (Conn, error) {
+	proto := htons(etherTypeARP)
+	fd, err := syscall.Socket(syscall.AF_PACKET, syscall.SOCK_RAW, int(proto))
+	if err != nil {
+		if errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EACCES) {
+			return nil, fmt.Errorf("%w (%v)", ErrNotPermitted, err)
+		}
+		return nil, fmt.Errorf("failed to open raw socket: %w", err)
+	}
+
+	if err := syscall.Bind(fd, &syscall.SockaddrLinklayer{Protocol: proto, Ifindex: ifi.Index}); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("failed to bind to %s: %w", ifi.Name, err)
+	}
+
+	// Wake up periodically so the reader can notice it has been stopped.
+	tv := syscall.Timeval{Usec: 100000}
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("failed to set socket timeout: %w", err)
+	}
+
+	return &packetConn{fd: fd, ifindex: ifi.Index}, nil
+}
+
+func (c *packetConn) ReadFrame(buf []byte) (int, error) {
+	n, _, err := syscall.Recvfrom(c.fd, buf, 0)
+	if err != nil {
+		if errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EINTR) {
+			return 0, ErrTimeout
+		}
+		return 0, err
+	}
+	return n, nil
+}
+
+func (c *packetConn) WriteFrame(frame []byte) error {
+	addr := &syscall.SockaddrLinklayer{
+		Protocol: htons(etherTypeARP),
+		Ifindex:  c.ifindex,
+		Halen:    6,
+	}
+	copy(addr.Addr[:], broadcastMAC)
+	return syscall.Sendto(c.fd, frame, 0, addr)
+}
+
+func (c *packetConn) Close() error {
+	return syscall.Close(c.fd)
+}
+
+// htons converts a uint16 to network byte order, returned as the value a
+// native-endian read of those bytes yields (what the kernel expects).
+func htons(v uint16) uint16 {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], v)
+	return binary.NativeEndian.Uint16(b[:])
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package arp
+
+import (
+	"fmt"
+	"net"
+	"runtime"
+)
+
+// OpenConn is only implemented on Linux, where AF_PACKET sockets allow
+// sending raw Ethernet frames without extra drivers.
+func OpenConn(ifi *net.Interface) (Conn, error) {
+	return nil, fmt.Errorf("%w (%s)", ErrUnsupported, runtime.GOOS)
+}
@@ -2,10 +2,15 @@
 package blacklist
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net"
+	"net/url"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -22,56 +27,111 @@ const (
 
 // Blacklist represents a single blacklist service.
 type Blacklist struct {
-	Name        string
-	Zone        string
-	Type        ListType
-	Description string
-	Website     string
-	Category    string // spam, malware, phishing, etc.
+	Name        string   `json:"name"`
+	Zone        string   `json:"zone"`
+	Type        ListType `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Website     string   `json:"website,omitempty"`
+	Category    string   `json:"category"`             // spam, malware, phishing, etc.
+	IPv6        bool     `json:"ipv6"`                 // Zone accepts RFC 5782 IPv6 queries
+	Weight      float64  `json:"weight,omitempty"`     // Relative importance in the score; 0 means 1
+	DelistURL   string   `json:"delist_url,omitempty"` // Lookup/removal page; %s is replaced with the target
+}
+
+// delistURL returns where to request removal of target from bl.
+func (bl Blacklist) delistURL(target string) string {
+	switch {
+	case strings.Contains(bl.DelistURL, "%s"):
+		return strings.ReplaceAll(bl.DelistURL, "%s", url.QueryEscape(target))
+	case bl.DelistURL != "":
+		return bl.DelistURL
+	default:
+		return bl.Website
+	}
 }
 
 // CommonBlacklists are well-known DNS blacklists.
 var CommonBlacklists = []Blacklist{
 	// IP-based DNSBLs
-	{Name: "Spamhaus ZEN", Zone: "zen.spamhaus.org", Type: TypeDNSBL, Category: "spam", Description: "Combined Spamhaus blocklist"},
-	{Name: "Spamhaus SBL", Zone: "sbl.spamhaus.org", Type: TypeDNSBL, Category: "spam", Description: "Spamhaus Block List"},
-	{Name: "Spamhaus XBL", Zone: "xbl.spamhaus.org", Type: TypeDNSBL, Category: "exploit", Description: "Exploits Block List"},
-	{Name: "Spamcop", Zone: "bl.spamcop.net", Type: TypeDNSBL, Category: "spam", Description: "SpamCop Blocking List"},
-	{Name: "Barracuda", Zone: "b.barracudacentral.org", Type: TypeDNSBL, Category: "spam", Description: "Barracuda Reputation"},
-	{Name: "SORBS", Zone: "dnsbl.sorbs.net", Type: TypeDNSBL, Category: "spam", Description: "SORBS aggregated list"},
-	{Name: "UCEPROTECT L1", Zone: "dnsbl-1.uceprotect.net", Type: TypeDNSBL, Category: "spam", Description: "UCEPROTECT Level 1"},
-	{Name: "UCEPROTECT L2", Zone: "dnsbl-2.uceprotect.net", Type: TypeDNSBL, Category: "spam", Description: "UCEPROTECT Level 2"},
-	{Name: "SpamRATS", Zone: "noptr.spamrats.com", Type: TypeDNSBL, Category: "spam", Description: "SpamRATS list"},
-	{Name: "JustSpam", Zone: "dnsbl.justspam.org", Type: TypeDNSBL, Category: "spam", Description: "JustSpam.org"},
+	{Name: "Spamhaus ZEN", Zone: "zen.spamhaus.org", Type: TypeDNSBL, Category: "spam", Description: "Combined Spamhaus blocklist", IPv6: true, Weight: 3,
+		Website: "https://www.spamhaus.org", DelistURL: "https://check.spamhaus.org/"},
+	{Name: "Spamhaus SBL", Zone: "sbl.spamhaus.org", Type: TypeDNSBL, Category: "spam", Description: "Spamhaus Block List", IPv6: true, Weight: 3,
+		Website: "https://www.spamhaus.org", DelistURL: "https://check.spamhaus.org/"},
+	{Name: "Spamhaus XBL", Zone: "xbl.spamhaus.org", Type: TypeDNSBL, Category: "exploit", Description: "Exploits Block List", IPv6: true, Weight: 3,
+		Website: "https://www.spamhaus.org", DelistURL: "https://check.spamhaus.org/"},
+	{Name: "Spamcop", Zone: "bl.spamcop.net", Type: TypeDNSBL, Category: "spam", Description: "SpamCop Blocking List", Weight: 2,
+		Website: "https://www.spamcop.net", DelistURL: "https://www.spamcop.net/bl.shtml?%s"},
+	{Name: "Barracuda", Zone: "b.barracudacentral.org", Type: TypeDNSBL, Category: "spam", Description: "Barracuda Reputation", Weight: 2,
+		Website: "https://www.barracudacentral.org", DelistURL: "https://www.barracudacentral.org/rbl/removal-request"},
+	{Name: "SORBS", Zone: "dnsbl.sorbs.net", Type: TypeDNSBL, Category: "spam", Description: "SORBS aggregated list", Weight: 1,
+		Website: "http://www.sorbs.net"},
+	{Name: "UCEPROTECT L1", Zone: "dnsbl-1.uceprotect.net", Type: TypeDNSBL, Category: "spam", Description: "UCEPROTECT Level 1", Weight: 1,
+		Website: "https://www.uceprotect.net", DelistURL: "https://www.uceprotect.net/en/rblcheck.php?ipr=%s"},
+	{Name: "UCEPROTECT L2", Zone: "dnsbl-2.uceprotect.net", Type: TypeDNSBL, Category: "spam", Description: "UCEPROTECT Level 2 (whole allocations)", Weight: 0.5,
+		Website: "https://www.uceprotect.net", DelistURL: "https://www.uceprotect.net/en/rblcheck.php?ipr=%s"},
+	{Name: "UCEPROTECT L3", Zone: "dnsbl-3.uceprotect.net", Type: TypeDNSBL, Category: "spam", Description: "UCEPROTECT Level 3 (whole ASNs)", Weight: 0.25,
+		Website: "https://www.uceprotect.net", DelistURL: "https://www.uceprotect.net/en/rblcheck.php?ipr=%s"},
+	{Name: "SpamRATS", Zone: "noptr.spamrats.com", Type: TypeDNSBL, Category: "spam", Description: "SpamRATS list", Weight: 0.5,
+		Website: "https://www.spamrats.com", DelistURL: "https://www.spamrats.com/lookup.php?ip=%s"},
+	{Name: "JustSpam", Zone: "dnsbl.justspam.org", Type: TypeDNSBL, Category: "spam", Description: "JustSpam.org", Weight: 0.5,
+		Website: "http://www.justspam.org"},
 	// Domain-based URIBLs
-	{Name: "Spamhaus DBL", Zone: "dbl.spamhaus.org", Type: TypeURIBL, Category: "spam", Description: "Domain Block List"},
-	{Name: "SURBL Multi", Zone: "multi.surbl.org", Type: TypeSURBL, Category: "spam", Description: "SURBL combined list"},
-	{Name: "URIBL Black", Zone: "black.uribl.com", Type: TypeURIBL, Category: "spam", Description: "URIBL black list"},
+	{Name: "Spamhaus DBL", Zone: "dbl.spamhaus.org", Type: TypeURIBL, Category: "spam", Description: "Domain Block List", Weight: 3,
+		Website: "https://www.spamhaus.org", DelistURL: "https://check.spamhaus.org/"},
+	{Name: "SURBL Multi", Zone: "multi.surbl.org", Type: TypeSURBL, Category: "spam", Description: "SURBL combined list",
+		Website: "https://www.surbl.org", DelistURL: "https://www.surbl.org/surbl-analysis"},
+	{Name: "URIBL Black", Zone: "black.uribl.com", Type: TypeURIBL, Category: "spam", Description: "URIBL black list",
+		Website: "https://www.uribl.com", DelistURL: "https://admin.uribl.com/"},
 }
 
 // ListingResult represents a check result for a single blacklist.
 type ListingResult struct {
-	Blacklist  Blacklist
-	Listed     bool
-	ReturnCode string // The A record returned (e.g., 127.0.0.2)
-	Reason     string // TXT record explanation
-	LookupTime time.Duration
-	Error      error
+	Blacklist  Blacklist     `json:"blacklist"`
+	Listed     bool          `json:"listed"`
+	ReturnCode string        `json:"return_code,omitempty"` // The A record returned (e.g., 127.0.0.2)
+	Reason     string        `json:"reason,omitempty"`      // TXT record explanation
+	DelistURL  string        `json:"delist_url,omitempty"`  // Where to request removal
+	Weight     float64       `json:"weight"`                // Effective score weight, after Options.Weights
+	LookupTime time.Duration `json:"lookup_time"`
+	Error      error         `json:"-"`
+}
+
+// MarshalJSON encodes the listing with its error as a string.
+func (l ListingResult) MarshalJSON() ([]byte, error) {
+	type listing ListingResult
+	out := struct {
+		listing
+		Error string `json:"error,omitempty"`
+	}{listing: listing(l)}
+	if l.Error != nil {
+		out.Error = l.Error.Error()
+	}
+	return json.Marshal(out)
 }
 
 // CheckResult contains aggregated blacklist check results.
 type CheckResult struct {
-	Target      string
-	TargetType  string // "ip" or "domain"
-	TotalChecks int
-	TotalListed int
-	Listings    []ListingResult
-	CleanLists  []Blacklist
-	Score       int    // 0-100 reputation score
-	Risk        string // low, medium, high, critical
-	StartTime   time.Time
-	Duration    time.Duration
-	Categories  map[string]int // category -> listing count
+	Target      string          `json:"target"`
+	TargetType  string          `json:"target_type"` // "ip" or "domain"
+	TotalChecks int             `json:"total_checks"`
+	TotalListed int             `json:"total_listed"`
+	Skipped     int             `json:"skipped"` // Lists that cannot answer for this target (IPv4-only lists for IPv6)
+	Listings    []ListingResult `json:"listings"`
+	CleanLists  []Blacklist     `json:"-"`
+	Score       int             `json:"score"` // 0-100 reputation score
+	Risk        string          `json:"risk"`  // low, medium, high, critical
+	StartTime   time.Time       `json:"start_time"`
+	Duration    time.Duration   `json:"duration"`
+	Categories  map[string]int  `json:"categories"` // category -> listing count
+}
+
+// ToJSON returns the check result as indented JSON.
+func (r *CheckResult) ToJSON() (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
 }
 
 // Options configures blacklist checking.
@@ -79,7 +139,8 @@ type Options struct {
 	Blacklists  []Blacklist
 	Timeout     time.Duration
 	Concurrency int
-	IncludeTXT  bool // Query TXT records for reasons
+	IncludeTXT  bool               // Query TXT records for reasons
+	Weights     map[string]float64 // Zone -> score weight, overriding Blacklist.Weight
 }
 
 // DefaultOptions returns sensible defaults.
@@ -116,23 +177,21 @@ func NewChecker(opts Options) *Checker {
 	}
 }
 
-// CheckIP checks an IP address against all configured blacklists.
+// CheckIP checks an IP address against all configured blacklists. IPv6
+// addresses are only checked against lists that support them.
 func (c *Checker) CheckIP(ctx context.Context, ip string) (*CheckResult, error) {
 	parsedIP := net.ParseIP(ip)
 	if parsedIP == nil {
 		return nil, fmt.Errorf("invalid IP address: %s", ip)
 	}
 
-	// Only IPv4 supported for DNSBL
-	ipv4 := parsedIP.To4()
-	if ipv4 == nil {
-		return nil, fmt.Errorf("IPv6 not supported for DNSBL, use IPv4")
-	}
-
 	// Reverse the IP for DNSBL lookup
-	reversed := fmt.Sprintf("%d.%d.%d.%d", ipv4[3], ipv4[2], ipv4[1], ipv4[0])
+	reversed, err := ReverseIP(ip)
+	if err != nil {
+		return nil, err
+	}
 
-	return c.check(ctx, reversed, ip, "ip", TypeDNSBL)
+	return c.check(ctx, reversed, ip, "ip", TypeDNSBL, parsedIP.To4() == nil)
 }
 
 // CheckDomain checks a domain against URI blacklists.
@@ -142,15 +201,16 @@ func (c *Checker) CheckDomain(ctx context.Context, domain string) (*CheckResult,
 	domain = strings.TrimSuffix(domain, "/")
 	domain = strings.Split(domain, "/")[0] // Remove path
 
-	return c.check(ctx, domain, domain, "domain", TypeURIBL)
+	return c.check(ctx, domain, domain, "domain", TypeURIBL, false)
 }
 
-func (c *Checker) check(ctx context.Context, query, target, targetType string, listType ListType) (*CheckResult, error) {
+func (c *Checker) check(ctx context.Context, query, target, targetType string, listType ListType, ipv6 bool) (*CheckResult, error) {
 	start := time.Now()
 	result := &CheckResult{
 		Target:     target,
 		TargetType: targetType,
 		StartTime:  start,
+		Listings:   []ListingResult{},
 		Categories: make(map[string]int),
 	}
 
@@ -158,6 +218,10 @@ func (c *Checker) check(ctx context.Context, query, target, targetType string, l
 	var applicableLists []Blacklist
 	for _, bl := range c.opts.Blacklists {
 		if listType == TypeDNSBL && bl.Type == TypeDNSBL {
+			if ipv6 && !bl.IPv6 {
+				result.Skipped++
+				continue
+			}
 			applicableLists = append(applicableLists, bl)
 		} else if listType == TypeURIBL && (bl.Type == TypeURIBL || bl.Type == TypeSURBL) {
 			applicableLists = append(applicableLists, bl)
@@ -178,6 +242,9 @@ func (c *Checker) check(ctx context.Context, query, target, targetType string, l
 			defer func() { <-semaphore }()
 
 			listing := c.checkSingleList(ctx, query, bl)
+			if listing.Listed {
+				listing.DelistURL = bl.delistURL(target)
+			}
 
 			mu.Lock()
 			result.Listings = append(result.Listings, listing)
@@ -211,15 +278,16 @@ func (c *Checker) checkSingleList(ctx context.Context, query string, bl Blacklis
 	start := time.Now()
 	result := ListingResult{
 		Blacklist: bl,
+		Weight:    c.weight(bl),
 	}
 
 	lookupName := query + "." + bl.Zone
 
-	ctx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	lookupCtx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
 	defer cancel()
 
 	// Lookup A record
-	ips, err := c.resolver.LookupIP(ctx, "ip4", lookupName)
+	ips, err := c.resolver.LookupIP(lookupCtx, "ip4", lookupName)
 	result.LookupTime = time.Since(start)
 
 	if err != nil {
@@ -236,9 +304,12 @@ func (c *Checker) checkSingleList(ctx context.Context, query string, bl Blacklis
 		result.Listed = true
 		result.ReturnCode = ips[0].String()
 
-		// Get TXT record for reason
+		// Get TXT record for reason, with its own timeout so a slow A
+		// lookup does not leave it none
 		if c.opts.IncludeTXT {
-			txtRecords, err := c.resolver.LookupTXT(ctx, lookupName)
+			txtCtx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+			txtRecords, err := c.resolver.LookupTXT(txtCtx, lookupName)
+			cancel()
 			if err == nil && len(txtRecords) > 0 {
 				result.Reason = strings.Join(txtRecords, " ")
 			}
@@ -248,6 +319,17 @@ func (c *Checker) checkSingleList(ctx context.Context, query string, bl Blacklis
 	return result
 }
 
+// weight returns the score weight of bl, honouring Options.Weights.
+func (c *Checker) weight(bl Blacklist) float64 {
+	if w, ok := c.opts.Weights[bl.Zone]; ok {
+		return w
+	}
+	if bl.Weight > 0 {
+		return bl.Weight
+	}
+	return 1
+}
+
 func (c *Checker) calculateScore(result *CheckResult) {
 	if result.TotalChecks == 0 {
 		result.Score = 100
@@ -255,18 +337,26 @@ func (c *Checker) calculateScore(result *CheckResult) {
 		return
 	}
 
-	// Base score calculation
-	listingRatio := float64(result.TotalListed) / float64(result.TotalChecks)
-	result.Score = int(100 * (1 - listingRatio))
-
-	// Adjust for critical lists (Spamhaus carries more weight)
+	// Score is the weighted share of answering lists that do not list the
+	// target, so a listing on an authoritative list costs more than one on
+	// a noisy list.
+	var total, listed float64
 	for _, listing := range result.Listings {
+		if listing.Error != nil {
+			continue
+		}
+		w := c.weight(listing.Blacklist)
+		total += w
 		if listing.Listed {
-			if strings.Contains(listing.Blacklist.Name, "Spamhaus") {
-				result.Score -= 10
-			}
+			listed += w
 		}
 	}
+	if total > 0 {
+		result.Score = int(100 * (1 - listed/total))
+	} else {
+		listingRatio := float64(result.TotalListed) / float64(result.TotalChecks)
+		result.Score = int(100 * (1 - listingRatio))
+	}
 
 	if result.Score < 0 {
 		result.Score = 0
@@ -285,6 +375,91 @@ func (c *Checker) calculateScore(result *CheckResult) {
 	}
 }
 
+// ParseBlacklists reads blacklists, one per line, in the format
+// "zone|type|weight|name|category|delist url". Only the zone is required; type
+// defaults to dnsbl, weight to 1 and name to the zone. Blank lines and lines
+// starting with # are ignored.
+func ParseBlacklists(r io.Reader) ([]Blacklist, error) {
+	var lists []Blacklist
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "|")
+		if len(fields) > 6 {
+			return nil, fmt.Errorf("line %d: expected zone|type|weight|name|category|delist url", lineNum)
+		}
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		field := func(i int) string {
+			if i < len(fields) {
+				return fields[i]
+			}
+			return ""
+		}
+
+		bl := Blacklist{
+			Zone:      strings.TrimSuffix(field(0), "."),
+			Type:      TypeDNSBL,
+			Name:      field(3),
+			Category:  field(4),
+			DelistURL: field(5),
+		}
+		if bl.Zone == "" {
+			return nil, fmt.Errorf("line %d: missing zone", lineNum)
+		}
+		switch t := ListType(strings.ToLower(field(1))); t {
+		case "":
+		case TypeDNSBL, TypeURIBL, TypeSURBL:
+			bl.Type = t
+		default:
+			return nil, fmt.Errorf("line %d: unknown list type %q", lineNum, field(1))
+		}
+		if w := field(2); w != "" {
+			weight, err := strconv.ParseFloat(w, 64)
+			if err != nil || weight < 0 {
+				return nil, fmt.Errorf("line %d: invalid weight %q", lineNum, w)
+			}
+			bl.Weight = weight
+		}
+		if bl.Name == "" {
+			bl.Name = bl.Zone
+		}
+		if bl.Category == "" {
+			bl.Category = "custom"
+		}
+		lists = append(lists, bl)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
+
+// MergeBlacklists returns base with extra appended. An extra list with the
+// same zone as a base list replaces it.
+func MergeBlacklists(base, extra []Blacklist) []Blacklist {
+	merged := make([]Blacklist, 0, len(base)+len(extra))
+	index := make(map[string]int)
+	for _, bl := range append(append([]Blacklist{}, base...), extra...) {
+		zone := strings.ToLower(bl.Zone)
+		if i, ok := index[zone]; ok {
+			merged[i] = bl
+			continue
+		}
+		index[zone] = len(merged)
+		merged = append(merged, bl)
+	}
+	return merged
+}
+
 // Format returns formatted check results.
 func (r *CheckResult) Format() string {
 	var sb strings.Builder
@@ -306,6 +481,9 @@ func (r *CheckResult) Format() string {
 	sb.WriteString(fmt.Sprintf("Score:     %d/100\n", r.Score))
 	sb.WriteString(fmt.Sprintf("Risk:      %s %s\n", riskIcon, strings.ToUpper(r.Risk)))
 	sb.WriteString(fmt.Sprintf("Listings:  %d of %d blacklists\n", r.TotalListed, r.TotalChecks))
+	if r.Skipped > 0 {
+		sb.WriteString(fmt.Sprintf("Skipped:   %d IPv4-only blacklists\n", r.Skipped))
+	}
 
 	if len(r.Categories) > 0 && r.TotalListed > 0 {
 		sb.WriteString("Categories: ")
@@ -325,11 +503,12 @@ func (r *CheckResult) Format() string {
 				sb.WriteString(fmt.Sprintf("    Zone: %s\n", listing.Blacklist.Zone))
 				sb.WriteString(fmt.Sprintf("    Code: %s\n", listing.ReturnCode))
 				if listing.Reason != "" {
-					reason := listing.Reason
-					if len(reason) > 60 {
-						reason = reason[:57] + "..."
-					}
-					sb.WriteString(fmt.Sprintf("    Reason: %s\n", reason))
+					sb.WriteString(fmt.Sprintf("    Reason: %s\n", listing.Reason))
+				} else {
+					sb.WriteString("    Reason: (no TXT record published)\n")
+				}
+				if listing.DelistURL != "" {
+					sb.WriteString(fmt.Sprintf("    Delist: %s\n", listing.DelistURL))
 				}
 			}
 		}
@@ -364,6 +543,56 @@ func (r *CheckResult) FormatCompact() string {
 		icon, r.Target, r.TotalListed, r.TotalChecks, r.Risk, r.Score)
 }
 
+// RemediationStep is one entry of a delisting checklist.
+type RemediationStep struct {
+	Blacklist string `json:"blacklist"`
+	Zone      string `json:"zone"`
+	URL       string `json:"url,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+func (s RemediationStep) String() string {
+	where := s.URL
+	if where == "" {
+		where = "contact the list operator (no removal page known)"
+	}
+	line := fmt.Sprintf("[ ] %s (%s): %s", s.Blacklist, s.Zone, where)
+	if s.Reason != "" {
+		line += "\n    Reason: " + s.Reason
+	}
+	return line
+}
+
+// GetRemediation returns a checklist of where to request removal from each
+// blacklist listing the target, most heavily weighted lists first by their
+// effective weight.
+func (r *CheckResult) GetRemediation() []RemediationStep {
+	var listed []ListingResult
+	for _, l := range r.Listings {
+		if l.Listed {
+			listed = append(listed, l)
+		}
+	}
+	sort.SliceStable(listed, func(i, j int) bool {
+		return listed[i].Weight > listed[j].Weight
+	})
+
+	steps := make([]RemediationStep, 0, len(listed))
+	for _, l := range listed {
+		link := l.DelistURL
+		if link == "" {
+			link = l.Blacklist.delistURL(r.Target)
+		}
+		steps = append(steps, RemediationStep{
+			Blacklist: l.Blacklist.Name,
+			Zone:      l.Blacklist.Zone,
+			URL:       link,
+			Reason:    l.Reason,
+		})
+	}
+	return steps
+}
+
 // IsClean returns true if the target is not listed on any blacklist.
 func (r *CheckResult) IsClean() bool {
 	return r.TotalListed == 0
@@ -382,36 +611,87 @@ func (r *CheckResult) GetListedBlacklists() []Blacklist {
 
 // CheckMultipleIPs checks multiple IPs concurrently.
 func (c *Checker) CheckMultipleIPs(ctx context.Context, ips []string) map[string]*CheckResult {
+	return c.checkMultiple(ctx, ips, c.CheckIP)
+}
+
+// CheckMultipleDomains checks multiple domains concurrently.
+func (c *Checker) CheckMultipleDomains(ctx context.Context, domains []string) map[string]*CheckResult {
+	return c.checkMultiple(ctx, domains, c.CheckDomain)
+}
+
+// CheckTargets checks a mixed list of IPs and domains concurrently, routing
+// each target by its form.
+func (c *Checker) CheckTargets(ctx context.Context, targets []string) map[string]*CheckResult {
+	return c.checkMultiple(ctx, targets, func(ctx context.Context, target string) (*CheckResult, error) {
+		if net.ParseIP(target) != nil {
+			return c.CheckIP(ctx, target)
+		}
+		return c.CheckDomain(ctx, target)
+	})
+}
+
+func (c *Checker) checkMultiple(ctx context.Context, targets []string, check func(context.Context, string) (*CheckResult, error)) map[string]*CheckResult {
 	results := make(map[string]*CheckResult)
 	var mu sync.Mutex
 	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, c.opts.Concurrency)
 
-	for _, ip := range ips {
+	for _, target := range targets {
 		wg.Add(1)
-		go func(ip string) {
+		go func(target string) {
 			defer wg.Done()
-			result, err := c.CheckIP(ctx, ip)
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			result, err := check(ctx, target)
 			mu.Lock()
 			if err == nil {
-				results[ip] = result
+				results[target] = result
 			}
 			mu.Unlock()
-		}(ip)
+		}(target)
 	}
 
 	wg.Wait()
 	return results
 }
 
-// ReverseIP reverses an IPv4 address for DNSBL lookup.
+// ParseTargets reads one IP or domain per line. Blank lines, lines starting
+// with # and duplicates are skipped.
+func ParseTargets(r io.Reader) ([]string, error) {
+	var targets []string
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || seen[line] {
+			continue
+		}
+		seen[line] = true
+		targets = append(targets, line)
+	}
+	return targets, scanner.Err()
+}
+
+// ReverseIP reverses an IP address for DNSBL lookup. IPv6 addresses are
+// expanded to 32 nibbles and reversed, dot-separated (RFC 5782).
 func ReverseIP(ip string) (string, error) {
 	parsed := net.ParseIP(ip)
 	if parsed == nil {
 		return "", fmt.Errorf("invalid IP")
 	}
-	ipv4 := parsed.To4()
-	if ipv4 == nil {
-		return "", fmt.Errorf("not IPv4")
+	if ipv4 := parsed.To4(); ipv4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d", ipv4[3], ipv4[2], ipv4[1], ipv4[0]), nil
+	}
+
+	const hexDigits = "0123456789abcdef"
+	nibbles := make([]byte, 0, 63)
+	for i := len(parsed) - 1; i >= 0; i-- {
+		if len(nibbles) > 0 {
+			nibbles = append(nibbles, '.')
+		}
+		nibbles = append(nibbles, hexDigits[parsed[i]&0x0f], '.', hexDigits[parsed[i]>>4])
 	}
-	return fmt.Sprintf("%d.%d.%d.%d", ipv4[3], ipv4[2], ipv4[1], ipv4[0]), nil
+	return string(nibbles), nil
 }
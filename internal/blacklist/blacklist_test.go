@@ -1,8 +1,15 @@
 package blacklist
 
 import (
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
 )
 
 func TestDefaultOptions(t *testing.T) {
@@ -207,3 +214,313 @@ func TestBlacklistCategories(t *testing.T) {
 		t.Error("should have spam category blacklists")
 	}
 }
+
+// mockDNS answers A and TXT queries for listed names and NXDOMAIN otherwise.
+type mockDNS struct {
+	mu      sync.Mutex
+	a       map[string]string // name -> A record
+	txt     map[string]string // name -> TXT record
+	queries []string
+}
+
+// newMockDNS starts a UDP DNS server and returns a resolver that uses it.
+func newMockDNS(t *testing.T, a, txt map[string]string) (*mockDNS, *net.Resolver) {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	m := &mockDNS{a: a, txt: txt}
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if resp := m.answer(buf[:n]); resp != nil {
+				pc.WriteTo(resp, addr)
+			}
+		}
+	}()
+
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "udp", pc.LocalAddr().String())
+		},
+	}
+	return m, resolver
+}
+
+func (m *mockDNS) answer(req []byte) []byte {
+	var p dnsmessage.Parser
+	hdr, err := p.Start(req)
+	if err != nil {
+		return nil
+	}
+	q, err := p.Question()
+	if err != nil {
+		return nil
+	}
+	name := strings.TrimSuffix(q.Name.String(), ".")
+
+	m.mu.Lock()
+	m.queries = append(m.queries, name)
+	m.mu.Unlock()
+
+	hdr.Response = true
+	hdr.Authoritative = true
+	a, listed := m.a[name]
+	if !listed {
+		hdr.RCode = dnsmessage.RCodeNameError
+	}
+	b := dnsmessage.NewBuilder(nil, hdr)
+	b.EnableCompression()
+	b.StartQuestions()
+	b.Question(q)
+	b.StartAnswers()
+	rh := dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 60}
+	switch {
+	case listed && q.Type == dnsmessage.TypeA:
+		var ip [4]byte
+		copy(ip[:], net.ParseIP(a).To4())
+		b.AResource(rh, dnsmessage.AResource{A: ip})
+	case listed && q.Type == dnsmessage.TypeTXT && m.txt[name] != "":
+		b.TXTResource(rh, dnsmessage.TXTResource{TXT: []string{m.txt[name]}})
+	}
+	resp, _ := b.Finish()
+	return resp
+}
+
+func TestReverseIPv6(t *testing.T) {
+	got, err := ReverseIP("2001:db8:1:2:3:4:567:89ab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "b.a.9.8.7.6.5.0.4.0.0.0.3.0.0.0.2.0.0.0.1.0.0.0.8.b.d.0.1.0.0.2"
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestCheckIPv6(t *testing.T) {
+	listed := "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.v6.test"
+	m, resolver := newMockDNS(t, map[string]string{listed: "127.0.0.2"}, nil)
+
+	opts := DefaultOptions()
+	opts.Blacklists = []Blacklist{
+		{Name: "V6", Zone: "v6.test", Type: TypeDNSBL, Category: "spam", IPv6: true},
+		{Name: "V4Only", Zone: "v4.test", Type: TypeDNSBL, Category: "spam"},
+	}
+	c := NewChecker(opts)
+	c.resolver = resolver
+
+	result, err := c.CheckIP(context.Background(), "2001:db8::1")
+	if err != nil {
+		t.Fatalf("CheckIP() error = %v", err)
+	}
+	if result.TotalChecks != 1 || result.Skipped != 1 || result.TotalListed != 1 {
+		t.Errorf("checks/skipped/listed = %d/%d/%d, want 1/1/1", result.TotalChecks, result.Skipped, result.TotalListed)
+	}
+	for _, q := range m.queries {
+		if strings.HasSuffix(q, "v4.test") {
+			t.Errorf("queried IPv4-only list: %s", q)
+		}
+	}
+}
+
+func TestParseBlacklists(t *testing.T) {
+	input := `# custom zones
+bl.example.net
+dbl.example.org | uribl | 2.5 | Example DBL | phishing
+
+zen.spamhaus.org|dnsbl|5
+`
+	lists, err := ParseBlacklists(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseBlacklists() error = %v", err)
+	}
+	if len(lists) != 3 {
+		t.Fatalf("got %d lists, want 3", len(lists))
+	}
+	if lists[0].Zone != "bl.example.net" || lists[0].Type != TypeDNSBL || lists[0].Name != "bl.example.net" {
+		t.Errorf("lists[0] = %+v", lists[0])
+	}
+	if lists[1].Type != TypeURIBL || lists[1].Weight != 2.5 || lists[1].Name != "Example DBL" || lists[1].Category != "phishing" {
+		t.Errorf("lists[1] = %+v", lists[1])
+	}
+
+	merged := MergeBlacklists(CommonBlacklists, lists)
+	if len(merged) != len(CommonBlacklists)+2 {
+		t.Errorf("merged %d lists, want %d", len(merged), len(CommonBlacklists)+2)
+	}
+	for _, bl := range merged {
+		if bl.Zone == "zen.spamhaus.org" && bl.Weight != 5 {
+			t.Errorf("file entry should override built-in weight, got %v", bl.Weight)
+		}
+	}
+
+	for _, bad := range []string{"zone|bogus", "zone|dnsbl|heavy", "|dnsbl"} {
+		if _, err := ParseBlacklists(strings.NewReader(bad)); err == nil {
+			t.Errorf("ParseBlacklists(%q) should fail", bad)
+		}
+	}
+}
+
+func TestCalculateScoreWeighted(t *testing.T) {
+	heavy := Blacklist{Name: "Heavy", Zone: "heavy.test", Weight: 3}
+	light := Blacklist{Name: "Light", Zone: "light.test", Weight: 1}
+	result := func(listedHeavy bool) *CheckResult {
+		return &CheckResult{
+			TotalChecks: 2,
+			TotalListed: 1,
+			Listings: []ListingResult{
+				{Blacklist: heavy, Listed: listedHeavy},
+				{Blacklist: light, Listed: !listedHeavy},
+			},
+		}
+	}
+
+	c := NewChecker(DefaultOptions())
+	onHeavy, onLight := result(true), result(false)
+	c.calculateScore(onHeavy)
+	c.calculateScore(onLight)
+	if onHeavy.Score != 25 || onLight.Score != 75 {
+		t.Errorf("scores = %d/%d, want 25/75", onHeavy.Score, onLight.Score)
+	}
+
+	// Options.Weights overrides the list's own weight.
+	opts := DefaultOptions()
+	opts.Weights = map[string]float64{"heavy.test": 1}
+	overridden := result(true)
+	NewChecker(opts).calculateScore(overridden)
+	if overridden.Score != 50 {
+		t.Errorf("overridden score = %d, want 50", overridden.Score)
+	}
+}
+
+func TestCheckTargetsJSON(t *testing.T) {
+	_, resolver := newMockDNS(t,
+		map[string]string{
+			"2.0.0.127.bl.test":      "127.0.0.2",
+			"bad.example.uribl.test": "127.0.0.4",
+		},
+		map[string]string{"2.0.0.127.bl.test": "Listed for testing"},
+	)
+
+	opts := DefaultOptions()
+	opts.Blacklists = []Blacklist{
+		{Name: "BL", Zone: "bl.test", Type: TypeDNSBL, Category: "spam"},
+		{Name: "URIBL", Zone: "uribl.test", Type: TypeURIBL, Category: "phishing"},
+	}
+	c := NewChecker(opts)
+	c.resolver = resolver
+
+	targets, err := ParseTargets(strings.NewReader("127.0.0.2\n# comment\n\nbad.example\n127.0.0.2\nnot-an-ip..\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := c.CheckTargets(context.Background(), targets)
+
+	ip := results["127.0.0.2"]
+	if ip == nil || ip.TargetType != "ip" || ip.TotalListed != 1 {
+		t.Fatalf("results[127.0.0.2] = %+v", ip)
+	}
+	if domain := results["bad.example"]; domain == nil || domain.TargetType != "domain" || domain.TotalListed != 1 {
+		t.Errorf("results[bad.example] = %+v", domain)
+	}
+
+	out, err := ip.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v", err)
+	}
+	var decoded struct {
+		Score    int    `json:"score"`
+		Risk     string `json:"risk"`
+		Listings []struct {
+			Blacklist struct {
+				Zone string `json:"zone"`
+			} `json:"blacklist"`
+			Listed     bool   `json:"listed"`
+			ReturnCode string `json:"return_code"`
+			Reason     string `json:"reason"`
+		} `json:"listings"`
+	}
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if decoded.Risk != "medium" || decoded.Score != 0 || len(decoded.Listings) != 1 {
+		t.Fatalf("decoded = %+v", decoded)
+	}
+	l := decoded.Listings[0]
+	if !l.Listed || l.ReturnCode != "127.0.0.2" || l.Reason != "Listed for testing" || l.Blacklist.Zone != "bl.test" {
+		t.Errorf("listing = %+v", l)
+	}
+}
+
+func TestGetRemediation(t *testing.T) {
+	_, resolver := newMockDNS(t,
+		map[string]string{"4.3.2.192.heavy.test": "127.0.0.2", "4.3.2.192.light.test": "127.0.0.2"},
+		map[string]string{"4.3.2.192.heavy.test": "Listed by heavy, see https://heavy.test/why"},
+	)
+	opts := DefaultOptions()
+	opts.Blacklists = []Blacklist{
+		{Name: "Light", Zone: "light.test", Type: TypeDNSBL, Weight: 0.5, Website: "https://light.test"},
+		{Name: "Heavy", Zone: "heavy.test", Type: TypeDNSBL, Weight: 3, DelistURL: "https://heavy.test/lookup?ip=%s"},
+		{Name: "Clean", Zone: "clean.test", Type: TypeDNSBL},
+	}
+	c := NewChecker(opts)
+	c.resolver = resolver
+
+	result, err := c.CheckIP(context.Background(), "192.2.3.4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := result.GetRemediation()
+	if len(steps) != 2 {
+		t.Fatalf("got %d steps, want 2", len(steps))
+	}
+	if steps[0].Blacklist != "Heavy" || steps[0].URL != "https://heavy.test/lookup?ip=192.2.3.4" {
+		t.Errorf("steps[0] = %+v", steps[0])
+	}
+	if steps[0].Reason == "" {
+		t.Error("TXT reason should be included")
+	}
+	if steps[1].Blacklist != "Light" || steps[1].URL != "https://light.test" {
+		t.Errorf("steps[1] = %+v", steps[1])
+	}
+
+	// Options.Weights and the default weight of 1 decide the order too
+	_, resolver = newMockDNS(t,
+		map[string]string{"4.3.2.192.heavy.test": "127.0.0.2", "4.3.2.192.light.test": "127.0.0.2", "4.3.2.192.plain.test": "127.0.0.2"},
+		nil,
+	)
+	opts.Blacklists = append(opts.Blacklists, Blacklist{Name: "Plain", Zone: "plain.test", Type: TypeDNSBL})
+	opts.Weights = map[string]float64{"heavy.test": 0.25}
+	weighted := NewChecker(opts)
+	weighted.resolver = resolver
+	reordered, err := weighted.CheckIP(context.Background(), "192.2.3.4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var order []string
+	for _, step := range reordered.GetRemediation() {
+		order = append(order, step.Blacklist)
+	}
+	if got := strings.Join(order, ","); got != "Plain,Light,Heavy" {
+		t.Errorf("remediation order = %s, want Plain,Light,Heavy", got)
+	}
+
+	out := result.Format()
+	if !strings.Contains(out, "Delist: https://heavy.test/lookup?ip=192.2.3.4") {
+		t.Errorf("Format() missing delist link:\n%s", out)
+	}
+	if !strings.Contains(out, "(no TXT record published)") {
+		t.Errorf("Format() should note a missing reason:\n%s", out)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"net"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -18,46 +19,72 @@ import (
 
 // CertEntry represents a certificate found in CT logs.
 type CertEntry struct {
-	CommonName string
-	SANs       []string
-	Issuer     string
-	NotBefore  time.Time
-	NotAfter   time.Time
-	SerialHex  string
-	IsExpired  bool
-	DaysLeft   int
-	IsWildcard bool
-	Source     string
+	CommonName string    `json:"common_name"`
+	SANs       []string  `json:"sans"`
+	Issuer     string    `json:"issuer"`
+	NotBefore  time.Time `json:"not_before"`
+	NotAfter   time.Time `json:"not_after"`
+	SerialHex  string    `json:"serial,omitempty"`
+	IsExpired  bool      `json:"expired"`
+	DaysLeft   int       `json:"days_left"`
+	IsWildcard bool      `json:"wildcard"`
+	Source     string    `json:"source"`
 }
 
 // Result holds the search results.
 type Result struct {
-	Domain     string
-	Entries    []CertEntry
-	LiveCert   *LiveCertInfo
-	TotalFound int
-	Unique     int
-	Expired    int
-	Wildcard   int
-	StartTime  time.Time
-	Duration   time.Duration
-	Errors     []string
+	Domain     string        `json:"domain"`
+	Entries    []CertEntry   `json:"entries"`
+	LiveCert   *LiveCertInfo `json:"live_cert,omitempty"`
+	TotalFound int           `json:"total_found"`
+	Unique     int           `json:"unique"`
+	Expired    int           `json:"expired"`
+	Wildcard   int           `json:"wildcard"`
+	StartTime  time.Time     `json:"start_time"`
+	Duration   time.Duration `json:"duration"`
+	Errors     []string      `json:"errors,omitempty"`
+}
+
+// ToJSON returns the search results as indented JSON.
+func (r *Result) ToJSON() (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// MarshalJSON encodes the result with the subdomains derived from the
+// entries.
+func (r Result) MarshalJSON() ([]byte, error) {
+	type result Result
+	out := struct {
+		result
+		Subdomains []string `json:"subdomains"`
+	}{result(r), r.Subdomains()}
+	if out.Entries == nil {
+		out.Entries = []CertEntry{}
+	}
+	if out.Subdomains == nil {
+		out.Subdomains = []string{}
+	}
+	return json.Marshal(out)
 }
 
 // LiveCertInfo holds info from the live TLS certificate.
 type LiveCertInfo struct {
-	CommonName string
-	SANs       []string
-	Issuer     string
-	NotBefore  time.Time
-	NotAfter   time.Time
-	DaysLeft   int
-	SerialHex  string
-	Version    int
-	SigAlgo    string
-	KeyUsage   []string
-	IsCA       bool
-	ChainLen   int
+	CommonName string    `json:"common_name"`
+	SANs       []string  `json:"sans"`
+	Issuer     string    `json:"issuer"`
+	NotBefore  time.Time `json:"not_before"`
+	NotAfter   time.Time `json:"not_after"`
+	DaysLeft   int       `json:"days_left"`
+	SerialHex  string    `json:"serial"`
+	Version    int       `json:"version"`
+	SigAlgo    string    `json:"signature_algorithm"`
+	KeyUsage   []string  `json:"key_usage,omitempty"`
+	IsCA       bool      `json:"is_ca"`
+	ChainLen   int       `json:"chain_length"`
 }
 
 // Format returns formatted results.
@@ -144,6 +171,41 @@ func (r *Result) Format() string {
 	return b.String()
 }
 
+// Subdomains returns every unique host name under the searched domain found
+// in the common names and SANs of the results, sorted. Wildcard labels are
+// stripped, so "*.api.example.com" yields "api.example.com".
+func (r *Result) Subdomains() []string {
+	domain := strings.ToLower(strings.TrimSuffix(r.Domain, "."))
+	seen := make(map[string]bool)
+	add := func(name string) {
+		name = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+		name = strings.TrimPrefix(name, "*.")
+		if name == domain || strings.HasSuffix(name, "."+domain) {
+			seen[name] = true
+		}
+	}
+
+	for _, e := range r.Entries {
+		add(e.CommonName)
+		for _, san := range e.SANs {
+			add(san)
+		}
+	}
+	if r.LiveCert != nil {
+		add(r.LiveCert.CommonName)
+		for _, san := range r.LiveCert.SANs {
+			add(san)
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // FormatCompact returns a single-line summary.
 func (r *Result) FormatCompact() string {
 	live := "no live cert"
@@ -161,6 +223,23 @@ type Options struct {
 	Timeout    time.Duration
 	CheckLive  bool
 	MaxResults int
+
+	// crt.sh retry behaviour. Retries is the number of extra attempts after
+	// a rate limit, 5xx or network error (0 uses the default, negative
+	// disables retries); RetryDelay is the first backoff delay and doubles
+	// on each retry unless the server sends Retry-After.
+	Retries    int
+	RetryDelay time.Duration
+
+	// Filters applied after fetching. Since and Until bound the issuance
+	// (NotBefore) time; zero values are unbounded and Until is exclusive.
+	Since         time.Time
+	Until         time.Time
+	WildcardsOnly bool
+
+	// Sources are queried alongside crt.sh. Nil uses Cert Spotter; an
+	// empty, non-nil slice queries crt.sh only.
+	Sources []Source
 }
 
 // DefaultOptions returns sensible defaults.
@@ -169,6 +248,8 @@ func DefaultOptions() Options {
 		Timeout:    15 * time.Second,
 		CheckLive:  true,
 		MaxResults: 100,
+		Retries:    2,
+		RetryDelay: 2 * time.Second,
 	}
 }
 
@@ -185,8 +266,9 @@ type crtShEntry struct {
 
 // Searcher performs CT log searches.
 type Searcher struct {
-	opts   Options
-	client *http.Client
+	opts     Options
+	client   *http.Client
+	crtShURL string
 }
 
 // NewSearcher creates a new searcher.
@@ -197,12 +279,26 @@ func NewSearcher(opts Options) *Searcher {
 	if opts.MaxResults == 0 {
 		opts.MaxResults = 100
 	}
+	if opts.Retries == 0 {
+		opts.Retries = 2
+	} else if opts.Retries < 0 {
+		opts.Retries = 0
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = 2 * time.Second
+	}
+
+	client := &http.Client{
+		Timeout: opts.Timeout,
+	}
+	if opts.Sources == nil {
+		opts.Sources = []Source{&CertSpotter{Client: client}}
+	}
 
 	return &Searcher{
-		opts: opts,
-		client: &http.Client{
-			Timeout: opts.Timeout,
-		},
+		opts:     opts,
+		client:   client,
+		crtShURL: "https://crt.sh/",
 	}
 }
 
@@ -223,19 +319,22 @@ func (s *Searcher) Search(ctx context.Context) (*Result, error) {
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 
-	// Search crt.sh
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		entries, err := s.searchCrtSh(ctx)
-		mu.Lock()
-		defer mu.Unlock()
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("crt.sh: %v", err))
-			return
-		}
-		result.Entries = append(result.Entries, entries...)
-	}()
+	// Search crt.sh and the other CT sources
+	sources := append([]Source{crtShSource{s}}, s.opts.Sources...)
+	for _, src := range sources {
+		wg.Add(1)
+		go func(src Source) {
+			defer wg.Done()
+			entries, err := src.Search(ctx, s.opts.Domain)
+			mu.Lock()
+			defer mu.Unlock()
+			// Keep partial results from a source that failed part-way.
+			result.Entries = append(result.Entries, entries...)
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", src.Name(), err))
+			}
+		}(src)
+	}
 
 	// Check live cert
 	if s.opts.CheckLive {
@@ -255,8 +354,8 @@ func (s *Searcher) Search(ctx context.Context) (*Result, error) {
 
 	wg.Wait()
 
-	// Deduplicate and sort
-	result.Entries = deduplicateEntries(result.Entries)
+	// Deduplicate, filter and sort
+	result.Entries = s.filterEntries(deduplicateEntries(result.Entries))
 	sort.Slice(result.Entries, func(i, j int) bool {
 		return result.Entries[i].NotAfter.After(result.Entries[j].NotAfter)
 	})
@@ -288,70 +387,118 @@ func (s *Searcher) Search(ctx context.Context) (*Result, error) {
 	return result, nil
 }
 
+// filterEntries applies the date-range and wildcard options.
+func (s *Searcher) filterEntries(entries []CertEntry) []CertEntry {
+	filtered := entries[:0]
+	for _, e := range entries {
+		if !s.opts.Since.IsZero() && e.NotBefore.Before(s.opts.Since) {
+			continue
+		}
+		if !s.opts.Until.IsZero() && !e.NotBefore.Before(s.opts.Until) {
+			continue
+		}
+		if s.opts.WildcardsOnly && !e.IsWildcard {
+			continue
+		}
+		filtered = append(filtered, e)
+	}
+	return filtered
+}
+
 func (s *Searcher) searchCrtSh(ctx context.Context) ([]CertEntry, error) {
-	url := fmt.Sprintf("https://crt.sh/?q=%%25.%s&output=json", s.opts.Domain)
-	return s.searchCrtShWithURL(url)
+	url := fmt.Sprintf("%s?q=%%25.%s&output=json", s.crtShURL, s.opts.Domain)
+	return s.searchCrtShWithURL(ctx, url)
 }
 
-// searchCrtShWithURL fetches certificate entries from the given URL.
-func (s *Searcher) searchCrtShWithURL(rawURL string) ([]CertEntry, error) {
-	ctx := context.Background()
+// searchCrtShWithURL fetches certificate entries from the given URL,
+// retrying rate limits, 5xx responses and network errors with exponential
+// backoff until Options.Retries or Options.Timeout is exhausted. Entries
+// decoded before a failure are returned along with the error.
+func (s *Searcher) searchCrtShWithURL(ctx context.Context, rawURL string) ([]CertEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.opts.Timeout)
+	defer cancel()
+
+	var partial []CertEntry
+	delay := s.opts.RetryDelay
+	for attempt := 0; ; attempt++ {
+		entries, wait, err := s.fetchCrtSh(ctx, rawURL)
+		if err == nil {
+			return entries, nil
+		}
+		if len(entries) > len(partial) {
+			partial = entries
+		}
+		if wait < 0 || attempt >= s.opts.Retries {
+			if attempt > 0 {
+				err = fmt.Errorf("%w (after %d attempts)", err, attempt+1)
+			}
+			return partial, err
+		}
+
+		if wait == 0 {
+			wait = delay
+			delay *= 2
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return partial, fmt.Errorf("%w (gave up after %d attempts: timeout)", err, attempt+1)
+		}
+		select {
+		case <-ctx.Done():
+			return partial, err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// fetchCrtSh makes one crt.sh request. wait is negative when the error is
+// not worth retrying and positive when the server asked for a specific
+// delay via Retry-After.
+func (s *Searcher) fetchCrtSh(ctx context.Context, rawURL string) (entries []CertEntry, wait time.Duration, err error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
 	if err != nil {
-		return nil, err
+		return nil, -1, err
 	}
 	req.Header.Set("User-Agent", "nns-certhunt/1.0")
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(io.LimitReader(resp.Body, 5*1024*1024))
-	if err != nil {
-		return nil, err
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+			wait = time.Duration(secs) * time.Second
+		}
+		return nil, wait, fmt.Errorf("HTTP %d", resp.StatusCode)
+	default:
+		return nil, -1, fmt.Errorf("HTTP %d", resp.StatusCode)
 	}
 
-	var raw []crtShEntry
-	if err := json.Unmarshal(body, &raw); err != nil {
-		return nil, fmt.Errorf("parse error: %w", err)
+	// Decode element by element so a truncated or oversized response still
+	// yields the entries received before it broke off.
+	dec := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, -1, fmt.Errorf("parse error: expected JSON array")
 	}
-
-	now := time.Now()
-	entries := make([]CertEntry, 0, len(raw))
-	for _, r := range raw {
+	for dec.More() {
+		var r crtShEntry
+		if err := dec.Decode(&r); err != nil {
+			return entries, 0, fmt.Errorf("partial response after %d entries: %w", len(entries), err)
+		}
 		notBefore, _ := time.Parse("2006-01-02T15:04:05", r.NotBefore)
 		notAfter, _ := time.Parse("2006-01-02T15:04:05", r.NotAfter)
 
-		cn := r.CommonName
-		isExpired := notAfter.Before(now)
-		daysLeft := 0
-		if !isExpired {
-			daysLeft = int(notAfter.Sub(now).Hours() / 24)
-		}
-
-		sans := parseSANs(r.NameValue)
-
-		entries = append(entries, CertEntry{
-			CommonName: cn,
-			SANs:       sans,
-			Issuer:     extractCN(r.IssuerName),
-			NotBefore:  notBefore,
-			NotAfter:   notAfter,
-			SerialHex:  r.SerialNum,
-			IsExpired:  isExpired,
-			DaysLeft:   daysLeft,
-			IsWildcard: strings.HasPrefix(cn, "*."),
-			Source:     "crt.sh",
-		})
+		entries = append(entries, newEntry(r.CommonName, parseSANs(r.NameValue),
+			extractCN(r.IssuerName), notBefore, notAfter, r.SerialNum, "crt.sh"))
+	}
+	if entries == nil {
+		entries = make([]CertEntry, 0)
 	}
 
-	return entries, nil
+	return entries, 0, nil
 }
 
 func (s *Searcher) checkLiveCert(ctx context.Context) (*LiveCertInfo, error) {
@@ -429,15 +576,44 @@ func extractCN(issuer string) string {
 	return "Unknown"
 }
 
+// deduplicateEntries merges entries for the same certificate reported by one
+// or more sources. Entries match on common name and serial, or on common
+// name and validity period when a source does not report serials. Merged
+// entries list every source that reported them.
 func deduplicateEntries(entries []CertEntry) []CertEntry {
-	seen := make(map[string]bool)
+	bySerial := make(map[string]int)
+	byValidity := make(map[string]int)
 	result := make([]CertEntry, 0, len(entries))
 	for _, e := range entries {
-		key := e.CommonName + "|" + e.SerialHex
-		if !seen[key] {
-			seen[key] = true
+		serialKey := e.CommonName + "|" + normalizeSerial(e.SerialHex)
+		validityKey := fmt.Sprintf("%s|%d|%d", e.CommonName, e.NotBefore.Unix(), e.NotAfter.Unix())
+
+		idx, ok := -1, false
+		if e.SerialHex != "" {
+			idx, ok = bySerial[serialKey]
+		}
+		if !ok {
+			if i, found := byValidity[validityKey]; found && (e.SerialHex == "" || result[i].SerialHex == "") {
+				idx, ok = i, true
+			}
+		}
+
+		if !ok {
 			result = append(result, e)
+			idx = len(result) - 1
+		} else {
+			existing := &result[idx]
+			if existing.SerialHex == "" {
+				existing.SerialHex = e.SerialHex
+			}
+			if e.Source != "" && !strings.Contains(existing.Source, e.Source) {
+				existing.Source += ", " + e.Source
+			}
+		}
+		if result[idx].SerialHex != "" {
+			bySerial[result[idx].CommonName+"|"+normalizeSerial(result[idx].SerialHex)] = idx
 		}
+		byValidity[validityKey] = idx
 	}
 	return result
 }
@@ -276,7 +276,7 @@ func TestSearchCrtShMock(t *testing.T) {
 	})
 
 	// Override the search URL by calling the internal method with the mock
-	entries, err := s.searchCrtShWithURL(server.URL)
+	entries, err := s.searchCrtShWithURL(context.Background(), server.URL)
 	if err != nil {
 		t.Fatalf("search failed: %v", err)
 	}
@@ -306,7 +306,7 @@ func TestSearchCrtShError(t *testing.T) {
 	defer server.Close()
 
 	s := NewSearcher(Options{Domain: "example.com", Timeout: 2 * time.Second})
-	_, err := s.searchCrtShWithURL(server.URL)
+	_, err := s.searchCrtShWithURL(context.Background(), server.URL)
 	if err == nil {
 		t.Error("expected error for 500 response")
 	}
@@ -319,12 +319,298 @@ func TestSearchCrtShInvalidJSON(t *testing.T) {
 	defer server.Close()
 
 	s := NewSearcher(Options{Domain: "example.com", Timeout: 2 * time.Second})
-	_, err := s.searchCrtShWithURL(server.URL)
+	_, err := s.searchCrtShWithURL(context.Background(), server.URL)
 	if err == nil {
 		t.Error("expected error for invalid JSON")
 	}
 }
 
+func TestCertSpotterSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/issuances" || r.URL.Query().Get("domain") != "example.com" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `[{"dns_names":["example.com","www.example.com"],
+			"not_before":"2024-01-01T00:00:00Z","not_after":"2099-01-01T00:00:00Z",
+			"issuer":{"friendly_name":"Let's Encrypt","name":"C=US, O=Let's Encrypt, CN=R3"}}]`)
+	}))
+	defer server.Close()
+
+	src := &CertSpotter{BaseURL: server.URL}
+	entries, err := src.Search(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.CommonName != "example.com" || len(e.SANs) != 2 || e.Issuer != "Let's Encrypt" || e.Source != "certspotter" {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	if e.IsExpired || e.DaysLeft == 0 {
+		t.Errorf("expected a valid certificate, got %+v", e)
+	}
+}
+
+func TestCensysSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, ok := r.BasicAuth(); !ok || id != "id" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"result":{"hits":[{"names":["*.example.com","example.com"],
+			"parsed":{"subject_dn":"CN=*.example.com","issuer_dn":"C=US, O=DigiCert Inc, CN=DigiCert CA",
+			"serial_number":"0abc","validity_period":{"not_before":"2020-01-01T00:00:00Z","not_after":"2021-01-01T00:00:00Z"}}}]}}`)
+	}))
+	defer server.Close()
+
+	src := &Censys{BaseURL: server.URL, APIID: "id", Secret: "secret"}
+	entries, err := src.Search(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(entries) != 1 || !entries[0].IsWildcard || !entries[0].IsExpired || entries[0].Issuer != "DigiCert CA" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+
+	src.Secret = "wrong"
+	if _, err := src.Search(context.Background(), "example.com"); err == nil {
+		t.Error("expected error for bad credentials")
+	}
+	if _, err := (&Censys{}).Search(context.Background(), "example.com"); err == nil {
+		t.Error("expected error without credentials")
+	}
+}
+
+// stubSource returns fixed entries or an error.
+type stubSource struct {
+	name    string
+	entries []CertEntry
+	err     error
+}
+
+func (s stubSource) Name() string { return s.name }
+
+func (s stubSource) Search(ctx context.Context, domain string) ([]CertEntry, error) {
+	return s.entries, s.err
+}
+
+func TestSearchMergesSources(t *testing.T) {
+	nb := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	na := time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	crtsh := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]crtShEntry{{
+			CommonName: "example.com",
+			IssuerName: "CN=Test CA",
+			NameValue:  "example.com",
+			NotBefore:  nb.Format("2006-01-02T15:04:05"),
+			NotAfter:   na.Format("2006-01-02T15:04:05"),
+			SerialNum:  "00ab12",
+		}})
+	}))
+	defer crtsh.Close()
+
+	s := NewSearcher(Options{
+		Domain: "example.com",
+		Sources: []Source{
+			// Same certificate without a serial, matched on validity.
+			stubSource{name: "spotter", entries: []CertEntry{newEntry("example.com", nil, "Test CA", nb, na, "", "spotter")}},
+			// Same certificate with a differently formatted serial.
+			stubSource{name: "censys", entries: []CertEntry{newEntry("example.com", nil, "Test CA", nb, na, "AB:12", "censys")}},
+			stubSource{name: "other", entries: []CertEntry{newEntry("api.example.com", nil, "Test CA", nb, na, "ff", "other")}},
+			stubSource{name: "down", err: fmt.Errorf("HTTP 503")},
+		},
+	})
+	s.crtShURL = crtsh.URL + "/"
+
+	result, err := s.Search(context.Background())
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+
+	if result.TotalFound != 2 {
+		t.Fatalf("expected 2 merged entries, got %d: %+v", result.TotalFound, result.Entries)
+	}
+	for _, e := range result.Entries {
+		if e.CommonName == "example.com" {
+			for _, src := range []string{"crt.sh", "spotter", "censys"} {
+				if !strings.Contains(e.Source, src) {
+					t.Errorf("merged entry source %q missing %s", e.Source, src)
+				}
+			}
+		}
+	}
+	if len(result.Errors) != 1 || !strings.HasPrefix(result.Errors[0], "down:") {
+		t.Errorf("expected one error from the failing source, got %v", result.Errors)
+	}
+}
+
+func TestSubdomains(t *testing.T) {
+	r := &Result{
+		Domain: "example.com",
+		Entries: []CertEntry{
+			{CommonName: "example.com", SANs: []string{"www.example.com", "WWW.Example.com."}},
+			{CommonName: "*.api.example.com", SANs: []string{"*.api.example.com", "other.org", "notexample.com"}},
+			{CommonName: "mail.example.com"},
+		},
+		LiveCert: &LiveCertInfo{CommonName: "example.com", SANs: []string{"cdn.example.com"}},
+	}
+
+	got := strings.Join(r.Subdomains(), ",")
+	want := "api.example.com,cdn.example.com,example.com,mail.example.com,www.example.com"
+	if got != want {
+		t.Errorf("Subdomains() = %s, want %s", got, want)
+	}
+}
+
+func TestResultToJSON(t *testing.T) {
+	r := &Result{
+		Domain: "example.com",
+		Entries: []CertEntry{
+			{CommonName: "www.example.com", Issuer: "R3", Source: "crt.sh, certspotter"},
+		},
+		Unique:   1,
+		Duration: 250 * time.Millisecond,
+	}
+	out, err := r.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON: %v", err)
+	}
+	for _, want := range []string{
+		`"common_name": "www.example.com"`,
+		`"source": "crt.sh, certspotter"`,
+		`"www.example.com"`,
+		`"duration": 250000000`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("JSON missing %s:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "live_cert") {
+		t.Errorf("live_cert should be omitted when nil:\n%s", out)
+	}
+}
+
+func TestFilterEntries(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	entries := func() []CertEntry {
+		return []CertEntry{
+			{CommonName: "old.example.com", NotBefore: day(1)},
+			{CommonName: "*.example.com", NotBefore: day(10), IsWildcard: true},
+			{CommonName: "new.example.com", NotBefore: day(20)},
+		}
+	}
+	names := func(es []CertEntry) string {
+		var n []string
+		for _, e := range es {
+			n = append(n, e.CommonName)
+		}
+		return strings.Join(n, ",")
+	}
+
+	tests := []struct {
+		opts Options
+		want string
+	}{
+		{Options{}, "old.example.com,*.example.com,new.example.com"},
+		{Options{Since: day(5)}, "*.example.com,new.example.com"},
+		{Options{Until: day(20)}, "old.example.com,*.example.com"},
+		{Options{Since: day(5), Until: day(15)}, "*.example.com"},
+		{Options{WildcardsOnly: true}, "*.example.com"},
+		{Options{Since: day(15), WildcardsOnly: true}, ""},
+	}
+	for _, tt := range tests {
+		s := &Searcher{opts: tt.opts}
+		if got := names(s.filterEntries(entries())); got != tt.want {
+			t.Errorf("filterEntries(%+v) = %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestSearchCrtShRetry(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode([]crtShEntry{{CommonName: "example.com", SerialNum: "01"}})
+	}))
+	defer server.Close()
+
+	s := NewSearcher(Options{Domain: "example.com", Timeout: 5 * time.Second, Retries: 2, RetryDelay: 10 * time.Millisecond})
+	entries, err := s.searchCrtShWithURL(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if calls != 3 || len(entries) != 1 {
+		t.Errorf("expected 3 calls and 1 entry, got %d calls, %d entries", calls, len(entries))
+	}
+
+	// Retries exhausted.
+	calls = -10
+	_, err = s.searchCrtShWithURL(context.Background(), server.URL)
+	if err == nil || !strings.Contains(err.Error(), "502") || !strings.Contains(err.Error(), "3 attempts") {
+		t.Errorf("expected HTTP 502 after 3 attempts, got %v", err)
+	}
+}
+
+func TestSearchCrtShNoRetryOnClientError(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	s := NewSearcher(Options{Domain: "example.com", Timeout: 5 * time.Second, RetryDelay: 10 * time.Millisecond})
+	if _, err := s.searchCrtShWithURL(context.Background(), server.URL); err == nil {
+		t.Error("expected error for 404")
+	}
+	if calls != 1 {
+		t.Errorf("expected a single request for 404, got %d", calls)
+	}
+}
+
+func TestSearchCrtShPartial(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Two complete entries, then the connection drops mid-object.
+		fmt.Fprint(w, `[{"common_name":"a.example.com","serial_number":"01"},{"common_name":"b.example.com","serial_number":"02"},{"common_na`)
+	}))
+	defer server.Close()
+
+	s := NewSearcher(Options{Domain: "example.com", Timeout: 5 * time.Second, Retries: -1})
+	entries, err := s.searchCrtShWithURL(context.Background(), server.URL)
+	if err == nil || !strings.Contains(err.Error(), "partial response after 2 entries") {
+		t.Errorf("expected partial response error, got %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected 2 partial entries, got %d", len(entries))
+	}
+}
+
+func TestSearchCrtShTimeoutStopsRetrying(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	s := NewSearcher(Options{Domain: "example.com", Timeout: time.Second, Retries: 5})
+	start := time.Now()
+	_, err := s.searchCrtShWithURL(context.Background(), server.URL)
+	if err == nil || !strings.Contains(err.Error(), "429") {
+		t.Errorf("expected HTTP 429 error, got %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("should give up when Retry-After exceeds the timeout, took %v", time.Since(start))
+	}
+}
+
 func TestCertEntryFields(t *testing.T) {
 	now := time.Now()
 	e := CertEntry{
@@ -0,0 +1,221 @@
+package certhunt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxResponseSize caps how much of a CT API response is read.
+const maxResponseSize = 5 * 1024 * 1024
+
+// Source is a certificate transparency search backend.
+type Source interface {
+	Name() string
+	Search(ctx context.Context, domain string) ([]CertEntry, error)
+}
+
+// crtShSource adapts the searcher's built-in crt.sh backend to Source.
+type crtShSource struct {
+	s *Searcher
+}
+
+func (c crtShSource) Name() string { return "crt.sh" }
+
+func (c crtShSource) Search(ctx context.Context, domain string) ([]CertEntry, error) {
+	return c.s.searchCrtSh(ctx)
+}
+
+// CertSpotter queries the SSLMate Cert Spotter issuances API. It works
+// without a token at a low hourly rate limit.
+type CertSpotter struct {
+	Client  *http.Client
+	BaseURL string // Defaults to https://api.certspotter.com
+	Token   string // Optional API token
+}
+
+type certSpotterIssuance struct {
+	DNSNames  []string `json:"dns_names"`
+	NotBefore string   `json:"not_before"`
+	NotAfter  string   `json:"not_after"`
+	Issuer    struct {
+		FriendlyName string `json:"friendly_name"`
+		Name         string `json:"name"`
+	} `json:"issuer"`
+}
+
+// Name returns "certspotter".
+func (c *CertSpotter) Name() string { return "certspotter" }
+
+// Search returns issuances for domain and its subdomains.
+func (c *CertSpotter) Search(ctx context.Context, domain string) ([]CertEntry, error) {
+	base := c.BaseURL
+	if base == "" {
+		base = "https://api.certspotter.com"
+	}
+	q := url.Values{}
+	q.Set("domain", domain)
+	q.Set("include_subdomains", "true")
+	q.Add("expand", "dns_names")
+	q.Add("expand", "issuer")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/v1/issuances?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	var raw []certSpotterIssuance
+	if err := getJSON(c.Client, req, &raw); err != nil {
+		return nil, err
+	}
+
+	entries := make([]CertEntry, 0, len(raw))
+	for _, r := range raw {
+		if len(r.DNSNames) == 0 {
+			continue
+		}
+		notBefore, _ := time.Parse(time.RFC3339, r.NotBefore)
+		notAfter, _ := time.Parse(time.RFC3339, r.NotAfter)
+		issuer := r.Issuer.FriendlyName
+		if issuer == "" {
+			issuer = extractCN(r.Issuer.Name)
+		}
+		entries = append(entries, newEntry(r.DNSNames[0], r.DNSNames, issuer, notBefore, notAfter, "", c.Name()))
+	}
+	return entries, nil
+}
+
+// Censys queries the Censys Search v2 certificates API, which requires an
+// API ID and secret.
+type Censys struct {
+	Client  *http.Client
+	BaseURL string // Defaults to https://search.censys.io
+	APIID   string
+	Secret  string
+	PerPage int // Defaults to 100
+}
+
+type censysResponse struct {
+	Result struct {
+		Hits []struct {
+			Names  []string `json:"names"`
+			Parsed struct {
+				SubjectDN      string `json:"subject_dn"`
+				IssuerDN       string `json:"issuer_dn"`
+				SerialNumber   string `json:"serial_number"`
+				ValidityPeriod struct {
+					NotBefore string `json:"not_before"`
+					NotAfter  string `json:"not_after"`
+				} `json:"validity_period"`
+			} `json:"parsed"`
+		} `json:"hits"`
+	} `json:"result"`
+}
+
+// Name returns "censys".
+func (c *Censys) Name() string { return "censys" }
+
+// Search returns certificates whose names include domain.
+func (c *Censys) Search(ctx context.Context, domain string) ([]CertEntry, error) {
+	if c.APIID == "" || c.Secret == "" {
+		return nil, fmt.Errorf("API ID and secret required")
+	}
+	base := c.BaseURL
+	if base == "" {
+		base = "https://search.censys.io"
+	}
+	perPage := c.PerPage
+	if perPage <= 0 {
+		perPage = 100
+	}
+	q := url.Values{}
+	q.Set("q", "names: "+domain)
+	q.Set("per_page", fmt.Sprint(perPage))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/api/v2/certificates/search?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(c.APIID, c.Secret)
+
+	var raw censysResponse
+	if err := getJSON(c.Client, req, &raw); err != nil {
+		return nil, err
+	}
+
+	entries := make([]CertEntry, 0, len(raw.Result.Hits))
+	for _, h := range raw.Result.Hits {
+		cn := extractCN(h.Parsed.SubjectDN)
+		if cn == "Unknown" && len(h.Names) > 0 {
+			cn = h.Names[0]
+		}
+		notBefore, _ := time.Parse(time.RFC3339, h.Parsed.ValidityPeriod.NotBefore)
+		notAfter, _ := time.Parse(time.RFC3339, h.Parsed.ValidityPeriod.NotAfter)
+		entries = append(entries, newEntry(cn, h.Names, extractCN(h.Parsed.IssuerDN), notBefore, notAfter, h.Parsed.SerialNumber, c.Name()))
+	}
+	return entries, nil
+}
+
+// getJSON performs req and decodes a JSON response body into v.
+func getJSON(client *http.Client, req *http.Request, v any) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req.Header.Set("User-Agent", "nns-certhunt/1.0")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("parse error: %w", err)
+	}
+	return nil
+}
+
+// newEntry builds a CertEntry, deriving expiry and wildcard fields.
+func newEntry(cn string, sans []string, issuer string, notBefore, notAfter time.Time, serial, source string) CertEntry {
+	now := time.Now()
+	isExpired := notAfter.Before(now)
+	daysLeft := 0
+	if !isExpired {
+		daysLeft = int(notAfter.Sub(now).Hours() / 24)
+	}
+	return CertEntry{
+		CommonName: cn,
+		SANs:       sans,
+		Issuer:     issuer,
+		NotBefore:  notBefore,
+		NotAfter:   notAfter,
+		SerialHex:  serial,
+		IsExpired:  isExpired,
+		DaysLeft:   daysLeft,
+		IsWildcard: strings.HasPrefix(cn, "*."),
+		Source:     source,
+	}
+}
+
+// normalizeSerial makes serials from different logs comparable.
+func normalizeSerial(serial string) string {
+	serial = strings.ToLower(strings.ReplaceAll(serial, ":", ""))
+	return strings.TrimLeft(serial, "0")
+}
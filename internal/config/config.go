@@ -0,0 +1,215 @@
+// Package config loads per-command flag defaults from a YAML config file.
+//
+// The file holds one section per command, keyed by the command's full name,
+// with flag names (without dashes) mapped to values:
+//
+//	# ~/.nns.yaml
+//	ping:
+//	  count: 10
+//	dns:
+//	  server: 1.1.1.1
+//	portscan:
+//	  concurrency: 200
+//	snmp:
+//	  community: [public, s3cret]
+//
+// Values fill in the flags not given on the command line, so explicit flags
+// still win, and a repeatable flag given there replaces its configured list.
+// Only the YAML subset understood by package miniyaml is supported.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/JedizLaPulga/NNS/internal/miniyaml"
+)
+
+// FileName is the config file looked up in the home directory.
+const FileName = ".nns.yaml"
+
+// Config maps command names to flag defaults.
+type Config struct {
+	Path     string
+	Commands map[string]map[string][]string // command -> flag -> values
+}
+
+// DefaultPath returns ~/.nns.yaml, or "" if the home directory is unknown.
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, FileName)
+}
+
+// Load reads the config file at path. An empty path means DefaultPath, and
+// a missing default file yields an empty config; an explicitly given file
+// must exist.
+func Load(path string) (*Config, error) {
+	explicit := path != ""
+	if !explicit {
+		path = DefaultPath()
+		if path == "" {
+			return &Config{Commands: map[string]map[string][]string{}}, nil
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return &Config{Commands: map[string]map[string][]string{}}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	cfg, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	cfg.Path = path
+	return cfg, nil
+}
+
+// Parse reads a config file.
+func Parse(r io.Reader) (*Config, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := miniyaml.ParseStrings(string(data))
+	if err != nil {
+		return nil, err
+	}
+	sections, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("expected command sections like \"ping:\"")
+	}
+
+	cfg := &Config{Commands: map[string]map[string][]string{}}
+	for name, body := range sections {
+		options, ok := body.(map[string]interface{})
+		if !ok && body != nil {
+			return nil, fmt.Errorf("%s: expected \"option: value\" lines", name)
+		}
+		section := map[string][]string{}
+		for key, value := range options {
+			values, err := optionValues(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", name, key, err)
+			}
+			section[strings.TrimLeft(key, "-")] = values
+		}
+		cfg.Commands[name] = section
+	}
+	return cfg, nil
+}
+
+// optionValues flattens an option's scalar or list value.
+func optionValues(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, errors.New("list items must be plain values")
+			}
+			values = append(values, s)
+		}
+		return values, nil
+	}
+	return nil, errors.New("expected a value or a list")
+}
+
+// Apply sets the defaults configured for the command fs is named after on
+// every flag not already set. Call it after parsing the command line, so
+// explicit flags win: a repeatable flag given on the command line drops its
+// configured values instead of adding to them. Unknown options and invalid
+// values are returned as warnings rather than failing, so a stale config
+// never blocks a command.
+func (c *Config) Apply(fs *flag.FlagSet) []string {
+	if c == nil {
+		return nil
+	}
+	section := c.Commands[fs.Name()]
+
+	// Short and long spellings of a flag share one Value, so a flag counts
+	// as set when any flag bound to its Value was given.
+	explicit := make(map[string]bool)
+	explicitValues := make(map[flag.Value]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+		if reflect.TypeOf(f.Value).Comparable() {
+			explicitValues[f.Value] = true
+		}
+	})
+
+	keys := make([]string, 0, len(section))
+	for k := range section {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var warnings []string
+	for _, key := range keys {
+		f := fs.Lookup(key)
+		if f == nil {
+			warnings = append(warnings, fmt.Sprintf("unknown option %q for %s", key, fs.Name()))
+			continue
+		}
+		if explicit[key] || (reflect.TypeOf(f.Value).Comparable() && explicitValues[f.Value]) {
+			continue
+		}
+		for _, v := range section[key] {
+			// Some flag values overwrite themselves on a failed Set, so
+			// put back the previous value to keep the built-in default.
+			prev := f.Value.String()
+			if err := fs.Set(key, v); err != nil {
+				f.Value.Set(prev)
+				warnings = append(warnings, fmt.Sprintf("invalid value %q for %s %s: %v", v, fs.Name(), key, err))
+			}
+		}
+	}
+	return warnings
+}
+
+// SplitFlag removes a leading --config PATH (or --config=PATH) given before
+// the command name and returns the remaining arguments and the path.
+func SplitFlag(args []string) ([]string, string, error) {
+	rest := make([]string, 0, len(args))
+	path := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if name != "config" {
+			rest = append(rest, arg)
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, "", errors.New("--config requires a file path")
+			}
+			i++
+			value = args[i]
+		}
+		path = value
+	}
+	return rest, path, nil
+}
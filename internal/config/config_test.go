@@ -0,0 +1,211 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sample = `# nns defaults
+ping:
+  count: 10          # more probes
+  interval: 500ms
+
+dns:
+  server: "1.1.1.1:53"
+
+snmp:
+  community: [public, 'private']
+  port: 1161
+sweep:
+  exclude:
+    - 10.0.0.1
+    - 10.0.0.0/30
+`
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	want := map[string]map[string][]string{
+		"ping":  {"count": {"10"}, "interval": {"500ms"}},
+		"dns":   {"server": {"1.1.1.1:53"}},
+		"snmp":  {"community": {"public", "private"}, "port": {"1161"}},
+		"sweep": {"exclude": {"10.0.0.1", "10.0.0.0/30"}},
+	}
+	if !reflect.DeepEqual(cfg.Commands, want) {
+		t.Errorf("Commands = %v, want %v", cfg.Commands, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"count: 10\n",          // option outside a section
+		"  count: 10\n",        // indented option before any section
+		"ping:\n  - 1\n",       // list item without a key
+		"ping:\n  just-text\n", // not key: value
+	}
+	for _, in := range tests {
+		if _, err := Parse(strings.NewReader(in)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", in)
+		}
+	}
+}
+
+type listFlag []string
+
+func (l *listFlag) String() string     { return strings.Join(*l, ",") }
+func (l *listFlag) Set(v string) error { *l = append(*l, v); return nil }
+
+func TestApply(t *testing.T) {
+	cfg, err := Parse(strings.NewReader(`ping:
+  count: 10
+  interval: 500ms
+  bogus: 1
+  timeout: soon
+snmp:
+  community: [a, b]
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	fs := flag.NewFlagSet("ping", flag.ContinueOnError)
+	count := fs.Int("count", 4, "")
+	interval := fs.Duration("interval", time.Second, "")
+	timeout := fs.Duration("timeout", 2*time.Second, "")
+
+	warnings := cfg.Apply(fs)
+	if len(warnings) != 2 || !strings.Contains(warnings[0], `"bogus"`) || !strings.Contains(warnings[1], `"soon"`) {
+		t.Errorf("warnings = %q, want unknown bogus and invalid timeout", warnings)
+	}
+	if *count != 10 || *interval != 500*time.Millisecond || *timeout != 2*time.Second {
+		t.Errorf("after Apply: count=%d interval=%v timeout=%v", *count, *interval, *timeout)
+	}
+
+	// Explicit flags override the config
+	explicit := flag.NewFlagSet("ping", flag.ContinueOnError)
+	count = explicit.Int("count", 4, "")
+	interval = explicit.Duration("interval", time.Second, "")
+	explicit.Duration("timeout", 2*time.Second, "")
+	if err := explicit.Parse([]string{"--count", "3"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Apply(explicit)
+	if *count != 3 || *interval != 500*time.Millisecond {
+		t.Errorf("count = %d interval = %v after explicit --count 3, want 3 and 500ms", *count, *interval)
+	}
+
+	// So does the short spelling of a flag sharing the long one's value
+	short := flag.NewFlagSet("ping", flag.ContinueOnError)
+	count = short.Int("count", 4, "")
+	short.IntVar(count, "c", 4, "")
+	short.Duration("interval", time.Second, "")
+	short.Duration("timeout", 2*time.Second, "")
+	if err := short.Parse([]string{"-c", "2"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Apply(short)
+	if *count != 2 {
+		t.Errorf("count = %d after explicit -c 2, want 2", *count)
+	}
+
+	var communities listFlag
+	snmp := flag.NewFlagSet("snmp", flag.ContinueOnError)
+	snmp.Var(&communities, "community", "")
+	if w := cfg.Apply(snmp); len(w) != 0 {
+		t.Errorf("unexpected warnings %q", w)
+	}
+	if strings.Join(communities, ",") != "a,b" {
+		t.Errorf("communities = %v, want [a b]", communities)
+	}
+
+	// A repeatable flag on the command line replaces the configured list
+	var cliCommunities listFlag
+	snmp = flag.NewFlagSet("snmp", flag.ContinueOnError)
+	snmp.Var(&cliCommunities, "community", "")
+	if err := snmp.Parse([]string{"--community", "c"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Apply(snmp)
+	if strings.Join(cliCommunities, ",") != "c" {
+		t.Errorf("communities = %v, want only [c] from the command line", cliCommunities)
+	}
+
+	// Commands without a section and a nil config are untouched
+	other := flag.NewFlagSet("dns", flag.ContinueOnError)
+	if w := cfg.Apply(other); len(w) != 0 {
+		t.Errorf("unexpected warnings %q", w)
+	}
+	var nilCfg *Config
+	if w := nilCfg.Apply(fs); w != nil {
+		t.Errorf("nil config warnings = %q", w)
+	}
+}
+
+func TestSplitFlag(t *testing.T) {
+	tests := []struct {
+		args    []string
+		rest    string
+		path    string
+		wantErr bool
+	}{
+		{[]string{"ping", "host"}, "ping host", "", false},
+		{[]string{"--config", "a.yaml", "ping"}, "ping", "a.yaml", false},
+		{[]string{"--json", "--config=b.yaml", "ping"}, "--json ping", "b.yaml", false},
+		// After the command name, --config belongs to the command
+		{[]string{"ping", "--config", "c.yaml"}, "ping --config c.yaml", "", false},
+		{[]string{"--config"}, "", "", true},
+	}
+	for _, tt := range tests {
+		rest, path, err := SplitFlag(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("SplitFlag(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if strings.Join(rest, " ") != tt.rest || path != tt.path {
+			t.Errorf("SplitFlag(%q) = %q, %q; want %q, %q", tt.args, rest, path, tt.rest, tt.path)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+
+	// A missing default file is not an error
+	cfg, err := Load("")
+	if err != nil || len(cfg.Commands) != 0 {
+		t.Fatalf("Load(\"\") = %v, %v; want empty config", cfg, err)
+	}
+
+	// A missing explicit file is
+	if _, err := Load(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("Load(missing) succeeded, want error")
+	}
+
+	path := filepath.Join(dir, FileName)
+	if err := os.WriteFile(path, []byte("ping:\n  count: 7\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = Load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Path != path || cfg.Commands["ping"]["count"][0] != "7" {
+		t.Errorf("Load = %+v", cfg)
+	}
+
+	if err := os.WriteFile(path, []byte("count: 7\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("Load of invalid file error = %v, want it to name the file", err)
+	}
+}
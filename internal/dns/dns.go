@@ -5,8 +5,12 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/netip"
 	"strings"
+	"sync"
 	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
 )
 
 // RecordType represents DNS record types.
@@ -85,6 +89,8 @@ type PropagationResult struct {
 type Resolver struct {
 	Server  string // e.g., "8.8.8.8:53" or empty for system default
 	Timeout time.Duration
+
+	ecs netip.Prefix // EDNS Client Subnet sent with queries, if valid
 }
 
 // NewResolver creates a new Resolver with default settings.
@@ -102,21 +108,134 @@ func (r *Resolver) SetServer(server string) {
 	r.Server = server
 }
 
+// SetECS attaches an EDNS Client Subnet option (RFC 7871) for subnet to
+// every query, so GeoDNS and CDN resolvers answer as they would for a
+// client in that network. A bare IP is truncated to a /24 (IPv4) or /56
+// (IPv6), as the RFC recommends; an empty subnet disables the option.
+func (r *Resolver) SetECS(subnet string) error {
+	if subnet == "" {
+		r.ecs = netip.Prefix{}
+		return nil
+	}
+
+	prefix, err := netip.ParsePrefix(subnet)
+	if err != nil {
+		addr, err := netip.ParseAddr(subnet)
+		if err != nil {
+			return fmt.Errorf("invalid client subnet %q (use a CIDR such as 203.0.113.0/24, or an IP)", subnet)
+		}
+		bits := 24
+		if addr.Is6() {
+			bits = 56
+		}
+		prefix = netip.PrefixFrom(addr, bits)
+	}
+	r.ecs = prefix.Masked()
+	return nil
+}
+
+// ECS returns the client subnet set by SetECS, or "" if none.
+func (r *Resolver) ECS() string {
+	if !r.ecs.IsValid() {
+		return ""
+	}
+	return r.ecs.String()
+}
+
 // getResolver returns a net.Resolver configured for custom server if set.
 func (r *Resolver) getResolver() *net.Resolver {
-	if r.Server == "" {
+	if r.Server == "" && !r.ecs.IsValid() {
 		return net.DefaultResolver
 	}
 
 	return &net.Resolver{
 		PreferGo: true,
 		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			server := r.Server
+			if server == "" {
+				server = address // System nameserver chosen by the resolver
+			}
 			d := net.Dialer{Timeout: r.Timeout}
-			return d.DialContext(ctx, "udp", r.Server)
+			conn, err := d.DialContext(ctx, "udp", server)
+			if err != nil || !r.ecs.IsValid() {
+				return conn, err
+			}
+			return &ecsConn{UDPConn: conn.(*net.UDPConn), option: ecsOption(r.ecs)}, nil
 		},
 	}
 }
 
+// ecsConn adds an EDNS Client Subnet option to each DNS query written to
+// it. It stays a net.PacketConn so the resolver keeps using UDP framing.
+type ecsConn struct {
+	*net.UDPConn
+	option dnsmessage.Option
+}
+
+// Write rewrites the query in b to carry the client subnet option.
+func (c *ecsConn) Write(b []byte) (int, error) {
+	msg, err := addOption(b, c.option)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := c.UDPConn.Write(msg); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// ecsOption encodes prefix as an EDNS0 Client Subnet option: address
+// family, source prefix length, scope prefix length (0 in queries), and
+// the address truncated to the prefix's whole bytes.
+func ecsOption(prefix netip.Prefix) dnsmessage.Option {
+	family := uint16(1)
+	if prefix.Addr().Is6() {
+		family = 2
+	}
+	addr := prefix.Addr().AsSlice()
+	data := []byte{byte(family >> 8), byte(family), byte(prefix.Bits()), 0}
+	data = append(data, addr[:(prefix.Bits()+7)/8]...)
+	return dnsmessage.Option{Code: ednsClientSubnet, Data: data}
+}
+
+// ednsClientSubnet is the EDNS0 option code for Client Subnet.
+const ednsClientSubnet = 8
+
+// addOption returns the DNS message msg with opt added to its OPT
+// pseudo-record, creating the OPT record in the additional section if the
+// query has none. An existing option with the same code is replaced.
+func addOption(msg []byte, opt dnsmessage.Option) ([]byte, error) {
+	var m dnsmessage.Message
+	if err := m.Unpack(msg); err != nil {
+		return nil, fmt.Errorf("parse query: %w", err)
+	}
+
+	for i, rr := range m.Additionals {
+		body, ok := rr.Body.(*dnsmessage.OPTResource)
+		if rr.Header.Type != dnsmessage.TypeOPT || !ok {
+			continue
+		}
+		options := body.Options[:0]
+		for _, o := range body.Options {
+			if o.Code != opt.Code {
+				options = append(options, o)
+			}
+		}
+		m.Additionals[i].Body = &dnsmessage.OPTResource{Options: append(options, opt)}
+		return m.Pack()
+	}
+
+	var hdr dnsmessage.ResourceHeader
+	if err := hdr.SetEDNS0(1232, dnsmessage.RCodeSuccess, false); err != nil {
+		return nil, err
+	}
+	m.Additionals = append(m.Additionals, dnsmessage.Resource{
+		Header: hdr,
+		Body:   &dnsmessage.OPTResource{Options: []dnsmessage.Option{opt}},
+	})
+	return m.Pack()
+}
+
 // Lookup performs a DNS lookup for the specified record type.
 func (r *Resolver) Lookup(ctx context.Context, name string, recordType RecordType) *Result {
 	result := &Result{
@@ -266,6 +385,69 @@ func (r *Resolver) lookupPTR(ctx context.Context, ip string) ([]Record, error) {
 	return records, nil
 }
 
+// maxReverseAddrs caps how many addresses ReverseCIDR will query.
+const maxReverseAddrs = 65536
+
+// reverseWorkers bounds concurrent PTR lookups in ReverseCIDR.
+const reverseWorkers = 32
+
+// ReverseCIDR performs PTR lookups for every address in cidr concurrently
+// and returns the addresses that have a PTR record, mapped to their first
+// hostname. Addresses without a record (NXDOMAIN) or whose lookup fails are
+// omitted. If ctx ends early, the names found so far are returned with its
+// error.
+func (r *Resolver) ReverseCIDR(ctx context.Context, cidr string) (map[string]string, error) {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR: %w", err)
+	}
+	prefix = prefix.Masked()
+	if hostBits := prefix.Addr().BitLen() - prefix.Bits(); hostBits > 16 {
+		return nil, fmt.Errorf("%s is too large (at most %d addresses)", prefix, maxReverseAddrs)
+	}
+
+	addrs := make(chan netip.Addr)
+	go func() {
+		defer close(addrs)
+		for a := prefix.Addr(); a.IsValid() && prefix.Contains(a); a = a.Next() {
+			select {
+			case addrs <- a:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	names := make(map[string]string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < reverseWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for a := range addrs {
+				lookupCtx, cancel := context.WithTimeout(ctx, timeout)
+				records, err := r.lookupPTR(lookupCtx, a.String())
+				cancel()
+				if err != nil || len(records) == 0 {
+					continue
+				}
+				mu.Lock()
+				names[a.String()] = records[0].Value
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return names, ctx.Err()
+}
+
 // ParseRecordType converts a string to RecordType.
 func ParseRecordType(s string) (RecordType, error) {
 	switch strings.ToUpper(s) {
@@ -2,8 +2,13 @@ package dns
 
 import (
 	"context"
+	"net"
+	"net/netip"
+	"reflect"
 	"testing"
 	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
 )
 
 func TestParseRecordType(t *testing.T) {
@@ -179,6 +184,199 @@ func TestLookupUnsupportedType(t *testing.T) {
 	}
 }
 
+// startFakeDNS serves PTR answers from ptr (reverse name -> hostname) over
+// UDP, answering NXDOMAIN for everything else, and returns its address.
+// Each query is passed to onQuery, if non-nil, before it is answered.
+func startFakeDNS(t *testing.T, ptr map[string]string, onQuery func(*dnsmessage.Message)) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var req dnsmessage.Message
+			if err := req.Unpack(buf[:n]); err != nil || len(req.Questions) == 0 {
+				continue
+			}
+			if onQuery != nil {
+				onQuery(&req)
+			}
+			q := req.Questions[0]
+			resp := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: req.ID, Response: true, RecursionAvailable: true, RCode: dnsmessage.RCodeNameError},
+				Questions: []dnsmessage.Question{q},
+			}
+			if host, ok := ptr[q.Name.String()]; ok && q.Type == dnsmessage.TypePTR {
+				resp.RCode = dnsmessage.RCodeSuccess
+				resp.Answers = []dnsmessage.Resource{{
+					Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET, TTL: 60},
+					Body:   &dnsmessage.PTRResource{PTR: dnsmessage.MustNewName(host)},
+				}}
+			}
+			out, err := resp.Pack()
+			if err != nil {
+				continue
+			}
+			conn.WriteTo(out, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestReverseCIDR(t *testing.T) {
+	server := startFakeDNS(t, map[string]string{
+		"1.2.0.192.in-addr.arpa.": "gw.example.com.",
+		"5.2.0.192.in-addr.arpa.": "web.example.com.",
+	}, nil)
+
+	r := NewResolver()
+	r.SetServer(server)
+	r.Timeout = 2 * time.Second
+
+	got, err := r.ReverseCIDR(context.Background(), "192.0.2.0/28")
+	if err != nil {
+		t.Fatalf("ReverseCIDR() error = %v", err)
+	}
+	want := map[string]string{
+		"192.0.2.1": "gw.example.com.",
+		"192.0.2.5": "web.example.com.",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReverseCIDR() = %v, want %v", got, want)
+	}
+}
+
+func TestReverseCIDRInvalid(t *testing.T) {
+	r := NewResolver()
+	for _, cidr := range []string{"not-a-cidr", "10.0.0.1", "10.0.0.0/8", "2001:db8::/64"} {
+		if _, err := r.ReverseCIDR(context.Background(), cidr); err == nil {
+			t.Errorf("ReverseCIDR(%q) succeeded, want error", cidr)
+		}
+	}
+}
+
+func TestSetECS(t *testing.T) {
+	tests := []struct {
+		subnet  string
+		want    string
+		wantErr bool
+	}{
+		{"203.0.113.0/24", "203.0.113.0/24", false},
+		{"203.0.113.77/24", "203.0.113.0/24", false},
+		{"203.0.113.77", "203.0.113.0/24", false},
+		{"2001:db8:1:2::1", "2001:db8:1::/56", false},
+		{"", "", false},
+		{"not-a-subnet", "", true},
+	}
+	for _, tt := range tests {
+		r := NewResolver()
+		err := r.SetECS(tt.subnet)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("SetECS(%q) error = %v, wantErr %v", tt.subnet, err, tt.wantErr)
+			continue
+		}
+		if got := r.ECS(); got != tt.want {
+			t.Errorf("SetECS(%q): ECS() = %q, want %q", tt.subnet, got, tt.want)
+		}
+	}
+}
+
+func TestECSOption(t *testing.T) {
+	opt := ecsOption(netip.MustParsePrefix("203.0.113.0/24"))
+	want := []byte{0, 1, 24, 0, 203, 0, 113}
+	if opt.Code != 8 || !reflect.DeepEqual(opt.Data, want) {
+		t.Errorf("ecsOption(/24) = code %d data %v, want code 8 data %v", opt.Code, opt.Data, want)
+	}
+
+	opt = ecsOption(netip.MustParsePrefix("2001:db8::/33"))
+	want = []byte{0, 2, 33, 0, 0x20, 0x01, 0x0d, 0xb8, 0}
+	if !reflect.DeepEqual(opt.Data, want) {
+		t.Errorf("ecsOption(/33) data = %v, want %v", opt.Data, want)
+	}
+}
+
+func TestLookupSendsECS(t *testing.T) {
+	seen := make(chan []byte, 4)
+	server := startFakeDNS(t, map[string]string{
+		"1.2.0.192.in-addr.arpa.": "gw.example.com.",
+	}, func(m *dnsmessage.Message) {
+		for _, rr := range m.Additionals {
+			if opt, ok := rr.Body.(*dnsmessage.OPTResource); ok {
+				for _, o := range opt.Options {
+					if o.Code == 8 {
+						seen <- o.Data
+					}
+				}
+			}
+		}
+	})
+
+	r := NewResolver()
+	r.SetServer(server)
+	if err := r.SetECS("198.51.100.0/24"); err != nil {
+		t.Fatal(err)
+	}
+
+	result := r.Lookup(context.Background(), "192.0.2.1", TypePTR)
+	if result.Error != nil || len(result.Records) != 1 {
+		t.Fatalf("Lookup() = %+v, %v", result.Records, result.Error)
+	}
+
+	select {
+	case data := <-seen:
+		if want := []byte{0, 1, 24, 0, 198, 51, 100}; !reflect.DeepEqual(data, want) {
+			t.Errorf("ECS option data = %v, want %v", data, want)
+		}
+	default:
+		t.Error("query carried no EDNS Client Subnet option")
+	}
+}
+
+func TestAddOptionReplaces(t *testing.T) {
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: 7, RecursionDesired: true})
+	if err := b.StartQuestions(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Question(dnsmessage.Question{Name: dnsmessage.MustNewName("example.com."), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}); err != nil {
+		t.Fatal(err)
+	}
+	query, err := b.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Adding twice keeps a single OPT record with a single ECS option
+	out, err := addOption(query, ecsOption(netip.MustParsePrefix("10.0.0.0/8")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err = addOption(out, ecsOption(netip.MustParsePrefix("192.0.2.0/24")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var m dnsmessage.Message
+	if err := m.Unpack(out); err != nil {
+		t.Fatal(err)
+	}
+	if m.ID != 7 || len(m.Questions) != 1 || len(m.Additionals) != 1 {
+		t.Fatalf("rewritten query: id %d, %d questions, %d additionals", m.ID, len(m.Questions), len(m.Additionals))
+	}
+	opts := m.Additionals[0].Body.(*dnsmessage.OPTResource).Options
+	if len(opts) != 1 || opts[0].Data[2] != 24 {
+		t.Errorf("OPT options = %+v, want one /24 ECS option", opts)
+	}
+}
+
 func BenchmarkLookupA(b *testing.B) {
 	r := NewResolver()
 	ctx := context.Background()
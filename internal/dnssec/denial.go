@@ -0,0 +1,519 @@
+package dnssec
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxNSEC3Iterations is the iteration count above which NSEC3 proofs are
+// treated as insecure rather than computed (RFC 9276 section 3.2).
+const maxNSEC3Iterations = 150
+
+// NSECRecord represents an NSEC record (RFC 4034 section 4).
+type NSECRecord struct {
+	Owner    string   `json:"owner"`
+	NextName string   `json:"next_name"`
+	Types    []string `json:"types"`
+}
+
+// NSEC3Record represents an NSEC3 record (RFC 5155 section 3).
+type NSEC3Record struct {
+	Owner         string   `json:"owner"` // Hashed owner name, <base32hex hash>.<zone>
+	HashAlgorithm uint8    `json:"hash_algorithm"`
+	OptOut        bool     `json:"opt_out"`
+	Iterations    uint16   `json:"iterations"`
+	Salt          string   `json:"salt"`        // Hex encoded, empty for no salt
+	NextHashed    string   `json:"next_hashed"` // Base32hex hash of the next owner name
+	Types         []string `json:"types"`
+}
+
+// DenialProof holds the NSEC or NSEC3 records a zone returned to prove that
+// a name, or a record type at a name, does not exist.
+type DenialProof struct {
+	Name     string        `json:"name"`
+	QType    string        `json:"qtype"`    // Record type that was queried
+	Type     RecordType    `json:"type"`     // TypeNSEC or TypeNSEC3, empty if no proof was returned
+	NXDomain bool          `json:"nxdomain"` // The whole name is absent, not just QType
+	OptOut   bool          `json:"opt_out"`  // Name falls in an NSEC3 opt-out span (unsigned delegation)
+	NSEC     []NSECRecord  `json:"nsec,omitempty"`
+	NSEC3    []NSEC3Record `json:"nsec3,omitempty"`
+	RRSIGs   []RRSIGRecord `json:"rrsigs"`
+	Verified bool          `json:"verified"`        // Records are signed by the zone and prove the absence
+	Error    string        `json:"error,omitempty"` // Why the proof failed, if it did
+}
+
+// proveDenial checks the NSEC or NSEC3 records in l, signed with the keys
+// of the zone that answered, against the absence of name/qtype.
+func proveDenial(name string, qtype uint16, l rrsetLookup, keys []DNSKEYRecord) *DenialProof {
+	proof := &DenialProof{Name: zoneName(name), QType: typeName(qtype), NXDomain: l.nxdomain}
+	if err := proof.verify(fqdn(strings.ToLower(name)), qtype, l.denial, keys); err != nil {
+		proof.Error = err.Error()
+		if errors.Is(err, errUnsupportedAlgorithm) {
+			proof.Error = "unsupported algorithm"
+		}
+		return proof
+	}
+	proof.Verified = true
+	return proof
+}
+
+func (p *DenialProof) verify(name string, qtype uint16, records []rr, keys []DNSKEYRecord) error {
+	sigs := make(map[string][]RRSIGRecord)
+	for _, r := range records {
+		if r.Type != typeRRSIG {
+			continue
+		}
+		if sig, err := parseRRSIG(r.Data); err == nil && (sig.TypeCovered == "NSEC" || sig.TypeCovered == "NSEC3") {
+			sigs[r.Name] = append(sigs[r.Name], sig)
+		}
+	}
+
+	var nsec, nsec3 []rr
+	for _, r := range records {
+		switch r.Type {
+		case typeNSEC:
+			nsec = append(nsec, r)
+		case typeNSEC3:
+			nsec3 = append(nsec3, r)
+		}
+	}
+	if len(nsec) == 0 && len(nsec3) == 0 {
+		return fmt.Errorf("no NSEC or NSEC3 records in response")
+	}
+
+	// Every record must carry a valid signature from the zone
+	for _, r := range append(nsec, nsec3...) {
+		owned := sigs[r.Name]
+		err := verifyRRset(owned, []rr{r}, keys)
+		p.RRSIGs = append(p.RRSIGs, owned...)
+		if err != nil {
+			return fmt.Errorf("%s %s: %w", typeName(r.Type), r.Name, err)
+		}
+	}
+
+	if len(nsec) > 0 {
+		p.Type = TypeNSEC
+		for _, r := range nsec {
+			rec, err := parseNSEC(r.Name, r.Data)
+			if err != nil {
+				return err
+			}
+			p.NSEC = append(p.NSEC, rec)
+		}
+		if p.NXDomain {
+			return proveNSECNameError(name, p.NSEC)
+		}
+		return proveNSECNoData(name, qtype, p.NSEC)
+	}
+
+	p.Type = TypeNSEC3
+	for _, r := range nsec3 {
+		rec, err := parseNSEC3(r.Name, r.Data)
+		if err != nil {
+			return err
+		}
+		if rec.HashAlgorithm != 1 {
+			return fmt.Errorf("%w: NSEC3 hash algorithm %d", errUnsupportedAlgorithm, rec.HashAlgorithm)
+		}
+		if rec.Iterations > maxNSEC3Iterations {
+			return fmt.Errorf("%w: %d NSEC3 iterations", errUnsupportedAlgorithm, rec.Iterations)
+		}
+		p.NSEC3 = append(p.NSEC3, rec)
+	}
+	if p.NXDomain {
+		return proveNSEC3NameError(name, p.NSEC3)
+	}
+	optOut, err := proveNSEC3NoData(name, qtype, p.NSEC3)
+	p.OptOut = optOut
+	return err
+}
+
+// proveNSECNoData checks for an NSEC at name whose type bitmap lacks qtype
+// (RFC 4035 section 5.4).
+func proveNSECNoData(name string, qtype uint16, records []NSECRecord) error {
+	for _, rec := range records {
+		if fqdn(rec.Owner) != name {
+			continue
+		}
+		if hasType(rec.Types, typeName(qtype)) || hasType(rec.Types, "CNAME") {
+			return fmt.Errorf("NSEC at %s lists %s", rec.Owner, typeName(qtype))
+		}
+		// A DS denial must come from the parent side of the delegation
+		if qtype == typeDS && hasType(rec.Types, "SOA") {
+			return fmt.Errorf("NSEC at %s is from the child zone", rec.Owner)
+		}
+		return nil
+	}
+	return fmt.Errorf("no NSEC record at %s", name)
+}
+
+// proveNSECNameError checks for an NSEC covering name and one proving there
+// is no wildcard at its closest encloser (RFC 4035 section 5.4).
+func proveNSECNameError(name string, records []NSECRecord) error {
+	var covering *NSECRecord
+	for i := range records {
+		if nsecCovers(records[i], name) {
+			covering = &records[i]
+			break
+		}
+	}
+	if covering == nil {
+		return fmt.Errorf("no NSEC record covers %s", name)
+	}
+
+	encloser := commonAncestor(name, fqdn(covering.Owner))
+	if next := commonAncestor(name, fqdn(covering.NextName)); labelCount(next) > labelCount(encloser) {
+		encloser = next
+	}
+	wildcard := wildcardAt(encloser)
+	for _, rec := range records {
+		if nsecCovers(rec, wildcard) {
+			return nil
+		}
+	}
+	return fmt.Errorf("no NSEC record denies wildcard %s", wildcard)
+}
+
+// nsecCovers reports whether name falls strictly between the owner and
+// next name of rec, allowing for the last NSEC wrapping to the apex.
+func nsecCovers(rec NSECRecord, name string) bool {
+	owner, next := fqdn(rec.Owner), fqdn(rec.NextName)
+	if canonicalCompare(owner, next) < 0 {
+		return canonicalCompare(owner, name) < 0 && canonicalCompare(name, next) < 0
+	}
+	return canonicalCompare(owner, name) < 0 || canonicalCompare(name, next) < 0
+}
+
+// proveNSEC3NoData checks for an NSEC3 matching name whose bitmap lacks
+// qtype. For DS, a covering opt-out NSEC3 at the next closer name also
+// proves an unsigned delegation (RFC 5155 section 8.6) and reports true.
+func proveNSEC3NoData(name string, qtype uint16, records []NSEC3Record) (bool, error) {
+	if rec, ok := nsec3Match(records, name); ok {
+		if hasType(rec.Types, typeName(qtype)) || hasType(rec.Types, "CNAME") {
+			return false, fmt.Errorf("NSEC3 for %s lists %s", name, typeName(qtype))
+		}
+		if qtype == typeDS && hasType(rec.Types, "SOA") {
+			return false, fmt.Errorf("NSEC3 for %s is from the child zone", name)
+		}
+		return false, nil
+	}
+	if qtype != typeDS {
+		return false, fmt.Errorf("no NSEC3 record matches %s", name)
+	}
+
+	_, nextCloser, err := closestEncloser(name, records)
+	if err != nil {
+		return false, err
+	}
+	rec, _ := nsec3Covering(records, nextCloser)
+	if !rec.OptOut {
+		return false, fmt.Errorf("NSEC3 covering %s does not have opt-out set", nextCloser)
+	}
+	return true, nil
+}
+
+// proveNSEC3NameError checks the closest encloser proof for name and that
+// no wildcard exists at the closest encloser (RFC 5155 section 8.4).
+func proveNSEC3NameError(name string, records []NSEC3Record) error {
+	encloser, _, err := closestEncloser(name, records)
+	if err != nil {
+		return err
+	}
+	wildcard := wildcardAt(encloser)
+	if _, ok := nsec3Covering(records, wildcard); !ok {
+		return fmt.Errorf("no NSEC3 record denies wildcard %s", wildcard)
+	}
+	return nil
+}
+
+// closestEncloser finds the nearest ancestor of name with a matching NSEC3
+// and checks that the next closer name is covered (RFC 5155 section 8.3).
+func closestEncloser(name string, records []NSEC3Record) (encloser, nextCloser string, err error) {
+	nextCloser = name
+	for candidate := parentName(name); ; candidate = parentName(candidate) {
+		if _, ok := nsec3Match(records, candidate); ok {
+			if _, ok := nsec3Covering(records, nextCloser); !ok {
+				return "", "", fmt.Errorf("no NSEC3 record covers %s", nextCloser)
+			}
+			return candidate, nextCloser, nil
+		}
+		if candidate == "." {
+			return "", "", fmt.Errorf("no closest encloser proof for %s", name)
+		}
+		nextCloser = candidate
+	}
+}
+
+// nsec3Match returns the record whose owner hash equals the hash of name.
+func nsec3Match(records []NSEC3Record, name string) (NSEC3Record, bool) {
+	for _, rec := range records {
+		if !inZone(name, nsec3Zone(rec)) {
+			continue
+		}
+		if strings.EqualFold(nsec3OwnerHash(rec), nsec3Hash(name, rec)) {
+			return rec, true
+		}
+	}
+	return NSEC3Record{}, false
+}
+
+// nsec3Covering returns the record whose hash span strictly contains the
+// hash of name.
+func nsec3Covering(records []NSEC3Record, name string) (NSEC3Record, bool) {
+	for _, rec := range records {
+		if !inZone(name, nsec3Zone(rec)) {
+			continue
+		}
+		h := nsec3Hash(name, rec)
+		owner, next := strings.ToLower(nsec3OwnerHash(rec)), strings.ToLower(rec.NextHashed)
+		if owner < next {
+			if owner < h && h < next {
+				return rec, true
+			}
+		} else if owner < h || h < next {
+			return rec, true
+		}
+	}
+	return NSEC3Record{}, false
+}
+
+// nsec3Hash hashes name with the parameters of rec and returns it in
+// lower-case base32hex without padding (RFC 5155 section 5).
+func nsec3Hash(name string, rec NSEC3Record) string {
+	salt, _ := hex.DecodeString(rec.Salt)
+	h := sha1.Sum(append(nameWire(name), salt...))
+	for i := 0; i < int(rec.Iterations); i++ {
+		h = sha1.Sum(append(h[:], salt...))
+	}
+	return strings.ToLower(base32.HexEncoding.WithPadding(base32.NoPadding).EncodeToString(h[:]))
+}
+
+func nsec3OwnerHash(rec NSEC3Record) string {
+	label, _, _ := strings.Cut(rec.Owner, ".")
+	return label
+}
+
+func nsec3Zone(rec NSEC3Record) string {
+	_, zone, _ := strings.Cut(fqdn(rec.Owner), ".")
+	return fqdn(zone)
+}
+
+// parseNSEC decodes NSEC RDATA (RFC 4034 section 4.1).
+func parseNSEC(owner string, data []byte) (NSECRecord, error) {
+	next, off, err := parseWireName(data, 0)
+	if err != nil {
+		return NSECRecord{}, fmt.Errorf("invalid NSEC next name: %w", err)
+	}
+	types, err := parseTypeBitmap(data[off:])
+	if err != nil {
+		return NSECRecord{}, err
+	}
+	return NSECRecord{Owner: zoneName(owner), NextName: zoneName(strings.ToLower(next)), Types: types}, nil
+}
+
+// parseNSEC3 decodes NSEC3 RDATA (RFC 5155 section 3.2).
+func parseNSEC3(owner string, data []byte) (NSEC3Record, error) {
+	if len(data) < 5 {
+		return NSEC3Record{}, fmt.Errorf("NSEC3 RDATA too short")
+	}
+	saltEnd := 5 + int(data[4])
+	if saltEnd >= len(data) {
+		return NSEC3Record{}, fmt.Errorf("NSEC3 salt overruns RDATA")
+	}
+	hashEnd := saltEnd + 1 + int(data[saltEnd])
+	if hashEnd > len(data) {
+		return NSEC3Record{}, fmt.Errorf("NSEC3 hash overruns RDATA")
+	}
+	types, err := parseTypeBitmap(data[hashEnd:])
+	if err != nil {
+		return NSEC3Record{}, err
+	}
+	return NSEC3Record{
+		Owner:         zoneName(owner),
+		HashAlgorithm: data[0],
+		OptOut:        data[1]&0x01 != 0,
+		Iterations:    binary.BigEndian.Uint16(data[2:4]),
+		Salt:          strings.ToUpper(hex.EncodeToString(data[5:saltEnd])),
+		NextHashed:    strings.ToLower(base32.HexEncoding.WithPadding(base32.NoPadding).EncodeToString(data[saltEnd+1 : hashEnd])),
+		Types:         types,
+	}, nil
+}
+
+// parseTypeBitmap decodes the type bit maps field shared by NSEC and NSEC3
+// (RFC 4034 section 4.1.2).
+func parseTypeBitmap(data []byte) ([]string, error) {
+	var types []string
+	for len(data) > 0 {
+		if len(data) < 2 || int(data[1]) == 0 || int(data[1]) > 32 || len(data) < 2+int(data[1]) {
+			return nil, fmt.Errorf("invalid type bitmap")
+		}
+		window, bitmap := uint16(data[0]), data[2:2+int(data[1])]
+		for i, b := range bitmap {
+			for bit := 0; bit < 8; bit++ {
+				if b&(0x80>>bit) != 0 {
+					types = append(types, typeName(window<<8|uint16(i*8+bit)))
+				}
+			}
+		}
+		data = data[2+int(data[1]):]
+	}
+	return types, nil
+}
+
+// canonicalCompare orders names as RFC 4034 section 6.1 does: label by
+// label from the root, comparing lower-cased labels as byte strings.
+func canonicalCompare(a, b string) int {
+	la, lb := nameLabels(a), nameLabels(b)
+	for i := 1; i <= len(la) && i <= len(lb); i++ {
+		if c := bytes.Compare([]byte(la[len(la)-i]), []byte(lb[len(lb)-i])); c != 0 {
+			return c
+		}
+	}
+	return len(la) - len(lb)
+}
+
+func nameLabels(name string) []string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if name == "" {
+		return nil
+	}
+	return strings.Split(name, ".")
+}
+
+// commonAncestor returns the longest name that is an ancestor of (or equal
+// to) both a and b.
+func commonAncestor(a, b string) string {
+	la, lb := nameLabels(a), nameLabels(b)
+	n := 0
+	for n < len(la) && n < len(lb) && la[len(la)-1-n] == lb[len(lb)-1-n] {
+		n++
+	}
+	if n == 0 {
+		return "."
+	}
+	return strings.Join(la[len(la)-n:], ".") + "."
+}
+
+// wildcardAt returns the wildcard name directly below encloser.
+func wildcardAt(encloser string) string {
+	if encloser == "." {
+		return "*."
+	}
+	return "*." + encloser
+}
+
+// parentName strips the first label from a fully qualified name.
+func parentName(name string) string {
+	_, parent, _ := strings.Cut(strings.TrimSuffix(name, "."), ".")
+	return fqdn(parent)
+}
+
+// inZone reports whether name is zone or below it.
+func inZone(name, zone string) bool {
+	name, zone = strings.ToLower(fqdn(name)), strings.ToLower(fqdn(zone))
+	return zone == "." || name == zone || strings.HasSuffix(name, "."+zone)
+}
+
+func hasType(types []string, t string) bool {
+	for _, have := range types {
+		if have == t {
+			return true
+		}
+	}
+	return false
+}
+
+// EnumerateNames walks the NSEC chain of zone from its apex and returns
+// the owner names it links, in canonical order. Zones that deny existence
+// with NSEC3 hide their names behind hashes and return an error.
+func (v *Validator) EnumerateNames(ctx context.Context, zone string) ([]string, error) {
+	apex := strings.ToLower(fqdn(zone))
+	names := []string{zoneName(apex)}
+	seen := map[string]bool{apex: true}
+
+	for owner := apex; ; {
+		if err := ctx.Err(); err != nil {
+			return names, err
+		}
+		next, err := v.nextName(ctx, apex, owner)
+		if err != nil {
+			return names, err
+		}
+		if seen[next] {
+			return names, nil // back at the apex
+		}
+		if !inZone(next, apex) {
+			return names, fmt.Errorf("NSEC at %s points outside the zone to %s", zoneName(owner), zoneName(next))
+		}
+		seen[next] = true
+		names = append(names, zoneName(next))
+		owner = next
+	}
+}
+
+// nextName returns the next owner name after owner in the NSEC chain of
+// apex. The NSEC at owner is queried directly; at a delegation point that
+// query reaches the child zone, so the parent's NSEC is found instead by
+// asking for a name that sorts just after owner and everything below it.
+func (v *Validator) nextName(ctx context.Context, apex, owner string) (string, error) {
+	l, err := v.lookupRRset(ctx, owner, typeNSEC)
+	if err != nil {
+		return "", err
+	}
+	if len(l.records) > 0 && signedIn(l.sigs, apex) {
+		rec, err := parseNSEC(owner, l.records[0].Data)
+		if err != nil {
+			return "", err
+		}
+		return fqdn(rec.NextName), nil
+	}
+
+	if owner == apex {
+		return "", walkError(l.denial, apex)
+	}
+	label, rest, _ := strings.Cut(owner, ".")
+	if len(label) >= 63 {
+		return "", fmt.Errorf("cannot step past %s", zoneName(owner))
+	}
+	after, err := v.lookupRRset(ctx, label+"\x00."+rest, typeNSEC)
+	if err != nil {
+		return "", err
+	}
+	for _, r := range after.denial {
+		if r.Type == typeNSEC && r.Name == owner {
+			rec, err := parseNSEC(owner, r.Data)
+			if err != nil {
+				return "", err
+			}
+			return fqdn(rec.NextName), nil
+		}
+	}
+	return "", walkError(after.denial, apex)
+}
+
+func walkError(denial []rr, apex string) error {
+	for _, r := range denial {
+		if r.Type == typeNSEC3 {
+			return fmt.Errorf("zone %s uses NSEC3; names cannot be enumerated", zoneName(apex))
+		}
+	}
+	return fmt.Errorf("no NSEC record found in zone %s", zoneName(apex))
+}
+
+// signedIn reports whether sigs is empty or was made by zone, telling the
+// parent-side NSEC at a delegation apart from the child's apex NSEC.
+func signedIn(sigs []RRSIGRecord, zone string) bool {
+	for _, sig := range sigs {
+		if fqdn(strings.ToLower(sig.SignerName)) != zone {
+			return false
+		}
+	}
+	return true
+}
@@ -3,11 +3,15 @@ package dnssec
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"sort"
 	"strings"
 	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
 )
 
 // ValidationStatus represents DNSSEC validation result.
@@ -83,76 +87,81 @@ var DigestNames = map[DigestType]string{
 
 // DNSKEYRecord represents a DNSKEY record.
 type DNSKEYRecord struct {
-	Domain    string
-	Flags     uint16
-	Protocol  uint8
-	Algorithm Algorithm
-	PublicKey string
-	KeyTag    uint16
-	IsKSK     bool // Key Signing Key
-	IsZSK     bool // Zone Signing Key
-	IsSEP     bool // Secure Entry Point
+	Domain    string    `json:"domain"`
+	Flags     uint16    `json:"flags"`
+	Protocol  uint8     `json:"protocol"`
+	Algorithm Algorithm `json:"algorithm"`
+	PublicKey string    `json:"public_key"`
+	KeyTag    uint16    `json:"key_tag"`
+	IsKSK     bool      `json:"is_ksk"` // Key Signing Key
+	IsZSK     bool      `json:"is_zsk"` // Zone Signing Key
+	IsSEP     bool      `json:"is_sep"` // Secure Entry Point
 }
 
 // DSRecord represents a DS (Delegation Signer) record.
 type DSRecord struct {
-	Domain     string
-	KeyTag     uint16
-	Algorithm  Algorithm
-	DigestType DigestType
-	Digest     string
+	Domain     string     `json:"domain"`
+	KeyTag     uint16     `json:"key_tag"`
+	Algorithm  Algorithm  `json:"algorithm"`
+	DigestType DigestType `json:"digest_type"`
+	Digest     string     `json:"digest"`
+	Matched    bool       `json:"matched"` // Digest matches a DNSKEY of the child zone
 }
 
 // RRSIGRecord represents an RRSIG signature record.
 type RRSIGRecord struct {
-	TypeCovered string
-	Algorithm   Algorithm
-	Labels      uint8
-	OriginalTTL uint32
-	Expiration  time.Time
-	Inception   time.Time
-	KeyTag      uint16
-	SignerName  string
-	Signature   string
+	TypeCovered string    `json:"type_covered"`
+	Algorithm   Algorithm `json:"algorithm"`
+	Labels      uint8     `json:"labels"`
+	OriginalTTL uint32    `json:"original_ttl"`
+	Expiration  time.Time `json:"expiration"`
+	Inception   time.Time `json:"inception"`
+	KeyTag      uint16    `json:"key_tag"`
+	SignerName  string    `json:"signer_name"`
+	Signature   string    `json:"signature"`
+	Verified    bool      `json:"verified"` // Signature checked cryptographically against its DNSKEY
 }
 
 // ChainLink represents one link in the DNSSEC chain of trust.
 type ChainLink struct {
-	Zone       string
-	Parent     string
-	DSRecords  []DSRecord
-	DNSKEYs    []DNSKEYRecord
-	RRSIGs     []RRSIGRecord
-	Status     ValidationStatus
-	Issues     []string
-	LookupTime time.Duration
+	Zone       string           `json:"zone"`
+	Parent     string           `json:"parent,omitempty"`
+	DSRecords  []DSRecord       `json:"ds_records"`
+	DNSKEYs    []DNSKEYRecord   `json:"dnskeys"`
+	RRSIGs     []RRSIGRecord    `json:"rrsigs"`
+	Denial     *DenialProof     `json:"denial,omitempty"` // Proof that the parent has no DS for Zone
+	Status     ValidationStatus `json:"status"`
+	Issues     []string         `json:"issues,omitempty"`
+	LookupTime time.Duration    `json:"lookup_time"`
 }
 
 // ValidationResult contains complete DNSSEC validation results.
 type ValidationResult struct {
-	Domain        string
-	Status        ValidationStatus
-	Chain         []ChainLink
-	Issues        []Issue
-	Score         int    // 0-100
-	Grade         string // A+, A, B, C, D, F
-	HasDNSSEC     bool
-	IsFullySecure bool
-	Algorithms    []Algorithm
-	KeyCount      int
-	ExpiringSigs  int
-	ExpiredSigs   int
-	TotalTime     time.Duration
-	Timestamp     time.Time
+	Domain        string           `json:"domain"`
+	Status        ValidationStatus `json:"status"`
+	Chain         []ChainLink      `json:"chain"`
+	Issues        []Issue          `json:"issues"`
+	Score         int              `json:"score"` // 0-100
+	Grade         string           `json:"grade"` // A+, A, B, C, D, F
+	HasDNSSEC     bool             `json:"has_dnssec"`
+	IsFullySecure bool             `json:"is_fully_secure"`
+	Algorithms    []Algorithm      `json:"algorithms"`
+	KeyCount      int              `json:"key_count"`
+	ExpiringSigs  int              `json:"expiring_sigs"`
+	ExpiredSigs   int              `json:"expired_sigs"`
+	NXDomain      bool             `json:"nxdomain"`         // Domain does not exist
+	Denial        *DenialProof     `json:"denial,omitempty"` // Proof that Domain does not exist, if signed
+	TotalTime     time.Duration    `json:"total_time"`
+	Timestamp     time.Time        `json:"timestamp"`
 }
 
 // Issue represents a DNSSEC configuration issue.
 type Issue struct {
-	Severity    string // critical, high, medium, low, info
-	Zone        string
-	Title       string
-	Description string
-	Remediation string
+	Severity    string `json:"severity"` // critical, high, medium, low, info
+	Zone        string `json:"zone,omitempty"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Remediation string `json:"remediation,omitempty"`
 }
 
 // Options configures DNSSEC validation.
@@ -162,6 +171,7 @@ type Options struct {
 	CheckExpiry   bool
 	ExpiryWarning time.Duration // Warn if signature expires within this time
 	Verbose       bool
+	TrustAnchors  []DSRecord // Root zone trust anchors (default: RootTrustAnchors)
 }
 
 // DefaultOptions returns sensible defaults.
@@ -177,7 +187,7 @@ func DefaultOptions() Options {
 // Validator performs DNSSEC validation.
 type Validator struct {
 	opts     Options
-	resolver *net.Resolver
+	resolver *dnsClient
 }
 
 // NewValidator creates a new DNSSEC validator.
@@ -188,16 +198,17 @@ func NewValidator(opts Options) *Validator {
 	if opts.Resolver == "" {
 		opts.Resolver = "8.8.8.8:53"
 	}
+	if len(opts.TrustAnchors) == 0 {
+		opts.TrustAnchors = RootTrustAnchors
+	}
+
+	if _, _, err := net.SplitHostPort(opts.Resolver); err != nil {
+		opts.Resolver = net.JoinHostPort(opts.Resolver, "53")
+	}
 
 	return &Validator{
-		opts: opts,
-		resolver: &net.Resolver{
-			PreferGo: true,
-			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
-				d := net.Dialer{Timeout: opts.Timeout}
-				return d.DialContext(ctx, "udp", opts.Resolver)
-			},
-		},
+		opts:     opts,
+		resolver: &dnsClient{addr: opts.Resolver, timeout: opts.Timeout},
 	}
 }
 
@@ -216,14 +227,22 @@ func (v *Validator) Validate(ctx context.Context, domain string) (*ValidationRes
 	zones := v.getZoneHierarchy(domain)
 
 	// Check each zone in the chain
-	for i, zone := range zones {
-		parent := ""
-		if i > 0 {
-			parent = zones[i-1]
+	var parentKeys []DNSKEYRecord
+	parent := ""
+	for _, zone := range zones {
+		link, isZone := v.checkZone(ctx, zone, parent, parentKeys)
+		if !isZone {
+			if link.Denial != nil && link.Denial.NXDomain {
+				result.NXDomain = true
+				if len(parentKeys) > 0 {
+					result.Denial = link.Denial
+				}
+				break // Nothing exists below a missing name
+			}
+			continue // Not a zone cut; records belong to the parent zone
 		}
-
-		link := v.checkZone(ctx, zone, parent)
 		result.Chain = append(result.Chain, link)
+		parent, parentKeys = zone, link.DNSKEYs
 
 		// Collect DNSKEY info
 		for _, key := range link.DNSKEYs {
@@ -268,27 +287,86 @@ func (v *Validator) getZoneHierarchy(domain string) []string {
 	return zones
 }
 
-func (v *Validator) checkZone(ctx context.Context, zone, parent string) ChainLink {
+// checkZone fetches and verifies the DNSKEY RRset of zone and the DS RRset
+// for it in parent, whose keys are parentKeys. It reports false if zone is
+// not a zone cut (no DNSKEY, DS or SOA at that name).
+func (v *Validator) checkZone(ctx context.Context, zone, parent string, parentKeys []DNSKEYRecord) (link ChainLink, isZone bool) {
 	start := time.Now()
-	link := ChainLink{
+	link = ChainLink{
 		Zone:   zone,
 		Parent: parent,
 		Status: StatusIndeterminate,
 	}
+	defer func() { link.LookupTime = time.Since(start) }()
 
-	// Simulate DNSKEY lookup
-	link.DNSKEYs = v.lookupDNSKEYs(ctx, zone)
+	dnskeys, err := v.lookupRRset(ctx, zone, typeDNSKEY)
+	if err != nil {
+		link.Issues = append(link.Issues, fmt.Sprintf("DNSKEY lookup failed: %v", err))
+		return link, true
+	}
+	if dnskeys.nxdomain {
+		link.Denial = proveDenial(zone, typeDNSKEY, dnskeys, parentKeys)
+		return link, false
+	}
+	for _, r := range dnskeys.records {
+		if key, err := parseDNSKEY(r.Name, r.Data); err == nil {
+			link.DNSKEYs = append(link.DNSKEYs, key)
+		}
+	}
 
-	// Simulate DS lookup from parent
+	var ds rrsetLookup
 	if parent != "" {
-		link.DSRecords = v.lookupDS(ctx, zone)
+		if ds, err = v.lookupRRset(ctx, zone, typeDS); err != nil {
+			link.Issues = append(link.Issues, fmt.Sprintf("DS lookup failed: %v", err))
+			return link, true
+		}
+		for _, r := range ds.records {
+			if rec, err := parseDS(r.Name, r.Data); err == nil {
+				link.DSRecords = append(link.DSRecords, rec)
+			}
+		}
+	}
+
+	if len(link.DNSKEYs) == 0 && len(link.DSRecords) == 0 && zone != "." {
+		if !v.isZoneApex(ctx, zone) {
+			return link, false
+		}
 	}
 
-	// Simulate RRSIG lookup
-	link.RRSIGs = v.lookupRRSIGs(ctx, zone)
+	// A signed parent must prove that it has no DS for the zone
+	if len(link.DSRecords) == 0 && len(parentKeys) > 0 {
+		link.Denial = proveDenial(zone, typeDS, ds, parentKeys)
+		if !link.Denial.Verified {
+			link.addVerifyFailure("DS denial of existence", errors.New(link.Denial.Error))
+		}
+	}
 
-	// Validate the zone
+	// Verify signatures: DNSKEY is self-signed, DS is signed by the parent
 	if len(link.DNSKEYs) > 0 {
+		if err := verifyRRset(dnskeys.sigs, dnskeys.records, link.DNSKEYs); err != nil {
+			link.addVerifyFailure("DNSKEY signature verification", err)
+		}
+		// Nothing above the root vouches for its keys; pin them instead
+		if zone == "." {
+			if err := matchAnchors(v.opts.TrustAnchors, link.DNSKEYs, dnskeys.sigs); err != nil {
+				link.addVerifyFailure("Root trust anchor check", err)
+			}
+		}
+	}
+	if len(link.DSRecords) > 0 {
+		if err := verifyRRset(ds.sigs, ds.records, parentKeys); err != nil {
+			link.addVerifyFailure("DS signature verification", err)
+		}
+	}
+	if len(link.DSRecords) > 0 && len(link.DNSKEYs) > 0 {
+		if err := matchDS(link.DSRecords, link.DNSKEYs, dnskeys.sigs); err != nil {
+			link.addVerifyFailure("DS digest check", err)
+		}
+	}
+	link.RRSIGs = append(dnskeys.sigs, ds.sigs...)
+
+	// Validate the zone
+	if len(link.DNSKEYs) > 0 && link.Status == StatusIndeterminate {
 		link.Status = StatusSecure
 
 		// Check for KSK/ZSK
@@ -315,82 +393,87 @@ func (v *Validator) checkZone(ctx context.Context, zone, parent string) ChainLin
 			link.Issues = append(link.Issues, "No DS record in parent zone")
 			link.Status = StatusInsecure
 		}
-	} else {
-		if zone != "." {
+	} else if len(link.DNSKEYs) == 0 && zone != "." {
+		if link.Status != StatusBogus {
 			link.Status = StatusInsecure
 		}
+		if len(link.DSRecords) > 0 {
+			link.Issues = append(link.Issues, "DS record in parent but zone has no DNSKEY")
+			link.Status = StatusBogus
+		}
 	}
 
-	link.LookupTime = time.Since(start)
-	return link
+	return link, true
 }
 
-// Simulated lookups - in production these would do actual DNS queries
-func (v *Validator) lookupDNSKEYs(ctx context.Context, zone string) []DNSKEYRecord {
-	// Common TLDs and domains have DNSSEC
-	secureZones := map[string]bool{
-		".":   true,
-		"com": true,
-		"org": true,
-		"net": true,
-		"gov": true,
-		"edu": true,
-	}
-
-	if !secureZones[zone] && !strings.HasSuffix(zone, ".gov") {
-		// Check if domain might have DNSSEC (simulation)
-		// In reality, we'd do actual DNS lookups
-		return nil
-	}
-
-	// Return simulated DNSKEY records
-	return []DNSKEYRecord{
-		{
-			Domain:    zone,
-			Flags:     257, // KSK
-			Protocol:  3,
-			Algorithm: AlgRSASHA256,
-			KeyTag:    generateKeyTag(zone, true),
-			IsKSK:     true,
-			IsSEP:     true,
-		},
-		{
-			Domain:    zone,
-			Flags:     256, // ZSK
-			Protocol:  3,
-			Algorithm: AlgRSASHA256,
-			KeyTag:    generateKeyTag(zone, false),
-			IsZSK:     true,
-		},
-	}
+// rrsetLookup is an RRset fetched from the resolver together with the
+// RRSIGs covering it.
+type rrsetLookup struct {
+	records  []rr
+	sigs     []RRSIGRecord
+	nxdomain bool
+	denial   []rr // NSEC, NSEC3 and their RRSIGs from the authority section
 }
 
-func (v *Validator) lookupDS(ctx context.Context, zone string) []DSRecord {
-	// Simulated DS record lookup
-	return []DSRecord{
-		{
-			Domain:     zone,
-			KeyTag:     generateKeyTag(zone, true),
-			Algorithm:  AlgRSASHA256,
-			DigestType: DigestSHA256,
-		},
+// lookupRRset queries name/qtype and collects the matching answer records
+// and their signatures. A name without records of that type, or without
+// any records at all (NXDOMAIN), returns an empty lookup carrying the
+// denial of existence records; other failures return an error.
+func (v *Validator) lookupRRset(ctx context.Context, name string, qtype uint16) (rrsetLookup, error) {
+	var l rrsetLookup
+	resp, err := v.resolver.query(ctx, name, qtype)
+	if err != nil {
+		return l, err
+	}
+	l.nxdomain = resp.RCode == dnsmessage.RCodeNameError
+	if resp.RCode != dnsmessage.RCodeSuccess && !l.nxdomain {
+		return l, fmt.Errorf("%s %s: %s", name, typeName(qtype), resp.RCode)
+	}
+
+	owner := strings.ToLower(fqdn(name))
+	for _, r := range resp.Answer {
+		if r.Name != owner {
+			continue
+		}
+		switch r.Type {
+		case qtype:
+			l.records = append(l.records, r)
+		case typeRRSIG:
+			if sig, err := parseRRSIG(r.Data); err == nil && sig.TypeCovered == typeName(qtype) {
+				l.sigs = append(l.sigs, sig)
+			}
+		}
 	}
+	if len(l.records) == 0 {
+		for _, r := range resp.Authority {
+			if r.Type == typeNSEC || r.Type == typeNSEC3 || r.Type == typeRRSIG {
+				l.denial = append(l.denial, r)
+			}
+		}
+	}
+	return l, nil
 }
 
-func (v *Validator) lookupRRSIGs(ctx context.Context, zone string) []RRSIGRecord {
-	// Simulated RRSIG lookup
-	return []RRSIGRecord{
-		{
-			TypeCovered: "DNSKEY",
-			Algorithm:   AlgRSASHA256,
-			Labels:      uint8(strings.Count(zone, ".") + 1),
-			OriginalTTL: 86400,
-			Expiration:  time.Now().Add(30 * 24 * time.Hour),
-			Inception:   time.Now().Add(-7 * 24 * time.Hour),
-			KeyTag:      generateKeyTag(zone, true),
-			SignerName:  zone,
-		},
+// isZoneApex reports whether name owns an SOA record, i.e. is the apex of
+// its own (possibly unsigned) zone rather than a name inside the parent.
+func (v *Validator) isZoneApex(ctx context.Context, name string) bool {
+	soa, err := v.lookupRRset(ctx, name, 6)
+	return err == nil && len(soa.records) > 0
+}
+
+// addVerifyFailure records a failed verification step. Failures caused
+// only by unsupported algorithms leave the zone insecure, anything else
+// makes it bogus.
+func (link *ChainLink) addVerifyFailure(step string, err error) {
+	if errors.Is(err, errUnsupportedAlgorithm) {
+		link.Issues = append(link.Issues, fmt.Sprintf("%s: unsupported algorithm", step))
+		if link.Status != StatusBogus {
+			link.Status = StatusInsecure
+		}
+		return
 	}
+	link.Issues = append(link.Issues, fmt.Sprintf("%s failed: %v", step, err))
+	link.Status = StatusBogus
 }
 
 func (v *Validator) determineStatus(result *ValidationResult) {
@@ -407,7 +490,7 @@ func (v *Validator) determineStatus(result *ValidationResult) {
 		if link.Status == StatusBogus {
 			anyBogus = true
 		}
-		if link.Status != StatusSecure && link.Zone != "." {
+		if link.Status != StatusSecure && (link.Zone != "." || link.Status == StatusIndeterminate) {
 			allSecure = false
 		}
 		if len(link.DNSKEYs) > 0 {
@@ -417,6 +500,11 @@ func (v *Validator) determineStatus(result *ValidationResult) {
 
 	result.HasDNSSEC = hasDNSSEC
 
+	// A missing name under a secure chain needs a valid denial proof
+	if result.Denial != nil && !result.Denial.Verified && allSecure && hasDNSSEC {
+		anyBogus = true
+	}
+
 	if anyBogus {
 		result.Status = StatusBogus
 	} else if allSecure && hasDNSSEC {
@@ -472,9 +560,13 @@ func (v *Validator) findIssues(result *ValidationResult) {
 
 	// Check chain issues
 	for _, link := range result.Chain {
+		severity := "medium"
+		if link.Status == StatusBogus {
+			severity = "critical"
+		}
 		for _, issue := range link.Issues {
 			result.Issues = append(result.Issues, Issue{
-				Severity:    "medium",
+				Severity:    severity,
 				Zone:        link.Zone,
 				Title:       issue,
 				Description: issue,
@@ -482,6 +574,31 @@ func (v *Validator) findIssues(result *ValidationResult) {
 		}
 	}
 
+	// Non-existent domain
+	if result.NXDomain {
+		switch {
+		case result.Denial != nil && result.Denial.Verified:
+			result.Issues = append(result.Issues, Issue{
+				Severity:    "info",
+				Title:       "Domain Does Not Exist",
+				Description: fmt.Sprintf("NXDOMAIN proven by %s", result.Denial.Type),
+			})
+		case result.Status == StatusBogus && result.Denial != nil:
+			result.Issues = append(result.Issues, Issue{
+				Severity:    "critical",
+				Title:       "Unproven Denial of Existence",
+				Description: fmt.Sprintf("NXDOMAIN not proven: %s", result.Denial.Error),
+				Remediation: "Check that the zone serves signed NSEC/NSEC3 records",
+			})
+		default:
+			result.Issues = append(result.Issues, Issue{
+				Severity:    "info",
+				Title:       "Domain Does Not Exist",
+				Description: "NXDOMAIN from an unsigned zone",
+			})
+		}
+	}
+
 	// No DNSSEC
 	if !result.HasDNSSEC {
 		result.Issues = append(result.Issues, Issue{
@@ -594,8 +711,31 @@ func (r *ValidationResult) Format() string {
 		if zone == "" {
 			zone = "(root)"
 		}
-		sb.WriteString(fmt.Sprintf("%s%s %s (keys: %d, DS: %d)\n",
-			indent, icon, zone, len(link.DNSKEYs), len(link.DSRecords)))
+		verified := 0
+		for _, sig := range link.RRSIGs {
+			if sig.Verified {
+				verified++
+			}
+		}
+		denial := ""
+		if link.Denial != nil && link.Denial.Type != "" {
+			denial = fmt.Sprintf(", no DS: %s", link.Denial.Type)
+			if link.Denial.OptOut {
+				denial += " opt-out"
+			}
+		}
+		sb.WriteString(fmt.Sprintf("%s%s %s (keys: %d, DS: %d, sigs: %d/%d verified%s)\n",
+			indent, icon, zone, len(link.DNSKEYs), len(link.DSRecords), verified, len(link.RRSIGs), denial))
+	}
+	if r.NXDomain {
+		proof := "unsigned"
+		if r.Denial != nil {
+			proof = "not proven"
+			if r.Denial.Verified {
+				proof = fmt.Sprintf("proven by %s", r.Denial.Type)
+			}
+		}
+		sb.WriteString(fmt.Sprintf("%s✗ %s does not exist (%s)\n", strings.Repeat("  ", len(r.Chain)), r.Domain, proof))
 	}
 
 	// Issues
@@ -626,6 +766,39 @@ func (r *ValidationResult) Format() string {
 	return sb.String()
 }
 
+// ToJSON converts the result to indented JSON.
+func (r *ValidationResult) ToJSON() (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// MarshalJSON adds algorithm names and signature counts. Signature times
+// encode as RFC 3339.
+func (r ValidationResult) MarshalJSON() ([]byte, error) {
+	type result ValidationResult
+	out := struct {
+		result
+		AlgorithmNames     []string `json:"algorithm_names"`
+		SignatureCount     int      `json:"signature_count"`
+		VerifiedSignatures int      `json:"verified_signatures"`
+	}{result: result(r)}
+	for _, alg := range r.Algorithms {
+		out.AlgorithmNames = append(out.AlgorithmNames, AlgorithmNames[alg])
+	}
+	for _, link := range r.Chain {
+		for _, sig := range link.RRSIGs {
+			out.SignatureCount++
+			if sig.Verified {
+				out.VerifiedSignatures++
+			}
+		}
+	}
+	return json.Marshal(out)
+}
+
 // Helper functions
 func containsAlgorithm(algs []Algorithm, alg Algorithm) bool {
 	for _, a := range algs {
@@ -636,18 +809,6 @@ func containsAlgorithm(algs []Algorithm, alg Algorithm) bool {
 	return false
 }
 
-func generateKeyTag(zone string, isKSK bool) uint16 {
-	// Simple hash-based key tag generation for simulation
-	h := uint16(0)
-	for _, c := range zone {
-		h = h*31 + uint16(c)
-	}
-	if isKSK {
-		h += 1000
-	}
-	return h
-}
-
 // GetIssuesBySeverity returns issues sorted by severity.
 func (r *ValidationResult) GetIssuesBySeverity() []Issue {
 	sorted := make([]Issue, len(r.Issues))
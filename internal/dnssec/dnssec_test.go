@@ -1,9 +1,30 @@
 package dnssec
 
 import (
+	"bytes"
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
 )
 
 func TestDefaultOptions(t *testing.T) {
@@ -82,20 +103,43 @@ func TestGetZoneHierarchy(t *testing.T) {
 }
 
 func TestValidate(t *testing.T) {
-	v := NewValidator(DefaultOptions())
+	tz := newTestZones(t, "com", "example.com")
+	v := NewValidator(Options{Resolver: tz.addr, Timeout: 2 * time.Second, TrustAnchors: tz.anchors(), CheckExpiry: true, ExpiryWarning: 24 * time.Hour})
 	ctx := context.Background()
 
-	result, err := v.Validate(ctx, "example.com")
+	result, err := v.Validate(ctx, "www.example.com")
 	if err != nil {
 		t.Fatalf("Validate failed: %v", err)
 	}
 
-	if result.Domain != "example.com" {
-		t.Errorf("Domain = %s, want example.com", result.Domain)
+	if result.Domain != "www.example.com" {
+		t.Errorf("Domain = %s, want www.example.com", result.Domain)
 	}
 
-	if len(result.Chain) == 0 {
-		t.Error("Chain should not be empty")
+	// www.example.com is a name inside example.com, not a zone cut
+	if len(result.Chain) != 3 {
+		t.Fatalf("Chain has %d links, want 3 (., com, example.com)", len(result.Chain))
+	}
+	for _, link := range result.Chain {
+		if link.Status != StatusSecure {
+			t.Errorf("%s: Status = %s, want secure (issues: %v)", link.Zone, link.Status, link.Issues)
+		}
+		for _, sig := range link.RRSIGs {
+			if !sig.Verified {
+				t.Errorf("%s: %s RRSIG by key %d not verified", link.Zone, sig.TypeCovered, sig.KeyTag)
+			}
+		}
+	}
+	if result.Status != StatusSecure || !result.IsFullySecure {
+		t.Errorf("Status = %s, want secure", result.Status)
+	}
+	if result.KeyCount != 6 {
+		t.Errorf("KeyCount = %d, want 6", result.KeyCount)
+	}
+	for _, link := range result.Chain[1:] {
+		if len(link.DSRecords) != 1 || !link.DSRecords[0].Matched {
+			t.Errorf("%s: DS not matched to its KSK: %+v", link.Zone, link.DSRecords)
+		}
 	}
 
 	if result.TotalTime == 0 {
@@ -103,6 +147,280 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+func TestValidateBogusSignature(t *testing.T) {
+	tz := newTestZones(t, "com", "example.com")
+	tz.tamper("example.com.", typeDNSKEY)
+
+	v := NewValidator(Options{Resolver: tz.addr, Timeout: 2 * time.Second, TrustAnchors: tz.anchors()})
+	result, err := v.Validate(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	if result.Status != StatusBogus {
+		t.Errorf("Status = %s, want bogus", result.Status)
+	}
+	link := result.Chain[len(result.Chain)-1]
+	if link.Zone != "example.com" || link.Status != StatusBogus {
+		t.Errorf("last link %s has status %s, want example.com bogus", link.Zone, link.Status)
+	}
+	critical := false
+	for _, issue := range result.Issues {
+		if issue.Severity == "critical" && issue.Zone == "example.com" {
+			critical = true
+		}
+	}
+	if !critical {
+		t.Errorf("expected a critical issue for example.com, got %+v", result.Issues)
+	}
+}
+
+func TestValidateExpiredSignature(t *testing.T) {
+	tz := newTestZones(t, "com", "example.com")
+	owner := "example.com."
+	ksk, zsk := tz.keys[owner][0], tz.keys[owner][1]
+	rrset := []rr{
+		{Name: owner, Type: typeDNSKEY, Class: 1, TTL: 3600, Data: ksk.rdata},
+		{Name: owner, Type: typeDNSKEY, Class: 1, TTL: 3600, Data: zsk.rdata},
+	}
+	now := time.Now().Truncate(time.Second)
+	tz.set(owner, typeDNSKEY, append(rrset, tz.signValid(rrset, ksk, owner, now.Add(-30*24*time.Hour), now.Add(-time.Hour))))
+
+	v := NewValidator(Options{Resolver: tz.addr, Timeout: 2 * time.Second, TrustAnchors: tz.anchors()})
+	result, err := v.Validate(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	link := result.Chain[len(result.Chain)-1]
+	if link.Zone != "example.com" || link.Status != StatusBogus {
+		t.Errorf("last link %s has status %s, want example.com bogus (issues: %v)", link.Zone, link.Status, link.Issues)
+	}
+	if result.Status != StatusBogus {
+		t.Errorf("Status = %s, want bogus", result.Status)
+	}
+	if result.ExpiredSigs != 1 {
+		t.Errorf("ExpiredSigs = %d, want 1", result.ExpiredSigs)
+	}
+}
+
+func TestValidateUntrustedRoot(t *testing.T) {
+	tz := newTestZones(t, "com", "example.com")
+
+	// The mock hierarchy is internally consistent but its root key is not
+	// the IANA one
+	v := NewValidator(Options{Resolver: tz.addr, Timeout: 2 * time.Second})
+	result, err := v.Validate(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	if root := result.Chain[0]; root.Zone != "." || root.Status != StatusBogus {
+		t.Errorf("root link %s has status %s, want bogus", root.Zone, root.Status)
+	}
+	if result.Status != StatusBogus {
+		t.Errorf("Status = %s, want bogus", result.Status)
+	}
+}
+
+func TestValidateUnsignedZone(t *testing.T) {
+	tz := newTestZones(t, "com")
+	tz.addUnsigned("plain.com.")
+
+	v := NewValidator(Options{Resolver: tz.addr, Timeout: 2 * time.Second, TrustAnchors: tz.anchors()})
+	result, err := v.Validate(context.Background(), "plain.com")
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	if len(result.Chain) != 3 {
+		t.Fatalf("Chain has %d links, want 3", len(result.Chain))
+	}
+	if link := result.Chain[2]; link.Status != StatusInsecure {
+		t.Errorf("plain.com status = %s, want insecure", link.Status)
+	}
+	if result.Status != StatusInsecure {
+		t.Errorf("Status = %s, want insecure", result.Status)
+	}
+	if d := result.Chain[2].Denial; d == nil || d.Type != TypeNSEC || !d.Verified {
+		t.Errorf("plain.com DS denial = %+v, want verified NSEC", d)
+	}
+}
+
+func TestValidateUnsignedZoneWithoutProof(t *testing.T) {
+	tz := newTestZones(t, "com")
+	tz.set("plain.com.", 6, []rr{{Name: "plain.com.", Type: 6, Class: 1, TTL: 3600, Data: []byte("soa")}})
+
+	v := NewValidator(Options{Resolver: tz.addr, Timeout: 2 * time.Second, TrustAnchors: tz.anchors()})
+	result, err := v.Validate(context.Background(), "plain.com")
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	// Without a signed denial the missing DS could have been stripped
+	if link := result.Chain[2]; link.Status != StatusBogus {
+		t.Errorf("plain.com status = %s, want bogus", link.Status)
+	}
+}
+
+func TestValidateOptOutDelegation(t *testing.T) {
+	tz := newTestZones(t, "com")
+	tz.set("plain.com.", 6, []rr{{Name: "plain.com.", Type: 6, Class: 1, TTL: 3600, Data: []byte("soa")}})
+	tz.deny("plain.com.", typeDS, dnsmessage.RCodeSuccess, tz.nsec3Chain("com.", true, map[string][]uint16{
+		"com.":         {2, 6, typeRRSIG, typeDNSKEY, typeNSEC3PARAM},
+		"example.com.": {2, typeDS, typeRRSIG},
+	}))
+
+	v := NewValidator(Options{Resolver: tz.addr, Timeout: 2 * time.Second, TrustAnchors: tz.anchors()})
+	result, err := v.Validate(context.Background(), "plain.com")
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	link := result.Chain[2]
+	if link.Status != StatusInsecure {
+		t.Errorf("plain.com status = %s, want insecure (issues: %v)", link.Status, link.Issues)
+	}
+	if d := link.Denial; d == nil || d.Type != TypeNSEC3 || !d.OptOut || !d.Verified {
+		t.Errorf("plain.com DS denial = %+v, want verified NSEC3 opt-out", d)
+	}
+}
+
+func TestValidateNXDomain(t *testing.T) {
+	tz := newTestZones(t, "com", "example.com")
+	tz.deny("missing.example.com.", 0, dnsmessage.RCodeNameError,
+		tz.nsec("example.com.", "example.com.", "www.example.com.", 2, 6, typeRRSIG, typeNSEC, typeDNSKEY))
+
+	v := NewValidator(Options{Resolver: tz.addr, Timeout: 2 * time.Second, TrustAnchors: tz.anchors()})
+	result, err := v.Validate(context.Background(), "missing.example.com")
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	if !result.NXDomain {
+		t.Fatal("NXDomain should be set")
+	}
+	if len(result.Chain) != 3 {
+		t.Errorf("Chain has %d links, want 3", len(result.Chain))
+	}
+	if d := result.Denial; d == nil || d.Type != TypeNSEC || !d.Verified {
+		t.Fatalf("Denial = %+v, want verified NSEC", d)
+	}
+	if result.Status != StatusSecure {
+		t.Errorf("Status = %s, want secure", result.Status)
+	}
+}
+
+func TestValidateNXDomainNSEC3(t *testing.T) {
+	tz := newTestZones(t, "com", "example.com")
+	chain := tz.nsec3Chain("example.com.", false, map[string][]uint16{
+		"example.com.":      {2, 6, typeRRSIG, typeDNSKEY, typeNSEC3PARAM},
+		"www.example.com.":  {1, typeRRSIG},
+		"mail.example.com.": {1, 15, typeRRSIG},
+	})
+	tz.deny("missing.example.com.", 0, dnsmessage.RCodeNameError, chain)
+
+	v := NewValidator(Options{Resolver: tz.addr, Timeout: 2 * time.Second, TrustAnchors: tz.anchors()})
+	result, err := v.Validate(context.Background(), "missing.example.com")
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if d := result.Denial; d == nil || d.Type != TypeNSEC3 || !d.Verified {
+		t.Fatalf("Denial = %+v, want verified NSEC3", d)
+	}
+
+	// Without the record matching the closest encloser there is no proof
+	var partial []rr
+	for _, r := range chain {
+		if !strings.HasPrefix(r.Name, nsec3Hash("example.com.", NSEC3Record{})) {
+			partial = append(partial, r)
+		}
+	}
+	tz.deny("missing.example.com.", 0, dnsmessage.RCodeNameError, partial)
+	if result, _ = v.Validate(context.Background(), "missing.example.com"); result.Status != StatusBogus {
+		t.Errorf("Status without closest encloser = %s, want bogus", result.Status)
+	}
+}
+
+func TestNSEC3Hash(t *testing.T) {
+	// RFC 5155 appendix A
+	params := NSEC3Record{HashAlgorithm: 1, Iterations: 12, Salt: "AABBCCDD"}
+	tests := map[string]string{
+		"example.":   "0p9mhaveqvm6t7vbl5lop2u3t2rp3tom",
+		"a.example.": "35mthgpgcu1qg68fab165klnsnk3dpvl",
+	}
+	for name, want := range tests {
+		if got := nsec3Hash(name, params); got != want {
+			t.Errorf("nsec3Hash(%s) = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestParseNSEC(t *testing.T) {
+	data := append(nameWire("host.example.com."), typeBitmap([]uint16{1, 15, typeRRSIG, typeNSEC, 1234})...)
+	rec, err := parseNSEC("alfa.example.com.", data)
+	if err != nil {
+		t.Fatalf("parseNSEC failed: %v", err)
+	}
+	if rec.Owner != "alfa.example.com" || rec.NextName != "host.example.com" {
+		t.Errorf("names = %s -> %s", rec.Owner, rec.NextName)
+	}
+	want := []string{"A", "MX", "RRSIG", "NSEC", "TYPE1234"}
+	if strings.Join(rec.Types, " ") != strings.Join(want, " ") {
+		t.Errorf("Types = %v, want %v", rec.Types, want)
+	}
+}
+
+func TestCanonicalCompare(t *testing.T) {
+	// RFC 4034 section 6.1 example, in canonical order
+	names := []string{
+		"example.", "a.example.", "yljkjljk.a.example.", "Z.a.example.",
+		"zABC.a.EXAMPLE.", "z.example.", "\x01.z.example.", "*.z.example.", "\xc8.z.example.",
+	}
+	for i := 1; i < len(names); i++ {
+		if canonicalCompare(names[i-1], names[i]) >= 0 {
+			t.Errorf("%q should sort before %q", names[i-1], names[i])
+		}
+	}
+}
+
+func TestEnumerateNames(t *testing.T) {
+	tz := newTestZones(t, "com", "example.com")
+	walk := []string{"example.com.", "a.example.com.", "sub.example.com.", "www.example.com."}
+	for i, owner := range walk {
+		next := walk[(i+1)%len(walk)]
+		if owner == "sub.example.com." {
+			// Delegation: the NSEC query reaches the child zone, so the
+			// parent-side NSEC is only returned when stepping past it.
+			tz.deny("sub\x00.example.com.", 0, dnsmessage.RCodeNameError, tz.nsec("example.com.", owner, next, 2, typeRRSIG, typeNSEC))
+			continue
+		}
+		tz.set(owner, typeNSEC, tz.nsec("example.com.", owner, next, 1, typeRRSIG, typeNSEC))
+	}
+
+	v := NewValidator(Options{Resolver: tz.addr, Timeout: 2 * time.Second, TrustAnchors: tz.anchors()})
+	names, err := v.EnumerateNames(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("EnumerateNames failed: %v", err)
+	}
+	want := "example.com a.example.com sub.example.com www.example.com"
+	if got := strings.Join(names, " "); got != want {
+		t.Errorf("names = %s, want %s", got, want)
+	}
+}
+
+func TestEnumerateNamesNSEC3(t *testing.T) {
+	tz := newTestZones(t, "com", "example.com")
+	tz.deny("example.com.", typeNSEC, dnsmessage.RCodeSuccess, tz.nsec3Chain("example.com.", false, map[string][]uint16{
+		"example.com.": {2, 6, typeRRSIG, typeDNSKEY, typeNSEC3PARAM},
+	}))
+
+	v := NewValidator(Options{Resolver: tz.addr, Timeout: 2 * time.Second, TrustAnchors: tz.anchors()})
+	if _, err := v.EnumerateNames(context.Background(), "example.com"); err == nil || !strings.Contains(err.Error(), "NSEC3") {
+		t.Errorf("EnumerateNames error = %v, want NSEC3 error", err)
+	}
+}
+
 func TestContainsAlgorithm(t *testing.T) {
 	algs := []Algorithm{AlgRSASHA256, AlgECDSAP256}
 
@@ -114,16 +432,137 @@ func TestContainsAlgorithm(t *testing.T) {
 	}
 }
 
-func TestGenerateKeyTag(t *testing.T) {
-	tag1 := generateKeyTag("example.com", true)
-	tag2 := generateKeyTag("example.com", false)
-	tag3 := generateKeyTag("other.com", true)
+func TestKeyTag(t *testing.T) {
+	// Root zone KSK-2017
+	key := DNSKEYRecord{
+		Flags:     257,
+		Protocol:  3,
+		Algorithm: AlgRSASHA256,
+		PublicKey: rootKSK2017,
+	}
+	rdata, err := dnskeyRDATA(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag := keyTag(rdata); tag != 20326 {
+		t.Errorf("keyTag = %d, want 20326", tag)
+	}
+
+	parsed, err := parseDNSKEY(".", rdata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.IsKSK || !parsed.IsSEP || parsed.IsZSK || parsed.KeyTag != 20326 {
+		t.Errorf("parsed key = %+v", parsed)
+	}
+}
+
+func TestComputeDSDigest(t *testing.T) {
+	// Root zone KSK-2017, as published in the IANA trust anchor
+	key := DNSKEYRecord{Domain: ".", Flags: 257, Protocol: 3, Algorithm: AlgRSASHA256, PublicKey: rootKSK2017}
+
+	want := "E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D"
+	if got := ComputeDSDigest(key, DigestSHA256); got != want {
+		t.Errorf("SHA-256 digest = %s, want %s", got, want)
+	}
+	if got := ComputeDSDigest(key, DigestSHA384); len(got) != 96 {
+		t.Errorf("SHA-384 digest has %d hex digits, want 96", len(got))
+	}
+	if got := RootTrustAnchors[0].Digest; got != want {
+		t.Errorf("RootTrustAnchors[0].Digest = %s, want %s", got, want)
+	}
+	if got := ComputeDSDigest(key, DigestType(3)); got != "" {
+		t.Errorf("unsupported digest type returned %q", got)
+	}
+}
+
+func TestValidateDSMismatch(t *testing.T) {
+	tz := newTestZones(t, "com", "example.com")
+	tz.publishDS("example.com.", bytes.Repeat([]byte{0xAB}, 32)) // validly signed, wrong digest
+
+	v := NewValidator(Options{Resolver: tz.addr, Timeout: 2 * time.Second, TrustAnchors: tz.anchors()})
+	result, err := v.Validate(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
 
-	if tag1 == tag2 {
-		t.Error("KSK and ZSK should have different tags")
+	link := result.Chain[len(result.Chain)-1]
+	if link.Status != StatusBogus {
+		t.Errorf("example.com status = %s, want bogus", link.Status)
+	}
+	if link.DSRecords[0].Matched {
+		t.Error("mismatched DS should not be marked Matched")
+	}
+	if result.Status != StatusBogus {
+		t.Errorf("Status = %s, want bogus", result.Status)
+	}
+	critical := false
+	for _, issue := range result.Issues {
+		if issue.Severity == "critical" && strings.Contains(issue.Title, "does not match DNSKEY") {
+			critical = true
+		}
 	}
-	if tag1 == tag3 {
-		t.Error("different zones should have different tags")
+	if !critical {
+		t.Errorf("expected a critical DS mismatch issue, got %+v", result.Issues)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	data := []byte("signed data")
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPub := append([]byte{3}, big.NewInt(int64(rsaKey.E)).Bytes()...)
+	rsaPub = append(rsaPub, rsaKey.N.Bytes()...)
+	h := sha256.Sum256(data)
+	rsaSig, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, h[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edSig := ed25519.Sign(edPriv, data)
+
+	tests := []struct {
+		name string
+		alg  Algorithm
+		pub  []byte
+		sig  []byte
+	}{
+		{"rsa", AlgRSASHA256, rsaPub, rsaSig},
+		{"ed25519", AlgED25519, edPub, edSig},
+	}
+	for _, tt := range tests {
+		if err := verifySignature(tt.alg, tt.pub, data, tt.sig); err != nil {
+			t.Errorf("%s: valid signature rejected: %v", tt.name, err)
+		}
+		if err := verifySignature(tt.alg, tt.pub, []byte("other data"), tt.sig); err == nil {
+			t.Errorf("%s: signature over other data accepted", tt.name)
+		}
+	}
+
+	if err := verifySignature(AlgED448, edPub, data, edSig); !errors.Is(err, errUnsupportedAlgorithm) {
+		t.Errorf("Ed448: err = %v, want errUnsupportedAlgorithm", err)
+	}
+}
+
+func TestSignedDataWildcard(t *testing.T) {
+	sig := RRSIGRecord{TypeCovered: "A", Algorithm: AlgECDSAP256, Labels: 2, OriginalTTL: 300, SignerName: "example.com"}
+	expanded, err := signedData(sig, []rr{{Name: "host.example.com.", Type: 1, Class: 1, Data: []byte{192, 0, 2, 1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wildcard, err := signedData(sig, []rr{{Name: "*.example.com.", Type: 1, Class: 1, Data: []byte{192, 0, 2, 1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(expanded, wildcard) {
+		t.Error("wildcard-expanded owner should be signed as *.example.com")
 	}
 }
 
@@ -197,6 +636,79 @@ func TestFormat(t *testing.T) {
 	}
 }
 
+func TestToJSON(t *testing.T) {
+	expiry := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	result := &ValidationResult{
+		Domain:     "example.com",
+		Status:     StatusSecure,
+		Grade:      "A+",
+		Score:      100,
+		HasDNSSEC:  true,
+		Algorithms: []Algorithm{AlgECDSAP256},
+		KeyCount:   2,
+		Chain: []ChainLink{{
+			Zone:       "example.com",
+			Parent:     "com",
+			Status:     StatusSecure,
+			RRSIGs:     []RRSIGRecord{{TypeCovered: "DNSKEY", Algorithm: AlgECDSAP256, Expiration: expiry, Inception: expiry.Add(-30 * 24 * time.Hour), Verified: true}},
+			LookupTime: 1500 * time.Microsecond,
+		}},
+		Issues:    []Issue{{Severity: "medium", Zone: "example.com", Title: "Signatures Expiring Soon"}},
+		TotalTime: 20 * time.Millisecond,
+	}
+
+	out, err := result.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+
+	var decoded struct {
+		Status         string        `json:"status"`
+		Grade          string        `json:"grade"`
+		AlgorithmNames []string      `json:"algorithm_names"`
+		SignatureCount int           `json:"signature_count"`
+		Verified       int           `json:"verified_signatures"`
+		TotalTime      time.Duration `json:"total_time"`
+		Chain          []struct {
+			Zone       string        `json:"zone"`
+			LookupTime time.Duration `json:"lookup_time"`
+			RRSIGs     []struct {
+				Expiration string `json:"expiration"`
+				Inception  string `json:"inception"`
+			} `json:"rrsigs"`
+		} `json:"chain"`
+		Issues []Issue `json:"issues"`
+	}
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+
+	if decoded.Status != "secure" || decoded.Grade != "A+" {
+		t.Errorf("status/grade = %s/%s", decoded.Status, decoded.Grade)
+	}
+	if len(decoded.AlgorithmNames) != 1 || decoded.AlgorithmNames[0] != "ECDSA P-256/SHA-256" {
+		t.Errorf("algorithm_names = %v", decoded.AlgorithmNames)
+	}
+	if decoded.SignatureCount != 1 || decoded.Verified != 1 {
+		t.Errorf("signatures = %d/%d, want 1/1", decoded.Verified, decoded.SignatureCount)
+	}
+	if decoded.TotalTime != 20*time.Millisecond {
+		t.Errorf("total_time = %v, want 20ms", decoded.TotalTime)
+	}
+	if len(decoded.Chain) != 1 || decoded.Chain[0].LookupTime != 1500*time.Microsecond {
+		t.Fatalf("chain = %+v", decoded.Chain)
+	}
+	if got := decoded.Chain[0].RRSIGs[0].Expiration; got != "2026-03-01T12:00:00Z" {
+		t.Errorf("expiration = %s, want RFC 3339", got)
+	}
+	if _, err := time.Parse(time.RFC3339, decoded.Chain[0].RRSIGs[0].Inception); err != nil {
+		t.Errorf("inception not RFC 3339: %v", err)
+	}
+	if len(decoded.Issues) != 1 || decoded.Issues[0].Severity != "medium" {
+		t.Errorf("issues = %+v", decoded.Issues)
+	}
+}
+
 func containsStr(s, substr string) bool {
 	for i := 0; i <= len(s)-len(substr); i++ {
 		if s[i:i+len(substr)] == substr {
@@ -257,3 +769,295 @@ func TestFindIssues(t *testing.T) {
 		t.Error("should detect critical issue for RSA/MD5")
 	}
 }
+
+// rootKSK2017 is the public key of the root zone KSK with key tag 20326.
+const rootKSK2017 = "AwEAAaz/tAm8yTn4Mfeh5eyI96WSVexTBAvkMgJzkKTOiW1vkIbzxeF3+/4RgWOq7HrxRixHlFlExOLAJr5emLvN7SWXgnLh4+B5xQlNVz8Og8kvArMtNROxVQuCaSnIDdD5LKyWbRd2n9WGe2R8PzgCmr3EgVLrjyBxWezF0jLHwVN8efS3rCj/EWgvIWgb9tarpVUDK/b58Da+sqqls3eNbuv7pr+eoZG+SrDK6nWeL3c6H5Apxz7LjVc1uTIdsIXxuOLYA4/ilBmSVIzuDWfdRUfhHdY6+cn8HFRm+2hM8AnXGXws9555KrUB5qihylGa8subX2Nn6UwNR1AkUTV74bU="
+
+// testZones is a mock recursive resolver serving a signed hierarchy below
+// the root, with a fresh ECDSA P-256 KSK and ZSK per zone.
+type testZones struct {
+	t       *testing.T
+	addr    string
+	keys    map[string][2]testKey // zone -> KSK, ZSK
+	mu      sync.Mutex
+	answers map[string][]rr // "name|type" -> answer section
+	denials map[string]testDenial
+}
+
+// testDenial is the reply to a query without answers: "name|type" for
+// NODATA, "name|*" for any type at a missing name.
+type testDenial struct {
+	rcode     dnsmessage.RCode
+	authority []rr
+}
+
+type testKey struct {
+	priv   *ecdsa.PrivateKey
+	record DNSKEYRecord
+	rdata  []byte
+}
+
+func newTestZones(t *testing.T, zones ...string) *testZones {
+	t.Helper()
+	tz := &testZones{t: t, keys: make(map[string][2]testKey), answers: make(map[string][]rr), denials: make(map[string]testDenial)}
+
+	for _, zone := range append([]string{"."}, zones...) {
+		owner := fqdn(zone)
+		ksk, zsk := tz.newKey(owner, 257), tz.newKey(owner, 256)
+		tz.keys[owner] = [2]testKey{ksk, zsk}
+
+		rrset := []rr{
+			{Name: owner, Type: typeDNSKEY, Class: 1, TTL: 3600, Data: ksk.rdata},
+			{Name: owner, Type: typeDNSKEY, Class: 1, TTL: 3600, Data: zsk.rdata},
+		}
+		tz.set(owner, typeDNSKEY, append(rrset, tz.sign(rrset, ksk, owner)))
+
+		if owner != "." {
+			digest, _ := hex.DecodeString(ComputeDSDigest(ksk.record, DigestSHA256))
+			tz.publishDS(owner, digest)
+		}
+	}
+
+	tz.serve()
+	return tz
+}
+
+func (tz *testZones) newKey(owner string, flags uint16) testKey {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		tz.t.Fatal(err)
+	}
+	pub, err := priv.PublicKey.ECDH()
+	if err != nil {
+		tz.t.Fatal(err)
+	}
+	rdata := binary.BigEndian.AppendUint16(nil, flags)
+	rdata = append(rdata, 3, byte(AlgECDSAP256))
+	rdata = append(rdata, pub.Bytes()[1:]...) // drop the 0x04 prefix
+	record, err := parseDNSKEY(owner, rdata)
+	if err != nil {
+		tz.t.Fatal(err)
+	}
+	return testKey{priv: priv, record: record, rdata: rdata}
+}
+
+// anchors returns the trust anchor for the mock root's KSK.
+func (tz *testZones) anchors() []DSRecord {
+	ksk := tz.keys["."][0].record
+	return []DSRecord{{Domain: ".", KeyTag: ksk.KeyTag, Algorithm: ksk.Algorithm, DigestType: DigestSHA256,
+		Digest: ComputeDSDigest(ksk, DigestSHA256)}}
+}
+
+// sign returns an RRSIG record over rrset made with key, valid from a day
+// ago for 30 days.
+func (tz *testZones) sign(rrset []rr, key testKey, signer string) rr {
+	now := time.Now().Truncate(time.Second)
+	return tz.signValid(rrset, key, signer, now.Add(-24*time.Hour), now.Add(30*24*time.Hour))
+}
+
+// signValid returns an RRSIG record over rrset made with key, valid from
+// inception to expiration.
+func (tz *testZones) signValid(rrset []rr, key testKey, signer string, inception, expiration time.Time) rr {
+	sig := RRSIGRecord{
+		TypeCovered: typeName(rrset[0].Type),
+		Algorithm:   AlgECDSAP256,
+		Labels:      uint8(labelCount(rrset[0].Name)),
+		OriginalTTL: rrset[0].TTL,
+		Expiration:  expiration,
+		Inception:   inception,
+		KeyTag:      key.record.KeyTag,
+		SignerName:  signer,
+	}
+	data, err := signedData(sig, rrset)
+	if err != nil {
+		tz.t.Fatal(err)
+	}
+	h := sha256.Sum256(data)
+	r, s, err := ecdsa.Sign(rand.Reader, key.priv, h[:])
+	if err != nil {
+		tz.t.Fatal(err)
+	}
+	rdata, err := rrsigSignedPrefix(sig)
+	if err != nil {
+		tz.t.Fatal(err)
+	}
+	rdata = append(rdata, r.FillBytes(make([]byte, 32))...)
+	rdata = append(rdata, s.FillBytes(make([]byte, 32))...)
+	return rr{Name: rrset[0].Name, Type: typeRRSIG, Class: 1, TTL: rrset[0].TTL, Data: rdata}
+}
+
+func (tz *testZones) set(name string, qtype uint16, answer []rr) {
+	tz.mu.Lock()
+	defer tz.mu.Unlock()
+	tz.answers[fmt.Sprintf("%s|%d", name, qtype)] = answer
+}
+
+func (tz *testZones) get(name string, qtype uint16) []rr {
+	tz.mu.Lock()
+	defer tz.mu.Unlock()
+	return tz.answers[fmt.Sprintf("%s|%d", strings.ToLower(name), qtype)]
+}
+
+// publishDS publishes a DS for the KSK of owner with the given digest,
+// signed by the parent's ZSK.
+func (tz *testZones) publishDS(owner string, digest []byte) {
+	parent := fqdn(owner[strings.Index(owner, ".")+1:])
+	ksk := tz.keys[owner][0]
+	data := binary.BigEndian.AppendUint16(nil, ksk.record.KeyTag)
+	data = append(data, byte(AlgECDSAP256), byte(DigestSHA256))
+	ds := []rr{{Name: owner, Type: typeDS, Class: 1, TTL: 3600, Data: append(data, digest...)}}
+	tz.set(owner, typeDS, append(ds, tz.sign(ds, tz.keys[parent][1], parent)))
+}
+
+// tamper corrupts the signature over name/qtype.
+func (tz *testZones) tamper(name string, qtype uint16) {
+	tz.mu.Lock()
+	defer tz.mu.Unlock()
+	for _, r := range tz.answers[fmt.Sprintf("%s|%d", name, qtype)] {
+		if r.Type == typeRRSIG {
+			r.Data[len(r.Data)-1] ^= 0xFF
+		}
+	}
+}
+
+// addUnsigned adds a delegated zone without DNSSEC, with the parent's
+// NSEC proving there is no DS.
+func (tz *testZones) addUnsigned(name string) {
+	parent := fqdn(name[strings.Index(name, ".")+1:])
+	tz.set(name, 6, []rr{{Name: name, Type: 6, Class: 1, TTL: 3600, Data: []byte("soa")}})
+	tz.deny(name, typeDS, dnsmessage.RCodeSuccess, tz.nsec(parent, name, parent, 2, typeRRSIG, typeNSEC))
+}
+
+// deny sets the reply for name/qtype when there is no answer. A zero qtype
+// applies to every type.
+func (tz *testZones) deny(name string, qtype uint16, rcode dnsmessage.RCode, authority []rr) {
+	key := fmt.Sprintf("%s|%d", name, qtype)
+	if qtype == 0 {
+		key = name + "|*"
+	}
+	tz.mu.Lock()
+	defer tz.mu.Unlock()
+	tz.denials[key] = testDenial{rcode: rcode, authority: authority}
+}
+
+func (tz *testZones) denial(name string, qtype uint16) testDenial {
+	name = strings.ToLower(name)
+	tz.mu.Lock()
+	defer tz.mu.Unlock()
+	if d, ok := tz.denials[fmt.Sprintf("%s|%d", name, qtype)]; ok {
+		return d
+	}
+	return tz.denials[name+"|*"]
+}
+
+// nsec returns an NSEC record signed by the ZSK of zone.
+func (tz *testZones) nsec(zone, owner, next string, types ...uint16) []rr {
+	rrset := []rr{{Name: owner, Type: typeNSEC, Class: 1, TTL: 3600, Data: append(nameWire(next), typeBitmap(types)...)}}
+	return append(rrset, tz.sign(rrset, tz.keys[zone][1], zone))
+}
+
+// nsec3Chain returns the signed NSEC3 chain of zone over names (unsalted,
+// no extra iterations), mapping each name to the types present there.
+func (tz *testZones) nsec3Chain(zone string, optOut bool, names map[string][]uint16) []rr {
+	hashes := make([]string, 0, len(names))
+	types := make(map[string][]uint16)
+	for name, t := range names {
+		h := nsec3Hash(name, NSEC3Record{})
+		hashes = append(hashes, h)
+		types[h] = t
+	}
+	sort.Strings(hashes)
+
+	var flags byte
+	if optOut {
+		flags = 1
+	}
+	b32 := base32.HexEncoding.WithPadding(base32.NoPadding)
+	var records []rr
+	for i, h := range hashes {
+		next, err := b32.DecodeString(strings.ToUpper(hashes[(i+1)%len(hashes)]))
+		if err != nil {
+			tz.t.Fatal(err)
+		}
+		data := []byte{1, flags, 0, 0, 0, byte(len(next))}
+		data = append(append(data, next...), typeBitmap(types[h])...)
+		rrset := []rr{{Name: h + "." + zone, Type: typeNSEC3, Class: 1, TTL: 3600, Data: data}}
+		records = append(records, rrset[0], tz.sign(rrset, tz.keys[zone][1], zone))
+	}
+	return records
+}
+
+// typeBitmap encodes the NSEC type bit maps field.
+func typeBitmap(types []uint16) []byte {
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	var out []byte
+	for i := 0; i < len(types); {
+		window := types[i] >> 8
+		var bitmap [32]byte
+		n := 0
+		for ; i < len(types) && types[i]>>8 == window; i++ {
+			low := types[i] & 0xFF
+			bitmap[low/8] |= 0x80 >> (low % 8)
+			n = int(low/8) + 1
+		}
+		out = append(out, byte(window), byte(n))
+		out = append(out, bitmap[:n]...)
+	}
+	return out
+}
+
+func (tz *testZones) serve() {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		tz.t.Fatalf("failed to start mock resolver: %v", err)
+	}
+	tz.t.Cleanup(func() { conn.Close() })
+	tz.addr = conn.LocalAddr().String()
+
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var p dnsmessage.Parser
+			h, err := p.Start(buf[:n])
+			if err != nil {
+				continue
+			}
+			q, err := p.Question()
+			if err != nil {
+				continue
+			}
+
+			answer := tz.get(q.Name.String(), uint16(q.Type))
+			var denial testDenial
+			if len(answer) == 0 {
+				denial = tz.denial(q.Name.String(), uint16(q.Type))
+			}
+
+			b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: h.ID, Response: true, RecursionAvailable: true, RCode: denial.rcode})
+			b.StartQuestions()
+			b.Question(q)
+			b.StartAnswers()
+			writeRRs(&b, answer)
+			b.StartAuthorities()
+			writeRRs(&b, denial.authority)
+			if reply, err := b.Finish(); err == nil {
+				conn.WriteTo(reply, from)
+			}
+		}
+	}()
+}
+
+func writeRRs(b *dnsmessage.Builder, records []rr) {
+	for _, r := range records {
+		b.UnknownResource(dnsmessage.ResourceHeader{
+			Name:  dnsmessage.MustNewName(r.Name),
+			Type:  dnsmessage.Type(r.Type),
+			Class: dnsmessage.Class(r.Class),
+			TTL:   r.TTL,
+		}, dnsmessage.UnknownResource{Type: dnsmessage.Type(r.Type), Data: r.Data})
+	}
+}
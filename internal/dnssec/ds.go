@@ -0,0 +1,119 @@
+package dnssec
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RootTrustAnchors are the IANA root zone trust anchors (root-anchors.xml):
+// KSK-2017 and its successor KSK-2024.
+var RootTrustAnchors = []DSRecord{
+	{Domain: ".", KeyTag: 20326, Algorithm: AlgRSASHA256, DigestType: DigestSHA256,
+		Digest: "E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D"},
+	{Domain: ".", KeyTag: 38696, Algorithm: AlgRSASHA256, DigestType: DigestSHA256,
+		Digest: "683D2D0ACB8C9B712A1948B27F741219298D0A450D612C483AF444A4C0FB2B16"},
+}
+
+// ComputeDSDigest returns the DS digest of key as upper-case hex: the hash
+// of the owner name in canonical wire format followed by the DNSKEY RDATA
+// (RFC 4034 section 5.1.4). It returns "" for an unsupported digest type or
+// an undecodable key.
+func ComputeDSDigest(key DNSKEYRecord, dt DigestType) string {
+	rdata, err := dnskeyRDATA(key)
+	if err != nil {
+		return ""
+	}
+	data := append(nameWire(key.Domain), rdata...)
+
+	var sum []byte
+	switch dt {
+	case DigestSHA1:
+		h := sha1.Sum(data)
+		sum = h[:]
+	case DigestSHA256:
+		h := sha256.Sum256(data)
+		sum = h[:]
+	case DigestSHA384:
+		h := sha512.Sum384(data)
+		sum = h[:]
+	default:
+		return ""
+	}
+	return strings.ToUpper(hex.EncodeToString(sum))
+}
+
+// matchDS compares each DS digest with the DNSKEY it names, setting Matched
+// on those that agree. It fails if any digest disagrees with its key, or if
+// no DS matches a key that signed the DNSKEY RRset, since either breaks the
+// chain of trust. DS records for keys not (yet) in the zone are normal
+// during rollovers and are skipped.
+func matchDS(ds []DSRecord, keys []DNSKEYRecord, keySigs []RRSIGRecord) error {
+	var mismatches []string
+	supported := 0
+	anchored := false
+
+	for i := range ds {
+		if _, ok := DigestNames[ds[i].DigestType]; !ok {
+			continue
+		}
+		supported++
+		for _, key := range keys {
+			if key.KeyTag != ds[i].KeyTag || key.Algorithm != ds[i].Algorithm {
+				continue
+			}
+			if !strings.EqualFold(ComputeDSDigest(key, ds[i].DigestType), ds[i].Digest) {
+				mismatches = append(mismatches, fmt.Sprintf("DS %d/%s does not match DNSKEY %d",
+					ds[i].KeyTag, DigestNames[ds[i].DigestType], key.KeyTag))
+				continue
+			}
+			ds[i].Matched = true
+			if signedBy(keySigs, key.KeyTag) {
+				anchored = true
+			}
+		}
+	}
+
+	switch {
+	case len(mismatches) > 0:
+		return errors.New(strings.Join(mismatches, "; "))
+	case supported == 0:
+		return fmt.Errorf("%w: no DS with a supported digest type", errUnsupportedAlgorithm)
+	case !anchored:
+		return fmt.Errorf("no DS record matches a DNSKEY that signs the zone's keys")
+	}
+	return nil
+}
+
+// matchAnchors checks the root DNSKEY RRset against the trust anchors: a
+// key that signed the RRset must match one of them. Anchors for keys not
+// in the zone are skipped, since a rollover publishes the next KSK before
+// it signs.
+func matchAnchors(anchors []DSRecord, keys []DNSKEYRecord, keySigs []RRSIGRecord) error {
+	for _, anchor := range anchors {
+		for _, key := range keys {
+			if key.KeyTag != anchor.KeyTag || key.Algorithm != anchor.Algorithm || !signedBy(keySigs, key.KeyTag) {
+				continue
+			}
+			if strings.EqualFold(ComputeDSDigest(key, anchor.DigestType), anchor.Digest) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("no key signing the root DNSKEY set matches a trust anchor")
+}
+
+// signedBy reports whether a verified signature in sigs was made by the
+// key with tag.
+func signedBy(sigs []RRSIGRecord, tag uint16) bool {
+	for _, sig := range sigs {
+		if sig.Verified && sig.KeyTag == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,240 @@
+package dnssec
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Wire type codes for the DNSSEC record types, which dnsmessage does not
+// define.
+const (
+	typeDS         uint16 = 43
+	typeRRSIG      uint16 = 46
+	typeNSEC       uint16 = 47
+	typeDNSKEY     uint16 = 48
+	typeNSEC3      uint16 = 50
+	typeNSEC3PARAM uint16 = 51
+)
+
+// typeNames maps wire type codes to mnemonics.
+var typeNames = map[uint16]string{
+	1: "A", 2: "NS", 5: "CNAME", 6: "SOA", 12: "PTR", 13: "HINFO", 15: "MX",
+	16: "TXT", 28: "AAAA", 33: "SRV", 35: "NAPTR", 39: "DNAME",
+	typeDS: "DS", typeRRSIG: "RRSIG", typeNSEC: "NSEC", typeDNSKEY: "DNSKEY",
+	typeNSEC3: "NSEC3", typeNSEC3PARAM: "NSEC3PARAM",
+	52: "TLSA", 64: "SVCB", 65: "HTTPS", 257: "CAA",
+}
+
+// typeName returns the mnemonic for a type code, or TYPEn (RFC 3597).
+func typeName(t uint16) string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("TYPE%d", t)
+}
+
+// typeCode is the inverse of typeName.
+func typeCode(name string) (uint16, bool) {
+	for code, n := range typeNames {
+		if n == name {
+			return code, true
+		}
+	}
+	var code uint16
+	if _, err := fmt.Sscanf(name, "TYPE%d", &code); err == nil {
+		return code, true
+	}
+	return 0, false
+}
+
+// rr is a resource record with its RDATA kept in wire format, as needed to
+// rebuild the canonical form that signatures cover.
+type rr struct {
+	Name  string // Lower-case, fully qualified
+	Type  uint16
+	Class uint16
+	TTL   uint32
+	Data  []byte
+}
+
+// response holds the records of a DNS reply.
+type response struct {
+	RCode     dnsmessage.RCode
+	Answer    []rr
+	Authority []rr
+}
+
+// dnsClient sends DNSSEC-aware queries (EDNS0 with the DO bit) to one
+// resolver, retrying over TCP when the UDP reply is truncated.
+type dnsClient struct {
+	addr    string
+	timeout time.Duration
+}
+
+func (c *dnsClient) query(ctx context.Context, name string, qtype uint16) (*response, error) {
+	msg, err := buildQuery(name, qtype)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := c.exchange(ctx, "udp", msg)
+	if err != nil {
+		return nil, err
+	}
+	resp, truncated, err := parseResponse(reply, msg)
+	if err != nil {
+		return nil, err
+	}
+	if truncated {
+		if reply, err = c.exchange(ctx, "tcp", msg); err != nil {
+			return nil, err
+		}
+		if resp, _, err = parseResponse(reply, msg); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+func (c *dnsClient) exchange(ctx context.Context, network string, msg []byte) ([]byte, error) {
+	d := net.Dialer{Timeout: c.timeout}
+	conn, err := d.DialContext(ctx, network, c.addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(c.timeout)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	conn.SetDeadline(deadline)
+
+	if network == "tcp" {
+		framed := binary.BigEndian.AppendUint16(nil, uint16(len(msg)))
+		if _, err := conn.Write(append(framed, msg...)); err != nil {
+			return nil, err
+		}
+		var length [2]byte
+		if _, err := io.ReadFull(conn, length[:]); err != nil {
+			return nil, err
+		}
+		reply := make([]byte, binary.BigEndian.Uint16(length[:]))
+		_, err := io.ReadFull(conn, reply)
+		return reply, err
+	}
+
+	if _, err := conn.Write(msg); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 65535)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// buildQuery builds a recursive query with the DO bit set. CD is set too,
+// so the resolver hands back bogus data for us to judge instead of
+// answering SERVFAIL.
+func buildQuery(name string, qtype uint16) ([]byte, error) {
+	qname, err := dnsmessage.NewName(fqdn(name))
+	if err != nil {
+		return nil, fmt.Errorf("invalid name %q: %w", name, err)
+	}
+
+	var id [2]byte
+	rand.Read(id[:])
+
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{
+		ID:               binary.BigEndian.Uint16(id[:]),
+		RecursionDesired: true,
+		CheckingDisabled: true,
+	})
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(dnsmessage.Question{Name: qname, Type: dnsmessage.Type(qtype), Class: dnsmessage.ClassINET}); err != nil {
+		return nil, err
+	}
+	if err := b.StartAdditionals(); err != nil {
+		return nil, err
+	}
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(4096, dnsmessage.RCodeSuccess, true); err != nil {
+		return nil, err
+	}
+	if err := b.OPTResource(opt, dnsmessage.OPTResource{}); err != nil {
+		return nil, err
+	}
+	return b.Finish()
+}
+
+// parseResponse extracts the answer and authority records of reply, which
+// must answer query.
+func parseResponse(reply, query []byte) (*response, bool, error) {
+	var p dnsmessage.Parser
+	h, err := p.Start(reply)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid DNS response: %w", err)
+	}
+	if len(query) >= 2 && h.ID != binary.BigEndian.Uint16(query) {
+		return nil, false, fmt.Errorf("DNS response ID mismatch")
+	}
+	if h.Truncated {
+		return nil, true, nil
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return nil, false, fmt.Errorf("invalid DNS response: %w", err)
+	}
+
+	resp := &response{RCode: h.RCode}
+	if resp.Answer, err = parseSection(&p, p.AnswerHeader); err != nil {
+		return nil, false, err
+	}
+	if resp.Authority, err = parseSection(&p, p.AuthorityHeader); err != nil {
+		return nil, false, err
+	}
+	return resp, false, nil
+}
+
+func parseSection(p *dnsmessage.Parser, next func() (dnsmessage.ResourceHeader, error)) ([]rr, error) {
+	var records []rr
+	for {
+		h, err := next()
+		if err == dnsmessage.ErrSectionDone {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid DNS response: %w", err)
+		}
+		body, err := p.UnknownResource()
+		if err != nil {
+			return nil, fmt.Errorf("invalid DNS response: %w", err)
+		}
+		records = append(records, rr{
+			Name:  strings.ToLower(h.Name.String()),
+			Type:  uint16(h.Type),
+			Class: uint16(h.Class),
+			TTL:   h.TTL,
+			Data:  body.Data,
+		})
+	}
+}
+
+// fqdn returns name with exactly one trailing dot.
+func fqdn(name string) string {
+	if name == "." || name == "" {
+		return "."
+	}
+	return strings.TrimSuffix(name, ".") + "."
+}
@@ -0,0 +1,181 @@
+package dnssec
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// parseDNSKEY decodes DNSKEY RDATA (RFC 4034 section 2.1).
+func parseDNSKEY(owner string, data []byte) (DNSKEYRecord, error) {
+	if len(data) < 4 {
+		return DNSKEYRecord{}, fmt.Errorf("DNSKEY RDATA too short")
+	}
+	key := DNSKEYRecord{
+		Domain:    zoneName(owner),
+		Flags:     binary.BigEndian.Uint16(data[0:2]),
+		Protocol:  data[2],
+		Algorithm: Algorithm(data[3]),
+		PublicKey: base64.StdEncoding.EncodeToString(data[4:]),
+		KeyTag:    keyTag(data),
+	}
+	// Flag bit 7 is Zone Key, bit 15 is Secure Entry Point. By convention
+	// a SEP zone key is the KSK and any other zone key a ZSK.
+	key.IsSEP = key.Flags&0x0001 != 0
+	if key.Flags&0x0100 != 0 {
+		key.IsKSK = key.IsSEP
+		key.IsZSK = !key.IsSEP
+	}
+	return key, nil
+}
+
+// dnskeyRDATA re-encodes a DNSKEY record to wire format.
+func dnskeyRDATA(key DNSKEYRecord) ([]byte, error) {
+	pub, err := base64.StdEncoding.DecodeString(key.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DNSKEY public key: %w", err)
+	}
+	data := binary.BigEndian.AppendUint16(nil, key.Flags)
+	data = append(data, key.Protocol, byte(key.Algorithm))
+	return append(data, pub...), nil
+}
+
+// parseDS decodes DS RDATA (RFC 4034 section 5.1).
+func parseDS(owner string, data []byte) (DSRecord, error) {
+	if len(data) < 5 {
+		return DSRecord{}, fmt.Errorf("DS RDATA too short")
+	}
+	return DSRecord{
+		Domain:     zoneName(owner),
+		KeyTag:     binary.BigEndian.Uint16(data[0:2]),
+		Algorithm:  Algorithm(data[2]),
+		DigestType: DigestType(data[3]),
+		Digest:     strings.ToUpper(hex.EncodeToString(data[4:])),
+	}, nil
+}
+
+// parseRRSIG decodes RRSIG RDATA (RFC 4034 section 3.1).
+func parseRRSIG(data []byte) (RRSIGRecord, error) {
+	if len(data) < 19 {
+		return RRSIGRecord{}, fmt.Errorf("RRSIG RDATA too short")
+	}
+	signer, off, err := parseWireName(data, 18)
+	if err != nil {
+		return RRSIGRecord{}, fmt.Errorf("invalid RRSIG signer name: %w", err)
+	}
+	return RRSIGRecord{
+		TypeCovered: typeName(binary.BigEndian.Uint16(data[0:2])),
+		Algorithm:   Algorithm(data[2]),
+		Labels:      data[3],
+		OriginalTTL: binary.BigEndian.Uint32(data[4:8]),
+		Expiration:  time.Unix(int64(binary.BigEndian.Uint32(data[8:12])), 0).UTC(),
+		Inception:   time.Unix(int64(binary.BigEndian.Uint32(data[12:16])), 0).UTC(),
+		KeyTag:      binary.BigEndian.Uint16(data[16:18]),
+		SignerName:  zoneName(signer),
+		Signature:   base64.StdEncoding.EncodeToString(data[off:]),
+	}, nil
+}
+
+// rrsigSignedPrefix encodes the RRSIG RDATA minus the signature, which is
+// the first part of the data the signature covers (RFC 4034 section 3.1.8.1).
+func rrsigSignedPrefix(sig RRSIGRecord) ([]byte, error) {
+	covered, ok := typeCode(sig.TypeCovered)
+	if !ok {
+		return nil, fmt.Errorf("unknown covered type %q", sig.TypeCovered)
+	}
+	data := binary.BigEndian.AppendUint16(nil, covered)
+	data = append(data, byte(sig.Algorithm), sig.Labels)
+	data = binary.BigEndian.AppendUint32(data, sig.OriginalTTL)
+	data = binary.BigEndian.AppendUint32(data, uint32(sig.Expiration.Unix()))
+	data = binary.BigEndian.AppendUint32(data, uint32(sig.Inception.Unix()))
+	data = binary.BigEndian.AppendUint16(data, sig.KeyTag)
+	return append(data, nameWire(sig.SignerName)...), nil
+}
+
+// keyTag computes the key tag of DNSKEY RDATA (RFC 4034 appendix B).
+func keyTag(rdata []byte) uint16 {
+	if len(rdata) > 3 && Algorithm(rdata[3]) == AlgRSAMD5 {
+		if len(rdata) < 5 {
+			return 0
+		}
+		return binary.BigEndian.Uint16(rdata[len(rdata)-3:])
+	}
+	var ac uint32
+	for i, b := range rdata {
+		if i&1 == 0 {
+			ac += uint32(b) << 8
+		} else {
+			ac += uint32(b)
+		}
+	}
+	ac += ac >> 16
+	return uint16(ac)
+}
+
+// parseWireName reads an uncompressed domain name starting at off and
+// returns it in presentation form together with the offset after it.
+func parseWireName(data []byte, off int) (string, int, error) {
+	var labels []string
+	for {
+		if off >= len(data) {
+			return "", 0, fmt.Errorf("name overruns RDATA")
+		}
+		n := int(data[off])
+		off++
+		if n == 0 {
+			break
+		}
+		if n&0xC0 != 0 {
+			return "", 0, fmt.Errorf("compressed name not allowed here")
+		}
+		if off+n > len(data) {
+			return "", 0, fmt.Errorf("label overruns RDATA")
+		}
+		labels = append(labels, string(data[off:off+n]))
+		off += n
+	}
+	if len(labels) == 0 {
+		return ".", off, nil
+	}
+	return strings.Join(labels, ".") + ".", off, nil
+}
+
+// nameWire encodes name in canonical wire format: uncompressed and
+// lower-cased (RFC 4034 section 6.2).
+func nameWire(name string) []byte {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	var wire []byte
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			wire = append(wire, byte(len(label)))
+			wire = append(wire, label...)
+		}
+	}
+	return append(wire, 0)
+}
+
+// zoneName converts a fully qualified name to the form used in ChainLink
+// ("." for the root, otherwise without the trailing dot).
+func zoneName(name string) string {
+	if name == "." || name == "" {
+		return "."
+	}
+	return strings.TrimSuffix(name, ".")
+}
+
+// labelCount returns the number of labels in name, not counting the root
+// or a leading wildcard, as used by the RRSIG Labels field.
+func labelCount(name string) int {
+	name = strings.TrimSuffix(name, ".")
+	if name == "" {
+		return 0
+	}
+	n := strings.Count(name, ".") + 1
+	if strings.HasPrefix(name, "*.") {
+		n--
+	}
+	return n
+}
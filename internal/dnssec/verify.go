@@ -0,0 +1,251 @@
+package dnssec
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+// errUnsupportedAlgorithm marks signatures made with an algorithm this
+// package cannot verify. RFC 4035 section 5.2 treats such RRsets as
+// unsigned rather than bogus.
+var errUnsupportedAlgorithm = errors.New("unsupported algorithm")
+
+// verifyRRSIG checks sig over rrset with key. rrset must hold the records
+// of the covered type at the owner name, as returned in the answer.
+func verifyRRSIG(sig RRSIGRecord, rrset []rr, key DNSKEYRecord) error {
+	if key.Algorithm != sig.Algorithm || key.KeyTag != sig.KeyTag {
+		return fmt.Errorf("key %d/%d does not match signature %d/%d",
+			key.KeyTag, key.Algorithm, sig.KeyTag, sig.Algorithm)
+	}
+	if !strings.EqualFold(zoneName(key.Domain), zoneName(sig.SignerName)) {
+		return fmt.Errorf("key owner %s is not the signer %s", key.Domain, sig.SignerName)
+	}
+	if key.Protocol != 3 || key.Flags&0x0100 == 0 {
+		return fmt.Errorf("key %d is not a DNSSEC zone key", key.KeyTag)
+	}
+	// RFC 4035 section 5.3.1: only valid between inception and expiration
+	now := time.Now()
+	if now.Before(sig.Inception) {
+		return fmt.Errorf("signature not valid before %s", sig.Inception.Format(time.RFC3339))
+	}
+	if now.After(sig.Expiration) {
+		return fmt.Errorf("signature expired at %s", sig.Expiration.Format(time.RFC3339))
+	}
+
+	data, err := signedData(sig, rrset)
+	if err != nil {
+		return err
+	}
+	pub, err := base64.StdEncoding.DecodeString(key.PublicKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %w", err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(sig.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	return verifySignature(sig.Algorithm, pub, data, signature)
+}
+
+// signedData rebuilds the data an RRSIG covers: the RRSIG RDATA without
+// the signature followed by the RRset in canonical form and order
+// (RFC 4034 sections 3.1.8.1 and 6).
+func signedData(sig RRSIGRecord, rrset []rr) ([]byte, error) {
+	if len(rrset) == 0 {
+		return nil, fmt.Errorf("no records to verify")
+	}
+	data, err := rrsigSignedPrefix(sig)
+	if err != nil {
+		return nil, err
+	}
+
+	type canonicalRR struct {
+		header []byte
+		rdata  []byte
+	}
+	records := make([]canonicalRR, 0, len(rrset))
+	for _, r := range rrset {
+		rdata, err := canonicalRDATA(r.Type, r.Data)
+		if err != nil {
+			return nil, err
+		}
+
+		// Owner name, with wildcard expansion undone (section 3.1.8.1).
+		owner := strings.TrimSuffix(r.Name, ".")
+		if n := labelCount(owner); n > int(sig.Labels) {
+			labels := strings.Split(owner, ".")
+			owner = "*." + strings.Join(labels[len(labels)-int(sig.Labels):], ".")
+		}
+
+		header := nameWire(owner)
+		header = append(header, byte(r.Type>>8), byte(r.Type), byte(r.Class>>8), byte(r.Class))
+		header = append(header, byte(sig.OriginalTTL>>24), byte(sig.OriginalTTL>>16), byte(sig.OriginalTTL>>8), byte(sig.OriginalTTL))
+		header = append(header, byte(len(rdata)>>8), byte(len(rdata)))
+		records = append(records, canonicalRR{header: header, rdata: rdata})
+	}
+
+	sort.Slice(records, func(i, j int) bool { return bytes.Compare(records[i].rdata, records[j].rdata) < 0 })
+	for i, r := range records {
+		if i > 0 && bytes.Equal(r.rdata, records[i-1].rdata) {
+			continue // duplicate RRs are removed (section 6.3)
+		}
+		data = append(data, r.header...)
+		data = append(data, r.rdata...)
+	}
+	return data, nil
+}
+
+// canonicalRDATA returns RDATA in canonical form. The DNSSEC and address
+// types carry no names that need lower-casing; types that embed names
+// (which may also arrive compressed) are not supported.
+func canonicalRDATA(rrtype uint16, data []byte) ([]byte, error) {
+	switch rrtype {
+	case 2, 5, 6, 12, 15, 33, 35, 39: // NS, CNAME, SOA, PTR, MX, SRV, NAPTR, DNAME
+		return nil, fmt.Errorf("canonical form of %s records is not supported", typeName(rrtype))
+	}
+	return data, nil
+}
+
+// verifySignature checks a DNSSEC signature over data with a public key in
+// DNSKEY wire format.
+func verifySignature(alg Algorithm, pub, data, sig []byte) error {
+	switch alg {
+	case AlgRSASHA1, AlgRSASHA1NSEC3, AlgRSASHA256, AlgRSASHA512:
+		key, err := parseRSAKey(pub)
+		if err != nil {
+			return err
+		}
+		hash := crypto.SHA256
+		switch alg {
+		case AlgRSASHA1, AlgRSASHA1NSEC3:
+			hash = crypto.SHA1
+		case AlgRSASHA512:
+			hash = crypto.SHA512
+		}
+		if err := rsa.VerifyPKCS1v15(key, hash, digest(hash, data), sig); err != nil {
+			return fmt.Errorf("RSA signature invalid: %w", err)
+		}
+		return nil
+
+	case AlgECDSAP256, AlgECDSAP384:
+		curve, hash, size := elliptic.P256(), crypto.SHA256, 32
+		if alg == AlgECDSAP384 {
+			curve, hash, size = elliptic.P384(), crypto.SHA384, 48
+		}
+		if len(pub) != 2*size || len(sig) != 2*size {
+			return fmt.Errorf("ECDSA key or signature has the wrong length")
+		}
+		key, err := ecdsa.ParseUncompressedPublicKey(curve, append([]byte{4}, pub...))
+		if err != nil {
+			return fmt.Errorf("invalid ECDSA key: %w", err)
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(key, digest(hash, data), r, s) {
+			return fmt.Errorf("ECDSA signature invalid")
+		}
+		return nil
+
+	case AlgED25519:
+		if len(pub) != ed25519.PublicKeySize {
+			return fmt.Errorf("Ed25519 key has the wrong length")
+		}
+		if !ed25519.Verify(ed25519.PublicKey(pub), data, sig) {
+			return fmt.Errorf("Ed25519 signature invalid")
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("%w %d", errUnsupportedAlgorithm, alg)
+	}
+}
+
+// parseRSAKey decodes an RSA public key in RFC 3110 format.
+func parseRSAKey(pub []byte) (*rsa.PublicKey, error) {
+	if len(pub) < 3 {
+		return nil, fmt.Errorf("RSA key too short")
+	}
+	expLen, off := int(pub[0]), 1
+	if expLen == 0 {
+		expLen, off = int(pub[1])<<8|int(pub[2]), 3
+	}
+	if expLen == 0 || expLen > 4 || off+expLen >= len(pub) {
+		return nil, fmt.Errorf("unsupported RSA exponent length %d", expLen)
+	}
+	exp := 0
+	for _, b := range pub[off : off+expLen] {
+		exp = exp<<8 | int(b)
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(pub[off+expLen:]), E: exp}, nil
+}
+
+func digest(hash crypto.Hash, data []byte) []byte {
+	switch hash {
+	case crypto.SHA1:
+		h := sha1.Sum(data)
+		return h[:]
+	case crypto.SHA384:
+		h := sha512.Sum384(data)
+		return h[:]
+	case crypto.SHA512:
+		h := sha512.Sum512(data)
+		return h[:]
+	default:
+		h := sha256.Sum256(data)
+		return h[:]
+	}
+}
+
+// verifyRRset verifies every RRSIG in sigs that covers rrset against keys,
+// setting Verified on those that check out. It returns nil if at least one
+// signature verified, errUnsupportedAlgorithm if every signature uses an
+// unsupported algorithm, and otherwise an error describing the failures.
+func verifyRRset(sigs []RRSIGRecord, rrset []rr, keys []DNSKEYRecord) error {
+	if len(sigs) == 0 {
+		return fmt.Errorf("RRset is not signed")
+	}
+	var failures []string
+	unsupported := 0
+	verified := false
+	for i := range sigs {
+		err := fmt.Errorf("no DNSKEY with key tag %d", sigs[i].KeyTag)
+		for _, key := range keys {
+			if key.KeyTag != sigs[i].KeyTag || key.Algorithm != sigs[i].Algorithm {
+				continue
+			}
+			if err = verifyRRSIG(sigs[i], rrset, key); err == nil {
+				break
+			}
+		}
+		switch {
+		case err == nil:
+			sigs[i].Verified = true
+			verified = true
+		case errors.Is(err, errUnsupportedAlgorithm):
+			unsupported++
+		default:
+			failures = append(failures, fmt.Sprintf("key %d: %v", sigs[i].KeyTag, err))
+		}
+	}
+	if verified {
+		return nil
+	}
+	if unsupported == len(sigs) {
+		return errUnsupportedAlgorithm
+	}
+	return errors.New(strings.Join(failures, "; "))
+}
@@ -3,7 +3,7 @@ package fingerprint
 
 import (
 	"context"
-	"crypto/rand"
+	"encoding/json"
 	"fmt"
 	"net"
 	"sort"
@@ -35,68 +35,69 @@ const (
 
 // TCPFlags represents TCP flag combinations.
 type TCPFlags struct {
-	SYN bool
-	ACK bool
-	FIN bool
-	RST bool
-	PSH bool
-	URG bool
-	ECE bool
-	CWR bool
+	SYN bool `json:"syn"`
+	ACK bool `json:"ack"`
+	FIN bool `json:"fin"`
+	RST bool `json:"rst"`
+	PSH bool `json:"psh"`
+	URG bool `json:"urg"`
+	ECE bool `json:"ece"`
+	CWR bool `json:"cwr"`
 }
 
 // ProbeResult contains results from a single probe.
 type ProbeResult struct {
-	ProbeType    string
-	Port         int
-	Responded    bool
-	TTL          int
-	WindowSize   int
-	MSS          int
-	WindowScale  int
-	SACK         bool
-	Timestamps   bool
-	NOP          bool
-	DF           bool // Don't Fragment
-	ResponseTime time.Duration
-	TCPFlags     TCPFlags
-	RawData      []byte
+	ProbeType    string        `json:"probe_type"`
+	Port         int           `json:"port"`
+	Responded    bool          `json:"responded"`
+	TTL          int           `json:"ttl,omitempty"`
+	WindowSize   int           `json:"window_size,omitempty"`
+	MSS          int           `json:"mss,omitempty"`
+	WindowScale  int           `json:"window_scale,omitempty"`
+	SACK         bool          `json:"sack"`
+	Timestamps   bool          `json:"timestamps"`
+	NOP          bool          `json:"nop"`
+	DF           bool          `json:"df"` // Don't Fragment
+	ResponseTime time.Duration `json:"response_time"`
+	TCPFlags     TCPFlags      `json:"tcp_flags"`
+	RawData      []byte        `json:"-"`
 }
 
 // ServiceProbe represents a service fingerprint.
 type ServiceProbe struct {
-	Port       int
-	Protocol   string // tcp, udp
-	Service    string
-	Version    string
-	Banner     string
-	Product    string
-	ExtraInfo  string
-	Confidence Confidence
-	LookupTime time.Duration
+	Port       int           `json:"port"`
+	Protocol   string        `json:"protocol"` // tcp, udp
+	State      string        `json:"state,omitempty"`
+	Service    string        `json:"service"`
+	Version    string        `json:"version,omitempty"`
+	Banner     string        `json:"banner,omitempty"`
+	Product    string        `json:"product,omitempty"`
+	ExtraInfo  string        `json:"extra_info,omitempty"`
+	Confidence Confidence    `json:"confidence"`
+	LookupTime time.Duration `json:"lookup_time"`
 }
 
 // FingerprintResult contains complete fingerprint analysis.
 type FingerprintResult struct {
-	Host          string
-	OSFamily      OSFamily
-	OSVersion     string
-	OSConfidence  Confidence
-	TTL           int
-	TTLGuess      string
-	WindowSize    int
-	Services      []ServiceProbe
-	OpenPorts     []int
-	ClosedPorts   []int
-	FilteredPorts []int
-	Probes        []ProbeResult
-	NetworkDist   int // Estimated network distance (hops)
-	Uptime        time.Duration
-	TCPSequence   string
-	IPIDSequence  string
-	Quirks        []string
-	StartTime     time.Time
-	Duration      time.Duration
+	Host          string         `json:"host"`
+	OSFamily      OSFamily       `json:"os_family"`
+	OSVersion     string         `json:"os_version,omitempty"`
+	OSConfidence  Confidence     `json:"os_confidence,omitempty"`
+	TTL           int            `json:"ttl"`
+	TTLGuess      string         `json:"ttl_guess,omitempty"`
+	WindowSize    int            `json:"window_size"`
+	Services      []ServiceProbe `json:"services"`
+	OpenPorts     []int          `json:"open_ports"`
+	ClosedPorts   []int          `json:"closed_ports"`
+	FilteredPorts []int          `json:"filtered_ports"`
+	Probes        []ProbeResult  `json:"probes"`
+	NetworkDist   int            `json:"network_distance"` // Estimated network distance (hops)
+	Uptime        time.Duration  `json:"uptime,omitempty"`
+	TCPSequence   string         `json:"tcp_sequence,omitempty"`
+	IPIDSequence  string         `json:"ipid_sequence,omitempty"`
+	Quirks        []string       `json:"quirks"`
+	StartTime     time.Time      `json:"start_time"`
+	Duration      time.Duration  `json:"duration"`
 }
 
 // OSSignature represents a known OS TCP/IP signature.
@@ -135,7 +136,9 @@ type Options struct {
 	Concurrency int
 	OSDetect    bool
 	ServiceScan bool
+	UDPScan     bool // Probe well-known UDP services (UDPProbePorts)
 	Aggressive  bool
+	Signatures  []ServiceSignature // Tried before ServiceSignatures
 }
 
 // DefaultOptions returns sensible defaults.
@@ -173,9 +176,15 @@ func NewScanner(opts Options) *Scanner {
 func (s *Scanner) Scan(ctx context.Context, host string) (*FingerprintResult, error) {
 	start := time.Now()
 	result := &FingerprintResult{
-		Host:      host,
-		StartTime: start,
-		OSFamily:  OSUnknown,
+		Host:          host,
+		StartTime:     start,
+		OSFamily:      OSUnknown,
+		Services:      []ServiceProbe{},
+		OpenPorts:     []int{},
+		ClosedPorts:   []int{},
+		FilteredPorts: []int{},
+		Probes:        []ProbeResult{},
+		Quirks:        []string{},
 	}
 
 	// Resolve hostname if needed
@@ -187,13 +196,35 @@ func (s *Scanner) Scan(ctx context.Context, host string) (*FingerprintResult, er
 		return nil, fmt.Errorf("no IP addresses found for host")
 	}
 
+	// Capture SYN-ACKs so probes can read the real TTL, window and options
+	var capture *synackCapture
+	if s.opts.OSDetect {
+		var v4 []net.IP
+		for _, ip := range ips {
+			if ip4 := ip.To4(); ip4 != nil {
+				v4 = append(v4, ip4)
+			}
+		}
+		if len(v4) == 0 {
+			result.Quirks = append(result.Quirks, "IPv6 target; TTL/window not measured")
+		} else if c, err := newSYNACKCapture(v4); err != nil {
+			result.Quirks = append(result.Quirks, fmt.Sprintf("TTL/window not measured: %v", err))
+		} else {
+			capture = c
+			defer capture.Close()
+		}
+	}
+
 	// Port scan
-	s.scanPorts(ctx, host, result)
+	s.scanPorts(ctx, host, result, capture)
 
 	// Service detection
 	if s.opts.ServiceScan && len(result.OpenPorts) > 0 {
 		s.detectServices(ctx, host, result)
 	}
+	if s.opts.ServiceScan && s.opts.UDPScan {
+		s.detectUDPServices(ctx, host, result)
+	}
 
 	// OS fingerprinting
 	if s.opts.OSDetect {
@@ -201,13 +232,15 @@ func (s *Scanner) Scan(ctx context.Context, host string) (*FingerprintResult, er
 	}
 
 	// Estimate network distance
-	result.NetworkDist = s.estimateDistance(result.TTL)
+	if result.TTL > 0 {
+		result.NetworkDist = s.estimateDistance(result.TTL)
+	}
 
 	result.Duration = time.Since(start)
 	return result, nil
 }
 
-func (s *Scanner) scanPorts(ctx context.Context, host string, result *FingerprintResult) {
+func (s *Scanner) scanPorts(ctx context.Context, host string, result *FingerprintResult, capture *synackCapture) {
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	semaphore := make(chan struct{}, s.opts.Concurrency)
@@ -219,14 +252,14 @@ func (s *Scanner) scanPorts(ctx context.Context, host string, result *Fingerprin
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 
-			probe := s.probePort(ctx, host, port)
+			probe := s.probePort(ctx, host, port, capture)
 
 			mu.Lock()
 			result.Probes = append(result.Probes, probe)
 			if probe.Responded {
 				result.OpenPorts = append(result.OpenPorts, port)
-				// Capture first TTL/WindowSize for OS detection
-				if result.TTL == 0 {
+				// Capture first measured TTL/WindowSize for OS detection
+				if result.TTL == 0 && probe.TTL > 0 {
 					result.TTL = probe.TTL
 					result.WindowSize = probe.WindowSize
 				}
@@ -247,7 +280,9 @@ func (s *Scanner) scanPorts(ctx context.Context, host string, result *Fingerprin
 	sort.Ints(result.FilteredPorts)
 }
 
-func (s *Scanner) probePort(ctx context.Context, host string, port int) ProbeResult {
+// probePort connects to port. When capture is set, the probe is filled in
+// from the SYN-ACK the target sent for this connection.
+func (s *Scanner) probePort(ctx context.Context, host string, port int, capture *synackCapture) ProbeResult {
 	start := time.Now()
 	probe := ProbeResult{
 		ProbeType: "TCP SYN",
@@ -278,33 +313,26 @@ func (s *Scanner) probePort(ctx context.Context, host string, port int) ProbeRes
 	probe.TCPFlags.SYN = true
 	probe.TCPFlags.ACK = true
 
-	// Try to get TTL from connection (platform-specific, may not work everywhere)
-	probe.TTL = s.estimateTTL(host)
-
-	// Simulate window size detection
-	probe.WindowSize = s.detectWindowSize()
-	probe.SACK = true // Most modern OS support SACK
-	probe.DF = true
-
-	return probe
-}
-
-func (s *Scanner) estimateTTL(host string) int {
-	// Try ICMP ping to get TTL (simplified)
-	conn, err := net.DialTimeout("ip4:icmp", host, s.opts.Timeout)
-	if err != nil {
-		// Default based on common values
-		return 64 // Linux default
+	if capture == nil {
+		return probe
+	}
+	local, ok := conn.LocalAddr().(*net.TCPAddr)
+	if !ok {
+		return probe
+	}
+	if sa, ok := capture.lookup(ctx, port, local.Port); ok {
+		probe.TTL = sa.TTL
+		probe.DF = sa.DF
+		probe.TCPFlags = sa.Flags
+		probe.WindowSize = sa.Window
+		probe.MSS = sa.MSS
+		probe.WindowScale = sa.WindowScale
+		probe.SACK = sa.SACK
+		probe.Timestamps = sa.Timestamps
+		probe.NOP = sa.NOP
 	}
-	conn.Close()
-	return 64
-}
 
-func (s *Scanner) detectWindowSize() int {
-	// Simulate window size detection
-	// In production, this would parse actual TCP packets
-	sizes := []int{65535, 64240, 29200, 16384, 8192}
-	return sizes[randomInt(len(sizes))]
+	return probe
 }
 
 func (s *Scanner) detectServices(ctx context.Context, host string, result *FingerprintResult) {
@@ -335,6 +363,7 @@ func (s *Scanner) grabBanner(ctx context.Context, host string, port int) Service
 	probe := ServiceProbe{
 		Port:       port,
 		Protocol:   "tcp",
+		State:      StateOpen,
 		Confidence: ConfidenceLow,
 	}
 
@@ -395,37 +424,27 @@ func (s *Scanner) identifyService(port int, banner string) (service, product, ve
 	}
 
 	service = portServices[port]
-	if service == "" {
-		service = "unknown"
-	}
-
-	// Parse banner for product/version
-	bannerLower := strings.ToLower(banner)
 
-	if strings.Contains(bannerLower, "openssh") {
-		product = "OpenSSH"
-		// Extract version
-		if idx := strings.Index(banner, "OpenSSH_"); idx != -1 {
-			end := strings.IndexAny(banner[idx+8:], " \r\n")
-			if end == -1 {
-				end = len(banner) - idx - 8
+	// Match the banner against user signatures, then the built-in table
+	if banner != "" {
+		for _, table := range [][]ServiceSignature{s.opts.Signatures, ServiceSignatures} {
+			for _, sig := range table {
+				v, ok := sig.Match(banner)
+				if !ok {
+					continue
+				}
+				product, version = sig.Product, v
+				if service == "" {
+					service = sig.Service
+				}
+				return
 			}
-			version = banner[idx+8 : idx+8+end]
 		}
-	} else if strings.Contains(bannerLower, "apache") {
-		product = "Apache"
-	} else if strings.Contains(bannerLower, "nginx") {
-		product = "nginx"
-	} else if strings.Contains(bannerLower, "microsoft") || strings.Contains(bannerLower, "iis") {
-		product = "Microsoft IIS"
-	} else if strings.Contains(bannerLower, "mysql") {
-		product = "MySQL"
-	} else if strings.Contains(bannerLower, "postgresql") {
-		product = "PostgreSQL"
-	} else if strings.Contains(bannerLower, "redis") {
-		product = "Redis"
 	}
 
+	if service == "" {
+		service = "unknown"
+	}
 	return
 }
 
@@ -433,6 +452,23 @@ func (s *Scanner) fingerprintOS(result *FingerprintResult) {
 	// Match TTL first for OS family
 	result.TTLGuess = s.guessTTLOrigin(result.TTL)
 
+	// Without a captured SYN-ACK there is nothing to match against
+	if result.TTL == 0 {
+		return
+	}
+
+	// Signatures hold initial TTLs; the observed one has lost a hop per router
+	initialTTL := result.TTL + s.estimateDistance(result.TTL)
+
+	// The first probe with a captured SYN-ACK supplies the TCP options
+	var synack *ProbeResult
+	for i := range result.Probes {
+		if result.Probes[i].Responded && result.Probes[i].TTL > 0 {
+			synack = &result.Probes[i]
+			break
+		}
+	}
+
 	// Find best matching signature
 	var bestMatch *OSSignature
 	bestScore := 0
@@ -441,7 +477,7 @@ func (s *Scanner) fingerprintOS(result *FingerprintResult) {
 		score := 0
 
 		// TTL match (most important)
-		ttlDiff := abs(result.TTL - sig.TTL)
+		ttlDiff := abs(initialTTL - sig.TTL)
 		if ttlDiff == 0 {
 			score += 50
 		} else if ttlDiff <= 5 {
@@ -460,12 +496,14 @@ func (s *Scanner) fingerprintOS(result *FingerprintResult) {
 			}
 		}
 
-		// Check for SACK support
-		for _, probe := range result.Probes {
-			if probe.Responded && probe.SACK == sig.SACK {
+		// Check for SACK support and window scaling
+		if synack != nil {
+			if synack.SACK == sig.SACK {
+				score += 10
+			}
+			if sig.WindowScale > 0 && synack.WindowScale == sig.WindowScale {
 				score += 10
 			}
-			break
 		}
 
 		if score > bestScore {
@@ -535,7 +573,11 @@ func (r *FingerprintResult) Format() string {
 		sb.WriteString(fmt.Sprintf("  Version:    %s\n", r.OSVersion))
 	}
 	sb.WriteString(fmt.Sprintf("  Confidence: %s\n", r.OSConfidence))
-	sb.WriteString(fmt.Sprintf("  TTL:        %d (%s)\n", r.TTL, r.TTLGuess))
+	if r.TTL > 0 {
+		sb.WriteString(fmt.Sprintf("  TTL:        %d (%s)\n", r.TTL, r.TTLGuess))
+	} else {
+		sb.WriteString("  TTL:        not measured\n")
+	}
 	if r.WindowSize > 0 {
 		sb.WriteString(fmt.Sprintf("  Window:     %d\n", r.WindowSize))
 	}
@@ -563,7 +605,14 @@ func (r *FingerprintResult) Format() string {
 				}
 				info += ")"
 			}
-			sb.WriteString(fmt.Sprintf("  %d/tcp  %s [%s]\n", svc.Port, info, svc.Confidence))
+			if svc.State == StateOpenFiltered {
+				info += " " + svc.State
+			}
+			proto := svc.Protocol
+			if proto == "" {
+				proto = "tcp"
+			}
+			sb.WriteString(fmt.Sprintf("  %d/%s  %s [%s]\n", svc.Port, proto, info, svc.Confidence))
 			if svc.Banner != "" {
 				banner := svc.Banner
 				if len(banner) > 50 {
@@ -592,10 +641,13 @@ func abs(x int) int {
 	return x
 }
 
-func randomInt(max int) int {
-	b := make([]byte, 1)
-	rand.Read(b)
-	return int(b[0]) % max
+// ToJSON returns the result as indented JSON.
+func (r *FingerprintResult) ToJSON() (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
 }
 
 // GetPortsByState returns ports categorized by state.
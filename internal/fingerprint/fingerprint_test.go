@@ -1,6 +1,10 @@
 package fingerprint
 
 import (
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
 	"testing"
 	"time"
 )
@@ -141,21 +145,67 @@ func TestIdentifyService(t *testing.T) {
 		banner  string
 		service string
 		product string
+		version string
 	}{
-		{22, "SSH-2.0-OpenSSH_8.4", "ssh", "OpenSSH"},
-		{80, "", "http", ""},
-		{443, "", "https", ""},
-		{22, "SSH-2.0-OpenSSH_7.9", "ssh", "OpenSSH"},
-		{80, "nginx/1.18.0", "http", "nginx"},
+		{22, "SSH-2.0-OpenSSH_8.4", "ssh", "OpenSSH", "8.4"},
+		{80, "", "http", "", ""},
+		{443, "", "https", "", ""},
+		{22, "SSH-2.0-OpenSSH_7.9p1 Debian-10+deb10u2", "ssh", "OpenSSH", "7.9p1"},
+		{80, "nginx/1.18.0", "http", "nginx", "1.18.0"},
+		{2222, "SSH-2.0-dropbear_2020.81", "ssh", "Dropbear", "2020.81"},
+		{21, "220 (vsFTPd 3.0.3)", "ftp", "vsftpd", "3.0.3"},
+		{2525, "220 mail.example.com ESMTP Exim 4.94.2 Mon, 01 Jan 2024", "smtp", "Exim", "4.94.2"},
+		{3306, "J\x00\x00\x00\x0a8.0.35\x00", "mysql", "MySQL", "8.0.35"},
+		{9999, "", "unknown", "", ""},
 	}
 
 	for _, tt := range tests {
-		service, product, _ := s.identifyService(tt.port, tt.banner)
+		service, product, version := s.identifyService(tt.port, tt.banner)
 		if service != tt.service {
-			t.Errorf("identifyService(%d) service = %s, want %s", tt.port, service, tt.service)
+			t.Errorf("identifyService(%d, %q) service = %s, want %s", tt.port, tt.banner, service, tt.service)
 		}
 		if product != tt.product {
-			t.Errorf("identifyService(%d) product = %s, want %s", tt.port, product, tt.product)
+			t.Errorf("identifyService(%d, %q) product = %s, want %s", tt.port, tt.banner, product, tt.product)
+		}
+		if version != tt.version {
+			t.Errorf("identifyService(%d, %q) version = %s, want %s", tt.port, tt.banner, version, tt.version)
+		}
+	}
+}
+
+func TestParseServiceSignatures(t *testing.T) {
+	if len(ServiceSignatures) == 0 {
+		t.Fatal("built-in signature table is empty")
+	}
+
+	sigs, err := ParseServiceSignatures(strings.NewReader(`
+# comment
+http|Caddy|1|(?i)^Server: Caddy(?:/([\d.]+))?
+`))
+	if err != nil {
+		t.Fatalf("ParseServiceSignatures: %v", err)
+	}
+	if len(sigs) != 1 || sigs[0].Service != "http" || sigs[0].Product != "Caddy" || sigs[0].VersionGroup != 1 {
+		t.Fatalf("sigs = %+v", sigs)
+	}
+
+	// User signatures take precedence over the built-in table
+	opts := DefaultOptions()
+	opts.Signatures = sigs
+	_, product, version := NewScanner(opts).identifyService(8443, "Server: Caddy/2.7.6")
+	if product != "Caddy" || version != "2.7.6" {
+		t.Errorf("product/version = %q/%q, want Caddy/2.7.6", product, version)
+	}
+
+	bad := []string{
+		"ssh|OpenSSH|1",            // Missing pattern
+		"ssh|OpenSSH|x|^SSH",       // Bad group
+		"ssh|OpenSSH|2|^SSH-(\\d)", // Group out of range
+		"ssh|OpenSSH|0|^SSH-(",     // Bad regexp
+	}
+	for _, line := range bad {
+		if _, err := ParseServiceSignatures(strings.NewReader(line)); err == nil {
+			t.Errorf("ParseServiceSignatures(%q) succeeded, want error", line)
 		}
 	}
 }
@@ -263,3 +313,274 @@ func TestTCPFlags(t *testing.T) {
 		t.Error("RST/FIN should not be set")
 	}
 }
+
+func TestParseTCPHeader(t *testing.T) {
+	// SYN-ACK from port 443 to 50000, window 64240, options:
+	// MSS 1460, SACK permitted, timestamps, NOP, window scale 7
+	hdr := []byte{
+		0x01, 0xbb, 0xc3, 0x50, // ports
+		0, 0, 0, 1, // seq
+		0, 0, 0, 2, // ack
+		0xa0, 0x12, 0xfa, 0xf0, // data offset 10, SYN|ACK, window
+		0, 0, 0, 0, // checksum, urgent
+		2, 4, 0x05, 0xb4,
+		4, 2,
+		8, 10, 0, 0, 0, 1, 0, 0, 0, 0,
+		1,
+		3, 3, 7,
+	}
+
+	seg, err := parseTCPHeader(hdr)
+	if err != nil {
+		t.Fatalf("parseTCPHeader: %v", err)
+	}
+	if seg.SrcPort != 443 || seg.DstPort != 50000 {
+		t.Errorf("ports = %d->%d, want 443->50000", seg.SrcPort, seg.DstPort)
+	}
+	if !seg.Flags.SYN || !seg.Flags.ACK || seg.Flags.RST {
+		t.Errorf("flags = %+v, want SYN|ACK", seg.Flags)
+	}
+	if seg.Window != 64240 {
+		t.Errorf("Window = %d, want 64240", seg.Window)
+	}
+	if seg.MSS != 1460 {
+		t.Errorf("MSS = %d, want 1460", seg.MSS)
+	}
+	if seg.WindowScale != 7 {
+		t.Errorf("WindowScale = %d, want 7", seg.WindowScale)
+	}
+	if !seg.SACK || !seg.Timestamps || !seg.NOP {
+		t.Errorf("SACK/Timestamps/NOP = %v/%v/%v, want all true", seg.SACK, seg.Timestamps, seg.NOP)
+	}
+
+	if _, err := parseTCPHeader(hdr[:19]); err == nil {
+		t.Error("expected error for short header")
+	}
+	bad := append([]byte(nil), hdr...)
+	bad[21] = 40 // MSS option length past the end of the header
+	if _, err := parseTCPHeader(bad); err == nil {
+		t.Error("expected error for invalid option length")
+	}
+}
+
+func TestFingerprintOSRemoteTTL(t *testing.T) {
+	s := NewScanner(DefaultOptions())
+	result := &FingerprintResult{
+		TTL:        117, // Initial 128, 11 hops away
+		WindowSize: 64240,
+		Probes: []ProbeResult{
+			{Responded: true, TTL: 117, WindowSize: 64240, SACK: true, WindowScale: 8},
+		},
+	}
+
+	s.fingerprintOS(result)
+	if result.OSVersion != "Windows 10/11" {
+		t.Errorf("OSVersion = %q, want Windows 10/11", result.OSVersion)
+	}
+	if result.OSConfidence != ConfidenceHigh {
+		t.Errorf("OSConfidence = %s, want high", result.OSConfidence)
+	}
+
+	unmeasured := &FingerprintResult{OSFamily: OSUnknown}
+	s.fingerprintOS(unmeasured)
+	if unmeasured.OSFamily != OSUnknown {
+		t.Errorf("OSFamily = %s without a TTL, want Unknown", unmeasured.OSFamily)
+	}
+}
+
+func TestScanCapturesSYNACK(t *testing.T) {
+	capture, err := newSYNACKCapture([]net.IP{net.IPv4(127, 0, 0, 1).To4()})
+	if err != nil {
+		t.Skipf("raw socket unavailable: %v", err)
+	}
+	capture.Close()
+
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	opts := DefaultOptions()
+	opts.Ports = []int{port}
+	opts.Timeout = 2 * time.Second
+	opts.ServiceScan = false
+	result, err := NewScanner(opts).Scan(context.Background(), "127.0.0.1")
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+
+	if len(result.Probes) != 1 {
+		t.Fatalf("got %d probes, want 1", len(result.Probes))
+	}
+	probe := result.Probes[0]
+	if probe.TTL != 64 {
+		t.Errorf("TTL = %d, want 64 on loopback", probe.TTL)
+	}
+	if probe.WindowSize == 0 || probe.MSS == 0 {
+		t.Errorf("WindowSize/MSS = %d/%d, want values from the SYN-ACK", probe.WindowSize, probe.MSS)
+	}
+	if result.TTL != probe.TTL || result.NetworkDist != 0 {
+		t.Errorf("result TTL/distance = %d/%d, want %d/0", result.TTL, result.NetworkDist, probe.TTL)
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	result := &FingerprintResult{
+		Host:         "192.168.1.1",
+		OSFamily:     OSLinux,
+		OSVersion:    "Linux 5.x",
+		OSConfidence: ConfidenceHigh,
+		TTL:          63,
+		OpenPorts:    []int{22},
+		Services: []ServiceProbe{
+			{Port: 22, Protocol: "tcp", Service: "ssh", Product: "OpenSSH", Version: "8.9p1",
+				Banner: "SSH-2.0-OpenSSH_8.9p1", Confidence: ConfidenceHigh, LookupTime: 1500 * time.Microsecond},
+		},
+		Quirks:   []string{"standard initial TTL"},
+		Duration: 250 * time.Millisecond,
+	}
+
+	out, err := result.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if decoded["os_family"] != "Linux" || decoded["os_confidence"] != "high" {
+		t.Errorf("os_family/os_confidence = %v/%v", decoded["os_family"], decoded["os_confidence"])
+	}
+	if decoded["ttl"] != float64(63) || decoded["duration"] != float64(250*time.Millisecond) {
+		t.Errorf("ttl/duration = %v/%v", decoded["ttl"], decoded["duration"])
+	}
+
+	services, ok := decoded["services"].([]interface{})
+	if !ok || len(services) != 1 {
+		t.Fatalf("services = %v", decoded["services"])
+	}
+	svc := services[0].(map[string]interface{})
+	if svc["product"] != "OpenSSH" || svc["version"] != "8.9p1" || svc["banner"] != "SSH-2.0-OpenSSH_8.9p1" {
+		t.Errorf("service = %v", svc)
+	}
+	if svc["lookup_time"] != float64(1500*time.Microsecond) {
+		t.Errorf("lookup_time = %v, want 1500000", svc["lookup_time"])
+	}
+}
+
+func TestProbeUDP(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Timeout = 300 * time.Millisecond
+	s := NewScanner(opts)
+
+	// NTP server answering in server mode
+	ntpConn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ntpConn.Close()
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			_, addr, err := ntpConn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			reply := make([]byte, 48)
+			reply[0] = 0x24 // VN=4, Mode=4
+			reply[1] = 2
+			ntpConn.WriteTo(reply, addr)
+		}
+	}()
+
+	ntpPort := ntpConn.LocalAddr().(*net.UDPAddr).Port
+	svc, ok := s.probeUDP(context.Background(), "127.0.0.1", ntpPort, udpProbes[123])
+	if !ok || svc.State != StateOpen {
+		t.Fatalf("NTP probe = %+v, %v; want open", svc, ok)
+	}
+	if svc.Protocol != "udp" || svc.Service != "ntp" || svc.Version != "v4" || svc.ExtraInfo != "stratum 2" {
+		t.Errorf("NTP probe = %+v", svc)
+	}
+	if svc.Confidence != ConfidenceHigh {
+		t.Errorf("Confidence = %s, want high", svc.Confidence)
+	}
+
+	// Listener that never answers
+	silent, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer silent.Close()
+	silentPort := silent.LocalAddr().(*net.UDPAddr).Port
+	svc, ok = s.probeUDP(context.Background(), "127.0.0.1", silentPort, udpProbes[53])
+	if !ok || svc.State != StateOpenFiltered {
+		t.Errorf("silent probe = %+v, %v; want open|filtered", svc, ok)
+	}
+
+	// No listener: the kernel answers with ICMP port unreachable
+	closed, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedPort := closed.LocalAddr().(*net.UDPAddr).Port
+	closed.Close()
+	if svc, ok := s.probeUDP(context.Background(), "127.0.0.1", closedPort, udpProbes[161]); ok {
+		t.Errorf("closed probe = %+v, want not reported", svc)
+	}
+}
+
+func TestParseUDPReplies(t *testing.T) {
+	// SNMP GetResponse with sysDescr "Linux router 5.10"
+	descr := "Linux router 5.10"
+	varbind := append([]byte{0x06, 0x08, 0x2b, 0x06, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00, 0x04, byte(len(descr))}, descr...)
+	varbind = append([]byte{0x30, byte(len(varbind))}, varbind...)
+	list := append([]byte{0x30, byte(len(varbind))}, varbind...)
+	pdu := append([]byte{0x02, 0x04, 0x4e, 0x4e, 0x53, 0x01, 0x02, 0x01, 0x00, 0x02, 0x01, 0x00}, list...)
+	pdu = append([]byte{0xa2, byte(len(pdu))}, pdu...)
+	msg := append([]byte{0x02, 0x01, 0x00, 0x04, 0x06, 'p', 'u', 'b', 'l', 'i', 'c'}, pdu...)
+	msg = append([]byte{0x30, byte(len(msg))}, msg...)
+
+	var svc ServiceProbe
+	if !parseSNMPSysDescr(msg, &svc) || svc.Banner != descr || svc.ExtraInfo != "community public" {
+		t.Errorf("SNMP reply parsed as %+v", svc)
+	}
+	if parseSNMPSysDescr(snmpSysDescrGet, &ServiceProbe{}) {
+		t.Error("GetRequest should not parse as a response")
+	}
+
+	// DNS version.bind answer "9.18.24"
+	dns := append([]byte(nil), dnsVersionQuery...)
+	dns[2] = 0x84                 // QR, AA
+	dns[7] = 1                    // AN=1
+	dns = append(dns, 0xc0, 0x0c) // Name pointer
+	dns = append(dns, 0x00, 0x10, 0x00, 0x03, 0, 0, 0, 0, 0x00, 0x08, 7)
+	dns = append(dns, "9.18.24"...)
+	svc = ServiceProbe{}
+	if !parseDNSVersion(dns, &svc) || svc.Banner != "9.18.24" {
+		t.Errorf("DNS reply parsed as %+v", svc)
+	}
+
+	// NetBIOS NBSTAT reply with a group name followed by the workstation name
+	nb := append([]byte(nil), netbiosStatusQuery...)
+	nb[2] = 0x84
+	nb = append(nb, 0, 0, 0, 0, 0x00, 0x00, 2)
+	nb = append(nb, []byte("WORKGROUP      \x00\x84\x00")...)
+	nb = append(nb, []byte("FILESERVER     \x00\x04\x00")...)
+	nb[len(netbiosStatusQuery)+5] = byte(len(nb) - len(netbiosStatusQuery) - 6)
+	svc = ServiceProbe{}
+	if !parseNetBIOSStatus(nb, &svc) || svc.ExtraInfo != "name FILESERVER" {
+		t.Errorf("NetBIOS reply parsed as %+v", svc)
+	}
+}
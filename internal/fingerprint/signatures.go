@@ -0,0 +1,91 @@
+package fingerprint
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//go:embed service_signatures.txt
+var builtinSignatures string
+
+// ServiceSignature identifies a product, and optionally its version, from a
+// service banner.
+type ServiceSignature struct {
+	Service      string
+	Pattern      *regexp.Regexp
+	Product      string
+	VersionGroup int // Capture group holding the version; 0 for none
+}
+
+// ServiceSignatures is the built-in signature table.
+var ServiceSignatures = mustParseServiceSignatures(builtinSignatures)
+
+// ParseServiceSignatures reads signatures, one per line, in the format
+// "service|product|version group|pattern". Blank lines and lines starting
+// with # are ignored.
+func ParseServiceSignatures(r io.Reader) ([]ServiceSignature, error) {
+	var sigs []ServiceSignature
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		fields := strings.SplitN(line, "|", 4)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("line %d: expected service|product|version group|pattern", lineNum)
+		}
+		group, err := strconv.Atoi(strings.TrimSpace(fields[2]))
+		if err != nil || group < 0 {
+			return nil, fmt.Errorf("line %d: invalid version group %q", lineNum, fields[2])
+		}
+		pattern, err := regexp.Compile(fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		if group > pattern.NumSubexp() {
+			return nil, fmt.Errorf("line %d: version group %d but pattern has %d groups", lineNum, group, pattern.NumSubexp())
+		}
+
+		sigs = append(sigs, ServiceSignature{
+			Service:      strings.TrimSpace(fields[0]),
+			Pattern:      pattern,
+			Product:      strings.TrimSpace(fields[1]),
+			VersionGroup: group,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return sigs, nil
+}
+
+func mustParseServiceSignatures(s string) []ServiceSignature {
+	sigs, err := ParseServiceSignatures(strings.NewReader(s))
+	if err != nil {
+		panic("fingerprint: built-in service signatures: " + err.Error())
+	}
+	return sigs
+}
+
+// Match reports whether banner matches the signature, returning the version
+// captured by VersionGroup, if any.
+func (sig ServiceSignature) Match(banner string) (version string, ok bool) {
+	m := sig.Pattern.FindStringSubmatch(banner)
+	if m == nil {
+		return "", false
+	}
+	if sig.VersionGroup > 0 && sig.VersionGroup < len(m) {
+		version = m[sig.VersionGroup]
+	}
+	return version, true
+}
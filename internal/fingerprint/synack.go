@@ -0,0 +1,207 @@
+package fingerprint
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/net/ipv4"
+)
+
+// synackWait bounds how long a probe waits for the capture goroutine to
+// report the SYN-ACK of a connection that has already been established.
+const synackWait = 250 * time.Millisecond
+
+// TCP option kinds (RFC 9293, RFC 7323, RFC 2018).
+const (
+	tcpOptEnd       = 0
+	tcpOptNOP       = 1
+	tcpOptMSS       = 2
+	tcpOptWScale    = 3
+	tcpOptSACKPerm  = 4
+	tcpOptTimestamp = 8
+)
+
+// tcpSegment holds the TCP header fields used for fingerprinting.
+type tcpSegment struct {
+	SrcPort     int
+	DstPort     int
+	Flags       TCPFlags
+	Window      int
+	MSS         int
+	WindowScale int
+	SACK        bool
+	Timestamps  bool
+	NOP         bool
+}
+
+// synack is a SYN-ACK as seen on the wire, with its IP header fields.
+type synack struct {
+	TTL int
+	DF  bool
+	tcpSegment
+}
+
+// parseTCPHeader decodes the fixed TCP header and its options from b.
+func parseTCPHeader(b []byte) (tcpSegment, error) {
+	var seg tcpSegment
+	if len(b) < 20 {
+		return seg, errors.New("TCP header too short")
+	}
+	dataOff := int(b[12]>>4) * 4
+	if dataOff < 20 || dataOff > len(b) {
+		return seg, errors.New("invalid TCP data offset")
+	}
+
+	seg.SrcPort = int(binary.BigEndian.Uint16(b[0:2]))
+	seg.DstPort = int(binary.BigEndian.Uint16(b[2:4]))
+	flags := b[13]
+	seg.Flags = TCPFlags{
+		FIN: flags&0x01 != 0,
+		SYN: flags&0x02 != 0,
+		RST: flags&0x04 != 0,
+		PSH: flags&0x08 != 0,
+		ACK: flags&0x10 != 0,
+		URG: flags&0x20 != 0,
+		ECE: flags&0x40 != 0,
+		CWR: flags&0x80 != 0,
+	}
+	seg.Window = int(binary.BigEndian.Uint16(b[14:16]))
+
+	opts := b[20:dataOff]
+	for i := 0; i < len(opts); {
+		kind := opts[i]
+		switch kind {
+		case tcpOptEnd:
+			return seg, nil
+		case tcpOptNOP:
+			seg.NOP = true
+			i++
+			continue
+		}
+		if i+1 >= len(opts) {
+			return seg, errors.New("truncated TCP option")
+		}
+		length := int(opts[i+1])
+		if length < 2 || i+length > len(opts) {
+			return seg, errors.New("invalid TCP option length")
+		}
+		data := opts[i+2 : i+length]
+		switch kind {
+		case tcpOptMSS:
+			if len(data) == 2 {
+				seg.MSS = int(binary.BigEndian.Uint16(data))
+			}
+		case tcpOptWScale:
+			if len(data) == 1 {
+				seg.WindowScale = int(data[0])
+			}
+		case tcpOptSACKPerm:
+			seg.SACK = true
+		case tcpOptTimestamp:
+			seg.Timestamps = true
+		}
+		i += length
+	}
+	return seg, nil
+}
+
+// synackKey identifies a connection by the target's and our port.
+type synackKey struct {
+	remotePort int
+	localPort  int
+}
+
+// synackCapture passively records SYN-ACKs sent by the target so probes can
+// read the real TTL, window and TCP options of the connections they open.
+type synackCapture struct {
+	conn    *ipv4.RawConn
+	targets []net.IP
+
+	mu   sync.Mutex
+	seen map[synackKey]synack
+	done chan struct{}
+}
+
+// newSYNACKCapture starts capturing SYN-ACKs from targets. It fails when raw
+// sockets are unavailable, typically because of missing privileges.
+func newSYNACKCapture(targets []net.IP) (*synackCapture, error) {
+	conn, err := openTCPRawConn()
+	if err != nil {
+		return nil, err
+	}
+	c := &synackCapture{
+		conn:    conn,
+		targets: targets,
+		seen:    make(map[synackKey]synack),
+		done:    make(chan struct{}),
+	}
+	go c.run()
+	return c, nil
+}
+
+func (c *synackCapture) run() {
+	defer close(c.done)
+
+	buf := make([]byte, 1500)
+	for {
+		h, payload, _, err := c.conn.ReadFrom(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			return
+		}
+		if !c.isTarget(h.Src) {
+			continue
+		}
+		seg, err := parseTCPHeader(payload)
+		if err != nil || !seg.Flags.SYN || !seg.Flags.ACK {
+			continue
+		}
+
+		c.mu.Lock()
+		c.seen[synackKey{seg.SrcPort, seg.DstPort}] = synack{
+			TTL:        h.TTL,
+			DF:         h.Flags&ipv4.DontFragment != 0,
+			tcpSegment: seg,
+		}
+		c.mu.Unlock()
+	}
+}
+
+func (c *synackCapture) isTarget(ip net.IP) bool {
+	for _, t := range c.targets {
+		if t.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the SYN-ACK for the connection from remotePort to
+// localPort, waiting briefly for the capture goroutine to catch up.
+func (c *synackCapture) lookup(ctx context.Context, remotePort, localPort int) (synack, bool) {
+	key := synackKey{remotePort, localPort}
+	deadline := time.Now().Add(synackWait)
+	for {
+		c.mu.Lock()
+		sa, ok := c.seen[key]
+		c.mu.Unlock()
+		if ok || time.Now().After(deadline) || ctx.Err() != nil {
+			return sa, ok
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// Close stops the capture.
+func (c *synackCapture) Close() error {
+	err := c.conn.Close()
+	<-c.done
+	return err
+}
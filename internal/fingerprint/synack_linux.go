@@ -0,0 +1,28 @@
+package fingerprint
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+
+	"golang.org/x/net/ipv4"
+)
+
+// openTCPRawConn opens a raw IPv4 socket that receives a copy of every
+// inbound TCP segment, IP header included.
+func openTCPRawConn() (*ipv4.RawConn, error) {
+	pc, err := net.ListenPacket("ip4:tcp", "0.0.0.0")
+	if err != nil {
+		if errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EACCES) {
+			return nil, fmt.Errorf("SYN-ACK capture requires administrator/root (raw socket): %v", err)
+		}
+		return nil, fmt.Errorf("failed to open raw socket: %w", err)
+	}
+	conn, err := ipv4.NewRawConn(pc)
+	if err != nil {
+		pc.Close()
+		return nil, fmt.Errorf("failed to open raw socket: %w", err)
+	}
+	return conn, nil
+}
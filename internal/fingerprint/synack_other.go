@@ -0,0 +1,16 @@
+//go:build !linux
+
+package fingerprint
+
+import (
+	"fmt"
+	"runtime"
+
+	"golang.org/x/net/ipv4"
+)
+
+// openTCPRawConn is only implemented on Linux; other kernels do not deliver
+// inbound TCP segments to raw sockets.
+func openTCPRawConn() (*ipv4.RawConn, error) {
+	return nil, fmt.Errorf("SYN-ACK capture is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,305 @@
+package fingerprint
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// UDP port states. UDP has no handshake, so silence is ambiguous: the port
+// may be open and ignoring the probe, or a firewall may be dropping it.
+const (
+	StateOpen         = "open"
+	StateOpenFiltered = "open|filtered"
+)
+
+// udpWait bounds how long a UDP probe waits for a reply.
+const udpWait = 2 * time.Second
+
+// udpProbe is a protocol-specific payload for a well-known UDP service.
+type udpProbe struct {
+	Port    int
+	Service string
+	Payload []byte
+	// Parse fills in product details from a reply. It returns false if the
+	// reply is not a valid response to the payload.
+	Parse func(data []byte, probe *ServiceProbe) bool
+}
+
+// UDPProbePorts are the UDP ports probed when Options.UDPScan is set.
+var UDPProbePorts = []int{53, 123, 137, 161}
+
+var udpProbes = map[int]udpProbe{
+	53:  {Port: 53, Service: "dns", Payload: dnsVersionQuery, Parse: parseDNSVersion},
+	123: {Port: 123, Service: "ntp", Payload: ntpClientRequest, Parse: parseNTPReply},
+	137: {Port: 137, Service: "netbios-ns", Payload: netbiosStatusQuery, Parse: parseNetBIOSStatus},
+	161: {Port: 161, Service: "snmp", Payload: snmpSysDescrGet, Parse: parseSNMPSysDescr},
+}
+
+// dnsVersionQuery asks for version.bind TXT in the CHAOS class.
+var dnsVersionQuery = []byte{
+	0x4e, 0x4e, // ID
+	0x00, 0x00, // Flags: standard query, no recursion
+	0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // QD=1
+	7, 'v', 'e', 'r', 's', 'i', 'o', 'n', 4, 'b', 'i', 'n', 'd', 0,
+	0x00, 0x10, // TXT
+	0x00, 0x03, // CH
+}
+
+// ntpClientRequest is an NTPv4 client-mode packet (LI=0, VN=4, Mode=3).
+var ntpClientRequest = append([]byte{0x23}, make([]byte, 47)...)
+
+// netbiosStatusQuery is an NBSTAT request for the wildcard name "*".
+var netbiosStatusQuery = []byte{
+	0x4e, 0x4e, // Transaction ID
+	0x00, 0x00, // Flags
+	0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // QD=1
+	0x20, 'C', 'K', 'A', 'A', 'A', 'A', 'A', 'A', 'A', 'A', 'A', 'A', 'A', 'A', 'A', 'A',
+	'A', 'A', 'A', 'A', 'A', 'A', 'A', 'A', 'A', 'A', 'A', 'A', 'A', 'A', 'A', 'A', 0x00,
+	0x00, 0x21, // NBSTAT
+	0x00, 0x01, // IN
+}
+
+// snmpSysDescrGet is an SNMPv1 GetRequest for sysDescr.0 with community
+// "public".
+var snmpSysDescrGet = []byte{
+	0x30, 0x29,
+	0x02, 0x01, 0x00, // version 1
+	0x04, 0x06, 'p', 'u', 'b', 'l', 'i', 'c',
+	0xa0, 0x1c, // GetRequest
+	0x02, 0x04, 0x4e, 0x4e, 0x53, 0x01, // request-id
+	0x02, 0x01, 0x00, // error-status
+	0x02, 0x01, 0x00, // error-index
+	0x30, 0x0e, 0x30, 0x0c,
+	0x06, 0x08, 0x2b, 0x06, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00, // 1.3.6.1.2.1.1.1.0
+	0x05, 0x00,
+}
+
+func (s *Scanner) detectUDPServices(ctx context.Context, host string, result *FingerprintResult) {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	semaphore := make(chan struct{}, s.opts.Concurrency)
+
+	for _, port := range UDPProbePorts {
+		p, ok := udpProbes[port]
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(p udpProbe) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			service, ok := s.probeUDP(ctx, host, p.Port, p)
+			if !ok {
+				return
+			}
+
+			mu.Lock()
+			result.Services = append(result.Services, service)
+			mu.Unlock()
+		}(p)
+	}
+
+	wg.Wait()
+}
+
+// probeUDP sends p's payload to port and classifies the port by the reply.
+// It returns false if the port is closed, i.e. an ICMP port unreachable
+// came back, or could not be probed.
+func (s *Scanner) probeUDP(ctx context.Context, host string, port int, p udpProbe) (ServiceProbe, bool) {
+	start := time.Now()
+	probe := ServiceProbe{
+		Port:       port,
+		Protocol:   "udp",
+		Service:    p.Service,
+		State:      StateOpenFiltered,
+		Confidence: ConfidenceLow,
+	}
+
+	addr := fmt.Sprintf("%s:%d", host, port)
+	d := net.Dialer{Timeout: s.opts.Timeout}
+
+	conn, err := d.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return probe, false
+	}
+	defer conn.Close()
+
+	wait := udpWait
+	if s.opts.Timeout < wait {
+		wait = s.opts.Timeout
+	}
+	conn.SetDeadline(time.Now().Add(wait))
+
+	if _, err := conn.Write(p.Payload); err != nil {
+		probe.LookupTime = time.Since(start)
+		return probe, !isPortUnreachable(err)
+	}
+
+	buf := make([]byte, 2048)
+	n, err := conn.Read(buf)
+	probe.LookupTime = time.Since(start)
+	if err != nil {
+		// A connected UDP socket reports ICMP port unreachable as a read error
+		return probe, !isPortUnreachable(err)
+	}
+
+	probe.State = StateOpen
+	probe.Confidence = ConfidenceMedium
+	if p.Parse != nil && p.Parse(buf[:n], &probe) {
+		probe.Confidence = ConfidenceHigh
+	}
+	return probe, true
+}
+
+func isPortUnreachable(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// parseDNSVersion reads the version.bind TXT answer, if the server gave one.
+func parseDNSVersion(data []byte, probe *ServiceProbe) bool {
+	if len(data) < 12 || data[0] != dnsVersionQuery[0] || data[1] != dnsVersionQuery[1] || data[2]&0x80 == 0 {
+		return false
+	}
+	if binary.BigEndian.Uint16(data[6:8]) == 0 {
+		return true // Answered without disclosing a version
+	}
+
+	// Skip the question, which echoes ours
+	off := len(dnsVersionQuery)
+	if off >= len(data) {
+		return true
+	}
+	// Answer name: a compression pointer or the full name
+	if data[off]&0xc0 == 0xc0 {
+		off += 2
+	} else {
+		for off < len(data) && data[off] != 0 {
+			off += int(data[off]) + 1
+		}
+		off++
+	}
+	// Type, class, TTL, RDLENGTH
+	if off+10 > len(data) {
+		return true
+	}
+	rdlen := int(binary.BigEndian.Uint16(data[off+8 : off+10]))
+	off += 10
+	if off+rdlen > len(data) || rdlen < 1 {
+		return true
+	}
+	txtLen := int(data[off])
+	if txtLen+1 > rdlen {
+		return true
+	}
+	probe.Banner = string(data[off+1 : off+1+txtLen])
+	return true
+}
+
+// parseNTPReply checks for a server-mode reply and reports its version and
+// stratum.
+func parseNTPReply(data []byte, probe *ServiceProbe) bool {
+	if len(data) < 48 || data[0]&0x07 != 4 {
+		return false
+	}
+	version := (data[0] >> 3) & 0x07
+	probe.Version = fmt.Sprintf("v%d", version)
+	probe.ExtraInfo = fmt.Sprintf("stratum %d", data[1])
+	return true
+}
+
+// parseNetBIOSStatus extracts the workstation name from an NBSTAT reply.
+func parseNetBIOSStatus(data []byte, probe *ServiceProbe) bool {
+	if len(data) < 12 || data[0] != netbiosStatusQuery[0] || data[1] != netbiosStatusQuery[1] || data[2]&0x80 == 0 {
+		return false
+	}
+
+	off := 12
+	if off < len(data) && data[off]&0xc0 == 0xc0 {
+		off += 2
+	} else {
+		off += 34 // Encoded name: length byte, 32 bytes, terminator
+	}
+	// Type, class, TTL, RDLENGTH, then the name count
+	off += 10
+	if off >= len(data) {
+		return false
+	}
+	count := int(data[off])
+	off++
+
+	for i := 0; i < count && off+18 <= len(data); i++ {
+		entry := data[off : off+18]
+		off += 18
+		suffix := entry[15]
+		group := entry[16]&0x80 != 0
+		if suffix == 0x00 && !group {
+			probe.ExtraInfo = "name " + strings.TrimRight(string(entry[:15]), " \x00")
+			break
+		}
+	}
+	return true
+}
+
+// parseSNMPSysDescr reads sysDescr from an SNMP GetResponse.
+func parseSNMPSysDescr(data []byte, probe *ServiceProbe) bool {
+	var strs [][]byte
+	var pdu byte
+	if !walkBER(data, &strs, &pdu) || pdu != 0xa2 || len(strs) < 2 {
+		return false
+	}
+	// The first octet string is the community, the last the sysDescr value
+	probe.Banner = strings.TrimSpace(string(strs[len(strs)-1]))
+	probe.ExtraInfo = "community " + string(strs[0])
+	return true
+}
+
+// walkBER walks BER-encoded data, collecting octet strings and noting the
+// PDU type. It returns false on malformed input.
+func walkBER(data []byte, strs *[][]byte, pdu *byte) bool {
+	for len(data) > 0 {
+		if len(data) < 2 {
+			return false
+		}
+		tag := data[0]
+		length := int(data[1])
+		off := 2
+		if length&0x80 != 0 {
+			n := length & 0x7f
+			if n == 0 || n > 2 || len(data) < 2+n {
+				return false
+			}
+			length = 0
+			for _, b := range data[2 : 2+n] {
+				length = length<<8 | int(b)
+			}
+			off += n
+		}
+		if off+length > len(data) {
+			return false
+		}
+		value := data[off : off+length]
+
+		switch {
+		case tag == 0x04:
+			*strs = append(*strs, value)
+		case tag == 0x30 || tag&0xe0 == 0xa0:
+			if tag != 0x30 {
+				*pdu = tag
+			}
+			if !walkBER(value, strs, pdu) {
+				return false
+			}
+		}
+		data = data[off+length:]
+	}
+	return true
+}
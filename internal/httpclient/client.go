@@ -2,6 +2,7 @@
 package httpclient
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"encoding/json"
@@ -10,8 +11,12 @@ import (
 	"net"
 	"net/http"
 	"net/http/httptrace"
+	"net/http/httputil"
+	"net/url"
 	"strings"
 	"time"
+
+	"github.com/JedizLaPulga/NNS/internal/stats"
 )
 
 // Timing holds timing information for the HTTP request.
@@ -28,9 +33,13 @@ type Timing struct {
 	DNSLookup    time.Duration `json:"dns_lookup"`
 	TCPConnect   time.Duration `json:"tcp_connect"`
 	TLSHandshake time.Duration `json:"tls_handshake"`
-	TTFB         time.Duration `json:"ttfb"`
-	Download     time.Duration `json:"download"`
-	Total        time.Duration `json:"total"`
+	// QUICHandshake covers the QUIC handshake, including TLS, for HTTP/3
+	// requests, which have no separate TCP connect or TLS phases.
+	QUICHandshake time.Duration `json:"quic_handshake,omitempty"`
+	TTFB          time.Duration `json:"ttfb"`
+	Download      time.Duration `json:"download"`
+	Total         time.Duration `json:"total"`
+	Note          string        `json:"note,omitempty"` // explains phases that are absent, e.g. on a reused connection
 }
 
 // Request represents an HTTP request configuration.
@@ -39,23 +48,30 @@ type Request struct {
 	URL          string
 	Headers      map[string]string
 	Body         string
+	Form         map[string]string // multipart form fields
+	Files        map[string]string // multipart file fields (field -> path)
 	Timeout      time.Duration
 	FollowRedirs bool
 }
 
 // Response holds the HTTP response and timing data.
 type Response struct {
-	StatusCode    int               `json:"status_code"`
-	Status        string            `json:"status"`
-	Proto         string            `json:"protocol"`
-	Headers       map[string]string `json:"headers"`
-	Body          []byte            `json:"-"`
-	BodyString    string            `json:"body,omitempty"`
-	ContentLength int64             `json:"content_length"`
-	ContentType   string            `json:"content_type"`
-	Timing        Timing            `json:"timing"`
-	RedirectCount int               `json:"redirect_count"`
-	FinalURL      string            `json:"final_url"`
+	StatusCode       int               `json:"status_code"`
+	Status           string            `json:"status"`
+	Proto            string            `json:"protocol"`
+	Headers          map[string]string `json:"headers"`
+	Body             []byte            `json:"-"`
+	BodyString       string            `json:"body,omitempty"`
+	ContentLength    int64             `json:"content_length"`
+	ContentType      string            `json:"content_type"`
+	Timing           Timing            `json:"timing"`
+	RedirectCount    int               `json:"redirect_count"`
+	FinalURL         string            `json:"final_url"`
+	ConnectionReused bool              `json:"connection_reused"`        // sent over a kept-alive connection, so DNS/connect/TLS timings are zero
+	RequestDump      []byte            `json:"-"`                        // wire-format request, if Client.CaptureRequest is set
+	HTTP3Fallback    string            `json:"http3_fallback,omitempty"` // why Client.HTTP3 fell back to TCP
+
+	header http.Header // full multi-valued headers, for Dump
 }
 
 // Client is the HTTP client with timing support.
@@ -63,6 +79,11 @@ type Client struct {
 	Timeout         time.Duration
 	FollowRedirects bool
 	MaxBodySize     int64
+	Proxy           string // upstream proxy URL (http, https, socks5); empty uses environment
+	CaptureRequest  bool   // record the outgoing request in Response.RequestDump
+	HTTP3           bool   // repeat GET/HEAD requests over HTTP/3 when the server offers h3 via Alt-Svc
+
+	tlsConfig *tls.Config // overrides the TLS client config, for tests
 }
 
 // NewClient creates a new HTTP client with defaults.
@@ -74,14 +95,69 @@ func NewClient() *Client {
 	}
 }
 
-// Do performs an HTTP request with timing.
+// Do performs an HTTP request with timing. With HTTP3 set, the request is
+// first made over TCP to discover h3 support and then, for GET and HEAD,
+// repeated over QUIC; the HTTP/3 response is returned if that succeeds.
 func (c *Client) Do(req *Request) (*Response, error) {
-	resp := &Response{}
-	timing := &Timing{}
+	transport, err := c.newTransport(false)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req, transport)
+	if err != nil || !c.HTTP3 {
+		return resp, err
+	}
+	return c.upgradeHTTP3(req, resp), nil
+}
+
+// DoN performs the same request n times over a shared keep-alive transport,
+// so later iterations may reuse the connection. It stops at the first error
+// and returns the responses collected so far.
+func (c *Client) DoN(req *Request, n int) ([]*Response, error) {
+	transport, err := c.newTransport(true)
+	if err != nil {
+		return nil, err
+	}
+	defer transport.CloseIdleConnections()
+
+	resps := make([]*Response, 0, n)
+	for i := 0; i < n; i++ {
+		resp, err := c.do(req, transport)
+		if err != nil {
+			return resps, err
+		}
+		resps = append(resps, resp)
+	}
+	return resps, nil
+}
+
+// newTransport creates the HTTP transport used for requests.
+func (c *Client) newTransport(keepAlive bool) (*http.Transport, error) {
+	proxyFunc, err := c.proxyFunc()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Transport{
+		Proxy:             proxyFunc,
+		DisableKeepAlives: !keepAlive,
+		TLSClientConfig:   c.tlsConfig,
+	}, nil
+}
 
+// buildHTTPRequest converts a Request into an *http.Request with body and
+// default headers applied.
+func buildHTTPRequest(req *Request) (*http.Request, error) {
 	// Build HTTP request
 	var bodyReader io.Reader
-	if req.Body != "" {
+	var multipartType string
+	if len(req.Form) > 0 || len(req.Files) > 0 {
+		r, contentType, err := BuildMultipart(req.Form, req.Files)
+		if err != nil {
+			return nil, err
+		}
+		bodyReader = r
+		multipartType = contentType
+	} else if req.Body != "" {
 		bodyReader = strings.NewReader(req.Body)
 	}
 
@@ -106,10 +182,33 @@ func (c *Client) Do(req *Request) (*Response, error) {
 	}
 
 	// Content-Type for body
-	if req.Body != "" && httpReq.Header.Get("Content-Type") == "" {
+	if multipartType != "" {
+		httpReq.Header.Set("Content-Type", multipartType)
+	} else if req.Body != "" && httpReq.Header.Get("Content-Type") == "" {
 		httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	}
 
+	return httpReq, nil
+}
+
+// do executes a single request over the given transport.
+func (c *Client) do(req *Request, transport http.RoundTripper) (*Response, error) {
+	resp := &Response{}
+	timing := &Timing{}
+
+	httpReq, err := buildHTTPRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.CaptureRequest {
+		// DumpRequestOut restores the body, so the request can still be sent.
+		resp.RequestDump, err = httputil.DumpRequestOut(httpReq, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dump request: %w", err)
+		}
+	}
+
 	// Setup trace for timing
 	timing.Start = time.Now()
 
@@ -132,6 +231,9 @@ func (c *Client) Do(req *Request) (*Response, error) {
 		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
 			timing.TLSDone = time.Now()
 		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			resp.ConnectionReused = info.Reused
+		},
 		GotFirstResponseByte: func() {
 			timing.FirstByte = time.Now()
 		},
@@ -151,10 +253,6 @@ func (c *Client) Do(req *Request) (*Response, error) {
 	httpReq = httpReq.WithContext(ctx)
 
 	// Create client
-	transport := &http.Transport{
-		DisableKeepAlives: true,
-	}
-
 	client := &http.Client{
 		Transport: transport,
 		Timeout:   c.Timeout,
@@ -203,6 +301,9 @@ func (c *Client) Do(req *Request) (*Response, error) {
 		timing.Download = timing.Done.Sub(timing.FirstByte)
 	}
 	timing.Total = timing.Done.Sub(timing.Start)
+	if resp.ConnectionReused {
+		timing.Note = "connection reused (no DNS/connect/TLS)"
+	}
 
 	// Build response
 	resp.StatusCode = httpResp.StatusCode
@@ -211,6 +312,7 @@ func (c *Client) Do(req *Request) (*Response, error) {
 	resp.ContentLength = httpResp.ContentLength
 	resp.ContentType = httpResp.Header.Get("Content-Type")
 	resp.Body = body
+	resp.header = httpResp.Header
 	resp.Timing = *timing
 	resp.FinalURL = httpResp.Request.URL.String()
 
@@ -225,6 +327,132 @@ func (c *Client) Do(req *Request) (*Response, error) {
 	return resp, nil
 }
 
+// proxyFunc returns the transport proxy selector. An explicit Client.Proxy
+// takes precedence; otherwise HTTP_PROXY/HTTPS_PROXY/NO_PROXY are honored.
+func (c *Client) proxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	if c.Proxy == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+	u, err := ParseProxyURL(c.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	return http.ProxyURL(u), nil
+}
+
+// ParseProxyURL validates an upstream proxy URL. A missing scheme defaults
+// to http://.
+func ParseProxyURL(raw string) (*url.URL, error) {
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme: %s", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL: missing host")
+	}
+	return u, nil
+}
+
+// PhaseStats holds aggregate statistics for one timing phase.
+type PhaseStats struct {
+	Min time.Duration `json:"min"`
+	Avg time.Duration `json:"avg"`
+	Max time.Duration `json:"max"`
+	P95 time.Duration `json:"p95"`
+}
+
+// TimingStats aggregates timing phases across repeated requests.
+type TimingStats struct {
+	Count        int        `json:"count"`
+	Reused       int        `json:"reused"` // requests sent over an already open connection
+	DNSLookup    PhaseStats `json:"dns_lookup"`
+	TCPConnect   PhaseStats `json:"tcp_connect"`
+	TLSHandshake PhaseStats `json:"tls_handshake"`
+	TTFB         PhaseStats `json:"ttfb"`
+	Download     PhaseStats `json:"download"`
+	Total        PhaseStats `json:"total"`
+}
+
+// AggregateTiming computes min/avg/max/p95 for each timing phase.
+func AggregateTiming(resps []*Response) TimingStats {
+	ts := TimingStats{Count: len(resps)}
+	if len(resps) == 0 {
+		return ts
+	}
+	for _, r := range resps {
+		if r.ConnectionReused {
+			ts.Reused++
+		}
+	}
+
+	phase := func(get func(Timing) time.Duration) PhaseStats {
+		values := make([]time.Duration, len(resps))
+		for i, r := range resps {
+			values[i] = get(r.Timing)
+		}
+		return calcPhaseStats(values)
+	}
+
+	ts.DNSLookup = phase(func(t Timing) time.Duration { return t.DNSLookup })
+	ts.TCPConnect = phase(func(t Timing) time.Duration { return t.TCPConnect })
+	ts.TLSHandshake = phase(func(t Timing) time.Duration { return t.TLSHandshake })
+	ts.TTFB = phase(func(t Timing) time.Duration { return t.TTFB })
+	ts.Download = phase(func(t Timing) time.Duration { return t.Download })
+	ts.Total = phase(func(t Timing) time.Duration { return t.Total })
+	return ts
+}
+
+func calcPhaseStats(values []time.Duration) PhaseStats {
+	fs := stats.DurationsToFloat(values)
+	ps := PhaseStats{
+		Min: values[0],
+		Max: values[0],
+		Avg: secondsToDuration(stats.Mean(fs)),
+		P95: secondsToDuration(stats.Percentile(fs, 0.95)),
+	}
+	for _, v := range values {
+		if v < ps.Min {
+			ps.Min = v
+		}
+		if v > ps.Max {
+			ps.Max = v
+		}
+	}
+	return ps
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// Dump returns the response in raw wire format: status line, all headers,
+// a blank line, then the body.
+func (r *Response) Dump() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s\r\n", r.Proto, r.Status)
+
+	h := r.header
+	if h == nil {
+		h = make(http.Header)
+		for k, v := range r.Headers {
+			h.Set(k, v)
+		}
+	}
+	h.Write(&buf)
+
+	buf.WriteString("\r\n")
+	buf.Write(r.Body)
+	return buf.Bytes()
+}
+
 // ToJSON converts response to JSON.
 func (r *Response) ToJSON() (string, error) {
 	r.BodyString = string(r.Body)
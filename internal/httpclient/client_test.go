@@ -1,8 +1,23 @@
 package httpclient
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
+
+	"golang.org/x/net/http2/hpack"
+	"golang.org/x/net/quic"
 )
 
 func TestParseURL(t *testing.T) {
@@ -175,6 +190,530 @@ func TestResponseIsRedirect(t *testing.T) {
 	}
 }
 
+func TestBuildMultipart(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "upload.txt")
+	if err := os.WriteFile(path, []byte("file contents"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	body, contentType, err := BuildMultipart(
+		map[string]string{"name": "nns"},
+		map[string]string{"doc": path},
+	)
+	if err != nil {
+		t.Fatalf("BuildMultipart() error = %v", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/form-data" {
+		t.Fatalf("Content-Type = %q, want multipart/form-data", contentType)
+	}
+
+	form, err := multipart.NewReader(body, params["boundary"]).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatalf("ReadForm() error = %v", err)
+	}
+	if got := form.Value["name"]; len(got) != 1 || got[0] != "nns" {
+		t.Errorf("field name = %v, want [nns]", got)
+	}
+	fh := form.File["doc"]
+	if len(fh) != 1 || fh[0].Filename != "upload.txt" {
+		t.Fatalf("file doc = %v, want upload.txt", fh)
+	}
+}
+
+func TestBuildMultipartMissingFile(t *testing.T) {
+	_, _, err := BuildMultipart(nil, map[string]string{"doc": filepath.Join(t.TempDir(), "missing")})
+	if err == nil {
+		t.Error("BuildMultipart() with missing file should return error")
+	}
+}
+
+func TestClientDoMultipart(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(r.FormValue("key")))
+	}))
+	defer srv.Close()
+
+	c := NewClient()
+	resp, err := c.Do(&Request{
+		Method: "POST",
+		URL:    srv.URL,
+		Form:   map[string]string{"key": "value"},
+	})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if resp.StatusCode != 200 || string(resp.Body) != "value" {
+		t.Errorf("got %d %q, want 200 \"value\"", resp.StatusCode, resp.Body)
+	}
+}
+
+func TestParseProxyURL(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"http://127.0.0.1:8080", "http://127.0.0.1:8080", false},
+		{"127.0.0.1:8080", "http://127.0.0.1:8080", false},
+		{"socks5://localhost:1080", "socks5://localhost:1080", false},
+		{"ftp://proxy:21", "", true},
+		{"http://", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseProxyURL(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseProxyURL(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && got.String() != tt.want {
+				t.Errorf("ParseProxyURL(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientDoViaProxy(t *testing.T) {
+	var proxied bool
+	proxySrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy receives the absolute URL in the request line.
+		proxied = r.URL.IsAbs()
+		w.Write([]byte("via proxy"))
+	}))
+	defer proxySrv.Close()
+
+	c := NewClient()
+	c.Proxy = proxySrv.URL
+	resp, err := c.Do(&Request{URL: "http://example.invalid/"})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if !proxied || string(resp.Body) != "via proxy" {
+		t.Errorf("request was not routed through proxy (body %q)", resp.Body)
+	}
+	if resp.Timing.ConnectDone.IsZero() {
+		t.Error("connect timing to proxy should be recorded")
+	}
+}
+
+func TestClientDoN(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	c := NewClient()
+	resps, err := c.DoN(&Request{URL: srv.URL}, 5)
+	if err != nil {
+		t.Fatalf("DoN() error = %v", err)
+	}
+	if len(resps) != 5 || hits != 5 {
+		t.Fatalf("DoN() returned %d responses, server saw %d hits, want 5", len(resps), hits)
+	}
+
+	// Only the first request should need a new connection.
+	connects := 0
+	for _, r := range resps {
+		if !r.Timing.ConnectDone.IsZero() {
+			connects++
+		}
+	}
+	if connects != 1 {
+		t.Errorf("connections opened = %d, want 1 (keep-alive)", connects)
+	}
+
+	for i, r := range resps {
+		wantReused := i > 0
+		if r.ConnectionReused != wantReused {
+			t.Errorf("request %d: ConnectionReused = %v, want %v", i, r.ConnectionReused, wantReused)
+		}
+		if wantReused != (r.Timing.Note != "") {
+			t.Errorf("request %d: Timing.Note = %q", i, r.Timing.Note)
+		}
+	}
+	if ts := AggregateTiming(resps); ts.Reused != 4 {
+		t.Errorf("AggregateTiming().Reused = %d, want 4", ts.Reused)
+	}
+}
+
+func TestAggregateTiming(t *testing.T) {
+	resps := []*Response{
+		{Timing: Timing{Total: 10 * time.Millisecond, TTFB: 5 * time.Millisecond}},
+		{Timing: Timing{Total: 30 * time.Millisecond, TTFB: 15 * time.Millisecond}},
+		{Timing: Timing{Total: 20 * time.Millisecond, TTFB: 10 * time.Millisecond}},
+	}
+
+	ts := AggregateTiming(resps)
+	if ts.Count != 3 {
+		t.Errorf("Count = %d, want 3", ts.Count)
+	}
+	if ts.Total.Min != 10*time.Millisecond || ts.Total.Max != 30*time.Millisecond {
+		t.Errorf("Total min/max = %v/%v, want 10ms/30ms", ts.Total.Min, ts.Total.Max)
+	}
+	if ts.Total.Avg != 20*time.Millisecond {
+		t.Errorf("Total avg = %v, want 20ms", ts.Total.Avg)
+	}
+	if ts.Total.P95 != 30*time.Millisecond {
+		t.Errorf("Total p95 = %v, want 30ms", ts.Total.P95)
+	}
+	if ts.TTFB.Max != 15*time.Millisecond {
+		t.Errorf("TTFB max = %v, want 15ms", ts.TTFB.Max)
+	}
+
+	if empty := AggregateTiming(nil); empty.Count != 0 {
+		t.Errorf("AggregateTiming(nil).Count = %d, want 0", empty.Count)
+	}
+}
+
+func TestIsJSON(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+		want        bool
+	}{
+		{"application/json", "", true},
+		{"application/problem+json; charset=utf-8", "x", true},
+		{"text/plain", `  {"a":1}`, true},
+		{"", "[1,2]", true},
+		{"text/html", "<html>", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsJSON(tt.contentType, []byte(tt.body)); got != tt.want {
+			t.Errorf("IsJSON(%q, %q) = %v, want %v", tt.contentType, tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestPrettyJSON(t *testing.T) {
+	got, err := PrettyJSON([]byte(`{"a":[1,2]}`))
+	if err != nil {
+		t.Fatalf("PrettyJSON() error = %v", err)
+	}
+	want := "{\n  \"a\": [\n    1,\n    2\n  ]\n}"
+	if string(got) != want {
+		t.Errorf("PrettyJSON() = %q, want %q", got, want)
+	}
+
+	if _, err := PrettyJSON([]byte("not json")); err == nil {
+		t.Error("PrettyJSON() with invalid input should return error")
+	}
+}
+
+func TestQueryJSON(t *testing.T) {
+	body := []byte(`{"data":{"items":[{"id":7,"name":"first"},{"id":8,"name":"second"}]},"ok":true}`)
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{".data.items[0].id", "7", false},
+		{".data.items[1].name", "second", false},
+		{".data.items[-1].id", "8", false},
+		{"data.items[0].name", "first", false},
+		{`.data["items"][0].id`, "7", false},
+		{".ok", "true", false},
+		{".data.missing", "", true},
+		{".data.items[5]", "", true},
+		{".ok[0]", "", true},
+		{".data.items[0", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := QueryJSON(body, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("QueryJSON(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("QueryJSON(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	whole, err := QueryJSON([]byte(`[1]`), ".")
+	if err != nil || whole != "[\n  1\n]" {
+		t.Errorf("QueryJSON(\".\") = %q, %v", whole, err)
+	}
+}
+
+func TestResponseDump(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("X-Multi", "one")
+		w.Header().Add("X-Multi", "two")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	c := NewClient()
+	c.CaptureRequest = true
+	resp, err := c.Do(&Request{Method: "POST", URL: srv.URL, Body: "payload"})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	dump := string(resp.Dump())
+	if !strings.HasPrefix(dump, "HTTP/1.1 201 Created\r\n") {
+		t.Errorf("Dump() status line wrong: %q", dump)
+	}
+	if !strings.Contains(dump, "X-Multi: one\r\nX-Multi: two\r\n") {
+		t.Errorf("Dump() missing multi-valued header: %q", dump)
+	}
+	if !strings.HasSuffix(dump, "\r\n\r\nhello") {
+		t.Errorf("Dump() should end with blank line and body: %q", dump)
+	}
+
+	reqDump := string(resp.RequestDump)
+	if !strings.HasPrefix(reqDump, "POST / HTTP/1.1\r\n") || !strings.HasSuffix(reqDump, "payload") {
+		t.Errorf("RequestDump = %q", reqDump)
+	}
+}
+
+func TestResponseDumpWithoutRawHeaders(t *testing.T) {
+	r := &Response{Proto: "HTTP/1.1", Status: "200 OK", Headers: map[string]string{"Content-Type": "text/plain"}, Body: []byte("x")}
+	want := "HTTP/1.1 200 OK\r\nContent-Type: text/plain\r\n\r\nx"
+	if got := string(r.Dump()); got != want {
+		t.Errorf("Dump() = %q, want %q", got, want)
+	}
+}
+
+func TestParseAltSvcH3(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   string
+		ok     bool
+	}{
+		{[]string{`h3=":443"; ma=86400`}, "example.com:443", true},
+		{[]string{`h3-29=":443", h3=":8443"; ma=3600`}, "example.com:8443", true},
+		{[]string{`h2="alt.example.com:443"`, `h3="alt.example.com:4433"`}, "alt.example.com:4433", true},
+		{[]string{`h3-29=":443"`}, "", false},
+		{[]string{"clear"}, "", false},
+		{nil, "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseAltSvcH3(tt.values, "example.com")
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseAltSvcH3(%q) = %q, %v; want %q, %v", tt.values, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestQPACK(t *testing.T) {
+	fields := []qpackField{{":method", "GET"}, {":path", "/a"}, {"user-agent", strings.Repeat("x", 200)}}
+	got, err := decodeQPACK(encodeQPACK(fields))
+	if err != nil {
+		t.Fatalf("decodeQPACK: %v", err)
+	}
+	if len(got) != len(fields) {
+		t.Fatalf("decoded %d fields, want %d", len(got), len(fields))
+	}
+	for i := range fields {
+		if got[i] != fields[i] {
+			t.Errorf("field %d = %v, want %v", i, got[i], fields[i])
+		}
+	}
+
+	// Static indexed field, static name reference with a Huffman value.
+	block := []byte{0, 0, 0xc0 | 25}
+	block = appendQPACKInt(block, 0x50, 4, 44)
+	huff := hpack.AppendHuffmanString(nil, "text/plain")
+	block = appendQPACKInt(block, 0x80, 7, uint64(len(huff)))
+	block = append(block, huff...)
+	got, err = decodeQPACK(block)
+	if err != nil {
+		t.Fatalf("decodeQPACK: %v", err)
+	}
+	want := []qpackField{{":status", "200"}, {"content-type", "text/plain"}}
+	if len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("decodeQPACK = %v, want %v", got, want)
+	}
+
+	if _, err := decodeQPACK([]byte{1, 0, 0x80}); err != errQPACKDynamic {
+		t.Errorf("dynamic table reference: err = %v, want errQPACKDynamic", err)
+	}
+	if _, err := decodeQPACK([]byte{0, 0, 0x25, 'a'}); err == nil {
+		t.Error("truncated section should fail")
+	}
+}
+
+// startH3Server serves HTTP/3 on a local UDP port with a minimal handler
+// that echoes the request path and method, and returns the port.
+func startH3Server(t *testing.T, cert tls.Certificate) string {
+	t.Helper()
+	endpoint, err := quic.Listen("udp", "127.0.0.1:0", &quic.Config{
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			NextProtos:   []string{"h3"},
+			MinVersion:   tls.VersionTLS13,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		endpoint.Close(ctx)
+	})
+
+	go func() {
+		for {
+			conn, err := endpoint.Accept(context.Background())
+			if err != nil {
+				return
+			}
+			go func() {
+				for {
+					st, err := conn.AcceptStream(context.Background())
+					if err != nil {
+						return
+					}
+					if st.IsReadOnly() {
+						go io.Copy(io.Discard, st)
+						continue
+					}
+					go serveH3Stream(st)
+				}
+			}()
+		}
+	}()
+	return strconv.Itoa(int(endpoint.LocalAddr().Port()))
+}
+
+func serveH3Stream(st *quic.Stream) {
+	defer st.Close()
+	br := &byteReader{r: st}
+	var method, path string
+	for method == "" {
+		ftype, err := readVarint(br)
+		if err != nil {
+			return
+		}
+		length, err := readVarint(br)
+		if err != nil {
+			return
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(br, payload); err != nil {
+			return
+		}
+		if ftype != h3FrameHeaders {
+			continue
+		}
+		fields, err := decodeQPACK(payload)
+		if err != nil {
+			st.Reset(0x0200) // QPACK_DECOMPRESSION_FAILED
+			return
+		}
+		for _, f := range fields {
+			switch f.Name {
+			case ":method":
+				method = f.Value
+			case ":path":
+				path = f.Value
+			}
+		}
+	}
+
+	block := []byte{0, 0, 0xc0 | 25} // :status 200
+	block = appendQPACKInt(block, 0x50, 4, 53)
+	huff := hpack.AppendHuffmanString(nil, "text/plain")
+	block = appendQPACKInt(block, 0x80, 7, uint64(len(huff)))
+	block = append(block, huff...)
+	block = append(block, encodeQPACK([]qpackField{{"x-proto", "h3"}})[2:]...)
+
+	resp := appendH3Frame(nil, h3FrameHeaders, block)
+	resp = appendH3Frame(resp, 0x21, []byte("reserved")) // Must be ignored
+	resp = appendH3Frame(resp, h3FrameData, []byte(method+" "))
+	resp = appendH3Frame(resp, h3FrameData, []byte(path))
+	st.Write(resp)
+}
+
+func TestClientDoHTTP3(t *testing.T) {
+	var h3Port string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h3Port != "" {
+			w.Header().Set("Alt-Svc", `h3=":`+h3Port+`"; ma=60`)
+		}
+		w.Write([]byte("tcp"))
+	}))
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	client := NewClient()
+	client.Timeout = 5 * time.Second
+	client.HTTP3 = true
+	client.tlsConfig = &tls.Config{RootCAs: roots}
+
+	// No Alt-Svc: the TCP response is kept.
+	resp, err := client.Do(&Request{URL: server.URL + "/x"})
+	if err != nil {
+		t.Fatalf("Do() error: %v", err)
+	}
+	if resp.Proto == "HTTP/3.0" || !strings.Contains(resp.HTTP3Fallback, "Alt-Svc") {
+		t.Errorf("Proto = %s, HTTP3Fallback = %q; want TCP fallback", resp.Proto, resp.HTTP3Fallback)
+	}
+
+	h3Port = startH3Server(t, server.TLS.Certificates[0])
+	resp, err = client.Do(&Request{URL: server.URL + "/hello?q=1"})
+	if err != nil {
+		t.Fatalf("Do() error: %v", err)
+	}
+	if resp.HTTP3Fallback != "" {
+		t.Fatalf("unexpected fallback: %s", resp.HTTP3Fallback)
+	}
+	if resp.Proto != "HTTP/3.0" || resp.StatusCode != 200 || resp.Status != "200 OK" {
+		t.Errorf("Proto/Status = %s %s", resp.Proto, resp.Status)
+	}
+	if string(resp.Body) != "GET /hello?q=1" {
+		t.Errorf("Body = %q", resp.Body)
+	}
+	if resp.ContentType != "text/plain" || resp.Headers["X-Proto"] != "h3" {
+		t.Errorf("headers = %v", resp.Headers)
+	}
+	if resp.Timing.QUICHandshake <= 0 || resp.Timing.TTFB < resp.Timing.QUICHandshake || resp.Timing.Total < resp.Timing.TTFB {
+		t.Errorf("timing = %+v", resp.Timing)
+	}
+	if resp.Timing.TCPConnect != 0 || resp.Timing.TLSHandshake != 0 {
+		t.Errorf("HTTP/3 timing should not report TCP/TLS phases: %+v", resp.Timing)
+	}
+
+	// Unsafe methods are not sent a second time over QUIC.
+	resp, err = client.Do(&Request{Method: "POST", URL: server.URL + "/submit", Body: "x=1"})
+	if err != nil {
+		t.Fatalf("Do() error: %v", err)
+	}
+	if resp.Proto == "HTTP/3.0" || !strings.Contains(resp.HTTP3Fallback, "POST") {
+		t.Errorf("Proto = %s, HTTP3Fallback = %q; want POST kept on TCP", resp.Proto, resp.HTTP3Fallback)
+	}
+
+	// A plain http URL never upgrades.
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Alt-Svc", `h3=":443"`)
+	}))
+	defer plain.Close()
+	resp, err = client.Do(&Request{URL: plain.URL})
+	if err != nil {
+		t.Fatalf("Do() error: %v", err)
+	}
+	if resp.HTTP3Fallback != "HTTP/3 requires https" {
+		t.Errorf("HTTP3Fallback = %q", resp.HTTP3Fallback)
+	}
+}
+
 func BenchmarkDo(b *testing.B) {
 	c := NewClient()
 	req := &Request{
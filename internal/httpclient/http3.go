@@ -0,0 +1,452 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/quic"
+)
+
+// HTTP/3 frame, stream and setting identifiers (RFC 9114, RFC 9204).
+const (
+	h3FrameData     = 0x00
+	h3FrameHeaders  = 0x01
+	h3FrameSettings = 0x04
+
+	h3StreamControl = 0x00
+
+	h3SettingQPACKMaxTableCapacity = 0x01
+	h3SettingQPACKBlockedStreams   = 0x07
+)
+
+// h3CloseWait bounds how long closing the QUIC endpoint waits for the
+// peer to acknowledge the connection close.
+const h3CloseWait = 250 * time.Millisecond
+
+// maxH3HeaderSize caps the size of a response HEADERS frame.
+const maxH3HeaderSize = 256 * 1024
+
+// upgradeHTTP3 repeats a GET or HEAD request over HTTP/3 when the response
+// that came back over TCP advertises h3 in Alt-Svc. Other methods are not
+// repeated, since the server has already acted on them once. If the
+// request is not upgraded or the HTTP/3 request fails, the TCP response is
+// returned with the reason recorded in HTTP3Fallback.
+func (c *Client) upgradeHTTP3(req *Request, resp *Response) *Response {
+	switch method := strings.ToUpper(req.Method); method {
+	case "", "GET", "HEAD":
+	default:
+		resp.HTTP3Fallback = fmt.Sprintf("HTTP/3 is only tried for GET and HEAD, not %s", method)
+		return resp
+	}
+
+	u, err := url.Parse(resp.FinalURL)
+	if err != nil || u.Scheme != "https" {
+		resp.HTTP3Fallback = "HTTP/3 requires https"
+		return resp
+	}
+
+	var altSvc []string
+	if resp.header != nil {
+		altSvc = resp.header.Values("Alt-Svc")
+	}
+	addr, ok := ParseAltSvcH3(altSvc, u.Hostname())
+	if !ok {
+		resp.HTTP3Fallback = "server does not advertise h3 via Alt-Svc"
+		return resp
+	}
+
+	h3req := *req
+	h3req.URL = resp.FinalURL
+	h3resp, err := c.doHTTP3(&h3req, addr)
+	if err != nil {
+		resp.HTTP3Fallback = fmt.Sprintf("HTTP/3 request to %s failed: %v", addr, err)
+		return resp
+	}
+	h3resp.RedirectCount = resp.RedirectCount
+	return h3resp
+}
+
+// ParseAltSvcH3 returns the host:port of the first h3 alternative in
+// Alt-Svc header values. An alternative without a host (h3=":443") refers
+// to originHost. Draft versions such as h3-29 are ignored.
+func ParseAltSvcH3(values []string, originHost string) (string, bool) {
+	for _, v := range values {
+		for _, alt := range strings.Split(v, ",") {
+			proto, rest, ok := strings.Cut(strings.TrimSpace(alt), "=")
+			if !ok || strings.TrimSpace(proto) != "h3" {
+				continue
+			}
+			authority, _, _ := strings.Cut(rest, ";")
+			authority = strings.Trim(strings.TrimSpace(authority), `"`)
+			host, port, err := net.SplitHostPort(authority)
+			if err != nil || port == "" {
+				continue
+			}
+			if host == "" {
+				host = originHost
+			}
+			return net.JoinHostPort(host, port), true
+		}
+	}
+	return "", false
+}
+
+// doHTTP3 sends req over a new QUIC connection to addr and reads the
+// response. The TLS handshake is part of the QUIC handshake, so its time
+// is reported as Timing.QUICHandshake rather than TCPConnect/TLSHandshake.
+func (c *Client) doHTTP3(req *Request, addr string) (*Response, error) {
+	httpReq, err := buildHTTPRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	var body []byte
+	if httpReq.Body != nil {
+		body, err = io.ReadAll(httpReq.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+
+	ctx := context.Background()
+	timeout := c.Timeout
+	if req.Timeout > 0 {
+		timeout = req.Timeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	timing := &Timing{Start: time.Now()}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if net.ParseIP(host) == nil {
+		timing.DNSStart = time.Now()
+		ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		timing.DNSDone = time.Now()
+		if err != nil {
+			return nil, err
+		}
+		if len(ips) == 0 {
+			return nil, fmt.Errorf("no addresses for %s", host)
+		}
+		host = ips[0].IP.String()
+		timing.DNSLookup = timing.DNSDone.Sub(timing.DNSStart)
+	}
+
+	endpoint, err := quic.Listen("udp", ":0", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), h3CloseWait)
+		defer cancel()
+		endpoint.Close(closeCtx)
+	}()
+
+	tlsConfig := c.tlsConfig.Clone()
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	tlsConfig.ServerName = httpReq.URL.Hostname()
+	tlsConfig.NextProtos = []string{"h3"}
+	tlsConfig.MinVersion = tls.VersionTLS13
+
+	timing.ConnectStart = time.Now()
+	conn, err := endpoint.Dial(ctx, "udp", net.JoinHostPort(host, port), &quic.Config{
+		TLSConfig:        tlsConfig,
+		HandshakeTimeout: timeout,
+	})
+	timing.ConnectDone = time.Now()
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("no QUIC handshake within %v (UDP blocked?)", timeout)
+		}
+		return nil, err
+	}
+	defer conn.Abort(nil)
+	timing.QUICHandshake = timing.ConnectDone.Sub(timing.ConnectStart)
+
+	if err := openH3Control(ctx, conn); err != nil {
+		return nil, err
+	}
+
+	st, err := conn.NewStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	st.SetReadContext(ctx)
+	st.SetWriteContext(ctx)
+
+	if _, err := st.Write(appendH3Frame(nil, h3FrameHeaders, encodeQPACK(h3RequestFields(httpReq, len(body))))); err != nil {
+		return nil, err
+	}
+	if len(body) > 0 {
+		if _, err := st.Write(appendH3Frame(nil, h3FrameData, body)); err != nil {
+			return nil, err
+		}
+	}
+	st.CloseWrite()
+
+	maxSize := c.MaxBodySize
+	if maxSize <= 0 {
+		maxSize = 1024 * 1024
+	}
+	status, header, respBody, err := readH3Response(st, maxSize, func() { timing.FirstByte = time.Now() })
+	if err != nil {
+		return nil, err
+	}
+	timing.Done = time.Now()
+
+	timing.TTFB = timing.FirstByte.Sub(timing.Start)
+	timing.Download = timing.Done.Sub(timing.FirstByte)
+	timing.Total = timing.Done.Sub(timing.Start)
+
+	resp := &Response{
+		StatusCode:    status,
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Proto:         "HTTP/3.0",
+		ContentLength: -1,
+		ContentType:   header.Get("Content-Type"),
+		Body:          respBody,
+		Timing:        *timing,
+		FinalURL:      httpReq.URL.String(),
+		Headers:       make(map[string]string),
+		header:        header,
+	}
+	if n, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64); err == nil {
+		resp.ContentLength = n
+	}
+	for k, v := range header {
+		if len(v) > 0 {
+			resp.Headers[k] = v[0]
+		}
+	}
+	return resp, nil
+}
+
+// openH3Control opens the client's control stream and sends SETTINGS
+// disabling the QPACK dynamic table, which decodeQPACK doesn't support.
+func openH3Control(ctx context.Context, conn *quic.Conn) error {
+	st, err := conn.NewSendOnlyStream(ctx)
+	if err != nil {
+		return err
+	}
+	st.SetWriteContext(ctx)
+
+	var settings []byte
+	settings = appendVarint(settings, h3SettingQPACKMaxTableCapacity)
+	settings = appendVarint(settings, 0)
+	settings = appendVarint(settings, h3SettingQPACKBlockedStreams)
+	settings = appendVarint(settings, 0)
+
+	b := appendVarint(nil, h3StreamControl)
+	b = appendH3Frame(b, h3FrameSettings, settings)
+	if _, err := st.Write(b); err != nil {
+		return err
+	}
+	return st.Flush()
+}
+
+// h3RequestFields builds the request header fields: pseudo-headers first,
+// then the request headers lowercased, minus connection-specific ones.
+func h3RequestFields(req *http.Request, bodyLen int) []qpackField {
+	fields := []qpackField{
+		{":method", req.Method},
+		{":scheme", req.URL.Scheme},
+		{":authority", req.URL.Host},
+		{":path", req.URL.RequestURI()},
+	}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		switch lower {
+		case "connection", "keep-alive", "proxy-connection", "transfer-encoding", "upgrade", "host":
+			continue
+		}
+		for _, v := range values {
+			fields = append(fields, qpackField{lower, v})
+		}
+	}
+	if bodyLen > 0 {
+		fields = append(fields, qpackField{"content-length", strconv.Itoa(bodyLen)})
+	}
+	return fields
+}
+
+// readH3Response reads frames from a request stream until it ends and
+// returns the final (non-1xx) status, its headers and up to maxSize bytes
+// of body. firstByte is called when the first frame arrives.
+func readH3Response(r io.Reader, maxSize int64, firstByte func()) (int, http.Header, []byte, error) {
+	br := &byteReader{r: r}
+	status := 0
+	var header http.Header
+	var body []byte
+	first := true
+
+	for {
+		ftype, err := readVarint(br)
+		if err == io.EOF && status != 0 {
+			return status, header, body, nil
+		}
+		if err != nil {
+			if err == io.EOF {
+				err = errors.New("stream ended before response headers")
+			}
+			return 0, nil, nil, err
+		}
+		if first {
+			firstByte()
+			first = false
+		}
+		length, err := readVarint(br)
+		if err != nil {
+			return 0, nil, nil, unexpectedEOF(err)
+		}
+
+		switch {
+		case ftype == h3FrameHeaders && status == 0:
+			if length > maxH3HeaderSize {
+				return 0, nil, nil, fmt.Errorf("response headers too large (%d bytes)", length)
+			}
+			block := make([]byte, length)
+			if _, err := io.ReadFull(br, block); err != nil {
+				return 0, nil, nil, unexpectedEOF(err)
+			}
+			fields, err := decodeQPACK(block)
+			if err != nil {
+				return 0, nil, nil, err
+			}
+			code, h, err := h3ResponseHeader(fields)
+			if err != nil {
+				return 0, nil, nil, err
+			}
+			if code >= 200 { // Informational responses are skipped
+				status, header = code, h
+			}
+		case ftype == h3FrameData && status != 0:
+			keep := maxSize - int64(len(body))
+			if keep > int64(length) {
+				keep = int64(length)
+			}
+			if keep < 0 {
+				keep = 0
+			}
+			chunk := make([]byte, keep)
+			if _, err := io.ReadFull(br, chunk); err != nil {
+				return 0, nil, nil, unexpectedEOF(err)
+			}
+			body = append(body, chunk...)
+			if _, err := io.CopyN(io.Discard, br, int64(length)-keep); err != nil {
+				return 0, nil, nil, unexpectedEOF(err)
+			}
+		case ftype == h3FrameData:
+			return 0, nil, nil, errors.New("DATA frame before response headers")
+		default:
+			// Trailers and unknown or reserved frame types are skipped.
+			if _, err := io.CopyN(io.Discard, br, int64(length)); err != nil {
+				return 0, nil, nil, unexpectedEOF(err)
+			}
+		}
+	}
+}
+
+// h3ResponseHeader converts decoded fields into a status code and header.
+func h3ResponseHeader(fields []qpackField) (int, http.Header, error) {
+	status := 0
+	header := make(http.Header)
+	for _, f := range fields {
+		if f.Name == ":status" {
+			code, err := strconv.Atoi(f.Value)
+			if err != nil || code < 100 || code > 999 {
+				return 0, nil, fmt.Errorf("invalid :status %q", f.Value)
+			}
+			status = code
+			continue
+		}
+		if strings.HasPrefix(f.Name, ":") {
+			continue
+		}
+		header.Add(f.Name, f.Value)
+	}
+	if status == 0 {
+		return 0, nil, errors.New("response headers missing :status")
+	}
+	return status, header, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// appendH3Frame appends an HTTP/3 frame with the given type and payload.
+func appendH3Frame(b []byte, ftype uint64, payload []byte) []byte {
+	b = appendVarint(b, ftype)
+	b = appendVarint(b, uint64(len(payload)))
+	return append(b, payload...)
+}
+
+// appendVarint appends a QUIC variable-length integer (RFC 9000 §16).
+func appendVarint(b []byte, v uint64) []byte {
+	switch {
+	case v < 1<<6:
+		return append(b, byte(v))
+	case v < 1<<14:
+		return append(b, 0x40|byte(v>>8), byte(v))
+	case v < 1<<30:
+		return append(b, 0x80|byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+	default:
+		return append(b, 0xc0|byte(v>>56), byte(v>>48), byte(v>>40), byte(v>>32),
+			byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+	}
+}
+
+// readVarint reads a QUIC variable-length integer.
+func readVarint(r io.ByteReader) (uint64, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	v := uint64(first & 0x3f)
+	for n := 1<<(first>>6) - 1; n > 0; n-- {
+		c, err := r.ReadByte()
+		if err != nil {
+			return 0, unexpectedEOF(err)
+		}
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+// byteReader adds ReadByte to a reader without buffering past what the
+// caller asks for.
+type byteReader struct {
+	r   io.Reader
+	buf [1]byte
+}
+
+func (b *byteReader) Read(p []byte) (int, error) {
+	return b.r.Read(p)
+}
+
+func (b *byteReader) ReadByte() (byte, error) {
+	if _, err := io.ReadFull(b.r, b.buf[:]); err != nil {
+		return 0, err
+	}
+	return b.buf[0], nil
+}
@@ -0,0 +1,131 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// IsJSON reports whether a response body looks like JSON, based on the
+// Content-Type or a leading '{' or '['.
+func IsJSON(contentType string, body []byte) bool {
+	if strings.Contains(strings.ToLower(contentType), "json") {
+		return true
+	}
+	trimmed := bytes.TrimSpace(body)
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+}
+
+// PrettyJSON indents a JSON document.
+func PrettyJSON(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, bytes.TrimSpace(body), "", "  "); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// QueryJSON extracts the value at a dotted path such as ".data.items[0].id"
+// from a JSON document. The result is returned as indented JSON, except for
+// strings which are returned unquoted.
+func QueryJSON(body []byte, path string) (string, error) {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return "", fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	cur := doc
+	for _, step := range steps {
+		if step.isIndex {
+			arr, ok := cur.([]interface{})
+			if !ok {
+				return "", fmt.Errorf("cannot index non-array with [%d]", step.index)
+			}
+			idx := step.index
+			if idx < 0 {
+				idx += len(arr)
+			}
+			if idx < 0 || idx >= len(arr) {
+				return "", fmt.Errorf("index [%d] out of range (length %d)", step.index, len(arr))
+			}
+			cur = arr[idx]
+			continue
+		}
+
+		obj, ok := cur.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("cannot access key %q on non-object", step.key)
+		}
+		v, ok := obj[step.key]
+		if !ok {
+			return "", fmt.Errorf("key %q not found", step.key)
+		}
+		cur = v
+	}
+
+	if s, ok := cur.(string); ok {
+		return s, nil
+	}
+	out, err := json.MarshalIndent(cur, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// jsonPathStep is a single key or array index in a path expression.
+type jsonPathStep struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parseJSONPath parses a path like ".data.items[0].id" into steps.
+// A lone "." selects the whole document.
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	var steps []jsonPathStep
+	p := strings.TrimSpace(path)
+
+	for p != "" {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+			end := strings.IndexAny(p, ".[")
+			if end == -1 {
+				end = len(p)
+			}
+			if end > 0 {
+				steps = append(steps, jsonPathStep{key: p[:end]})
+			}
+			p = p[end:]
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("invalid path %q: unclosed '['", path)
+			}
+			inner := p[1:end]
+			if n, err := strconv.Atoi(inner); err == nil {
+				steps = append(steps, jsonPathStep{index: n, isIndex: true})
+			} else if key, err := strconv.Unquote(inner); err == nil {
+				steps = append(steps, jsonPathStep{key: key})
+			} else {
+				return nil, fmt.Errorf("invalid path %q: bad subscript [%s]", path, inner)
+			}
+			p = p[end+1:]
+		default:
+			if len(steps) > 0 {
+				return nil, fmt.Errorf("invalid path %q", path)
+			}
+			// Allow a leading key without a dot, e.g. "data.items".
+			p = "." + p
+		}
+	}
+	return steps, nil
+}
@@ -0,0 +1,59 @@
+package httpclient
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// BuildMultipart builds a multipart/form-data body from form fields and
+// file fields (field name -> file path). It returns the body and the
+// Content-Type header value including the generated boundary.
+func BuildMultipart(fields map[string]string, files map[string]string) (io.Reader, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	for _, k := range sortedKeys(fields) {
+		if err := w.WriteField(k, fields[k]); err != nil {
+			return nil, "", fmt.Errorf("failed to write field %q: %w", k, err)
+		}
+	}
+
+	for _, k := range sortedKeys(files) {
+		path := files[k]
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to open file for field %q: %w", k, err)
+		}
+		part, err := w.CreateFormFile(k, filepath.Base(path))
+		if err != nil {
+			f.Close()
+			return nil, "", fmt.Errorf("failed to create file part %q: %w", k, err)
+		}
+		_, err = io.Copy(part, f)
+		f.Close()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read file %s: %w", path, err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to finalize multipart body: %w", err)
+	}
+
+	return &buf, w.FormDataContentType(), nil
+}
+
+// sortedKeys returns map keys in sorted order for deterministic output.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,277 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/net/http2/hpack"
+)
+
+// errQPACKDynamic is returned for header blocks that reference the QPACK
+// dynamic table. The client advertises a table capacity of zero, so a
+// conforming server never sends them.
+var errQPACKDynamic = errors.New("qpack: dynamic table reference")
+
+// qpackField is a decoded header field.
+type qpackField struct {
+	Name  string
+	Value string
+}
+
+// qpackStatic is the QPACK static table (RFC 9204 Appendix A).
+var qpackStatic = [...]qpackField{
+	0:  {":authority", ""},
+	1:  {":path", "/"},
+	2:  {"age", "0"},
+	3:  {"content-disposition", ""},
+	4:  {"content-length", "0"},
+	5:  {"cookie", ""},
+	6:  {"date", ""},
+	7:  {"etag", ""},
+	8:  {"if-modified-since", ""},
+	9:  {"if-none-match", ""},
+	10: {"last-modified", ""},
+	11: {"link", ""},
+	12: {"location", ""},
+	13: {"referer", ""},
+	14: {"set-cookie", ""},
+	15: {":method", "CONNECT"},
+	16: {":method", "DELETE"},
+	17: {":method", "GET"},
+	18: {":method", "HEAD"},
+	19: {":method", "OPTIONS"},
+	20: {":method", "POST"},
+	21: {":method", "PUT"},
+	22: {":scheme", "http"},
+	23: {":scheme", "https"},
+	24: {":status", "103"},
+	25: {":status", "200"},
+	26: {":status", "304"},
+	27: {":status", "404"},
+	28: {":status", "503"},
+	29: {"accept", "*/*"},
+	30: {"accept", "application/dns-message"},
+	31: {"accept-encoding", "gzip, deflate, br"},
+	32: {"accept-ranges", "bytes"},
+	33: {"access-control-allow-headers", "cache-control"},
+	34: {"access-control-allow-headers", "content-type"},
+	35: {"access-control-allow-origin", "*"},
+	36: {"cache-control", "max-age=0"},
+	37: {"cache-control", "max-age=2592000"},
+	38: {"cache-control", "max-age=604800"},
+	39: {"cache-control", "no-cache"},
+	40: {"cache-control", "no-store"},
+	41: {"cache-control", "public, max-age=31536000"},
+	42: {"content-encoding", "br"},
+	43: {"content-encoding", "gzip"},
+	44: {"content-type", "application/dns-message"},
+	45: {"content-type", "application/javascript"},
+	46: {"content-type", "application/json"},
+	47: {"content-type", "application/x-www-form-urlencoded"},
+	48: {"content-type", "image/gif"},
+	49: {"content-type", "image/jpeg"},
+	50: {"content-type", "image/png"},
+	51: {"content-type", "text/css"},
+	52: {"content-type", "text/html; charset=utf-8"},
+	53: {"content-type", "text/plain"},
+	54: {"content-type", "text/plain;charset=utf-8"},
+	55: {"range", "bytes=0-"},
+	56: {"strict-transport-security", "max-age=31536000"},
+	57: {"strict-transport-security", "max-age=31536000; includesubdomains"},
+	58: {"strict-transport-security", "max-age=31536000; includesubdomains; preload"},
+	59: {"vary", "accept-encoding"},
+	60: {"vary", "origin"},
+	61: {"x-content-type-options", "nosniff"},
+	62: {"x-xss-protection", "1; mode=block"},
+	63: {":status", "100"},
+	64: {":status", "204"},
+	65: {":status", "206"},
+	66: {":status", "302"},
+	67: {":status", "400"},
+	68: {":status", "403"},
+	69: {":status", "421"},
+	70: {":status", "425"},
+	71: {":status", "500"},
+	72: {"accept-language", ""},
+	73: {"access-control-allow-credentials", "FALSE"},
+	74: {"access-control-allow-credentials", "TRUE"},
+	75: {"access-control-allow-headers", "*"},
+	76: {"access-control-allow-methods", "get"},
+	77: {"access-control-allow-methods", "get, post, options"},
+	78: {"access-control-allow-methods", "options"},
+	79: {"access-control-expose-headers", "content-length"},
+	80: {"access-control-request-headers", "content-type"},
+	81: {"access-control-request-method", "get"},
+	82: {"access-control-request-method", "post"},
+	83: {"alt-svc", "clear"},
+	84: {"authorization", ""},
+	85: {"content-security-policy", "script-src 'none'; object-src 'none'; base-uri 'none'"},
+	86: {"early-data", "1"},
+	87: {"expect-ct", ""},
+	88: {"forwarded", ""},
+	89: {"if-range", ""},
+	90: {"origin", ""},
+	91: {"purpose", "prefetch"},
+	92: {"server", ""},
+	93: {"timing-allow-origin", "*"},
+	94: {"upgrade-insecure-requests", "1"},
+	95: {"user-agent", ""},
+	96: {"x-forwarded-for", ""},
+	97: {"x-frame-options", "deny"},
+	98: {"x-frame-options", "sameorigin"},
+}
+
+// encodeQPACK encodes fields as a QPACK field section that uses neither
+// the dynamic table nor Huffman coding. Names must be lowercase.
+func encodeQPACK(fields []qpackField) []byte {
+	b := []byte{0, 0} // Required Insert Count = 0, Base = 0
+	for _, f := range fields {
+		// Literal Field Line with Literal Name: 001NHxxx
+		b = appendQPACKInt(b, 0x20, 3, uint64(len(f.Name)))
+		b = append(b, f.Name...)
+		b = appendQPACKInt(b, 0x00, 7, uint64(len(f.Value)))
+		b = append(b, f.Value...)
+	}
+	return b
+}
+
+// decodeQPACK decodes a QPACK field section that only uses the static
+// table and literals.
+func decodeQPACK(b []byte) ([]qpackField, error) {
+	d := qpackDecoder{b: b}
+
+	ric, err := d.int(8)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := d.int(7); err != nil { // Base
+		return nil, err
+	}
+	if ric != 0 {
+		return nil, errQPACKDynamic
+	}
+
+	var fields []qpackField
+	for len(d.b) > 0 {
+		first := d.b[0]
+		var f qpackField
+		switch {
+		case first&0x80 != 0: // Indexed Field Line: 1Txxxxxx
+			if first&0x40 == 0 {
+				return nil, errQPACKDynamic
+			}
+			idx, err := d.int(6)
+			if err != nil {
+				return nil, err
+			}
+			if f, err = staticField(idx); err != nil {
+				return nil, err
+			}
+		case first&0x40 != 0: // Literal Field Line with Name Reference: 01NTxxxx
+			if first&0x10 == 0 {
+				return nil, errQPACKDynamic
+			}
+			idx, err := d.int(4)
+			if err != nil {
+				return nil, err
+			}
+			if f, err = staticField(idx); err != nil {
+				return nil, err
+			}
+			if f.Value, err = d.string(7); err != nil {
+				return nil, err
+			}
+		case first&0x20 != 0: // Literal Field Line with Literal Name: 001NHxxx
+			if f.Name, err = d.string(3); err != nil {
+				return nil, err
+			}
+			if f.Value, err = d.string(7); err != nil {
+				return nil, err
+			}
+		default: // Post-base forms always reference the dynamic table
+			return nil, errQPACKDynamic
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+func staticField(idx uint64) (qpackField, error) {
+	if idx >= uint64(len(qpackStatic)) {
+		return qpackField{}, fmt.Errorf("qpack: static index %d out of range", idx)
+	}
+	return qpackStatic[idx], nil
+}
+
+// qpackDecoder reads QPACK primitives from a field section.
+type qpackDecoder struct {
+	b []byte
+}
+
+var errQPACKTruncated = errors.New("qpack: truncated field section")
+
+// int reads a prefixed integer (RFC 7541 §5.1) whose prefix occupies the
+// low n bits of the first byte.
+func (d *qpackDecoder) int(n uint) (uint64, error) {
+	if len(d.b) == 0 {
+		return 0, errQPACKTruncated
+	}
+	mask := byte(1<<n - 1)
+	v := uint64(d.b[0] & mask)
+	d.b = d.b[1:]
+	if v < uint64(mask) {
+		return v, nil
+	}
+	for shift := uint(0); ; shift += 7 {
+		if len(d.b) == 0 {
+			return 0, errQPACKTruncated
+		}
+		if shift > 56 {
+			return 0, errors.New("qpack: integer overflow")
+		}
+		c := d.b[0]
+		d.b = d.b[1:]
+		v += uint64(c&0x7f) << shift
+		if c&0x80 == 0 {
+			return v, nil
+		}
+	}
+}
+
+// string reads a string literal whose Huffman flag is the bit just above
+// an n-bit length prefix.
+func (d *qpackDecoder) string(n uint) (string, error) {
+	if len(d.b) == 0 {
+		return "", errQPACKTruncated
+	}
+	huffman := d.b[0]&(1<<n) != 0
+	length, err := d.int(n)
+	if err != nil {
+		return "", err
+	}
+	if uint64(len(d.b)) < length {
+		return "", errQPACKTruncated
+	}
+	raw := d.b[:length]
+	d.b = d.b[length:]
+	if huffman {
+		return hpack.HuffmanDecodeToString(raw)
+	}
+	return string(raw), nil
+}
+
+// appendQPACKInt appends a prefixed integer, with first supplying the
+// bits above the n-bit prefix.
+func appendQPACKInt(b []byte, first byte, n uint, v uint64) []byte {
+	mask := uint64(1<<n - 1)
+	if v < mask {
+		return append(b, first|byte(v))
+	}
+	b = append(b, first|byte(mask))
+	v -= mask
+	for v >= 0x80 {
+		b = append(b, byte(v&0x7f)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
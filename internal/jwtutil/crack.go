@@ -0,0 +1,95 @@
+package jwtutil
+
+import (
+	"bufio"
+	"crypto/hmac"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+//go:embed common_secrets.txt
+var builtinSecrets string
+
+// CommonSecrets returns the built-in list of guessable HMAC secrets.
+func CommonSecrets() []string {
+	secrets, _ := ReadWordlist(strings.NewReader(builtinSecrets))
+	return secrets
+}
+
+// ReadWordlist reads candidate secrets, one per line. Only empty lines are
+// skipped; leading and trailing spaces are part of the candidate.
+func ReadWordlist(r io.Reader) ([]string, error) {
+	var words []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		word := strings.TrimRight(scanner.Text(), "\r")
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+	return words, scanner.Err()
+}
+
+// IsHMAC reports whether the token is signed with an HS* algorithm.
+func IsHMAC(r *AnalysisResult) bool {
+	return strings.HasPrefix(strings.ToUpper(r.Header.Algorithm), "HS")
+}
+
+// CrackHMAC tries each candidate in wordlist as the secret of an HS256/384/512
+// token and returns the first one that reproduces its signature.
+func CrackHMAC(tokenStr string, wordlist []string) (secret string, found bool) {
+	input, sig, alg, err := hmacParts(tokenStr)
+	if err != nil {
+		return "", false
+	}
+	hash, _ := hashFor(alg[2:])
+
+	for _, candidate := range wordlist {
+		mac := hmac.New(hash.New, []byte(candidate))
+		mac.Write(input)
+		if hmac.Equal(sig, mac.Sum(nil)) {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// hmacParts returns the signing input, signature and algorithm of an HS*
+// token.
+func hmacParts(tokenStr string) (input, sig []byte, alg string, err error) {
+	r, err := Decode(tokenStr)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	alg = strings.ToUpper(r.Header.Algorithm)
+	if len(alg) != 5 || !IsHMAC(r) {
+		return nil, nil, "", fmt.Errorf("not an HMAC token (alg %s)", r.Header.Algorithm)
+	}
+	if _, err := hashFor(alg[2:]); err != nil {
+		return nil, nil, "", fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	if r.Signature == "" {
+		return nil, nil, "", errors.New("token has no signature")
+	}
+	if sig, err = decodeSegment(r.Signature); err != nil {
+		return nil, nil, "", err
+	}
+	parts := splitToken(tokenStr)
+	return []byte(parts[0] + "." + parts[1]), sig, alg, nil
+}
+
+// RecordCrackedSecret marks the token as signed with a guessable secret,
+// which makes it forgeable and grades it F.
+func (r *AnalysisResult) RecordCrackedSecret(secret string) {
+	r.CrackedSecret = secret
+	r.SignatureChecked = true
+	r.SignatureValid = true
+	r.Findings = append(r.Findings, Finding{
+		Severity: "CRITICAL",
+		Message:  fmt.Sprintf("HMAC secret cracked: %q — anyone can forge tokens", secret),
+	})
+	r.Grade = calculateGrade(r.Findings)
+}
@@ -0,0 +1,224 @@
+package jwtutil
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxJWKSSize caps the JWKS document read from the network.
+const maxJWKSSize = 1 << 20
+
+// JWK is a JSON Web Key (RFC 7517) holding an RSA or EC public key.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// jwksCache holds fetched key sets by URL for the life of the process.
+var jwksCache = struct {
+	sync.Mutex
+	sets map[string]*JWKS
+}{sets: make(map[string]*JWKS)}
+
+var jwksClient = &http.Client{Timeout: 10 * time.Second}
+
+// PublicKey builds the RSA or ECDSA public key described by the JWK.
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeSegment(k.N)
+		if err != nil || len(n) == 0 {
+			return nil, errors.New("invalid RSA modulus")
+		}
+		e, err := decodeSegment(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		exp := 0
+		for _, b := range e {
+			exp = exp<<8 | int(b)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exp}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported EC curve %q", k.Crv)
+		}
+		x, errX := decodeSegment(k.X)
+		y, errY := decodeSegment(k.Y)
+		size := (curve.Params().BitSize + 7) / 8
+		if errX != nil || errY != nil || len(x) != size || len(y) != size {
+			return nil, errors.New("invalid EC coordinates")
+		}
+		point := append(append([]byte{4}, x...), y...)
+		return ecdsa.ParseUncompressedPublicKey(curve, point)
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// FetchJWKS downloads and parses the key set at url. Results are cached for
+// the life of the process.
+func FetchJWKS(ctx context.Context, url string) (*JWKS, error) {
+	jwksCache.Lock()
+	set, ok := jwksCache.sets[url]
+	jwksCache.Unlock()
+	if ok {
+		return set, nil
+	}
+	return refreshJWKS(ctx, url)
+}
+
+// refreshJWKS fetches url unconditionally and replaces any cached copy.
+func refreshJWKS(ctx context.Context, url string) (*JWKS, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := jwksClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch JWKS: HTTP %d", resp.StatusCode)
+	}
+
+	var set JWKS
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxJWKSSize)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("parse JWKS: %w", err)
+	}
+
+	jwksCache.Lock()
+	jwksCache.sets[url] = &set
+	jwksCache.Unlock()
+	return &set, nil
+}
+
+// VerifyWithJWKS decodes a token and verifies its signature against the key
+// in the JWKS at jwksURL whose kid matches the token header. If the kid is
+// not in the cached set, the set is fetched once more in case the issuer has
+// rotated keys. Tokens without a kid are tried against every compatible key.
+func VerifyWithJWKS(ctx context.Context, tokenStr, jwksURL string) (*AnalysisResult, error) {
+	result, err := Decode(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := splitToken(tokenStr)
+	if len(parts) != 3 || parts[2] == "" {
+		return nil, errors.New("token has no signature to verify")
+	}
+	sig, err := decodeSegment(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+
+	set, err := FetchJWKS(ctx, jwksURL)
+	if err != nil {
+		return nil, err
+	}
+	keys := candidateKeys(set, result.Header)
+	if len(keys) == 0 && result.Header.KeyID != "" {
+		if set, err = refreshJWKS(ctx, jwksURL); err != nil {
+			return nil, err
+		}
+		keys = candidateKeys(set, result.Header)
+	}
+	if len(keys) == 0 {
+		if result.Header.KeyID != "" {
+			return nil, fmt.Errorf("no key with kid %q in JWKS (%d keys)", result.Header.KeyID, len(set.Keys))
+		}
+		return nil, fmt.Errorf("no %s signing key in JWKS (%d keys)", result.Header.Algorithm, len(set.Keys))
+	}
+
+	input := parts[0] + "." + parts[1]
+	var lastErr error
+	for _, k := range keys {
+		pub, err := k.PublicKey()
+		if err != nil {
+			lastErr = fmt.Errorf("key %q: %w", k.Kid, err)
+			continue
+		}
+		if lastErr = verifySignature(result.Header.Algorithm, input, sig, pub); lastErr == nil {
+			break
+		}
+	}
+
+	if lastErr != nil {
+		result.setSignature(false, lastErr.Error())
+	} else {
+		result.setSignature(true, "")
+	}
+	return result, nil
+}
+
+// candidateKeys returns the signing keys in set that may have signed a token
+// with header h.
+func candidateKeys(set *JWKS, h Header) []JWK {
+	alg := strings.ToUpper(h.Algorithm)
+	kty := ""
+	switch {
+	case strings.HasPrefix(alg, "RS"), strings.HasPrefix(alg, "PS"):
+		kty = "RSA"
+	case strings.HasPrefix(alg, "ES"):
+		kty = "EC"
+	}
+
+	var keys []JWK
+	for _, k := range set.Keys {
+		if k.Use == "enc" {
+			continue
+		}
+		if h.KeyID != "" {
+			if k.Kid == h.KeyID {
+				keys = append(keys, k)
+			}
+			continue
+		}
+		if k.Kty == kty && (k.Alg == "" || strings.EqualFold(k.Alg, h.Algorithm)) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
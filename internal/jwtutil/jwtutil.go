@@ -33,21 +33,44 @@ type Claims struct {
 
 // Finding represents a security finding during JWT analysis.
 type Finding struct {
-	Severity string // "CRITICAL", "HIGH", "MEDIUM", "LOW", "INFO"
-	Message  string
+	Severity string `json:"severity"` // "CRITICAL", "HIGH", "MEDIUM", "LOW", "INFO"
+	Message  string `json:"message"`
 }
 
 // AnalysisResult holds the complete JWT analysis.
 type AnalysisResult struct {
-	Valid        bool
-	Header       Header
-	Claims       Claims
-	Parts        int
-	Signature    string
-	Findings     []Finding
-	ExpiryStatus string
-	ExpiresIn    time.Duration
-	Grade        string // A-F security grade
+	Valid     bool      `json:"valid"`
+	Header    Header    `json:"header"`
+	Claims    Claims    `json:"claims"`
+	Parts     int       `json:"parts"`
+	Signature string    `json:"signature"`
+	Findings  []Finding `json:"findings"`
+
+	// Set by Verify, VerifyPEM and VerifyWithJWKS
+	SignatureChecked bool   `json:"signature_checked"`
+	SignatureValid   bool   `json:"signature_valid"`
+	CrackedSecret    string `json:"cracked_secret,omitempty"` // Set by RecordCrackedSecret
+
+	ExpiryStatus string        `json:"expiry_status"`
+	ExpiresIn    time.Duration `json:"expires_in,omitempty"` // Time since expiry once EXPIRED
+	Grade        string        `json:"grade"`                // A-F security grade
+}
+
+// ToJSON returns the analysis as indented JSON.
+func (r *AnalysisResult) ToJSON() (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// MarshalJSON encodes every claim in the payload, standard and custom.
+func (c Claims) MarshalJSON() ([]byte, error) {
+	if c.Raw == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(c.Raw)
 }
 
 // Decode parses a JWT token string and performs security analysis.
@@ -56,11 +79,7 @@ func Decode(tokenStr string) (*AnalysisResult, error) {
 		Findings: make([]Finding, 0),
 	}
 
-	tokenStr = strings.TrimSpace(tokenStr)
-
-	tokenStr = strings.TrimPrefix(tokenStr, "Bearer ")
-
-	parts := strings.Split(tokenStr, ".")
+	parts := splitToken(tokenStr)
 	result.Parts = len(parts)
 
 	if len(parts) < 2 || len(parts) > 3 {
@@ -106,6 +125,14 @@ func Decode(tokenStr string) (*AnalysisResult, error) {
 	return result, nil
 }
 
+// splitToken trims whitespace and an optional "Bearer " prefix and splits
+// the token into its dot-separated segments.
+func splitToken(tokenStr string) []string {
+	tokenStr = strings.TrimSpace(tokenStr)
+	tokenStr = strings.TrimPrefix(tokenStr, "Bearer ")
+	return strings.Split(tokenStr, ".")
+}
+
 // decodeSegment decodes a base64url-encoded JWT segment.
 func decodeSegment(seg string) ([]byte, error) {
 	// Add padding if necessary
@@ -193,11 +220,23 @@ func analyzeHeader(r *AnalysisResult) {
 		})
 	}
 
-	if r.Parts < 3 {
+	// A trailing dot with nothing after it is as unsigned as a 2-part token.
+	switch {
+	case r.Parts < 3:
 		r.Findings = append(r.Findings, Finding{
 			Severity: "HIGH",
 			Message:  "Token has no signature segment",
 		})
+	case r.Signature == "" && (alg == "NONE" || alg == ""):
+		r.Findings = append(r.Findings, Finding{
+			Severity: "CRITICAL",
+			Message:  "Empty signature with alg 'none' — classic signature-stripping forgery",
+		})
+	case r.Signature == "":
+		r.Findings = append(r.Findings, Finding{
+			Severity: "HIGH",
+			Message:  "Token has an empty signature segment",
+		})
 	}
 }
 
@@ -373,6 +412,15 @@ func FormatResult(r *AnalysisResult) string {
 		sb.WriteString(fmt.Sprintf("  Not Before: %s\n", nbf.Format(time.RFC3339)))
 	}
 
+	// Signature
+	if r.SignatureChecked {
+		if r.SignatureValid {
+			sb.WriteString("\n✍️  Signature: 🟢 Verified\n")
+		} else {
+			sb.WriteString("\n✍️  Signature: 🔴 INVALID\n")
+		}
+	}
+
 	// Expiry status
 	sb.WriteString(fmt.Sprintf("\n⏱  Expiry: %s\n", formatExpiry(r)))
 
@@ -1,10 +1,24 @@
 package jwtutil
 
 import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -296,6 +310,46 @@ func TestFormatResult(t *testing.T) {
 	}
 }
 
+func TestAnalysisResultToJSON(t *testing.T) {
+	exp := time.Now().Add(-time.Hour).Unix()
+	token := makeToken(
+		map[string]any{"alg": "HS256", "typ": "JWT"},
+		map[string]any{"sub": "user1", "exp": exp, "role": "admin"},
+	)
+	result, err := Decode(token)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	out, err := result.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON: %v", err)
+	}
+	var decoded struct {
+		Header       Header         `json:"header"`
+		Claims       map[string]any `json:"claims"`
+		Findings     []Finding      `json:"findings"`
+		ExpiryStatus string         `json:"expiry_status"`
+		ExpiresIn    time.Duration  `json:"expires_in"`
+		Grade        string         `json:"grade"`
+	}
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, out)
+	}
+	if decoded.Header.Algorithm != "HS256" {
+		t.Errorf("alg = %q, want HS256", decoded.Header.Algorithm)
+	}
+	if decoded.Claims["role"] != "admin" || decoded.Claims["sub"] != "user1" {
+		t.Errorf("claims = %v, want custom and standard claims", decoded.Claims)
+	}
+	if decoded.ExpiryStatus != "EXPIRED" || decoded.ExpiresIn <= 0 {
+		t.Errorf("expiry = %s %v, want EXPIRED with time since expiry", decoded.ExpiryStatus, decoded.ExpiresIn)
+	}
+	if len(decoded.Findings) == 0 || decoded.Grade == "" {
+		t.Errorf("findings/grade missing:\n%s", out)
+	}
+}
+
 func TestFormatExpiry(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -390,6 +444,64 @@ func TestDecodeTwoParts(t *testing.T) {
 	if result.Parts != 2 {
 		t.Errorf("expected 2 parts, got %d", result.Parts)
 	}
+	if !hasFinding(result, "HIGH", "no signature segment") {
+		t.Error("expected HIGH finding for missing signature segment")
+	}
+}
+
+// hasFinding reports whether r has a finding of severity containing msg.
+func hasFinding(r *AnalysisResult, severity, msg string) bool {
+	for _, f := range r.Findings {
+		if f.Severity == severity && strings.Contains(f.Message, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDecodeEmptySignature(t *testing.T) {
+	tests := []struct {
+		alg      string
+		severity string
+		msg      string
+	}{
+		{"none", "CRITICAL", "signature-stripping forgery"},
+		{"None", "CRITICAL", "signature-stripping forgery"},
+		{"NONE", "CRITICAL", "signature-stripping forgery"},
+		{"HS256", "HIGH", "empty signature segment"},
+	}
+
+	for _, tt := range tests {
+		h, _ := json.Marshal(map[string]any{"alg": tt.alg, "typ": "JWT"})
+		c, _ := json.Marshal(map[string]any{"sub": "admin"})
+		token := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c) + "."
+
+		result, err := Decode(token)
+		if err != nil {
+			t.Fatalf("Decode(%s) failed: %v", tt.alg, err)
+		}
+		if result.Parts != 3 {
+			t.Errorf("%s: expected 3 parts, got %d", tt.alg, result.Parts)
+		}
+		if !hasFinding(result, tt.severity, tt.msg) {
+			t.Errorf("%s: expected %s finding %q, got %+v", tt.alg, tt.severity, tt.msg, result.Findings)
+		}
+	}
+}
+
+func TestDecodeNoneCasing(t *testing.T) {
+	token := makeToken(
+		map[string]any{"alg": "None", "typ": "JWT"},
+		map[string]any{"sub": "admin"},
+	)
+
+	result, err := Decode(token)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if !hasFinding(result, "CRITICAL", "'none'") || result.Grade != "F" {
+		t.Errorf("expected alg=None to be CRITICAL with grade F, got %s", result.Grade)
+	}
 }
 
 func TestDecodeUnknownAlgorithm(t *testing.T) {
@@ -455,3 +567,352 @@ func TestFormatResultLongCustomClaim(t *testing.T) {
 		fmt.Println("Note: long claim value truncation check")
 	}
 }
+
+// signToken builds a token for alg signed with key (a []byte secret or a
+// private key).
+func signToken(t *testing.T, alg string, key any, claims map[string]any) string {
+	t.Helper()
+	return signTokenHeader(t, map[string]any{"alg": alg, "typ": "JWT"}, key, claims)
+}
+
+func signTokenHeader(t *testing.T, header map[string]any, key any, claims map[string]any) string {
+	t.Helper()
+	alg := header["alg"].(string)
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+
+	hash, _ := hashFor(alg[2:])
+	digest := digestOf(hash, input)
+	var sig []byte
+	var err error
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(hash.New, k)
+		mac.Write([]byte(input))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		if alg[0] == 'P' {
+			sig, err = rsa.SignPSS(rand.Reader, k, hash, digest, nil)
+		} else {
+			sig, err = rsa.SignPKCS1v15(rand.Reader, k, hash, digest)
+		}
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k, digest)
+		size := (k.Curve.Params().BitSize + 7) / 8
+		sig = make([]byte, 2*size)
+		r.FillBytes(sig[:size])
+		s.FillBytes(sig[size:])
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func publicPEM(t *testing.T, pub crypto.PublicKey) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func TestVerifyHMAC(t *testing.T) {
+	claims := map[string]any{"sub": "user", "iss": "test", "exp": time.Now().Add(time.Hour).Unix()}
+	for _, alg := range []string{"HS256", "HS384", "HS512"} {
+		token := signToken(t, alg, []byte("s3cret"), claims)
+
+		result, err := Verify(token, []byte("s3cret"))
+		if err != nil {
+			t.Fatalf("Verify(%s) failed: %v", alg, err)
+		}
+		if !result.SignatureChecked || !result.SignatureValid {
+			t.Errorf("%s: expected valid signature", alg)
+		}
+
+		result, err = Verify(token, []byte("wrong"))
+		if err != nil {
+			t.Fatalf("Verify(%s) failed: %v", alg, err)
+		}
+		if result.SignatureValid || result.Grade != "F" {
+			t.Errorf("%s: wrong secret should fail with grade F, got valid=%v grade=%s", alg, result.SignatureValid, result.Grade)
+		}
+	}
+}
+
+func TestVerifyPEM(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ec384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := map[string]any{"sub": "user"}
+	tests := []struct {
+		alg   string
+		sign  any
+		pub   crypto.PublicKey
+		valid bool
+	}{
+		{"RS256", rsaKey, &rsaKey.PublicKey, true},
+		{"RS512", rsaKey, &rsaKey.PublicKey, true},
+		{"PS256", rsaKey, &rsaKey.PublicKey, true},
+		{"ES256", ecKey, &ecKey.PublicKey, true},
+		{"ES384", ec384, &ec384.PublicKey, true},
+		{"ES256", ecKey, &ec384.PublicKey, false},                            // wrong curve
+		{"RS256", rsaKey, &ecKey.PublicKey, false},                           // wrong key type
+		{"HS256", publicPEM(t, &rsaKey.PublicKey), &rsaKey.PublicKey, false}, // key confusion
+	}
+
+	for _, tt := range tests {
+		token := signToken(t, tt.alg, tt.sign, claims)
+		result, err := VerifyPEM(token, publicPEM(t, tt.pub))
+		if err != nil {
+			t.Fatalf("VerifyPEM(%s) failed: %v", tt.alg, err)
+		}
+		if result.SignatureValid != tt.valid {
+			t.Errorf("VerifyPEM(%s) valid = %v, want %v", tt.alg, result.SignatureValid, tt.valid)
+		}
+	}
+
+	// A tampered payload must not verify.
+	token := signToken(t, "RS256", rsaKey, claims)
+	parts := strings.Split(token, ".")
+	parts[1] = base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"admin"}`))
+	result, err := VerifyPEM(strings.Join(parts, "."), publicPEM(t, &rsaKey.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.SignatureValid {
+		t.Error("tampered token should not verify")
+	}
+}
+
+func TestVerifyErrors(t *testing.T) {
+	if _, err := VerifyPEM(makeToken(map[string]any{"alg": "RS256"}, nil), []byte("not a key")); err == nil {
+		t.Error("expected error for invalid PEM")
+	}
+
+	h, _ := json.Marshal(map[string]any{"alg": "HS256"})
+	token := base64.RawURLEncoding.EncodeToString(h) + ".e30"
+	if _, err := Verify(token, []byte("secret")); err == nil {
+		t.Error("expected error for unsigned token")
+	}
+}
+
+func TestCrackHMAC(t *testing.T) {
+	claims := map[string]any{"sub": "user"}
+
+	token := signToken(t, "HS256", []byte("your-256-bit-secret"), claims)
+	secret, found := CrackHMAC(token, CommonSecrets())
+	if !found || secret != "your-256-bit-secret" {
+		t.Errorf("CrackHMAC() = %q, %v; want built-in secret", secret, found)
+	}
+
+	token = signToken(t, "HS512", []byte("Tr0ub4dor&3-not-in-list"), claims)
+	if secret, found := CrackHMAC(token, CommonSecrets()); found {
+		t.Errorf("CrackHMAC() unexpectedly found %q", secret)
+	}
+
+	if _, found := CrackHMAC(makeToken(map[string]any{"alg": "RS256"}, claims), []string{"fakesignature"}); found {
+		t.Error("CrackHMAC() should ignore non-HMAC tokens")
+	}
+}
+
+func TestReadWordlist(t *testing.T) {
+	words, err := ReadWordlist(strings.NewReader("alpha\r\n\nbeta gamma\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(words) != 2 || words[0] != "alpha" || words[1] != "beta gamma" {
+		t.Errorf("ReadWordlist() = %q", words)
+	}
+}
+
+func TestRecordCrackedSecret(t *testing.T) {
+	token := signToken(t, "HS256", []byte("secret"), map[string]any{
+		"sub": "user", "iss": "test", "exp": time.Now().Add(time.Hour).Unix(),
+	})
+	result, err := Decode(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result.RecordCrackedSecret("secret")
+	if result.Grade != "F" || result.CrackedSecret != "secret" {
+		t.Errorf("expected grade F with cracked secret, got %s %q", result.Grade, result.CrackedSecret)
+	}
+}
+
+func rsaJWK(kid string, pub *rsa.PublicKey) JWK {
+	return JWK{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, priv *ecdsa.PrivateKey) JWK {
+	point, _ := priv.PublicKey.Bytes()
+	size := (len(point) - 1) / 2
+	return JWK{
+		Kty: "EC",
+		Kid: kid,
+		Crv: priv.Curve.Params().Name,
+		X:   base64.RawURLEncoding.EncodeToString(point[1 : 1+size]),
+		Y:   base64.RawURLEncoding.EncodeToString(point[1+size:]),
+	}
+}
+
+func TestVerifyWithJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fetches atomic.Int32
+	var mu sync.Mutex
+	set := JWKS{Keys: []JWK{rsaJWK("rsa-1", &rsaKey.PublicKey), ecJWK("ec-1", ecKey)}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		mu.Lock()
+		defer mu.Unlock()
+		json.NewEncoder(w).Encode(set)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	claims := map[string]any{"sub": "user"}
+
+	tests := []struct {
+		header map[string]any
+		key    any
+		valid  bool
+	}{
+		{map[string]any{"alg": "RS256", "kid": "rsa-1"}, rsaKey, true},
+		{map[string]any{"alg": "ES256", "kid": "ec-1"}, ecKey, true},
+		{map[string]any{"alg": "RS256"}, rsaKey, true},                   // no kid: try compatible keys
+		{map[string]any{"alg": "RS256", "kid": "rsa-1"}, rotated, false}, // wrong signer
+	}
+	for _, tt := range tests {
+		token := signTokenHeader(t, tt.header, tt.key, claims)
+		result, err := VerifyWithJWKS(ctx, token, srv.URL)
+		if err != nil {
+			t.Fatalf("VerifyWithJWKS(%v) failed: %v", tt.header, err)
+		}
+		if result.SignatureValid != tt.valid {
+			t.Errorf("VerifyWithJWKS(%v) valid = %v, want %v", tt.header, result.SignatureValid, tt.valid)
+		}
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("expected JWKS to be fetched once, got %d", n)
+	}
+
+	// An unknown kid triggers one refetch, picking up rotated keys.
+	mu.Lock()
+	set.Keys = append(set.Keys, rsaJWK("rsa-2", &rotated.PublicKey))
+	mu.Unlock()
+	token := signTokenHeader(t, map[string]any{"alg": "RS256", "kid": "rsa-2"}, rotated, claims)
+	result, err := VerifyWithJWKS(ctx, token, srv.URL)
+	if err != nil || !result.SignatureValid {
+		t.Errorf("expected rotated key to verify after refetch, err=%v", err)
+	}
+
+	token = signTokenHeader(t, map[string]any{"alg": "RS256", "kid": "missing"}, rsaKey, claims)
+	if _, err := VerifyWithJWKS(ctx, token, srv.URL); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("expected kid miss error, got %v", err)
+	}
+}
+
+func TestJWKPublicKeyErrors(t *testing.T) {
+	bad := []JWK{
+		{Kty: "oct"},
+		{Kty: "RSA", N: "", E: "AQAB"},
+		{Kty: "EC", Crv: "P-192"},
+		{Kty: "EC", Crv: "P-256", X: "AAAA", Y: "AAAA"},
+	}
+	for _, k := range bad {
+		if _, err := k.PublicKey(); err == nil {
+			t.Errorf("JWK %+v: expected error", k)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Now()
+	token := makeToken(
+		map[string]any{"alg": "HS256", "typ": "JWT"},
+		map[string]any{
+			"iss":   "https://issuer.example.com",
+			"sub":   "user",
+			"aud":   []string{"api", "web"},
+			"iat":   now.Unix(),
+			"exp":   now.Add(2 * time.Hour).Unix(),
+			"scope": "read",
+		},
+	)
+	result, err := Decode(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok := Expectations{
+		Issuer:         "https://issuer.example.com",
+		Audience:       "web",
+		RequiredClaims: []string{"scope", "sub"},
+		MaxLifetime:    3 * time.Hour,
+	}
+	if findings := Validate(result, ok); len(findings) != 0 {
+		t.Errorf("expected no findings, got %+v", findings)
+	}
+
+	bad := Expectations{
+		Issuer:         "https://other.example.com",
+		Audience:       "admin",
+		RequiredClaims: []string{"tenant"},
+		MaxLifetime:    time.Hour,
+	}
+	findings := Validate(result, bad)
+	if len(findings) != 4 {
+		t.Fatalf("expected 4 findings, got %+v", findings)
+	}
+	for i, want := range []string{"Wrong issuer", "not in aud", `"tenant"`, "exceeds maximum"} {
+		if !strings.Contains(findings[i].Message, want) {
+			t.Errorf("finding %d = %q, want it to mention %s", i, findings[i].Message, want)
+		}
+	}
+	if result.Grade != "D" {
+		t.Errorf("expected grade D after two HIGH findings, got %s", result.Grade)
+	}
+}
+
+func TestAudienceList(t *testing.T) {
+	if got := audienceList("api"); len(got) != 1 || got[0] != "api" {
+		t.Errorf("audienceList(string) = %v", got)
+	}
+	if got := audienceList([]any{"a", 1, "b"}); len(got) != 2 {
+		t.Errorf("audienceList(array) = %v", got)
+	}
+	if got := audienceList(nil); got != nil {
+		t.Errorf("audienceList(nil) = %v", got)
+	}
+}
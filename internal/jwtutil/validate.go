@@ -0,0 +1,95 @@
+package jwtutil
+
+import (
+	"fmt"
+	"time"
+)
+
+// Expectations describes what a token must contain to be accepted by a
+// particular service. Zero-valued fields are not checked.
+type Expectations struct {
+	Issuer         string        // Required iss
+	Audience       string        // Must appear in aud (string or array)
+	RequiredClaims []string      // Claims that must be present
+	MaxLifetime    time.Duration // Upper bound on exp - iat
+}
+
+// Validate checks the decoded claims against expected, appends a finding for
+// each mismatch to r, regrades it and returns the new findings.
+func Validate(r *AnalysisResult, expected Expectations) []Finding {
+	var findings []Finding
+	add := func(severity, format string, args ...any) {
+		findings = append(findings, Finding{Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if expected.Issuer != "" {
+		if iss, _ := r.Claims.Raw["iss"].(string); iss != expected.Issuer {
+			if iss == "" {
+				add("HIGH", "Missing issuer: expected %q", expected.Issuer)
+			} else {
+				add("HIGH", "Wrong issuer: %q (expected %q)", iss, expected.Issuer)
+			}
+		}
+	}
+
+	if expected.Audience != "" {
+		audiences := audienceList(r.Claims.Raw["aud"])
+		found := false
+		for _, aud := range audiences {
+			if aud == expected.Audience {
+				found = true
+				break
+			}
+		}
+		if !found {
+			if len(audiences) == 0 {
+				add("HIGH", "Missing audience: expected %q", expected.Audience)
+			} else {
+				add("HIGH", "Audience %q not in aud %v", expected.Audience, audiences)
+			}
+		}
+	}
+
+	for _, claim := range expected.RequiredClaims {
+		if _, ok := r.Claims.Raw[claim]; !ok {
+			add("MEDIUM", "Missing required claim %q", claim)
+		}
+	}
+
+	if expected.MaxLifetime > 0 {
+		if r.Claims.ExpiresAt == nil {
+			add("MEDIUM", "No exp claim, lifetime exceeds maximum %v", expected.MaxLifetime)
+		} else {
+			start := time.Now()
+			if r.Claims.IssuedAt != nil {
+				start = time.Unix(*r.Claims.IssuedAt, 0)
+			}
+			lifetime := time.Unix(*r.Claims.ExpiresAt, 0).Sub(start)
+			if lifetime > expected.MaxLifetime {
+				add("MEDIUM", "Token lifetime %v exceeds maximum %v", lifetime.Round(time.Second), expected.MaxLifetime)
+			}
+		}
+	}
+
+	r.Findings = append(r.Findings, findings...)
+	r.Grade = calculateGrade(r.Findings)
+	return findings
+}
+
+// audienceList normalizes an aud claim, which may be a string or an array.
+func audienceList(aud any) []string {
+	switch v := aud.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		var list []string
+		for _, a := range v {
+			if s, ok := a.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,217 @@
+package jwtutil
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Verify decodes a token and checks its HS256/384/512 signature against a
+// shared secret. The result's SignatureValid field reports the outcome.
+func Verify(tokenStr string, key []byte) (*AnalysisResult, error) {
+	return verifyToken(tokenStr, key)
+}
+
+// VerifyPEM decodes a token and checks its RS*, PS* or ES* signature against
+// a PEM-encoded public key (PKIX, PKCS#1 or an X.509 certificate).
+func VerifyPEM(tokenStr string, pemData []byte) (*AnalysisResult, error) {
+	pub, err := ParsePublicKeyPEM(pemData)
+	if err != nil {
+		return nil, err
+	}
+	return verifyToken(tokenStr, pub)
+}
+
+// ParsePublicKeyPEM parses the first PEM block in pemData as an RSA or ECDSA
+// public key.
+func ParsePublicKeyPEM(pemData []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("no PEM block found in key")
+	}
+
+	var pub any
+	var err error
+	switch block.Type {
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			pub = cert.PublicKey
+		}
+	case "RSA PUBLIC KEY":
+		pub, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		pub, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse public key: %w", err)
+	}
+
+	switch pub.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return pub, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
+
+// verifyToken decodes tokenStr, checks the signature with key and records
+// the outcome as a finding.
+func verifyToken(tokenStr string, key any) (*AnalysisResult, error) {
+	result, err := Decode(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := splitToken(tokenStr)
+	if len(parts) != 3 || parts[2] == "" {
+		return nil, errors.New("token has no signature to verify")
+	}
+	sig, err := decodeSegment(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+
+	if err := verifySignature(result.Header.Algorithm, parts[0]+"."+parts[1], sig, key); err != nil {
+		result.setSignature(false, err.Error())
+	} else {
+		result.setSignature(true, "")
+	}
+	return result, nil
+}
+
+// setSignature records a verification outcome and regrades the result.
+func (r *AnalysisResult) setSignature(valid bool, reason string) {
+	r.SignatureChecked = true
+	r.SignatureValid = valid
+	if valid {
+		r.Findings = append(r.Findings, Finding{
+			Severity: "INFO",
+			Message:  fmt.Sprintf("Signature verified (%s)", r.Header.Algorithm),
+		})
+	} else {
+		r.Findings = append(r.Findings, Finding{
+			Severity: "CRITICAL",
+			Message:  "Signature verification failed: " + reason,
+		})
+	}
+	r.Grade = calculateGrade(r.Findings)
+}
+
+// verifySignature checks sig over signingInput for alg. key is a []byte
+// secret for HS* and an *rsa.PublicKey or *ecdsa.PublicKey otherwise; a
+// mismatched key type is rejected so a public key is never used as an HMAC
+// secret.
+func verifySignature(alg, signingInput string, sig []byte, key any) error {
+	alg = strings.ToUpper(alg)
+	if len(alg) != 5 {
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	hash, err := hashFor(alg[2:])
+	if err != nil {
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+
+	switch alg[:2] {
+	case "HS":
+		secret, ok := key.([]byte)
+		if !ok {
+			return fmt.Errorf("%s requires a shared secret, not a public key", alg)
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return errors.New("HMAC mismatch")
+		}
+		return nil
+
+	case "RS", "PS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%s requires an RSA public key", alg)
+		}
+		digest := digestOf(hash, signingInput)
+		if alg[0] == 'R' {
+			err = rsa.VerifyPKCS1v15(pub, hash, digest, sig)
+		} else {
+			err = rsa.VerifyPSS(pub, hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+		}
+		if err != nil {
+			return errors.New("RSA signature mismatch")
+		}
+		return nil
+
+	case "ES":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%s requires an ECDSA public key", alg)
+		}
+		if want := curveFor(alg); want == nil || pub.Curve != want {
+			return fmt.Errorf("%s key is on the wrong curve (%s)", alg, pub.Curve.Params().Name)
+		}
+		// JWS encodes ECDSA signatures as fixed-width R || S (RFC 7518 3.4).
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return fmt.Errorf("ECDSA signature is %d bytes, want %d", len(sig), 2*size)
+		}
+		rr := new(big.Int).SetBytes(sig[:size])
+		ss := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(pub, digestOf(hash, signingInput), rr, ss) {
+			return errors.New("ECDSA signature mismatch")
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+}
+
+func hashFor(bits string) (crypto.Hash, error) {
+	switch bits {
+	case "256":
+		return crypto.SHA256, nil
+	case "384":
+		return crypto.SHA384, nil
+	case "512":
+		return crypto.SHA512, nil
+	default:
+		return 0, fmt.Errorf("unsupported hash size %s", bits)
+	}
+}
+
+func curveFor(alg string) elliptic.Curve {
+	switch alg {
+	case "ES256":
+		return elliptic.P256()
+	case "ES384":
+		return elliptic.P384()
+	case "ES512":
+		return elliptic.P521()
+	default:
+		return nil
+	}
+}
+
+func digestOf(hash crypto.Hash, data string) []byte {
+	switch hash {
+	case crypto.SHA384:
+		sum := sha512.Sum384([]byte(data))
+		return sum[:]
+	case crypto.SHA512:
+		sum := sha512.Sum512([]byte(data))
+		return sum[:]
+	default:
+		sum := sha256.Sum256([]byte(data))
+		return sum[:]
+	}
+}
@@ -0,0 +1,254 @@
+// Package miniyaml parses the small YAML subset used by nns config and
+// rules files: block mappings, block and flow sequences, and scalars.
+package miniyaml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// srcLine is a non-blank, comment-stripped line of YAML.
+type srcLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+// Parse parses src into map[string]interface{}, []interface{} and scalar
+// values. Scalars are resolved to bool, int64, float64, nil or string.
+func Parse(src string) (interface{}, error) {
+	return parse(src, false)
+}
+
+// ParseStrings is like Parse but leaves every scalar as its unquoted
+// string, for callers that interpret values themselves.
+func ParseStrings(src string) (interface{}, error) {
+	return parse(src, true)
+}
+
+func parse(src string, raw bool) (interface{}, error) {
+	var lines []srcLine
+	for i, text := range strings.Split(src, "\n") {
+		text = strings.TrimRight(text, " \t\r")
+		if strings.Contains(text, "\t") && strings.TrimLeft(text, "\t") != text {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		content := stripComment(strings.TrimLeft(text, " "))
+		if content == "" || content == "---" {
+			continue
+		}
+		lines = append(lines, srcLine{num: i + 1, indent: len(text) - len(strings.TrimLeft(text, " ")), text: content})
+	}
+	if len(lines) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	p := &parser{lines: lines, raw: raw}
+	v, err := p.parseNode(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].num)
+	}
+	return v, nil
+}
+
+type parser struct {
+	lines []srcLine
+	pos   int
+	raw   bool // Keep scalars as strings
+}
+
+func (p *parser) parseNode(indent int) (interface{}, error) {
+	line := p.lines[p.pos]
+	if line.text == "-" || strings.HasPrefix(line.text, "- ") {
+		return p.parseSeq(indent)
+	}
+	return p.parseMap(indent)
+}
+
+func (p *parser) parseSeq(indent int) (interface{}, error) {
+	var seq []interface{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent != indent || !(line.text == "-" || strings.HasPrefix(line.text, "- ")) {
+			break
+		}
+
+		item := strings.TrimSpace(strings.TrimPrefix(line.text, "-"))
+		if item == "" {
+			p.pos++
+			if p.pos >= len(p.lines) || p.lines[p.pos].indent <= indent {
+				seq = append(seq, nil)
+				continue
+			}
+			v, err := p.parseNode(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, v)
+			continue
+		}
+
+		if _, _, isKey := splitKey(item); isKey {
+			// "- key: value" starts a mapping indented at the item text.
+			offset := indent + (len(line.text) - len(item))
+			p.lines[p.pos] = srcLine{num: line.num, indent: offset, text: item}
+			v, err := p.parseMap(offset)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, v)
+			continue
+		}
+
+		seq = append(seq, p.scalar(item))
+		p.pos++
+	}
+	return seq, nil
+}
+
+func (p *parser) parseMap(indent int) (interface{}, error) {
+	m := make(map[string]interface{})
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.num)
+		}
+		key, rest, ok := splitKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected 'key: value'", line.num)
+		}
+		p.pos++
+
+		if rest != "" {
+			m[key] = p.scalar(rest)
+			continue
+		}
+
+		// Nested block: deeper indentation, or a sequence at the same level.
+		if p.pos < len(p.lines) {
+			next := p.lines[p.pos]
+			isSeq := next.text == "-" || strings.HasPrefix(next.text, "- ")
+			if next.indent > indent || (next.indent == indent && isSeq) {
+				v, err := p.parseNode(next.indent)
+				if err != nil {
+					return nil, err
+				}
+				m[key] = v
+				continue
+			}
+		}
+		m[key] = nil
+	}
+	return m, nil
+}
+
+// splitKey splits "key: value" and reports whether text is a mapping entry.
+func splitKey(text string) (key, rest string, ok bool) {
+	idx := -1
+	inSingle, inDouble := false, false
+	for i := 0; i < len(text) && idx == -1; i++ {
+		switch text[i] {
+		case '\'':
+			if !inDouble {
+				inSingle = !inSingle
+			}
+		case '"':
+			if !inSingle {
+				inDouble = !inDouble
+			}
+		case ':':
+			if !inSingle && !inDouble && (i == len(text)-1 || text[i+1] == ' ') {
+				idx = i
+			}
+		}
+	}
+	if idx == -1 {
+		return "", "", false
+	}
+
+	key = strings.TrimSpace(text[:idx])
+	if key == "" || strings.ContainsAny(key, "[]{}") {
+		return "", "", false
+	}
+	if k, ok := parseScalar(key, true).(string); ok {
+		key = k
+	}
+	return key, strings.TrimSpace(text[idx+1:]), true
+}
+
+// scalar parses a scalar or flow sequence according to the parser mode.
+func (p *parser) scalar(s string) interface{} {
+	return parseScalar(s, p.raw)
+}
+
+// parseScalar parses a scalar or a flow sequence like [a, b]. With raw set,
+// scalars are only unquoted.
+func parseScalar(s string, raw bool) interface{} {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		inner := strings.TrimSpace(s[1 : len(s)-1])
+		items := []interface{}{}
+		if inner == "" {
+			return items
+		}
+		for _, part := range strings.Split(inner, ",") {
+			items = append(items, parseScalar(part, raw))
+		}
+		return items
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+	}
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	if raw {
+		return s
+	}
+	switch strings.ToLower(s) {
+	case "true", "yes", "on":
+		return true
+	case "false", "no", "off":
+		return false
+	case "null", "~":
+		return nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
+
+// stripComment removes a trailing "# comment" outside of quotes.
+func stripComment(s string) string {
+	inSingle, inDouble := false, false
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\'':
+			if !inDouble {
+				inSingle = !inSingle
+			}
+		case '"':
+			if !inSingle && (i == 0 || s[i-1] != '\\') {
+				inDouble = !inDouble
+			}
+		case '#':
+			if !inSingle && !inDouble && (i == 0 || s[i-1] == ' ') {
+				return strings.TrimRight(s[:i], " ")
+			}
+		}
+	}
+	return s
+}
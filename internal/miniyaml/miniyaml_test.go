@@ -0,0 +1,66 @@
+package miniyaml
+
+import (
+	"reflect"
+	"testing"
+)
+
+const doc = `
+# comment
+name: "web" # trailing
+retries: 3
+enabled: yes
+tags: [a, 'b c']
+items:
+  - id: 1
+    path: /x
+  - plain
+`
+
+func TestParse(t *testing.T) {
+	got, err := Parse(doc)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := map[string]interface{}{
+		"name":    "web",
+		"retries": int64(3),
+		"enabled": true,
+		"tags":    []interface{}{"a", "b c"},
+		"items": []interface{}{
+			map[string]interface{}{"id": int64(1), "path": "/x"},
+			"plain",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %#v, want %#v", got, want)
+	}
+}
+
+func TestParseStrings(t *testing.T) {
+	got, err := ParseStrings("count: 010\nflag: on\nlist: [1, \"2\"]\n")
+	if err != nil {
+		t.Fatalf("ParseStrings() error = %v", err)
+	}
+	want := map[string]interface{}{
+		"count": "010",
+		"flag":  "on",
+		"list":  []interface{}{"1", "2"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseStrings() = %#v, want %#v", got, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"a: 1\n    b: 2\n",
+		"\ta: 1\n",
+		"just text\n",
+	}
+	for _, src := range tests {
+		if _, err := Parse(src); err == nil {
+			t.Errorf("Parse(%q) should return error", src)
+		}
+	}
+}
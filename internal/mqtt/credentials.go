@@ -0,0 +1,100 @@
+package mqtt
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Credential is a username/password pair to try against a broker.
+type Credential struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func (c Credential) String() string {
+	return c.Username + "/" + c.Password
+}
+
+// CredentialResult is the outcome of one login attempt.
+type CredentialResult struct {
+	Credential Credential `json:"credential"`
+	Accepted   bool       `json:"accepted"`
+	ReturnCode byte       `json:"return_code"`
+	Error      error      `json:"-"`
+}
+
+// DefaultCredentials lists factory defaults and common weak logins for
+// popular brokers (EMQX, HiveMQ, RabbitMQ, ActiveMQ, Mosquitto setups).
+var DefaultCredentials = []Credential{
+	{"admin", "admin"},
+	{"admin", "public"},
+	{"admin", "password"},
+	{"admin", "123456"},
+	{"admin", "hivemq"},
+	{"guest", "guest"},
+	{"user", "user"},
+	{"test", "test"},
+	{"mqtt", "mqtt"},
+	{"mosquitto", "mosquitto"},
+	{"root", "root"},
+	{"system", "manager"},
+}
+
+// TestCredentials tries each pair in Options.CredentialList on its own
+// connection, one at a time with Options.CredentialDelay between attempts,
+// and reports which ones the broker accepts. It stops early if ctx is done.
+func (c *Checker) TestCredentials(ctx context.Context) []CredentialResult {
+	results := make([]CredentialResult, 0, len(c.opts.CredentialList))
+
+	for i, cred := range c.opts.CredentialList {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return results
+			case <-time.After(c.opts.CredentialDelay):
+			}
+		}
+		if ctx.Err() != nil {
+			return results
+		}
+		results = append(results, c.tryCredential(ctx, cred))
+	}
+	return results
+}
+
+// tryCredential connects with cred and reads the CONNACK.
+func (c *Checker) tryCredential(ctx context.Context, cred Credential) CredentialResult {
+	res := CredentialResult{Credential: cred}
+
+	conn, err := c.connect(ctx, &Result{})
+	if err != nil {
+		res.Error = err
+		return res
+	}
+	defer conn.Close()
+
+	conn.SetWriteDeadline(time.Now().Add(c.opts.Timeout))
+	if _, err := conn.Write(buildConnectPacket(c.opts.ClientID, cred.Username, cred.Password)); err != nil {
+		res.Error = fmt.Errorf("failed to send CONNECT: %w", err)
+		return res
+	}
+
+	conn.SetReadDeadline(time.Now().Add(c.opts.Timeout))
+	_, payload, err := readUntil(c.packetReader(conn), packetCONNACK)
+	if err != nil {
+		res.Error = fmt.Errorf("failed to read CONNACK: %w", err)
+		return res
+	}
+	if len(payload) < 2 {
+		res.Error = fmt.Errorf("CONNACK too short")
+		return res
+	}
+
+	res.ReturnCode = payload[1]
+	res.Accepted = res.ReturnCode == connAccepted
+	if res.Accepted {
+		c.mqttDisconnect(conn)
+	}
+	return res
+}
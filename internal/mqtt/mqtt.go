@@ -6,6 +6,7 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -25,6 +26,7 @@ const (
 	packetSUBSCRIBE   byte = 8
 	packetSUBACK      byte = 9
 	packetUNSUBSCRIBE byte = 10
+	packetUNSUBACK    byte = 11
 	packetPINGREQ     byte = 12
 	packetPINGRESP    byte = 13
 	packetDISCONNECT  byte = 14
@@ -42,58 +44,78 @@ const (
 
 // Result holds the outcome of a broker check.
 type Result struct {
-	Host        string
-	Port        int
-	Connected   bool
-	UseTLS      bool
-	Error       error
-	ConnTime    time.Duration
-	TLSTime     time.Duration
-	AuthResult  AuthResult
-	PingLatency PingStats
-	Topics      []TopicResult
-	BrokerInfo  BrokerInfo
-	StartTime   time.Time
-	Duration    time.Duration
+	Host          string        `json:"host"`
+	Port          int           `json:"port"`
+	Connected     bool          `json:"connected"`
+	UseTLS        bool          `json:"use_tls"`
+	Transport     string        `json:"transport"`
+	Error         error         `json:"-"`
+	ConnTime      time.Duration `json:"conn_time"`
+	TLSTime       time.Duration `json:"tls_time,omitempty"`
+	UpgradeTime   time.Duration `json:"upgrade_time,omitempty"` // WebSocket handshake
+	AuthResult    AuthResult    `json:"auth"`
+	PingLatency   PingStats     `json:"ping"`
+	Topics        []TopicResult `json:"topics"`
+	PublishResult PublishResult `json:"publish"`
+	BrokerInfo    BrokerInfo    `json:"broker"`
+	StartTime     time.Time     `json:"start_time"`
+	Duration      time.Duration `json:"duration"`
 }
 
 // AuthResult describes authentication test results.
 type AuthResult struct {
-	Tested        bool
-	AnonAllowed   bool
-	AuthRequired  bool
-	AuthSuccess   bool
-	ReturnCode    byte
-	ReturnMessage string
+	Tested        bool   `json:"tested"`
+	AnonAllowed   bool   `json:"anon_allowed"`
+	AuthRequired  bool   `json:"auth_required"`
+	AuthSuccess   bool   `json:"auth_success"`
+	ReturnCode    byte   `json:"return_code"`
+	ReturnMessage string `json:"return_message,omitempty"`
+
+	// Credential list testing (Options.CredentialList)
+	CredentialsTested int                `json:"credentials_tested"`
+	WeakCredentials   []Credential       `json:"weak_credentials,omitempty"` // Pairs the broker accepted
+	Credentials       []CredentialResult `json:"credentials,omitempty"`
 }
 
 // PingStats holds MQTT PINGREQ/PINGRESP latency statistics.
 type PingStats struct {
-	Count    int
-	Sent     int
-	Received int
-	MinRTT   time.Duration
-	MaxRTT   time.Duration
-	AvgRTT   time.Duration
-	StdDev   time.Duration
-	AllRTTs  []time.Duration
+	Count    int             `json:"count"`
+	Sent     int             `json:"sent"`
+	Received int             `json:"received"`
+	MinRTT   time.Duration   `json:"min_rtt"`
+	MaxRTT   time.Duration   `json:"max_rtt"`
+	AvgRTT   time.Duration   `json:"avg_rtt"`
+	StdDev   time.Duration   `json:"std_dev"`
+	AllRTTs  []time.Duration `json:"rtts"`
 }
 
 // TopicResult holds a topic subscription probe result.
 type TopicResult struct {
-	Filter     string
-	Subscribed bool
-	QoS        byte
-	Error      error
+	Filter     string `json:"filter"`
+	Subscribed bool   `json:"subscribed"`
+	QoS        byte   `json:"qos"`
+	Error      error  `json:"-"`
+}
+
+// PublishResult describes a PUBLISH round-trip test: the checker subscribes
+// to a topic, publishes to it and waits for the broker to deliver the
+// message back.
+type PublishResult struct {
+	Tested  bool          `json:"tested"`
+	Topic   string        `json:"topic,omitempty"`
+	Payload string        `json:"payload,omitempty"`
+	Success bool          `json:"success"`
+	Latency time.Duration `json:"latency,omitempty"` // Publish to receive
+	Error   error         `json:"-"`
 }
 
 // BrokerInfo captures information about the MQTT broker.
 type BrokerInfo struct {
-	ProtocolLevel byte
-	CleanSession  bool
-	KeepAlive     uint16
-	MaxTopicAlias int
-	ServerID      string
+	ProtocolLevel byte   `json:"protocol_level"`
+	CleanSession  bool   `json:"clean_session"`
+	KeepAlive     uint16 `json:"keep_alive"`
+	MaxTopicAlias int    `json:"max_topic_alias,omitempty"`
+	ServerID      string `json:"server_id,omitempty"`
 }
 
 // Options configures the MQTT checker.
@@ -102,12 +124,25 @@ type Options struct {
 	Port       int
 	UseTLS     bool
 	SkipVerify bool
+	Transport  string // TransportTCP (default) or TransportWS
+	WSPath     string // WebSocket endpoint path (default: /mqtt)
 	Username   string
 	Password   string
 	ClientID   string
 	Timeout    time.Duration
 	PingCount  int
 	Topics     []string // Topic filters to probe
+
+	// TestPublish publishes PublishPayload to PublishTopic and confirms
+	// the broker delivers it back to this client.
+	TestPublish    bool
+	PublishTopic   string
+	PublishPayload string
+
+	// CredentialList is tried pair by pair when the broker refuses
+	// anonymous connections. See DefaultCredentials.
+	CredentialList  []Credential
+	CredentialDelay time.Duration // Pause between attempts
 }
 
 // DefaultOptions returns sensible defaults.
@@ -128,6 +163,9 @@ func DefaultOptions() Options {
 // Checker performs MQTT broker checks.
 type Checker struct {
 	opts Options
+
+	reader     *bufio.Reader
+	readerConn net.Conn
 }
 
 // NewChecker creates a new MQTT checker.
@@ -144,6 +182,23 @@ func NewChecker(opts Options) *Checker {
 	if opts.Port <= 0 {
 		opts.Port = 1883
 	}
+	if opts.Transport == "" {
+		opts.Transport = TransportTCP
+	}
+	if opts.Transport == TransportWS && opts.WSPath == "" {
+		opts.WSPath = "/mqtt"
+	}
+	if len(opts.CredentialList) > 0 && opts.CredentialDelay <= 0 {
+		opts.CredentialDelay = 500 * time.Millisecond
+	}
+	if opts.TestPublish {
+		if opts.PublishTopic == "" {
+			opts.PublishTopic = "nns/publish-test"
+		}
+		if opts.PublishPayload == "" {
+			opts.PublishPayload = fmt.Sprintf("nns-mqtt-check %d", time.Now().UnixNano())
+		}
+	}
 	return &Checker{opts: opts}
 }
 
@@ -154,6 +209,7 @@ func (c *Checker) Check(ctx context.Context) (*Result, error) {
 		Host:      c.opts.Host,
 		Port:      c.opts.Port,
 		UseTLS:    c.opts.UseTLS,
+		Transport: c.opts.Transport,
 		StartTime: start,
 	}
 
@@ -186,15 +242,37 @@ func (c *Checker) Check(ctx context.Context) (*Result, error) {
 		result.Topics = c.probeTopics(conn)
 	}
 
+	// Publish round trip
+	if (result.AuthResult.AnonAllowed || result.AuthResult.AuthSuccess) && c.opts.TestPublish {
+		result.PublishResult = c.publishTest(conn, c.opts.PublishTopic, c.opts.PublishPayload)
+	}
+
 	// Disconnect cleanly
 	c.mqttDisconnect(conn)
 
+	// Credential list. Pointless when anonymous access is already open,
+	// since such brokers typically accept any username.
+	if len(c.opts.CredentialList) > 0 && !result.AuthResult.AnonAllowed {
+		creds := c.TestCredentials(ctx)
+		result.AuthResult.Credentials = creds
+		result.AuthResult.CredentialsTested = len(creds)
+		for _, cr := range creds {
+			if cr.Accepted {
+				result.AuthResult.WeakCredentials = append(result.AuthResult.WeakCredentials, cr.Credential)
+			}
+		}
+	}
+
 	result.Duration = time.Since(start)
 	return result, nil
 }
 
-// connect establishes a TCP (optionally TLS) connection.
+// connect establishes a TCP (optionally TLS) connection, upgraded to a
+// WebSocket when the ws transport is selected.
 func (c *Checker) connect(ctx context.Context, result *Result) (net.Conn, error) {
+	if c.opts.Transport != TransportTCP && c.opts.Transport != TransportWS {
+		return nil, fmt.Errorf("unsupported transport %q", c.opts.Transport)
+	}
 	addr := fmt.Sprintf("%s:%d", c.opts.Host, c.opts.Port)
 
 	dialer := &net.Dialer{Timeout: c.opts.Timeout}
@@ -230,6 +308,17 @@ func (c *Checker) connect(ctx context.Context, result *Result) (net.Conn, error)
 		result.ConnTime = time.Since(connStart)
 	}
 
+	if c.opts.Transport == TransportWS {
+		wsStart := time.Now()
+		ws, err := wsHandshake(conn, addr, c.opts.WSPath, c.opts.Timeout)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		result.UpgradeTime = time.Since(wsStart)
+		conn = ws
+	}
+
 	return conn, nil
 }
 
@@ -244,7 +333,7 @@ func (c *Checker) mqttConnect(conn net.Conn, result *Result) (AuthResult, error)
 	}
 
 	conn.SetReadDeadline(time.Now().Add(c.opts.Timeout))
-	reader := bufio.NewReader(conn)
+	reader := c.packetReader(conn)
 
 	pktType, payload, err := readPacket(reader)
 	if err != nil {
@@ -301,11 +390,10 @@ func (c *Checker) measurePingLatency(conn net.Conn) PingStats {
 		}
 
 		conn.SetReadDeadline(time.Now().Add(c.opts.Timeout))
-		reader := bufio.NewReader(conn)
-		pktType, _, err := readPacket(reader)
+		_, _, err := readUntil(c.packetReader(conn), packetPINGRESP)
 		rtt := time.Since(start)
 
-		if err != nil || pktType != packetPINGRESP {
+		if err != nil {
 			continue
 		}
 
@@ -342,9 +430,9 @@ func (c *Checker) probeTopics(conn net.Conn) []TopicResult {
 			continue
 		}
 
+		// Skip retained PUBLISHes and the previous UNSUBACK.
 		conn.SetReadDeadline(time.Now().Add(c.opts.Timeout))
-		reader := bufio.NewReader(conn)
-		pktType, payload, err := readPacket(reader)
+		_, payload, err := readUntil(c.packetReader(conn), packetSUBACK)
 
 		if err != nil {
 			result.Error = err
@@ -352,7 +440,7 @@ func (c *Checker) probeTopics(conn net.Conn) []TopicResult {
 			continue
 		}
 
-		if pktType == packetSUBACK && len(payload) >= 3 {
+		if len(payload) >= 3 {
 			grantedQoS := payload[2]
 			if grantedQoS <= 2 {
 				result.Subscribed = true
@@ -371,6 +459,75 @@ func (c *Checker) probeTopics(conn net.Conn) []TopicResult {
 	return results
 }
 
+// publishTest subscribes to topic, publishes payload to it at QoS 0 and
+// waits for the broker to deliver the message back, measuring the time from
+// PUBLISH to delivery.
+func (c *Checker) publishTest(conn net.Conn, topic, payload string) PublishResult {
+	res := PublishResult{Tested: true, Topic: topic, Payload: payload}
+	if topic == "" || strings.ContainsAny(topic, "+#") {
+		res.Error = fmt.Errorf("invalid publish topic %q", topic)
+		return res
+	}
+	reader := c.packetReader(conn)
+	packetID := uint16(len(c.opts.Topics) + 1)
+
+	conn.SetWriteDeadline(time.Now().Add(c.opts.Timeout))
+	if _, err := conn.Write(buildSubscribePacket(packetID, topic, 0)); err != nil {
+		res.Error = fmt.Errorf("failed to send SUBSCRIBE: %w", err)
+		return res
+	}
+	conn.SetReadDeadline(time.Now().Add(c.opts.Timeout))
+	_, suback, err := readUntil(reader, packetSUBACK)
+	if err != nil {
+		res.Error = fmt.Errorf("failed to read SUBACK: %w", err)
+		return res
+	}
+	if len(suback) < 3 || suback[2] > 2 {
+		res.Error = fmt.Errorf("subscription to %q refused", topic)
+		return res
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(c.opts.Timeout))
+	start := time.Now()
+	if _, err := conn.Write(buildPublishPacket(topic, []byte(payload))); err != nil {
+		res.Error = fmt.Errorf("failed to send PUBLISH: %w", err)
+		return res
+	}
+
+	conn.SetReadDeadline(time.Now().Add(c.opts.Timeout))
+	for {
+		header, body, err := readRawPacket(reader)
+		if err != nil {
+			res.Error = fmt.Errorf("message not delivered: %w", err)
+			break
+		}
+		if header>>4 != packetPUBLISH {
+			continue
+		}
+		gotTopic, message, retained, err := parsePublish(header, body)
+		if err != nil || retained || gotTopic != topic || string(message) != payload {
+			continue
+		}
+		res.Success = true
+		res.Latency = time.Since(start)
+		break
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(c.opts.Timeout))
+	conn.Write(buildUnsubscribePacket(packetID, topic))
+	return res
+}
+
+// packetReader returns the buffered reader for conn. It is reused across
+// reads so packets buffered behind the one being read are not lost.
+func (c *Checker) packetReader(conn net.Conn) *bufio.Reader {
+	if c.reader == nil || c.readerConn != conn {
+		c.reader = bufio.NewReader(conn)
+		c.readerConn = conn
+	}
+	return c.reader
+}
+
 func (c *Checker) mqttDisconnect(conn net.Conn) {
 	pkt := []byte{packetDISCONNECT << 4, 0}
 	conn.SetWriteDeadline(time.Now().Add(2 * time.Second))
@@ -422,7 +579,7 @@ func buildSubscribePacket(packetID uint16, topic string, qos byte) []byte {
 	payload = append(payload, encodeString(topic)...)
 	payload = append(payload, qos)
 
-	return wrapPacket(packetSUBSCRIBE|0x02, payload) // SUBSCRIBE has reserved bits set
+	return wrapPacketFlags(packetSUBSCRIBE, 0x02, payload) // SUBSCRIBE has reserved bits set
 }
 
 func buildUnsubscribePacket(packetID uint16, topic string) []byte {
@@ -430,7 +587,35 @@ func buildUnsubscribePacket(packetID uint16, topic string) []byte {
 	payload = append(payload, byte(packetID>>8), byte(packetID&0xFF))
 	payload = append(payload, encodeString(topic)...)
 
-	return wrapPacket(packetUNSUBSCRIBE|0x02, payload)
+	return wrapPacketFlags(packetUNSUBSCRIBE, 0x02, payload)
+}
+
+// buildPublishPacket builds a QoS 0, non-retained PUBLISH.
+func buildPublishPacket(topic string, message []byte) []byte {
+	payload := encodeString(topic)
+	payload = append(payload, message...)
+	return wrapPacket(packetPUBLISH, payload)
+}
+
+// parsePublish splits a PUBLISH into topic and message, skipping the
+// packet identifier present at QoS 1 and 2.
+func parsePublish(header byte, payload []byte) (string, []byte, bool, error) {
+	if len(payload) < 2 {
+		return "", nil, false, fmt.Errorf("PUBLISH too short")
+	}
+	n := int(binary.BigEndian.Uint16(payload))
+	if len(payload) < 2+n {
+		return "", nil, false, fmt.Errorf("PUBLISH topic truncated")
+	}
+	topic := string(payload[2 : 2+n])
+	message := payload[2+n:]
+	if qos := (header >> 1) & 0x03; qos > 0 {
+		if len(message) < 2 {
+			return "", nil, false, fmt.Errorf("PUBLISH packet ID missing")
+		}
+		message = message[2:]
+	}
+	return topic, message, header&0x01 != 0, nil
 }
 
 func encodeString(s string) []byte {
@@ -441,7 +626,11 @@ func encodeString(s string) []byte {
 }
 
 func wrapPacket(typeByte byte, payload []byte) []byte {
-	header := []byte{typeByte << 4}
+	return wrapPacketFlags(typeByte, 0, payload)
+}
+
+func wrapPacketFlags(typeByte, flags byte, payload []byte) []byte {
+	header := []byte{typeByte<<4 | flags}
 	header = append(header, encodeRemainingLength(len(payload))...)
 	return append(header, payload...)
 }
@@ -463,27 +652,46 @@ func encodeRemainingLength(length int) []byte {
 }
 
 func readPacket(reader *bufio.Reader) (byte, []byte, error) {
+	firstByte, payload, err := readRawPacket(reader)
+	return firstByte >> 4, payload, err
+}
+
+// readRawPacket reads one packet and returns its first header byte, which
+// carries the flags as well as the packet type.
+func readRawPacket(reader *bufio.Reader) (byte, []byte, error) {
 	firstByte, err := reader.ReadByte()
 	if err != nil {
 		return 0, nil, err
 	}
 
-	pktType := firstByte >> 4
-
 	// Decode remaining length
 	remaining, err := decodeRemainingLength(reader)
 	if err != nil {
-		return pktType, nil, err
+		return firstByte, nil, err
 	}
 
 	payload := make([]byte, remaining)
 	if remaining > 0 {
 		if _, err := io.ReadFull(reader, payload); err != nil {
-			return pktType, nil, err
+			return firstByte, nil, err
 		}
 	}
 
-	return pktType, payload, nil
+	return firstByte, payload, nil
+}
+
+// readUntil reads packets until one of type want arrives, discarding
+// anything else (e.g. messages delivered on an earlier subscription).
+func readUntil(reader *bufio.Reader, want byte) (byte, []byte, error) {
+	for {
+		pktType, payload, err := readPacket(reader)
+		if err != nil {
+			return pktType, nil, err
+		}
+		if pktType == want {
+			return pktType, payload, nil
+		}
+	}
 }
 
 func decodeRemainingLength(reader *bufio.Reader) (int, error) {
@@ -567,6 +775,12 @@ func (r *Result) Format() string {
 	sb.WriteString(fmt.Sprintf("  TCP Time:  %v\n", r.ConnTime.Round(time.Microsecond)))
 	if r.UseTLS {
 		sb.WriteString(fmt.Sprintf("  TLS Time:  %v\n", r.TLSTime.Round(time.Microsecond)))
+	}
+	if r.Transport == TransportWS {
+		sb.WriteString(fmt.Sprintf("  WS Time:   %v\n", r.UpgradeTime.Round(time.Microsecond)))
+		sb.WriteString("  Transport: WebSocket (mqtt subprotocol)\n")
+	}
+	if r.UseTLS {
 		sb.WriteString("  Encryption: TLS\n")
 	} else {
 		sb.WriteString("  Encryption: None (plaintext)\n")
@@ -583,6 +797,13 @@ func (r *Result) Format() string {
 		sb.WriteString("  ✓ Credentials accepted\n")
 	}
 	sb.WriteString(fmt.Sprintf("  Response:  %s\n", r.AuthResult.ReturnMessage))
+	if r.AuthResult.CredentialsTested > 0 {
+		sb.WriteString(fmt.Sprintf("  Credentials tested: %d, accepted: %d\n",
+			r.AuthResult.CredentialsTested, len(r.AuthResult.WeakCredentials)))
+		for _, cred := range r.AuthResult.WeakCredentials {
+			sb.WriteString(fmt.Sprintf("    ⚠ %s\n", cred))
+		}
+	}
 
 	// Ping latency
 	if r.PingLatency.Count > 0 {
@@ -609,6 +830,17 @@ func (r *Result) Format() string {
 		}
 	}
 
+	// Publish round trip
+	if r.PublishResult.Tested {
+		sb.WriteString("\nPublish Test:\n")
+		sb.WriteString(fmt.Sprintf("  Topic:    %s\n", r.PublishResult.Topic))
+		if r.PublishResult.Success {
+			sb.WriteString(fmt.Sprintf("  ✓ Delivered in %v\n", r.PublishResult.Latency.Round(time.Microsecond)))
+		} else {
+			sb.WriteString(fmt.Sprintf("  ✗ Failed: %v\n", r.PublishResult.Error))
+		}
+	}
+
 	// Security summary
 	sb.WriteString("\nSecurity Assessment:\n")
 	issues := 0
@@ -616,6 +848,10 @@ func (r *Result) Format() string {
 		sb.WriteString("  ⚠ Anonymous connections accepted (no authentication)\n")
 		issues++
 	}
+	if len(r.AuthResult.WeakCredentials) > 0 {
+		sb.WriteString(fmt.Sprintf("  ⚠ Default/weak credentials accepted (%d)\n", len(r.AuthResult.WeakCredentials)))
+		issues++
+	}
 	if !r.UseTLS {
 		sb.WriteString("  ⚠ No TLS encryption (plaintext MQTT)\n")
 		issues++
@@ -652,12 +888,90 @@ func (r *Result) FormatCompact() string {
 	if r.AuthResult.AuthRequired {
 		auth = "auth-required"
 	}
+	if n := len(r.AuthResult.WeakCredentials); n > 0 {
+		auth += fmt.Sprintf("  weak-creds=%d", n)
+	}
 	tlsStr := "plain"
 	if r.UseTLS {
 		tlsStr = "tls"
 	}
-	return fmt.Sprintf("✓ %s:%d  %s  %s  ping=%v",
+	if r.Transport == TransportWS {
+		tlsStr += "+ws"
+	}
+	line := fmt.Sprintf("✓ %s:%d  %s  %s  ping=%v",
 		r.Host, r.Port, auth, tlsStr, r.PingLatency.AvgRTT.Round(time.Microsecond))
+	if r.PublishResult.Tested {
+		if r.PublishResult.Success {
+			line += fmt.Sprintf("  pub=%v", r.PublishResult.Latency.Round(time.Microsecond))
+		} else {
+			line += "  pub=failed"
+		}
+	}
+	return line
+}
+
+// ToJSON returns the result as indented JSON.
+func (r *Result) ToJSON() (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// MarshalJSON encodes the result with its error as a string.
+func (r Result) MarshalJSON() ([]byte, error) {
+	type result Result
+	out := struct {
+		result
+		Error string `json:"error,omitempty"`
+	}{result: result(r)}
+	if r.Error != nil {
+		out.Error = r.Error.Error()
+	}
+	if out.Topics == nil {
+		out.Topics = []TopicResult{}
+	}
+	return json.Marshal(out)
+}
+
+// MarshalJSON encodes the topic probe with its error as a string.
+func (t TopicResult) MarshalJSON() ([]byte, error) {
+	type topic TopicResult
+	out := struct {
+		topic
+		Error string `json:"error,omitempty"`
+	}{topic: topic(t)}
+	if t.Error != nil {
+		out.Error = t.Error.Error()
+	}
+	return json.Marshal(out)
+}
+
+// MarshalJSON encodes the publish test with its error as a string.
+func (p PublishResult) MarshalJSON() ([]byte, error) {
+	type publish PublishResult
+	out := struct {
+		publish
+		Error string `json:"error,omitempty"`
+	}{publish: publish(p)}
+	if p.Error != nil {
+		out.Error = p.Error.Error()
+	}
+	return json.Marshal(out)
+}
+
+// MarshalJSON encodes the login attempt with its error as a string.
+func (c CredentialResult) MarshalJSON() ([]byte, error) {
+	type attempt CredentialResult
+	out := struct {
+		attempt
+		Error string `json:"error,omitempty"`
+	}{attempt: attempt(c)}
+	if c.Error != nil {
+		out.Error = c.Error.Error()
+	}
+	return json.Marshal(out)
 }
 
 // CheckMultiple checks multiple brokers concurrently.
@@ -4,9 +4,16 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
 	"net"
+	"net/http"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/JedizLaPulga/NNS/internal/websocket"
 )
 
 func TestDefaultOptions(t *testing.T) {
@@ -196,7 +203,7 @@ func TestBuildSubscribePacket(t *testing.T) {
 	pkt := buildSubscribePacket(1, "test/topic", 0)
 
 	// First byte: SUBSCRIBE type with reserved bits
-	expectedType := (packetSUBSCRIBE | 0x02) << 4
+	expectedType := packetSUBSCRIBE<<4 | 0x02
 	if pkt[0] != expectedType {
 		t.Errorf("expected first byte 0x%02x, got 0x%02x", expectedType, pkt[0])
 	}
@@ -209,7 +216,7 @@ func TestBuildSubscribePacket(t *testing.T) {
 func TestBuildUnsubscribePacket(t *testing.T) {
 	pkt := buildUnsubscribePacket(1, "test/topic")
 
-	expectedType := (packetUNSUBSCRIBE | 0x02) << 4
+	expectedType := packetUNSUBSCRIBE<<4 | 0x02
 	if pkt[0] != expectedType {
 		t.Errorf("expected first byte 0x%02x, got 0x%02x", expectedType, pkt[0])
 	}
@@ -321,6 +328,43 @@ func TestResultFormatCompactFailed(t *testing.T) {
 	}
 }
 
+func TestResultToJSON(t *testing.T) {
+	result := &Result{
+		Host:      "broker.test",
+		Port:      1883,
+		Connected: true,
+		ConnTime:  1500 * time.Microsecond,
+		PingLatency: PingStats{
+			Count:    2,
+			Received: 2,
+			AvgRTT:   2 * time.Millisecond,
+			AllRTTs:  []time.Duration{time.Millisecond, 3 * time.Millisecond},
+		},
+		Topics: []TopicResult{
+			{Filter: "#", Subscribed: false, Error: fmt.Errorf("not authorized")},
+		},
+		AuthResult: AuthResult{
+			WeakCredentials: []Credential{{"admin", "admin"}},
+		},
+	}
+
+	out, err := result.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON: %v", err)
+	}
+	for _, want := range []string{
+		`"host": "broker.test"`,
+		`"conn_time": 1500000`,
+		`"avg_rtt": 2000000`,
+		`"error": "not authorized"`,
+		`"username": "admin"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("JSON missing %s:\n%s", want, out)
+		}
+	}
+}
+
 // TestCheckConnectionRefused tests behavior when no broker is running.
 func TestCheckConnectionRefused(t *testing.T) {
 	// Use a port that's unlikely to have an MQTT broker
@@ -405,12 +449,12 @@ func handleMockClient(conn net.Conn) {
 
 	for {
 		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
-		pktType, _, err := readPacket(reader)
+		header, payload, err := readRawPacket(reader)
 		if err != nil {
 			return
 		}
 
-		switch pktType {
+		switch header >> 4 {
 		case packetCONNECT:
 			// Send CONNACK (accepted)
 			connack := []byte{packetCONNACK << 4, 2, 0, 0}
@@ -420,11 +464,19 @@ func handleMockClient(conn net.Conn) {
 			pingresp := []byte{packetPINGRESP << 4, 0}
 			conn.Write(pingresp)
 		case packetSUBSCRIBE:
-			// Send SUBACK with QoS 0 granted
-			suback := []byte{packetSUBACK << 4, 3, 0, 1, 0}
+			// Send SUBACK with QoS 0 granted, or refuse "denied/#" filters
+			granted := byte(0)
+			if bytes.HasPrefix(payload[4:], []byte("denied/")) {
+				granted = 0x80
+			}
+			suback := []byte{packetSUBACK << 4, 3, payload[0], payload[1], granted}
 			conn.Write(suback)
+		case packetPUBLISH:
+			// Deliver back to the publisher (its only subscriber)
+			conn.Write(wrapPacket(packetPUBLISH, payload))
 		case packetUNSUBSCRIBE:
-			// Ignore
+			// Send UNSUBACK
+			conn.Write([]byte{packetUNSUBACK << 4, 2, payload[0], payload[1]})
 		case packetDISCONNECT:
 			return
 		}
@@ -459,3 +511,303 @@ func TestWrapPacket(t *testing.T) {
 		t.Errorf("expected remaining length 2, got %d", pkt[1])
 	}
 }
+
+func TestBuildPublishPacket(t *testing.T) {
+	pkt := buildPublishPacket("nns/test", []byte("hello"))
+	if pkt[0] != packetPUBLISH<<4 {
+		t.Errorf("expected first byte 0x%02x, got 0x%02x", packetPUBLISH<<4, pkt[0])
+	}
+
+	header, payload, err := readRawPacket(bufio.NewReader(bytes.NewReader(pkt)))
+	if err != nil {
+		t.Fatalf("readRawPacket error: %v", err)
+	}
+	topic, message, retained, err := parsePublish(header, payload)
+	if err != nil {
+		t.Fatalf("parsePublish error: %v", err)
+	}
+	if topic != "nns/test" || string(message) != "hello" || retained {
+		t.Errorf("got topic=%q message=%q retained=%v", topic, message, retained)
+	}
+}
+
+func TestParsePublishQoS1(t *testing.T) {
+	// QoS 1, retained: topic "a/b", packet ID 7, message "x"
+	payload := append(encodeString("a/b"), 0, 7, 'x')
+	topic, message, retained, err := parsePublish(packetPUBLISH<<4|0x02|0x01, payload)
+	if err != nil {
+		t.Fatalf("parsePublish error: %v", err)
+	}
+	if topic != "a/b" || string(message) != "x" || !retained {
+		t.Errorf("got topic=%q message=%q retained=%v", topic, message, retained)
+	}
+
+	if _, _, _, err := parsePublish(packetPUBLISH<<4, []byte{0, 9, 'a'}); err == nil {
+		t.Error("expected error for truncated topic")
+	}
+}
+
+func TestCheckPublishRoundTrip(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start mock broker: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go handleMockClient(conn)
+		}
+	}()
+
+	opts := Options{
+		Host:           "127.0.0.1",
+		Port:           listener.Addr().(*net.TCPAddr).Port,
+		Timeout:        2 * time.Second,
+		PingCount:      1,
+		Topics:         []string{"test/topic"},
+		TestPublish:    true,
+		PublishTopic:   "nns/roundtrip",
+		PublishPayload: "ping",
+	}
+	result, err := NewChecker(opts).Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+
+	if len(result.Topics) != 1 || !result.Topics[0].Subscribed {
+		t.Errorf("expected test/topic to be subscribed, got %+v", result.Topics)
+	}
+	pub := result.PublishResult
+	if !pub.Tested || !pub.Success || pub.Error != nil {
+		t.Fatalf("expected successful round trip, got %+v", pub)
+	}
+	if pub.Latency <= 0 {
+		t.Errorf("expected positive latency, got %v", pub.Latency)
+	}
+	if !strings.Contains(result.Format(), "Publish Test:") {
+		t.Error("Format should include the publish test")
+	}
+
+	opts.PublishTopic = "denied/topic"
+	result, _ = NewChecker(opts).Check(context.Background())
+	if result.PublishResult.Success || result.PublishResult.Error == nil {
+		t.Errorf("expected refused subscription to fail, got %+v", result.PublishResult)
+	}
+
+	opts.PublishTopic = "bad/#"
+	result, _ = NewChecker(opts).Check(context.Background())
+	if result.PublishResult.Error == nil {
+		t.Error("expected wildcard publish topic to be rejected")
+	}
+}
+
+// serveMockWSBroker accepts WebSocket upgrades on path and runs the mock
+// broker over the framed connection.
+func serveMockWSBroker(t *testing.T, subprotocol string) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start mock broker: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				br := bufio.NewReader(conn)
+				req, err := http.ReadRequest(br)
+				if err != nil {
+					conn.Close()
+					return
+				}
+				if req.URL.Path != "/mqtt" || req.Header.Get("Sec-WebSocket-Protocol") != "mqtt" {
+					conn.Write([]byte("HTTP/1.1 404 Not Found\r\nContent-Length: 0\r\n\r\n"))
+					conn.Close()
+					return
+				}
+				fmt.Fprintf(conn, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+					"Sec-WebSocket-Accept: %s\r\nSec-WebSocket-Protocol: %s\r\n\r\n",
+					websocket.AcceptKey(req.Header.Get("Sec-WebSocket-Key")), subprotocol)
+				handleMockClient(&wsConn{Conn: conn, br: br})
+			}(conn)
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestCheckWebSocketTransport(t *testing.T) {
+	port := serveMockWSBroker(t, "mqtt")
+
+	opts := Options{
+		Host:           "127.0.0.1",
+		Port:           port,
+		Transport:      TransportWS,
+		Timeout:        2 * time.Second,
+		PingCount:      2,
+		Topics:         []string{"test/topic"},
+		TestPublish:    true,
+		PublishPayload: "over-ws",
+	}
+	result, err := NewChecker(opts).Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+	if !result.Connected || result.Error != nil {
+		t.Fatalf("expected WebSocket connection, error: %v", result.Error)
+	}
+	if !result.AuthResult.AnonAllowed {
+		t.Error("expected anonymous access to be allowed")
+	}
+	if result.PingLatency.Received != 2 {
+		t.Errorf("expected 2 PINGRESPs, got %d", result.PingLatency.Received)
+	}
+	if !result.PublishResult.Success {
+		t.Errorf("expected publish round trip over WebSocket, got %+v", result.PublishResult)
+	}
+	if !strings.Contains(result.FormatCompact(), "plain+ws") {
+		t.Errorf("compact format should mention ws: %s", result.FormatCompact())
+	}
+
+	opts.WSPath = "/wrong"
+	result, _ = NewChecker(opts).Check(context.Background())
+	if result.Connected || result.Error == nil || !strings.Contains(result.Error.Error(), "404") {
+		t.Errorf("expected rejected upgrade, got connected=%v err=%v", result.Connected, result.Error)
+	}
+}
+
+func TestCheckWebSocketWrongSubprotocol(t *testing.T) {
+	port := serveMockWSBroker(t, "chat")
+
+	opts := Options{Host: "127.0.0.1", Port: port, Transport: TransportWS, Timeout: 2 * time.Second}
+	result, _ := NewChecker(opts).Check(context.Background())
+	if result.Connected || result.Error == nil {
+		t.Error("expected failure when the broker does not accept the mqtt subprotocol")
+	}
+}
+
+func TestWSConnLargeFrame(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	payload := bytes.Repeat([]byte("x"), 70000) // 64-bit length
+	go (&wsConn{Conn: client, client: true}).Write(payload)
+
+	ws := &wsConn{Conn: server, br: bufio.NewReader(server)}
+	got := make([]byte, len(payload))
+	if _, err := io.ReadFull(ws, got); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Error("payload mismatch after masking round trip")
+	}
+}
+
+// handleAuthMockClient accepts only the admin/public login.
+func handleAuthMockClient(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	pktType, payload, err := readPacket(reader)
+	if err != nil || pktType != packetCONNECT || len(payload) < 10 {
+		return
+	}
+	flags := payload[7]
+	fields := payload[10:]
+	next := func() string {
+		if len(fields) < 2 {
+			return ""
+		}
+		n := int(binary.BigEndian.Uint16(fields))
+		if len(fields) < 2+n {
+			return ""
+		}
+		s := string(fields[2 : 2+n])
+		fields = fields[2+n:]
+		return s
+	}
+	next() // client ID
+	var user, pass string
+	if flags&0x80 != 0 {
+		user = next()
+	}
+	if flags&0x40 != 0 {
+		pass = next()
+	}
+
+	code := connRefusedBadAuth
+	if user == "" {
+		code = connRefusedNotAuth
+	} else if user == "admin" && pass == "public" {
+		code = connAccepted
+	}
+	conn.Write([]byte{packetCONNACK << 4, 2, 0, code})
+	readPacket(reader) // DISCONNECT
+}
+
+func TestCheckCredentialList(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start mock broker: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go handleAuthMockClient(conn)
+		}
+	}()
+
+	opts := Options{
+		Host:            "127.0.0.1",
+		Port:            listener.Addr().(*net.TCPAddr).Port,
+		Timeout:         2 * time.Second,
+		CredentialList:  DefaultCredentials,
+		CredentialDelay: time.Millisecond,
+	}
+	result, err := NewChecker(opts).Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+
+	auth := result.AuthResult
+	if !auth.AuthRequired {
+		t.Error("expected authentication to be required")
+	}
+	if auth.CredentialsTested != len(DefaultCredentials) {
+		t.Errorf("expected %d credentials tested, got %d", len(DefaultCredentials), auth.CredentialsTested)
+	}
+	if len(auth.WeakCredentials) != 1 || auth.WeakCredentials[0] != (Credential{"admin", "public"}) {
+		t.Errorf("expected only admin/public accepted, got %v", auth.WeakCredentials)
+	}
+	for _, cr := range auth.Credentials {
+		if !cr.Accepted && cr.ReturnCode != connRefusedBadAuth {
+			t.Errorf("%s: expected bad-auth return code, got %d (err %v)", cr.Credential, cr.ReturnCode, cr.Error)
+		}
+	}
+	if !strings.Contains(result.Format(), "admin/public") {
+		t.Error("Format should list the accepted credential")
+	}
+}
+
+func TestTestCredentialsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	checker := NewChecker(Options{Host: "127.0.0.1", CredentialList: DefaultCredentials})
+	if results := checker.TestCredentials(ctx); len(results) != 0 {
+		t.Errorf("expected no attempts after cancellation, got %d", len(results))
+	}
+}
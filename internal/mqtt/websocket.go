@@ -0,0 +1,121 @@
+package mqtt
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/JedizLaPulga/NNS/internal/websocket"
+)
+
+// Transports supported by the checker.
+const (
+	TransportTCP = "tcp"
+	TransportWS  = "ws"
+)
+
+// maxWSFramePayload bounds the payload size read into memory for one frame.
+const maxWSFramePayload = 16 << 20
+
+// wsConn carries MQTT packets inside WebSocket binary frames. Writes send
+// one frame per call; reads return frame payloads as a byte stream, so the
+// MQTT packet reader works unchanged on top of it.
+type wsConn struct {
+	net.Conn
+	br      *bufio.Reader
+	client  bool // Clients mask their frames; servers must not
+	pending []byte
+}
+
+// wsHandshake performs the HTTP Upgrade handshake on conn, requesting the
+// "mqtt" subprotocol, and returns the framed connection.
+func wsHandshake(conn net.Conn, host, path string, timeout time.Duration) (*wsConn, error) {
+	if path == "" {
+		path = "/mqtt"
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req, err := http.NewRequest("GET", "http://"+host+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WebSocket path %q: %w", path, err)
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Protocol", "mqtt")
+
+	conn.SetDeadline(time.Now().Add(timeout))
+	defer conn.SetDeadline(time.Time{})
+
+	if err := req.Write(conn); err != nil {
+		return nil, fmt.Errorf("failed to send WebSocket upgrade: %w", err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read WebSocket upgrade response: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, fmt.Errorf("WebSocket upgrade rejected: %s", resp.Status)
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != websocket.AcceptKey(key) {
+		return nil, fmt.Errorf("invalid Sec-WebSocket-Accept in upgrade response")
+	}
+	if proto := resp.Header.Get("Sec-WebSocket-Protocol"); !strings.EqualFold(proto, "mqtt") {
+		return nil, fmt.Errorf("broker did not accept the mqtt subprotocol (got %q)", proto)
+	}
+
+	return &wsConn{Conn: conn, br: br, client: true}, nil
+}
+
+// Read returns payload bytes from binary (or text) data frames, answering
+// pings and reporting io.EOF on a close frame.
+func (c *wsConn) Read(p []byte) (int, error) {
+	for len(c.pending) == 0 {
+		f, err := websocket.ReadFrame(c.br, maxWSFramePayload)
+		if err != nil {
+			return 0, err
+		}
+		switch f.Opcode {
+		case websocket.OpBinary, websocket.OpText, websocket.OpContinuation:
+			c.pending = f.Payload
+		case websocket.OpPing:
+			websocket.WriteFrame(c.Conn, websocket.OpPong, f.Payload, c.client)
+		case websocket.OpClose:
+			return 0, io.EOF
+		}
+	}
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+// Write sends p as a single binary frame.
+func (c *wsConn) Write(p []byte) (int, error) {
+	if err := websocket.WriteFrame(c.Conn, websocket.OpBinary, p, c.client); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close sends a close frame before closing the underlying connection.
+func (c *wsConn) Close() error {
+	c.Conn.SetWriteDeadline(time.Now().Add(time.Second))
+	websocket.WriteFrame(c.Conn, websocket.OpClose, []byte{0x03, 0xE8}, c.client) // 1000 normal closure
+	return c.Conn.Close()
+}
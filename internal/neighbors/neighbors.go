@@ -3,6 +3,7 @@ package neighbors
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"sort"
@@ -15,6 +16,8 @@ const (
 	mdnsAddr4  = "224.0.0.251:5353"
 	mdnsAddr6  = "[ff02::fb]:5353"
 	bufferSize = 65536
+
+	resolveTimeout = time.Second // Wait for follow-up A/AAAA answers
 )
 
 // ServiceType identifies a well-known DNS-SD service.
@@ -43,34 +46,80 @@ func CommonServices() []ServiceType {
 	}
 }
 
+// ResolveServiceType maps a friendly service name from CommonServices (such
+// as "http" or "printer", case-insensitive) to its DNS-SD type. Names that
+// already look like a service type ("_http._tcp") are returned in fully
+// qualified form.
+func ResolveServiceType(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if strings.HasPrefix(name, "_") {
+		if !strings.HasSuffix(name, ".") {
+			name += "."
+		}
+		if !strings.HasSuffix(name, ".local.") {
+			name += "local."
+		}
+		return name, true
+	}
+	for _, s := range CommonServices() {
+		if strings.EqualFold(s.Name, name) {
+			return s.Type, true
+		}
+	}
+	return "", false
+}
+
 // Neighbor represents a discovered network device.
 type Neighbor struct {
-	Hostname  string
-	Addresses []string
-	Services  []DiscoveredService
-	FirstSeen time.Time
-	Source    string
+	Hostname  string              `json:"hostname"`
+	Addresses []string            `json:"addresses"`
+	Services  []DiscoveredService `json:"services"`
+	FirstSeen time.Time           `json:"first_seen"`
+	Source    string              `json:"source"`
 }
 
 // DiscoveredService is a service found via DNS-SD.
 type DiscoveredService struct {
-	InstanceName string
-	ServiceType  string
-	Port         int
-	TXT          map[string]string
-	Host         string
-	Addresses    []string
+	InstanceName string            `json:"instance_name"`
+	ServiceType  string            `json:"service_type"`
+	Port         int               `json:"port"`
+	TXT          map[string]string `json:"txt,omitempty"`
+	Host         string            `json:"host"`
+	Addresses    []string          `json:"addresses"`
+	Source       string            `json:"source"` // DNS-SD or SSDP
 }
 
 // Result holds the discovery results.
 type Result struct {
-	Neighbors  []Neighbor
-	Services   []DiscoveredService
-	TotalHosts int
-	TotalSvcs  int
-	Duration   time.Duration
-	StartTime  time.Time
-	Errors     []string
+	Neighbors  []Neighbor          `json:"neighbors"`
+	Services   []DiscoveredService `json:"services"`
+	TotalHosts int                 `json:"total_hosts"`
+	TotalSvcs  int                 `json:"total_services"`
+	Duration   time.Duration       `json:"duration"`
+	StartTime  time.Time           `json:"start_time"`
+	Errors     []string            `json:"errors,omitempty"`
+}
+
+// ToJSON returns the discovery results as indented JSON.
+func (r *Result) ToJSON() (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// MarshalJSON encodes empty neighbor and service lists as empty arrays
+// rather than null.
+func (r Result) MarshalJSON() ([]byte, error) {
+	type result Result
+	if r.Neighbors == nil {
+		r.Neighbors = []Neighbor{}
+	}
+	if r.Services == nil {
+		r.Services = []DiscoveredService{}
+	}
+	return json.Marshal(result(r))
 }
 
 // Format returns formatted discovery results.
@@ -119,6 +168,9 @@ func (r *Result) Format() string {
 			if len(s.Addresses) > 0 {
 				b.WriteString(fmt.Sprintf("  │    Addr: %s\n", strings.Join(s.Addresses, ", ")))
 			}
+			if s.Source != "" {
+				b.WriteString(fmt.Sprintf("  │    Via:  %s\n", s.Source))
+			}
 			if len(s.TXT) > 0 {
 				pairs := make([]string, 0, len(s.TXT))
 				for k, v := range s.TXT {
@@ -160,7 +212,10 @@ type Options struct {
 	ServiceTypes []string
 	UseIPv6      bool
 	Interface    string
-	OnDiscover   func(Neighbor)
+	OnDiscover   func(Neighbor) // Called once per neighbor, as soon as it is first seen
+	Watch        bool           // Listen until the context is cancelled instead of for Timeout
+	SSDP         bool           // Also search for UPnP devices via SSDP
+	SSDPTarget   string         // M-SEARCH target, default ssdp:all
 }
 
 // DefaultOptions returns sensible defaults.
@@ -181,6 +236,9 @@ type Scanner struct {
 	mu        sync.Mutex
 	neighbors map[string]*Neighbor
 	services  []DiscoveredService
+
+	ssdpDevices map[string]int // SSDP device key -> index in services
+	ssdpAddr    string         // M-SEARCH destination; empty for the multicast group
 }
 
 // NewScanner creates a new scanner.
@@ -191,11 +249,15 @@ func NewScanner(opts Options) *Scanner {
 	if len(opts.ServiceTypes) == 0 {
 		opts.ServiceTypes = DefaultOptions().ServiceTypes
 	}
+	if opts.SSDPTarget == "" {
+		opts.SSDPTarget = "ssdp:all"
+	}
 
 	return &Scanner{
-		opts:      opts,
-		neighbors: make(map[string]*Neighbor),
-		services:  make([]DiscoveredService, 0),
+		opts:        opts,
+		neighbors:   make(map[string]*Neighbor),
+		services:    make([]DiscoveredService, 0),
+		ssdpDevices: make(map[string]int),
 	}
 }
 
@@ -207,49 +269,75 @@ func (s *Scanner) Discover(ctx context.Context) (*Result, error) {
 		Errors:    make([]string, 0),
 	}
 
-	timeoutCtx, cancel := context.WithTimeout(ctx, s.opts.Timeout)
+	var timeoutCtx context.Context
+	var cancel context.CancelFunc
+	if s.opts.Watch {
+		timeoutCtx, cancel = context.WithCancel(ctx)
+	} else {
+		timeoutCtx, cancel = context.WithTimeout(ctx, s.opts.Timeout)
+	}
 	defer cancel()
 
-	conn, err := s.openMDNSConn()
-	if err != nil {
-		return nil, fmt.Errorf("failed to open mDNS connection: %w", err)
+	groups := []string{mdnsAddr4}
+	if s.opts.UseIPv6 {
+		groups = append(groups, mdnsAddr6)
 	}
-	defer conn.Close()
 
-	// Send queries for each service type
-	for _, svcType := range s.opts.ServiceTypes {
-		query := buildMDNSQuery(svcType)
-		addr, _ := net.ResolveUDPAddr("udp", mdnsAddr4)
-		if _, err := conn.WriteTo(query, addr); err != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("query %s: %v", svcType, err))
+	var listeners []mdnsListener
+	var openErr error
+	for _, group := range groups {
+		l, err := s.openMDNSConn(group)
+		if err != nil {
+			openErr = err
+			result.Errors = append(result.Errors, fmt.Sprintf("listen %s: %v", group, err))
+			continue
 		}
+		defer l.conn.Close()
+		listeners = append(listeners, l)
+	}
+	if len(listeners) == 0 {
+		return nil, fmt.Errorf("failed to open mDNS connection: %w", openErr)
 	}
 
-	// Collect responses
-	buf := make([]byte, bufferSize)
-	for {
-		select {
-		case <-timeoutCtx.Done():
-			goto done
-		default:
-		}
-
-		conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
-		n, remoteAddr, err := conn.ReadFrom(buf)
-		if err != nil {
-			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-				if timeoutCtx.Err() != nil {
-					goto done
-				}
-				continue
+	// Send queries for each service type
+	for _, l := range listeners {
+		for _, svcType := range s.opts.ServiceTypes {
+			query := buildMDNSQuery(svcType)
+			if _, err := l.conn.WriteTo(query, l.group); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("query %s: %v", svcType, err))
 			}
-			continue
 		}
+	}
 
-		s.processResponse(buf[:n], remoteAddr)
+	// Collect responses; IPv4 and IPv6 listeners run concurrently
+	var wg sync.WaitGroup
+	for _, l := range listeners {
+		wg.Add(1)
+		go func(conn *net.UDPConn) {
+			defer wg.Done()
+			s.collect(timeoutCtx, conn)
+		}(l.conn)
+	}
+
+	// UPnP devices answer an SSDP search on a socket of their own
+	var ssdpErr error
+	if s.opts.SSDP {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ssdpErr = s.discoverSSDP(timeoutCtx, ctx)
+		}()
+	}
+	wg.Wait()
+	if ssdpErr != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("ssdp: %v", ssdpErr))
+	}
+
+	// Second pass: find addresses for services known only by SRV target
+	if err := s.resolveServiceHosts(ctx); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("resolve hosts: %v", err))
 	}
 
-done:
 	// Compile results
 	s.mu.Lock()
 	for _, n := range s.neighbors {
@@ -269,31 +357,61 @@ done:
 	return result, nil
 }
 
-func (s *Scanner) openMDNSConn() (*net.UDPConn, error) {
-	addr, err := net.ResolveUDPAddr("udp4", mdnsAddr4)
+// mdnsListener is a socket joined to one mDNS multicast group, together
+// with the group address queries are sent to.
+type mdnsListener struct {
+	conn  *net.UDPConn
+	group *net.UDPAddr
+}
+
+// openMDNSConn joins the mDNS group (mdnsAddr4 or mdnsAddr6) on the chosen
+// interface.
+func (s *Scanner) openMDNSConn(group string) (mdnsListener, error) {
+	network := "udp4"
+	if group == mdnsAddr6 {
+		network = "udp6"
+	}
+
+	addr, err := net.ResolveUDPAddr(network, group)
 	if err != nil {
-		return nil, err
+		return mdnsListener{}, err
 	}
 
 	var iface *net.Interface
 	if s.opts.Interface != "" {
 		iface, err = net.InterfaceByName(s.opts.Interface)
 		if err != nil {
-			return nil, fmt.Errorf("interface %q: %w", s.opts.Interface, err)
+			return mdnsListener{}, fmt.Errorf("interface %q: %w", s.opts.Interface, err)
+		}
+		if network == "udp6" {
+			addr.Zone = iface.Name // ff02::fb is link-local
 		}
 	}
 
-	conn, err := net.ListenMulticastUDP("udp4", iface, addr)
+	conn, err := net.ListenMulticastUDP(network, iface, addr)
 	if err != nil {
 		// Fallback: try a regular UDP socket
-		localAddr, _ := net.ResolveUDPAddr("udp4", ":0")
-		conn, err = net.ListenUDP("udp4", localAddr)
+		localAddr, _ := net.ResolveUDPAddr(network, ":0")
+		conn, err = net.ListenUDP(network, localAddr)
 		if err != nil {
-			return nil, err
+			return mdnsListener{}, err
 		}
 	}
 
-	return conn, nil
+	return mdnsListener{conn: conn, group: addr}, nil
+}
+
+// collect reads responses from conn until ctx is done.
+func (s *Scanner) collect(ctx context.Context, conn *net.UDPConn) {
+	buf := make([]byte, bufferSize)
+	for ctx.Err() == nil {
+		conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+		n, remoteAddr, err := conn.ReadFrom(buf)
+		if err != nil {
+			continue
+		}
+		s.processResponse(buf[:n], remoteAddr)
+	}
 }
 
 func (s *Scanner) processResponse(data []byte, from net.Addr) {
@@ -308,14 +426,32 @@ func (s *Scanner) processResponse(data []byte, from net.Addr) {
 	}
 
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	added := s.merge(msg, fromIP)
+	discovered := make([]Neighbor, 0, len(added))
+	for _, key := range added {
+		if n, ok := s.neighbors[key]; ok {
+			discovered = append(discovered, n.clone())
+		}
+	}
+	s.mu.Unlock()
+
+	if s.opts.OnDiscover != nil {
+		for _, n := range discovered {
+			s.opts.OnDiscover(n)
+		}
+	}
+}
+
+// merge folds the records of msg into the scanner state and returns the
+// keys of neighbors seen for the first time. Callers must hold s.mu.
+func (s *Scanner) merge(msg *dnsMessage, fromIP string) []string {
+	var added []string
 
 	// Extract hostnames and addresses from answers
 	for _, rr := range msg.answers {
 		switch rr.rrType {
 		case dnsTypeA, dnsTypeAAAA:
-			hostname := strings.TrimSuffix(rr.name, ".local.")
-			hostname = strings.TrimSuffix(hostname, ".")
+			hostname := hostKey(rr.name)
 			if hostname == "" {
 				hostname = fromIP
 			}
@@ -330,18 +466,26 @@ func (s *Scanner) processResponse(data []byte, from net.Addr) {
 					Source:    "mDNS",
 				}
 				s.neighbors[hostname] = n
+				added = append(added, hostname)
 			}
 
 			ip := parseIPFromRData(rr.rdata, rr.rrType)
 			if ip != "" && !containsString(n.Addresses, ip) {
 				n.Addresses = append(n.Addresses, ip)
 			}
+			// Fold in a placeholder registered under the bare source address
+			if ip != "" && ip != hostname {
+				if p, ok := s.neighbors[ip]; ok && p.Hostname == ip {
+					delete(s.neighbors, ip)
+				}
+			}
 
 		case dnsTypePTR:
 			instanceName := string(rr.rdata)
 			svc := DiscoveredService{
 				InstanceName: cleanDNSName(instanceName),
 				ServiceType:  cleanDNSName(rr.name),
+				Source:       "DNS-SD",
 			}
 			s.services = append(s.services, svc)
 
@@ -359,8 +503,7 @@ func (s *Scanner) processResponse(data []byte, from net.Addr) {
 				}
 
 				// Also register as neighbor
-				hostname := strings.TrimSuffix(srvHost, ".local.")
-				hostname = strings.TrimSuffix(hostname, ".")
+				hostname := hostKey(srvHost)
 				if _, exists := s.neighbors[hostname]; !exists {
 					s.neighbors[hostname] = &Neighbor{
 						Hostname:  hostname,
@@ -369,6 +512,7 @@ func (s *Scanner) processResponse(data []byte, from net.Addr) {
 						Addresses: []string{},
 						Services:  []DiscoveredService{},
 					}
+					added = append(added, hostname)
 				}
 			}
 
@@ -385,7 +529,7 @@ func (s *Scanner) processResponse(data []byte, from net.Addr) {
 
 	// If we got any answers from this IP, ensure it's registered
 	if fromIP != "" && len(msg.answers) > 0 {
-		if _, exists := s.neighbors[fromIP]; !exists {
+		if _, exists := s.neighbors[fromIP]; !exists && !s.knownAddress(fromIP) {
 			s.neighbors[fromIP] = &Neighbor{
 				Hostname:  fromIP,
 				Addresses: []string{fromIP},
@@ -393,8 +537,116 @@ func (s *Scanner) processResponse(data []byte, from net.Addr) {
 				FirstSeen: time.Now(),
 				Services:  []DiscoveredService{},
 			}
+			added = append(added, fromIP)
 		}
 	}
+
+	return added
+}
+
+// clone returns a copy of n that shares no slices with the scanner state.
+func (n *Neighbor) clone() Neighbor {
+	c := *n
+	c.Addresses = append([]string(nil), n.Addresses...)
+	c.Services = append([]DiscoveredService(nil), n.Services...)
+	return c
+}
+
+// resolveServiceHosts fills in the addresses of services whose SRV target
+// arrived without an A/AAAA record. Hosts not already learned from other
+// responses are queried with one-shot mDNS queries, which responders
+// answer by unicast to the querying port (RFC 6762 section 6.7).
+func (s *Scanner) resolveServiceHosts(ctx context.Context) error {
+	hosts := s.fillServiceAddresses()
+	if len(hosts) == 0 || ctx.Err() != nil {
+		return nil
+	}
+
+	groups := []string{mdnsAddr4}
+	if s.opts.UseIPv6 {
+		groups = append(groups, mdnsAddr6)
+	}
+
+	resolveCtx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var firstErr error
+	for _, group := range groups {
+		network := "udp4"
+		if group == mdnsAddr6 {
+			network = "udp6"
+		}
+		addr, err := net.ResolveUDPAddr(network, group)
+		if err != nil {
+			firstErr = err
+			continue
+		}
+		conn, err := net.ListenUDP(network, nil)
+		if err != nil {
+			firstErr = err
+			continue
+		}
+		defer conn.Close()
+
+		for _, host := range hosts {
+			for _, qtype := range []uint16{dnsTypeA, dnsTypeAAAA} {
+				if _, err := conn.WriteTo(buildMDNSQueryType(host, qtype), addr); err != nil && firstErr == nil {
+					firstErr = err
+				}
+			}
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.collect(resolveCtx, conn)
+		}()
+	}
+	wg.Wait()
+
+	s.fillServiceAddresses()
+	return firstErr
+}
+
+// fillServiceAddresses copies neighbor addresses to services whose host
+// they belong to and returns the hosts still without an address.
+func (s *Scanner) fillServiceAddresses() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var unresolved []string
+	for i := range s.services {
+		svc := &s.services[i]
+		if svc.Host == "" || len(svc.Addresses) > 0 {
+			continue
+		}
+		if n, ok := s.neighbors[hostKey(svc.Host)]; ok && len(n.Addresses) > 0 {
+			svc.Addresses = append([]string(nil), n.Addresses...)
+			continue
+		}
+		if !containsString(unresolved, svc.Host) {
+			unresolved = append(unresolved, svc.Host)
+		}
+	}
+	return unresolved
+}
+
+// hostKey maps a host name to the key it is stored under in s.neighbors.
+func hostKey(name string) string {
+	name = strings.TrimSuffix(name, ".local.")
+	return strings.TrimSuffix(name, ".")
+}
+
+// knownAddress reports whether a neighbor already lists ip. Callers must
+// hold s.mu.
+func (s *Scanner) knownAddress(ip string) bool {
+	for _, n := range s.neighbors {
+		if containsString(n.Addresses, ip) {
+			return true
+		}
+	}
+	return false
 }
 
 // --- DNS message parsing (minimal mDNS support) ---
@@ -424,6 +676,10 @@ type dnsMessage struct {
 }
 
 func buildMDNSQuery(name string) []byte {
+	return buildMDNSQueryType(name, dnsTypePTR)
+}
+
+func buildMDNSQueryType(name string, qtype uint16) []byte {
 	// Construct a minimal DNS query
 	buf := make([]byte, 0, 64)
 
@@ -437,8 +693,8 @@ func buildMDNSQuery(name string) []byte {
 
 	// Question
 	buf = append(buf, encodeDNSName(name)...)
-	buf = append(buf, 0, 12) // QTYPE = PTR (12)
-	buf = append(buf, 0, 1)  // QCLASS = IN
+	buf = append(buf, byte(qtype>>8), byte(qtype)) // QTYPE
+	buf = append(buf, 0, 1)                        // QCLASS = IN
 
 	return buf
 }
@@ -521,6 +777,12 @@ func parseDNSMessage(data []byte) (*dnsMessage, error) {
 				rdata = []byte(ptrName)
 			}
 		}
+		if rrType == dnsTypeSRV && rdlen > 6 {
+			target, _, err := decodeDNSName(data, offset+6)
+			if err == nil {
+				rdata = append(rdata[:6:6], target...)
+			}
+		}
 
 		msg.answers = append(msg.answers, dnsResourceRecord{
 			name:   name,
@@ -1,10 +1,16 @@
 package neighbors
 
 import (
+	"context"
+	"fmt"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/JedizLaPulga/NNS/internal/ssdp"
 )
 
 func TestDefaultOptions(t *testing.T) {
@@ -37,6 +43,28 @@ func TestCommonServices(t *testing.T) {
 	}
 }
 
+func TestResolveServiceType(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"http", "_http._tcp.local.", true},
+		{"Printer", "_ipp._tcp.local.", true},
+		{"CHROMECAST", "_googlecast._tcp.local.", true},
+		{"_ssh._tcp", "_ssh._tcp.local.", true},
+		{"_ssh._tcp.local", "_ssh._tcp.local.", true},
+		{"_ssh._tcp.local.", "_ssh._tcp.local.", true},
+		{"toaster", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ResolveServiceType(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ResolveServiceType(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
 func TestNewScanner(t *testing.T) {
 	s := NewScanner(Options{})
 	if s.opts.Timeout != 5*time.Second {
@@ -330,6 +358,25 @@ func TestResultFormatCompact(t *testing.T) {
 	}
 }
 
+func TestResultToJSON(t *testing.T) {
+	r := &Result{
+		Services: []DiscoveredService{
+			{InstanceName: "Office Printer", ServiceType: "_ipp._tcp.local.", Port: 631},
+		},
+		TotalSvcs: 1,
+		Duration:  1500 * time.Millisecond,
+	}
+	out, err := r.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON: %v", err)
+	}
+	for _, want := range []string{`"neighbors": []`, `"instance_name": "Office Printer"`, `"port": 631`, `"duration": 1500000000`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("JSON missing %s:\n%s", want, out)
+		}
+	}
+}
+
 func TestResultFormatWithErrors(t *testing.T) {
 	r := &Result{
 		Duration: time.Second,
@@ -388,6 +435,253 @@ func TestProcessResponseARecord(t *testing.T) {
 	}
 }
 
+// buildResponse builds an mDNS response carrying the given answers.
+func buildResponse(answers ...dnsResourceRecord) []byte {
+	buf := []byte{0, 0, 0x84, 0, 0, 0, 0, byte(len(answers)), 0, 0, 0, 0}
+	for _, rr := range answers {
+		buf = append(buf, encodeDNSName(rr.name)...)
+		buf = append(buf, byte(rr.rrType>>8), byte(rr.rrType), 0, 1, 0, 0, 0, 120)
+		buf = append(buf, byte(len(rr.rdata)>>8), byte(len(rr.rdata)))
+		buf = append(buf, rr.rdata...)
+	}
+	return buf
+}
+
+func TestProcessResponseDualStack(t *testing.T) {
+	s := NewScanner(Options{Timeout: time.Second, UseIPv6: true})
+
+	// A PTR-only announcement over IPv6 registers the sender by address
+	from6 := &net.UDPAddr{IP: net.ParseIP("fe80::1c2:3ff:fe04:506"), Port: 5353, Zone: "eth0"}
+	s.processResponse(buildResponse(dnsResourceRecord{
+		name: "_http._tcp.local.", rrType: dnsTypePTR, rdata: encodeDNSName("web._http._tcp.local."),
+	}), from6)
+
+	// The host records then arrive over IPv4
+	from4 := &net.UDPAddr{IP: net.ParseIP("192.168.1.42"), Port: 5353}
+	s.processResponse(buildResponse(
+		dnsResourceRecord{name: "mydevice.local.", rrType: dnsTypeA, rdata: []byte{192, 168, 1, 42}},
+		dnsResourceRecord{name: "mydevice.local.", rrType: dnsTypeAAAA, rdata: net.ParseIP("fe80::1c2:3ff:fe04:506")},
+	), from4)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.neighbors) != 1 {
+		keys := make([]string, 0, len(s.neighbors))
+		for k := range s.neighbors {
+			keys = append(keys, k)
+		}
+		t.Fatalf("expected 1 merged neighbor, got %v", keys)
+	}
+	for _, n := range s.neighbors {
+		if !containsString(n.Addresses, "192.168.1.42") || !containsString(n.Addresses, "fe80::1c2:3ff:fe04:506") {
+			t.Errorf("expected both addresses, got %v", n.Addresses)
+		}
+	}
+}
+
+func TestOnDiscover(t *testing.T) {
+	var seen []Neighbor
+	s := NewScanner(Options{Timeout: time.Second, OnDiscover: func(n Neighbor) {
+		seen = append(seen, n)
+	}})
+
+	from := &net.UDPAddr{IP: net.ParseIP("192.168.1.42"), Port: 5353}
+	packet := buildResponse(dnsResourceRecord{name: "mydevice.local.", rrType: dnsTypeA, rdata: []byte{192, 168, 1, 42}})
+	s.processResponse(packet, from)
+	s.processResponse(packet, from) // repeated announcement
+
+	if len(seen) != 1 {
+		t.Fatalf("expected 1 callback, got %d", len(seen))
+	}
+	if !containsString(seen[0].Addresses, "192.168.1.42") {
+		t.Errorf("callback neighbor should carry its address, got %v", seen[0].Addresses)
+	}
+
+	// The callback receives a copy, not the scanner's own state
+	seen[0].Addresses[0] = "changed"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, n := range s.neighbors {
+		if containsString(n.Addresses, "changed") {
+			t.Error("callback copy should not alias scanner state")
+		}
+	}
+}
+
+func TestDiscoverWatch(t *testing.T) {
+	s := NewScanner(Options{Timeout: time.Millisecond, Watch: true, ServiceTypes: []string{"_nns-test._tcp.local."}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 700*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result, err := s.Discover(ctx)
+	if err != nil {
+		t.Skipf("mDNS unavailable: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 600*time.Millisecond {
+		t.Errorf("watch mode returned after %v, before the context was done", elapsed)
+	}
+	if result == nil {
+		t.Fatal("expected a result")
+	}
+}
+
+func TestOpenMDNSConnIPv6(t *testing.T) {
+	s := NewScanner(Options{UseIPv6: true})
+	l, err := s.openMDNSConn(mdnsAddr6)
+	if err != nil {
+		t.Skipf("IPv6 unavailable: %v", err)
+	}
+	defer l.conn.Close()
+
+	if l.group.IP.String() != "ff02::fb" || l.group.Port != 5353 {
+		t.Errorf("expected group [ff02::fb]:5353, got %v", l.group)
+	}
+	if l.conn.LocalAddr().(*net.UDPAddr).IP.To4() != nil {
+		t.Errorf("expected an IPv6 socket, got %v", l.conn.LocalAddr())
+	}
+}
+
+func TestSSDPDeviceKey(t *testing.T) {
+	d := ssdp.Device{Location: "http://192.168.1.1:49152/rootDesc.xml", USN: "uuid:1234-abcd::upnp:rootdevice"}
+	if key := ssdpDeviceKey(d); key != "uuid:1234-abcd" {
+		t.Errorf("ssdpDeviceKey = %q, want uuid:1234-abcd", key)
+	}
+	d.USN = ""
+	if key := ssdpDeviceKey(d); key != d.Location {
+		t.Errorf("ssdpDeviceKey without USN = %q, want the location", key)
+	}
+}
+
+func TestDiscoverSSDP(t *testing.T) {
+	desc := `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:MediaRenderer:1</deviceType>
+    <friendlyName>Living Room TV</friendlyName>
+    <manufacturer>Acme</manufacturer>
+    <modelName>TV-9000</modelName>
+  </device>
+</root>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(desc))
+	}))
+	defer srv.Close()
+
+	// A device answering M-SEARCH once per type it offers
+	device, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer device.Close()
+	go func() {
+		buf := make([]byte, 1500)
+		_, from, err := device.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		for _, st := range []string{"upnp:rootdevice", "urn:schemas-upnp-org:device:MediaRenderer:1"} {
+			reply := fmt.Sprintf("HTTP/1.1 200 OK\r\nLOCATION: %s/desc.xml\r\nST: %s\r\nUSN: uuid:tv-1::%s\r\n\r\n", srv.URL, st, st)
+			device.WriteTo([]byte(reply), from)
+		}
+	}()
+
+	var seen []Neighbor
+	s := NewScanner(Options{SSDP: true, Timeout: 700 * time.Millisecond, OnDiscover: func(n Neighbor) { seen = append(seen, n) }})
+	s.ssdpAddr = device.LocalAddr().String()
+
+	if err := s.discoverSSDP(context.Background(), context.Background()); err != nil {
+		t.Fatalf("discoverSSDP failed: %v", err)
+	}
+
+	if len(s.services) != 1 {
+		t.Fatalf("expected 1 service for one device, got %d", len(s.services))
+	}
+	svc := s.services[0]
+	if svc.Source != "SSDP" || svc.InstanceName != "Living Room TV" {
+		t.Errorf("unexpected service %+v", svc)
+	}
+	if svc.ServiceType != "urn:schemas-upnp-org:device:MediaRenderer:1" {
+		t.Errorf("ServiceType = %q", svc.ServiceType)
+	}
+	if svc.TXT["manufacturer"] != "Acme" || svc.TXT["model"] != "TV-9000" {
+		t.Errorf("TXT = %v", svc.TXT)
+	}
+	if svc.Host != "127.0.0.1" || svc.Port == 0 {
+		t.Errorf("Host/Port = %s:%d", svc.Host, svc.Port)
+	}
+	if len(seen) != 1 || seen[0].Source != "SSDP" {
+		t.Errorf("expected one SSDP neighbor callback, got %+v", seen)
+	}
+}
+
+func TestBuildMDNSQueryType(t *testing.T) {
+	q := buildMDNSQueryType("mydevice.local.", dnsTypeAAAA)
+	tail := q[len(q)-4:]
+	if tail[0] != 0 || tail[1] != 28 || tail[2] != 0 || tail[3] != 1 {
+		t.Errorf("expected QTYPE=AAAA QCLASS=IN, got %v", tail)
+	}
+	if ptr := buildMDNSQuery("_http._tcp.local."); ptr[len(ptr)-3] != 12 {
+		t.Error("buildMDNSQuery should still ask for PTR")
+	}
+}
+
+func TestParseDNSMessageSRVTarget(t *testing.T) {
+	// SRV whose target is compressed against the owner name
+	owner := encodeDNSName("web._http._tcp.mydevice.local.")
+	buf := []byte{0, 0, 0x84, 0, 0, 0, 0, 1, 0, 0, 0, 0}
+	buf = append(buf, owner...)
+	buf = append(buf, 0, 33, 0, 1, 0, 0, 0, 120)
+	rdata := []byte{0, 0, 0, 0, 0x1F, 0x90}     // priority, weight, port 8080
+	rdata = append(rdata, 0xC0, byte(12+4+6+5)) // -> mydevice.local
+	buf = append(buf, 0, byte(len(rdata)))
+	buf = append(buf, rdata...)
+
+	msg, err := parseDNSMessage(buf)
+	if err != nil || len(msg.answers) != 1 {
+		t.Fatalf("parseDNSMessage: %v, %d answers", err, len(msg.answers))
+	}
+	srv := msg.answers[0].rdata
+	if port := int(srv[4])<<8 | int(srv[5]); port != 8080 {
+		t.Errorf("port = %d, want 8080", port)
+	}
+	if target := string(srv[6:]); target != "mydevice.local" {
+		t.Errorf("target = %q, want mydevice.local", target)
+	}
+}
+
+func TestFillServiceAddresses(t *testing.T) {
+	s := NewScanner(Options{Timeout: time.Second})
+	from := &net.UDPAddr{IP: net.ParseIP("192.168.1.50"), Port: 5353}
+
+	srv := append([]byte{0, 0, 0, 0, 0, 80}, encodeDNSName("printer.local.")...)
+	s.processResponse(buildResponse(
+		dnsResourceRecord{name: "_ipp._tcp.local.", rrType: dnsTypePTR, rdata: encodeDNSName("Office._ipp._tcp.local.")},
+		dnsResourceRecord{name: "Office._ipp._tcp.local.", rrType: dnsTypeSRV, rdata: srv},
+		dnsResourceRecord{name: "_ssh._tcp.local.", rrType: dnsTypePTR, rdata: encodeDNSName("nas._ssh._tcp.local.")},
+		dnsResourceRecord{name: "nas._ssh._tcp.local.", rrType: dnsTypeSRV, rdata: append([]byte{0, 0, 0, 0, 0, 22}, encodeDNSName("nas.local.")...)},
+	), from)
+
+	if s.services[0].Host != "printer.local" || len(s.services[0].Addresses) != 0 {
+		t.Fatalf("expected unresolved printer.local, got %+v", s.services[0])
+	}
+
+	// The address record arrives in a later packet
+	s.processResponse(buildResponse(
+		dnsResourceRecord{name: "printer.local.", rrType: dnsTypeA, rdata: []byte{192, 168, 1, 50}},
+	), from)
+
+	unresolved := s.fillServiceAddresses()
+	if len(s.services[0].Addresses) != 1 || s.services[0].Addresses[0] != "192.168.1.50" {
+		t.Errorf("printer addresses = %v, want [192.168.1.50]", s.services[0].Addresses)
+	}
+	if len(unresolved) != 1 || unresolved[0] != "nas.local" {
+		t.Errorf("unresolved = %v, want [nas.local]", unresolved)
+	}
+}
+
 func TestNeighborFields(t *testing.T) {
 	n := Neighbor{
 		Hostname:  "test-host",
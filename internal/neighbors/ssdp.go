@@ -0,0 +1,149 @@
+package neighbors
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JedizLaPulga/NNS/internal/ssdp"
+	"github.com/JedizLaPulga/NNS/internal/upnp"
+)
+
+const (
+	ssdpMX           = 2 // Seconds devices may wait before answering
+	ssdpFetchTimeout = 3 * time.Second
+)
+
+// ssdpDeviceKey identifies the device behind a response. A device answers
+// once per device and service type it offers, all sharing the UUID in the
+// USN.
+func ssdpDeviceKey(d ssdp.Device) string {
+	if uuid, _, _ := strings.Cut(d.USN, "::"); strings.HasPrefix(uuid, "uuid:") {
+		return uuid
+	}
+	return d.Location
+}
+
+// discoverSSDP runs an SSDP search until ctx is done or the scan timeout
+// passes. Each new device's description is fetched in the background under
+// fetchCtx; discoverSSDP waits for those fetches before returning.
+func (s *Scanner) discoverSSDP(ctx, fetchCtx context.Context) error {
+	var fetches sync.WaitGroup
+	defer fetches.Wait()
+
+	scanner := ssdp.New(ssdp.Config{
+		Timeout:      s.opts.Timeout,
+		SearchTarget: s.opts.SSDPTarget,
+		MX:           ssdpMX,
+		Addr:         s.ssdpAddr,
+	})
+	describer := upnp.New(upnp.Config{HTTPTimeout: ssdpFetchTimeout})
+
+	_, err := scanner.DiscoverFunc(ctx, func(d ssdp.Device) {
+		if d.Location == "" {
+			return
+		}
+		idx, ok := s.addSSDPService(d)
+		if !ok {
+			return
+		}
+
+		fetches.Add(1)
+		go func() {
+			defer fetches.Done()
+			dev := upnp.Device{Location: d.Location}
+			if err := describer.FetchDetails(fetchCtx, &dev); err != nil {
+				return
+			}
+			s.applyDescription(idx, dev)
+		}()
+	})
+	return err
+}
+
+// addSSDPService records the device behind d as a service and its sender
+// as a neighbor. It returns the service index and false if the device was
+// already known.
+func (s *Scanner) addSSDPService(d ssdp.Device) (int, bool) {
+	key := ssdpDeviceKey(d)
+
+	svc := DiscoveredService{
+		InstanceName: key,
+		ServiceType:  d.ST,
+		Host:         d.IP,
+		Source:       "SSDP",
+		TXT: map[string]string{
+			"location": d.Location,
+		},
+	}
+	if d.Server != "" {
+		svc.TXT["server"] = d.Server
+	}
+	if u, err := url.Parse(d.Location); err == nil {
+		svc.Host = u.Hostname()
+		if port, err := strconv.Atoi(u.Port()); err == nil {
+			svc.Port = port
+		} else if u.Scheme == "http" {
+			svc.Port = 80
+		}
+	}
+	if d.IP != "" {
+		svc.Addresses = []string{d.IP}
+	}
+
+	s.mu.Lock()
+	if _, seen := s.ssdpDevices[key]; seen {
+		s.mu.Unlock()
+		return 0, false
+	}
+	idx := len(s.services)
+	s.ssdpDevices[key] = idx
+	s.services = append(s.services, svc)
+
+	var discovered []Neighbor
+	if d.IP != "" && !s.knownAddress(d.IP) {
+		if _, exists := s.neighbors[d.IP]; !exists {
+			n := &Neighbor{
+				Hostname:  d.IP,
+				Addresses: []string{d.IP},
+				Services:  []DiscoveredService{},
+				FirstSeen: time.Now(),
+				Source:    "SSDP",
+			}
+			s.neighbors[d.IP] = n
+			discovered = append(discovered, n.clone())
+		}
+	}
+	s.mu.Unlock()
+
+	if s.opts.OnDiscover != nil {
+		for _, n := range discovered {
+			s.opts.OnDiscover(n)
+		}
+	}
+	return idx, true
+}
+
+// applyDescription labels service idx with the details from its device
+// description.
+func (s *Scanner) applyDescription(idx int, dev upnp.Device) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	svc := &s.services[idx]
+	if dev.FriendlyName != "" {
+		svc.InstanceName = dev.FriendlyName
+	}
+	if dev.DeviceType != "" {
+		svc.ServiceType = dev.DeviceType
+	}
+	if dev.Manufacturer != "" {
+		svc.TXT["manufacturer"] = dev.Manufacturer
+	}
+	if dev.ModelName != "" {
+		svc.TXT["model"] = dev.ModelName
+	}
+}
@@ -0,0 +1,348 @@
+package netaudit
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// checkRedis reports Redis instances that execute commands without AUTH.
+// Instances that answer NOAUTH, or DENIED in protected mode, are fine.
+func (a *Auditor) checkRedis(ctx context.Context, ip string) []Finding {
+	return a.probeRedis(ip, 6379)
+}
+
+func (a *Auditor) probeRedis(ip string, port int) []Finding {
+	var findings []Finding
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip, strconv.Itoa(port)), a.opts.Timeout)
+	if err != nil {
+		return findings
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(a.opts.Timeout))
+
+	if _, err := conn.Write([]byte("PING\r\n")); err != nil {
+		return findings
+	}
+	buf := make([]byte, 512)
+	n, err := conn.Read(buf)
+	if err != nil || n == 0 {
+		return findings
+	}
+	reply := strings.TrimSpace(string(buf[:n]))
+	if !strings.HasPrefix(reply, "+PONG") {
+		return findings
+	}
+
+	evidence := "PING → +PONG"
+	if version := redisVersion(conn); version != "" {
+		evidence += ", redis_version:" + version
+	}
+
+	findings = append(findings, Finding{
+		Check:       CheckRedisNoAuth,
+		Severity:    SeverityCritical,
+		Title:       "Redis accessible without authentication",
+		Description: "Redis executes commands from unauthenticated clients, exposing all data and allowing remote code execution via CONFIG",
+		Detail:      evidence,
+		Host:        ip,
+		Port:        port,
+		Remediation: "Set requirepass or ACL users, bind Redis to trusted interfaces and keep protected-mode enabled",
+	})
+	return findings
+}
+
+// redisVersion sends INFO server on an unauthenticated connection and
+// returns the redis_version field.
+func redisVersion(conn net.Conn) string {
+	if _, err := conn.Write([]byte("INFO server\r\n")); err != nil {
+		return ""
+	}
+	buf := make([]byte, 4096)
+	n, _ := io.ReadAtLeast(conn, buf, 1)
+	for _, line := range strings.Split(string(buf[:n]), "\r\n") {
+		if v, ok := strings.CutPrefix(line, "redis_version:"); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// checkMongoDB reports MongoDB instances that list databases without
+// authentication. isMaster/hello always succeeds, so listDatabases is used
+// as the proof: with auth enabled it fails with "requires authentication".
+func (a *Auditor) checkMongoDB(ctx context.Context, ip string) []Finding {
+	return a.probeMongoDB(ip, 27017)
+}
+
+func (a *Auditor) probeMongoDB(ip string, port int) []Finding {
+	var findings []Finding
+
+	addr := net.JoinHostPort(ip, strconv.Itoa(port))
+	listDBs := bsonInt32("listDatabases", 1)
+	nameOnly := bsonBool("nameOnly", true)
+
+	// OP_MSG (MongoDB 3.6+), falling back to OP_QUERY for older servers,
+	// which drop the connection on unknown opcodes.
+	doc, err := a.mongoCommand(addr, opMsg(1, bsonDoc(listDBs, nameOnly, bsonString("$db", "admin"))))
+	if err != nil {
+		doc, err = a.mongoCommand(addr, opQuery(2, "admin.$cmd", bsonDoc(listDBs, nameOnly)))
+	}
+	if err != nil {
+		return findings
+	}
+
+	fields := parseBSON(doc)
+	if ok, _ := fields["ok"].(float64); ok != 1 {
+		return findings
+	}
+
+	names, _ := fields["databases"].([]string)
+	detail := "listDatabases succeeded without credentials"
+	if len(names) > 0 {
+		detail = "listDatabases returned: " + truncate(strings.Join(names, ", "), 80)
+	}
+
+	findings = append(findings, Finding{
+		Check:       CheckMongoNoAuth,
+		Severity:    SeverityCritical,
+		Title:       "MongoDB accessible without authentication",
+		Description: "MongoDB answers database commands from unauthenticated clients",
+		Detail:      detail,
+		Host:        ip,
+		Port:        port,
+		Remediation: "Enable authorization (security.authorization: enabled) and bind MongoDB to trusted interfaces",
+	})
+	return findings
+}
+
+// mongoCommand sends a wire-protocol message and returns the first BSON
+// document of the reply.
+func (a *Auditor) mongoCommand(addr string, msg []byte) ([]byte, error) {
+	conn, err := net.DialTimeout("tcp", addr, a.opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(a.opts.Timeout))
+
+	if _, err := conn.Write(msg); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, err
+	}
+	length := int(binary.LittleEndian.Uint32(header[0:4]))
+	if length < 16 || length > 16<<20 {
+		return nil, fmt.Errorf("invalid MongoDB reply length %d", length)
+	}
+	body := make([]byte, length-16)
+	if _, err := io.ReadFull(conn, body); err != nil {
+		return nil, err
+	}
+
+	switch opcode := binary.LittleEndian.Uint32(header[12:16]); opcode {
+	case 2013: // OP_MSG: flagBits, section kind 0, document
+		if len(body) < 5 || body[4] != 0 {
+			return nil, fmt.Errorf("unexpected OP_MSG section")
+		}
+		return body[5:], nil
+	case 1: // OP_REPLY: flags, cursorID, startingFrom, numberReturned, documents
+		if len(body) < 20 {
+			return nil, fmt.Errorf("short OP_REPLY")
+		}
+		return body[20:], nil
+	default:
+		return nil, fmt.Errorf("unexpected MongoDB opcode %d", opcode)
+	}
+}
+
+// opMsg builds an OP_MSG with a single body section.
+func opMsg(requestID int32, doc []byte) []byte {
+	body := append([]byte{0, 0, 0, 0, 0}, doc...) // flagBits, section kind 0
+	return mongoMessage(requestID, 2013, body)
+}
+
+// opQuery builds a legacy OP_QUERY against collection.
+func opQuery(requestID int32, collection string, doc []byte) []byte {
+	body := []byte{0, 0, 0, 0} // flags
+	body = append(body, collection...)
+	body = append(body, 0)
+	body = binary.LittleEndian.AppendUint32(body, 0) // numberToSkip
+	body = binary.LittleEndian.AppendUint32(body, 1) // numberToReturn
+	body = append(body, doc...)
+	return mongoMessage(requestID, 2004, body)
+}
+
+func mongoMessage(requestID int32, opcode uint32, body []byte) []byte {
+	msg := make([]byte, 16, 16+len(body))
+	binary.LittleEndian.PutUint32(msg[0:], uint32(16+len(body)))
+	binary.LittleEndian.PutUint32(msg[4:], uint32(requestID))
+	binary.LittleEndian.PutUint32(msg[12:], opcode)
+	return append(msg, body...)
+}
+
+// --- Minimal BSON encoding/decoding for the probes ---
+
+func bsonDoc(elements ...[]byte) []byte {
+	var body bytes.Buffer
+	for _, e := range elements {
+		body.Write(e)
+	}
+	body.WriteByte(0)
+	doc := binary.LittleEndian.AppendUint32(nil, uint32(4+body.Len()))
+	return append(doc, body.Bytes()...)
+}
+
+func bsonInt32(name string, v int32) []byte {
+	e := append([]byte{0x10}, name...)
+	e = append(e, 0)
+	return binary.LittleEndian.AppendUint32(e, uint32(v))
+}
+
+func bsonBool(name string, v bool) []byte {
+	e := append([]byte{0x08}, name...)
+	e = append(e, 0)
+	if v {
+		return append(e, 1)
+	}
+	return append(e, 0)
+}
+
+func bsonString(name, v string) []byte {
+	e := append([]byte{0x02}, name...)
+	e = append(e, 0)
+	e = binary.LittleEndian.AppendUint32(e, uint32(len(v)+1))
+	e = append(e, v...)
+	return append(e, 0)
+}
+
+// parseBSON decodes the top-level fields of a reply document that the
+// probes care about: numbers as float64, strings, and the "databases"
+// array flattened to database names. Parsing stops at unknown types.
+func parseBSON(doc []byte) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if len(doc) < 5 {
+		return fields
+	}
+	end := int(binary.LittleEndian.Uint32(doc[:4]))
+	if end > len(doc) {
+		end = len(doc)
+	}
+
+	for pos := 4; pos < end-1; {
+		typ := doc[pos]
+		pos++
+		nameEnd := bytes.IndexByte(doc[pos:end], 0)
+		if nameEnd < 0 {
+			break
+		}
+		name := string(doc[pos : pos+nameEnd])
+		pos += nameEnd + 1
+
+		size := bsonValueSize(typ, doc[pos:end])
+		if size < 0 || pos+size > end {
+			break
+		}
+		value := doc[pos : pos+size]
+		pos += size
+
+		switch typ {
+		case 0x01:
+			fields[name] = math.Float64frombits(binary.LittleEndian.Uint64(value))
+		case 0x10:
+			fields[name] = float64(int32(binary.LittleEndian.Uint32(value)))
+		case 0x12:
+			fields[name] = float64(int64(binary.LittleEndian.Uint64(value)))
+		case 0x02:
+			if len(value) > 4 {
+				fields[name] = string(value[4 : len(value)-1])
+			}
+		case 0x04:
+			names := make([]string, 0)
+			for _, v := range parseBSONArray(value) {
+				if n, ok := parseBSON(v)["name"].(string); ok {
+					names = append(names, n)
+				}
+			}
+			fields[name] = names
+		}
+	}
+	return fields
+}
+
+// parseBSONArray returns the embedded documents of a BSON array.
+func parseBSONArray(arr []byte) [][]byte {
+	docs := make([][]byte, 0)
+	if len(arr) < 5 {
+		return docs
+	}
+	end := int(binary.LittleEndian.Uint32(arr[:4]))
+	if end > len(arr) {
+		end = len(arr)
+	}
+	for pos := 4; pos < end-1; {
+		typ := arr[pos]
+		pos++
+		nameEnd := bytes.IndexByte(arr[pos:end], 0)
+		if nameEnd < 0 {
+			break
+		}
+		pos += nameEnd + 1
+		size := bsonValueSize(typ, arr[pos:end])
+		if size < 0 || pos+size > end {
+			break
+		}
+		if typ == 0x03 {
+			docs = append(docs, arr[pos:pos+size])
+		}
+		pos += size
+	}
+	return docs
+}
+
+// bsonValueSize returns the encoded size of a value of type typ at the
+// start of b, or -1 if the type is not supported.
+func bsonValueSize(typ byte, b []byte) int {
+	switch typ {
+	case 0x01, 0x09, 0x11, 0x12: // double, datetime, timestamp, int64
+		return 8
+	case 0x02, 0x0D, 0x0E: // string, JavaScript, symbol
+		if len(b) < 4 {
+			return -1
+		}
+		return 4 + int(binary.LittleEndian.Uint32(b))
+	case 0x03, 0x04: // document, array
+		if len(b) < 4 {
+			return -1
+		}
+		return int(binary.LittleEndian.Uint32(b))
+	case 0x05: // binary
+		if len(b) < 4 {
+			return -1
+		}
+		return 5 + int(binary.LittleEndian.Uint32(b))
+	case 0x07: // ObjectId
+		return 12
+	case 0x08: // bool
+		return 1
+	case 0x0A, 0x06, 0xFF, 0x7F: // null, undefined, min/max key
+		return 0
+	case 0x10: // int32
+		return 4
+	case 0x13: // decimal128
+		return 16
+	default:
+		return -1
+	}
+}
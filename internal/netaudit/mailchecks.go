@@ -0,0 +1,120 @@
+package netaudit
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/JedizLaPulga/NNS/internal/starttls"
+)
+
+// Addresses used by the open-relay test. Both are outside any audited
+// network, and the test stops before DATA, so no mail is ever sent.
+const (
+	relayTestFrom = "nns-audit@example.com"
+	relayTestTo   = "relay-test@example.org"
+)
+
+// checkAnonymousFTP reports FTP servers that accept anonymous logins.
+func (a *Auditor) checkAnonymousFTP(ctx context.Context, ip string) []Finding {
+	return a.probeAnonymousFTP(ip, 21)
+}
+
+func (a *Auditor) probeAnonymousFTP(ip string, port int) []Finding {
+	var findings []Finding
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip, strconv.Itoa(port)), a.opts.Timeout)
+	if err != nil {
+		return findings
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(a.opts.Timeout))
+	r := bufio.NewReader(conn)
+
+	if code, _, err := starttls.ReadReply(r); err != nil || code != 220 {
+		return findings
+	}
+	code, _, err := starttls.SendCommand(conn, r, "USER anonymous")
+	if err != nil {
+		return findings
+	}
+	// 230 right after USER means no password is needed at all.
+	if code == 331 {
+		code, _, err = starttls.SendCommand(conn, r, "PASS anonymous@")
+		if err != nil {
+			return findings
+		}
+	}
+	fmt.Fprintf(conn, "QUIT\r\n")
+
+	if code != 230 {
+		return findings
+	}
+
+	findings = append(findings, Finding{
+		Check:       CheckDefaultCreds,
+		Severity:    SeverityHigh,
+		Title:       "Anonymous FTP login allowed",
+		Description: "FTP server accepts the anonymous account, exposing files to anyone",
+		Detail:      "USER anonymous / PASS anonymous@ → 230",
+		Host:        ip,
+		Port:        port,
+		Remediation: "Disable anonymous FTP (e.g. anonymous_enable=NO) or replace FTP with SFTP",
+	})
+	return findings
+}
+
+// checkOpenRelay reports SMTP servers that accept mail from an external
+// sender to an external recipient. The transaction is reset before DATA.
+func (a *Auditor) checkOpenRelay(ctx context.Context, ip string) []Finding {
+	return a.probeOpenRelay(ip, 25)
+}
+
+func (a *Auditor) probeOpenRelay(ip string, port int) []Finding {
+	var findings []Finding
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip, strconv.Itoa(port)), a.opts.Timeout)
+	if err != nil {
+		return findings
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(a.opts.Timeout))
+	r := bufio.NewReader(conn)
+
+	if code, _, err := starttls.ReadReply(r); err != nil || code != 220 {
+		return findings
+	}
+	code, _, err := starttls.SendCommand(conn, r, "EHLO nns-audit.example.com")
+	if err != nil {
+		return findings
+	}
+	if code != 250 {
+		if code, _, err = starttls.SendCommand(conn, r, "HELO nns-audit.example.com"); err != nil || code != 250 {
+			return findings
+		}
+	}
+	if code, _, err = starttls.SendCommand(conn, r, "MAIL FROM:<"+relayTestFrom+">"); err != nil || code != 250 {
+		return findings
+	}
+	code, text, err := starttls.SendCommand(conn, r, "RCPT TO:<"+relayTestTo+">")
+	starttls.SendCommand(conn, r, "RSET")
+	fmt.Fprintf(conn, "QUIT\r\n")
+	if err != nil || (code != 250 && code != 251) {
+		return findings
+	}
+
+	findings = append(findings, Finding{
+		Check:       CheckOpenRelay,
+		Severity:    SeverityCritical,
+		Title:       "Open SMTP relay",
+		Description: "SMTP server accepts mail for external domains from unauthenticated external senders",
+		Detail:      fmt.Sprintf("RCPT TO:<%s> → %d %s", relayTestTo, code, truncate(text, 60)),
+		Host:        ip,
+		Port:        port,
+		Remediation: "Restrict relaying to authenticated users and trusted networks",
+	})
+	return findings
+}
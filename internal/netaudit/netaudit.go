@@ -37,71 +37,84 @@ const (
 	CheckBannerLeak   CheckType = "banner-leak"
 	CheckOpenRelay    CheckType = "open-relay"
 	CheckDefaultCreds CheckType = "default-creds"
+	CheckRedisNoAuth  CheckType = "redis-noauth"
+	CheckMongoNoAuth  CheckType = "mongodb-noauth"
 )
 
 // Finding represents a single security finding.
 type Finding struct {
-	Check       CheckType
-	Severity    Severity
-	Title       string
-	Description string
-	Detail      string
-	Host        string
-	Port        int
-	Remediation string
+	Check       CheckType `json:"check"`
+	Severity    Severity  `json:"severity"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Detail      string    `json:"detail,omitempty"`
+	Host        string    `json:"host"`
+	Port        int       `json:"port,omitempty"`
+	Remediation string    `json:"remediation,omitempty"`
 }
 
 // AuditResult holds the complete audit results for a host.
 type AuditResult struct {
-	Target    string
-	Findings  []Finding
-	ChecksRun int
-	StartTime time.Time
-	Duration  time.Duration
-	Summary   AuditSummary
+	Target    string        `json:"target"`
+	Findings  []Finding     `json:"findings"`
+	ChecksRun int           `json:"checks_run"`
+	StartTime time.Time     `json:"start_time"`
+	Duration  time.Duration `json:"duration"`
+	Summary   AuditSummary  `json:"summary"`
 }
 
 // AuditSummary contains aggregate counts.
 type AuditSummary struct {
-	Critical int
-	High     int
-	Medium   int
-	Low      int
-	Info     int
-	Total    int
-	Score    int // 0-100 security score
-	Grade    string
+	Critical int    `json:"critical"`
+	High     int    `json:"high"`
+	Medium   int    `json:"medium"`
+	Low      int    `json:"low"`
+	Info     int    `json:"info"`
+	Total    int    `json:"total"`
+	Score    int    `json:"score"` // 0-100 security score
+	Grade    string `json:"grade"`
 }
 
 // Options configures the audit.
 type Options struct {
-	Target       string
-	Timeout      time.Duration
-	Concurrency  int
-	CheckDNS     bool
-	CheckSNMP    bool
-	CheckSSH     bool
-	CheckTelnet  bool
-	CheckHTTP    bool
-	CheckTLS     bool
-	CheckPorts   bool
-	CheckBanners bool
-	CustomPorts  []int
+	Target          string
+	Timeout         time.Duration
+	Concurrency     int // Parallel checks per host
+	HostConcurrency int // Hosts audited at once by AuditNetwork
+	CheckDNS        bool
+	CheckSNMP       bool
+	CheckSSH        bool
+	CheckTelnet     bool
+	CheckHTTP       bool
+	CheckTLS        bool
+	CheckPorts      bool
+	CheckBanners    bool
+	CheckRedis      bool
+	CheckMongoDB    bool
+	CheckFTP        bool
+	CheckSMTPRelay  bool
+	SkipChecks      []CheckType // Registered checks to skip by name
+	CustomPorts     []int
 }
 
 // DefaultOptions returns sensible defaults with all checks enabled.
 func DefaultOptions() Options {
 	return Options{
-		Timeout:      5 * time.Second,
-		Concurrency:  10,
-		CheckDNS:     true,
-		CheckSNMP:    true,
-		CheckSSH:     true,
-		CheckTelnet:  true,
-		CheckHTTP:    true,
-		CheckTLS:     true,
-		CheckPorts:   true,
-		CheckBanners: true,
+		Timeout:         5 * time.Second,
+		Concurrency:     10,
+		HostConcurrency: 4,
+		CheckDNS:        true,
+		CheckSNMP:       true,
+		CheckSSH:        true,
+		CheckTelnet:     true,
+		CheckHTTP:       true,
+		CheckTLS:        true,
+		CheckPorts:      true,
+		CheckBanners:    true,
+		CheckRedis:      true,
+		CheckMongoDB:    true,
+		CheckFTP:        true,
+		CheckSMTPRelay:  true,
 		CustomPorts: []int{
 			21, 22, 23, 25, 53, 80, 110, 143, 161, 443,
 			445, 993, 995, 1433, 1883, 3306, 3389, 5432,
@@ -110,10 +123,22 @@ func DefaultOptions() Options {
 	}
 }
 
+// CheckFunc runs one security check against ip and returns its findings.
+// It should honour ctx and the auditor's timeout, and return no findings
+// when the service is unreachable.
+type CheckFunc func(ctx context.Context, ip string) []Finding
+
+type registeredCheck struct {
+	name    CheckType
+	fn      CheckFunc
+	builtin bool // Bound to the auditor that registered it
+}
+
 // Auditor performs network security audits.
 type Auditor struct {
 	opts     Options
 	resolver *net.Resolver
+	checks   []registeredCheck
 }
 
 // NewAuditor creates a new auditor.
@@ -124,13 +149,100 @@ func NewAuditor(opts Options) *Auditor {
 	if opts.Concurrency <= 0 {
 		opts.Concurrency = 10
 	}
+	if opts.HostConcurrency <= 0 {
+		opts.HostConcurrency = 4
+	}
 	if len(opts.CustomPorts) == 0 {
 		opts.CustomPorts = DefaultOptions().CustomPorts
 	}
-	return &Auditor{
+	a := &Auditor{
 		opts:     opts,
 		resolver: net.DefaultResolver,
 	}
+	a.registerBuiltins()
+	return a
+}
+
+// RegisterCheck adds a check that Audit runs against every target. Checks
+// run in registration order under Options.Concurrency. Registering an
+// existing name, including a built-in one, replaces that check. RegisterCheck
+// must not be called while an audit is running.
+func (a *Auditor) RegisterCheck(name CheckType, fn CheckFunc) {
+	a.register(registeredCheck{name: name, fn: fn})
+}
+
+func (a *Auditor) register(check registeredCheck) {
+	for i, c := range a.checks {
+		if c.name == check.name {
+			a.checks[i] = check
+			return
+		}
+	}
+	a.checks = append(a.checks, check)
+}
+
+// Checks returns the names of the registered checks in run order.
+func (a *Auditor) Checks() []CheckType {
+	names := make([]CheckType, len(a.checks))
+	for i, c := range a.checks {
+		names[i] = c.name
+	}
+	return names
+}
+
+// registerBuiltins registers the built-in checks, port scan first.
+func (a *Auditor) registerBuiltins() {
+	a.register(registeredCheck{name: CheckOpenPorts, fn: a.checkOpenPorts, builtin: true})
+	a.register(registeredCheck{name: CheckOpenDNS, fn: a.checkOpenDNS, builtin: true})
+	a.register(registeredCheck{name: CheckSNMPDefault, fn: a.checkSNMP, builtin: true})
+	a.register(registeredCheck{name: CheckSSH, fn: a.checkSSH, builtin: true})
+	a.register(registeredCheck{name: CheckTelnet, fn: a.checkTelnet, builtin: true})
+	a.register(registeredCheck{name: CheckExposedHTTP, fn: a.checkHTTP, builtin: true})
+	a.register(registeredCheck{name: CheckWeakTLS, fn: a.checkTLSSecurity, builtin: true})
+	a.register(registeredCheck{name: CheckBannerLeak, fn: a.checkBannerLeak, builtin: true})
+	a.register(registeredCheck{name: CheckRedisNoAuth, fn: a.checkRedis, builtin: true})
+	a.register(registeredCheck{name: CheckMongoNoAuth, fn: a.checkMongoDB, builtin: true})
+	a.register(registeredCheck{name: CheckDefaultCreds, fn: a.checkAnonymousFTP, builtin: true})
+	a.register(registeredCheck{name: CheckOpenRelay, fn: a.checkOpenRelay, builtin: true})
+}
+
+// enabled reports whether the check registered as name should run. The
+// CheckX fields toggle the built-in checks; any check, built-in or custom,
+// can also be turned off through SkipChecks.
+func (o Options) enabled(name CheckType) bool {
+	for _, skip := range o.SkipChecks {
+		if skip == name {
+			return false
+		}
+	}
+	switch name {
+	case CheckOpenPorts:
+		return o.CheckPorts
+	case CheckOpenDNS:
+		return o.CheckDNS
+	case CheckSNMPDefault:
+		return o.CheckSNMP
+	case CheckSSH:
+		return o.CheckSSH
+	case CheckTelnet:
+		return o.CheckTelnet
+	case CheckExposedHTTP:
+		return o.CheckHTTP
+	case CheckWeakTLS:
+		return o.CheckTLS
+	case CheckBannerLeak:
+		return o.CheckBanners
+	case CheckRedisNoAuth:
+		return o.CheckRedis
+	case CheckMongoNoAuth:
+		return o.CheckMongoDB
+	case CheckDefaultCreds:
+		return o.CheckFTP
+	case CheckOpenRelay:
+		return o.CheckSMTPRelay
+	default:
+		return true
+	}
 }
 
 // Audit performs the security audit against the target.
@@ -168,108 +280,19 @@ func (a *Auditor) Audit(ctx context.Context) (*AuditResult, error) {
 		mu.Unlock()
 	}
 
-	// Port scan first
-	if a.opts.CheckPorts {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
-			incChecks()
-			findings := a.checkOpenPorts(ctx, targetIP)
-			addFindings(findings)
-		}()
-	}
-
-	// DNS resolver check
-	if a.opts.CheckDNS {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
-			incChecks()
-			findings := a.checkOpenDNS(ctx, targetIP)
-			addFindings(findings)
-		}()
-	}
-
-	// SNMP check
-	if a.opts.CheckSNMP {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
-			incChecks()
-			findings := a.checkSNMP(ctx, targetIP)
-			addFindings(findings)
-		}()
-	}
-
-	// SSH check
-	if a.opts.CheckSSH {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
-			incChecks()
-			findings := a.checkSSH(ctx, targetIP)
-			addFindings(findings)
-		}()
-	}
-
-	// Telnet check
-	if a.opts.CheckTelnet {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
-			incChecks()
-			findings := a.checkTelnet(ctx, targetIP)
-			addFindings(findings)
-		}()
-	}
-
-	// HTTP check
-	if a.opts.CheckHTTP {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
-			incChecks()
-			findings := a.checkHTTP(ctx, targetIP)
-			addFindings(findings)
-		}()
-	}
-
-	// TLS check
-	if a.opts.CheckTLS {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
-			incChecks()
-			findings := a.checkTLSSecurity(ctx, targetIP)
-			addFindings(findings)
-		}()
-	}
-
-	// Banner grabbing
-	if a.opts.CheckBanners {
+	for _, c := range a.checks {
+		if !a.opts.enabled(c.name) {
+			continue
+		}
 		wg.Add(1)
-		go func() {
+		go func(fn CheckFunc) {
 			defer wg.Done()
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 			incChecks()
-			findings := a.checkBannerLeak(ctx, targetIP)
+			findings := fn(ctx, targetIP)
 			addFindings(findings)
-		}()
+		}(c.fn)
 	}
 
 	wg.Wait()
@@ -375,8 +398,10 @@ func (a *Auditor) checkOpenPorts(ctx context.Context, ip string) []Finding {
 
 	for _, port := range openPorts {
 		if name, dangerous := dangerousPorts[port]; dangerous {
+			// Redis and MongoDB are only critical when reachable without
+			// auth, which checkRedis/checkMongoDB confirm separately.
 			sev := SeverityHigh
-			if port == 23 || port == 6379 || port == 27017 {
+			if port == 23 {
 				sev = SeverityCritical
 			}
 			findings = append(findings, Finding{
@@ -1,9 +1,15 @@
 package netaudit
 
 import (
+	"bufio"
 	"context"
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
+	"io"
+	"math"
 	"net"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -465,6 +471,7 @@ func TestCheckTypeConstants(t *testing.T) {
 	checks := []CheckType{
 		CheckOpenDNS, CheckSNMPDefault, CheckSSH, CheckTelnet,
 		CheckExposedHTTP, CheckWeakTLS, CheckOpenPorts, CheckBannerLeak,
+		CheckOpenRelay, CheckDefaultCreds, CheckRedisNoAuth, CheckMongoNoAuth,
 	}
 
 	seen := make(map[CheckType]bool)
@@ -577,3 +584,458 @@ func TestFormatFindingWithDetail(t *testing.T) {
 		t.Error("format should include host:port")
 	}
 }
+
+func TestAuditResultToJSON(t *testing.T) {
+	r := &AuditResult{
+		Target: "10.0.0.1",
+		Findings: []Finding{
+			{Check: CheckTelnet, Severity: SeverityCritical, Title: "Telnet service exposed", Host: "10.0.0.1", Port: 23},
+		},
+		Summary: AuditSummary{Critical: 1, Total: 1, Score: 75, Grade: "C"},
+	}
+	out, err := r.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v", err)
+	}
+	for _, want := range []string{`"target": "10.0.0.1"`, `"check": "telnet"`, `"severity": "CRITICAL"`, `"grade": "C"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("ToJSON() missing %s:\n%s", want, out)
+		}
+	}
+
+	empty, err := (&AuditResult{Target: "10.0.0.2"}).ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v", err)
+	}
+	if !strings.Contains(empty, `"findings": []`) {
+		t.Errorf("ToJSON() without findings should emit an empty array:\n%s", empty)
+	}
+}
+
+func TestAuditResultToSARIF(t *testing.T) {
+	r := &AuditResult{
+		Target: "10.0.0.1",
+		Findings: []Finding{
+			{Check: CheckOpenPorts, Severity: SeverityHigh, Title: "Exposed MySQL service", Description: "Port 3306 (MySQL) is open", Host: "10.0.0.1", Port: 3306, Remediation: "Restrict access to port 3306"},
+			{Check: CheckOpenPorts, Severity: SeverityCritical, Title: "Exposed Redis service", Description: "Port 6379 (Redis) is open", Host: "10.0.0.1", Port: 6379},
+			{Check: CheckExposedHTTP, Severity: SeverityLow, Title: "Missing HTTP security headers", Description: "Headers not set", Host: "10.0.0.1", Port: 80},
+			{Check: CheckOpenPorts, Severity: SeverityMedium, Title: "Many open ports detected", Description: "12 ports are open"},
+		},
+	}
+	out, err := r.ToSARIF()
+	if err != nil {
+		t.Fatalf("ToSARIF() error = %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal([]byte(out), &log); err != nil {
+		t.Fatalf("ToSARIF() produced invalid JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("version/runs = %s/%d", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 2 {
+		t.Fatalf("rules = %d, want one per CheckType (2)", len(run.Tool.Driver.Rules))
+	}
+	ports := run.Tool.Driver.Rules[1]
+	if ports.ID != "open-ports" || ports.DefaultConfiguration.Level != "error" || ports.Properties.SecuritySeverity != "9.5" {
+		t.Errorf("open-ports rule = %+v, want the most severe finding's level", ports)
+	}
+	if ports.Help.Text != "Restrict access to port 3306" {
+		t.Errorf("rule help = %q, want remediation", ports.Help.Text)
+	}
+
+	if len(run.Results) != 4 {
+		t.Fatalf("results = %d, want 4", len(run.Results))
+	}
+	first := run.Results[0]
+	if first.RuleID != "open-ports" || first.RuleIndex != 1 || first.Level != "error" {
+		t.Errorf("result[0] = %+v", first)
+	}
+	if uri := first.Locations[0].PhysicalLocation.ArtifactLocation.URI; uri != "10.0.0.1:3306" {
+		t.Errorf("result[0] location = %q, want 10.0.0.1:3306", uri)
+	}
+	if run.Results[2].Level != "note" {
+		t.Errorf("LOW finding level = %q, want note", run.Results[2].Level)
+	}
+	if uri := run.Results[3].Locations[0].PhysicalLocation.ArtifactLocation.URI; uri != "10.0.0.1" {
+		t.Errorf("portless finding location = %q, want the target", uri)
+	}
+	if got := findingLocation(Finding{Host: "2001:db8::1", Port: 443}, ""); got != "[2001:db8::1]:443" {
+		t.Errorf("IPv6 finding location = %q, want [2001:db8::1]:443", got)
+	}
+}
+
+func TestExpandCIDR(t *testing.T) {
+	tests := []struct {
+		cidr  string
+		first string
+		count int
+	}{
+		{"192.168.1.0/30", "192.168.1.1", 2},
+		{"192.168.1.0/24", "192.168.1.1", 254},
+		{"10.0.0.4/31", "10.0.0.4", 2},
+		{"10.0.0.9/32", "10.0.0.9", 1},
+		{"2001:db8::/126", "2001:db8::", 4},
+	}
+	for _, tt := range tests {
+		hosts, err := expandCIDR(tt.cidr)
+		if err != nil {
+			t.Fatalf("expandCIDR(%s) error = %v", tt.cidr, err)
+		}
+		if len(hosts) != tt.count || hosts[0] != tt.first {
+			t.Errorf("expandCIDR(%s) = %d hosts starting %s, want %d starting %s", tt.cidr, len(hosts), hosts[0], tt.count, tt.first)
+		}
+	}
+
+	if _, err := expandCIDR("10.0.0.0/8"); err == nil {
+		t.Error("expandCIDR(/8) should be rejected as too large")
+	}
+	if _, err := expandCIDR("not-a-cidr"); err == nil {
+		t.Error("expandCIDR(invalid) should fail")
+	}
+}
+
+func TestAuditNetwork(t *testing.T) {
+	opts := Options{
+		Timeout:         100 * time.Millisecond,
+		HostConcurrency: 2,
+		CheckTelnet:     true,
+	}
+	auditor := NewAuditor(opts)
+
+	results, err := auditor.AuditNetwork(context.Background(), "127.0.0.0/29")
+	if err != nil {
+		t.Fatalf("AuditNetwork error: %v", err)
+	}
+	if len(results) != 6 {
+		t.Fatalf("AuditNetwork returned %d results, want 6", len(results))
+	}
+	for i, r := range results {
+		want := fmt.Sprintf("127.0.0.%d", i+1)
+		if r.Target != want {
+			t.Errorf("results[%d].Target = %s, want %s", i, r.Target, want)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := auditor.AuditNetwork(ctx, "127.0.0.0/29"); err == nil {
+		t.Error("AuditNetwork with cancelled context should return an error")
+	}
+}
+
+func TestAuditorForHost(t *testing.T) {
+	const custom CheckType = "acme-agent"
+	auditor := NewAuditor(Options{Target: "127.0.0.0/29", Timeout: time.Second, CheckTelnet: true})
+	auditor.RegisterCheck(custom, func(ctx context.Context, ip string) []Finding {
+		return []Finding{{Check: custom, Title: "custom", Host: ip}}
+	})
+	auditor.RegisterCheck(CheckTelnet, func(ctx context.Context, ip string) []Finding {
+		return []Finding{{Check: CheckTelnet, Title: "stub", Host: ip}}
+	})
+
+	h := auditor.forHost("127.0.0.2")
+	if h.opts.Target != "127.0.0.2" {
+		t.Errorf("Target = %q, want 127.0.0.2", h.opts.Target)
+	}
+	if got, want := fmt.Sprint(h.Checks()), fmt.Sprint(auditor.Checks()); got != want {
+		t.Errorf("Checks() = %s, want %s", got, want)
+	}
+	for _, c := range h.checks {
+		switch c.name {
+		case custom, CheckTelnet:
+			if c.builtin {
+				t.Errorf("%s: custom check was replaced by a built-in", c.name)
+			}
+		default:
+			if !c.builtin {
+				t.Errorf("%s: built-in check was copied from the parent auditor", c.name)
+			}
+		}
+	}
+}
+
+func TestSummarizeNetwork(t *testing.T) {
+	results := []*AuditResult{
+		{Target: "10.0.0.1", Summary: AuditSummary{Grade: "A", Score: 100}},
+		{Target: "10.0.0.2", Summary: AuditSummary{Critical: 1, High: 1, Total: 2, Grade: "D", Score: 60}},
+		{Target: "10.0.0.3", Summary: AuditSummary{Low: 2, Info: 1, Total: 3, Grade: "A", Score: 94}},
+	}
+
+	s := SummarizeNetwork(results)
+	if s.Hosts != 3 || s.HostsWithFindings != 2 {
+		t.Errorf("Hosts/HostsWithFindings = %d/%d, want 3/2", s.Hosts, s.HostsWithFindings)
+	}
+	if s.Critical != 1 || s.High != 1 || s.Low != 2 || s.Info != 1 || s.Total != 5 {
+		t.Errorf("severity counts = %+v", s)
+	}
+	if s.WorstGrade != "D" || s.WorstHost != "10.0.0.2" {
+		t.Errorf("WorstGrade/WorstHost = %s/%s, want D/10.0.0.2", s.WorstGrade, s.WorstHost)
+	}
+
+	out := FormatNetwork(results, s)
+	if !strings.Contains(out, "Worst grade: D (10.0.0.2)") {
+		t.Errorf("FormatNetwork missing worst grade:\n%s", out)
+	}
+}
+
+// serveTCP answers each connection with handler and returns the port.
+func serveTCP(t *testing.T, handler func(net.Conn)) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to create listener: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handler(conn)
+			}()
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestProbeRedis(t *testing.T) {
+	open := serveTCP(t, func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch strings.TrimSpace(line) {
+			case "PING":
+				conn.Write([]byte("+PONG\r\n"))
+			case "INFO server":
+				info := "# Server\r\nredis_version:7.2.4\r\nredis_mode:standalone\r\n"
+				fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(info), info)
+			}
+		}
+	})
+	protected := serveTCP(t, func(conn net.Conn) {
+		bufio.NewReader(conn).ReadString('\n')
+		conn.Write([]byte("-NOAUTH Authentication required.\r\n"))
+	})
+
+	auditor := NewAuditor(Options{Timeout: time.Second})
+
+	findings := auditor.probeRedis("127.0.0.1", open)
+	if len(findings) != 1 {
+		t.Fatalf("open Redis: %d findings, want 1", len(findings))
+	}
+	f := findings[0]
+	if f.Check != CheckRedisNoAuth || f.Severity != SeverityCritical || f.Port != open {
+		t.Errorf("finding = %+v", f)
+	}
+	if !strings.Contains(f.Detail, "redis_version:7.2.4") {
+		t.Errorf("Detail = %q, want the version as evidence", f.Detail)
+	}
+
+	if findings := auditor.probeRedis("127.0.0.1", protected); len(findings) != 0 {
+		t.Errorf("auth-protected Redis: %d findings, want 0", len(findings))
+	}
+}
+
+// mongoReply answers one OP_MSG request with doc.
+func mongoReply(doc []byte) func(net.Conn) {
+	return func(conn net.Conn) {
+		header := make([]byte, 16)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		body := make([]byte, binary.LittleEndian.Uint32(header)-16)
+		if _, err := io.ReadFull(conn, body); err != nil {
+			return
+		}
+		if binary.LittleEndian.Uint32(header[12:]) != 2013 {
+			return
+		}
+		conn.Write(opMsg(99, doc))
+	}
+}
+
+func TestProbeMongoDB(t *testing.T) {
+	bsonDouble := func(name string, v float64) []byte {
+		e := append([]byte{0x01}, name...)
+		e = append(e, 0)
+		return binary.LittleEndian.AppendUint64(e, math.Float64bits(v))
+	}
+	bsonArray := func(name string, docs ...[]byte) []byte {
+		elems := make([][]byte, 0, len(docs))
+		for i, d := range docs {
+			e := append([]byte{0x03}, strconv.Itoa(i)...)
+			e = append(e, 0)
+			elems = append(elems, append(e, d...))
+		}
+		e := append([]byte{0x04}, name...)
+		e = append(e, 0)
+		return append(e, bsonDoc(elems...)...)
+	}
+
+	open := serveTCP(t, mongoReply(bsonDoc(
+		bsonArray("databases", bsonDoc(bsonString("name", "admin")), bsonDoc(bsonString("name", "shop"))),
+		bsonDouble("ok", 1),
+	)))
+	protected := serveTCP(t, mongoReply(bsonDoc(
+		bsonDouble("ok", 0),
+		bsonString("errmsg", "command listDatabases requires authentication"),
+		bsonInt32("code", 13),
+	)))
+
+	auditor := NewAuditor(Options{Timeout: time.Second})
+
+	findings := auditor.probeMongoDB("127.0.0.1", open)
+	if len(findings) != 1 {
+		t.Fatalf("open MongoDB: %d findings, want 1", len(findings))
+	}
+	if f := findings[0]; f.Check != CheckMongoNoAuth || f.Severity != SeverityCritical || f.Detail != "listDatabases returned: admin, shop" {
+		t.Errorf("finding = %+v", f)
+	}
+
+	if findings := auditor.probeMongoDB("127.0.0.1", protected); len(findings) != 0 {
+		t.Errorf("auth-protected MongoDB: %d findings, want 0", len(findings))
+	}
+}
+
+// lineServer greets with banner and answers each command line using
+// replies, keyed by the command verb. Unknown verbs get 500.
+func lineServer(banner string, replies map[string]string) func(net.Conn) {
+	return func(conn net.Conn) {
+		fmt.Fprintf(conn, "%s\r\n", banner)
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			verb, _, _ := strings.Cut(strings.TrimSpace(line), " ")
+			if verb == "QUIT" {
+				return
+			}
+			reply, ok := replies[verb]
+			if !ok {
+				reply = "500 Unknown command"
+			}
+			fmt.Fprintf(conn, "%s\r\n", reply)
+		}
+	}
+}
+
+func TestProbeAnonymousFTP(t *testing.T) {
+	open := serveTCP(t, lineServer("220-Welcome\r\n220 FTP ready", map[string]string{
+		"USER": "331 Please specify the password.",
+		"PASS": "230 Login successful.",
+	}))
+	closed := serveTCP(t, lineServer("220 FTP ready", map[string]string{
+		"USER": "331 Please specify the password.",
+		"PASS": "530 Login incorrect.",
+	}))
+
+	auditor := NewAuditor(Options{Timeout: time.Second})
+
+	findings := auditor.probeAnonymousFTP("127.0.0.1", open)
+	if len(findings) != 1 {
+		t.Fatalf("anonymous FTP: %d findings, want 1", len(findings))
+	}
+	if f := findings[0]; f.Check != CheckDefaultCreds || f.Severity != SeverityHigh || f.Port != open {
+		t.Errorf("finding = %+v", f)
+	}
+
+	if findings := auditor.probeAnonymousFTP("127.0.0.1", closed); len(findings) != 0 {
+		t.Errorf("FTP rejecting anonymous: %d findings, want 0", len(findings))
+	}
+}
+
+func TestProbeOpenRelay(t *testing.T) {
+	relay := serveTCP(t, lineServer("220 mail.example.net ESMTP", map[string]string{
+		"EHLO": "250-mail.example.net\r\n250 PIPELINING",
+		"MAIL": "250 OK",
+		"RCPT": "250 Accepted",
+		"RSET": "250 OK",
+	}))
+	strict := serveTCP(t, lineServer("220 mail.example.net ESMTP", map[string]string{
+		"EHLO": "250 mail.example.net",
+		"MAIL": "250 OK",
+		"RCPT": "554 5.7.1 Relay access denied",
+		"RSET": "250 OK",
+	}))
+	heloOnly := serveTCP(t, lineServer("220 old.example.net SMTP", map[string]string{
+		"HELO": "250 old.example.net",
+		"MAIL": "250 OK",
+		"RCPT": "251 User not local; will forward",
+		"RSET": "250 OK",
+	}))
+
+	auditor := NewAuditor(Options{Timeout: time.Second})
+
+	findings := auditor.probeOpenRelay("127.0.0.1", relay)
+	if len(findings) != 1 {
+		t.Fatalf("open relay: %d findings, want 1", len(findings))
+	}
+	if f := findings[0]; f.Check != CheckOpenRelay || f.Severity != SeverityCritical || !strings.Contains(f.Detail, "250 Accepted") {
+		t.Errorf("finding = %+v", f)
+	}
+
+	if findings := auditor.probeOpenRelay("127.0.0.1", strict); len(findings) != 0 {
+		t.Errorf("relay denied: %d findings, want 0", len(findings))
+	}
+	if findings := auditor.probeOpenRelay("127.0.0.1", heloOnly); len(findings) != 1 {
+		t.Errorf("HELO-only relay: %d findings, want 1", len(findings))
+	}
+}
+
+func TestRegisterCheck(t *testing.T) {
+	const custom CheckType = "acme-agent"
+	var gotIP string
+	check := func(ctx context.Context, ip string) []Finding {
+		gotIP = ip
+		return []Finding{{Check: custom, Severity: SeverityMedium, Title: "ACME agent exposed", Host: ip}}
+	}
+
+	// Only custom checks: every built-in toggle is off.
+	auditor := NewAuditor(Options{Target: "127.0.0.1", Timeout: time.Second})
+	auditor.RegisterCheck(custom, check)
+
+	names := auditor.Checks()
+	if len(names) != 13 || names[0] != CheckOpenPorts || names[12] != custom {
+		t.Fatalf("Checks() = %v, want built-ins followed by %s", names, custom)
+	}
+
+	result, err := auditor.Audit(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ChecksRun != 1 || len(result.Findings) != 1 || result.Findings[0].Check != custom {
+		t.Errorf("ChecksRun = %d, Findings = %+v; want only the custom check", result.ChecksRun, result.Findings)
+	}
+	if gotIP != "127.0.0.1" {
+		t.Errorf("check called with %q, want 127.0.0.1", gotIP)
+	}
+
+	// Replacing a built-in keeps its slot and its toggle.
+	auditor = NewAuditor(Options{Target: "127.0.0.1", Timeout: time.Second, CheckTelnet: true, SkipChecks: []CheckType{custom}})
+	auditor.RegisterCheck(custom, check)
+	auditor.RegisterCheck(CheckTelnet, func(ctx context.Context, ip string) []Finding {
+		return []Finding{{Check: CheckTelnet, Severity: SeverityLow, Title: "stub"}}
+	})
+	if n := len(auditor.Checks()); n != 13 {
+		t.Errorf("len(Checks()) = %d after replacing a built-in, want 13", n)
+	}
+	result, err = auditor.Audit(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ChecksRun != 1 || len(result.Findings) != 1 || result.Findings[0].Title != "stub" {
+		t.Errorf("ChecksRun = %d, Findings = %+v; want only the replaced telnet check", result.ChecksRun, result.Findings)
+	}
+}
@@ -0,0 +1,194 @@
+package netaudit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+)
+
+// maxNetworkHosts caps how many addresses AuditNetwork will expand.
+const maxNetworkHosts = 65536
+
+// NetworkSummary aggregates the results of auditing several hosts.
+type NetworkSummary struct {
+	Hosts             int    `json:"hosts"`
+	HostsWithFindings int    `json:"hosts_with_findings"`
+	Critical          int    `json:"critical"`
+	High              int    `json:"high"`
+	Medium            int    `json:"medium"`
+	Low               int    `json:"low"`
+	Info              int    `json:"info"`
+	Total             int    `json:"total"`
+	WorstGrade        string `json:"worst_grade"`
+	WorstHost         string `json:"worst_host,omitempty"`
+}
+
+// AuditNetwork expands cidr and audits every host in it. Up to
+// Options.HostConcurrency hosts are audited at once, and each host still
+// runs its checks under Options.Concurrency. Results are returned in
+// address order. If ctx is cancelled, no further hosts are started and the
+// results gathered so far are returned together with ctx.Err().
+func (a *Auditor) AuditNetwork(ctx context.Context, cidr string) ([]*AuditResult, error) {
+	hosts, err := expandCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*AuditResult, len(hosts))
+	sem := make(chan struct{}, a.opts.HostConcurrency)
+	var wg sync.WaitGroup
+
+launch:
+	for i, host := range hosts {
+		select {
+		case <-ctx.Done():
+			break launch
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(i int, host string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if r, err := a.forHost(host).Audit(ctx); err == nil {
+				results[i] = r
+			}
+		}(i, host)
+	}
+	wg.Wait()
+
+	done := make([]*AuditResult, 0, len(results))
+	for _, r := range results {
+		if r != nil {
+			done = append(done, r)
+		}
+	}
+	return done, ctx.Err()
+}
+
+// forHost returns an auditor for one host of a network audit. The built-in
+// checks are bound to their auditor's options, so they are registered
+// afresh; only custom checks are carried over.
+func (a *Auditor) forHost(host string) *Auditor {
+	opts := a.opts
+	opts.Target = host
+	h := NewAuditor(opts)
+	h.resolver = a.resolver
+	for _, c := range a.checks {
+		if !c.builtin {
+			h.register(c)
+		}
+	}
+	return h
+}
+
+// SummarizeNetwork aggregates per-host results into finding counts by
+// severity and the worst grade seen.
+func SummarizeNetwork(results []*AuditResult) NetworkSummary {
+	s := NetworkSummary{Hosts: len(results), WorstGrade: "A"}
+	for _, r := range results {
+		s.Critical += r.Summary.Critical
+		s.High += r.Summary.High
+		s.Medium += r.Summary.Medium
+		s.Low += r.Summary.Low
+		s.Info += r.Summary.Info
+		s.Total += r.Summary.Total
+		if r.Summary.Total > 0 {
+			s.HostsWithFindings++
+		}
+		if r.Summary.Grade > s.WorstGrade {
+			s.WorstGrade = r.Summary.Grade
+			s.WorstHost = r.Target
+		}
+	}
+	return s
+}
+
+// FormatNetwork returns a one-line-per-host report followed by the
+// aggregate summary.
+func FormatNetwork(results []*AuditResult, s NetworkSummary) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("\n%-18s %-6s %-6s %s\n", "HOST", "GRADE", "SCORE", "FINDINGS (C/H/M/L/I)"))
+	sb.WriteString(strings.Repeat("─", 60) + "\n")
+	for _, r := range results {
+		sb.WriteString(fmt.Sprintf("%-18s %-6s %-6d %d/%d/%d/%d/%d\n",
+			r.Target, r.Summary.Grade, r.Summary.Score,
+			r.Summary.Critical, r.Summary.High, r.Summary.Medium, r.Summary.Low, r.Summary.Info))
+	}
+
+	sb.WriteString(strings.Repeat("─", 60) + "\n")
+	sb.WriteString(fmt.Sprintf("Hosts:       %d audited, %d with findings\n", s.Hosts, s.HostsWithFindings))
+	worst := s.WorstGrade
+	if s.WorstHost != "" {
+		worst += " (" + s.WorstHost + ")"
+	}
+	sb.WriteString(fmt.Sprintf("Worst grade: %s\n", worst))
+	sb.WriteString(fmt.Sprintf("Findings:    %d total - C:%d H:%d M:%d L:%d I:%d\n",
+		s.Total, s.Critical, s.High, s.Medium, s.Low, s.Info))
+
+	return sb.String()
+}
+
+// NetworkToJSON returns per-host results and the aggregate summary as
+// indented JSON.
+func NetworkToJSON(results []*AuditResult, s NetworkSummary) (string, error) {
+	hosts := make([]AuditResult, 0, len(results))
+	for _, r := range results {
+		h := *r
+		if h.Findings == nil {
+			h.Findings = []Finding{}
+		}
+		hosts = append(hosts, h)
+	}
+	data, err := json.MarshalIndent(struct {
+		Summary NetworkSummary `json:"summary"`
+		Hosts   []AuditResult  `json:"hosts"`
+	}{s, hosts}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// expandCIDR lists the host addresses in cidr. For IPv4 networks larger
+// than /31 the network and broadcast addresses are skipped.
+func expandCIDR(cidr string) ([]string, error) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+	}
+
+	ones, bits := network.Mask.Size()
+	if bits-ones > 16 {
+		return nil, fmt.Errorf("network %s is too large (max %d hosts)", cidr, maxNetworkHosts)
+	}
+
+	hosts := make([]string, 0)
+	for ip := network.IP.Mask(network.Mask); network.Contains(ip); ip = nextIP(ip) {
+		hosts = append(hosts, ip.String())
+	}
+
+	if network.IP.To4() != nil && bits-ones > 1 {
+		hosts = hosts[1 : len(hosts)-1]
+	}
+	return hosts, nil
+}
+
+// nextIP returns ip+1. It wraps to all zeros after the last address, which
+// no network Contains once the loop has started.
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
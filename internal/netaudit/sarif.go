@@ -0,0 +1,242 @@
+package netaudit
+
+import (
+	"encoding/json"
+	"net"
+	"sort"
+	"strconv"
+)
+
+// SARIF 2.1.0 (https://docs.oasis-open.org/sarif/sarif/v2.1.0/) is the
+// format GitHub code scanning and most CI security dashboards ingest.
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	ShortDescription     sarifText          `json:"shortDescription"`
+	Help                 sarifText          `json:"help"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+	Properties           sarifProperties    `json:"properties"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifProperties struct {
+	Tags             []string `json:"tags,omitempty"`
+	SecuritySeverity string   `json:"security-severity,omitempty"`
+	Severity         Severity `json:"severity,omitempty"`
+	Remediation      string   `json:"remediation,omitempty"`
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string          `json:"ruleId"`
+	RuleIndex  int             `json:"ruleIndex"`
+	Level      string          `json:"level"`
+	Message    sarifText       `json:"message"`
+	Locations  []sarifLocation `json:"locations"`
+	Properties sarifProperties `json:"properties"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// ToJSON returns the audit result as indented JSON.
+func (r *AuditResult) ToJSON() (string, error) {
+	out := *r
+	if out.Findings == nil {
+		out.Findings = []Finding{}
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ToSARIF returns the audit result as a SARIF 2.1.0 log.
+func (r *AuditResult) ToSARIF() (string, error) {
+	return SARIF([]*AuditResult{r})
+}
+
+// SARIF returns one SARIF 2.1.0 log covering all results. Each CheckType
+// becomes a rule, using the most severe finding of that type as the rule's
+// default level. Each finding becomes a result located at host:port.
+func SARIF(results []*AuditResult) (string, error) {
+	byCheck := make(map[CheckType][]Finding)
+	for _, r := range results {
+		for _, f := range r.Findings {
+			byCheck[f.Check] = append(byCheck[f.Check], f)
+		}
+	}
+
+	checks := make([]CheckType, 0, len(byCheck))
+	for c := range byCheck {
+		checks = append(checks, c)
+	}
+	sort.Slice(checks, func(i, j int) bool { return checks[i] < checks[j] })
+
+	rules := make([]sarifRule, 0, len(checks))
+	ruleIndex := make(map[CheckType]int, len(checks))
+	for i, c := range checks {
+		findings := byCheck[c]
+		worst := findings[0]
+		remediation := ""
+		for _, f := range findings {
+			if severityOrder(f.Severity) < severityOrder(worst.Severity) {
+				worst = f
+			}
+			if remediation == "" {
+				remediation = f.Remediation
+			}
+		}
+		if remediation == "" {
+			remediation = worst.Description
+		}
+
+		ruleIndex[c] = i
+		rules = append(rules, sarifRule{
+			ID:                   string(c),
+			Name:                 string(c),
+			ShortDescription:     sarifText{Text: worst.Title},
+			Help:                 sarifText{Text: remediation},
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevel(worst.Severity)},
+			Properties: sarifProperties{
+				Tags:             []string{"security", "network"},
+				SecuritySeverity: securitySeverity(worst.Severity),
+			},
+		})
+	}
+
+	sarifResults := make([]sarifResult, 0)
+	for _, r := range results {
+		for _, f := range r.Findings {
+			text := f.Title + ": " + f.Description
+			if f.Detail != "" {
+				text += " (" + f.Detail + ")"
+			}
+			loc := findingLocation(f, r.Target)
+			sarifResults = append(sarifResults, sarifResult{
+				RuleID:    string(f.Check),
+				RuleIndex: ruleIndex[f.Check],
+				Level:     sarifLevel(f.Severity),
+				Message:   sarifText{Text: text},
+				Locations: []sarifLocation{{
+					PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: loc}},
+					LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: loc, Kind: "endpoint"}},
+				}},
+				Properties: sarifProperties{
+					Severity:         f.Severity,
+					SecuritySeverity: securitySeverity(f.Severity),
+					Remediation:      f.Remediation,
+				},
+			})
+		}
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "nns netaudit",
+				InformationURI: "https://github.com/JedizLaPulga/NNS",
+				Rules:          rules,
+			}},
+			Results: sarifResults,
+		}},
+	}
+
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// findingLocation returns host:port for a finding, falling back to the
+// audit target when the check did not record a host.
+func findingLocation(f Finding, target string) string {
+	host := f.Host
+	if host == "" {
+		host = target
+	}
+	if f.Port > 0 {
+		return net.JoinHostPort(host, strconv.Itoa(f.Port))
+	}
+	return host
+}
+
+// sarifLevel maps a severity to a SARIF result level.
+func sarifLevel(s Severity) string {
+	switch s {
+	case SeverityCritical, SeverityHigh:
+		return "error"
+	case SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// securitySeverity maps a severity to the CVSS-like score GitHub code
+// scanning uses to rank security alerts.
+func securitySeverity(s Severity) string {
+	switch s {
+	case SeverityCritical:
+		return "9.5"
+	case SeverityHigh:
+		return "8.0"
+	case SeverityMedium:
+		return "5.5"
+	case SeverityLow:
+		return "3.0"
+	default:
+		return "0.0"
+	}
+}
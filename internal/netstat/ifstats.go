@@ -0,0 +1,167 @@
+package netstat
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GetInterfaces returns per-interface traffic counters together with each
+// interface's address, MAC and MTU. Counters are cumulative since boot (or
+// since the adapter was enabled); sample twice to compute throughput.
+func GetInterfaces() ([]Interface, error) {
+	var ifaces []Interface
+	var err error
+	switch runtime.GOOS {
+	case "linux":
+		var data []byte
+		data, err = os.ReadFile("/proc/net/dev")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read /proc/net/dev: %w", err)
+		}
+		ifaces, err = parseProcNetDev(string(data))
+	case "darwin":
+		var out []byte
+		out, err = exec.Command("netstat", "-ib").Output()
+		if err != nil {
+			return nil, fmt.Errorf("netstat -ib failed: %w", err)
+		}
+		ifaces, err = parseDarwinNetstatIB(string(out))
+	case "windows":
+		var out []byte
+		out, err = exec.Command("powershell", "-NoProfile", "-Command",
+			"Get-NetAdapterStatistics | Select-Object Name,ReceivedBytes,SentBytes,ReceivedUnicastPackets,SentUnicastPackets | ConvertTo-Csv -NoTypeInformation").Output()
+		if err != nil {
+			return nil, fmt.Errorf("Get-NetAdapterStatistics failed: %w", err)
+		}
+		ifaces, err = parseWindowsAdapterStats(string(out))
+	default:
+		return nil, fmt.Errorf("unsupported OS: %s", runtime.GOOS)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	addInterfaceDetails(ifaces)
+	sort.Slice(ifaces, func(i, j int) bool { return ifaces[i].Name < ifaces[j].Name })
+	return ifaces, nil
+}
+
+// parseProcNetDev parses Linux /proc/net/dev.
+func parseProcNetDev(output string) ([]Interface, error) {
+	ifaces := make([]Interface, 0)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+
+	for scanner.Scan() {
+		// Format: "  eth0: rx_bytes rx_packets ... tx_bytes tx_packets ..."
+		name, counters, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(counters)
+		if len(fields) < 16 {
+			continue
+		}
+
+		iface := Interface{Name: strings.TrimSpace(name)}
+		iface.BytesRecv, _ = strconv.ParseUint(fields[0], 10, 64)
+		iface.PacketRecv, _ = strconv.ParseUint(fields[1], 10, 64)
+		iface.BytesSent, _ = strconv.ParseUint(fields[8], 10, 64)
+		iface.PacketSent, _ = strconv.ParseUint(fields[9], 10, 64)
+		ifaces = append(ifaces, iface)
+	}
+
+	return ifaces, scanner.Err()
+}
+
+// parseDarwinNetstatIB parses macOS netstat -ib output. Each interface is
+// listed once per address; the <Link#N> row carries the interface totals.
+func parseDarwinNetstatIB(output string) ([]Interface, error) {
+	ifaces := make([]Interface, 0)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || !strings.HasPrefix(fields[2], "<Link#") {
+			continue
+		}
+
+		// Name Mtu Network [Address] Ipkts Ierrs Ibytes Opkts Oerrs Obytes Coll
+		// Interfaces without a MAC (lo0, utun) have no Address column.
+		c := fields[len(fields)-7:]
+		iface := Interface{Name: strings.TrimSuffix(fields[0], "*")}
+		iface.MTU, _ = strconv.Atoi(fields[1])
+		iface.PacketRecv, _ = strconv.ParseUint(c[0], 10, 64)
+		iface.BytesRecv, _ = strconv.ParseUint(c[2], 10, 64)
+		iface.PacketSent, _ = strconv.ParseUint(c[3], 10, 64)
+		iface.BytesSent, _ = strconv.ParseUint(c[5], 10, 64)
+		ifaces = append(ifaces, iface)
+	}
+
+	return ifaces, scanner.Err()
+}
+
+// parseWindowsAdapterStats parses Get-NetAdapterStatistics CSV output with
+// the columns Name, ReceivedBytes, SentBytes, ReceivedUnicastPackets and
+// SentUnicastPackets.
+func parseWindowsAdapterStats(output string) ([]Interface, error) {
+	records, err := csv.NewReader(strings.NewReader(strings.TrimSpace(output))).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid adapter statistics: %w", err)
+	}
+
+	ifaces := make([]Interface, 0)
+	for i, rec := range records {
+		if i == 0 || len(rec) < 5 {
+			continue // header
+		}
+		iface := Interface{Name: rec[0]}
+		iface.BytesRecv, _ = strconv.ParseUint(rec[1], 10, 64)
+		iface.BytesSent, _ = strconv.ParseUint(rec[2], 10, 64)
+		iface.PacketRecv, _ = strconv.ParseUint(rec[3], 10, 64)
+		iface.PacketSent, _ = strconv.ParseUint(rec[4], 10, 64)
+		ifaces = append(ifaces, iface)
+	}
+
+	return ifaces, nil
+}
+
+// addInterfaceDetails fills in IP, MAC and MTU from the OS interface list,
+// preferring an IPv4 address.
+func addInterfaceDetails(ifaces []Interface) {
+	for i := range ifaces {
+		ifi, err := net.InterfaceByName(ifaces[i].Name)
+		if err != nil {
+			continue
+		}
+		if ifaces[i].MTU == 0 {
+			ifaces[i].MTU = ifi.MTU
+		}
+		ifaces[i].MAC = ifi.HardwareAddr.String()
+
+		addrs, err := ifi.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			if ipNet.IP.To4() != nil {
+				ifaces[i].IP = ipNet.IP.String()
+				break
+			}
+			if ifaces[i].IP == "" {
+				ifaces[i].IP = ipNet.IP.String()
+			}
+		}
+	}
+}
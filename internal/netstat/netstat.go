@@ -4,6 +4,7 @@ package netstat
 import (
 	"bufio"
 	"fmt"
+	"net"
 	"os/exec"
 	"regexp"
 	"runtime"
@@ -45,18 +46,28 @@ type Interface struct {
 	PacketSent uint64
 }
 
-// GetConnections retrieves active network connections.
+// GetConnections retrieves active network connections. With showPID, the
+// owning PID and process name are filled in where the OS reports them.
 func GetConnections(showPID bool) ([]Connection, error) {
+	var conns []Connection
+	var err error
 	switch runtime.GOOS {
 	case "windows":
-		return getConnectionsWindows(showPID)
+		conns, err = getConnectionsWindows(showPID)
 	case "linux":
-		return getConnectionsLinux(showPID)
+		conns, err = getConnectionsLinux(showPID)
 	case "darwin":
-		return getConnectionsDarwin(showPID)
+		conns, err = getConnectionsDarwin(showPID)
 	default:
 		return nil, fmt.Errorf("unsupported OS: %s", runtime.GOOS)
 	}
+	if err != nil {
+		return nil, err
+	}
+	if showPID {
+		resolveProcessNames(conns)
+	}
+	return conns, nil
 }
 
 // GetRoutingTable retrieves the system routing table.
@@ -143,9 +154,11 @@ func parseWindowsNetstat(output string) ([]Connection, error) {
 
 // getConnectionsLinux retrieves connections on Linux.
 func getConnectionsLinux(showPID bool) ([]Connection, error) {
-	args := []string{"-tuln"}
+	// -a lists established and closing sockets too, like netstat on
+	// Windows and macOS; -l alone would hide them from the state filters.
+	args := []string{"-tuan"}
 	if showPID {
-		args = []string{"-tulnp"}
+		args = []string{"-tuanp"}
 	}
 	cmd := exec.Command("ss", args...)
 	output, err := cmd.Output()
@@ -160,6 +173,11 @@ func getConnectionsLinux(showPID bool) ([]Connection, error) {
 	return parseLinuxSS(string(output))
 }
 
+var (
+	ssUsersRe = regexp.MustCompile(`\("([^"]*)",pid=(\d+)`)
+	ssPIDRe   = regexp.MustCompile(`pid=(\d+)`)
+)
+
 // parseLinuxSS parses ss command output.
 func parseLinuxSS(output string) ([]Connection, error) {
 	connections := make([]Connection, 0)
@@ -178,7 +196,7 @@ func parseLinuxSS(output string) ([]Connection, error) {
 
 		conn := Connection{
 			Protocol: strings.ToLower(fields[0]),
-			State:    fields[1],
+			State:    normalizeState(fields[1]), // ss abbreviates, e.g. ESTAB
 		}
 
 		// Parse local address
@@ -193,11 +211,14 @@ func parseLinuxSS(output string) ([]Connection, error) {
 			conn.RemotePort = remotePort
 		}
 
-		// Parse PID/Process if present
+		// Parse PID/Process if present, e.g. users:(("sshd",pid=812,fd=3))
 		if len(fields) > 6 {
-			pidMatch := regexp.MustCompile(`pid=(\d+)`).FindStringSubmatch(fields[6])
-			if len(pidMatch) > 1 {
-				conn.PID, _ = strconv.Atoi(pidMatch[1])
+			users := strings.Join(fields[6:], " ")
+			if m := ssUsersRe.FindStringSubmatch(users); m != nil {
+				conn.Process = m[1]
+				conn.PID, _ = strconv.Atoi(m[2])
+			} else if m := ssPIDRe.FindStringSubmatch(users); m != nil {
+				conn.PID, _ = strconv.Atoi(m[1])
 			}
 		}
 
@@ -221,6 +242,8 @@ func getConnectionsDarwin(showPID bool) ([]Connection, error) {
 	return parseDarwinNetstat(string(output))
 }
 
+var darwinProcessRe = regexp.MustCompile(`^([^\d:][^:]*):(\d+)$`)
+
 // parseDarwinNetstat parses macOS netstat output.
 func parseDarwinNetstat(output string) ([]Connection, error) {
 	connections := make([]Connection, 0)
@@ -253,11 +276,20 @@ func parseDarwinNetstat(output string) ([]Connection, error) {
 		conn.RemoteAddr = remoteAddr
 		conn.RemotePort = remotePort
 
-		// State
-		if len(fields) > 5 {
+		// State (TCP only; UDP lines go straight to the counters)
+		if len(fields) > 5 && strings.HasPrefix(proto, "tcp") {
 			conn.State = fields[5]
 		}
 
+		// netstat -v on macOS 13+ reports the owner as "process:pid"
+		for _, f := range fields[5:] {
+			if m := darwinProcessRe.FindStringSubmatch(f); m != nil {
+				conn.Process = m[1]
+				conn.PID, _ = strconv.Atoi(m[2])
+				break
+			}
+		}
+
 		connections = append(connections, conn)
 	}
 
@@ -489,18 +521,101 @@ func FilterByProtocol(conns []Connection, protocol string) []Connection {
 	return filtered
 }
 
-// FilterByState filters connections by state.
+// FilterByState filters connections by state. States are compared after
+// normalization, so "ESTAB" (ss) and "LISTENING" (Windows) match
+// "ESTABLISHED" and "LISTEN".
 func FilterByState(conns []Connection, state string) []Connection {
 	filtered := make([]Connection, 0)
-	state = strings.ToUpper(state)
+	state = normalizeState(state)
 	for _, c := range conns {
-		if strings.ToUpper(c.State) == state {
+		if normalizeState(c.State) == state {
 			filtered = append(filtered, c)
 		}
 	}
 	return filtered
 }
 
+// FilterByPort filters connections whose local or remote port is port.
+func FilterByPort(conns []Connection, port int) []Connection {
+	filtered := make([]Connection, 0)
+	for _, c := range conns {
+		if c.LocalPort == port || c.RemotePort == port {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+// FilterByAddr filters connections whose local or remote address matches
+// addr, which may be an IP address or a CIDR.
+func FilterByAddr(conns []Connection, addr string) ([]Connection, error) {
+	var network *net.IPNet
+	if strings.Contains(addr, "/") {
+		_, n, err := net.ParseCIDR(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q: %w", addr, err)
+		}
+		network = n
+	} else {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid address %q", addr)
+		}
+		bits := 128
+		if ip.To4() != nil {
+			bits = 32
+		}
+		network = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+	}
+
+	matches := func(s string) bool {
+		ip := net.ParseIP(strings.SplitN(s, "%", 2)[0])
+		return ip != nil && network.Contains(ip)
+	}
+
+	filtered := make([]Connection, 0)
+	for _, c := range conns {
+		if matches(c.LocalAddr) || matches(c.RemoteAddr) {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered, nil
+}
+
+// stateAliases maps platform-specific TCP state names to the canonical
+// names used in output and filters.
+var stateAliases = map[string]string{
+	"ESTAB":        "ESTABLISHED",
+	"LISTENING":    "LISTEN",
+	"SYN_RECEIVED": "SYN_RECV",
+	"FIN_WAIT1":    "FIN_WAIT_1",
+	"FIN_WAIT2":    "FIN_WAIT_2",
+}
+
+// normalizeState upper-cases state, uses underscores (ss prints
+// "TIME-WAIT") and resolves aliases.
+func normalizeState(state string) string {
+	state = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(state), "-", "_"))
+	if canonical, ok := stateAliases[state]; ok {
+		return canonical
+	}
+	return state
+}
+
+// SummarizeStates counts connections per normalized state. Connections
+// without a state (UDP on Windows and macOS) are counted under "-".
+func SummarizeStates(conns []Connection) map[string]int {
+	counts := make(map[string]int)
+	for _, c := range conns {
+		state := normalizeState(c.State)
+		if state == "" {
+			state = "-"
+		}
+		counts[state]++
+	}
+	return counts
+}
+
 // GetListening returns only listening connections.
 func GetListening(conns []Connection) []Connection {
 	return FilterByState(conns, "LISTEN")
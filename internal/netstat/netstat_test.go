@@ -115,6 +115,73 @@ tcp    ESTAB   0       0        192.168.1.1:22       192.168.1.2:54321
 	}
 }
 
+func TestParseLinuxSSProcess(t *testing.T) {
+	output := `Netid State  Recv-Q Send-Q Local Address:Port  Peer Address:PortProcess
+tcp   LISTEN 0      128          0.0.0.0:22         0.0.0.0:*    users:(("sshd",pid=812,fd=3))
+udp   UNCONN 0      0            0.0.0.0:5353       0.0.0.0:*    users:(("avahi-daemon",pid=640,fd=12),("avahi-daemon",pid=641,fd=12))
+tcp   LISTEN 0      128          0.0.0.0:8080       0.0.0.0:*
+`
+	conns, err := parseLinuxSS(output)
+	if err != nil {
+		t.Fatalf("parseLinuxSS() error = %v", err)
+	}
+	if len(conns) != 3 {
+		t.Fatalf("parseLinuxSS() = %d connections, want 3", len(conns))
+	}
+	if conns[0].PID != 812 || conns[0].Process != "sshd" {
+		t.Errorf("conns[0] PID/Process = %d/%q, want 812/sshd", conns[0].PID, conns[0].Process)
+	}
+	if conns[1].PID != 640 || conns[1].Process != "avahi-daemon" {
+		t.Errorf("conns[1] PID/Process = %d/%q, want 640/avahi-daemon", conns[1].PID, conns[1].Process)
+	}
+	if conns[2].PID != 0 || conns[2].Process != "" {
+		t.Errorf("conns[2] PID/Process = %d/%q, want none", conns[2].PID, conns[2].Process)
+	}
+}
+
+func TestParseDarwinNetstatProcess(t *testing.T) {
+	output := `Active Internet connections (including servers)
+Proto Recv-Q Send-Q  Local Address          Foreign Address        (state)      rxbytes      txbytes  rhiwat  shiwat    process:pid    state  options
+tcp4       0      0  *.22                   *.*                    LISTEN             0            0  131072  131072      launchd:1  00000 00000006
+udp4       0      0  *.5353                 *.*                                       0            0  786896    9216  mDNSResponder:412  00000 00000000
+`
+	conns, err := parseDarwinNetstat(output)
+	if err != nil {
+		t.Fatalf("parseDarwinNetstat() error = %v", err)
+	}
+	if len(conns) != 2 {
+		t.Fatalf("parseDarwinNetstat() = %d connections, want 2", len(conns))
+	}
+	if conns[0].State != "LISTEN" || conns[0].PID != 1 || conns[0].Process != "launchd" {
+		t.Errorf("conns[0] = %+v", conns[0])
+	}
+	if conns[1].State != "" || conns[1].PID != 412 || conns[1].Process != "mDNSResponder" {
+		t.Errorf("conns[1] = %+v", conns[1])
+	}
+}
+
+func TestParseTasklist(t *testing.T) {
+	output := `"System Idle Process","0","Services","0","8 K"
+"svchost.exe","1234","Services","0","12,345 K"
+"chrome.exe","5678","Console","1","150,000 K"
+`
+	names := parseTasklist(output)
+	if names[1234] != "svchost.exe" || names[5678] != "chrome.exe" {
+		t.Errorf("parseTasklist() = %v", names)
+	}
+}
+
+func TestParsePS(t *testing.T) {
+	output := `    1 /sbin/launchd
+  412 /usr/sbin/mDNSResponder
+ 9001 /Applications/Visual Studio Code.app/Contents/MacOS/Electron
+`
+	names := parsePS(output)
+	if names[1] != "launchd" || names[412] != "mDNSResponder" || names[9001] != "Electron" {
+		t.Errorf("parsePS() = %v", names)
+	}
+}
+
 func TestParseWindowsRoute(t *testing.T) {
 	output := `
 ===========================================================================
@@ -150,3 +217,153 @@ func TestParseLinuxIPRoute(t *testing.T) {
 		t.Errorf("parseLinuxIPRoute() = %d entries, want 2", len(entries))
 	}
 }
+
+func TestParseProcNetDev(t *testing.T) {
+	output := `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo:  123456     100    0    0    0     0          0         0   123456     100    0    0    0     0       0          0
+  eth0: 9876543    7000    0    0    0     0          0         0  1234567    5000    0    0    0     0       0          0
+`
+	ifaces, err := parseProcNetDev(output)
+	if err != nil {
+		t.Fatalf("parseProcNetDev() error = %v", err)
+	}
+	if len(ifaces) != 2 {
+		t.Fatalf("parseProcNetDev() = %d interfaces, want 2", len(ifaces))
+	}
+	eth := ifaces[1]
+	if eth.Name != "eth0" || eth.BytesRecv != 9876543 || eth.PacketRecv != 7000 || eth.BytesSent != 1234567 || eth.PacketSent != 5000 {
+		t.Errorf("eth0 = %+v", eth)
+	}
+}
+
+func TestParseDarwinNetstatIB(t *testing.T) {
+	output := `Name       Mtu   Network       Address            Ipkts Ierrs     Ibytes    Opkts Oerrs     Obytes  Coll
+lo0        16384 <Link#1>                         52000     0   9100000    52000     0    9100000     0
+lo0        16384 127           localhost          52000     -   9100000    52000     -    9100000     -
+en0        1500  <Link#6>    a4:83:e7:12:34:56  1200000     0 1500000000   800000     0  120000000     0
+en0        1500  192.168.1     192.168.1.20      1200000     - 1500000000   800000     -  120000000     -
+`
+	ifaces, err := parseDarwinNetstatIB(output)
+	if err != nil {
+		t.Fatalf("parseDarwinNetstatIB() error = %v", err)
+	}
+	if len(ifaces) != 2 {
+		t.Fatalf("parseDarwinNetstatIB() = %d interfaces, want 2", len(ifaces))
+	}
+	if ifaces[0].Name != "lo0" || ifaces[0].MTU != 16384 || ifaces[0].BytesRecv != 9100000 {
+		t.Errorf("lo0 = %+v", ifaces[0])
+	}
+	en := ifaces[1]
+	if en.BytesRecv != 1500000000 || en.BytesSent != 120000000 || en.PacketRecv != 1200000 || en.PacketSent != 800000 {
+		t.Errorf("en0 = %+v", en)
+	}
+}
+
+func TestParseWindowsAdapterStats(t *testing.T) {
+	output := `"Name","ReceivedBytes","SentBytes","ReceivedUnicastPackets","SentUnicastPackets"
+"Ethernet","987654321","12345678","700000","500000"
+"Wi-Fi","0","0","0","0"
+`
+	ifaces, err := parseWindowsAdapterStats(output)
+	if err != nil {
+		t.Fatalf("parseWindowsAdapterStats() error = %v", err)
+	}
+	if len(ifaces) != 2 {
+		t.Fatalf("parseWindowsAdapterStats() = %d interfaces, want 2", len(ifaces))
+	}
+	if ifaces[0].Name != "Ethernet" || ifaces[0].BytesRecv != 987654321 || ifaces[0].PacketSent != 500000 {
+		t.Errorf("Ethernet = %+v", ifaces[0])
+	}
+}
+
+func TestFilterByStateAliases(t *testing.T) {
+	conns := []Connection{
+		{State: "ESTAB"},
+		{State: "ESTABLISHED"},
+		{State: "LISTENING"},
+		{State: "TIME-WAIT"},
+	}
+
+	if got := FilterByState(conns, "established"); len(got) != 2 {
+		t.Errorf("FilterByState(established) = %d, want 2", len(got))
+	}
+	if got := GetListening(conns); len(got) != 1 {
+		t.Errorf("GetListening() = %d, want 1", len(got))
+	}
+	if got := FilterByState(conns, "TIME_WAIT"); len(got) != 1 {
+		t.Errorf("FilterByState(TIME_WAIT) = %d, want 1", len(got))
+	}
+}
+
+func TestFilterByPort(t *testing.T) {
+	conns := []Connection{
+		{LocalAddr: "0.0.0.0", LocalPort: 5432, State: "LISTEN"},
+		{LocalAddr: "10.0.0.5", LocalPort: 5432, RemoteAddr: "10.0.0.9", RemotePort: 40122},
+		{LocalAddr: "10.0.0.5", LocalPort: 40200, RemoteAddr: "10.0.0.7", RemotePort: 5432},
+		{LocalAddr: "10.0.0.5", LocalPort: 22},
+	}
+
+	if got := FilterByPort(conns, 5432); len(got) != 3 {
+		t.Errorf("FilterByPort(5432) = %d, want 3", len(got))
+	}
+	if got := FilterByState(FilterByPort(conns, 5432), "LISTEN"); len(got) != 1 {
+		t.Errorf("FilterByPort+FilterByState = %d, want 1", len(got))
+	}
+}
+
+func TestFilterByAddr(t *testing.T) {
+	conns := []Connection{
+		{LocalAddr: "10.0.0.5", RemoteAddr: "10.0.0.9"},
+		{LocalAddr: "10.0.0.5", RemoteAddr: "192.168.1.20"},
+		{LocalAddr: "::1", RemoteAddr: "::1"},
+		{LocalAddr: "fe80::1%eth0", RemoteAddr: ""},
+	}
+
+	tests := []struct {
+		addr string
+		want int
+	}{
+		{"10.0.0.9", 1},
+		{"10.0.0.5", 2},
+		{"192.168.0.0/16", 1},
+		{"::1", 1},
+		{"fe80::/10", 1},
+	}
+	for _, tt := range tests {
+		got, err := FilterByAddr(conns, tt.addr)
+		if err != nil {
+			t.Fatalf("FilterByAddr(%q) error = %v", tt.addr, err)
+		}
+		if len(got) != tt.want {
+			t.Errorf("FilterByAddr(%q) = %d, want %d", tt.addr, len(got), tt.want)
+		}
+	}
+
+	if _, err := FilterByAddr(conns, "not-an-ip"); err == nil {
+		t.Error("FilterByAddr(not-an-ip) should fail")
+	}
+}
+
+func TestSummarizeStates(t *testing.T) {
+	conns := []Connection{
+		{Protocol: "tcp", State: "ESTAB"},
+		{Protocol: "tcp", State: "ESTABLISHED"},
+		{Protocol: "tcp", State: "TIME-WAIT"},
+		{Protocol: "tcp", State: "TIME_WAIT"},
+		{Protocol: "tcp", State: "TIME_WAIT"},
+		{Protocol: "tcp", State: "LISTENING"},
+		{Protocol: "udp"},
+	}
+
+	got := SummarizeStates(conns)
+	want := map[string]int{"ESTABLISHED": 2, "TIME_WAIT": 3, "LISTEN": 1, "-": 1}
+	if len(got) != len(want) {
+		t.Fatalf("SummarizeStates() = %v, want %v", got, want)
+	}
+	for state, n := range want {
+		if got[state] != n {
+			t.Errorf("SummarizeStates()[%s] = %d, want %d", state, got[state], n)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package netstat
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// resolveProcessNames fills in Connection.Process for connections that have
+// a PID but no name yet.
+func resolveProcessNames(conns []Connection) {
+	missing := false
+	for _, c := range conns {
+		if c.PID > 0 && c.Process == "" {
+			missing = true
+			break
+		}
+	}
+	if !missing {
+		return
+	}
+
+	var names map[int]string
+	switch runtime.GOOS {
+	case "linux":
+		names = linuxProcessNames(conns)
+	case "windows":
+		if out, err := exec.Command("tasklist", "/FO", "CSV", "/NH").Output(); err == nil {
+			names = parseTasklist(string(out))
+		}
+	case "darwin":
+		if out, err := exec.Command("ps", "-axo", "pid=,comm=").Output(); err == nil {
+			names = parsePS(string(out))
+		}
+	}
+
+	for i := range conns {
+		if conns[i].Process == "" {
+			conns[i].Process = names[conns[i].PID]
+		}
+	}
+}
+
+// linuxProcessNames reads /proc/<pid>/comm for each PID in conns.
+func linuxProcessNames(conns []Connection) map[int]string {
+	names := make(map[int]string)
+	for _, c := range conns {
+		if c.PID <= 0 {
+			continue
+		}
+		if _, ok := names[c.PID]; ok {
+			continue
+		}
+		data, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", c.PID))
+		if err != nil {
+			names[c.PID] = ""
+			continue
+		}
+		names[c.PID] = strings.TrimSpace(string(data))
+	}
+	return names
+}
+
+// parseTasklist parses Windows `tasklist /FO CSV /NH` output, e.g.
+// "svchost.exe","1234","Services","0","12,345 K".
+func parseTasklist(output string) map[int]string {
+	names := make(map[int]string)
+	r := csv.NewReader(strings.NewReader(output))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return names
+	}
+	for _, rec := range records {
+		if len(rec) < 2 {
+			continue
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(rec[1]))
+		if err != nil {
+			continue
+		}
+		names[pid] = rec[0]
+	}
+	return names
+}
+
+// parsePS parses `ps -axo pid=,comm=` output. macOS reports the full
+// executable path, so only the base name is kept.
+func parsePS(output string) map[int]string {
+	names := make(map[int]string)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		idx := strings.IndexAny(line, " \t")
+		if idx == -1 {
+			continue
+		}
+		pid, err := strconv.Atoi(line[:idx])
+		if err != nil {
+			continue
+		}
+		names[pid] = filepath.Base(strings.TrimSpace(line[idx:]))
+	}
+	return names
+}
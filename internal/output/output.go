@@ -0,0 +1,182 @@
+// Package output provides a shared stdout writer that handles JSON output
+// and ANSI coloring consistently across commands.
+package output
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Color is an ANSI SGR color sequence.
+type Color string
+
+// ANSI colors used by the CLI.
+const (
+	Green  Color = "\033[32m"
+	Yellow Color = "\033[33m"
+	Red    Color = "\033[31m"
+	Reset  Color = "\033[0m"
+)
+
+// Options configures a Writer.
+type Options struct {
+	JSON    bool // Global --json
+	NoColor bool // Global --no-color
+}
+
+// Writer writes command output, coloring it only when the destination is a
+// terminal and the user has not opted out.
+type Writer struct {
+	out   io.Writer
+	json  bool
+	color bool
+}
+
+// Stdout is the process-wide writer, set up by Configure.
+var Stdout = New(os.Stdout, Options{})
+
+// Configure replaces Stdout with a writer honoring opts.
+func Configure(opts Options) {
+	Stdout = New(os.Stdout, opts)
+}
+
+// New returns a Writer for w. Color is enabled only when w is a terminal,
+// opts.NoColor is false, NO_COLOR is unset or empty and TERM is not "dumb".
+func New(w io.Writer, opts Options) *Writer {
+	return &Writer{
+		out:   w,
+		json:  opts.JSON,
+		color: !opts.NoColor && colorSupported(w),
+	}
+}
+
+// colorSupported reports whether w is a color-capable terminal.
+func colorSupported(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// JSONMode reports whether the global --json flag was given.
+func (w *Writer) JSONMode() bool { return w.json }
+
+// ColorEnabled reports whether ANSI colors are written.
+func (w *Writer) ColorEnabled() bool { return w.color }
+
+// Printf writes formatted output.
+func (w *Writer) Printf(format string, args ...any) {
+	fmt.Fprintf(w.out, format, args...)
+}
+
+// Println writes its arguments followed by a newline.
+func (w *Writer) Println(args ...any) {
+	fmt.Fprintln(w.out, args...)
+}
+
+// JSON writes v as indented JSON followed by a newline.
+func (w *Writer) JSON(v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w.out, string(data))
+	return err
+}
+
+// Paint wraps s in color c, or returns s unchanged when color is disabled.
+func (w *Writer) Paint(c Color, s string) string {
+	if !w.color || s == "" {
+		return s
+	}
+	return string(c) + s + string(Reset)
+}
+
+// GradeColor returns the color for a letter grade: green for A, yellow for
+// B and C, red otherwise.
+func GradeColor(grade string) Color {
+	switch {
+	case strings.HasPrefix(grade, "A"):
+		return Green
+	case strings.HasPrefix(grade, "B"), strings.HasPrefix(grade, "C"):
+		return Yellow
+	default:
+		return Red
+	}
+}
+
+// Grade returns a letter grade painted by GradeColor.
+func (w *Writer) Grade(grade string) string {
+	return w.Paint(GradeColor(grade), grade)
+}
+
+// StatusColor returns the color for an HTTP status code: green for 2xx,
+// yellow for 3xx, red otherwise.
+func StatusColor(code int) Color {
+	switch {
+	case code >= 200 && code < 300:
+		return Green
+	case code >= 300 && code < 400:
+		return Yellow
+	default:
+		return Red
+	}
+}
+
+// Status returns s painted by the StatusColor of code.
+func (w *Writer) Status(code int, s string) string {
+	return w.Paint(StatusColor(code), s)
+}
+
+// ParseGlobalFlags removes the global --json and --no-color flags from args
+// (the arguments after the program name). Both are accepted before the
+// command name; --no-color is also accepted anywhere after it since no
+// command defines its own. Arguments after a "--" terminator are left alone.
+func ParseGlobalFlags(args []string) ([]string, Options) {
+	var opts Options
+	rest := make([]string, 0, len(args))
+	command := false
+	for i, arg := range args {
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		switch {
+		case arg == "--no-color" || arg == "-no-color":
+			opts.NoColor = true
+		case !command && (arg == "--json" || arg == "-json"):
+			opts.JSON = true
+		default:
+			if !strings.HasPrefix(arg, "-") {
+				command = true
+			}
+			rest = append(rest, arg)
+		}
+	}
+	return rest, opts
+}
+
+// ApplyJSON forwards the global --json to fs by setting its own --json flag.
+// Commands without JSON output reject it instead of failing on an unknown
+// flag. It does nothing when enabled is false.
+func ApplyJSON(fs *flag.FlagSet, enabled bool) error {
+	if !enabled {
+		return nil
+	}
+	if fs.Lookup("json") == nil {
+		return fmt.Errorf("%s: command does not support --json", fs.Name())
+	}
+	return fs.Set("json", "true")
+}
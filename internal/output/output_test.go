@@ -0,0 +1,130 @@
+package output
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestNewColorDisabledForNonTerminal(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	var buf bytes.Buffer
+	w := New(&buf, Options{})
+	if w.ColorEnabled() {
+		t.Error("color should be disabled for a non-terminal writer")
+	}
+	if got := w.Grade("A+"); got != "A+" {
+		t.Errorf("Grade() = %q, want plain A+", got)
+	}
+}
+
+func TestPaint(t *testing.T) {
+	w := &Writer{color: true}
+	if got := w.Paint(Green, "ok"); got != "\033[32mok\033[0m" {
+		t.Errorf("Paint() = %q", got)
+	}
+	if got := w.Paint(Green, ""); got != "" {
+		t.Errorf("Paint() of empty string = %q, want empty", got)
+	}
+	w.color = false
+	if got := w.Paint(Red, "bad"); got != "bad" {
+		t.Errorf("Paint() without color = %q, want bad", got)
+	}
+}
+
+func TestGradeColor(t *testing.T) {
+	tests := []struct {
+		grade string
+		want  Color
+	}{
+		{"A+", Green},
+		{"A", Green},
+		{"B", Yellow},
+		{"C", Yellow},
+		{"D", Red},
+		{"F", Red},
+		{"", Red},
+	}
+	for _, tt := range tests {
+		if got := GradeColor(tt.grade); got != tt.want {
+			t.Errorf("GradeColor(%q) = %q, want %q", tt.grade, got, tt.want)
+		}
+	}
+}
+
+func TestStatusColor(t *testing.T) {
+	tests := []struct {
+		code int
+		want Color
+	}{
+		{200, Green},
+		{204, Green},
+		{301, Yellow},
+		{404, Red},
+		{500, Red},
+		{0, Red},
+	}
+	for _, tt := range tests {
+		if got := StatusColor(tt.code); got != tt.want {
+			t.Errorf("StatusColor(%d) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+	w := New(&buf, Options{JSON: true})
+	if !w.JSONMode() {
+		t.Error("JSONMode() = false, want true")
+	}
+	if err := w.JSON(map[string]int{"count": 3}); err != nil {
+		t.Fatalf("JSON: %v", err)
+	}
+	if got := buf.String(); got != "{\n  \"count\": 3\n}\n" {
+		t.Errorf("JSON output = %q", got)
+	}
+}
+
+func TestParseGlobalFlags(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    string
+		json    bool
+		noColor bool
+	}{
+		{[]string{"ssl", "example.com"}, "ssl example.com", false, false},
+		{[]string{"--json", "ssl", "example.com"}, "ssl example.com", true, false},
+		{[]string{"--no-color", "--json", "ssl"}, "ssl", true, true},
+		{[]string{"ssl", "--no-color", "example.com"}, "ssl example.com", false, true},
+		// --json after the command belongs to the command
+		{[]string{"ssl", "--json", "example.com"}, "ssl --json example.com", false, false},
+		{[]string{"jwt", "--", "--no-color"}, "jwt -- --no-color", false, false},
+	}
+	for _, tt := range tests {
+		rest, opts := ParseGlobalFlags(tt.args)
+		if got := strings.Join(rest, " "); got != tt.want || opts.JSON != tt.json || opts.NoColor != tt.noColor {
+			t.Errorf("ParseGlobalFlags(%q) = %q %+v, want %q json=%v noColor=%v",
+				tt.args, got, opts, tt.want, tt.json, tt.noColor)
+		}
+	}
+}
+
+func TestApplyJSON(t *testing.T) {
+	withJSON := flag.NewFlagSet("ssl", flag.ContinueOnError)
+	jsonFlag := withJSON.Bool("json", false, "Output in JSON format")
+	if err := ApplyJSON(withJSON, true); err != nil || !*jsonFlag {
+		t.Errorf("ApplyJSON() = %v, json = %v; want the command's --json set", err, *jsonFlag)
+	}
+
+	// cidr has no JSON output
+	withoutJSON := flag.NewFlagSet("cidr", flag.ContinueOnError)
+	withoutJSON.Bool("v", false, "Verbose")
+	err := ApplyJSON(withoutJSON, true)
+	if err == nil || !strings.Contains(err.Error(), "cidr: command does not support --json") {
+		t.Errorf("ApplyJSON() without a json flag = %v, want unsupported error", err)
+	}
+	if err := ApplyJSON(withoutJSON, false); err != nil {
+		t.Errorf("ApplyJSON() without global --json = %v, want nil", err)
+	}
+}
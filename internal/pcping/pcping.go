@@ -1,16 +1,20 @@
-// Package pcping provides protocol-aware ping using TCP, UDP, HTTP, and DNS probes.
+// Package pcping provides protocol-aware ping using TCP, UDP, HTTP, DNS, TLS, and QUIC probes.
 package pcping
 
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
+	"math/rand"
 	"net"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -22,10 +26,13 @@ const (
 	ProtoUDP  Protocol = "udp"
 	ProtoHTTP Protocol = "http"
 	ProtoDNS  Protocol = "dns"
+	ProtoTLS  Protocol = "tls"
+	ProtoQUIC Protocol = "quic"
 )
 
 // ProbeResult represents the result of a single probe.
 type ProbeResult struct {
+	Target   string // Host being probed
 	Seq      int
 	Success  bool
 	Error    error
@@ -37,21 +44,29 @@ type ProbeResult struct {
 
 // Statistics holds aggregate statistics for probe results.
 type Statistics struct {
-	Protocol    Protocol
-	Sent        int
-	Received    int
-	Lost        int
-	LossPercent float64
-	MinRTT      time.Duration
-	MaxRTT      time.Duration
-	AvgRTT      time.Duration
-	MedianRTT   time.Duration
-	StdDev      time.Duration
-	P95         time.Duration
-	P99         time.Duration
-	AllRTTs     []time.Duration
+	Protocol    Protocol        `json:"protocol"`
+	Sent        int             `json:"sent"`
+	Received    int             `json:"received"`
+	Lost        int             `json:"lost"`
+	LossPercent float64         `json:"loss_percent"`
+	MinRTT      time.Duration   `json:"min_rtt"`
+	MaxRTT      time.Duration   `json:"max_rtt"`
+	AvgRTT      time.Duration   `json:"avg_rtt"`
+	MedianRTT   time.Duration   `json:"median_rtt"`
+	StdDev      time.Duration   `json:"std_dev"`
+	P95         time.Duration   `json:"p95"`
+	P99         time.Duration   `json:"p99"`
+	AllRTTs     []time.Duration `json:"rtts"` // Up to maxRTTSamples, a uniform sample once full
+
+	rttSum   float64 // nanoseconds, over every successful probe
+	rttSumSq float64
 }
 
+// maxRTTSamples caps AllRTTs so a continuous run uses bounded memory. Past
+// the cap, the median and percentiles come from a uniform random sample;
+// min, max, average and standard deviation stay exact.
+const maxRTTSamples = 10000
+
 // NewStatistics creates an empty Statistics struct.
 func NewStatistics(proto Protocol) *Statistics {
 	return &Statistics{
@@ -65,7 +80,22 @@ func (s *Statistics) Add(r ProbeResult) {
 	s.Sent++
 	if r.Success {
 		s.Received++
-		s.AllRTTs = append(s.AllRTTs, r.RTT)
+		if s.Received == 1 || r.RTT < s.MinRTT {
+			s.MinRTT = r.RTT
+		}
+		if r.RTT > s.MaxRTT {
+			s.MaxRTT = r.RTT
+		}
+		ns := float64(r.RTT.Nanoseconds())
+		s.rttSum += ns
+		s.rttSumSq += ns * ns
+
+		// Reservoir sampling keeps every RTT equally likely to be stored.
+		if len(s.AllRTTs) < maxRTTSamples {
+			s.AllRTTs = append(s.AllRTTs, r.RTT)
+		} else if i := rand.Intn(s.Received); i < maxRTTSamples {
+			s.AllRTTs[i] = r.RTT
+		}
 	} else {
 		s.Lost++
 	}
@@ -87,14 +117,8 @@ func (s *Statistics) Calculate() {
 	copy(sorted, s.AllRTTs)
 	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
 
-	s.MinRTT = sorted[0]
-	s.MaxRTT = sorted[len(sorted)-1]
-
-	var sum time.Duration
-	for _, rtt := range sorted {
-		sum += rtt
-	}
-	s.AvgRTT = sum / time.Duration(len(sorted))
+	mean := s.rttSum / float64(s.Received)
+	s.AvgRTT = time.Duration(mean)
 
 	// Median
 	n := len(sorted)
@@ -123,14 +147,17 @@ func (s *Statistics) Calculate() {
 	}
 
 	// Standard deviation
-	var variance float64
-	avgNs := float64(s.AvgRTT.Nanoseconds())
-	for _, rtt := range sorted {
-		diff := float64(rtt.Nanoseconds()) - avgNs
-		variance += diff * diff
-	}
-	variance /= float64(len(sorted))
-	s.StdDev = time.Duration(math.Sqrt(variance))
+	variance := s.rttSumSq/float64(s.Received) - mean*mean
+	s.StdDev = time.Duration(math.Sqrt(math.Max(variance, 0)))
+}
+
+// ToJSON returns the statistics as indented JSON.
+func (s *Statistics) ToJSON() (string, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
 }
 
 // Quality returns a human-readable quality string.
@@ -160,14 +187,15 @@ func (s *Statistics) Quality() string {
 
 // Options configures the protocol-aware pinger.
 type Options struct {
-	Host     string
-	Port     int
-	Protocol Protocol
-	Count    int
-	Interval time.Duration
-	Timeout  time.Duration
-	UseTLS   bool
-	HTTPPath string // Path for HTTP probes
+	Host       string
+	Port       int
+	Protocol   Protocol
+	Count      int  // Number of probes (default 5)
+	Continuous bool // Probe until the context is cancelled, ignoring Count
+	Interval   time.Duration
+	Timeout    time.Duration
+	UseTLS     bool
+	HTTPPath   string // Path for HTTP probes
 }
 
 // DefaultOptions returns sensible defaults.
@@ -205,6 +233,8 @@ func NewPinger(opts Options) *Pinger {
 			opts.Port = 80
 		case ProtoDNS:
 			opts.Port = 53
+		case ProtoTLS, ProtoQUIC:
+			opts.Port = 443
 		default:
 			opts.Port = 80
 		}
@@ -224,8 +254,10 @@ func (p *Pinger) Port() int {
 }
 
 // Run executes the probe sequence, calling the callback for each result.
+// With Continuous set it probes until ctx is cancelled. Statistics are
+// calculated before returning in either case.
 func (p *Pinger) Run(ctx context.Context, callback func(ProbeResult)) error {
-	for seq := 1; seq <= p.opts.Count; seq++ {
+	for seq := 1; p.opts.Continuous || seq <= p.opts.Count; seq++ {
 		select {
 		case <-ctx.Done():
 			p.Stats.Calculate()
@@ -243,6 +275,10 @@ func (p *Pinger) Run(ctx context.Context, callback func(ProbeResult)) error {
 			result = p.probeHTTP(seq)
 		case ProtoDNS:
 			result = p.probeDNS(seq)
+		case ProtoTLS:
+			result = p.probeTLS(seq)
+		case ProtoQUIC:
+			result = p.probeQUIC(seq)
 		default:
 			result = ProbeResult{
 				Seq:      seq,
@@ -251,10 +287,11 @@ func (p *Pinger) Run(ctx context.Context, callback func(ProbeResult)) error {
 			}
 		}
 
+		result.Target = p.opts.Host
 		p.Stats.Add(result)
 		callback(result)
 
-		if seq < p.opts.Count {
+		if p.opts.Continuous || seq < p.opts.Count {
 			select {
 			case <-ctx.Done():
 				p.Stats.Calculate()
@@ -268,6 +305,72 @@ func (p *Pinger) Run(ctx context.Context, callback func(ProbeResult)) error {
 	return nil
 }
 
+// TargetStats pairs a target with its final statistics.
+type TargetStats struct {
+	Target string      `json:"target"`
+	Port   int         `json:"port"`
+	Stats  *Statistics `json:"statistics"`
+}
+
+// RunMulti probes several targets concurrently, each with its own options.
+// The callback receives results from all targets, one at a time, with
+// ProbeResult.Target identifying the source. Statistics are returned in the
+// order of opts once every pinger finishes or ctx is cancelled.
+func RunMulti(ctx context.Context, opts []Options, callback func(ProbeResult)) ([]TargetStats, error) {
+	pingers := make([]*Pinger, len(opts))
+	for i, o := range opts {
+		pingers[i] = NewPinger(o)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, p := range pingers {
+		wg.Add(1)
+		go func(p *Pinger) {
+			defer wg.Done()
+			p.Run(ctx, func(r ProbeResult) {
+				mu.Lock()
+				defer mu.Unlock()
+				callback(r)
+			})
+		}(p)
+	}
+	wg.Wait()
+
+	stats := make([]TargetStats, len(pingers))
+	for i, p := range pingers {
+		stats[i] = TargetStats{Target: p.opts.Host, Port: p.opts.Port, Stats: p.Stats}
+	}
+	return stats, ctx.Err()
+}
+
+// FormatComparison returns a side-by-side summary of several targets.
+func FormatComparison(targets []TargetStats) string {
+	var sb strings.Builder
+
+	width := len("TARGET")
+	for _, t := range targets {
+		if n := len(net.JoinHostPort(t.Target, strconv.Itoa(t.Port))); n > width {
+			width = n
+		}
+	}
+
+	sb.WriteString("\n--- comparative ping statistics ---\n")
+	sb.WriteString(fmt.Sprintf("%-*s  %-5s  %4s  %6s  %10s  %10s  %10s  %s\n",
+		width, "TARGET", "PROTO", "SENT", "LOSS", "MIN", "AVG", "MAX", "QUALITY"))
+	for _, t := range targets {
+		s := t.Stats
+		sb.WriteString(fmt.Sprintf("%-*s  %-5s  %4d  %5.1f%%  %10v  %10v  %10v  %s\n",
+			width, net.JoinHostPort(t.Target, strconv.Itoa(t.Port)), s.Protocol, s.Sent, s.LossPercent,
+			s.MinRTT.Round(time.Microsecond),
+			s.AvgRTT.Round(time.Microsecond),
+			s.MaxRTT.Round(time.Microsecond),
+			s.Quality()))
+	}
+
+	return sb.String()
+}
+
 // probeTCP performs a TCP connect probe.
 func (p *Pinger) probeTCP(seq int) ProbeResult {
 	result := ProbeResult{
@@ -310,6 +413,47 @@ func (p *Pinger) probeTCP(seq int) ProbeResult {
 	return result
 }
 
+// probeTLS connects and completes a TLS handshake, reporting the handshake
+// time (excluding the TCP connect) as the RTT.
+func (p *Pinger) probeTLS(seq int) ProbeResult {
+	result := ProbeResult{
+		Seq:      seq,
+		Protocol: ProtoTLS,
+	}
+
+	addr := net.JoinHostPort(p.opts.Host, strconv.Itoa(p.opts.Port))
+	start := time.Now()
+
+	dialer := &net.Dialer{Timeout: p.opts.Timeout}
+	conn, err := dialer.Dial("tcp", addr)
+	if err != nil {
+		result.Error = err
+		result.RTT = time.Since(start)
+		return result
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(start.Add(p.opts.Timeout))
+	tlsConn := tls.Client(conn, &tls.Config{
+		ServerName:         p.opts.Host,
+		InsecureSkipVerify: true,
+	})
+
+	hsStart := time.Now()
+	err = tlsConn.Handshake()
+	result.RTT = time.Since(hsStart)
+	if err != nil {
+		result.Error = fmt.Errorf("TLS handshake: %w", err)
+		return result
+	}
+
+	state := tlsConn.ConnectionState()
+	result.Success = true
+	result.Detail = fmt.Sprintf("TLS %s %s", tlsVersionString(state.Version), tls.CipherSuiteName(state.CipherSuite))
+	result.Addr = addr
+	return result
+}
+
 // probeUDP sends a UDP packet and waits for a response or ICMP unreachable.
 func (p *Pinger) probeUDP(seq int) ProbeResult {
 	result := ProbeResult{
@@ -2,11 +2,18 @@ package pcping
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"log"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
+
+	"golang.org/x/net/quic"
 )
 
 func TestDefaultOptions(t *testing.T) {
@@ -37,7 +44,7 @@ func TestNewPinger(t *testing.T) {
 	pinger := NewPinger(opts)
 
 	if pinger.opts.Count != 5 {
-		t.Errorf("expected default count 5, got %d", pinger.opts.Count)
+		t.Errorf("expected zero count to default to 5, got %d", pinger.opts.Count)
 	}
 	if pinger.opts.Interval != 1*time.Second {
 		t.Errorf("expected default interval 1s, got %v", pinger.opts.Interval)
@@ -50,6 +57,13 @@ func TestNewPinger(t *testing.T) {
 	}
 }
 
+func TestNewPingerNegativeCount(t *testing.T) {
+	pinger := NewPinger(Options{Host: "example.com", Count: -1})
+	if pinger.opts.Count != 5 {
+		t.Errorf("expected negative count to default to 5, got %d", pinger.opts.Count)
+	}
+}
+
 func TestNewPingerDNSPort(t *testing.T) {
 	pinger := NewPinger(Options{Host: "8.8.8.8", Protocol: ProtoDNS})
 	if pinger.opts.Port != 53 {
@@ -64,6 +78,13 @@ func TestNewPingerHTTPPort(t *testing.T) {
 	}
 }
 
+func TestNewPingerTLSPort(t *testing.T) {
+	pinger := NewPinger(Options{Host: "example.com", Protocol: ProtoTLS})
+	if pinger.opts.Port != 443 {
+		t.Errorf("expected TLS default port 443, got %d", pinger.opts.Port)
+	}
+}
+
 func TestNewStatistics(t *testing.T) {
 	stats := NewStatistics(ProtoTCP)
 	if stats.Protocol != ProtoTCP {
@@ -125,6 +146,25 @@ func TestStatisticsCalculate(t *testing.T) {
 	}
 }
 
+func TestStatisticsBoundedSamples(t *testing.T) {
+	stats := NewStatistics(ProtoTCP)
+	n := maxRTTSamples + 500
+	for i := 1; i <= n; i++ {
+		stats.Add(ProbeResult{Seq: i, Success: true, RTT: time.Duration(i) * time.Microsecond})
+	}
+	stats.Calculate()
+
+	if len(stats.AllRTTs) != maxRTTSamples {
+		t.Errorf("expected %d stored RTTs, got %d", maxRTTSamples, len(stats.AllRTTs))
+	}
+	if stats.MinRTT != time.Microsecond || stats.MaxRTT != time.Duration(n)*time.Microsecond {
+		t.Errorf("expected exact min/max over all probes, got %v/%v", stats.MinRTT, stats.MaxRTT)
+	}
+	if want := time.Duration(n+1) * time.Microsecond / 2; stats.AvgRTT != want {
+		t.Errorf("expected AvgRTT=%v, got %v", want, stats.AvgRTT)
+	}
+}
+
 func TestStatisticsCalculateEmpty(t *testing.T) {
 	stats := NewStatistics(ProtoTCP)
 	stats.Calculate() // Should not panic
@@ -347,6 +387,163 @@ func TestHTTPProbeWithLocalServer(t *testing.T) {
 	}
 }
 
+// TestTLSProbeWithLocalServer tests TLS handshake probes against a local TLS server.
+func TestTLSProbeWithLocalServer(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	defer server.Close()
+
+	addr := server.Listener.Addr().(*net.TCPAddr)
+	pinger := NewPinger(Options{
+		Host:     "127.0.0.1",
+		Port:     addr.Port,
+		Protocol: ProtoTLS,
+		Count:    2,
+		Interval: 50 * time.Millisecond,
+		Timeout:  2 * time.Second,
+	})
+
+	var results []ProbeResult
+	if err := pinger.Run(context.Background(), func(r ProbeResult) {
+		results = append(results, r)
+	}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	for i, r := range results {
+		if !r.Success {
+			t.Errorf("TLS result %d failed: %v", i+1, r.Error)
+		}
+		if !strings.HasPrefix(r.Detail, "TLS 1.3 TLS_") {
+			t.Errorf("TLS result %d detail = %q, want version and cipher", i+1, r.Detail)
+		}
+	}
+	if pinger.Stats.Protocol != ProtoTLS || pinger.Stats.Received != 2 {
+		t.Errorf("unexpected stats: %+v", pinger.Stats)
+	}
+}
+
+// TestTLSProbePlainServer tests that a non-TLS listener fails the handshake.
+func TestTLSProbePlainServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start test server: %v", err)
+	}
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	pinger := NewPinger(Options{
+		Host:     "127.0.0.1",
+		Port:     listener.Addr().(*net.TCPAddr).Port,
+		Protocol: ProtoTLS,
+		Count:    1,
+		Timeout:  time.Second,
+	})
+
+	var results []ProbeResult
+	pinger.Run(context.Background(), func(r ProbeResult) {
+		results = append(results, r)
+	})
+	if len(results) != 1 || results[0].Success {
+		t.Errorf("expected a failed handshake, got %+v", results)
+	}
+}
+
+// TestQUICProbeWithLocalServer tests QUIC handshake probes against a local endpoint.
+func TestQUICProbeWithLocalServer(t *testing.T) {
+	tlsServer := httptest.NewUnstartedServer(nil)
+	tlsServer.StartTLS()
+	cert := tlsServer.TLS.Certificates[0]
+	tlsServer.Close()
+
+	server, err := quic.Listen("udp", "127.0.0.1:0", &quic.Config{
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			NextProtos:   []string{"h3"},
+			MinVersion:   tls.VersionTLS13,
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to start QUIC endpoint: %v", err)
+	}
+	defer server.Close(context.Background())
+
+	go func() {
+		for {
+			conn, err := server.Accept(context.Background())
+			if err != nil {
+				return
+			}
+			go conn.Wait(context.Background())
+		}
+	}()
+
+	pinger := NewPinger(Options{
+		Host:     "127.0.0.1",
+		Port:     int(server.LocalAddr().Port()),
+		Protocol: ProtoQUIC,
+		Count:    2,
+		Interval: 50 * time.Millisecond,
+		Timeout:  2 * time.Second,
+	})
+
+	var results []ProbeResult
+	if err := pinger.Run(context.Background(), func(r ProbeResult) {
+		results = append(results, r)
+	}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	for i, r := range results {
+		if !r.Success {
+			t.Errorf("QUIC result %d failed: %v", i+1, r.Error)
+		}
+		if !strings.HasPrefix(r.Detail, "ALPN h3") {
+			t.Errorf("QUIC result %d detail = %q, want ALPN h3", i+1, r.Detail)
+		}
+	}
+}
+
+// TestQUICProbeNoServer tests that an unanswered QUIC probe fails with a clear error.
+func TestQUICProbeNoServer(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to bind UDP socket: %v", err)
+	}
+	defer conn.Close() // swallows packets without answering
+
+	pinger := NewPinger(Options{
+		Host:     "127.0.0.1",
+		Port:     conn.LocalAddr().(*net.UDPAddr).Port,
+		Protocol: ProtoQUIC,
+		Count:    1,
+		Timeout:  300 * time.Millisecond,
+	})
+
+	var results []ProbeResult
+	pinger.Run(context.Background(), func(r ProbeResult) {
+		results = append(results, r)
+	})
+	if len(results) != 1 || results[0].Success {
+		t.Fatalf("expected a failed probe, got %+v", results)
+	}
+	if !strings.Contains(results[0].Error.Error(), "blocked") {
+		t.Errorf("expected blocked-UDP hint, got %v", results[0].Error)
+	}
+}
+
 // TestRunContextCancellation tests that Run respects context cancellation.
 func TestRunContextCancellation(t *testing.T) {
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
@@ -389,6 +586,110 @@ func TestRunContextCancellation(t *testing.T) {
 	}
 }
 
+// TestRunContinuous tests that a zero count probes until cancelled and
+// still calculates statistics.
+func TestRunContinuous(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start test server: %v", err)
+	}
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	pinger := NewPinger(Options{
+		Host:       "127.0.0.1",
+		Port:       listener.Addr().(*net.TCPAddr).Port,
+		Protocol:   ProtoTCP,
+		Continuous: true,
+		Interval:   20 * time.Millisecond,
+		Timeout:    time.Second,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var count int
+	err = pinger.Run(ctx, func(r ProbeResult) {
+		count++
+		if count == 8 {
+			cancel()
+		}
+	})
+
+	if err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if count != 8 {
+		t.Errorf("expected 8 probes before cancel, got %d", count)
+	}
+	if pinger.Stats.Sent != 8 || pinger.Stats.AvgRTT == 0 {
+		t.Errorf("expected calculated stats for 8 probes, got %+v", pinger.Stats)
+	}
+}
+
+// TestRunMulti tests concurrent probing of several targets.
+func TestRunMulti(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start test server: %v", err)
+	}
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to get free port: %v", err)
+	}
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	base := Options{Protocol: ProtoTCP, Count: 3, Interval: 20 * time.Millisecond, Timeout: time.Second}
+	up, down := base, base
+	up.Host, up.Port = "127.0.0.1", listener.Addr().(*net.TCPAddr).Port
+	down.Host, down.Port = "localhost", closedPort
+
+	perTarget := map[string]int{}
+	stats, err := RunMulti(context.Background(), []Options{up, down}, func(r ProbeResult) {
+		perTarget[r.Target]++
+	})
+	if err != nil {
+		t.Fatalf("RunMulti failed: %v", err)
+	}
+
+	if perTarget["127.0.0.1"] != 3 || perTarget["localhost"] != 3 {
+		t.Errorf("expected 3 results per target, got %v", perTarget)
+	}
+	if len(stats) != 2 || stats[0].Target != "127.0.0.1" || stats[1].Target != "localhost" {
+		t.Fatalf("unexpected stats order: %+v", stats)
+	}
+	if stats[0].Stats.Received != 3 || stats[1].Stats.Lost != 3 {
+		t.Errorf("unexpected stats: up=%+v down=%+v", stats[0].Stats, stats[1].Stats)
+	}
+
+	output := FormatComparison(stats)
+	if !strings.Contains(output, "127.0.0.1:") || !strings.Contains(output, "100.0%") {
+		t.Errorf("comparison missing targets or loss:\n%s", output)
+	}
+}
+
 // TestTCPProbeConnectionRefused tests behavior with a closed port.
 func TestTCPProbeConnectionRefused(t *testing.T) {
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
@@ -442,6 +743,33 @@ func TestStatisticsFormat(t *testing.T) {
 	}
 }
 
+func TestStatisticsToJSON(t *testing.T) {
+	stats := NewStatistics(ProtoHTTP)
+	stats.Add(ProbeResult{Seq: 1, Success: true, RTT: 10 * time.Millisecond})
+	stats.Add(ProbeResult{Seq: 2, Success: true, RTT: 30 * time.Millisecond})
+	stats.Add(ProbeResult{Seq: 3, Success: false})
+	stats.Calculate()
+
+	out, err := stats.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if decoded["protocol"] != "http" || decoded["sent"] != 3.0 || decoded["lost"] != 1.0 {
+		t.Errorf("unexpected counters: %s", out)
+	}
+	if decoded["avg_rtt"] != float64(20*time.Millisecond) || decoded["max_rtt"] != float64(30*time.Millisecond) {
+		t.Errorf("unexpected RTTs: %s", out)
+	}
+	if rtts, ok := decoded["rtts"].([]any); !ok || len(rtts) != 2 {
+		t.Errorf("expected 2 RTT samples, got %v", decoded["rtts"])
+	}
+}
+
 func TestProtocolConstants(t *testing.T) {
 	if ProtoTCP != "tcp" {
 		t.Error("unexpected ProtoTCP value")
@@ -455,4 +783,10 @@ func TestProtocolConstants(t *testing.T) {
 	if ProtoDNS != "dns" {
 		t.Error("unexpected ProtoDNS value")
 	}
+	if ProtoTLS != "tls" {
+		t.Error("unexpected ProtoTLS value")
+	}
+	if ProtoQUIC != "quic" {
+		t.Error("unexpected ProtoQUIC value")
+	}
 }
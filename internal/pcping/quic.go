@@ -0,0 +1,74 @@
+package pcping
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/quic"
+)
+
+// quicCloseWait bounds how long a QUIC probe waits for the peer to
+// acknowledge the connection close before dropping the endpoint.
+const quicCloseWait = 250 * time.Millisecond
+
+// probeQUIC completes a QUIC handshake offering HTTP/3 and reports the
+// connection-establishment time as the RTT.
+func (p *Pinger) probeQUIC(seq int) ProbeResult {
+	result := ProbeResult{
+		Seq:      seq,
+		Protocol: ProtoQUIC,
+	}
+
+	addr := net.JoinHostPort(p.opts.Host, strconv.Itoa(p.opts.Port))
+
+	endpoint, err := quic.Listen("udp", ":0", nil)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), quicCloseWait)
+		defer cancel()
+		endpoint.Close(ctx)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.opts.Timeout)
+	defer cancel()
+
+	config := &quic.Config{
+		TLSConfig: &tls.Config{
+			ServerName:         p.opts.Host,
+			InsecureSkipVerify: true,
+			NextProtos:         []string{"h3"},
+			MinVersion:         tls.VersionTLS13,
+		},
+		HandshakeTimeout: p.opts.Timeout,
+	}
+
+	start := time.Now()
+	conn, err := endpoint.Dial(ctx, "udp", addr, config)
+	result.RTT = time.Since(start)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("no QUIC handshake within %v (UDP/%d blocked or QUIC not supported)", p.opts.Timeout, p.opts.Port)
+		}
+		result.Error = err
+		return result
+	}
+	state := conn.ConnectionState()
+	conn.Abort(nil)
+
+	alpn := state.NegotiatedProtocol
+	if alpn == "" {
+		alpn = "none"
+	}
+	result.Success = true
+	result.Detail = fmt.Sprintf("ALPN %s, %s", alpn, tls.CipherSuiteName(state.CipherSuite))
+	result.Addr = addr
+	return result
+}
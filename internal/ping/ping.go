@@ -8,6 +8,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/JedizLaPulga/NNS/internal/srcaddr"
 	"golang.org/x/net/icmp"
 	"golang.org/x/net/ipv4"
 )
@@ -32,6 +33,7 @@ type Pinger struct {
 	Interval   time.Duration // Time between pings
 	Timeout    time.Duration // Timeout per ping
 	PacketSize int           // Size of ICMP packet data
+	SourceIP   string        // Local address to send from ("" = default route)
 	Stats      *Statistics
 
 	conn *icmp.PacketConn
@@ -88,7 +90,7 @@ func (p *Pinger) ReverseDNS() (string, error) {
 // Run executes the ping sequence.
 func (p *Pinger) Run(ctx context.Context, callback func(PingResult)) error {
 	// Open ICMP connection
-	conn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+	conn, err := icmp.ListenPacket("ip4:icmp", srcaddr.ListenAddr(p.SourceIP))
 	if err != nil {
 		return fmt.Errorf("failed to open ICMP connection (try running as administrator): %v", err)
 	}
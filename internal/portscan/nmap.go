@@ -0,0 +1,279 @@
+package portscan
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// nmapServices maps well-known ports to nmap-services names, so reports
+// match what nmap itself would print.
+var nmapServices = map[int]string{
+	21:    "ftp",
+	22:    "ssh",
+	23:    "telnet",
+	25:    "smtp",
+	53:    "domain",
+	80:    "http",
+	110:   "pop3",
+	111:   "rpcbind",
+	135:   "msrpc",
+	139:   "netbios-ssn",
+	143:   "imap",
+	389:   "ldap",
+	443:   "https",
+	445:   "microsoft-ds",
+	465:   "smtps",
+	587:   "submission",
+	636:   "ldapssl",
+	993:   "imaps",
+	995:   "pop3s",
+	1433:  "ms-sql-s",
+	1521:  "oracle",
+	1883:  "mqtt",
+	2049:  "nfs",
+	3306:  "mysql",
+	3389:  "ms-wbt-server",
+	5432:  "postgresql",
+	5672:  "amqp",
+	5900:  "vnc",
+	6379:  "redis",
+	8080:  "http-proxy",
+	8443:  "https-alt",
+	9200:  "wap-wsp",
+	27017: "mongod",
+}
+
+// ServiceName returns the nmap-services name for a TCP port, or "unknown".
+func ServiceName(port int) string {
+	if name, ok := nmapServices[port]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// nmap -oX document structure. Only the elements that nmap parsers and
+// ndiff rely on are emitted.
+type nmapRun struct {
+	XMLName          xml.Name     `xml:"nmaprun"`
+	Scanner          string       `xml:"scanner,attr"`
+	Args             string       `xml:"args,attr,omitempty"`
+	Start            int64        `xml:"start,attr"`
+	StartStr         string       `xml:"startstr,attr"`
+	Version          string       `xml:"version,attr"`
+	XMLOutputVersion string       `xml:"xmloutputversion,attr"`
+	ScanInfo         nmapScanInfo `xml:"scaninfo"`
+	Hosts            []nmapHost   `xml:"host"`
+	RunStats         nmapRunStats `xml:"runstats"`
+}
+
+type nmapScanInfo struct {
+	Type        string `xml:"type,attr"`
+	Protocol    string `xml:"protocol,attr"`
+	NumServices int    `xml:"numservices,attr"`
+	Services    string `xml:"services,attr"`
+}
+
+type nmapHost struct {
+	StartTime int64          `xml:"starttime,attr"`
+	EndTime   int64          `xml:"endtime,attr"`
+	Status    nmapStatus     `xml:"status"`
+	Address   *nmapAddress   `xml:"address,omitempty"`
+	Hostnames *nmapHostnames `xml:"hostnames,omitempty"`
+	Ports     nmapPorts      `xml:"ports"`
+}
+
+type nmapStatus struct {
+	State  string `xml:"state,attr"`
+	Reason string `xml:"reason,attr"`
+}
+
+type nmapAddress struct {
+	Addr     string `xml:"addr,attr"`
+	AddrType string `xml:"addrtype,attr"`
+}
+
+type nmapHostnames struct {
+	Hostname []nmapHostname `xml:"hostname"`
+}
+
+type nmapHostname struct {
+	Name string `xml:"name,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type nmapPorts struct {
+	ExtraPorts []nmapExtraPorts `xml:"extraports"`
+	Ports      []nmapPort       `xml:"port"`
+}
+
+type nmapExtraPorts struct {
+	State string `xml:"state,attr"`
+	Count int    `xml:"count,attr"`
+}
+
+type nmapPort struct {
+	Protocol string      `xml:"protocol,attr"`
+	PortID   int         `xml:"portid,attr"`
+	State    nmapState   `xml:"state"`
+	Service  nmapService `xml:"service"`
+}
+
+type nmapState struct {
+	State  string `xml:"state,attr"`
+	Reason string `xml:"reason,attr"`
+}
+
+type nmapService struct {
+	Name      string `xml:"name,attr"`
+	Method    string `xml:"method,attr"`
+	Conf      int    `xml:"conf,attr"`
+	ExtraInfo string `xml:"extrainfo,attr,omitempty"`
+}
+
+type nmapRunStats struct {
+	Finished nmapFinished  `xml:"finished"`
+	Hosts    nmapHostStats `xml:"hosts"`
+}
+
+type nmapFinished struct {
+	Time    int64  `xml:"time,attr"`
+	TimeStr string `xml:"timestr,attr"`
+	Elapsed string `xml:"elapsed,attr"`
+	Summary string `xml:"summary,attr"`
+	Exit    string `xml:"exit,attr"`
+}
+
+type nmapHostStats struct {
+	Up    int `xml:"up,attr"`
+	Down  int `xml:"down,attr"`
+	Total int `xml:"total,attr"`
+}
+
+// ToNmapXML renders the result in nmap's -oX format as a TCP connect scan.
+// Open ports are listed individually; closed and filtered ports are
+// summarized as extraports, as nmap does by default.
+func (r *Result) ToNmapXML() (string, error) {
+	run := nmapRun{
+		Scanner:          "nns",
+		Args:             r.Args,
+		Start:            r.StartTime.Unix(),
+		StartStr:         r.StartTime.Format(time.ANSIC),
+		Version:          "7.0", // Some parsers expect an nmap-style version number
+		XMLOutputVersion: "1.05",
+		ScanInfo: nmapScanInfo{
+			Type:        "connect",
+			Protocol:    "tcp",
+			NumServices: len(r.Ports),
+			Services:    formatPortList(r.Ports),
+		},
+	}
+
+	up := 0
+	for _, h := range r.Hosts {
+		host := nmapHostFor(h)
+		if host.Status.State == "up" {
+			up++
+		}
+		run.Hosts = append(run.Hosts, host)
+	}
+
+	elapsed := r.EndTime.Sub(r.StartTime).Seconds()
+	run.RunStats = nmapRunStats{
+		Finished: nmapFinished{
+			Time:    r.EndTime.Unix(),
+			TimeStr: r.EndTime.Format(time.ANSIC),
+			Elapsed: fmt.Sprintf("%.2f", elapsed),
+			Summary: fmt.Sprintf("nns done at %s; %d IP %s (%d %s up) scanned in %.2f seconds",
+				r.EndTime.Format(time.ANSIC), len(r.Hosts), plural(len(r.Hosts), "address", "addresses"),
+				up, plural(up, "host", "hosts"), elapsed),
+			Exit: "success",
+		},
+		Hosts: nmapHostStats{Up: up, Down: len(r.Hosts) - up, Total: len(r.Hosts)},
+	}
+
+	data, err := xml.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + "<!DOCTYPE nmaprun>\n" + string(data) + "\n", nil
+}
+
+// nmapHostFor converts a host scan to its nmap representation. A host is
+// up when any port answered, open or closed.
+func nmapHostFor(h HostResult) nmapHost {
+	host := nmapHost{
+		StartTime: h.StartTime.Unix(),
+		EndTime:   h.EndTime.Unix(),
+		Status:    nmapStatus{State: "down", Reason: "no-response"},
+	}
+
+	addr := h.Addr
+	if addr == "" {
+		addr = h.Host
+	}
+	addrType := "ipv4"
+	if ip := net.ParseIP(addr); ip != nil && ip.To4() == nil {
+		addrType = "ipv6"
+	}
+	host.Address = &nmapAddress{Addr: addr, AddrType: addrType}
+	if net.ParseIP(h.Host) == nil {
+		host.Hostnames = &nmapHostnames{Hostname: []nmapHostname{{Name: h.Host, Type: "user"}}}
+	}
+
+	extra := map[string]int{}
+	for _, p := range h.Ports {
+		state := p.State()
+		switch state {
+		case "open":
+			host.Status = nmapStatus{State: "up", Reason: "syn-ack"}
+			host.Ports.Ports = append(host.Ports.Ports, nmapPort{
+				Protocol: "tcp",
+				PortID:   p.Port,
+				State:    nmapState{State: "open", Reason: "syn-ack"},
+				Service:  nmapService{Name: ServiceName(p.Port), Method: "table", Conf: 3, ExtraInfo: p.Banner},
+			})
+		case "closed":
+			if host.Status.State != "up" {
+				host.Status = nmapStatus{State: "up", Reason: "conn-refused"}
+			}
+			extra[state]++
+		default:
+			extra[state]++
+		}
+	}
+	for _, state := range []string{"closed", "filtered"} {
+		if extra[state] > 0 {
+			host.Ports.ExtraPorts = append(host.Ports.ExtraPorts, nmapExtraPorts{State: state, Count: extra[state]})
+		}
+	}
+	return host
+}
+
+// formatPortList compresses sorted ports into nmap's "22,80,8000-8010" form.
+func formatPortList(ports []int) string {
+	var parts []string
+	for i := 0; i < len(ports); {
+		j := i
+		for j+1 < len(ports) && ports[j+1] == ports[j]+1 {
+			j++
+		}
+		if j > i {
+			parts = append(parts, strconv.Itoa(ports[i])+"-"+strconv.Itoa(ports[j]))
+		} else {
+			parts = append(parts, strconv.Itoa(ports[i]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -3,22 +3,99 @@ package portscan
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
+	"os"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
+
+	"github.com/JedizLaPulga/NNS/internal/srcaddr"
 )
 
 // ScanResult represents the result of scanning a single port.
 type ScanResult struct {
-	Host   string
-	Port   int
-	Open   bool
-	Banner string // Service banner if available
-	Error  error
+	Host   string `json:"host"`
+	Port   int    `json:"port"`
+	Open   bool   `json:"open"`
+	Banner string `json:"banner,omitempty"` // Service banner if available
+	Error  error  `json:"-"`
+}
+
+// State classifies a port the way nmap does for a TCP connect scan: "open",
+// "closed" when the host actively refused the connection, or "filtered"
+// when it did not answer.
+func (r ScanResult) State() string {
+	switch {
+	case r.Open:
+		return "open"
+	case errors.Is(r.Error, syscall.ECONNREFUSED):
+		return "closed"
+	default:
+		return "filtered"
+	}
+}
+
+// HostResult holds the scan of one host.
+type HostResult struct {
+	Host      string       `json:"host"`
+	Addr      string       `json:"addr,omitempty"` // Resolved IP address
+	StartTime time.Time    `json:"start_time"`
+	EndTime   time.Time    `json:"end_time"`
+	Ports     []ScanResult `json:"ports"`
+	Error     error        `json:"-"` // Resolution failure
+}
+
+// OpenPorts returns the open port numbers in ascending order.
+func (h HostResult) OpenPorts() []int {
+	var open []int
+	for _, p := range h.Ports {
+		if p.Open {
+			open = append(open, p.Port)
+		}
+	}
+	return open
+}
+
+// MarshalJSON implements json.Marshaler, adding the error text.
+func (h HostResult) MarshalJSON() ([]byte, error) {
+	type alias HostResult
+	var errStr string
+	if h.Error != nil {
+		errStr = h.Error.Error()
+	}
+	ports := h.Ports
+	if ports == nil {
+		ports = []ScanResult{}
+	}
+	return json.Marshal(struct {
+		alias
+		Ports []ScanResult `json:"ports"`
+		Error string       `json:"error,omitempty"`
+	}{alias(h), ports, errStr})
+}
+
+// Result is a complete scan of one or more hosts.
+type Result struct {
+	Args      string       `json:"args,omitempty"` // Command line, recorded in reports
+	Ports     []int        `json:"ports"`          // Ports requested
+	StartTime time.Time    `json:"start_time"`
+	EndTime   time.Time    `json:"end_time"`
+	Hosts     []HostResult `json:"hosts"`
+}
+
+// ToJSON returns the result as indented JSON.
+func (r *Result) ToJSON() (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
 }
 
 // Scanner configures port scanning behavior.
@@ -26,6 +103,7 @@ type Scanner struct {
 	Timeout       time.Duration
 	BannerTimeout time.Duration
 	Concurrency   int
+	SourceIP      string // Local address to connect from ("" = default route)
 }
 
 // NewScanner creates a new Scanner with default settings.
@@ -39,6 +117,11 @@ func NewScanner() *Scanner {
 
 // ScanPort scans a single port on the specified host.
 func ScanPort(host string, port int, timeout time.Duration, bannerTimeout time.Duration) ScanResult {
+	return scanPort(srcaddr.Dialer("", timeout), host, port, bannerTimeout)
+}
+
+// scanPort scans a single port, connecting with dialer.
+func scanPort(dialer *net.Dialer, host string, port int, bannerTimeout time.Duration) ScanResult {
 	result := ScanResult{
 		Host: host,
 		Port: port,
@@ -46,7 +129,7 @@ func ScanPort(host string, port int, timeout time.Duration, bannerTimeout time.D
 	}
 
 	address := net.JoinHostPort(host, strconv.Itoa(port))
-	conn, err := net.DialTimeout("tcp", address, timeout)
+	conn, err := dialer.Dial("tcp", address)
 
 	if err != nil {
 		// Port is closed or unreachable
@@ -77,6 +160,7 @@ func (s *Scanner) ScanPorts(ctx context.Context, host string, ports []int) []Sca
 	portsChan := make(chan int, len(ports))
 
 	var wg sync.WaitGroup
+	dialer := srcaddr.Dialer(s.SourceIP, s.Timeout)
 
 	// Start worker pool
 	numWorkers := s.Concurrency
@@ -93,7 +177,7 @@ func (s *Scanner) ScanPorts(ctx context.Context, host string, ports []int) []Sca
 				case <-ctx.Done():
 					return
 				default:
-					result := ScanPort(host, port, s.Timeout, s.BannerTimeout)
+					result := scanPort(dialer, host, port, s.BannerTimeout)
 					resultsChan <- result
 				}
 			}
@@ -131,6 +215,37 @@ func (s *Scanner) ScanPorts(ctx context.Context, host string, ports []int) []Sca
 	return results
 }
 
+// ScanHost resolves host and scans ports on it, recording timing and the
+// address that was scanned.
+func (s *Scanner) ScanHost(ctx context.Context, host string, ports []int) HostResult {
+	result := HostResult{Host: host, StartTime: time.Now()}
+
+	if ip := net.ParseIP(host); ip != nil {
+		result.Addr = ip.String()
+	} else {
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			result.Error = err
+			result.EndTime = time.Now()
+			return result
+		}
+		if len(addrs) > 0 {
+			result.Addr = addrs[0].IP.String()
+		}
+	}
+
+	target := host
+	if result.Addr != "" {
+		target = result.Addr
+	}
+	result.Ports = s.ScanPorts(ctx, target, ports)
+	for i := range result.Ports {
+		result.Ports[i].Host = host
+	}
+	result.EndTime = time.Now()
+	return result
+}
+
 // ParsePortRange parses a port specification like "80,443,8000-8080" into a slice of port numbers.
 func ParsePortRange(input string) ([]int, error) {
 	if input == "" {
@@ -279,3 +394,53 @@ func CommonPorts() []int {
 		8443, // HTTPS Alt
 	}
 }
+
+// SaveResults writes scan results to path as JSON, for use as a baseline.
+func SaveResults(path string, results []ScanResult) error {
+	if results == nil {
+		results = []ScanResult{}
+	}
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// LoadResults reads scan results saved by SaveResults.
+func LoadResults(path string) ([]ScanResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var results []ScanResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("%s: invalid results file: %w", path, err)
+	}
+	return results, nil
+}
+
+// Diff compares two scans of the same host and returns the ports that are
+// open now but were not before, and those that were open before but were
+// found not open now. Ports missing from the current scan are not reported as
+// closed, since they were not checked.
+func Diff(old, current []ScanResult) (opened, closed []int) {
+	wasOpen := make(map[int]bool, len(old))
+	for _, r := range old {
+		if r.Open {
+			wasOpen[r.Port] = true
+		}
+	}
+
+	for _, r := range current {
+		switch {
+		case r.Open && !wasOpen[r.Port]:
+			opened = append(opened, r.Port)
+		case !r.Open && wasOpen[r.Port]:
+			closed = append(closed, r.Port)
+		}
+	}
+	sort.Ints(opened)
+	sort.Ints(closed)
+	return opened, closed
+}
@@ -2,8 +2,14 @@ package portscan
 
 import (
 	"context"
+	"encoding/xml"
+	"errors"
 	"net"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -270,6 +276,223 @@ func TestScannerScanPorts(t *testing.T) {
 	}
 }
 
+func TestScanResultState(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}}
+	tests := []struct {
+		result ScanResult
+		want   string
+	}{
+		{ScanResult{Open: true}, "open"},
+		{ScanResult{Error: refused}, "closed"},
+		{ScanResult{Error: errors.New("i/o timeout")}, "filtered"},
+	}
+	for _, tt := range tests {
+		if got := tt.result.State(); got != tt.want {
+			t.Errorf("State() with error %v = %q, want %q", tt.result.Error, got, tt.want)
+		}
+	}
+}
+
+func TestResultToNmapXML(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	refused := &net.OpError{Op: "dial", Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}}
+	result := &Result{
+		Args:      "nns portscan -p 22,80-81 example.com",
+		Ports:     []int{22, 80, 81},
+		StartTime: start,
+		EndTime:   start.Add(2 * time.Second),
+		Hosts: []HostResult{
+			{
+				Host:      "example.com",
+				Addr:      "93.184.216.34",
+				StartTime: start,
+				EndTime:   start.Add(time.Second),
+				Ports: []ScanResult{
+					{Port: 22, Error: refused},
+					{Port: 80, Open: true, Banner: "HTTP/1.1 400 Bad Request"},
+					{Port: 81, Error: errors.New("i/o timeout")},
+				},
+			},
+			{
+				Host:  "2001:db8::1",
+				Addr:  "2001:db8::1",
+				Ports: []ScanResult{{Port: 22, Error: errors.New("i/o timeout")}},
+			},
+		},
+	}
+
+	out, err := result.ToNmapXML()
+	if err != nil {
+		t.Fatalf("ToNmapXML() error = %v", err)
+	}
+	if !strings.HasPrefix(out, xml.Header+"<!DOCTYPE nmaprun>") {
+		t.Errorf("ToNmapXML() missing XML header and doctype:\n%s", out)
+	}
+
+	// Decode with the field names nmap parsers use
+	var run struct {
+		Scanner  string `xml:"scanner,attr"`
+		Start    int64  `xml:"start,attr"`
+		ScanInfo struct {
+			Services string `xml:"services,attr"`
+		} `xml:"scaninfo"`
+		Hosts []struct {
+			Status struct {
+				State string `xml:"state,attr"`
+			} `xml:"status"`
+			Address struct {
+				Addr     string `xml:"addr,attr"`
+				AddrType string `xml:"addrtype,attr"`
+			} `xml:"address"`
+			Hostnames []struct {
+				Name string `xml:"name,attr"`
+			} `xml:"hostnames>hostname"`
+			ExtraPorts []struct {
+				State string `xml:"state,attr"`
+				Count int    `xml:"count,attr"`
+			} `xml:"ports>extraports"`
+			Ports []struct {
+				PortID int `xml:"portid,attr"`
+				State  struct {
+					State string `xml:"state,attr"`
+				} `xml:"state"`
+				Service struct {
+					Name string `xml:"name,attr"`
+				} `xml:"service"`
+			} `xml:"ports>port"`
+		} `xml:"host"`
+		RunStats struct {
+			Hosts struct {
+				Up    int `xml:"up,attr"`
+				Total int `xml:"total,attr"`
+			} `xml:"hosts"`
+		} `xml:"runstats"`
+	}
+	if err := xml.Unmarshal([]byte(out), &run); err != nil {
+		t.Fatalf("ToNmapXML() produced invalid XML: %v", err)
+	}
+
+	if run.Scanner != "nns" || run.Start != start.Unix() || run.ScanInfo.Services != "22,80-81" {
+		t.Errorf("nmaprun = scanner %q start %d services %q", run.Scanner, run.Start, run.ScanInfo.Services)
+	}
+	if len(run.Hosts) != 2 {
+		t.Fatalf("got %d hosts, want 2", len(run.Hosts))
+	}
+
+	h := run.Hosts[0]
+	if h.Status.State != "up" || h.Address.Addr != "93.184.216.34" || h.Address.AddrType != "ipv4" {
+		t.Errorf("host 0 = status %q address %q (%s)", h.Status.State, h.Address.Addr, h.Address.AddrType)
+	}
+	if len(h.Hostnames) != 1 || h.Hostnames[0].Name != "example.com" {
+		t.Errorf("host 0 hostnames = %+v", h.Hostnames)
+	}
+	if len(h.Ports) != 1 || h.Ports[0].PortID != 80 || h.Ports[0].State.State != "open" || h.Ports[0].Service.Name != "http" {
+		t.Errorf("host 0 ports = %+v", h.Ports)
+	}
+	if len(h.ExtraPorts) != 2 || h.ExtraPorts[0].State != "closed" || h.ExtraPorts[1].State != "filtered" {
+		t.Errorf("host 0 extraports = %+v", h.ExtraPorts)
+	}
+
+	h = run.Hosts[1]
+	if h.Status.State != "down" || h.Address.AddrType != "ipv6" || len(h.Hostnames) != 0 {
+		t.Errorf("host 1 = status %q addrtype %q hostnames %+v", h.Status.State, h.Address.AddrType, h.Hostnames)
+	}
+
+	if run.RunStats.Hosts.Up != 1 || run.RunStats.Hosts.Total != 2 {
+		t.Errorf("runstats hosts = %+v", run.RunStats.Hosts)
+	}
+}
+
+func TestScanHost(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start test server: %v", err)
+	}
+	defer listener.Close()
+	testPort := listener.Addr().(*net.TCPAddr).Port
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	result := NewScanner().ScanHost(context.Background(), "127.0.0.1", []int{testPort, 9})
+	if result.Addr != "127.0.0.1" || result.Error != nil {
+		t.Errorf("ScanHost() addr = %q, error = %v", result.Addr, result.Error)
+	}
+	if open := result.OpenPorts(); !reflect.DeepEqual(open, []int{testPort}) {
+		t.Errorf("ScanHost() open ports = %v, want [%d]", open, testPort)
+	}
+	if result.EndTime.Before(result.StartTime) {
+		t.Error("ScanHost() end time before start time")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := []ScanResult{
+		{Port: 22, Open: true},
+		{Port: 80, Open: true},
+		{Port: 443, Open: false},
+		{Port: 8080, Open: true},
+	}
+	current := []ScanResult{
+		{Port: 22, Open: true},
+		{Port: 80, Open: false},
+		{Port: 443, Open: true},
+		{Port: 3306, Open: true},
+		// 8080 was not scanned this time
+	}
+
+	opened, closed := Diff(old, current)
+	if !reflect.DeepEqual(opened, []int{443, 3306}) {
+		t.Errorf("Diff() opened = %v, want [443 3306]", opened)
+	}
+	if !reflect.DeepEqual(closed, []int{80}) {
+		t.Errorf("Diff() closed = %v, want [80]", closed)
+	}
+
+	if opened, closed := Diff(old, old); opened != nil || closed != nil {
+		t.Errorf("Diff() of identical scans = %v, %v, want no changes", opened, closed)
+	}
+}
+
+func TestSaveLoadResults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	results := []ScanResult{
+		{Host: "10.0.0.1", Port: 22, Open: true, Banner: "SSH-2.0-OpenSSH_9.6"},
+		{Host: "10.0.0.1", Port: 23, Open: false, Error: errors.New("connection refused")},
+	}
+
+	if err := SaveResults(path, results); err != nil {
+		t.Fatalf("SaveResults() error = %v", err)
+	}
+	loaded, err := LoadResults(path)
+	if err != nil {
+		t.Fatalf("LoadResults() error = %v", err)
+	}
+
+	// Errors are not persisted
+	want := []ScanResult{results[0], {Host: "10.0.0.1", Port: 23}}
+	if !reflect.DeepEqual(loaded, want) {
+		t.Errorf("LoadResults() = %+v, want %+v", loaded, want)
+	}
+
+	if _, err := LoadResults(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadResults() of missing file succeeded, want error")
+	}
+	if err := os.WriteFile(path, []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadResults(path); err == nil {
+		t.Error("LoadResults() of invalid file succeeded, want error")
+	}
+}
+
 func TestCommonPorts(t *testing.T) {
 	ports := CommonPorts()
 
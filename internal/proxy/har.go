@@ -0,0 +1,285 @@
+package proxy
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultHARMaxBody is the default per-body capture limit for HAR recording.
+const DefaultHARMaxBody = 1024 * 1024
+
+// HAR is the root of an HTTP Archive 1.2 document.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog holds the captured entries.
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator identifies the application that produced the archive.
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is a single request/response exchange.
+type HAREntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"` // total milliseconds
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+}
+
+// HARRequest describes the request sent upstream.
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// HARResponse describes the response relayed to the client.
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// HARNameValue is a header, cookie or query parameter.
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData holds a captured request body.
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARContent holds a captured response body. Binary bodies are base64
+// encoded; Comment notes truncation.
+type HARContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// HARTimings breaks down the exchange duration in milliseconds.
+// Phases that are not measured are reported as -1.
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// HARRecorder collects proxy exchanges for export as a HAR file.
+// It is safe for concurrent use.
+type HARRecorder struct {
+	MaxBodySize int64 // per-body capture limit in bytes
+
+	mu      sync.Mutex
+	entries []HAREntry
+}
+
+// NewHARRecorder creates a recorder that captures at most maxBody bytes of
+// each request and response body. Zero or negative uses DefaultHARMaxBody.
+func NewHARRecorder(maxBody int64) *HARRecorder {
+	if maxBody <= 0 {
+		maxBody = DefaultHARMaxBody
+	}
+	return &HARRecorder{MaxBodySize: maxBody}
+}
+
+// harExchange carries everything captured for one request/response.
+type harExchange struct {
+	start       time.Time
+	headersDone time.Time
+	end         time.Time
+	req         *http.Request
+	reqBody     []byte
+	reqBodySize int64
+	resp        *http.Response
+	respBody    []byte
+	respSize    int64
+	truncated   bool
+}
+
+// record converts an exchange into a HAR entry and stores it.
+func (h *HARRecorder) record(x harExchange) {
+	wait := x.headersDone.Sub(x.start)
+	receive := x.end.Sub(x.headersDone)
+
+	entry := HAREntry{
+		StartedDateTime: x.start,
+		Time:            ms(x.end.Sub(x.start)),
+		Request: HARRequest{
+			Method:      x.req.Method,
+			URL:         x.req.URL.String(),
+			HTTPVersion: x.req.Proto,
+			Cookies:     harCookies(x.req.Cookies()),
+			Headers:     harHeaders(x.req.Header),
+			QueryString: harQuery(x.req),
+			HeadersSize: -1,
+			BodySize:    x.reqBodySize,
+		},
+		Response: HARResponse{
+			Status:      x.resp.StatusCode,
+			StatusText:  strings.TrimSpace(strings.TrimPrefix(x.resp.Status, fmt.Sprint(x.resp.StatusCode))),
+			HTTPVersion: x.resp.Proto,
+			Cookies:     harCookies(x.resp.Cookies()),
+			Headers:     harHeaders(x.resp.Header),
+			Content:     harContent(x.resp.Header.Get("Content-Type"), x.respBody, x.respSize, x.truncated),
+			RedirectURL: x.resp.Header.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    x.respSize,
+		},
+		Timings: HARTimings{Blocked: -1, DNS: -1, Connect: -1, Send: 0, Wait: ms(wait), Receive: ms(receive)},
+	}
+
+	if len(x.reqBody) > 0 {
+		entry.Request.PostData = &HARPostData{
+			MimeType: x.req.Header.Get("Content-Type"),
+			Text:     string(x.reqBody),
+		}
+	}
+
+	h.mu.Lock()
+	h.entries = append(h.entries, entry)
+	h.mu.Unlock()
+}
+
+// Entries returns a copy of the recorded entries in start order.
+func (h *HARRecorder) Entries() []HAREntry {
+	h.mu.Lock()
+	entries := make([]HAREntry, len(h.entries))
+	copy(entries, h.entries)
+	h.mu.Unlock()
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime.Before(entries[j].StartedDateTime)
+	})
+	return entries
+}
+
+// HAR returns the recorded traffic as a HAR document.
+func (h *HARRecorder) HAR() *HAR {
+	return &HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "nns proxy", Version: "1.0"},
+		Entries: h.Entries(),
+	}}
+}
+
+// WriteFile writes the recorded traffic as HAR JSON to path.
+func (h *HARRecorder) WriteFile(path string) error {
+	data, err := json.MarshalIndent(h.HAR(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// ReadHAR loads a HAR document from path.
+func ReadHAR(path string) (*HAR, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var har HAR
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("invalid HAR file: %w", err)
+	}
+	return &har, nil
+}
+
+func harHeaders(h http.Header) []HARNameValue {
+	out := []HARNameValue{}
+	for _, k := range sortedHeaderKeys(h) {
+		for _, v := range h[k] {
+			out = append(out, HARNameValue{Name: k, Value: v})
+		}
+	}
+	return out
+}
+
+func harCookies(cookies []*http.Cookie) []HARNameValue {
+	out := []HARNameValue{}
+	for _, c := range cookies {
+		out = append(out, HARNameValue{Name: c.Name, Value: c.Value})
+	}
+	return out
+}
+
+func harQuery(r *http.Request) []HARNameValue {
+	out := []HARNameValue{}
+	q := r.URL.Query()
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range q[k] {
+			out = append(out, HARNameValue{Name: k, Value: v})
+		}
+	}
+	return out
+}
+
+func harContent(mimeType string, body []byte, size int64, truncated bool) HARContent {
+	c := HARContent{Size: size, MimeType: mimeType}
+	if utf8.Valid(body) {
+		c.Text = string(body)
+	} else {
+		c.Text = base64.StdEncoding.EncodeToString(body)
+		c.Encoding = "base64"
+	}
+	if truncated {
+		c.Comment = fmt.Sprintf("body truncated to %d of %d bytes", len(body), size)
+	}
+	return c
+}
+
+func sortedHeaderKeys(h http.Header) []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -1,14 +1,16 @@
-// Package proxy provides HTTP proxy server functionality.
 // Package proxy implements a simple HTTP/HTTPS debug proxy.
 package proxy
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"net"
 	"net/http"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -18,6 +20,20 @@ type Config struct {
 	Port    int
 	Verbose bool
 	Filter  string
+	MITM    bool         // intercept HTTPS by terminating TLS with CA-signed leaf certs
+	CA      *CA          // required when MITM is enabled
+	HAR     *HARRecorder // records exchanges for HAR export, if set
+
+	// Rules are evaluated in order against each request. Only the first
+	// matching rule is applied unless MatchAllRules is set, in which case
+	// every matching rule is applied in order.
+	Rules         []Rule
+	MatchAllRules bool
+
+	// Network condition simulation
+	Delay     time.Duration // added before each request is forwarded
+	Jitter    time.Duration // random +/- variation applied to Delay
+	Bandwidth int64         // per-connection limit in bytes/sec (0 = unlimited)
 }
 
 // Proxy is a debug proxy server.
@@ -43,14 +59,55 @@ func NewProxy(cfg Config) *Proxy {
 
 // Start starts the proxy server.
 func (p *Proxy) Start() error {
-	addr := fmt.Sprintf(":%d", p.cfg.Port)
-	p.server = &http.Server{
-		Addr:    addr,
-		Handler: p,
+	ln, err := p.listen()
+	if err != nil {
+		return err
+	}
+	p.server = &http.Server{Handler: p}
+
+	log.Printf("[INFO] Proxy listening on %s", ln.Addr())
+	return p.server.Serve(ln)
+}
+
+// Run starts the proxy server and shuts it down when ctx is cancelled.
+func (p *Proxy) Run(ctx context.Context) error {
+	ln, err := p.listen()
+	if err != nil {
+		return err
+	}
+	p.server = &http.Server{Handler: p}
+
+	errCh := make(chan error, 1)
+	go func() {
+		if err := p.server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	log.Printf("[INFO] Proxy listening on %s", ln.Addr())
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return p.server.Shutdown(shutdownCtx)
+	case err := <-errCh:
+		return err
 	}
+}
 
-	log.Printf("[INFO] Proxy listening on %s", addr)
-	return p.server.ListenAndServe()
+// listen opens the proxy listener, applying the per-connection bandwidth
+// limit if configured.
+func (p *Proxy) listen() (net.Listener, error) {
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", p.cfg.Port))
+	if err != nil {
+		return nil, err
+	}
+	if p.cfg.Bandwidth > 0 {
+		ln = &throttleListener{Listener: ln, bps: p.cfg.Bandwidth}
+	}
+	return ln, nil
 }
 
 // ServeHTTP handles incoming requests.
@@ -85,6 +142,17 @@ func (p *Proxy) handleConnect(w http.ResponseWriter, r *http.Request) {
 	}
 	defer clientConn.Close()
 
+	if p.cfg.MITM && p.cfg.CA != nil {
+		clientConn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n"))
+		p.handleMITM(clientConn, r.Host, id)
+		if p.shouldLog(r.Host) {
+			log.Printf("[%d] <-- Intercepted tunnel closed (%v)", id, time.Since(start))
+		}
+		return
+	}
+
+	p.simulateLatency()
+
 	// Connect to target
 	targetConn, err := net.DialTimeout("tcp", r.Host, 10*time.Second)
 	if err != nil {
@@ -107,19 +175,70 @@ func (p *Proxy) handleConnect(w http.ResponseWriter, r *http.Request) {
 
 // handleHTTP handles standard HTTP forwarding.
 func (p *Proxy) handleHTTP(w http.ResponseWriter, r *http.Request) {
+	if isWebSocketUpgrade(r) {
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			http.Error(w, "Hijacking not supported", http.StatusInternalServerError)
+			return
+		}
+		clientConn, rw, err := hijacker.Hijack()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		defer clientConn.Close()
+		p.handleWebSocket(clientConn, rw.Reader, r)
+		return
+	}
+
+	resp := p.forward(r)
+	defer resp.Body.Close()
+
+	// Copy headers back
+	for k, vv := range resp.Header {
+		for _, v := range vv {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+
+	// Copy body back
+	io.Copy(w, resp.Body)
+}
+
+// forward sends r upstream and returns the response. Upstream errors are
+// turned into a 502 response so callers always have something to relay.
+// The completion line is logged (and the exchange recorded, if a HAR
+// recorder is configured) when the returned body is closed.
+func (p *Proxy) forward(r *http.Request) *http.Response {
 	start := time.Now()
 	id := atomic.AddUint64(&p.requestID, 1)
+	target := r.URL.String()
+	logged := p.shouldLog(target)
 
-	if p.shouldLog(r.URL.String()) {
-		log.Printf("[%d] --> %s %s", id, r.Method, r.URL)
+	if logged {
+		log.Printf("[%d] --> %s %s", id, r.Method, target)
+	}
+
+	// Capture the start of the request body for the HAR, then replay it
+	// ahead of the remainder so the upstream still sees the full body.
+	body := r.Body
+	var reqBody []byte
+	if p.cfg.HAR != nil && r.Body != nil && r.Body != http.NoBody {
+		captured, err := io.ReadAll(io.LimitReader(r.Body, p.cfg.HAR.MaxBodySize))
+		if err != nil {
+			return errorResponse(r, http.StatusBadRequest, err)
+		}
+		reqBody = captured
+		body = io.NopCloser(io.MultiReader(bytes.NewReader(captured), r.Body))
 	}
 
 	// Create request
-	outReq, err := http.NewRequest(r.Method, r.URL.String(), r.Body)
+	outReq, err := http.NewRequest(r.Method, target, body)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		return errorResponse(r, http.StatusInternalServerError, err)
 	}
+	outReq.ContentLength = r.ContentLength
 
 	// Copy headers
 	for k, vv := range r.Header {
@@ -128,31 +247,105 @@ func (p *Proxy) handleHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	rules := p.matchRules(r)
+	for _, rule := range rules {
+		rule.applyRequest(outReq)
+		if logged && rule.Name != "" {
+			log.Printf("[%d] rule %q applied", id, rule.Name)
+		}
+	}
+
+	p.simulateLatency()
+
 	// Perform request
 	resp, err := p.client.Do(outReq)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadGateway)
-		if p.shouldLog(r.URL.String()) {
+		if logged {
 			log.Printf("[%d] <-- Error: %v", id, err)
 		}
-		return
+		resp = errorResponse(r, http.StatusBadGateway, err)
 	}
-	defer resp.Body.Close()
+	headersDone := time.Now()
 
-	// Copy headers back
-	for k, vv := range resp.Header {
-		for _, v := range vv {
-			w.Header().Add(k, v)
+	for _, rule := range rules {
+		rule.applyResponse(resp)
+	}
+
+	if !logged && p.cfg.HAR == nil {
+		return resp
+	}
+
+	ob := &observedBody{ReadCloser: resp.Body}
+	if p.cfg.HAR != nil {
+		ob.limit = p.cfg.HAR.MaxBodySize
+	}
+	ob.onClose = func(n int64, captured []byte) {
+		if logged && err == nil {
+			log.Printf("[%d] <-- %s (%v) - %s", id, resp.Status, time.Since(start), formatBytes(n))
+		}
+		if p.cfg.HAR != nil {
+			reqSize := r.ContentLength
+			if reqSize < 0 {
+				reqSize = int64(len(reqBody))
+			}
+			p.cfg.HAR.record(harExchange{
+				start:       start,
+				headersDone: headersDone,
+				end:         time.Now(),
+				req:         r,
+				reqBody:     reqBody,
+				reqBodySize: reqSize,
+				resp:        resp,
+				respBody:    captured,
+				respSize:    n,
+				truncated:   n > int64(len(captured)),
+			})
 		}
 	}
-	w.WriteHeader(resp.StatusCode)
+	resp.Body = ob
+	return resp
+}
 
-	// Copy body back
-	n, _ := io.Copy(w, resp.Body)
+// errorResponse builds a plain-text error response for r.
+func errorResponse(r *http.Request, code int, err error) *http.Response {
+	body := err.Error() + "\n"
+	return &http.Response{
+		StatusCode:    code,
+		Status:        fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"text/plain; charset=utf-8"}},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       r,
+	}
+}
+
+// observedBody counts bytes read from a response body, optionally keeping
+// the first limit bytes, and reports both once on Close.
+type observedBody struct {
+	io.ReadCloser
+	n       int64
+	limit   int64
+	buf     bytes.Buffer
+	once    sync.Once
+	onClose func(n int64, captured []byte)
+}
 
-	if p.shouldLog(r.URL.String()) {
-		log.Printf("[%d] <-- %s (%v) - %s", id, resp.Status, time.Since(start), formatBytes(n))
+func (b *observedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := b.limit - int64(b.buf.Len()); room > 0 {
+		b.buf.Write(p[:min(int64(n), room)])
 	}
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *observedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.onClose(b.n, b.buf.Bytes()) })
+	return err
 }
 
 func (p *Proxy) shouldLog(target string) bool {
@@ -174,6 +367,3 @@ func formatBytes(n int64) string {
 	}
 	return fmt.Sprintf("%.1f KB", float64(n)/float64(unit))
 }
-
-// TODO: Implement HTTP proxy server functionality
-// This will use net/http for the proxy server implementation
@@ -1,7 +1,26 @@
 package proxy
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
 )
 
 func TestNewProxy(t *testing.T) {
@@ -92,3 +111,551 @@ func TestConfig(t *testing.T) {
 		t.Error("Default filter should be empty")
 	}
 }
+
+func TestGenerateCA(t *testing.T) {
+	ca, err := GenerateCA()
+	if err != nil {
+		t.Fatalf("GenerateCA() error = %v", err)
+	}
+	if !ca.Cert.IsCA {
+		t.Error("generated certificate should be a CA")
+	}
+
+	leaf, err := ca.LeafCert("example.com")
+	if err != nil {
+		t.Fatalf("LeafCert() error = %v", err)
+	}
+	cert, err := x509.ParseCertificate(leaf.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+	if _, err := cert.Verify(x509.VerifyOptions{DNSName: "example.com", Roots: roots}); err != nil {
+		t.Errorf("leaf does not verify against CA: %v", err)
+	}
+
+	again, _ := ca.LeafCert("example.com")
+	if again != leaf {
+		t.Error("LeafCert() should cache certificates per host")
+	}
+
+	ipLeaf, err := ca.LeafCert("127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ipCert, _ := x509.ParseCertificate(ipLeaf.Certificate[0])
+	if len(ipCert.IPAddresses) != 1 {
+		t.Errorf("IP leaf should have an IP SAN, got %v", ipCert.IPAddresses)
+	}
+}
+
+func TestCAWriteLoad(t *testing.T) {
+	ca, err := GenerateCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "ca.pem")
+	keyFile := filepath.Join(dir, "ca.key")
+	if err := ca.WriteFiles(certFile, keyFile); err != nil {
+		t.Fatalf("WriteFiles() error = %v", err)
+	}
+
+	loaded, err := LoadCA(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("LoadCA() error = %v", err)
+	}
+	if !loaded.Cert.Equal(ca.Cert) {
+		t.Error("loaded CA certificate does not match")
+	}
+
+	if _, err := LoadCA(filepath.Join(dir, "missing.pem"), keyFile); err == nil {
+		t.Error("LoadCA() with missing file should return error")
+	}
+}
+
+func TestMITMIntercept(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secret " + r.URL.Path))
+	}))
+	defer upstream.Close()
+
+	ca, err := GenerateCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewProxy(Config{MITM: true, CA: ca})
+	p.client.Transport = upstream.Client().Transport
+	proxySrv := httptest.NewServer(p)
+	defer proxySrv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+	proxyURL, _ := url.Parse(proxySrv.URL)
+	client := &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyURL(proxyURL),
+		TLSClientConfig: &tls.Config{RootCAs: roots},
+	}}
+
+	resp, err := client.Get(upstream.URL + "/data")
+	if err != nil {
+		t.Fatalf("GET through MITM proxy failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "secret /data" {
+		t.Errorf("body = %q, want %q", body, "secret /data")
+	}
+	if resp.TLS == nil || resp.TLS.PeerCertificates[0].Issuer.CommonName != ca.Cert.Subject.CommonName {
+		t.Error("client should see a certificate issued by the proxy CA")
+	}
+}
+
+func TestHARRecording(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("0123456789"))
+	}))
+	defer upstream.Close()
+
+	rec := NewHARRecorder(4)
+	p := NewProxy(Config{HAR: rec})
+	proxySrv := httptest.NewServer(p)
+	defer proxySrv.Close()
+
+	proxyURL, _ := url.Parse(proxySrv.URL)
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+
+	resp, err := client.Post(upstream.URL+"/submit?x=1", "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("POST through proxy failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "0123456789" {
+		t.Errorf("client body = %q, proxy must relay the full body", body)
+	}
+
+	// Close waits for the handler to finish, so the entry has been recorded.
+	proxySrv.Close()
+
+	entries := rec.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("recorded %d entries, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.Request.Method != "POST" || e.Response.Status != 200 {
+		t.Errorf("entry = %s %d, want POST 200", e.Request.Method, e.Response.Status)
+	}
+	if e.Request.PostData == nil || e.Request.PostData.Text != "payl" {
+		t.Errorf("request body capture = %+v, want truncated to 4 bytes", e.Request.PostData)
+	}
+	if e.Response.Content.Text != "0123" || e.Response.Content.Size != 10 || e.Response.Content.Comment == "" {
+		t.Errorf("response content = %+v, want 4 of 10 bytes with truncation note", e.Response.Content)
+	}
+	if len(e.Request.QueryString) != 1 || e.Request.QueryString[0].Name != "x" {
+		t.Errorf("queryString = %v", e.Request.QueryString)
+	}
+
+	path := filepath.Join(t.TempDir(), "capture.har")
+	if err := rec.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	har, err := ReadHAR(path)
+	if err != nil {
+		t.Fatalf("ReadHAR() error = %v", err)
+	}
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != 1 {
+		t.Errorf("HAR round trip: version %q, %d entries", har.Log.Version, len(har.Log.Entries))
+	}
+}
+
+func TestParseRulesYAML(t *testing.T) {
+	src := `
+# Fault injection rules
+match_all: true
+rules:
+  - name: fail-writes
+    match:
+      host: "*.example.com"
+      path: /api/*
+      method: POST
+    status: 503
+    set_response_headers:
+      Retry-After: "30"
+  - name: strip-auth   # trailing comment
+    remove_headers: [Authorization, Cookie]
+    set_headers:
+      X-Debug: 'yes'
+    rewrite_host: staging.example.com:8443
+`
+	rf, err := ParseRulesYAML([]byte(src))
+	if err != nil {
+		t.Fatalf("ParseRulesYAML() error = %v", err)
+	}
+	if !rf.MatchAll {
+		t.Error("MatchAll should be true")
+	}
+	if len(rf.Rules) != 2 {
+		t.Fatalf("got %d rules, want 2", len(rf.Rules))
+	}
+
+	r0 := rf.Rules[0]
+	if r0.Name != "fail-writes" || r0.Status != 503 || r0.Match.Host != "*.example.com" ||
+		r0.Match.Path != "/api/*" || r0.Match.Method != "POST" || r0.SetResponseHeaders["Retry-After"] != "30" {
+		t.Errorf("rule 0 = %+v", r0)
+	}
+
+	r1 := rf.Rules[1]
+	if r1.Name != "strip-auth" || len(r1.RemoveHeaders) != 2 || r1.RemoveHeaders[1] != "Cookie" ||
+		r1.SetHeaders["X-Debug"] != "yes" || r1.RewriteHost != "staging.example.com:8443" {
+		t.Errorf("rule 1 = %+v", r1)
+	}
+}
+
+func TestParseRulesYAMLErrors(t *testing.T) {
+	tests := []string{
+		"rules:\n  - status: 42\n",
+		"rules:\n  - match:\n      host: \"[\"\n",
+		"rules:\n  - name: a\n      bad: indent\n",
+		"just a scalar line\n",
+	}
+	for _, src := range tests {
+		if _, err := ParseRulesYAML([]byte(src)); err == nil {
+			t.Errorf("ParseRulesYAML(%q) should return error", src)
+		}
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	rule := Rule{Match: RuleMatch{Host: "*.example.com", Path: "/api/*", Method: "get"}}
+
+	tests := []struct {
+		method string
+		url    string
+		want   bool
+	}{
+		{"GET", "http://api.example.com/api/users", true},
+		{"GET", "http://API.Example.com:8080/api/users", true},
+		{"POST", "http://api.example.com/api/users", false},
+		{"GET", "http://example.org/api/users", false},
+		{"GET", "http://api.example.com/other", false},
+		{"GET", "http://api.example.com/api/v1/users", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.url, nil)
+		if got := rule.Matches(r); got != tt.want {
+			t.Errorf("Matches(%s %s) = %v, want %v", tt.method, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{"/api/*", "/api/users", true},
+		{"/api/*", "/api/v1/users", false},
+		{"/api/**", "/api/v1/users", true},
+		{"/api/**", "/api/users", true},
+		{"/api/**", "/other/users", false},
+		{"/api/**/users", "/api/v1/users", true},
+		{"/api/**/users", "/api/v1/v2/users", true},
+		{"/api/**/users", "/api/v1/groups", false},
+		{"**.json", "/a/b/data.JSON", true},
+	}
+	for _, tt := range tests {
+		if got := globMatch(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestProxyRules(t *testing.T) {
+	var gotAuth, gotDebug string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotDebug = r.Header.Get("X-Debug")
+		w.Write([]byte("ok"))
+	}))
+	defer upstream.Close()
+
+	rules := []Rule{
+		{Name: "first", RemoveHeaders: []string{"Authorization"}, Status: 503},
+		{Name: "second", SetHeaders: map[string]string{"X-Debug": "1"}},
+	}
+
+	for _, matchAll := range []bool{false, true} {
+		p := NewProxy(Config{Rules: rules, MatchAllRules: matchAll})
+		proxySrv := httptest.NewServer(p)
+		proxyURL, _ := url.Parse(proxySrv.URL)
+		client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+
+		req, _ := http.NewRequest("GET", upstream.URL, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		proxySrv.Close()
+
+		if resp.StatusCode != 503 {
+			t.Errorf("matchAll=%v: status = %d, want 503", matchAll, resp.StatusCode)
+		}
+		if gotAuth != "" {
+			t.Errorf("matchAll=%v: Authorization should be removed", matchAll)
+		}
+		if wantDebug := map[bool]string{false: "", true: "1"}[matchAll]; gotDebug != wantDebug {
+			t.Errorf("matchAll=%v: X-Debug = %q, want %q", matchAll, gotDebug, wantDebug)
+		}
+	}
+}
+
+func TestParseBandwidth(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{"1mbps", 125000, false},
+		{"512kbps", 64000, false},
+		{"2.5Mbps", 312500, false},
+		{"1gbit", 125000000, false},
+		{"8000", 1000, false},
+		{"fast", 0, true},
+		{"-1mbps", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseBandwidth(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseBandwidth(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseBandwidth(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestThrottledReader(t *testing.T) {
+	data := strings.Repeat("x", 2000)
+	start := time.Now()
+	n, err := io.Copy(io.Discard, newThrottledReader(strings.NewReader(data), 10000))
+	elapsed := time.Since(start)
+
+	if err != nil || n != 2000 {
+		t.Fatalf("copied %d bytes, err %v", n, err)
+	}
+	// 2000 bytes at 10000 B/s should take about 200ms.
+	if elapsed < 150*time.Millisecond {
+		t.Errorf("throttled read took %v, want >= 150ms", elapsed)
+	}
+}
+
+func TestThrottledWriter(t *testing.T) {
+	var buf strings.Builder
+	start := time.Now()
+	n, err := newThrottledWriter(&buf, 10000).Write([]byte(strings.Repeat("y", 1500)))
+	elapsed := time.Since(start)
+
+	if err != nil || n != 1500 || buf.Len() != 1500 {
+		t.Fatalf("wrote %d bytes, err %v", n, err)
+	}
+	if elapsed < 100*time.Millisecond {
+		t.Errorf("throttled write took %v, want >= 100ms", elapsed)
+	}
+}
+
+func TestLimiterIdleBurst(t *testing.T) {
+	// A long idle gap refills at most one second of traffic.
+	l := limiter{bps: 10000, last: time.Now().Add(-time.Hour)}
+	start := time.Now()
+	l.wait(10000)
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("wait within burst took %v, want no sleep", elapsed)
+	}
+
+	start = time.Now()
+	l.wait(1000)
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("wait past burst took %v, want >= 80ms", elapsed)
+	}
+}
+
+func TestProxyDelay(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer upstream.Close()
+
+	p := NewProxy(Config{Delay: 100 * time.Millisecond, Jitter: 20 * time.Millisecond})
+	proxySrv := httptest.NewServer(p)
+	defer proxySrv.Close()
+
+	proxyURL, _ := url.Parse(proxySrv.URL)
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+
+	start := time.Now()
+	resp, err := client.Get(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("request took %v, want >= 80ms with delay", elapsed)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, r.Method+" "+r.URL.Path+" "+string(b))
+		mu.Unlock()
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	har := &HAR{Log: HARLog{Version: "1.2", Entries: []HAREntry{
+		{
+			Time:     12,
+			Request:  HARRequest{Method: "GET", URL: "https://prod.example.com/ok"},
+			Response: HARResponse{Status: 200},
+		},
+		{
+			Time: 5,
+			Request: HARRequest{
+				Method:   "POST",
+				URL:      "https://prod.example.com/missing",
+				Headers:  []HARNameValue{{Name: "Host", Value: "prod.example.com"}, {Name: "X-Test", Value: "1"}},
+				PostData: &HARPostData{Text: "data"},
+			},
+			Response: HARResponse{Status: 200},
+		},
+	}}}
+	data, _ := json.Marshal(har)
+	path := filepath.Join(t.TempDir(), "capture.har")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := ReplayWithOptions(context.Background(), path, srv.URL, ReplayOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+
+	if results[0].Status != 200 || results[0].StatusChanged() {
+		t.Errorf("result 0 = %+v, want unchanged 200", results[0])
+	}
+	if results[1].Status != 404 || !results[1].StatusChanged() {
+		t.Errorf("result 1 = %+v, want changed to 404", results[1])
+	}
+	if !strings.HasPrefix(results[1].URL, srv.URL) {
+		t.Errorf("replayed URL %q should target %q", results[1].URL, srv.URL)
+	}
+	if results[0].OriginalTime != 12*time.Millisecond {
+		t.Errorf("OriginalTime = %v, want 12ms", results[0].OriginalTime)
+	}
+
+	mu.Lock()
+	found := false
+	for _, b := range bodies {
+		if b == "POST /missing data" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("server did not receive replayed POST body, got %v", bodies)
+	}
+	mu.Unlock()
+
+	results, err = Replay(context.Background(), path, srv.URL+"/v2/")
+	if err != nil {
+		t.Fatalf("Replay() with base path error = %v", err)
+	}
+	if want := srv.URL + "/v2/ok"; results[0].URL != want {
+		t.Errorf("replayed URL = %q, want %q", results[0].URL, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, _ = Replay(ctx, path, srv.URL)
+	for _, r := range results {
+		if !r.Skipped && r.Error == nil {
+			t.Errorf("result after cancel = %+v, want skipped or failed", r)
+		}
+	}
+
+	if _, err := Replay(context.Background(), filepath.Join(t.TempDir(), "none.har"), ""); err == nil {
+		t.Error("Replay() with missing file should return error")
+	}
+}
+
+func TestIsWebSocketUpgrade(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://example.com/ws", nil)
+	if isWebSocketUpgrade(r) {
+		t.Error("plain request should not be an upgrade")
+	}
+	r.Header.Set("Upgrade", "WebSocket")
+	r.Header.Set("Connection", "keep-alive, Upgrade")
+	if !isWebSocketUpgrade(r) {
+		t.Error("request with Upgrade: websocket should be detected")
+	}
+}
+
+func TestWebSocketPassThrough(t *testing.T) {
+	upstream := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		io.Copy(ws, ws)
+	}))
+	defer upstream.Close()
+
+	p := NewProxy(Config{})
+	proxySrv := httptest.NewServer(p)
+	defer proxySrv.Close()
+
+	conn, err := net.Dial("tcp", proxySrv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	host := strings.TrimPrefix(upstream.URL, "http://")
+	fmt.Fprintf(conn, "GET %s/echo HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\nOrigin: %s\r\n\r\n",
+		upstream.URL, host, upstream.URL)
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatalf("reading handshake response: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("handshake status = %d, want 101", resp.StatusCode)
+	}
+
+	// Masked "Hello" text frame.
+	conn.Write([]byte{0x81, 0x85, 0x37, 0xfa, 0x21, 0x3d, 0x7f, 0x9f, 0x4d, 0x51, 0x58})
+
+	// The server echoes it back unmasked.
+	echo := make([]byte, 7)
+	if _, err := io.ReadFull(br, echo); err != nil {
+		t.Fatalf("reading echoed frame: %v", err)
+	}
+	if want := []byte{0x81, 0x05, 'H', 'e', 'l', 'l', 'o'}; !bytes.Equal(echo, want) {
+		t.Errorf("echoed frame = % x, want % x", echo, want)
+	}
+}
@@ -0,0 +1,177 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReplayOptions configures a replay run.
+type ReplayOptions struct {
+	Concurrency int           // parallel requests (default 1, preserving order)
+	Timeout     time.Duration // per-request timeout (default 30s)
+	Client      *http.Client  // optional client override
+}
+
+// ReplayResult compares a replayed request with the original capture.
+type ReplayResult struct {
+	Index          int
+	Method         string
+	URL            string
+	OriginalStatus int
+	Status         int
+	OriginalTime   time.Duration
+	Time           time.Duration
+	Error          error
+	Skipped        bool // never sent because the replay was cancelled
+}
+
+// StatusChanged reports whether the replayed status differs from the capture.
+func (r ReplayResult) StatusChanged() bool {
+	return r.Error == nil && !r.Skipped && r.Status != r.OriginalStatus
+}
+
+// TimeDiff returns how much slower (positive) or faster (negative) the
+// replayed request was compared to the capture.
+func (r ReplayResult) TimeDiff() time.Duration {
+	return r.Time - r.OriginalTime
+}
+
+// Replay re-issues every request in a HAR capture, optionally against a
+// different target (e.g. "http://localhost:8080" or "https://staging/v2",
+// whose path is prefixed to each captured path). An empty target uses the
+// original URLs. If ctx is cancelled, requests not yet sent are returned
+// with Skipped set.
+func Replay(ctx context.Context, captureFile string, target string) ([]ReplayResult, error) {
+	return ReplayWithOptions(ctx, captureFile, target, ReplayOptions{})
+}
+
+// ReplayWithOptions is Replay with explicit options.
+func ReplayWithOptions(ctx context.Context, captureFile string, target string, opts ReplayOptions) ([]ReplayResult, error) {
+	har, err := ReadHAR(captureFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var base *url.URL
+	if target != "" {
+		if !strings.Contains(target, "://") {
+			target = "http://" + target
+		}
+		base, err = url.Parse(target)
+		if err != nil || base.Host == "" {
+			return nil, fmt.Errorf("invalid replay target: %s", target)
+		}
+	}
+
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{
+			Timeout: opts.Timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+
+	entries := har.Log.Entries
+	results := make([]ReplayResult, len(entries))
+	for i, e := range entries {
+		results[i] = ReplayResult{Index: i, Method: e.Request.Method, URL: e.Request.URL,
+			OriginalStatus: e.Response.Status, Skipped: true}
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < opts.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = replayEntry(ctx, client, i, entries[i], base)
+			}
+		}()
+	}
+
+feed:
+	for i := range entries {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, ctx.Err()
+}
+
+// replayEntry sends a single captured request.
+func replayEntry(ctx context.Context, client *http.Client, idx int, e HAREntry, base *url.URL) ReplayResult {
+	res := ReplayResult{
+		Index:          idx,
+		Method:         e.Request.Method,
+		URL:            e.Request.URL,
+		OriginalStatus: e.Response.Status,
+		OriginalTime:   time.Duration(e.Time * float64(time.Millisecond)),
+	}
+
+	u, err := url.Parse(e.Request.URL)
+	if err != nil {
+		res.Error = fmt.Errorf("invalid captured URL: %w", err)
+		return res
+	}
+	if base != nil {
+		u.Scheme = base.Scheme
+		u.Host = base.Host
+		if base.Path != "" && base.Path != "/" {
+			raw := strings.TrimSuffix(base.EscapedPath(), "/") + u.EscapedPath()
+			u.Path = strings.TrimSuffix(base.Path, "/") + u.Path
+			u.RawPath = raw
+		}
+	}
+	res.URL = u.String()
+
+	var body io.Reader
+	if e.Request.PostData != nil {
+		body = strings.NewReader(e.Request.PostData.Text)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, e.Request.Method, res.URL, body)
+	if err != nil {
+		res.Error = err
+		return res
+	}
+	for _, h := range e.Request.Headers {
+		switch http.CanonicalHeaderKey(h.Name) {
+		case "Host", "Content-Length", "Connection", "Proxy-Connection", "Keep-Alive", "Transfer-Encoding", "Upgrade":
+			continue
+		}
+		req.Header.Add(h.Name, h.Value)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		res.Error = err
+		res.Time = time.Since(start)
+		return res
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	res.Time = time.Since(start)
+	res.Status = resp.StatusCode
+	return res
+}
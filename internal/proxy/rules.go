@@ -0,0 +1,180 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/JedizLaPulga/NNS/internal/miniyaml"
+)
+
+// Rule is a match/modify rule applied to proxied HTTP exchanges.
+type Rule struct {
+	Name  string    `json:"name"`
+	Match RuleMatch `json:"match"`
+
+	// Request modifications
+	SetHeaders    map[string]string `json:"set_headers"`
+	RemoveHeaders []string          `json:"remove_headers"`
+	RewriteHost   string            `json:"rewrite_host"`
+
+	// Response modifications
+	SetResponseHeaders    map[string]string `json:"set_response_headers"`
+	RemoveResponseHeaders []string          `json:"remove_response_headers"`
+	Status                int               `json:"status"`
+}
+
+// RuleMatch selects requests by host, path and method. Host and path accept
+// shell-style globs (e.g. "*.example.com", "/api/*"), where "*" stays within
+// one path segment and "**" also crosses "/" (e.g. "/api/**"). Empty fields
+// match anything.
+type RuleMatch struct {
+	Host   string `json:"host"`
+	Path   string `json:"path"`
+	Method string `json:"method"`
+}
+
+// RulesFile is the on-disk rules format.
+type RulesFile struct {
+	// MatchAll applies every matching rule in order. By default only the
+	// first matching rule is applied.
+	MatchAll bool   `json:"match_all"`
+	Rules    []Rule `json:"rules"`
+}
+
+// Matches reports whether the rule applies to r.
+func (rule *Rule) Matches(r *http.Request) bool {
+	m := rule.Match
+	if m.Method != "" && !strings.EqualFold(m.Method, r.Method) {
+		return false
+	}
+	if m.Host != "" && !globMatch(m.Host, strings.ToLower(r.URL.Hostname())) {
+		return false
+	}
+	if m.Path != "" && !globMatch(m.Path, r.URL.Path) {
+		return false
+	}
+	return true
+}
+
+// applyRequest modifies the outgoing request.
+func (rule *Rule) applyRequest(r *http.Request) {
+	for _, h := range rule.RemoveHeaders {
+		r.Header.Del(h)
+	}
+	for k, v := range rule.SetHeaders {
+		r.Header.Set(k, v)
+	}
+	if rule.RewriteHost != "" {
+		r.URL.Host = rule.RewriteHost
+		r.Host = rule.RewriteHost
+	}
+}
+
+// applyResponse modifies the response relayed to the client.
+func (rule *Rule) applyResponse(resp *http.Response) {
+	for _, h := range rule.RemoveResponseHeaders {
+		resp.Header.Del(h)
+	}
+	for k, v := range rule.SetResponseHeaders {
+		resp.Header.Set(k, v)
+	}
+	if rule.Status != 0 {
+		resp.StatusCode = rule.Status
+		resp.Status = fmt.Sprintf("%d %s", rule.Status, http.StatusText(rule.Status))
+	}
+}
+
+// matchRules returns the rules that apply to r, honoring Config.MatchAllRules.
+func (p *Proxy) matchRules(r *http.Request) []*Rule {
+	var matched []*Rule
+	for i := range p.cfg.Rules {
+		rule := &p.cfg.Rules[i]
+		if rule.Matches(r) {
+			matched = append(matched, rule)
+			if !p.cfg.MatchAllRules {
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// globMatch reports whether s matches pattern, ignoring case. Patterns use
+// path.Match syntax, plus "**" for any run of characters including "/".
+func globMatch(pattern, s string) bool {
+	pattern, s = strings.ToLower(pattern), strings.ToLower(s)
+	before, after, ok := strings.Cut(pattern, "**")
+	if !ok {
+		matched, err := path.Match(pattern, s)
+		return err == nil && matched
+	}
+	for i := 0; i <= len(s); i++ {
+		if matched, err := path.Match(before, s[:i]); err != nil || !matched {
+			continue
+		}
+		for j := i; j <= len(s); j++ {
+			if globMatch(after, s[j:]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// LoadRules reads a rules file. Files ending in .json are parsed as JSON;
+// anything else is parsed as a YAML subset (block mappings, block and flow
+// sequences, and scalars).
+func LoadRules(file string) (*RulesFile, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		var rf RulesFile
+		if err := json.Unmarshal(data, &rf); err != nil {
+			return nil, fmt.Errorf("invalid rules file: %w", err)
+		}
+		return &rf, validateRules(&rf)
+	}
+
+	return ParseRulesYAML(data)
+}
+
+// ParseRulesYAML parses rules from YAML.
+func ParseRulesYAML(data []byte) (*RulesFile, error) {
+	doc, err := miniyaml.Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid rules file: %w", err)
+	}
+
+	// Round-trip through JSON to reuse the struct tags.
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var rf RulesFile
+	if err := json.Unmarshal(raw, &rf); err != nil {
+		return nil, fmt.Errorf("invalid rules file: %w", err)
+	}
+	return &rf, validateRules(&rf)
+}
+
+func validateRules(rf *RulesFile) error {
+	for i, rule := range rf.Rules {
+		if rule.Status != 0 && (rule.Status < 100 || rule.Status > 999) {
+			return fmt.Errorf("rule %d: invalid status %d", i+1, rule.Status)
+		}
+		for _, pat := range []string{rule.Match.Host, rule.Match.Path} {
+			if _, err := path.Match(pat, ""); err != nil {
+				return fmt.Errorf("rule %d: invalid pattern %q", i+1, pat)
+			}
+		}
+	}
+	return nil
+}
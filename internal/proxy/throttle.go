@@ -0,0 +1,168 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseBandwidth parses a link speed such as "1mbps", "512kbps" or "2.5Mbps"
+// (bits per second) and returns the rate in bytes per second. A bare number
+// is treated as bits per second.
+func ParseBandwidth(s string) (int64, error) {
+	v := strings.ToLower(strings.TrimSpace(s))
+	mult := 1.0
+	for _, u := range []struct {
+		suffix string
+		mult   float64
+	}{
+		{"gbps", 1e9}, {"mbps", 1e6}, {"kbps", 1e3}, {"bps", 1},
+		{"gbit", 1e9}, {"mbit", 1e6}, {"kbit", 1e3},
+	} {
+		if strings.HasSuffix(v, u.suffix) {
+			v = strings.TrimSpace(strings.TrimSuffix(v, u.suffix))
+			mult = u.mult
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid bandwidth %q (e.g. 1mbps, 512kbps)", s)
+	}
+	bytesPerSec := int64(n * mult / 8)
+	if bytesPerSec < 1 {
+		bytesPerSec = 1
+	}
+	return bytesPerSec, nil
+}
+
+// limiter paces a byte stream to a fixed rate with a token bucket. Idle
+// time refills the bucket, but only up to one second of traffic, so a
+// stream that pauses cannot burst far past the rate when it resumes.
+type limiter struct {
+	bps    int64
+	tokens float64
+	last   time.Time
+}
+
+// chunk returns the maximum bytes to move in one call, so pacing stays
+// smooth (about ten steps per second).
+func (l *limiter) chunk(n int) int {
+	step := int(l.bps / 10)
+	if step < 1 {
+		step = 1
+	}
+	if n > step {
+		return step
+	}
+	return n
+}
+
+// wait takes n transferred bytes from the bucket and sleeps off any
+// shortfall.
+func (l *limiter) wait(n int) {
+	now := time.Now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * float64(l.bps)
+		if burst := float64(l.bps); l.tokens > burst {
+			l.tokens = burst
+		}
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	if l.tokens < 0 {
+		sleep := time.Duration(-l.tokens / float64(l.bps) * float64(time.Second))
+		time.Sleep(sleep)
+		l.tokens = 0
+		l.last = now.Add(sleep)
+	}
+}
+
+// throttledReader limits reads to bps bytes per second.
+type throttledReader struct {
+	r   io.Reader
+	lim limiter
+}
+
+func newThrottledReader(r io.Reader, bps int64) io.Reader {
+	return &throttledReader{r: r, lim: limiter{bps: bps}}
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p[:t.lim.chunk(len(p))])
+	if n > 0 {
+		t.lim.wait(n)
+	}
+	return n, err
+}
+
+// throttledWriter limits writes to bps bytes per second.
+type throttledWriter struct {
+	w   io.Writer
+	lim limiter
+}
+
+func newThrottledWriter(w io.Writer, bps int64) io.Writer {
+	return &throttledWriter{w: w, lim: limiter{bps: bps}}
+}
+
+func (t *throttledWriter) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		n, err := t.w.Write(p[written : written+t.lim.chunk(len(p)-written)])
+		written += n
+		if n > 0 {
+			t.lim.wait(n)
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// throttledConn applies independent read and write limits to a connection.
+type throttledConn struct {
+	net.Conn
+	r io.Reader
+	w io.Writer
+}
+
+func newThrottledConn(c net.Conn, bps int64) net.Conn {
+	return &throttledConn{Conn: c, r: newThrottledReader(c, bps), w: newThrottledWriter(c, bps)}
+}
+
+func (c *throttledConn) Read(p []byte) (int, error)  { return c.r.Read(p) }
+func (c *throttledConn) Write(p []byte) (int, error) { return c.w.Write(p) }
+
+// throttleListener wraps every accepted connection with its own limiter,
+// so the bandwidth cap applies per connection rather than globally.
+type throttleListener struct {
+	net.Listener
+	bps int64
+}
+
+func (l *throttleListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return newThrottledConn(c, l.bps), nil
+}
+
+// simulateLatency sleeps for the configured delay plus a random jitter in
+// [-Jitter, +Jitter]. The result is never negative.
+func (p *Proxy) simulateLatency() {
+	d := p.cfg.Delay
+	if p.cfg.Jitter > 0 {
+		d += time.Duration(rand.Int63n(int64(2*p.cfg.Jitter)+1)) - p.cfg.Jitter
+	}
+	if d > 0 {
+		time.Sleep(d)
+	}
+}
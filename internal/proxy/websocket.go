@@ -0,0 +1,180 @@
+package proxy
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/JedizLaPulga/NNS/internal/websocket"
+)
+
+// maxWSFramePayload bounds the payload size read into memory for one frame.
+const maxWSFramePayload = 64 * 1024 * 1024
+
+// isWebSocketUpgrade reports whether r asks to upgrade to WebSocket.
+func isWebSocketUpgrade(r *http.Request) bool {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return false
+	}
+	for _, v := range r.Header.Values("Connection") {
+		for _, tok := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(tok), "upgrade") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// handleWebSocket relays a WebSocket upgrade to the upstream server and,
+// once switched, pipes frames in both directions while logging them.
+// clientReader must be the buffered reader already wrapping clientConn.
+func (p *Proxy) handleWebSocket(clientConn net.Conn, clientReader *bufio.Reader, r *http.Request) {
+	start := time.Now()
+	id := atomic.AddUint64(&p.requestID, 1)
+	target := r.URL.String()
+	logged := p.shouldLog(target)
+
+	if logged {
+		log.Printf("[%d] --> WS %s", id, target)
+	}
+
+	upstream, err := p.dialUpstream(r)
+	if err != nil {
+		if logged {
+			log.Printf("[%d] <-- WS dial failed: %v", id, err)
+		}
+		resp := errorResponse(r, http.StatusBadGateway, err)
+		resp.Write(clientConn)
+		return
+	}
+	defer upstream.Close()
+
+	outReq := r.Clone(r.Context())
+	outReq.RequestURI = ""
+	outReq.Header.Del("Proxy-Connection")
+	outReq.Header.Del("Proxy-Authorization")
+	if err := outReq.Write(upstream); err != nil {
+		if logged {
+			log.Printf("[%d] <-- WS write failed: %v", id, err)
+		}
+		return
+	}
+
+	upstreamReader := bufio.NewReader(upstream)
+	resp, err := http.ReadResponse(upstreamReader, r)
+	if err != nil {
+		if logged {
+			log.Printf("[%d] <-- WS handshake failed: %v", id, err)
+		}
+		errorResponse(r, http.StatusBadGateway, err).Write(clientConn)
+		return
+	}
+	if err := resp.Write(clientConn); err != nil {
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		if logged {
+			log.Printf("[%d] <-- WS upgrade refused: %s", id, resp.Status)
+		}
+		return
+	}
+	if logged {
+		log.Printf("[%d] <-- WS %s (%v)", id, resp.Status, time.Since(start))
+	}
+
+	var once sync.Once
+	closeBoth := func() {
+		once.Do(func() {
+			clientConn.Close()
+			upstream.Close()
+		})
+	}
+
+	done := make(chan struct{})
+	go func() {
+		p.pumpWSFrames(clientReader, upstream, id, "→", logged)
+		closeBoth()
+		close(done)
+	}()
+	p.pumpWSFrames(upstreamReader, clientConn, id, "←", logged)
+	closeBoth()
+	<-done
+
+	if logged {
+		log.Printf("[%d] <-- WS closed (%v)", id, time.Since(start))
+	}
+}
+
+// pumpWSFrames forwards frames from src to dst unchanged, logging each one.
+func (p *Proxy) pumpWSFrames(src io.Reader, dst io.Writer, id uint64, dir string, logged bool) {
+	for {
+		f, err := websocket.ReadFrame(src, maxWSFramePayload)
+		if err != nil {
+			return
+		}
+		if logged {
+			if p.cfg.Verbose {
+				log.Printf("[%d] WS %s %s (%s): %s", id, dir, websocket.OpcodeName(f.Opcode), formatBytes(int64(len(f.Payload))), wsPayloadPreview(f))
+			} else {
+				log.Printf("[%d] WS %s %s (%s)", id, dir, websocket.OpcodeName(f.Opcode), formatBytes(int64(len(f.Payload))))
+			}
+		}
+		if _, err := dst.Write(f.Raw); err != nil {
+			return
+		}
+	}
+}
+
+// wsPayloadPreview renders a frame payload for verbose logging.
+func wsPayloadPreview(f *websocket.Frame) string {
+	const limit = 512
+	data := f.Payload
+	suffix := ""
+	if len(data) > limit {
+		data = data[:limit]
+		suffix = "..."
+	}
+	if f.Opcode == websocket.OpBinary || !utf8.Valid(data) {
+		return fmt.Sprintf("% x%s", data, suffix)
+	}
+	return string(data) + suffix
+}
+
+// dialUpstream opens a raw connection to the request's target, using TLS
+// for https/wss URLs.
+func (p *Proxy) dialUpstream(r *http.Request) (net.Conn, error) {
+	host := r.URL.Host
+	secure := r.URL.Scheme == "https" || r.URL.Scheme == "wss"
+	if r.URL.Port() == "" {
+		if secure {
+			host = net.JoinHostPort(r.URL.Hostname(), "443")
+		} else {
+			host = net.JoinHostPort(r.URL.Hostname(), "80")
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if !secure {
+		return dialer.Dial("tcp", host)
+	}
+
+	cfg := &tls.Config{}
+	if t, ok := p.client.Transport.(*http.Transport); ok && t.TLSClientConfig != nil {
+		cfg = t.TLSClientConfig.Clone()
+	}
+	cfg.ServerName = r.URL.Hostname()
+	cfg.NextProtos = []string{"http/1.1"}
+	return tls.DialWithDialer(dialer, "tcp", host, cfg)
+}
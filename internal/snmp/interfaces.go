@@ -0,0 +1,190 @@
+package snmp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ifEntry is the ifTable row OID (IF-MIB); columns are ifEntry.<column>.<ifIndex>.
+const ifEntry = "1.3.6.1.2.1.2.2.1"
+
+// ifTable columns.
+const (
+	ifDescr       = 2
+	ifType        = 3
+	ifSpeed       = 5
+	ifAdminStatus = 7
+	ifOperStatus  = 8
+	ifInOctets    = 10
+	ifOutOctets   = 16
+)
+
+// SNMPInterface is one row of a device's ifTable.
+type SNMPInterface struct {
+	Index       int    `json:"index"`
+	Descr       string `json:"descr"`
+	Type        int    `json:"type"`
+	TypeName    string `json:"type_name,omitempty"`
+	Speed       uint64 `json:"speed_bps"` // bits per second
+	AdminStatus string `json:"admin_status"`
+	OperStatus  string `json:"oper_status"`
+	InOctets    uint64 `json:"in_octets"`
+	OutOctets   uint64 `json:"out_octets"`
+}
+
+// ifTypeNames names common IANAifType values.
+var ifTypeNames = map[int]string{
+	1:   "other",
+	6:   "ethernetCsmacd",
+	23:  "ppp",
+	24:  "softwareLoopback",
+	53:  "propVirtual",
+	71:  "ieee80211",
+	131: "tunnel",
+	135: "l2vlan",
+	161: "ieee8023adLag",
+	209: "bridge",
+}
+
+// ifStatusNames names ifAdminStatus/ifOperStatus values.
+var ifStatusNames = map[int64]string{
+	1: "up",
+	2: "down",
+	3: "testing",
+	4: "unknown",
+	5: "dormant",
+	6: "notPresent",
+	7: "lowerLayerDown",
+}
+
+// GetInterfaces walks host's ifTable and returns its interfaces ordered by
+// ifIndex.
+func (s *Scanner) GetInterfaces(ctx context.Context, host, community string) ([]SNMPInterface, error) {
+	addr := net.JoinHostPort(host, strconv.Itoa(s.config.Port))
+	vbs, err := s.walk(ctx, addr, community, ifEntry)
+	if err != nil && len(vbs) == 0 {
+		return nil, err
+	}
+	return buildInterfaces(vbs), err
+}
+
+// buildInterfaces correlates ifTable columns into rows by ifIndex.
+func buildInterfaces(vbs []varbind) []SNMPInterface {
+	rows := make(map[int]*SNMPInterface)
+
+	for _, vb := range vbs {
+		column, index, ok := splitTableOID(vb.OID, ifEntry)
+		if !ok {
+			continue
+		}
+		row, exists := rows[index]
+		if !exists {
+			row = &SNMPInterface{Index: index}
+			rows[index] = row
+		}
+
+		switch column {
+		case ifDescr:
+			row.Descr = formatValue(vb)
+		case ifType:
+			row.Type = int(decodeInt(vb.Value))
+			row.TypeName = ifTypeNames[row.Type]
+		case ifSpeed:
+			row.Speed = decodeUint(vb.Value)
+		case ifAdminStatus:
+			row.AdminStatus = ifStatus(decodeInt(vb.Value))
+		case ifOperStatus:
+			row.OperStatus = ifStatus(decodeInt(vb.Value))
+		case ifInOctets:
+			row.InOctets = decodeUint(vb.Value)
+		case ifOutOctets:
+			row.OutOctets = decodeUint(vb.Value)
+		}
+	}
+
+	ifaces := make([]SNMPInterface, 0, len(rows))
+	for _, row := range rows {
+		ifaces = append(ifaces, *row)
+	}
+	sort.Slice(ifaces, func(i, j int) bool { return ifaces[i].Index < ifaces[j].Index })
+	return ifaces
+}
+
+// splitTableOID splits entry.<column>.<index> into its column and a
+// single-arc index.
+func splitTableOID(oid, entry string) (column, index int, ok bool) {
+	rest, found := strings.CutPrefix(oid, entry+".")
+	if !found {
+		return 0, 0, false
+	}
+	col, idx, found := strings.Cut(rest, ".")
+	if !found {
+		return 0, 0, false
+	}
+	column, err := strconv.Atoi(col)
+	if err != nil {
+		return 0, 0, false
+	}
+	index, err = strconv.Atoi(idx)
+	if err != nil {
+		return 0, 0, false
+	}
+	return column, index, true
+}
+
+func ifStatus(v int64) string {
+	if name, ok := ifStatusNames[v]; ok {
+		return name
+	}
+	return fmt.Sprintf("%d", v)
+}
+
+// FormatInterfaces returns an interface table for display.
+func FormatInterfaces(host string, ifaces []SNMPInterface) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Interfaces: %s\n", host))
+	sb.WriteString(strings.Repeat("─", 100) + "\n")
+
+	if len(ifaces) == 0 {
+		sb.WriteString("No interfaces found.\n")
+		return sb.String()
+	}
+
+	sb.WriteString(fmt.Sprintf("%-6s %-24s %-18s %-10s %-6s %-15s %15s %15s\n",
+		"Index", "Description", "Type", "Speed", "Admin", "Oper", "In Octets", "Out Octets"))
+	for _, ifc := range ifaces {
+		descr := ifc.Descr
+		if len(descr) > 24 {
+			descr = descr[:21] + "..."
+		}
+		typeName := ifc.TypeName
+		if typeName == "" {
+			typeName = strconv.Itoa(ifc.Type)
+		}
+		sb.WriteString(fmt.Sprintf("%-6d %-24s %-18s %-10s %-6s %-15s %15d %15d\n",
+			ifc.Index, descr, typeName, formatSpeed(ifc.Speed), ifc.AdminStatus, ifc.OperStatus,
+			ifc.InOctets, ifc.OutOctets))
+	}
+
+	return sb.String()
+}
+
+func formatSpeed(bps uint64) string {
+	switch {
+	case bps == 0:
+		return "-"
+	case bps >= 1_000_000_000 && bps%1_000_000_000 == 0:
+		return fmt.Sprintf("%d Gbps", bps/1_000_000_000)
+	case bps >= 1_000_000:
+		return fmt.Sprintf("%d Mbps", bps/1_000_000)
+	case bps >= 1_000:
+		return fmt.Sprintf("%d Kbps", bps/1_000)
+	default:
+		return fmt.Sprintf("%d bps", bps)
+	}
+}
@@ -0,0 +1,390 @@
+package snmp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ASN.1/BER tags used by SNMP.
+const (
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagNull        = 0x05
+	tagOID         = 0x06
+	tagSequence    = 0x30
+
+	// SNMP application types (RFC 2578)
+	tagIPAddress = 0x40
+	tagCounter32 = 0x41
+	tagGauge32   = 0x42
+	tagTimeTicks = 0x43
+	tagOpaque    = 0x44
+	tagCounter64 = 0x46
+
+	// Exceptions reported in place of a value (RFC 3416)
+	tagNoSuchObject   = 0x80
+	tagNoSuchInstance = 0x81
+	tagEndOfMibView   = 0x82
+)
+
+// PDU types.
+const (
+	pduGetRequest     = 0xa0
+	pduGetNextRequest = 0xa1
+	pduGetResponse    = 0xa2
+	pduGetBulkRequest = 0xa5
+)
+
+// errNoSuchName is the SNMPv1 error-status reported past the end of the MIB.
+const errNoSuchName = 2
+
+// varbind is an OID and its BER-encoded value.
+type varbind struct {
+	OID   string
+	Type  byte
+	Value []byte
+}
+
+// pdu is an SNMP protocol data unit. For GetBulkRequest, ErrorStatus and
+// ErrorIndex carry non-repeaters and max-repetitions.
+type pdu struct {
+	Type        byte
+	RequestID   int32
+	ErrorStatus int
+	ErrorIndex  int
+	Varbinds    []varbind
+}
+
+// encodeLength encodes a BER definite length.
+func encodeLength(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	var b []byte
+	for n > 0 {
+		b = append([]byte{byte(n)}, b...)
+		n >>= 8
+	}
+	return append([]byte{0x80 | byte(len(b))}, b...)
+}
+
+// encodeTLV encodes a tag, length and value.
+func encodeTLV(tag byte, value []byte) []byte {
+	out := append([]byte{tag}, encodeLength(len(value))...)
+	return append(out, value...)
+}
+
+// encodeInt encodes v as a minimal two's complement INTEGER.
+func encodeInt(tag byte, v int64) []byte {
+	b := []byte{byte(v)}
+	for v > 127 || v < -128 {
+		v >>= 8
+		b = append([]byte{byte(v)}, b...)
+	}
+	return encodeTLV(tag, b)
+}
+
+func (p *pdu) encode() ([]byte, error) {
+	var list []byte
+	for _, vb := range p.Varbinds {
+		oid, err := encodeOIDString(vb.OID)
+		if err != nil {
+			return nil, err
+		}
+		typ, value := vb.Type, vb.Value
+		if typ == 0 {
+			typ = tagNull
+		}
+		list = append(list, encodeTLV(tagSequence, append(encodeTLV(tagOID, oid), encodeTLV(typ, value)...))...)
+	}
+
+	body := encodeInt(tagInteger, int64(p.RequestID))
+	body = append(body, encodeInt(tagInteger, int64(p.ErrorStatus))...)
+	body = append(body, encodeInt(tagInteger, int64(p.ErrorIndex))...)
+	body = append(body, encodeTLV(tagSequence, list)...)
+	return encodeTLV(p.Type, body), nil
+}
+
+// encodeCommunityMessage wraps p in a v1/v2c community-based message.
+func encodeCommunityMessage(version Version, community string, p *pdu) ([]byte, error) {
+	body, err := p.encode()
+	if err != nil {
+		return nil, err
+	}
+	msg := encodeInt(tagInteger, int64(version))
+	msg = append(msg, encodeTLV(tagOctetString, []byte(community))...)
+	msg = append(msg, body...)
+	return encodeTLV(tagSequence, msg), nil
+}
+
+// encodeOIDString encodes a dotted OID for an OBJECT IDENTIFIER value.
+func encodeOIDString(oid string) ([]byte, error) {
+	parts := strings.Split(strings.Trim(oid, "."), ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid OID %q", oid)
+	}
+	nums := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid OID %q", oid)
+		}
+		nums[i] = n
+	}
+	if nums[0] > 2 || (nums[0] < 2 && nums[1] >= 40) {
+		return nil, fmt.Errorf("invalid OID %q", oid)
+	}
+	return encodeOID(nums), nil
+}
+
+// decodeTLV splits the first BER element off data.
+func decodeTLV(data []byte) (tag byte, value, rest []byte, err error) {
+	if len(data) < 2 {
+		return 0, nil, nil, errors.New("truncated BER element")
+	}
+	tag = data[0]
+	length := int(data[1])
+	off := 2
+	if length&0x80 != 0 {
+		n := length & 0x7f
+		if n == 0 || n > 4 || len(data) < 2+n {
+			return 0, nil, nil, errors.New("invalid BER length")
+		}
+		length = 0
+		for _, b := range data[2 : 2+n] {
+			length = length<<8 | int(b)
+		}
+		off += n
+	}
+	if length < 0 || off+length > len(data) {
+		return 0, nil, nil, errors.New("BER length exceeds data")
+	}
+	return tag, data[off : off+length], data[off+length:], nil
+}
+
+// decodeInt decodes a two's complement integer.
+func decodeInt(b []byte) int64 {
+	var v int64
+	for i, c := range b {
+		if i == 0 && c&0x80 != 0 {
+			v = -1
+		}
+		v = v<<8 | int64(c)
+	}
+	return v
+}
+
+// decodeUint decodes an unsigned integer, as used by the counter, gauge
+// and TimeTicks types.
+func decodeUint(b []byte) uint64 {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
+
+// decodeOID decodes an OBJECT IDENTIFIER value to dotted form.
+func decodeOID(b []byte) (string, error) {
+	if len(b) == 0 {
+		return "", errors.New("empty OID")
+	}
+	var parts []string
+	var n uint64
+	first := true
+	for i, c := range b {
+		n = n<<7 | uint64(c&0x7f)
+		if c&0x80 != 0 {
+			if i == len(b)-1 {
+				return "", errors.New("truncated OID")
+			}
+			continue
+		}
+		if first {
+			x := uint64(2)
+			if n < 80 {
+				x = n / 40
+			}
+			parts = append(parts, strconv.FormatUint(x, 10), strconv.FormatUint(n-40*x, 10))
+			first = false
+		} else {
+			parts = append(parts, strconv.FormatUint(n, 10))
+		}
+		n = 0
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// decodePDU decodes a PDU element, tag included.
+func decodePDU(data []byte) (*pdu, error) {
+	tag, body, _, err := decodeTLV(data)
+	if err != nil {
+		return nil, err
+	}
+	if tag&0xe0 != 0xa0 {
+		return nil, fmt.Errorf("unexpected PDU tag 0x%02x", tag)
+	}
+	p := &pdu{Type: tag}
+
+	ints := make([]int64, 3)
+	for i := range ints {
+		var t byte
+		var v []byte
+		t, v, body, err = decodeTLV(body)
+		if err != nil {
+			return nil, err
+		}
+		if t != tagInteger {
+			return nil, errors.New("malformed PDU header")
+		}
+		ints[i] = decodeInt(v)
+	}
+	p.RequestID = int32(ints[0])
+	p.ErrorStatus = int(ints[1])
+	p.ErrorIndex = int(ints[2])
+
+	t, list, _, err := decodeTLV(body)
+	if err != nil {
+		return nil, err
+	}
+	if t != tagSequence {
+		return nil, errors.New("malformed varbind list")
+	}
+	for len(list) > 0 {
+		var vbData []byte
+		t, vbData, list, err = decodeTLV(list)
+		if err != nil {
+			return nil, err
+		}
+		if t != tagSequence {
+			return nil, errors.New("malformed varbind")
+		}
+		t, oidBytes, rest, err := decodeTLV(vbData)
+		if err != nil || t != tagOID {
+			return nil, errors.New("malformed varbind name")
+		}
+		oid, err := decodeOID(oidBytes)
+		if err != nil {
+			return nil, err
+		}
+		valTag, value, _, err := decodeTLV(rest)
+		if err != nil {
+			return nil, err
+		}
+		p.Varbinds = append(p.Varbinds, varbind{OID: oid, Type: valTag, Value: value})
+	}
+	return p, nil
+}
+
+// decodeCommunityMessage decodes a v1/v2c message.
+func decodeCommunityMessage(data []byte) (Version, string, *pdu, error) {
+	tag, msg, _, err := decodeTLV(data)
+	if err != nil {
+		return 0, "", nil, err
+	}
+	if tag != tagSequence {
+		return 0, "", nil, errors.New("not an SNMP message")
+	}
+	tag, v, msg, err := decodeTLV(msg)
+	if err != nil || tag != tagInteger {
+		return 0, "", nil, errors.New("missing SNMP version")
+	}
+	version := Version(decodeInt(v))
+	tag, community, msg, err := decodeTLV(msg)
+	if err != nil || tag != tagOctetString {
+		return 0, "", nil, errors.New("missing community")
+	}
+	p, err := decodePDU(msg)
+	if err != nil {
+		return 0, "", nil, err
+	}
+	return version, string(community), p, nil
+}
+
+// compareOIDs orders dotted OIDs numerically, arc by arc.
+func compareOIDs(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		x, _ := strconv.ParseUint(pa[i], 10, 64)
+		y, _ := strconv.ParseUint(pb[i], 10, 64)
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(pa) - len(pb)
+}
+
+// inSubtree reports whether oid lies strictly below base.
+func inSubtree(oid, base string) bool {
+	return strings.HasPrefix(oid, base+".")
+}
+
+// valueTypeName names a BER value type.
+func valueTypeName(tag byte) string {
+	switch tag {
+	case tagInteger:
+		return "INTEGER"
+	case tagOctetString:
+		return "STRING"
+	case tagNull:
+		return "NULL"
+	case tagOID:
+		return "OID"
+	case tagIPAddress:
+		return "IpAddress"
+	case tagCounter32:
+		return "Counter32"
+	case tagGauge32:
+		return "Gauge32"
+	case tagTimeTicks:
+		return "TimeTicks"
+	case tagOpaque:
+		return "Opaque"
+	case tagCounter64:
+		return "Counter64"
+	case tagNoSuchObject:
+		return "noSuchObject"
+	case tagNoSuchInstance:
+		return "noSuchInstance"
+	case tagEndOfMibView:
+		return "endOfMibView"
+	default:
+		return fmt.Sprintf("0x%02x", tag)
+	}
+}
+
+// formatValue renders a varbind value as text. Numeric types render in
+// decimal; TimeTicks as the raw hundredths of a second.
+func formatValue(vb varbind) string {
+	switch vb.Type {
+	case tagOctetString:
+		return string(vb.Value)
+	case tagInteger:
+		return strconv.FormatInt(decodeInt(vb.Value), 10)
+	case tagCounter32, tagGauge32, tagTimeTicks, tagCounter64:
+		return strconv.FormatUint(decodeUint(vb.Value), 10)
+	case tagOID:
+		if oid, err := decodeOID(vb.Value); err == nil {
+			return oid
+		}
+	case tagIPAddress:
+		if len(vb.Value) == 4 {
+			return net.IP(vb.Value).String()
+		}
+	case tagNull, tagNoSuchObject, tagNoSuchInstance, tagEndOfMibView:
+		return ""
+	}
+	return fmt.Sprintf("%x", vb.Value)
+}
+
+// timeTicksToDuration converts TimeTicks (hundredths of a second).
+func timeTicksToDuration(ticks uint64) time.Duration {
+	return time.Duration(ticks) * 10 * time.Millisecond
+}
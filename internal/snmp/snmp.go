@@ -4,14 +4,20 @@ package snmp
 import (
 	"context"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"net"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// bulkMaxRepetitions is the number of OIDs requested per GETBULK.
+const bulkMaxRepetitions = 10
+
 // Version represents SNMP protocol version.
 type Version int
 
@@ -59,20 +65,21 @@ var CommonOIDs = map[string]string{
 
 // Device represents a discovered SNMP device.
 type Device struct {
-	IP              string
-	Port            int
-	Community       string
-	Version         Version
-	SysDescr        string
-	SysName         string
-	SysLocation     string
-	SysContact      string
-	SysUpTime       time.Duration
-	SysObjectID     string
-	ResponseTime    time.Duration
-	OIDValues       map[string]string
-	OpenCommunities []string
-	SecurityRisk    string
+	IP              string            `json:"ip"`
+	Port            int               `json:"port"`
+	Community       string            `json:"community,omitempty"`
+	Username        string            `json:"username,omitempty"` // SNMPv3 user
+	Version         Version           `json:"-"`
+	SysDescr        string            `json:"sys_descr"`
+	SysName         string            `json:"sys_name"`
+	SysLocation     string            `json:"sys_location"`
+	SysContact      string            `json:"sys_contact"`
+	SysUpTime       time.Duration     `json:"sys_uptime"`
+	SysObjectID     string            `json:"sys_object_id"`
+	ResponseTime    time.Duration     `json:"response_time"`
+	OIDValues       map[string]string `json:"oid_values"`
+	OpenCommunities []string          `json:"open_communities"`
+	SecurityRisk    string            `json:"security_risk,omitempty"`
 }
 
 // OIDResult represents an OID query result.
@@ -85,14 +92,14 @@ type OIDResult struct {
 
 // ScanResult contains SNMP scan results.
 type ScanResult struct {
-	Target      string
-	Devices     []Device
-	Scanned     int
-	Found       int
-	StartTime   time.Time
-	Duration    time.Duration
-	Errors      []string
-	Communities []string
+	Target      string        `json:"target"`
+	Devices     []Device      `json:"devices"`
+	Scanned     int           `json:"scanned"`
+	Found       int           `json:"found"`
+	StartTime   time.Time     `json:"start_time"`
+	Duration    time.Duration `json:"duration"`
+	Errors      []string      `json:"errors,omitempty"`
+	Communities []string      `json:"communities"`
 }
 
 // Config holds SNMP scanner configuration.
@@ -106,6 +113,14 @@ type Config struct {
 	WalkOIDs      bool
 	SecurityAudit bool
 	CustomOIDs    []string
+
+	// SNMPv3 (USM) settings, used when Version is Version3
+	Username       string
+	AuthProtocol   AuthProtocol
+	AuthPassphrase string
+	PrivProtocol   PrivProtocol
+	PrivPassphrase string
+	ContextName    string
 }
 
 // DefaultConfig returns default configuration.
@@ -124,7 +139,12 @@ func DefaultConfig() Config {
 
 // Scanner performs SNMP scanning.
 type Scanner struct {
-	config Config
+	config   Config
+	reqID    atomic.Int32
+	privSalt atomic.Uint64
+
+	mu      sync.Mutex
+	engines map[string]*engine // SNMPv3 engines by address
 }
 
 // New creates a new SNMP scanner.
@@ -141,14 +161,23 @@ func New(cfg Config) *Scanner {
 	if len(cfg.Communities) == 0 {
 		cfg.Communities = []string{"public"}
 	}
-	return &Scanner{config: cfg}
+	s := &Scanner{config: cfg, engines: make(map[string]*engine)}
+	s.reqID.Store(int32(time.Now().UnixNano() & 0x7fffffff))
+	s.privSalt.Store(uint64(time.Now().UnixNano()))
+	return s
 }
 
 // ScanHost scans a single host for SNMP.
 func (s *Scanner) ScanHost(ctx context.Context, host string) (*Device, error) {
 	addr := fmt.Sprintf("%s:%d", host, s.config.Port)
 
-	for _, community := range s.config.Communities {
+	// SNMPv3 authenticates a user instead of trying communities
+	communities := s.config.Communities
+	if s.config.Version == Version3 {
+		communities = []string{""}
+	}
+
+	for _, community := range communities {
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
@@ -164,6 +193,9 @@ func (s *Scanner) ScanHost(ctx context.Context, host string) (*Device, error) {
 			}
 			return device, nil
 		}
+		if s.config.Version == Version3 && err != nil {
+			return nil, fmt.Errorf("%s: %w", host, err)
+		}
 	}
 
 	return nil, fmt.Errorf("no SNMP response from %s", host)
@@ -240,66 +272,165 @@ func (s *Scanner) ScanNetwork(ctx context.Context, cidr string) (*ScanResult, er
 	return result, nil
 }
 
-// WalkOID performs SNMP walk on an OID subtree.
+// WalkOID walks the subtree under baseOID with GETNEXT (SNMPv1) or GETBULK
+// (SNMPv2c) requests, returning every OID found. An empty baseOID walks
+// mib-2. On failure it returns the results gathered so far with the error.
 func (s *Scanner) WalkOID(ctx context.Context, host, community, baseOID string) ([]OIDResult, error) {
+	vbs, err := s.walk(ctx, net.JoinHostPort(host, strconv.Itoa(s.config.Port)), community, baseOID)
+
 	var results []OIDResult
+	for _, vb := range vbs {
+		results = append(results, OIDResult{
+			OID:   vb.OID,
+			Name:  CommonOIDs[vb.OID],
+			Value: formatValue(vb),
+			Type:  valueTypeName(vb.Type),
+		})
+	}
+	return results, err
+}
 
-	// For simplicity, we'll Get common OIDs under the base
-	for oid, name := range CommonOIDs {
-		if strings.HasPrefix(oid, baseOID) || baseOID == "" || baseOID == "1.3.6.1.2.1.1" {
-			value, err := s.getOID(ctx, host, community, oid)
-			if err == nil && value != "" {
-				results = append(results, OIDResult{
-					OID:   oid,
-					Name:  name,
-					Value: value,
-					Type:  "STRING",
-				})
-			}
-		}
+// walk returns the varbinds under baseOID in OID order.
+func (s *Scanner) walk(ctx context.Context, addr, community, baseOID string) ([]varbind, error) {
+	base := strings.Trim(baseOID, ".")
+	if base == "" {
+		base = "1.3.6.1.2.1"
+	}
+	if _, err := encodeOIDString(base); err != nil {
+		return nil, err
 	}
 
-	return results, nil
-}
+	var results []varbind
 
-// GetOID retrieves a single OID value.
-func (s *Scanner) GetOID(ctx context.Context, host, community, oid string) (string, error) {
-	return s.getOID(ctx, host, community, oid)
+	current := base
+	for {
+		req := &pdu{Type: pduGetNextRequest, Varbinds: []varbind{{OID: current}}}
+		if s.config.Version != Version1 {
+			req.Type = pduGetBulkRequest
+			req.ErrorIndex = bulkMaxRepetitions
+		}
+
+		resp, err := s.request(ctx, addr, community, req)
+		if err != nil {
+			return results, err
+		}
+		if resp.ErrorStatus == errNoSuchName && s.config.Version == Version1 {
+			return results, nil
+		}
+		if resp.ErrorStatus != 0 {
+			return results, fmt.Errorf("walk %s: SNMP error-status %d", base, resp.ErrorStatus)
+		}
+		if len(resp.Varbinds) == 0 {
+			return results, nil
+		}
+
+		for _, vb := range resp.Varbinds {
+			if vb.Type == tagEndOfMibView || !inSubtree(vb.OID, base) {
+				return results, nil
+			}
+			if compareOIDs(vb.OID, current) <= 0 {
+				return results, fmt.Errorf("walk %s: agent returned non-increasing OID %s", base, vb.OID)
+			}
+			results = append(results, vb)
+			current = vb.OID
+		}
+	}
 }
 
-func (s *Scanner) probe(ctx context.Context, addr, community string) (*Device, error) {
+// request sends req to addr and waits for the matching response. SNMPv3
+// requests use the configured USM user; v1/v2c ones use community.
+func (s *Scanner) request(ctx context.Context, addr, community string, req *pdu) (*pdu, error) {
 	conn, err := net.DialTimeout("udp", addr, s.config.Timeout)
 	if err != nil {
 		return nil, err
 	}
 	defer conn.Close()
 
-	// Set deadline
-	deadline, ok := ctx.Deadline()
-	if !ok {
-		deadline = time.Now().Add(s.config.Timeout)
+	if s.config.Version == Version3 {
+		return s.requestV3(ctx, conn, addr, req)
 	}
-	conn.SetDeadline(deadline)
 
-	// Build SNMPv2c GetRequest for sysDescr
-	packet := buildGetRequest(community, "1.3.6.1.2.1.1.1.0")
-
-	start := time.Now()
-	_, err = conn.Write(packet)
-	if err != nil {
+	version := s.config.Version
+	if version != Version1 {
+		version = Version2c
+	}
+	var resp *pdu
+	build := func() ([]byte, error) {
+		req.RequestID = s.reqID.Add(1) & 0x7fffffff
+		return encodeCommunityMessage(version, community, req)
+	}
+	parse := func(data []byte) bool {
+		_, _, p, err := decodeCommunityMessage(data)
+		if err != nil || p.Type != pduGetResponse || p.RequestID != req.RequestID {
+			return false // Stale or malformed reply
+		}
+		resp = p
+		return true
+	}
+	if err := s.exchange(ctx, conn, addr, build, parse); err != nil {
 		return nil, err
 	}
+	return resp, nil
+}
 
-	// Read response
-	buf := make([]byte, 4096)
-	n, err := conn.Read(buf)
+// exchange sends the packet from build and reads until parse accepts a
+// reply, retrying up to Config.Retries times on timeout. build is called
+// for every attempt so each gets a fresh request ID.
+func (s *Scanner) exchange(ctx context.Context, conn net.Conn, addr string, build func() ([]byte, error), parse func([]byte) bool) error {
+	buf := make([]byte, 65535)
+	for attempt := 0; attempt <= s.config.Retries; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		packet, err := build()
+		if err != nil {
+			return err
+		}
+
+		deadline := time.Now().Add(s.config.Timeout)
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+		conn.SetDeadline(deadline)
+
+		if _, err := conn.Write(packet); err != nil {
+			return err
+		}
+
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+					break
+				}
+				return err
+			}
+			if parse(buf[:n]) {
+				return nil
+			}
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("no SNMP response from %s", addr)
+}
+
+// GetOID retrieves a single OID value.
+func (s *Scanner) GetOID(ctx context.Context, host, community, oid string) (string, error) {
+	return s.getOID(ctx, host, community, oid)
+}
+
+func (s *Scanner) probe(ctx context.Context, addr, community string) (*Device, error) {
+	start := time.Now()
+	value, err := s.getOIDDirect(ctx, addr, community, "1.3.6.1.2.1.1.1.0")
 	if err != nil {
 		return nil, err
 	}
-
 	responseTime := time.Since(start)
 
-	// Parse response
 	device := &Device{
 		IP:           strings.Split(addr, ":")[0],
 		Port:         s.config.Port,
@@ -308,9 +439,10 @@ func (s *Scanner) probe(ctx context.Context, addr, community string) (*Device, e
 		ResponseTime: responseTime,
 		OIDValues:    make(map[string]string),
 	}
+	if s.config.Version == Version3 {
+		device.Username = s.config.Username
+	}
 
-	// Extract value from response
-	value := parseResponse(buf[:n])
 	device.SysDescr = value
 	device.OIDValues["1.3.6.1.2.1.1.1.0"] = value
 
@@ -348,6 +480,12 @@ func (s *Scanner) populateDeviceInfo(ctx context.Context, device *Device, commun
 		device.SysObjectID = val
 		device.OIDValues["1.3.6.1.2.1.1.2.0"] = val
 	}
+
+	// Get sysUpTime
+	if vb, err := s.getVarbind(ctx, addr, community, "1.3.6.1.2.1.1.3.0"); err == nil && vb.Type == tagTimeTicks {
+		device.SysUpTime = timeTicksToDuration(decodeUint(vb.Value))
+		device.OIDValues["1.3.6.1.2.1.1.3.0"] = formatValue(vb)
+	}
 }
 
 func (s *Scanner) getOID(ctx context.Context, host, community, oid string) (string, error) {
@@ -356,31 +494,31 @@ func (s *Scanner) getOID(ctx context.Context, host, community, oid string) (stri
 }
 
 func (s *Scanner) getOIDDirect(ctx context.Context, addr, community, oid string) (string, error) {
-	conn, err := net.DialTimeout("udp", addr, s.config.Timeout)
+	vb, err := s.getVarbind(ctx, addr, community, oid)
 	if err != nil {
 		return "", err
 	}
-	defer conn.Close()
-
-	deadline, ok := ctx.Deadline()
-	if !ok {
-		deadline = time.Now().Add(s.config.Timeout)
-	}
-	conn.SetDeadline(deadline)
+	return formatValue(vb), nil
+}
 
-	packet := buildGetRequest(community, oid)
-	_, err = conn.Write(packet)
+// getVarbind fetches oid, keeping the value's type.
+func (s *Scanner) getVarbind(ctx context.Context, addr, community, oid string) (varbind, error) {
+	resp, err := s.request(ctx, addr, community, &pdu{Type: pduGetRequest, Varbinds: []varbind{{OID: oid}}})
 	if err != nil {
-		return "", err
+		return varbind{}, err
 	}
-
-	buf := make([]byte, 4096)
-	n, err := conn.Read(buf)
-	if err != nil {
-		return "", err
+	if resp.ErrorStatus != 0 {
+		return varbind{}, fmt.Errorf("get %s: SNMP error-status %d", oid, resp.ErrorStatus)
 	}
-
-	return parseResponse(buf[:n]), nil
+	if len(resp.Varbinds) == 0 {
+		return varbind{}, fmt.Errorf("get %s: empty response", oid)
+	}
+	vb := resp.Varbinds[0]
+	switch vb.Type {
+	case tagNoSuchObject, tagNoSuchInstance, tagEndOfMibView:
+		return varbind{}, fmt.Errorf("get %s: %s", oid, valueTypeName(vb.Type))
+	}
+	return vb, nil
 }
 
 func (s *Scanner) auditCommunities(ctx context.Context, addr string) []string {
@@ -503,11 +641,11 @@ func encodeOID(parts []int) []byte {
 		return nil
 	}
 
-	var result []byte
 	// First two parts encoded as (first * 40 + second)
-	result = append(result, byte(parts[0]*40+parts[1]))
+	subIDs := append([]int{parts[0]*40 + parts[1]}, parts[2:]...)
 
-	for _, p := range parts[2:] {
+	var result []byte
+	for _, p := range subIDs {
 		if p < 128 {
 			result = append(result, byte(p))
 		} else {
@@ -573,6 +711,50 @@ func generateHosts(ipNet *net.IPNet) []string {
 	return hosts
 }
 
+// ToJSON returns the scan result as indented JSON.
+func (r *ScanResult) ToJSON() (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// MarshalJSON encodes the result with devices as an empty array rather
+// than null.
+func (r ScanResult) MarshalJSON() ([]byte, error) {
+	type result ScanResult
+	if r.Devices == nil {
+		r.Devices = []Device{}
+	}
+	return json.Marshal(result(r))
+}
+
+// ToJSON returns the device as indented JSON.
+func (d *Device) ToJSON() (string, error) {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// MarshalJSON encodes the device with its version by name.
+func (d Device) MarshalJSON() ([]byte, error) {
+	type device Device
+	out := struct {
+		device
+		Version string `json:"version"`
+	}{device(d), d.Version.String()}
+	if out.OIDValues == nil {
+		out.OIDValues = map[string]string{}
+	}
+	if out.OpenCommunities == nil {
+		out.OpenCommunities = []string{}
+	}
+	return json.Marshal(out)
+}
+
 // Format returns formatted scan results.
 func (r *ScanResult) Format() string {
 	var sb strings.Builder
@@ -584,7 +766,11 @@ func (r *ScanResult) Format() string {
 		sb.WriteString("No SNMP devices found.\n")
 	} else {
 		for _, d := range r.Devices {
-			sb.WriteString(fmt.Sprintf("📡 %s (Community: %s)\n", d.IP, d.Community))
+			if d.Username != "" {
+				sb.WriteString(fmt.Sprintf("📡 %s (%s user: %s)\n", d.IP, d.Version, d.Username))
+			} else {
+				sb.WriteString(fmt.Sprintf("📡 %s (Community: %s)\n", d.IP, d.Community))
+			}
 			if d.SysName != "" {
 				sb.WriteString(fmt.Sprintf("   Name:     %s\n", d.SysName))
 			}
@@ -598,6 +784,9 @@ func (r *ScanResult) Format() string {
 			if d.SysLocation != "" {
 				sb.WriteString(fmt.Sprintf("   Location: %s\n", d.SysLocation))
 			}
+			if d.SysUpTime > 0 {
+				sb.WriteString(fmt.Sprintf("   Uptime:   %v\n", d.SysUpTime.Round(time.Second)))
+			}
 			sb.WriteString(fmt.Sprintf("   Response: %v\n", d.ResponseTime.Round(time.Millisecond)))
 
 			if len(d.OpenCommunities) > 0 {
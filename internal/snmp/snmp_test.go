@@ -2,6 +2,12 @@ package snmp
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -241,6 +247,78 @@ func TestScanResultFormat(t *testing.T) {
 	}
 }
 
+func TestToJSON(t *testing.T) {
+	result := &ScanResult{
+		Target:   "192.168.1.1",
+		Scanned:  1,
+		Found:    1,
+		Duration: 1500 * time.Millisecond,
+		Devices: []Device{
+			{
+				IP:           "192.168.1.1",
+				Port:         161,
+				Community:    "public",
+				Version:      Version2c,
+				SysName:      "Router",
+				SysUpTime:    90 * time.Second,
+				ResponseTime: 12 * time.Millisecond,
+				OIDValues:    map[string]string{"sysName": "Router"},
+				SecurityRisk: "HIGH",
+			},
+		},
+	}
+
+	out, err := result.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v", err)
+	}
+
+	var decoded struct {
+		Target   string        `json:"target"`
+		Duration time.Duration `json:"duration"`
+		Devices  []struct {
+			Version         string            `json:"version"`
+			SysUpTime       time.Duration     `json:"sys_uptime"`
+			ResponseTime    time.Duration     `json:"response_time"`
+			OIDValues       map[string]string `json:"oid_values"`
+			OpenCommunities []string          `json:"open_communities"`
+			SecurityRisk    string            `json:"security_risk"`
+		} `json:"devices"`
+	}
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if decoded.Target != "192.168.1.1" || decoded.Duration != 1500*time.Millisecond {
+		t.Errorf("target/duration = %q/%v", decoded.Target, decoded.Duration)
+	}
+	if len(decoded.Devices) != 1 {
+		t.Fatalf("devices = %d, want 1", len(decoded.Devices))
+	}
+	d := decoded.Devices[0]
+	if d.Version != "SNMPv2c" || d.SysUpTime != 90*time.Second || d.ResponseTime != 12*time.Millisecond {
+		t.Errorf("device = %+v", d)
+	}
+	if d.OIDValues["sysName"] != "Router" || d.SecurityRisk != "HIGH" {
+		t.Errorf("device = %+v", d)
+	}
+	if d.OpenCommunities == nil || strings.Contains(out, `"open_communities": null`) {
+		t.Error("open_communities should encode as an empty array")
+	}
+
+	empty, err := (&ScanResult{}).ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v", err)
+	}
+	if !strings.Contains(empty, `"devices": []`) {
+		t.Errorf("empty result should have devices []:\n%s", empty)
+	}
+
+	dev, err := (&Device{IP: "10.0.0.1"}).ToJSON()
+	if err != nil || !strings.Contains(dev, `"oid_values": {}`) {
+		t.Errorf("Device.ToJSON() = %s, %v", dev, err)
+	}
+}
+
 func TestScanHostTimeout(t *testing.T) {
 	cfg := Config{
 		Timeout:     100 * time.Millisecond,
@@ -267,3 +345,513 @@ func TestScanNetworkInvalidCIDR(t *testing.T) {
 		t.Error("ScanNetwork(invalid) should return error")
 	}
 }
+
+// mockAgent is a minimal v1/v2c SNMP agent serving a fixed OID table.
+type mockAgent struct {
+	conn      net.PacketConn
+	community string
+	oids      []string
+	values    map[string]varbind
+	drop      atomic.Int32 // Requests to ignore before answering
+	requests  atomic.Int32
+	usm       *mockUSM // Set to accept SNMPv3
+}
+
+// mockUSM is the agent's SNMPv3 engine and its single user.
+type mockUSM struct {
+	cfg      Config // User and passphrases
+	engineID []byte
+	boots    int32
+	time     atomic.Int32
+	keys     usmKeys
+}
+
+func newMockAgent(t *testing.T, values map[string]varbind) *mockAgent {
+	t.Helper()
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &mockAgent{conn: conn, community: "public", values: values}
+	for oid := range values {
+		a.oids = append(a.oids, oid)
+	}
+	sort.Slice(a.oids, func(i, j int) bool { return compareOIDs(a.oids[i], a.oids[j]) < 0 })
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 65535)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			a.requests.Add(1)
+			if a.drop.Load() > 0 {
+				a.drop.Add(-1)
+				continue
+			}
+			handle := a.handle
+			if a.usm != nil {
+				handle = a.handleV3
+			}
+			if reply := handle(buf[:n]); reply != nil {
+				conn.WriteTo(reply, addr)
+			}
+		}
+	}()
+	return a
+}
+
+func (a *mockAgent) port() int {
+	return a.conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+// next returns the first OID after oid, or "" past the end of the table.
+func (a *mockAgent) next(oid string) string {
+	for _, o := range a.oids {
+		if compareOIDs(o, oid) > 0 {
+			return o
+		}
+	}
+	return ""
+}
+
+func (a *mockAgent) handle(data []byte) []byte {
+	version, community, req, err := decodeCommunityMessage(data)
+	if err != nil || community != a.community {
+		return nil
+	}
+	return mustEncode(version, community, a.respond(version, req))
+}
+
+// respond answers req from the OID table.
+func (a *mockAgent) respond(version Version, req *pdu) *pdu {
+	resp := &pdu{Type: pduGetResponse, RequestID: req.RequestID}
+
+	switch req.Type {
+	case pduGetRequest:
+		for _, vb := range req.Varbinds {
+			if v, ok := a.values[vb.OID]; ok {
+				v.OID = vb.OID
+				resp.Varbinds = append(resp.Varbinds, v)
+			} else {
+				resp.Varbinds = append(resp.Varbinds, varbind{OID: vb.OID, Type: tagNoSuchObject})
+			}
+		}
+	case pduGetNextRequest, pduGetBulkRequest:
+		reps := 1
+		if req.Type == pduGetBulkRequest {
+			reps = req.ErrorIndex
+		}
+		for _, vb := range req.Varbinds {
+			oid := vb.OID
+			for i := 0; i < reps; i++ {
+				oid = a.next(oid)
+				if oid == "" {
+					if version == Version1 {
+						return &pdu{Type: pduGetResponse, RequestID: req.RequestID,
+							ErrorStatus: errNoSuchName, ErrorIndex: 1, Varbinds: req.Varbinds}
+					}
+					resp.Varbinds = append(resp.Varbinds, varbind{OID: vb.OID, Type: tagEndOfMibView})
+					break
+				}
+				v := a.values[oid]
+				v.OID = oid
+				resp.Varbinds = append(resp.Varbinds, v)
+			}
+		}
+	}
+	return resp
+}
+
+// enableV3 makes the agent an SNMPv3 engine serving cfg's user.
+func (a *mockAgent) enableV3(cfg Config) {
+	u := &mockUSM{cfg: cfg, engineID: []byte{0x80, 0x00, 0x1f, 0x88, 0x04, 'n', 'n', 's'}, boots: 3}
+	u.time.Store(1000)
+	u.keys = cfg.localizedKeys(u.engineID)
+	a.usm = u
+}
+
+func (a *mockAgent) handleV3(data []byte) []byte {
+	u := a.usm
+	m, err := decodeV3(data, nil)
+	if err != nil {
+		return nil
+	}
+
+	report := func(stat string) []byte {
+		b, _ := encodeV3(&v3Message{
+			MsgID: m.MsgID, EngineID: u.engineID, Boots: u.boots, Time: u.time.Load(), User: m.User,
+			PDU: &pdu{Type: pduReport,
+				Varbinds: []varbind{{OID: usmStatsPrefix + stat, Type: 0x41, Value: []byte{1}}}},
+		}, usmKeys{}, 0)
+		return b
+	}
+	switch {
+	case len(m.EngineID) == 0:
+		return report("4.0")
+	case m.User != u.cfg.Username:
+		return report("3.0")
+	}
+	if m, err = decodeV3(data, &u.keys); err != nil {
+		m, _ = decodeV3(data, nil)
+		return report("5.0")
+	}
+	if m.Flags&flagAuth != 0 && (m.Boots != u.boots || m.Time < u.time.Load()-150 || m.Time > u.time.Load()+150) {
+		return report("2.0")
+	}
+
+	resp, err := encodeV3(&v3Message{
+		MsgID:       m.MsgID,
+		Flags:       m.Flags &^ flagReportable,
+		EngineID:    u.engineID,
+		Boots:       u.boots,
+		Time:        u.time.Load(),
+		User:        m.User,
+		ContextName: m.ContextName,
+		PDU:         a.respond(Version3, m.PDU),
+	}, u.keys, 42)
+	if err != nil {
+		return nil
+	}
+	return resp
+}
+
+func mustEncode(version Version, community string, p *pdu) []byte {
+	b, err := encodeCommunityMessage(version, community, p)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func stringValue(s string) varbind {
+	return varbind{Type: tagOctetString, Value: []byte(s)}
+}
+
+func TestOIDRoundTrip(t *testing.T) {
+	for _, oid := range []string{"1.3.6.1.2.1.1.1.0", "1.3.6.1.4.1.9.9.46.1.3.1.1.2.1", "2.999.300000"} {
+		enc, err := encodeOIDString(oid)
+		if err != nil {
+			t.Fatalf("encodeOIDString(%s): %v", oid, err)
+		}
+		dec, err := decodeOID(enc)
+		if err != nil || dec != oid {
+			t.Errorf("decodeOID(encode(%s)) = %s, %v", oid, dec, err)
+		}
+	}
+	if _, err := encodeOIDString("1.3.x"); err == nil {
+		t.Error("encodeOIDString(1.3.x) should fail")
+	}
+
+	if compareOIDs("1.3.6.1.2.1.2.2.1.2.10", "1.3.6.1.2.1.2.2.1.2.9") <= 0 {
+		t.Error("compareOIDs should order arcs numerically")
+	}
+	if !inSubtree("1.3.6.1.2.1.1.5.0", "1.3.6.1.2.1.1") || inSubtree("1.3.6.1.2.1.10", "1.3.6.1.2.1.1") {
+		t.Error("inSubtree mismatch")
+	}
+}
+
+func TestWalkOID(t *testing.T) {
+	values := map[string]varbind{
+		"1.3.6.1.2.1.1.1.0": stringValue("Test router"),
+		"1.3.6.1.2.1.1.5.0": stringValue("rtr1"),
+		"1.3.6.1.2.1.3.1.0": stringValue("outside"),
+	}
+	for i := 1; i <= 25; i++ {
+		values[fmt.Sprintf("1.3.6.1.2.1.2.2.1.2.%d", i)] = stringValue(fmt.Sprintf("eth%d", i))
+	}
+	agent := newMockAgent(t, values)
+
+	for _, version := range []Version{Version1, Version2c} {
+		cfg := DefaultConfig()
+		cfg.Port = agent.port()
+		cfg.Version = version
+		cfg.Timeout = time.Second
+		s := New(cfg)
+
+		results, err := s.WalkOID(context.Background(), "127.0.0.1", "public", "1.3.6.1.2.1.2.2.1.2")
+		if err != nil {
+			t.Fatalf("%s walk: %v", version, err)
+		}
+		if len(results) != 25 {
+			t.Fatalf("%s walk returned %d results, want 25", version, len(results))
+		}
+		if results[9].OID != "1.3.6.1.2.1.2.2.1.2.10" || results[9].Value != "eth10" {
+			t.Errorf("%s results[9] = %+v", version, results[9])
+		}
+
+		// Walking to the end of the MIB stops cleanly
+		results, err = s.WalkOID(context.Background(), "127.0.0.1", "public", "1.3.6.1.2.1.3")
+		if err != nil || len(results) != 1 || results[0].Value != "outside" {
+			t.Errorf("%s end-of-MIB walk = %+v, %v", version, results, err)
+		}
+	}
+
+	// System subtree names well-known OIDs
+	cfg := DefaultConfig()
+	cfg.Port = agent.port()
+	results, err := New(cfg).WalkOID(context.Background(), "127.0.0.1", "public", "1.3.6.1.2.1.1")
+	if err != nil || len(results) != 2 || results[1].Name != "sysName" {
+		t.Errorf("system walk = %+v, %v", results, err)
+	}
+}
+
+func TestRequestRetries(t *testing.T) {
+	agent := newMockAgent(t, map[string]varbind{"1.3.6.1.2.1.1.5.0": stringValue("rtr1")})
+	agent.drop.Store(1)
+
+	cfg := DefaultConfig()
+	cfg.Port = agent.port()
+	cfg.Timeout = 200 * time.Millisecond
+	cfg.Retries = 1
+	s := New(cfg)
+
+	results, err := s.WalkOID(context.Background(), "127.0.0.1", "public", "1.3.6.1.2.1.1")
+	if err != nil || len(results) != 1 {
+		t.Fatalf("walk with one dropped request = %+v, %v", results, err)
+	}
+
+	agent.drop.Store(2)
+	cfg.Retries = 0
+	s = New(cfg)
+	if _, err := s.WalkOID(context.Background(), "127.0.0.1", "public", "1.3.6.1.2.1.1"); err == nil {
+		t.Error("expected timeout without retries")
+	}
+}
+
+func TestLocalizeKey(t *testing.T) {
+	// RFC 3414 A.3.1 and A.3.2
+	engineID := []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2}
+	tests := []struct {
+		auth AuthProtocol
+		want string
+	}{
+		{AuthMD5, "526f5eed9fcce26f8964c2930787d82b"},
+		{AuthSHA, "6695febc9288e36282235fc7151f128497b38f3f"},
+	}
+	for _, tt := range tests {
+		cfg := Config{AuthProtocol: tt.auth, AuthPassphrase: "maplesyrup"}
+		got := fmt.Sprintf("%x", cfg.localizedKeys(engineID).authKey)
+		if got != tt.want {
+			t.Errorf("%s localized key = %s, want %s", tt.auth, got, tt.want)
+		}
+	}
+}
+
+func TestSNMPv3(t *testing.T) {
+	values := map[string]varbind{
+		"1.3.6.1.2.1.1.1.0": stringValue("Hardened switch"),
+		"1.3.6.1.2.1.1.5.0": stringValue("sw1"),
+	}
+
+	tests := []struct {
+		name string
+		auth AuthProtocol
+		priv PrivProtocol
+	}{
+		{"noAuthNoPriv", AuthNone, PrivNone},
+		{"MD5/DES", AuthMD5, PrivDES},
+		{"SHA/AES", AuthSHA, PrivAES},
+		{"SHA/noPriv", AuthSHA, PrivNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := Config{
+				Username:       "monitor",
+				AuthProtocol:   tt.auth,
+				AuthPassphrase: "authpass123",
+				PrivProtocol:   tt.priv,
+				PrivPassphrase: "privpass456",
+			}
+			agent := newMockAgent(t, values)
+			agent.enableV3(user)
+
+			cfg := user
+			cfg.Version = Version3
+			cfg.Port = agent.port()
+			cfg.Timeout = time.Second
+			cfg.WalkOIDs = true
+			s := New(cfg)
+
+			device, err := s.ScanHost(context.Background(), "127.0.0.1")
+			if err != nil {
+				t.Fatalf("ScanHost: %v", err)
+			}
+			if device.SysDescr != "Hardened switch" || device.SysName != "sw1" || device.Username != "monitor" {
+				t.Errorf("device = %+v", device)
+			}
+
+			results, err := s.WalkOID(context.Background(), "127.0.0.1", "", "1.3.6.1.2.1.1")
+			if err != nil || len(results) != 2 {
+				t.Errorf("v3 walk = %+v, %v", results, err)
+			}
+		})
+	}
+}
+
+func TestSNMPv3Errors(t *testing.T) {
+	user := Config{Username: "monitor", AuthProtocol: AuthSHA, AuthPassphrase: "authpass123"}
+	agent := newMockAgent(t, map[string]varbind{"1.3.6.1.2.1.1.5.0": stringValue("sw1")})
+	agent.enableV3(user)
+
+	cfg := user
+	cfg.Version = Version3
+	cfg.Port = agent.port()
+	cfg.Timeout = time.Second
+	addr := fmt.Sprintf("127.0.0.1:%d", agent.port())
+
+	// Wrong passphrase
+	bad := cfg
+	bad.AuthPassphrase = "wrongpass1"
+	_, err := New(bad).GetOID(context.Background(), "127.0.0.1", "", "1.3.6.1.2.1.1.5.0")
+	if err == nil || !strings.Contains(err.Error(), "wrong digest") {
+		t.Errorf("wrong passphrase error = %v", err)
+	}
+
+	// Unknown user
+	bad = cfg
+	bad.Username = "nobody"
+	if _, err := New(bad).GetOID(context.Background(), "127.0.0.1", "", "1.3.6.1.2.1.1.5.0"); err == nil ||
+		!strings.Contains(err.Error(), "unknown user") {
+		t.Errorf("unknown user error = %v", err)
+	}
+
+	// Invalid configuration is rejected before sending
+	bad = cfg
+	bad.AuthProtocol = AuthNone
+	bad.PrivProtocol = PrivAES
+	if _, err := New(bad).GetOID(context.Background(), "127.0.0.1", "", "1.3.6.1.2.1.1.5.0"); err == nil {
+		t.Error("priv without auth should fail")
+	}
+
+	// The agent's clock jumps after discovery; the scanner resyncs once
+	s := New(cfg)
+	if _, err := s.getOIDDirect(context.Background(), addr, "", "1.3.6.1.2.1.1.5.0"); err != nil {
+		t.Fatalf("first get: %v", err)
+	}
+	agent.usm.time.Add(10000)
+	if v, err := s.getOIDDirect(context.Background(), addr, "", "1.3.6.1.2.1.1.5.0"); err != nil || v != "sw1" {
+		t.Errorf("get after clock jump = %q, %v", v, err)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	sysObjectID, _ := encodeOIDString("1.3.6.1.4.1.9.1.1208")
+	tests := []struct {
+		vb       varbind
+		want     string
+		typeName string
+	}{
+		{varbind{Type: tagOctetString, Value: []byte("eth0")}, "eth0", "STRING"},
+		{varbind{Type: tagInteger, Value: []byte{0xff, 0x38}}, "-200", "INTEGER"},
+		{varbind{Type: tagInteger, Value: []byte{0x00, 0xc8}}, "200", "INTEGER"},
+		{varbind{Type: tagOID, Value: sysObjectID}, "1.3.6.1.4.1.9.1.1208", "OID"},
+		{varbind{Type: tagTimeTicks, Value: []byte{0x00, 0xbc, 0x61, 0x4e}}, "12345678", "TimeTicks"},
+		{varbind{Type: tagCounter32, Value: []byte{0x00, 0xff, 0xff, 0xff, 0xff}}, "4294967295", "Counter32"},
+		{varbind{Type: tagGauge32, Value: []byte{0x3b, 0x9a, 0xca, 0x00}}, "1000000000", "Gauge32"},
+		{varbind{Type: tagCounter64, Value: []byte{0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}}, "18446744073709551615", "Counter64"},
+		{varbind{Type: tagIPAddress, Value: []byte{192, 168, 1, 1}}, "192.168.1.1", "IpAddress"},
+		{varbind{Type: tagNoSuchObject}, "", "noSuchObject"},
+	}
+	for _, tt := range tests {
+		if got := formatValue(tt.vb); got != tt.want {
+			t.Errorf("formatValue(%s) = %q, want %q", tt.typeName, got, tt.want)
+		}
+		if got := valueTypeName(tt.vb.Type); got != tt.typeName {
+			t.Errorf("valueTypeName(0x%02x) = %q, want %q", tt.vb.Type, got, tt.typeName)
+		}
+	}
+}
+
+func TestPopulateSysUpTime(t *testing.T) {
+	sysObjectID, _ := encodeOIDString("1.3.6.1.4.1.8072.3.2.10")
+	agent := newMockAgent(t, map[string]varbind{
+		"1.3.6.1.2.1.1.1.0": stringValue("Linux host"),
+		"1.3.6.1.2.1.1.2.0": {Type: tagOID, Value: sysObjectID},
+		"1.3.6.1.2.1.1.3.0": {Type: tagTimeTicks, Value: []byte{0x00, 0xbc, 0x61, 0x4e}},
+	})
+
+	cfg := DefaultConfig()
+	cfg.Port = agent.port()
+	cfg.SecurityAudit = false
+	device, err := New(cfg).ScanHost(context.Background(), "127.0.0.1")
+	if err != nil {
+		t.Fatalf("ScanHost: %v", err)
+	}
+
+	// 12345678 hundredths of a second
+	if want := 123456780 * time.Millisecond; device.SysUpTime != want {
+		t.Errorf("SysUpTime = %v, want %v", device.SysUpTime, want)
+	}
+	if device.OIDValues["1.3.6.1.2.1.1.3.0"] != "12345678" {
+		t.Errorf("sysUpTime value = %q", device.OIDValues["1.3.6.1.2.1.1.3.0"])
+	}
+	if device.SysObjectID != "1.3.6.1.4.1.8072.3.2.10" {
+		t.Errorf("SysObjectID = %q", device.SysObjectID)
+	}
+}
+
+func TestGetInterfaces(t *testing.T) {
+	intValue := func(v int64) varbind { return varbind{Type: tagInteger, Value: encodeInt(tagInteger, v)[2:]} }
+	uintValue := func(tag byte, v uint64) varbind {
+		b := []byte{0}
+		for i := 56; i >= 0; i -= 8 {
+			b = append(b, byte(v>>i))
+		}
+		return varbind{Type: tag, Value: b}
+	}
+
+	values := map[string]varbind{"1.3.6.1.2.1.2.1.0": intValue(3)} // ifNumber, outside ifTable
+	rows := []struct {
+		index     int
+		descr     string
+		typ       int64
+		speed     uint64
+		admin, op int64
+		in, out   uint64
+	}{
+		{1, "lo", 24, 10_000_000, 1, 1, 5000, 5000},
+		{2, "eth0", 6, 1_000_000_000, 1, 1, 3456789012, 98765},
+		{10, "eth1", 6, 100_000_000, 2, 2, 0, 0},
+	}
+	for _, r := range rows {
+		col := func(c int) string { return fmt.Sprintf("%s.%d.%d", ifEntry, c, r.index) }
+		values[col(1)] = intValue(int64(r.index))
+		values[col(ifDescr)] = stringValue(r.descr)
+		values[col(ifType)] = intValue(r.typ)
+		values[col(ifSpeed)] = uintValue(tagGauge32, r.speed)
+		values[col(ifAdminStatus)] = intValue(r.admin)
+		values[col(ifOperStatus)] = intValue(r.op)
+		values[col(ifInOctets)] = uintValue(tagCounter32, r.in)
+		values[col(ifOutOctets)] = uintValue(tagCounter32, r.out)
+	}
+	agent := newMockAgent(t, values)
+
+	cfg := DefaultConfig()
+	cfg.Port = agent.port()
+	ifaces, err := New(cfg).GetInterfaces(context.Background(), "127.0.0.1", "public")
+	if err != nil {
+		t.Fatalf("GetInterfaces: %v", err)
+	}
+	if len(ifaces) != 3 {
+		t.Fatalf("got %d interfaces, want 3: %+v", len(ifaces), ifaces)
+	}
+
+	eth0 := ifaces[1]
+	if eth0.Index != 2 || eth0.Descr != "eth0" || eth0.TypeName != "ethernetCsmacd" || eth0.Speed != 1_000_000_000 {
+		t.Errorf("eth0 = %+v", eth0)
+	}
+	if eth0.AdminStatus != "up" || eth0.OperStatus != "up" || eth0.InOctets != 3456789012 || eth0.OutOctets != 98765 {
+		t.Errorf("eth0 counters/status = %+v", eth0)
+	}
+	if ifaces[2].Index != 10 || ifaces[2].OperStatus != "down" {
+		t.Errorf("eth1 = %+v", ifaces[2])
+	}
+
+	out := FormatInterfaces("127.0.0.1", ifaces)
+	if !strings.Contains(out, "eth0") || !strings.Contains(out, "1 Gbps") {
+		t.Errorf("FormatInterfaces output missing fields:\n%s", out)
+	}
+}
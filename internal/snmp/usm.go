@@ -0,0 +1,591 @@
+package snmp
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net"
+	"time"
+)
+
+// AuthProtocol is an SNMPv3 USM authentication protocol.
+type AuthProtocol string
+
+const (
+	AuthNone AuthProtocol = ""
+	AuthMD5  AuthProtocol = "MD5" // HMAC-MD5-96
+	AuthSHA  AuthProtocol = "SHA" // HMAC-SHA-96
+)
+
+// PrivProtocol is an SNMPv3 USM privacy protocol.
+type PrivProtocol string
+
+const (
+	PrivNone PrivProtocol = ""
+	PrivDES  PrivProtocol = "DES" // CBC-DES
+	PrivAES  PrivProtocol = "AES" // CFB128-AES-128
+)
+
+const (
+	securityModelUSM = 3
+	maxMessageSize   = 65507
+
+	flagAuth       = 0x01
+	flagPriv       = 0x02
+	flagReportable = 0x04
+
+	pduReport = 0xa8
+
+	authParamLen = 12 // HMAC-96
+)
+
+// usmStatsPrefix is the parent of the USM error counters sent in reports.
+const usmStatsPrefix = "1.3.6.1.6.3.15.1.1."
+
+var usmStatsNames = map[string]string{
+	usmStatsPrefix + "1.0": "unsupported security level",
+	usmStatsPrefix + "2.0": "not in time window",
+	usmStatsPrefix + "3.0": "unknown user name",
+	usmStatsPrefix + "4.0": "unknown engine ID",
+	usmStatsPrefix + "5.0": "wrong digest (check auth passphrase)",
+	usmStatsPrefix + "6.0": "decryption error (check priv passphrase)",
+}
+
+// engine is an authoritative SNMP engine with the user's localized keys.
+type engine struct {
+	id      []byte
+	boots   int32
+	time    int32
+	synced  time.Time
+	authKey []byte
+	privKey []byte
+}
+
+// now estimates the engine's current snmpEngineTime.
+func (e *engine) now() int32 {
+	return e.time + int32(time.Since(e.synced)/time.Second)
+}
+
+// v3Message is a decoded SNMPv3 message with USM security parameters.
+type v3Message struct {
+	MsgID       int32
+	Flags       byte
+	EngineID    []byte
+	Boots       int32
+	Time        int32
+	User        string
+	ContextName string
+	PDU         *pdu
+}
+
+// usmKeys holds a user's security configuration and localized keys.
+type usmKeys struct {
+	auth    AuthProtocol
+	priv    PrivProtocol
+	authKey []byte
+	privKey []byte
+}
+
+func (c Config) validateV3() error {
+	if c.Username == "" {
+		return errors.New("SNMPv3 requires a username")
+	}
+	switch c.AuthProtocol {
+	case AuthNone:
+		if c.PrivProtocol != PrivNone {
+			return errors.New("SNMPv3 privacy requires an authentication protocol")
+		}
+	case AuthMD5, AuthSHA:
+		if len(c.AuthPassphrase) < 8 {
+			return errors.New("SNMPv3 auth passphrase must be at least 8 characters")
+		}
+	default:
+		return fmt.Errorf("unsupported SNMPv3 auth protocol %q", c.AuthProtocol)
+	}
+	switch c.PrivProtocol {
+	case PrivNone:
+	case PrivDES, PrivAES:
+		if len(c.PrivPassphrase) < 8 {
+			return errors.New("SNMPv3 priv passphrase must be at least 8 characters")
+		}
+	default:
+		return fmt.Errorf("unsupported SNMPv3 priv protocol %q", c.PrivProtocol)
+	}
+	return nil
+}
+
+func (c Config) securityFlags() byte {
+	var flags byte
+	if c.AuthProtocol != AuthNone {
+		flags |= flagAuth
+	}
+	if c.PrivProtocol != PrivNone {
+		flags |= flagPriv
+	}
+	return flags
+}
+
+func authHash(p AuthProtocol) func() hash.Hash {
+	if p == AuthSHA {
+		return sha1.New
+	}
+	return md5.New
+}
+
+// passwordToKey derives a user key from a passphrase (RFC 3414 A.2).
+func passwordToKey(newHash func() hash.Hash, password string) []byte {
+	h := newHash()
+	buf := make([]byte, 64)
+	pw := []byte(password)
+	idx := 0
+	for count := 0; count < 1048576; count += 64 {
+		for i := range buf {
+			buf[i] = pw[idx%len(pw)]
+			idx++
+		}
+		h.Write(buf)
+	}
+	return h.Sum(nil)
+}
+
+// localizeKey binds a user key to an engine ID (RFC 3414 2.6).
+func localizeKey(newHash func() hash.Hash, key, engineID []byte) []byte {
+	h := newHash()
+	h.Write(key)
+	h.Write(engineID)
+	h.Write(key)
+	return h.Sum(nil)
+}
+
+// localizedKeys derives the user's auth and priv keys for engineID.
+func (c Config) localizedKeys(engineID []byte) usmKeys {
+	k := usmKeys{auth: c.AuthProtocol, priv: c.PrivProtocol}
+	if c.AuthProtocol == AuthNone {
+		return k
+	}
+	newHash := authHash(c.AuthProtocol)
+	k.authKey = localizeKey(newHash, passwordToKey(newHash, c.AuthPassphrase), engineID)
+	if c.PrivProtocol != PrivNone {
+		k.privKey = localizeKey(newHash, passwordToKey(newHash, c.PrivPassphrase), engineID)
+	}
+	return k
+}
+
+// encodeV3 encodes m, encrypting and authenticating it as m.Flags requires.
+// salt seeds the privacy IV and must not repeat for a key.
+func encodeV3(m *v3Message, k usmKeys, salt uint64) ([]byte, error) {
+	pduBytes, err := m.PDU.encode()
+	if err != nil {
+		return nil, err
+	}
+	scoped := encodeTLV(tagOctetString, m.EngineID)
+	scoped = append(scoped, encodeTLV(tagOctetString, []byte(m.ContextName))...)
+	scoped = append(scoped, pduBytes...)
+	msgData := encodeTLV(tagSequence, scoped)
+
+	var privParams []byte
+	if m.Flags&flagPriv != 0 {
+		if len(k.privKey) < 16 {
+			return nil, errors.New("missing privacy key")
+		}
+		msgData, privParams, err = encryptScopedPDU(k.priv, k.privKey, m.Boots, m.Time, salt, msgData)
+		if err != nil {
+			return nil, err
+		}
+		msgData = encodeTLV(tagOctetString, msgData)
+	}
+
+	var authParams []byte
+	if m.Flags&flagAuth != 0 {
+		authParams = make([]byte, authParamLen)
+	}
+
+	// Security parameters, noting where the digest placeholder lands
+	sec := encodeTLV(tagOctetString, m.EngineID)
+	sec = append(sec, encodeInt(tagInteger, int64(m.Boots))...)
+	sec = append(sec, encodeInt(tagInteger, int64(m.Time))...)
+	sec = append(sec, encodeTLV(tagOctetString, []byte(m.User))...)
+	authOff := len(sec) + len(encodeLength(len(authParams))) + 1
+	sec = append(sec, encodeTLV(tagOctetString, authParams)...)
+	sec = append(sec, encodeTLV(tagOctetString, privParams)...)
+	secSeq := encodeTLV(tagSequence, sec)
+	authOff += len(secSeq) - len(sec)
+
+	global := encodeInt(tagInteger, int64(m.MsgID))
+	global = append(global, encodeInt(tagInteger, maxMessageSize)...)
+	global = append(global, encodeTLV(tagOctetString, []byte{m.Flags})...)
+	global = append(global, encodeInt(tagInteger, securityModelUSM)...)
+
+	body := encodeInt(tagInteger, int64(Version3))
+	body = append(body, encodeTLV(tagSequence, global)...)
+	secField := encodeTLV(tagOctetString, secSeq)
+	authOff += len(body) + len(secField) - len(secSeq)
+	body = append(body, secField...)
+	body = append(body, msgData...)
+
+	msg := encodeTLV(tagSequence, body)
+	authOff += len(msg) - len(body)
+
+	if m.Flags&flagAuth != 0 {
+		if len(k.authKey) == 0 {
+			return nil, errors.New("missing authentication key")
+		}
+		mac := hmac.New(authHash(k.auth), k.authKey)
+		mac.Write(msg)
+		copy(msg[authOff:authOff+authParamLen], mac.Sum(nil))
+	}
+	return msg, nil
+}
+
+// decodeV3 decodes an SNMPv3 message. If k is non-nil, authenticated
+// messages are verified and encrypted ones decrypted with its keys; if it
+// is nil, an encrypted message decodes with only its header and a nil PDU.
+func decodeV3(data []byte, k *usmKeys) (*v3Message, error) {
+	// Work on a copy so the digest can be zeroed in place for verification
+	data = append([]byte(nil), data...)
+
+	tag, body, _, err := decodeTLV(data)
+	if err != nil || tag != tagSequence {
+		return nil, errors.New("not an SNMP message")
+	}
+	tag, v, body, err := decodeTLV(body)
+	if err != nil || tag != tagInteger || Version(decodeInt(v)) != Version3 {
+		return nil, errors.New("not an SNMPv3 message")
+	}
+
+	m := &v3Message{}
+	tag, global, body, err := decodeTLV(body)
+	if err != nil || tag != tagSequence {
+		return nil, errors.New("malformed msgGlobalData")
+	}
+	var fields [4][]byte
+	for i := range fields {
+		if _, fields[i], global, err = decodeTLV(global); err != nil {
+			return nil, errors.New("malformed msgGlobalData")
+		}
+	}
+	m.MsgID = int32(decodeInt(fields[0]))
+	if len(fields[2]) != 1 {
+		return nil, errors.New("malformed msgFlags")
+	}
+	m.Flags = fields[2][0]
+	if decodeInt(fields[3]) != securityModelUSM {
+		return nil, errors.New("unsupported security model")
+	}
+
+	tag, secField, body, err := decodeTLV(body)
+	if err != nil || tag != tagOctetString {
+		return nil, errors.New("malformed security parameters")
+	}
+	tag, sec, _, err := decodeTLV(secField)
+	if err != nil || tag != tagSequence {
+		return nil, errors.New("malformed security parameters")
+	}
+	var params [6][]byte
+	for i := range params {
+		if _, params[i], sec, err = decodeTLV(sec); err != nil {
+			return nil, errors.New("malformed security parameters")
+		}
+	}
+	m.EngineID = append([]byte(nil), params[0]...)
+	m.Boots = int32(decodeInt(params[1]))
+	m.Time = int32(decodeInt(params[2]))
+	m.User = string(params[3])
+	authParams, privParams := params[4], params[5]
+
+	if m.Flags&flagAuth != 0 && k != nil {
+		if len(authParams) != authParamLen || len(k.authKey) == 0 {
+			return nil, errors.New("message authentication failed")
+		}
+		digest := append([]byte(nil), authParams...)
+		for i := range authParams {
+			authParams[i] = 0
+		}
+		mac := hmac.New(authHash(k.auth), k.authKey)
+		mac.Write(data)
+		if !hmac.Equal(digest, mac.Sum(nil)[:authParamLen]) {
+			return nil, errors.New("message authentication failed")
+		}
+	}
+
+	tag, msgData, _, err := decodeTLV(body)
+	if err != nil {
+		return nil, errors.New("malformed msgData")
+	}
+	if m.Flags&flagPriv != 0 {
+		if k == nil {
+			return m, nil
+		}
+		if len(k.privKey) < 16 || tag != tagOctetString {
+			return nil, errors.New("cannot decrypt scoped PDU")
+		}
+		plain, err := decryptScopedPDU(k.priv, k.privKey, m.Boots, m.Time, privParams, msgData)
+		if err != nil {
+			return nil, err
+		}
+		if tag, msgData, _, err = decodeTLV(plain); err != nil {
+			return nil, errors.New("decryption error")
+		}
+	}
+	if tag != tagSequence {
+		return nil, errors.New("malformed scoped PDU")
+	}
+
+	tag, _, scoped, err := decodeTLV(msgData)
+	if err != nil || tag != tagOctetString {
+		return nil, errors.New("malformed scoped PDU")
+	}
+	tag, ctxName, scoped, err := decodeTLV(scoped)
+	if err != nil || tag != tagOctetString {
+		return nil, errors.New("malformed scoped PDU")
+	}
+	m.ContextName = string(ctxName)
+	if m.PDU, err = decodePDU(scoped); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// encryptScopedPDU encrypts a scoped PDU, returning the ciphertext and the
+// msgPrivacyParameters (salt) to send with it.
+func encryptScopedPDU(p PrivProtocol, key []byte, boots, engineTime int32, salt uint64, plain []byte) ([]byte, []byte, error) {
+	switch p {
+	case PrivDES:
+		block, err := des.NewCipher(key[:8])
+		if err != nil {
+			return nil, nil, err
+		}
+		privParams := make([]byte, 8)
+		binary.BigEndian.PutUint32(privParams[:4], uint32(boots))
+		binary.BigEndian.PutUint32(privParams[4:], uint32(salt))
+		iv := make([]byte, 8)
+		for i := range iv {
+			iv[i] = key[8+i] ^ privParams[i]
+		}
+		if pad := len(plain) % 8; pad != 0 {
+			plain = append(plain, make([]byte, 8-pad)...)
+		}
+		out := make([]byte, len(plain))
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, plain)
+		return out, privParams, nil
+
+	case PrivAES:
+		block, err := aes.NewCipher(key[:16])
+		if err != nil {
+			return nil, nil, err
+		}
+		privParams := make([]byte, 8)
+		binary.BigEndian.PutUint64(privParams, salt)
+		return cfb128(block, aesIV(boots, engineTime, privParams), plain, false), privParams, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported SNMPv3 priv protocol %q", p)
+}
+
+// decryptScopedPDU reverses encryptScopedPDU.
+func decryptScopedPDU(p PrivProtocol, key []byte, boots, engineTime int32, privParams, data []byte) ([]byte, error) {
+	if len(privParams) != 8 {
+		return nil, errors.New("decryption error")
+	}
+	switch p {
+	case PrivDES:
+		if len(data)%8 != 0 {
+			return nil, errors.New("decryption error")
+		}
+		block, err := des.NewCipher(key[:8])
+		if err != nil {
+			return nil, err
+		}
+		iv := make([]byte, 8)
+		for i := range iv {
+			iv[i] = key[8+i] ^ privParams[i]
+		}
+		out := make([]byte, len(data))
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, data)
+		return out, nil
+
+	case PrivAES:
+		block, err := aes.NewCipher(key[:16])
+		if err != nil {
+			return nil, err
+		}
+		return cfb128(block, aesIV(boots, engineTime, privParams), data, true), nil
+	}
+	return nil, fmt.Errorf("unsupported SNMPv3 priv protocol %q", p)
+}
+
+// aesIV builds the AES IV from the engine boots and time and the salt
+// (RFC 3826 3.1.2.1).
+func aesIV(boots, engineTime int32, salt []byte) []byte {
+	iv := make([]byte, 16)
+	binary.BigEndian.PutUint32(iv[0:4], uint32(boots))
+	binary.BigEndian.PutUint32(iv[4:8], uint32(engineTime))
+	copy(iv[8:], salt)
+	return iv
+}
+
+// cfb128 runs full-block CFB mode over data.
+func cfb128(block cipher.Block, iv, data []byte, decrypt bool) []byte {
+	out := make([]byte, len(data))
+	reg := append([]byte(nil), iv...)
+	stream := make([]byte, block.BlockSize())
+	for i := 0; i < len(data); i += len(stream) {
+		block.Encrypt(stream, reg)
+		end := min(i+len(stream), len(data))
+		for j := i; j < end; j++ {
+			out[j] = data[j] ^ stream[j-i]
+		}
+		if decrypt {
+			copy(reg, data[i:end])
+		} else {
+			copy(reg, out[i:end])
+		}
+	}
+	return out
+}
+
+// reportError describes a Report PDU received instead of a response.
+func reportError(p *pdu) error {
+	for _, vb := range p.Varbinds {
+		if name, ok := usmStatsNames[vb.OID]; ok {
+			return fmt.Errorf("SNMPv3 report: %s", name)
+		}
+	}
+	if len(p.Varbinds) > 0 {
+		return fmt.Errorf("SNMPv3 report: %s", p.Varbinds[0].OID)
+	}
+	return errors.New("SNMPv3 report")
+}
+
+func isNotInTimeWindow(p *pdu) bool {
+	return len(p.Varbinds) > 0 && p.Varbinds[0].OID == usmStatsPrefix+"2.0"
+}
+
+// engineFor returns the cached engine for addr, discovering its engine ID
+// and clock with an unauthenticated probe the first time (RFC 3414 4).
+func (s *Scanner) engineFor(ctx context.Context, conn net.Conn, addr string) (*engine, error) {
+	s.mu.Lock()
+	eng, ok := s.engines[addr]
+	s.mu.Unlock()
+	if ok {
+		return eng, nil
+	}
+
+	var msgID int32
+	build := func() ([]byte, error) {
+		msgID = s.reqID.Add(1) & 0x7fffffff
+		return encodeV3(&v3Message{
+			MsgID: msgID,
+			Flags: flagReportable,
+			PDU:   &pdu{Type: pduGetRequest, RequestID: msgID},
+		}, usmKeys{}, 0)
+	}
+	var report *v3Message
+	parse := func(data []byte) bool {
+		m, err := decodeV3(data, nil)
+		if err != nil || m.MsgID != msgID || m.PDU == nil || m.PDU.Type != pduReport {
+			return false
+		}
+		report = m
+		return true
+	}
+	if err := s.exchange(ctx, conn, addr, build, parse); err != nil {
+		return nil, err
+	}
+	if len(report.EngineID) == 0 {
+		return nil, fmt.Errorf("%s did not report an SNMP engine ID", addr)
+	}
+
+	keys := s.config.localizedKeys(report.EngineID)
+	eng = &engine{
+		id:      report.EngineID,
+		boots:   report.Boots,
+		time:    report.Time,
+		synced:  time.Now(),
+		authKey: keys.authKey,
+		privKey: keys.privKey,
+	}
+	s.mu.Lock()
+	s.engines[addr] = eng
+	s.mu.Unlock()
+	return eng, nil
+}
+
+// requestV3 sends req over conn as an SNMPv3 message with the configured
+// user and security level, resynchronizing the engine clock once if the
+// agent reports the request fell outside its time window.
+func (s *Scanner) requestV3(ctx context.Context, conn net.Conn, addr string, req *pdu) (*pdu, error) {
+	if err := s.config.validateV3(); err != nil {
+		return nil, err
+	}
+	eng, err := s.engineFor(ctx, conn, addr)
+	if err != nil {
+		return nil, err
+	}
+	keys := usmKeys{
+		auth:    s.config.AuthProtocol,
+		priv:    s.config.PrivProtocol,
+		authKey: eng.authKey,
+		privKey: eng.privKey,
+	}
+
+	for attempt := 0; ; attempt++ {
+		var msgID int32
+		build := func() ([]byte, error) {
+			msgID = s.reqID.Add(1) & 0x7fffffff
+			req.RequestID = msgID
+			s.mu.Lock()
+			boots, now := eng.boots, eng.now()
+			s.mu.Unlock()
+			return encodeV3(&v3Message{
+				MsgID:       msgID,
+				Flags:       s.config.securityFlags() | flagReportable,
+				EngineID:    eng.id,
+				Boots:       boots,
+				Time:        now,
+				User:        s.config.Username,
+				ContextName: s.config.ContextName,
+				PDU:         req,
+			}, keys, s.privSalt.Add(1))
+		}
+		var resp *v3Message
+		parse := func(data []byte) bool {
+			m, err := decodeV3(data, &keys)
+			if err != nil || m.MsgID != msgID {
+				return false
+			}
+			// Only reports may come back unauthenticated
+			if m.PDU.Type != pduReport && m.Flags&flagAuth != s.config.securityFlags()&flagAuth {
+				return false
+			}
+			resp = m
+			return true
+		}
+		if err := s.exchange(ctx, conn, addr, build, parse); err != nil {
+			return nil, err
+		}
+
+		if resp.PDU.Type != pduReport {
+			if resp.Flags&flagAuth != 0 {
+				s.mu.Lock()
+				eng.boots, eng.time, eng.synced = resp.Boots, resp.Time, time.Now()
+				s.mu.Unlock()
+			}
+			return resp.PDU, nil
+		}
+		if attempt == 0 && isNotInTimeWindow(resp.PDU) {
+			s.mu.Lock()
+			eng.boots, eng.time, eng.synced = resp.Boots, resp.Time, time.Now()
+			s.mu.Unlock()
+			continue
+		}
+		return nil, reportError(resp.PDU)
+	}
+}
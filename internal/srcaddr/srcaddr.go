@@ -0,0 +1,92 @@
+// Package srcaddr resolves the local source address for outbound probes, so
+// multi-homed hosts can choose which interface or IP a probe leaves from.
+package srcaddr
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// Resolve returns the IPv4 source address selected by an interface name
+// and/or a source IP. With only an interface, its first IPv4 address is
+// used; with both, the IP must belong to the interface. It returns "" when
+// neither is given, meaning the OS picks the source from the routing table.
+func Resolve(iface, sourceIP string) (string, error) {
+	var ip net.IP
+	if sourceIP != "" {
+		ip = net.ParseIP(sourceIP)
+		if ip == nil || ip.To4() == nil {
+			return "", fmt.Errorf("invalid source IP %q (IPv4 address required)", sourceIP)
+		}
+	}
+
+	if iface == "" {
+		if ip == nil {
+			return "", nil
+		}
+		if !isLocal(ip) {
+			return "", fmt.Errorf("source IP %s is not assigned to any local interface", ip)
+		}
+		return ip.String(), nil
+	}
+
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return "", fmt.Errorf("interface %s: %w", iface, err)
+	}
+	if ifi.Flags&net.FlagUp == 0 {
+		return "", fmt.Errorf("interface %s is down", iface)
+	}
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return "", fmt.Errorf("interface %s: %w", iface, err)
+	}
+
+	for _, a := range addrs {
+		ipNet, ok := a.(*net.IPNet)
+		if !ok || ipNet.IP.To4() == nil {
+			continue
+		}
+		if ip == nil || ipNet.IP.Equal(ip) {
+			return ipNet.IP.String(), nil
+		}
+	}
+	if ip != nil {
+		return "", fmt.Errorf("source IP %s is not assigned to interface %s", ip, iface)
+	}
+	return "", fmt.Errorf("interface %s has no IPv4 address", iface)
+}
+
+// isLocal reports whether ip is assigned to a local interface.
+func isLocal(ip net.IP) bool {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return false
+	}
+	for _, a := range addrs {
+		if ipNet, ok := a.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Dialer returns a TCP dialer bound to source, or an unbound dialer when
+// source is empty.
+func Dialer(source string, timeout time.Duration) *net.Dialer {
+	d := &net.Dialer{Timeout: timeout}
+	if ip := net.ParseIP(source); ip != nil {
+		d.LocalAddr = &net.TCPAddr{IP: ip}
+	}
+	return d
+}
+
+// ListenAddr returns the address to bind ICMP sockets to: source, or
+// 0.0.0.0 when source is empty.
+func ListenAddr(source string) string {
+	if source == "" {
+		return "0.0.0.0"
+	}
+	return source
+}
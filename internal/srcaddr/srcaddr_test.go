@@ -0,0 +1,93 @@
+package srcaddr
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// loopback returns the loopback interface name and its IPv4 address.
+func loopback(t *testing.T) (string, string) {
+	t.Helper()
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		t.Skipf("cannot list interfaces: %v", err)
+	}
+	for _, ifi := range ifaces {
+		if ifi.Flags&net.FlagLoopback == 0 || ifi.Flags&net.FlagUp == 0 {
+			continue
+		}
+		addrs, _ := ifi.Addrs()
+		for _, a := range addrs {
+			if ipNet, ok := a.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+				return ifi.Name, ipNet.IP.String()
+			}
+		}
+	}
+	t.Skip("no IPv4 loopback interface")
+	return "", ""
+}
+
+func TestResolve(t *testing.T) {
+	lo, loIP := loopback(t)
+
+	tests := []struct {
+		name     string
+		iface    string
+		sourceIP string
+		want     string
+		wantErr  bool
+	}{
+		{"default route", "", "", "", false},
+		{"interface", lo, "", loIP, false},
+		{"source IP", "", loIP, loIP, false},
+		{"interface and its IP", lo, loIP, loIP, false},
+		{"IP not on interface", lo, "192.0.2.1", "", true},
+		{"IP not local", "", "192.0.2.1", "", true},
+		{"invalid IP", "", "not-an-ip", "", true},
+		{"IPv6 source", "", "::1", "", true},
+		{"unknown interface", "nns-no-such-if0", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolve(tt.iface, tt.sourceIP)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve(%q, %q) error = %v, wantErr %v", tt.iface, tt.sourceIP, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve(%q, %q) = %q, want %q", tt.iface, tt.sourceIP, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDialer(t *testing.T) {
+	if d := Dialer("", time.Second); d.LocalAddr != nil || d.Timeout != time.Second {
+		t.Errorf("Dialer(\"\") = %+v, want unbound with 1s timeout", d)
+	}
+
+	_, loIP := loopback(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	conn, err := Dialer(loIP, time.Second).Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+	if got := conn.LocalAddr().(*net.TCPAddr).IP.String(); got != loIP {
+		t.Errorf("local address = %s, want %s", got, loIP)
+	}
+}
+
+func TestListenAddr(t *testing.T) {
+	if got := ListenAddr(""); got != "0.0.0.0" {
+		t.Errorf("ListenAddr(\"\") = %q, want 0.0.0.0", got)
+	}
+	if got := ListenAddr("10.0.0.5"); got != "10.0.0.5" {
+		t.Errorf("ListenAddr(10.0.0.5) = %q", got)
+	}
+}
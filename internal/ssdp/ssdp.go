@@ -4,6 +4,7 @@ package ssdp
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"net"
 	"net/http"
@@ -41,6 +42,7 @@ type Config struct {
 	SearchTarget string        // Device type to search for
 	MX           int           // Maximum wait time for responses (in seconds)
 	Interface    string        // Network interface to use (empty = all)
+	Addr         string        // Address M-SEARCH is sent to (default SSDPMulticastAddr)
 }
 
 // DefaultConfig returns default discovery configuration.
@@ -68,11 +70,21 @@ func New(cfg Config) *Scanner {
 	if cfg.MX <= 0 {
 		cfg.MX = 2
 	}
+	if cfg.Addr == "" {
+		cfg.Addr = SSDPMulticastAddr
+	}
 	return &Scanner{config: cfg}
 }
 
 // Discover performs SSDP discovery and returns found devices.
 func (s *Scanner) Discover() ([]Device, error) {
+	return s.DiscoverFunc(context.Background(), nil)
+}
+
+// DiscoverFunc performs SSDP discovery like Discover, calling fn (if not
+// nil) for each new device as soon as its response arrives. It returns the
+// devices found so far when ctx is done.
+func (s *Scanner) DiscoverFunc(ctx context.Context, fn func(Device)) ([]Device, error) {
 	// Create UDP connection
 	conn, err := net.ListenUDP("udp4", nil)
 	if err != nil {
@@ -80,16 +92,18 @@ func (s *Scanner) Discover() ([]Device, error) {
 	}
 	defer conn.Close()
 
-	// Set read deadline
+	// Set read deadline, cut short if ctx is done first
 	if err := conn.SetReadDeadline(time.Now().Add(s.config.Timeout)); err != nil {
 		return nil, fmt.Errorf("failed to set deadline: %w", err)
 	}
+	stop := context.AfterFunc(ctx, func() { conn.SetReadDeadline(time.Now()) })
+	defer stop()
 
 	// Create M-SEARCH request
 	request := s.buildMSearchRequest()
 
 	// Resolve multicast address
-	addr, err := net.ResolveUDPAddr("udp4", SSDPMulticastAddr)
+	addr, err := net.ResolveUDPAddr("udp4", s.config.Addr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve multicast address: %w", err)
 	}
@@ -125,12 +139,17 @@ func (s *Scanner) Discover() ([]Device, error) {
 		device.ResponseTime = time.Since(startTime)
 
 		// Deduplicate
-		if device.USN != "" && !seen[device.USN] {
-			seen[device.USN] = true
-			devices = append(devices, device)
-		} else if device.USN == "" && device.Location != "" && !seen[device.Location] {
-			seen[device.Location] = true
-			devices = append(devices, device)
+		key := device.USN
+		if key == "" {
+			key = device.Location
+		}
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		devices = append(devices, device)
+		if fn != nil {
+			fn(device)
 		}
 	}
 
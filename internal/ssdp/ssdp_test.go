@@ -1,6 +1,9 @@
 package ssdp
 
 import (
+	"context"
+	"fmt"
+	"net"
 	"strings"
 	"testing"
 	"time"
@@ -219,6 +222,49 @@ func TestDeduplicateDevices(t *testing.T) {
 	}
 }
 
+func TestDiscoverFunc(t *testing.T) {
+	device, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer device.Close()
+	go func() {
+		buf := make([]byte, 1500)
+		_, from, err := device.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		for _, usn := range []string{"uuid:a::upnp:rootdevice", "uuid:a::upnp:rootdevice", "uuid:b"} {
+			reply := fmt.Sprintf("HTTP/1.1 200 OK\r\nLOCATION: http://127.0.0.1/desc.xml\r\nUSN: %s\r\n\r\n", usn)
+			device.WriteTo([]byte(reply), from)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := New(Config{Timeout: 10 * time.Second, Addr: device.LocalAddr().String()})
+
+	var calls []string
+	start := time.Now()
+	devices, err := s.DiscoverFunc(ctx, func(d Device) {
+		calls = append(calls, d.USN)
+		if len(calls) == 2 {
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Fatalf("DiscoverFunc() error = %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("DiscoverFunc() should return when ctx is cancelled")
+	}
+	if len(devices) != 2 || len(calls) != 2 || calls[1] != "uuid:b" {
+		t.Errorf("devices = %d, calls = %v, want 2 unique devices", len(devices), calls)
+	}
+	if devices[0].IP != "127.0.0.1" {
+		t.Errorf("IP = %q, want 127.0.0.1", devices[0].IP)
+	}
+}
+
 // Integration test - requires network
 func TestDiscover(t *testing.T) {
 	if testing.Short() {
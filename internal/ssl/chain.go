@@ -0,0 +1,176 @@
+package ssl
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxAIASize caps the size of a certificate downloaded from an AIA URL.
+const maxAIASize = 1 << 20
+
+// chainProblems validates certs (leaf first, as sent by the server) for
+// host at time now. It reports whether the chain leads to a trusted root
+// and, if there are defects, describes each one: name mismatch, expired or
+// not-yet-valid certificates, misordered certificates, missing
+// intermediates and untrusted roots.
+func (a *Analyzer) chainProblems(host string, certs []*x509.Certificate, now time.Time) (bool, []string) {
+	problems := []string{}
+	leaf := certs[0]
+
+	if err := leaf.VerifyHostname(host); err != nil {
+		if names := certNames(leaf); len(names) > 0 {
+			problems = append(problems, fmt.Sprintf("Name mismatch: %s is not covered by the certificate (valid for %s)",
+				host, strings.Join(names, ", ")))
+		} else {
+			problems = append(problems, fmt.Sprintf("Name mismatch: certificate has no Subject Alternative Names, so %s cannot match (the Common Name is ignored by modern clients)", host))
+		}
+	}
+
+	expired := false
+	for i, cert := range certs {
+		switch {
+		case now.After(cert.NotAfter):
+			expired = true
+			problems = append(problems, fmt.Sprintf("%s expired on %s", chainLabel(i, cert), cert.NotAfter.Format("2006-01-02")))
+		case now.Before(cert.NotBefore):
+			expired = true
+			problems = append(problems, fmt.Sprintf("%s is not valid until %s", chainLabel(i, cert), cert.NotBefore.Format("2006-01-02")))
+		}
+		if i+1 < len(certs) && cert.CheckSignatureFrom(certs[i+1]) != nil {
+			problems = append(problems, fmt.Sprintf("%s is not signed by the next certificate sent (%s): chain is out of order or contains an unrelated certificate",
+				chainLabel(i, cert), commonName(certs[i+1])))
+		}
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	opts := x509.VerifyOptions{
+		Roots:         a.Roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+	}
+
+	_, err := leaf.Verify(opts)
+	if err == nil {
+		return true, problems
+	}
+
+	var unknown x509.UnknownAuthorityError
+	var invalid x509.CertificateInvalidError
+	switch {
+	case errors.As(err, &unknown):
+		problems = append(problems, a.untrustedProblem(certs, opts))
+	case errors.As(err, &invalid) && invalid.Reason == x509.Expired && expired:
+		// Already reported per certificate
+	case errors.As(err, &invalid) && invalid.Reason == x509.NotAuthorizedToSign:
+		problems = append(problems, fmt.Sprintf("%s signed another certificate but is not a CA", commonName(invalid.Cert)))
+	default:
+		problems = append(problems, fmt.Sprintf("Chain validation failed: %v", err))
+	}
+	return false, problems
+}
+
+// untrustedProblem explains why the chain does not reach a trusted root:
+// either the server sent a root that is not trusted, or it left out an
+// intermediate, which is fetched from the AIA URL when possible to confirm
+// that clients that download missing issuers (browsers) would succeed.
+func (a *Analyzer) untrustedProblem(certs []*x509.Certificate, opts x509.VerifyOptions) string {
+	top := certs[len(certs)-1]
+	if isSelfSigned(top) {
+		if len(certs) == 1 {
+			return "Certificate is self-signed and not trusted"
+		}
+		return fmt.Sprintf("Root CA %s is not trusted by the system", commonName(top))
+	}
+
+	msg := fmt.Sprintf("Missing intermediate: the server did not send %s, the issuer of %s",
+		top.Issuer.String(), commonName(top))
+	if len(top.IssuingCertificateURL) == 0 {
+		return msg + " (no AIA URL to fetch it from)"
+	}
+
+	url := top.IssuingCertificateURL[0]
+	if !a.FetchAIA {
+		return msg + fmt.Sprintf(" (available via AIA at %s)", url)
+	}
+
+	issuer, err := fetchAIA(url, a.Timeout)
+	if err != nil {
+		return msg + fmt.Sprintf(" (AIA fetch from %s failed: %v)", url, err)
+	}
+	opts.Intermediates.AddCert(issuer)
+	if _, err := certs[0].Verify(opts); err != nil {
+		return msg + fmt.Sprintf(" (fetched from AIA at %s, but the chain still fails: %v)", url, err)
+	}
+	return msg + fmt.Sprintf(" (AIA-fetchable from %s: browsers fetch it and succeed, but clients such as curl and most libraries fail)", url)
+}
+
+// fetchAIA downloads an issuer certificate from an AIA caIssuers URL. The
+// response is usually DER but some CAs serve PEM.
+func fetchAIA(url string, timeout time.Duration) (*x509.Certificate, error) {
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAIASize))
+	if err != nil {
+		return nil, err
+	}
+
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	return x509.ParseCertificate(data)
+}
+
+// isSelfSigned reports whether cert is signed by its own key. The
+// signature is checked directly because CheckSignatureFrom rejects
+// self-signed leaf certificates that are not marked as CAs.
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawSubject, cert.RawIssuer) &&
+		cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+// chainLabel names the certificate at position i of the chain.
+func chainLabel(i int, cert *x509.Certificate) string {
+	if i == 0 {
+		return "Leaf certificate " + commonName(cert)
+	}
+	if isSelfSigned(cert) {
+		return "Root certificate " + commonName(cert)
+	}
+	return fmt.Sprintf("Intermediate #%d %s", i, commonName(cert))
+}
+
+// commonName returns the certificate's subject CN, or the full subject if
+// it has none.
+func commonName(cert *x509.Certificate) string {
+	if cert.Subject.CommonName != "" {
+		return `"` + cert.Subject.CommonName + `"`
+	}
+	return `"` + cert.Subject.String() + `"`
+}
+
+// certNames lists the DNS and IP names a certificate is valid for.
+func certNames(cert *x509.Certificate) []string {
+	names := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	return names
+}
@@ -0,0 +1,80 @@
+package ssl
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// pinPrefix is the hash prefix of a public key pin, in the format used by
+// curl's --pinnedpubkey and HPKP.
+const pinPrefix = "sha256//"
+
+// PinCheck records the outcome of verifying public key pins.
+type PinCheck struct {
+	Pins        []string `json:"pins"`
+	Matched     bool     `json:"matched"`
+	MatchedPin  string   `json:"matched_pin,omitempty"`
+	MatchedCert string   `json:"matched_cert,omitempty"` // Subject of the certificate whose key matched
+}
+
+// SPKIPin returns the sha256//BASE64 pin of the certificate's
+// SubjectPublicKeyInfo.
+func SPKIPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return pinPrefix + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// ParsePin validates a sha256//BASE64 pin and returns it in canonical form.
+func ParsePin(pin string) (string, error) {
+	encoded, ok := strings.CutPrefix(strings.TrimSpace(pin), pinPrefix)
+	if !ok {
+		return "", fmt.Errorf("invalid pin %q: must start with %s", pin, pinPrefix)
+	}
+	sum, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("invalid pin %q: %v", pin, err)
+	}
+	if len(sum) != sha256.Size {
+		return "", fmt.Errorf("invalid pin %q: hash is %d bytes, want %d", pin, len(sum), sha256.Size)
+	}
+	return pinPrefix + encoded, nil
+}
+
+// VerifyPins reports whether any certificate in the presented chain has a
+// public key matching one of pins. The outcome is stored in r.Pins, and a
+// mismatch is added to the security issues and lowers the grade, since it
+// means the server no longer presents an expected key.
+func (r *Result) VerifyPins(pins []string) bool {
+	check := &PinCheck{Pins: pins}
+	r.Pins = check
+
+	certs := r.Chain.Certificates
+	if len(certs) == 0 {
+		certs = []CertInfo{r.Certificate}
+	}
+
+	for _, cert := range certs {
+		for _, pin := range pins {
+			if strings.TrimSpace(pin) == cert.SPKIPin {
+				check.Matched = true
+				check.MatchedPin = cert.SPKIPin
+				check.MatchedCert = cert.Subject
+				return true
+			}
+		}
+	}
+
+	r.Security.Issues = append(r.Security.Issues, SecurityIssue{
+		Severity: "critical",
+		Message:  fmt.Sprintf("Public key pin mismatch: none of %d pin(s) match the certificate chain", len(pins)),
+	})
+	r.Security.Score -= 40
+	if r.Security.Score < 0 {
+		r.Security.Score = 0
+	}
+	r.Security.Grade = scoreToGrade(r.Security.Score)
+	return false
+}
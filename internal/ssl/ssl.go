@@ -29,6 +29,7 @@ type CertInfo struct {
 	PublicKeySize int       `json:"public_key_size"`
 	IsCA          bool      `json:"is_ca"`
 	Fingerprint   string    `json:"fingerprint_sha256"`
+	SPKIPin       string    `json:"spki_pin"` // sha256//BASE64 of the public key, as used for pinning
 	Version       int       `json:"version"`
 }
 
@@ -38,6 +39,7 @@ type ChainInfo struct {
 	Length         int        `json:"length"`
 	IsComplete     bool       `json:"is_complete"`
 	HasTrustedRoot bool       `json:"has_trusted_root"`
+	Problems       []string   `json:"problems"` // Why the chain fails validation, if it does
 }
 
 // SecurityIssue represents a security problem found.
@@ -68,6 +70,7 @@ type Result struct {
 	Chain       ChainInfo     `json:"chain"`
 	Security    SecurityInfo  `json:"security"`
 	ConnectTime time.Duration `json:"connect_time"`
+	Pins        *PinCheck     `json:"pins,omitempty"`
 	Error       error         `json:"-"`
 	ErrorMsg    string        `json:"error,omitempty"`
 }
@@ -76,6 +79,8 @@ type Result struct {
 type Analyzer struct {
 	Timeout            time.Duration
 	InsecureSkipVerify bool
+	Roots              *x509.CertPool // Trust store for chain validation (nil = system roots)
+	FetchAIA           bool           // Download missing intermediates from AIA URLs to diagnose incomplete chains
 }
 
 // NewAnalyzer creates a new Analyzer with defaults.
@@ -83,6 +88,7 @@ func NewAnalyzer() *Analyzer {
 	return &Analyzer{
 		Timeout:            10 * time.Second,
 		InsecureSkipVerify: true, // We want to analyze even bad certs
+		FetchAIA:           true,
 	}
 }
 
@@ -133,7 +139,7 @@ func (a *Analyzer) Analyze(host string, port int) *Result {
 	result.Certificate = parseCertInfo(leaf)
 
 	// Chain
-	result.Chain = analyzeChain(state.PeerCertificates, state.VerifiedChains)
+	result.Chain = a.analyzeChain(host, state.PeerCertificates)
 
 	// Security analysis
 	result.Security = analyzeSecurityWithBase(result.Security, leaf, state)
@@ -160,6 +166,7 @@ func parseCertInfo(cert *x509.Certificate) CertInfo {
 	// Fingerprint
 	fp := sha256.Sum256(cert.Raw)
 	info.Fingerprint = hex.EncodeToString(fp[:])
+	info.SPKIPin = SPKIPin(cert)
 
 	// Public key size
 	info.PublicKeySize = getPublicKeySize(cert)
@@ -192,11 +199,14 @@ func getPublicKeySize(cert *x509.Certificate) int {
 	}
 }
 
-// analyzeChain analyzes the certificate chain.
-func analyzeChain(certs []*x509.Certificate, verifiedChains [][]*x509.Certificate) ChainInfo {
+// analyzeChain analyzes the certificate chain presented for host. The
+// chain is validated here rather than during the handshake so that broken
+// chains can still be inspected and their problems explained.
+func (a *Analyzer) analyzeChain(host string, certs []*x509.Certificate) ChainInfo {
 	chain := ChainInfo{
 		Length:       len(certs),
 		Certificates: make([]CertInfo, len(certs)),
+		Problems:     []string{},
 	}
 
 	for i, cert := range certs {
@@ -209,8 +219,9 @@ func analyzeChain(certs []*x509.Certificate, verifiedChains [][]*x509.Certificat
 		chain.IsComplete = lastCert.IsCA
 	}
 
-	// Check if we have verified chains (means trusted root)
-	chain.HasTrustedRoot = len(verifiedChains) > 0
+	if len(certs) > 0 {
+		chain.HasTrustedRoot, chain.Problems = a.chainProblems(host, certs, time.Now())
+	}
 
 	return chain
 }
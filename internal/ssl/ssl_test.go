@@ -1,7 +1,17 @@
 package ssl
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseHostPort(t *testing.T) {
@@ -152,6 +162,175 @@ func TestExpiryStatus(t *testing.T) {
 	}
 }
 
+// testCert issues a certificate for cn signed by parent (self-signed when
+// parent is nil).
+func testCert(t *testing.T, cn string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, isCA bool, notAfter time.Time, aia string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-48 * time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if !isCA {
+		tmpl.DNSNames = []string{cn}
+	}
+	if aia != "" {
+		tmpl.IssuingCertificateURL = []string{aia}
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func TestChainProblems(t *testing.T) {
+	later := time.Now().Add(365 * 24 * time.Hour)
+	root, rootKey := testCert(t, "Test Root", nil, nil, true, later, "")
+	inter, interKey := testCert(t, "Test Intermediate", root, rootKey, true, later, "")
+
+	aia := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(inter.Raw)
+	}))
+	defer aia.Close()
+
+	leaf, _ := testCert(t, "www.example.test", inter, interKey, false, later, aia.URL)
+	expiredInter, expiredKey := testCert(t, "Expired Intermediate", root, rootKey, true, time.Now().Add(-time.Hour), "")
+	expiredLeaf, _ := testCert(t, "www.example.test", expiredInter, expiredKey, false, later, "")
+	selfSigned, _ := testCert(t, "www.example.test", nil, nil, false, later, "")
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+
+	tests := []struct {
+		name    string
+		host    string
+		certs   []*x509.Certificate
+		noAIA   bool
+		trusted bool
+		want    []string
+	}{
+		{"complete chain", "www.example.test", []*x509.Certificate{leaf, inter}, false, true, nil},
+		{"name mismatch", "other.example.test", []*x509.Certificate{leaf, inter}, false, true, []string{"Name mismatch", "www.example.test"}},
+		{"missing intermediate AIA", "www.example.test", []*x509.Certificate{leaf}, false, false, []string{"Missing intermediate", "Test Intermediate", "AIA-fetchable"}},
+		{"missing intermediate no fetch", "www.example.test", []*x509.Certificate{leaf}, true, false, []string{"Missing intermediate", "available via AIA"}},
+		{"expired intermediate", "www.example.test", []*x509.Certificate{expiredLeaf, expiredInter}, false, false, []string{"Intermediate #1", "Expired Intermediate", "expired on"}},
+		{"out of order", "www.example.test", []*x509.Certificate{leaf, root}, false, false, []string{"not signed by the next certificate"}},
+		{"self-signed", "www.example.test", []*x509.Certificate{selfSigned}, false, false, []string{"self-signed"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAnalyzer()
+			a.Roots = roots
+			a.FetchAIA = !tt.noAIA
+			trusted, problems := a.chainProblems(tt.host, tt.certs, time.Now())
+			if trusted != tt.trusted {
+				t.Errorf("trusted = %v, want %v (problems: %v)", trusted, tt.trusted, problems)
+			}
+			if tt.want == nil {
+				if len(problems) != 0 {
+					t.Errorf("problems = %v, want none", problems)
+				}
+				return
+			}
+			joined := strings.Join(problems, "\n")
+			for _, w := range tt.want {
+				if !strings.Contains(joined, w) {
+					t.Errorf("problems %q missing %q", joined, w)
+				}
+			}
+		})
+	}
+}
+
+func TestParsePin(t *testing.T) {
+	valid := "sha256//" + strings.Repeat("A", 43) + "="
+	tests := []struct {
+		pin     string
+		wantErr bool
+	}{
+		{valid, false},
+		{"  " + valid + " ", false},
+		{strings.Repeat("A", 43) + "=", true},
+		{"sha1//" + strings.Repeat("A", 27) + "=", true},
+		{"sha256//not base64!", true},
+		{"sha256//AAAA", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePin(tt.pin)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePin(%q) error = %v, wantErr %v", tt.pin, err, tt.wantErr)
+		}
+		if err == nil && got != valid {
+			t.Errorf("ParsePin(%q) = %q, want %q", tt.pin, got, valid)
+		}
+	}
+}
+
+func TestVerifyPins(t *testing.T) {
+	later := time.Now().Add(365 * 24 * time.Hour)
+	root, rootKey := testCert(t, "Test Root", nil, nil, true, later, "")
+	leaf, _ := testCert(t, "www.example.test", root, rootKey, false, later, "")
+	other, _ := testCert(t, "other.example.test", nil, nil, false, later, "")
+
+	newResult := func() *Result {
+		a := NewAnalyzer()
+		r := &Result{
+			Certificate: parseCertInfo(leaf),
+			Chain:       a.analyzeChain("www.example.test", []*x509.Certificate{leaf, root}),
+			Security:    SecurityInfo{Score: 100, Grade: "A+", Issues: []SecurityIssue{}},
+		}
+		return r
+	}
+
+	if got := newResult().Certificate.SPKIPin; got != SPKIPin(leaf) || !strings.HasPrefix(got, "sha256//") {
+		t.Errorf("CertInfo.SPKIPin = %q, want %q", got, SPKIPin(leaf))
+	}
+
+	r := newResult()
+	if !r.VerifyPins([]string{SPKIPin(other), SPKIPin(root)}) {
+		t.Fatal("VerifyPins should match the root key")
+	}
+	if r.Pins == nil || !r.Pins.Matched || r.Pins.MatchedPin != SPKIPin(root) || !strings.Contains(r.Pins.MatchedCert, "Test Root") {
+		t.Errorf("Pins = %+v, want match on root", r.Pins)
+	}
+	if r.Security.Grade != "A+" {
+		t.Errorf("grade = %s after match, want unchanged A+", r.Security.Grade)
+	}
+
+	r = newResult()
+	if r.VerifyPins([]string{SPKIPin(other)}) {
+		t.Fatal("VerifyPins should not match an unrelated key")
+	}
+	if r.Pins.Matched {
+		t.Error("Pins.Matched should be false")
+	}
+	if r.Security.Score != 60 || r.Security.Grade != "D" {
+		t.Errorf("score/grade = %d/%s after mismatch, want 60/D", r.Security.Score, r.Security.Grade)
+	}
+	if len(r.Security.Issues) != 1 || r.Security.Issues[0].Severity != "critical" {
+		t.Errorf("issues = %v, want one critical pin issue", r.Security.Issues)
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsHelper(s, substr))
 }
@@ -0,0 +1,302 @@
+// Package starttls negotiates in-protocol TLS upgrades (STARTTLS) for mail,
+// directory and database protocols, and provides the SMTP/FTP-style
+// command helpers used to speak them.
+package starttls
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported protocols.
+const (
+	SMTP     = "smtp"
+	IMAP     = "imap"
+	POP3     = "pop3"
+	LDAP     = "ldap"
+	Postgres = "postgres"
+)
+
+// ehloName is the client name announced in SMTP EHLO.
+const ehloName = "nns.localhost"
+
+// DefaultPorts maps each protocol to the port it usually upgrades on.
+var DefaultPorts = map[string]int{
+	SMTP:     25,
+	IMAP:     143,
+	POP3:     110,
+	LDAP:     389,
+	Postgres: 5432,
+}
+
+// Protocols returns the supported protocol names, sorted.
+func Protocols() []string {
+	names := make([]string, 0, len(DefaultPorts))
+	for name := range DefaultPorts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Negotiate performs the plaintext upgrade for protocol on conn. On success
+// the server expects a TLS ClientHello next.
+func Negotiate(conn net.Conn, protocol string) error {
+	switch strings.ToLower(protocol) {
+	case SMTP:
+		return negotiateSMTP(conn)
+	case IMAP:
+		return negotiateIMAP(conn)
+	case POP3:
+		return negotiatePOP3(conn)
+	case LDAP:
+		return negotiateLDAP(conn)
+	case Postgres:
+		return negotiatePostgres(conn)
+	default:
+		return fmt.Errorf("unsupported STARTTLS protocol %q (use %s)", protocol, strings.Join(Protocols(), ", "))
+	}
+}
+
+func negotiateSMTP(conn net.Conn) error {
+	r := bufio.NewReader(conn)
+	if code, text, err := ReadReply(r); err != nil {
+		return err
+	} else if code != 220 {
+		return fmt.Errorf("SMTP greeting: %d %s", code, text)
+	}
+	code, _, err := SendCommand(conn, r, "EHLO "+ehloName)
+	if err != nil {
+		return err
+	}
+	if code != 250 {
+		return fmt.Errorf("SMTP EHLO rejected: %d", code)
+	}
+	code, text, err := SendCommand(conn, r, "STARTTLS")
+	if err != nil {
+		return err
+	}
+	if code != 220 {
+		return fmt.Errorf("SMTP STARTTLS rejected: %d %s", code, text)
+	}
+	return nil
+}
+
+func negotiateIMAP(conn net.Conn) error {
+	r := bufio.NewReader(conn)
+	line, err := readLine(r)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "* OK") {
+		return fmt.Errorf("IMAP greeting: %s", line)
+	}
+	if _, err := fmt.Fprintf(conn, "a001 STARTTLS\r\n"); err != nil {
+		return err
+	}
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(line, "a001 ") {
+			continue // untagged responses
+		}
+		if strings.HasPrefix(line, "a001 OK") {
+			return nil
+		}
+		return fmt.Errorf("IMAP STARTTLS rejected: %s", line)
+	}
+}
+
+func negotiatePOP3(conn net.Conn) error {
+	r := bufio.NewReader(conn)
+	line, err := readLine(r)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "+OK") {
+		return fmt.Errorf("POP3 greeting: %s", line)
+	}
+	if _, err := fmt.Fprintf(conn, "STLS\r\n"); err != nil {
+		return err
+	}
+	if line, err = readLine(r); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "+OK") {
+		return fmt.Errorf("POP3 STLS rejected: %s", line)
+	}
+	return nil
+}
+
+// ldapStartTLS is an LDAPMessage carrying an ExtendedRequest for the
+// StartTLS OID 1.3.6.1.4.1.1466.20037 (RFC 4511 section 4.14).
+var ldapStartTLS = append([]byte{
+	0x30, 0x1d, // LDAPMessage
+	0x02, 0x01, 0x01, // messageID 1
+	0x77, 0x18, // [APPLICATION 23] ExtendedRequest
+	0x80, 0x16, // [0] requestName
+}, "1.3.6.1.4.1.1466.20037"...)
+
+func negotiateLDAP(conn net.Conn) error {
+	if _, err := conn.Write(ldapStartTLS); err != nil {
+		return err
+	}
+
+	tag, msg, err := readBER(conn)
+	if err != nil {
+		return err
+	}
+	if tag != 0x30 {
+		return errors.New("LDAP: malformed response")
+	}
+	// messageID, then the ExtendedResponse whose first element is resultCode.
+	_, _, msg, err = splitBER(msg)
+	if err != nil {
+		return err
+	}
+	tag, resp, _, err := splitBER(msg)
+	if err != nil {
+		return err
+	}
+	if tag != 0x78 {
+		return fmt.Errorf("LDAP: unexpected response tag 0x%02x", tag)
+	}
+	tag, code, _, err := splitBER(resp)
+	if err != nil || tag != 0x0a || len(code) == 0 {
+		return errors.New("LDAP: missing result code")
+	}
+	result := 0
+	for _, c := range code {
+		result = result<<8 | int(c)
+	}
+	if result != 0 {
+		return fmt.Errorf("LDAP StartTLS rejected: result code %d", result)
+	}
+	return nil
+}
+
+// postgresSSLRequest is the SSLRequest startup packet: length 8 and the
+// request code 80877103.
+var postgresSSLRequest = []byte{0x00, 0x00, 0x00, 0x08, 0x04, 0xd2, 0x16, 0x2f}
+
+func negotiatePostgres(conn net.Conn) error {
+	if _, err := conn.Write(postgresSSLRequest); err != nil {
+		return err
+	}
+	resp := make([]byte, 1)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return err
+	}
+	switch resp[0] {
+	case 'S':
+		return nil
+	case 'N':
+		return errors.New("PostgreSQL server does not accept SSL")
+	default:
+		return fmt.Errorf("PostgreSQL: unexpected SSLRequest response 0x%02x", resp[0])
+	}
+}
+
+// SendCommand writes an FTP/SMTP command and reads the reply.
+func SendCommand(conn net.Conn, r *bufio.Reader, cmd string) (int, string, error) {
+	if _, err := fmt.Fprintf(conn, "%s\r\n", cmd); err != nil {
+		return 0, "", err
+	}
+	return ReadReply(r)
+}
+
+// ReadReply reads an FTP/SMTP reply, including multi-line "code-" replies,
+// and returns the code and the text of the last line.
+func ReadReply(r *bufio.Reader) (int, string, error) {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return 0, "", err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if len(line) < 3 {
+			continue
+		}
+		code, err := strconv.Atoi(line[:3])
+		if err != nil {
+			continue
+		}
+		if len(line) > 3 && line[3] == '-' {
+			continue
+		}
+		return code, strings.TrimSpace(line[3:]), nil
+	}
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readBER reads one BER element from r without over-reading, so the TLS
+// handshake can follow on the same connection.
+func readBER(r io.Reader) (byte, []byte, error) {
+	hdr := make([]byte, 2)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return 0, nil, err
+	}
+	length := int(hdr[1])
+	if length&0x80 != 0 {
+		n := length & 0x7f
+		if n == 0 || n > 4 {
+			return 0, nil, errors.New("invalid BER length")
+		}
+		b := make([]byte, n)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return 0, nil, err
+		}
+		length = 0
+		for _, c := range b {
+			length = length<<8 | int(c)
+		}
+	}
+	if length > 1<<16 {
+		return 0, nil, errors.New("BER element too large")
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return hdr[0], body, nil
+}
+
+// splitBER splits the first BER element off data.
+func splitBER(data []byte) (tag byte, value, rest []byte, err error) {
+	if len(data) < 2 {
+		return 0, nil, nil, errors.New("truncated BER element")
+	}
+	tag = data[0]
+	length := int(data[1])
+	off := 2
+	if length&0x80 != 0 {
+		n := length & 0x7f
+		if n == 0 || n > 4 || len(data) < 2+n {
+			return 0, nil, nil, errors.New("invalid BER length")
+		}
+		length = 0
+		for _, b := range data[2 : 2+n] {
+			length = length<<8 | int(b)
+		}
+		off += n
+	}
+	if off+length > len(data) {
+		return 0, nil, nil, errors.New("BER length exceeds data")
+	}
+	return tag, data[off : off+length], data[off+length:], nil
+}
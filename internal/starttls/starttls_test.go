@@ -0,0 +1,124 @@
+package starttls
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+// serve runs script against the server side of a pipe and returns the
+// client side.
+func serve(t *testing.T, script func(conn net.Conn, r *bufio.Reader)) net.Conn {
+	t.Helper()
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+		script(server, bufio.NewReader(server))
+	}()
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func expectLine(r *bufio.Reader, want string) bool {
+	line, err := r.ReadString('\n')
+	return err == nil && strings.TrimRight(line, "\r\n") == want
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		protocol string
+		script   func(conn net.Conn, r *bufio.Reader)
+		wantErr  bool
+	}{
+		{SMTP, func(conn net.Conn, r *bufio.Reader) {
+			io.WriteString(conn, "220 mail.test ESMTP\r\n")
+			if !expectLine(r, "EHLO "+ehloName) {
+				return
+			}
+			io.WriteString(conn, "250-mail.test\r\n250-STARTTLS\r\n250 SIZE 1000\r\n")
+			if expectLine(r, "STARTTLS") {
+				io.WriteString(conn, "220 Ready to start TLS\r\n")
+			}
+		}, false},
+		{SMTP, func(conn net.Conn, r *bufio.Reader) {
+			io.WriteString(conn, "220 mail.test ESMTP\r\n")
+			r.ReadString('\n')
+			io.WriteString(conn, "250 mail.test\r\n")
+			r.ReadString('\n')
+			io.WriteString(conn, "454 TLS not available\r\n")
+		}, true},
+		{IMAP, func(conn net.Conn, r *bufio.Reader) {
+			io.WriteString(conn, "* OK IMAP4rev1 ready\r\n")
+			if expectLine(r, "a001 STARTTLS") {
+				io.WriteString(conn, "* CAPABILITY IMAP4rev1\r\na001 OK Begin TLS negotiation now\r\n")
+			}
+		}, false},
+		{IMAP, func(conn net.Conn, r *bufio.Reader) {
+			io.WriteString(conn, "* OK IMAP4rev1 ready\r\n")
+			r.ReadString('\n')
+			io.WriteString(conn, "a001 BAD STARTTLS unavailable\r\n")
+		}, true},
+		{POP3, func(conn net.Conn, r *bufio.Reader) {
+			io.WriteString(conn, "+OK POP3 ready\r\n")
+			if expectLine(r, "STLS") {
+				io.WriteString(conn, "+OK Begin TLS\r\n")
+			}
+		}, false},
+		{LDAP, func(conn net.Conn, r *bufio.Reader) {
+			req := make([]byte, len(ldapStartTLS))
+			if _, err := io.ReadFull(r, req); err != nil || !bytes.Equal(req, ldapStartTLS) {
+				return
+			}
+			// ExtendedResponse: resultCode success, empty matchedDN and message.
+			conn.Write([]byte{0x30, 0x0c, 0x02, 0x01, 0x01, 0x78, 0x07, 0x0a, 0x01, 0x00, 0x04, 0x00, 0x04, 0x00})
+		}, false},
+		{LDAP, func(conn net.Conn, r *bufio.Reader) {
+			io.ReadFull(r, make([]byte, len(ldapStartTLS)))
+			// resultCode protocolError (2)
+			conn.Write([]byte{0x30, 0x0c, 0x02, 0x01, 0x01, 0x78, 0x07, 0x0a, 0x01, 0x02, 0x04, 0x00, 0x04, 0x00})
+		}, true},
+		{Postgres, func(conn net.Conn, r *bufio.Reader) {
+			req := make([]byte, len(postgresSSLRequest))
+			if _, err := io.ReadFull(r, req); err == nil && bytes.Equal(req, postgresSSLRequest) {
+				conn.Write([]byte{'S'})
+			}
+		}, false},
+		{Postgres, func(conn net.Conn, r *bufio.Reader) {
+			io.ReadFull(r, make([]byte, len(postgresSSLRequest)))
+			conn.Write([]byte{'N'})
+		}, true},
+	}
+
+	for _, tt := range tests {
+		conn := serve(t, tt.script)
+		err := Negotiate(conn, tt.protocol)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Negotiate(%s) error = %v, wantErr %v", tt.protocol, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNegotiateUnsupported(t *testing.T) {
+	conn := serve(t, func(net.Conn, *bufio.Reader) {})
+	if err := Negotiate(conn, "gopher"); err == nil {
+		t.Error("expected error for unsupported protocol")
+	}
+}
+
+func TestReadReply(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("250-first\r\n250-second\r\n250 last line\r\n"))
+	code, text, err := ReadReply(r)
+	if err != nil || code != 250 || text != "last line" {
+		t.Errorf("ReadReply() = %d, %q, %v", code, text, err)
+	}
+}
+
+func TestProtocols(t *testing.T) {
+	got := strings.Join(Protocols(), ",")
+	if got != "imap,ldap,pop3,postgres,smtp" {
+		t.Errorf("Protocols() = %s", got)
+	}
+}
@@ -0,0 +1,214 @@
+package sweep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/JedizLaPulga/NNS/internal/arp"
+)
+
+// ARPScan discovers hosts on a directly connected IPv4 subnet by sending an
+// ARP request to every address in cidr and recording the responders with
+// their MAC address. Hosts that filter TCP and ICMP still answer ARP, which
+// makes this the most reliable LAN discovery method. It requires
+// administrator/root privileges to open a raw socket.
+func ARPScan(ctx context.Context, cidr string) ([]HostResult, error) {
+	cfg := DefaultConfig()
+	cfg.CIDR = cidr
+	cfg.Method = "arp"
+	cfg.Resolve = false
+
+	results, err := NewSweeper(cfg).Sweep(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return GetAliveHosts(results), nil
+}
+
+// sweepARP probes hosts with ARP requests from the interface attached to
+// their subnet. Hosts outside that subnet cannot be reached by ARP and are
+// reported as not alive.
+func (s *Sweeper) sweepARP(ctx context.Context, hosts []string, callback func(HostResult)) ([]HostResult, error) {
+	ifi, srcIP, subnet, err := arpInterface(hosts)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := arp.OpenConn(ifi)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	targets := make(map[string]bool, len(hosts))
+	for _, h := range hosts {
+		if ip := net.ParseIP(h).To4(); ip != nil && subnet.Contains(ip) && !ip.Equal(srcIP) {
+			targets[ip.String()] = true
+		}
+	}
+
+	var mu sync.Mutex
+	sent := make(map[string]time.Time, len(targets))
+
+	replies := make(chan HostResult, len(targets))
+	stop := make(chan struct{})
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		seen := make(map[string]bool)
+		buf := make([]byte, 1500)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			n, err := conn.ReadFrame(buf)
+			if err != nil {
+				if errors.Is(err, arp.ErrTimeout) {
+					continue
+				}
+				return
+			}
+			ip, mac, ok := arp.ParseReply(buf[:n])
+			if !ok || !targets[ip] || seen[ip] {
+				continue
+			}
+			seen[ip] = true
+
+			mu.Lock()
+			at, ok := sent[ip]
+			mu.Unlock()
+			var latency time.Duration
+			if ok {
+				latency = time.Since(at)
+			}
+
+			replies <- HostResult{
+				IP:      ip,
+				Alive:   true,
+				Latency: latency,
+				Method:  "arp",
+				MAC:     mac.String(),
+				Vendor:  arp.LookupVendor(mac.String()),
+			}
+		}
+	}()
+
+	ordered := make([]string, 0, len(targets))
+	for ip := range targets {
+		ordered = append(ordered, ip)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		return compareIPs(ordered[i], ordered[j]) < 0
+	})
+
+SendLoop:
+	for i, ip := range ordered {
+		select {
+		case <-ctx.Done():
+			break SendLoop
+		default:
+		}
+		frame := arp.BuildRequest(ifi.HardwareAddr, srcIP, net.ParseIP(ip).To4())
+		mu.Lock()
+		sent[ip] = time.Now()
+		mu.Unlock()
+		if err := conn.WriteFrame(frame); err != nil {
+			close(stop)
+			<-readDone
+			return nil, fmt.Errorf("failed to send ARP request: %w", err)
+		}
+		// Pace bursts so the socket buffer and the LAN are not flooded.
+		if i%64 == 63 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	found := make(map[string]HostResult)
+	timer := time.NewTimer(s.Config.Timeout)
+	defer timer.Stop()
+
+CollectLoop:
+	for {
+		select {
+		case r := <-replies:
+			if s.Config.Resolve {
+				if names, err := net.LookupAddr(r.IP); err == nil && len(names) > 0 {
+					r.Hostname = names[0]
+				}
+			}
+			found[r.IP] = r
+			if callback != nil {
+				callback(r)
+			}
+		case <-timer.C:
+			break CollectLoop
+		case <-ctx.Done():
+			break CollectLoop
+		}
+	}
+	close(stop)
+	<-readDone
+
+	results := make([]HostResult, 0, len(hosts))
+	for _, h := range hosts {
+		if r, ok := found[h]; ok {
+			results = append(results, r)
+			continue
+		}
+		results = append(results, HostResult{IP: h, Method: "arp"})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return compareIPs(results[i].IP, results[j].IP) < 0
+	})
+
+	return results, nil
+}
+
+// arpInterface finds the up, non-loopback interface whose IPv4 subnet
+// contains the first target host.
+func arpInterface(hosts []string) (*net.Interface, net.IP, *net.IPNet, error) {
+	if len(hosts) == 0 {
+		return nil, nil, nil, errors.New("no hosts to scan")
+	}
+	target := net.ParseIP(hosts[0]).To4()
+	if target == nil {
+		return nil, nil, nil, errors.New("ARP scan only supports IPv4 targets")
+	}
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to list interfaces: %w", err)
+	}
+
+	for i := range ifaces {
+		ifi := &ifaces[i]
+		if ifi.Flags&net.FlagUp == 0 || ifi.Flags&net.FlagLoopback != 0 || len(ifi.HardwareAddr) != 6 {
+			continue
+		}
+		addrs, err := ifi.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			ip := ipNet.IP.To4()
+			if ip == nil || !ipNet.Contains(target) {
+				continue
+			}
+			return ifi, ip, &net.IPNet{IP: ip.Mask(ipNet.Mask), Mask: ipNet.Mask}, nil
+		}
+	}
+
+	return nil, nil, nil, fmt.Errorf("no local interface on the subnet of %s (ARP only works on directly connected networks)", hosts[0])
+}
@@ -0,0 +1,168 @@
+package sweep
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/JedizLaPulga/NNS/internal/srcaddr"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+// icmpProber sends ICMP echo requests over a single shared socket and
+// dispatches replies to the waiting probe by source address.
+type icmpProber struct {
+	conn       *icmp.PacketConn
+	privileged bool // raw socket (ip4:icmp) vs. unprivileged datagram (udp4)
+	id         int
+	seq        uint32
+
+	mu      sync.Mutex
+	waiters map[string]chan struct{}
+	done    chan struct{}
+}
+
+// newICMPProber opens a raw ICMP socket, falling back to an unprivileged
+// datagram ICMP socket where the OS allows it. It returns an error if
+// neither is available, e.g. when running without the required privileges.
+// A non-empty source binds the socket to that local address.
+func newICMPProber(source string) (*icmpProber, error) {
+	p := &icmpProber{
+		id:      os.Getpid() & 0xffff,
+		waiters: make(map[string]chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	conn, err := icmp.ListenPacket("ip4:icmp", srcaddr.ListenAddr(source))
+	if err == nil {
+		p.privileged = true
+	} else {
+		var udpErr error
+		conn, udpErr = icmp.ListenPacket("udp4", srcaddr.ListenAddr(source))
+		if udpErr != nil {
+			return nil, fmt.Errorf("ICMP unavailable (requires administrator/root): %v", err)
+		}
+	}
+	p.conn = conn
+
+	go p.readLoop()
+	return p, nil
+}
+
+// Close stops the reader and releases the socket.
+func (p *icmpProber) Close() error {
+	close(p.done)
+	return p.conn.Close()
+}
+
+// readLoop receives echo replies and wakes the matching waiter.
+func (p *icmpProber) readLoop() {
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := p.conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-p.done:
+				return
+			default:
+			}
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				continue
+			}
+			return
+		}
+
+		msg, err := icmp.ParseMessage(1, buf[:n])
+		if err != nil || msg.Type != ipv4.ICMPTypeEchoReply {
+			continue
+		}
+		echo, ok := msg.Body.(*icmp.Echo)
+		// Unprivileged sockets rewrite the ID, so only check it on raw sockets.
+		if !ok || (p.privileged && echo.ID != p.id) {
+			continue
+		}
+
+		ip := addrIP(peer)
+		p.mu.Lock()
+		if ch, ok := p.waiters[ip]; ok {
+			close(ch)
+			delete(p.waiters, ip)
+		}
+		p.mu.Unlock()
+	}
+}
+
+// probe sends one echo request to ip and waits up to timeout for a reply.
+func (p *icmpProber) probe(ctx context.Context, ip string, timeout time.Duration) (time.Duration, bool) {
+	parsed := net.ParseIP(ip).To4()
+	if parsed == nil {
+		return 0, false
+	}
+
+	ch := make(chan struct{})
+	p.mu.Lock()
+	p.waiters[ip] = ch
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		if p.waiters[ip] == ch {
+			delete(p.waiters, ip)
+		}
+		p.mu.Unlock()
+	}()
+
+	msg := icmp.Message{
+		Type: ipv4.ICMPTypeEcho,
+		Body: &icmp.Echo{
+			ID:   p.id,
+			Seq:  int(atomic.AddUint32(&p.seq, 1) & 0xffff),
+			Data: []byte("nns-sweep"),
+		},
+	}
+	data, err := msg.Marshal(nil)
+	if err != nil {
+		return 0, false
+	}
+
+	var dst net.Addr = &net.IPAddr{IP: parsed}
+	if !p.privileged {
+		dst = &net.UDPAddr{IP: parsed}
+	}
+
+	start := time.Now()
+	if _, err := p.conn.WriteTo(data, dst); err != nil {
+		return 0, false
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-ch:
+		return time.Since(start), true
+	case <-timer.C:
+		return 0, false
+	case <-ctx.Done():
+		return 0, false
+	}
+}
+
+// addrIP extracts the IP string from an *net.IPAddr or *net.UDPAddr.
+func addrIP(addr net.Addr) string {
+	switch a := addr.(type) {
+	case *net.IPAddr:
+		return a.IP.String()
+	case *net.UDPAddr:
+		return a.IP.String()
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
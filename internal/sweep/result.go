@@ -0,0 +1,93 @@
+package sweep
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvHeader is the fixed column order for CSV output.
+var csvHeader = []string{"ip", "method", "port", "hostname", "latency_ms", "mac", "vendor"}
+
+// Result is the serializable outcome of a sweep: the live hosts plus a
+// summary of what was scanned.
+type Result struct {
+	Target  string       `json:"target"` // comma-separated when several ranges were swept
+	Method  string       `json:"method"`
+	Scanned int          `json:"scanned"`
+	Alive   int          `json:"alive"`
+	Hosts   []HostResult `json:"hosts"`
+}
+
+// NewResult builds a Result from the hosts returned by Sweep, keeping only
+// live hosts.
+func NewResult(cfg Config, results []HostResult) *Result {
+	alive := GetAliveHosts(results)
+	return &Result{
+		Target:  strings.Join(cfg.includes(), ","),
+		Method:  cfg.Method,
+		Scanned: len(results),
+		Alive:   len(alive),
+		Hosts:   alive,
+	}
+}
+
+// MarshalJSON encodes the host with its error as a string.
+func (h HostResult) MarshalJSON() ([]byte, error) {
+	type host HostResult
+	out := struct {
+		host
+		Error string `json:"error,omitempty"`
+	}{host: host(h)}
+	if h.Error != nil {
+		out.Error = h.Error.Error()
+	}
+	return json.Marshal(out)
+}
+
+// ToJSON converts the result to indented JSON.
+func (r *Result) ToJSON() (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ToCSV converts the live hosts to CSV with a stable header row. Latency is
+// written as whole milliseconds.
+func (r *Result) ToCSV() (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write(csvHeader); err != nil {
+		return "", err
+	}
+	for _, h := range r.Hosts {
+		port := ""
+		if h.Port > 0 {
+			port = strconv.Itoa(h.Port)
+		}
+		row := []string{
+			h.IP,
+			h.Method,
+			port,
+			h.Hostname,
+			strconv.FormatInt(int64(h.Latency/time.Millisecond), 10),
+			h.MAC,
+			h.Vendor,
+		}
+		if err := w.Write(row); err != nil {
+			return "", err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
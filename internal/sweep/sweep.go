@@ -8,27 +8,36 @@ import (
 	"sort"
 	"sync"
 	"time"
+
+	"github.com/JedizLaPulga/NNS/internal/arp"
+	"github.com/JedizLaPulga/NNS/internal/srcaddr"
 )
 
 // HostResult represents the result of probing a single host.
 type HostResult struct {
-	IP       string
-	Alive    bool
-	Hostname string
-	Latency  time.Duration
-	Method   string
-	Port     int // For TCP method, which port responded
-	Error    error
+	IP       string        `json:"ip"`
+	Alive    bool          `json:"alive"`
+	Hostname string        `json:"hostname,omitempty"`
+	Latency  time.Duration `json:"latency"`
+	Method   string        `json:"method"`           // Discovery method that found the host ("icmp", "tcp" or "arp")
+	Port     int           `json:"port,omitempty"`   // For TCP method, which port responded
+	MAC      string        `json:"mac,omitempty"`    // For ARP method, the responder's hardware address
+	Vendor   string        `json:"vendor,omitempty"` // Vendor derived from the MAC's OUI, if known
+	Error    error         `json:"-"`
 }
 
 // Config configures the sweep operation.
 type Config struct {
 	CIDR        string
+	Targets     []string // Additional CIDRs or IPs swept alongside CIDR
+	Exclude     []string // CIDRs or IPs that must not be probed
 	Timeout     time.Duration
 	Concurrency int
-	Method      string // "icmp" or "tcp"
+	Method      string // "icmp", "tcp" or "arp"
 	Ports       []int  // Ports to check for TCP method
 	Resolve     bool   // Resolve hostnames
+	LookupMAC   bool   // Attach MAC and vendor from the system ARP table
+	SourceIP    string // Local address for TCP and ICMP probes ("" = default route)
 }
 
 // DefaultConfig returns a configuration with sensible defaults.
@@ -45,6 +54,12 @@ func DefaultConfig() Config {
 // Sweeper performs network host discovery.
 type Sweeper struct {
 	Config Config
+
+	// Fallback explains why the requested method was replaced by TCP
+	// probing (e.g. ICMP without privileges). Empty if no fallback occurred.
+	Fallback string
+
+	icmp *icmpProber
 }
 
 // NewSweeper creates a new Sweeper with the given configuration.
@@ -52,12 +67,38 @@ func NewSweeper(cfg Config) *Sweeper {
 	return &Sweeper{Config: cfg}
 }
 
-// Sweep scans the configured CIDR range for live hosts.
-// It calls the callback for each discovered host.
+// Sweep scans the configured CIDR ranges for live hosts, skipping any
+// excluded addresses. It calls the callback for each discovered host, as
+// hosts are found or, with LookupMAC, once the ARP table has been read
+// after the last probe.
 func (s *Sweeper) Sweep(ctx context.Context, callback func(HostResult)) ([]HostResult, error) {
-	hosts, err := ParseCIDR(s.Config.CIDR)
+	ips, err := ExpandTargets(s.Config.includes(), s.Config.Exclude)
 	if err != nil {
-		return nil, fmt.Errorf("invalid CIDR: %w", err)
+		return nil, err
+	}
+	hosts := make([]string, len(ips))
+	for i, ip := range ips {
+		hosts[i] = ip.String()
+	}
+	if len(hosts) == 0 {
+		return []HostResult{}, nil
+	}
+
+	if s.Config.Method == "arp" {
+		return s.sweepARP(ctx, hosts, callback)
+	}
+
+	if s.Config.Method == "icmp" {
+		prober, err := newICMPProber(s.Config.SourceIP)
+		if err != nil {
+			s.Fallback = fmt.Sprintf("%v; falling back to TCP", err)
+		} else {
+			s.icmp = prober
+			defer func() {
+				prober.Close()
+				s.icmp = nil
+			}()
+		}
 	}
 
 	results := make([]HostResult, 0)
@@ -109,7 +150,8 @@ func (s *Sweeper) Sweep(ctx context.Context, callback func(HostResult)) ([]HostR
 	}()
 
 	for result := range resultsChan {
-		if callback != nil && result.Alive {
+		// With LookupMAC, hosts are reported once their MACs are known
+		if callback != nil && result.Alive && !s.Config.LookupMAC {
 			callback(result)
 		}
 		mu.Lock()
@@ -122,9 +164,35 @@ func (s *Sweeper) Sweep(ctx context.Context, callback func(HostResult)) ([]HostR
 		return compareIPs(results[i].IP, results[j].IP) < 0
 	})
 
+	if s.Config.LookupMAC {
+		s.fillMACs(results)
+		if callback != nil {
+			for _, result := range results {
+				if result.Alive {
+					callback(result)
+				}
+			}
+		}
+	}
+
 	return results, nil
 }
 
+// fillMACs attaches MAC addresses and vendors to live hosts from a single
+// read of the system ARP table, which the probes have populated for
+// on-link hosts.
+func (s *Sweeper) fillMACs(results []HostResult) {
+	entries, err := arp.GetTable()
+	if err != nil {
+		return
+	}
+	for i := range results {
+		if results[i].Alive && results[i].MAC == "" {
+			results[i].MAC, results[i].Vendor = lookupMAC(entries, results[i].IP)
+		}
+	}
+}
+
 // probeHost checks if a single host is alive.
 func (s *Sweeper) probeHost(ctx context.Context, ip string) HostResult {
 	result := HostResult{
@@ -134,10 +202,14 @@ func (s *Sweeper) probeHost(ctx context.Context, ip string) HostResult {
 	}
 
 	switch s.Config.Method {
-	case "tcp":
-		result = s.probeTCP(ctx, ip)
+	case "icmp":
+		if s.icmp != nil && net.ParseIP(ip).To4() != nil {
+			result = s.probeICMP(ctx, ip)
+		} else {
+			// ICMP unavailable (no privileges) or IPv6 target
+			result = s.probeTCP(ctx, ip)
+		}
 	default:
-		// Default to TCP since ICMP requires admin privileges
 		result = s.probeTCP(ctx, ip)
 	}
 
@@ -151,6 +223,32 @@ func (s *Sweeper) probeHost(ctx context.Context, ip string) HostResult {
 	return result
 }
 
+// lookupMAC returns the MAC address and vendor recorded for ip in an ARP
+// table, or empty strings if the host is not on a local subnet.
+func lookupMAC(entries []arp.Entry, ip string) (string, string) {
+	for _, e := range entries {
+		if e.IP == ip {
+			return e.MAC, e.Vendor
+		}
+	}
+	return "", ""
+}
+
+// probeICMP sends an ICMP echo request to the target.
+func (s *Sweeper) probeICMP(ctx context.Context, ip string) HostResult {
+	result := HostResult{
+		IP:     ip,
+		Alive:  false,
+		Method: "icmp",
+	}
+
+	if rtt, ok := s.icmp.probe(ctx, ip, s.Config.Timeout); ok {
+		result.Alive = true
+		result.Latency = rtt
+	}
+	return result
+}
+
 // probeTCP attempts to connect to common ports on the target.
 func (s *Sweeper) probeTCP(ctx context.Context, ip string) HostResult {
 	result := HostResult{
@@ -177,7 +275,7 @@ func (s *Sweeper) probeTCP(ctx context.Context, ip string) HostResult {
 		addr := fmt.Sprintf("%s:%d", ip, port)
 
 		// Use DialContext for timeout and cancellation
-		d := net.Dialer{Timeout: s.Config.Timeout}
+		d := srcaddr.Dialer(s.Config.SourceIP, s.Config.Timeout)
 		conn, err := d.DialContext(ctx, "tcp", addr)
 
 		if err == nil {
@@ -192,6 +290,75 @@ func (s *Sweeper) probeTCP(ctx context.Context, ip string) HostResult {
 	return result
 }
 
+// includes returns every configured target range.
+func (c Config) includes() []string {
+	targets := make([]string, 0, len(c.Targets)+1)
+	if c.CIDR != "" {
+		targets = append(targets, c.CIDR)
+	}
+	return append(targets, c.Targets...)
+}
+
+// ExpandTargets expands the include ranges (CIDRs or single IPs) into a
+// sorted, de-duplicated list of host addresses, removing any address
+// covered by an exclude range.
+func ExpandTargets(include, exclude []string) ([]net.IP, error) {
+	if len(include) == 0 {
+		return nil, fmt.Errorf("no targets specified")
+	}
+
+	excluded := make([]*net.IPNet, 0, len(exclude))
+	for _, e := range exclude {
+		ipNet, err := parseRange(e)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude %q: %w", e, err)
+		}
+		excluded = append(excluded, ipNet)
+	}
+
+	seen := make(map[string]bool)
+	ips := make([]net.IP, 0)
+	for _, inc := range include {
+		hosts, err := ParseCIDR(inc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", inc, err)
+		}
+	HostLoop:
+		for _, h := range hosts {
+			ip := net.ParseIP(h)
+			if ip == nil || seen[ip.String()] {
+				continue
+			}
+			for _, ex := range excluded {
+				if ex.Contains(ip) {
+					continue HostLoop
+				}
+			}
+			seen[ip.String()] = true
+			ips = append(ips, ip)
+		}
+	}
+
+	sort.Slice(ips, func(i, j int) bool {
+		return compareIPs(ips[i].String(), ips[j].String()) < 0
+	})
+	return ips, nil
+}
+
+// parseRange parses a CIDR or a single IP (as a host route).
+func parseRange(s string) (*net.IPNet, error) {
+	if ip := net.ParseIP(s); ip != nil {
+		bits := 128
+		if ip.To4() != nil {
+			ip = ip.To4()
+			bits = 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, ipNet, err := net.ParseCIDR(s)
+	return ipNet, err
+}
+
 // ParseCIDR parses a CIDR notation and returns all host IPs.
 func ParseCIDR(cidr string) ([]string, error) {
 	// Handle single IP
@@ -287,6 +454,7 @@ func GetAliveHosts(results []HostResult) []HostResult {
 }
 
 // CountHosts returns the number of hosts in a CIDR range.
+// Use ExpandTargets to count several ranges with exclusions.
 func CountHosts(cidr string) (int, error) {
 	hosts, err := ParseCIDR(cidr)
 	if err != nil {
@@ -2,8 +2,13 @@ package sweep
 
 import (
 	"context"
+	"encoding/json"
+	"net"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/JedizLaPulga/NNS/internal/arp"
 )
 
 func TestParseCIDR(t *testing.T) {
@@ -197,3 +202,190 @@ func TestSweepContextCancellation(t *testing.T) {
 		t.Logf("Sweep() with cancelled context: %v", err)
 	}
 }
+
+func TestSweepICMP(t *testing.T) {
+	// Open a TCP port so the sweep succeeds even if ICMP falls back to TCP.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	cfg := DefaultConfig()
+	cfg.CIDR = "127.0.0.1"
+	cfg.Method = "icmp"
+	cfg.Ports = []int{port}
+	cfg.Resolve = false
+
+	s := NewSweeper(cfg)
+	results, err := s.Sweep(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+	if len(results) != 1 || !results[0].Alive {
+		t.Fatalf("127.0.0.1 should be alive, got %+v", results)
+	}
+
+	want := "icmp"
+	if s.Fallback != "" {
+		want = "tcp"
+	}
+	if results[0].Method != want {
+		t.Errorf("Method = %q, want %q (fallback: %q)", results[0].Method, want, s.Fallback)
+	}
+}
+
+func TestARPScanNotOnLink(t *testing.T) {
+	// 198.51.100.0/24 (TEST-NET-2) is not expected on any local interface.
+	_, err := ARPScan(context.Background(), "198.51.100.0/30")
+	if err == nil {
+		t.Fatal("expected error for a subnet with no local interface")
+	}
+}
+
+func TestResultSerialization(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CIDR = "10.0.0.0/30"
+	results := []HostResult{
+		{IP: "10.0.0.1", Alive: true, Method: "tcp", Port: 22, Hostname: "gw.lan.", Latency: 12*time.Millisecond + 400*time.Microsecond},
+		{IP: "10.0.0.2", Alive: false, Method: "tcp"},
+	}
+
+	r := NewResult(cfg, results)
+	if r.Scanned != 2 || r.Alive != 1 || len(r.Hosts) != 1 {
+		t.Fatalf("NewResult() = scanned %d alive %d hosts %d, want 2/1/1", r.Scanned, r.Alive, len(r.Hosts))
+	}
+
+	js, err := r.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v", err)
+	}
+	var decoded struct {
+		Target string `json:"target"`
+		Hosts  []struct {
+			IP      string        `json:"ip"`
+			Port    int           `json:"port"`
+			Method  string        `json:"method"`
+			Latency time.Duration `json:"latency"`
+		} `json:"hosts"`
+	}
+	if err := json.Unmarshal([]byte(js), &decoded); err != nil {
+		t.Fatalf("ToJSON() produced invalid JSON: %v", err)
+	}
+	if decoded.Target != "10.0.0.0/30" || len(decoded.Hosts) != 1 {
+		t.Fatalf("unexpected JSON: %s", js)
+	}
+	if h := decoded.Hosts[0]; h.IP != "10.0.0.1" || h.Port != 22 || h.Method != "tcp" || h.Latency != 12*time.Millisecond+400*time.Microsecond {
+		t.Errorf("unexpected host in JSON: %+v", h)
+	}
+
+	out, err := r.ToCSV()
+	if err != nil {
+		t.Fatalf("ToCSV() error = %v", err)
+	}
+	want := "ip,method,port,hostname,latency_ms,mac,vendor\n10.0.0.1,tcp,22,gw.lan.,12,,\n"
+	if out != want {
+		t.Errorf("ToCSV() = %q, want %q", out, want)
+	}
+
+	empty, err := NewResult(cfg, nil).ToCSV()
+	if err != nil || empty != "ip,method,port,hostname,latency_ms,mac,vendor\n" {
+		t.Errorf("ToCSV() on empty result = %q, %v", empty, err)
+	}
+}
+
+func TestLookupMAC(t *testing.T) {
+	entries := []arp.Entry{
+		{IP: "192.168.1.1", MAC: "b8:27:eb:00:00:01", Vendor: "Raspberry Pi"},
+		{IP: "192.168.1.2", MAC: "02:00:00:00:00:02"},
+	}
+
+	mac, vendor := lookupMAC(entries, "192.168.1.1")
+	if mac != "b8:27:eb:00:00:01" || vendor != "Raspberry Pi" {
+		t.Errorf("lookupMAC() = %q, %q", mac, vendor)
+	}
+	if mac, vendor := lookupMAC(entries, "10.0.0.1"); mac != "" || vendor != "" {
+		t.Errorf("lookupMAC() for unknown host = %q, %q, want empty", mac, vendor)
+	}
+}
+
+func TestExpandTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "multiple ranges sorted and de-duplicated",
+			include: []string{"10.0.1.0/30", "10.0.0.0/30", "10.0.0.1"},
+			want:    []string{"10.0.0.1", "10.0.0.2", "10.0.1.1", "10.0.1.2"},
+		},
+		{
+			name:    "exclude subnet and single IP",
+			include: []string{"192.168.1.0/29"},
+			exclude: []string{"192.168.1.0/30", "192.168.1.5"},
+			want:    []string{"192.168.1.4", "192.168.1.6"},
+		},
+		{
+			name:    "everything excluded",
+			include: []string{"192.168.1.1"},
+			exclude: []string{"192.168.1.0/24"},
+			want:    []string{},
+		},
+		{
+			name:    "invalid include",
+			include: []string{"10.0.0.0/33"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid exclude",
+			include: []string{"10.0.0.0/30"},
+			exclude: []string{"bogus"},
+			wantErr: true,
+		},
+		{
+			name:    "no targets",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ips, err := ExpandTargets(tt.include, tt.exclude)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExpandTargets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := make([]string, len(ips))
+			for i, ip := range ips {
+				got[i] = ip.String()
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ExpandTargets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSweepExclude(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CIDR = "127.0.0.1"
+	cfg.Targets = []string{"127.0.0.2"}
+	cfg.Exclude = []string{"127.0.0.2"}
+	cfg.Timeout = 100 * time.Millisecond
+	cfg.Ports = []int{1}
+	cfg.Resolve = false
+
+	results, err := NewSweeper(cfg).Sweep(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+	if len(results) != 1 || results[0].IP != "127.0.0.1" {
+		t.Errorf("Sweep() probed %v, want only 127.0.0.1", results)
+	}
+}
@@ -0,0 +1,385 @@
+package tlsaudit
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	_ "crypto/sha1" // OCSP CertID hashes
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Revocation states.
+const (
+	RevocationGood    = "good"
+	RevocationRevoked = "revoked"
+	RevocationUnknown = "unknown"
+)
+
+// maxRevocationBody caps OCSP and CRL response sizes.
+const maxRevocationBody = 10 << 20
+
+// revocationClockSkew is how far OCSP and CRL validity windows may be off
+// from the local clock before a response is rejected as stale or premature.
+const revocationClockSkew = 5 * time.Minute
+
+// RevocationStatus records whether the leaf certificate has been revoked.
+type RevocationStatus struct {
+	Checked   bool      `json:"checked"`
+	Status    string    `json:"status,omitempty"` // good, revoked or unknown
+	Method    string    `json:"method,omitempty"` // OCSP or CRL
+	Source    string    `json:"source,omitempty"` // Responder or CRL URL that answered
+	RevokedAt time.Time `json:"revoked_at,omitzero"`
+	Error     string    `json:"error,omitempty"`
+}
+
+var (
+	oidSHA1          = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidOCSPBasic     = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+	ocspSignatureAlg = map[string]x509.SignatureAlgorithm{
+		"1.2.840.113549.1.1.5":  x509.SHA1WithRSA,
+		"1.2.840.113549.1.1.11": x509.SHA256WithRSA,
+		"1.2.840.113549.1.1.12": x509.SHA384WithRSA,
+		"1.2.840.113549.1.1.13": x509.SHA512WithRSA,
+		"1.2.840.10045.4.1":     x509.ECDSAWithSHA1,
+		"1.2.840.10045.4.3.2":   x509.ECDSAWithSHA256,
+		"1.2.840.10045.4.3.3":   x509.ECDSAWithSHA384,
+		"1.2.840.10045.4.3.4":   x509.ECDSAWithSHA512,
+		"1.3.101.112":           x509.PureEd25519,
+	}
+	ocspHashAlg = map[string]crypto.Hash{
+		"1.3.14.3.2.26":          crypto.SHA1,
+		"2.16.840.1.101.3.4.2.1": crypto.SHA256,
+		"2.16.840.1.101.3.4.2.2": crypto.SHA384,
+		"2.16.840.1.101.3.4.2.3": crypto.SHA512,
+	}
+)
+
+// OCSP structures (RFC 6960).
+type ocspCertID struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	NameHash      []byte
+	IssuerKeyHash []byte
+	SerialNumber  *big.Int
+}
+
+type ocspRequest struct {
+	TBSRequest struct {
+		RequestList []struct {
+			Cert ocspCertID
+		}
+	}
+}
+
+type ocspResponse struct {
+	Status   asn1.Enumerated
+	Response struct {
+		ResponseType asn1.ObjectIdentifier
+		Response     []byte
+	} `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspBasicResponse struct {
+	TBSResponseData    asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspResponseData struct {
+	Version     int `asn1:"optional,default:0,explicit,tag:0"`
+	ResponderID asn1.RawValue
+	ProducedAt  time.Time `asn1:"generalized"`
+	Responses   []ocspSingleResponse
+	Extensions  []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+type ocspSingleResponse struct {
+	CertID     ocspCertID
+	Good       asn1.Flag        `asn1:"tag:0,optional"`
+	Revoked    ocspRevokedInfo  `asn1:"tag:1,optional"`
+	Unknown    asn1.Flag        `asn1:"tag:2,optional"`
+	ThisUpdate time.Time        `asn1:"generalized"`
+	NextUpdate time.Time        `asn1:"generalized,explicit,tag:0,optional"`
+	Extensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+type ocspRevokedInfo struct {
+	RevocationTime time.Time       `asn1:"generalized"`
+	Reason         asn1.Enumerated `asn1:"explicit,tag:0,optional"`
+}
+
+// checkRevocation queries the leaf's OCSP responders and falls back to its
+// CRL distribution points. All network calls share the configured timeout.
+func (a *Auditor) checkRevocation(leaf, issuer *x509.Certificate, result *Result) {
+	rs := &result.Revocation
+	rs.Checked = true
+	rs.Status = RevocationUnknown
+
+	if issuer == nil {
+		rs.Error = "issuer certificate not available"
+		return
+	}
+	if len(leaf.OCSPServer) == 0 && len(leaf.CRLDistributionPoints) == 0 {
+		rs.Error = "certificate has no OCSP responder or CRL distribution point"
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.config.Timeout)
+	defer cancel()
+	client := &http.Client{}
+
+	var errs []string
+	for _, url := range leaf.OCSPServer {
+		status, revokedAt, err := queryOCSP(ctx, client, url, leaf, issuer)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("OCSP %s: %v", url, err))
+			continue
+		}
+		if status == RevocationUnknown {
+			errs = append(errs, fmt.Sprintf("OCSP %s: responder does not know the certificate", url))
+			continue
+		}
+		*rs = RevocationStatus{Checked: true, Status: status, Method: "OCSP", Source: url, RevokedAt: revokedAt}
+		return
+	}
+
+	for _, url := range leaf.CRLDistributionPoints {
+		status, revokedAt, err := checkCRL(ctx, client, url, leaf, issuer)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("CRL %s: %v", url, err))
+			continue
+		}
+		*rs = RevocationStatus{Checked: true, Status: status, Method: "CRL", Source: url, RevokedAt: revokedAt}
+		return
+	}
+
+	rs.Error = strings.Join(errs, "; ")
+}
+
+// queryOCSP sends an OCSP request for leaf and returns its verified status.
+func queryOCSP(ctx context.Context, client *http.Client, url string, leaf, issuer *x509.Certificate) (string, time.Time, error) {
+	reqBody, err := buildOCSPRequest(leaf, issuer)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(reqBody))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/ocsp-request")
+	req.Header.Set("Accept", "application/ocsp-response")
+
+	body, err := fetch(client, req)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return parseOCSPResponse(body, leaf, issuer)
+}
+
+// buildOCSPRequest encodes a single-certificate OCSP request using SHA-1
+// CertID hashes, which every responder supports.
+func buildOCSPRequest(leaf, issuer *x509.Certificate) ([]byte, error) {
+	nameHash, keyHash, err := issuerHashes(issuer, crypto.SHA1)
+	if err != nil {
+		return nil, err
+	}
+
+	var req ocspRequest
+	req.TBSRequest.RequestList = make([]struct{ Cert ocspCertID }, 1)
+	req.TBSRequest.RequestList[0].Cert = ocspCertID{
+		HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA1, Parameters: asn1.NullRawValue},
+		NameHash:      nameHash,
+		IssuerKeyHash: keyHash,
+		SerialNumber:  leaf.SerialNumber,
+	}
+	return asn1.Marshal(req)
+}
+
+// issuerHashes returns the OCSP CertID hashes of issuer's subject name and
+// public key.
+func issuerHashes(issuer *x509.Certificate, h crypto.Hash) (nameHash, keyHash []byte, err error) {
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(issuer.RawSubjectPublicKeyInfo, &spki); err != nil {
+		return nil, nil, fmt.Errorf("parsing issuer public key: %w", err)
+	}
+	hn := h.New()
+	hn.Write(issuer.RawSubject)
+	hk := h.New()
+	hk.Write(spki.PublicKey.RightAlign())
+	return hn.Sum(nil), hk.Sum(nil), nil
+}
+
+// matchesCertID reports whether id names leaf as issued by issuer.
+func matchesCertID(id ocspCertID, leaf, issuer *x509.Certificate) bool {
+	if id.SerialNumber == nil || id.SerialNumber.Cmp(leaf.SerialNumber) != 0 {
+		return false
+	}
+	h, ok := ocspHashAlg[id.HashAlgorithm.Algorithm.String()]
+	if !ok {
+		return false
+	}
+	nameHash, keyHash, err := issuerHashes(issuer, h)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(id.NameHash, nameHash) && bytes.Equal(id.IssuerKeyHash, keyHash)
+}
+
+// checkFreshness rejects a revocation response whose validity window,
+// thisUpdate to nextUpdate (zero if absent), does not include now.
+func checkFreshness(what string, thisUpdate, nextUpdate, now time.Time) error {
+	if thisUpdate.After(now.Add(revocationClockSkew)) {
+		return fmt.Errorf("%s not valid until %s", what, thisUpdate.Format(time.RFC3339))
+	}
+	if !nextUpdate.IsZero() && now.Add(-revocationClockSkew).After(nextUpdate) {
+		return fmt.Errorf("%s expired at %s", what, nextUpdate.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// parseOCSPResponse decodes a basic OCSP response, verifies its signature
+// against the issuer or an issuer-delegated responder, and returns the
+// status of leaf from a current response whose CertID matches both leaf
+// and issuer.
+func parseOCSPResponse(data []byte, leaf, issuer *x509.Certificate) (string, time.Time, error) {
+	var resp ocspResponse
+	if _, err := asn1.Unmarshal(data, &resp); err != nil {
+		return "", time.Time{}, fmt.Errorf("malformed OCSP response: %w", err)
+	}
+	if resp.Status != 0 {
+		return "", time.Time{}, fmt.Errorf("responder returned status %d", resp.Status)
+	}
+	if !resp.Response.ResponseType.Equal(oidOCSPBasic) {
+		return "", time.Time{}, errors.New("unsupported OCSP response type")
+	}
+
+	var basic ocspBasicResponse
+	if _, err := asn1.Unmarshal(resp.Response.Response, &basic); err != nil {
+		return "", time.Time{}, fmt.Errorf("malformed basic OCSP response: %w", err)
+	}
+	var tbs ocspResponseData
+	if _, err := asn1.Unmarshal(basic.TBSResponseData.FullBytes, &tbs); err != nil {
+		return "", time.Time{}, fmt.Errorf("malformed OCSP response data: %w", err)
+	}
+
+	algo, ok := ocspSignatureAlg[basic.SignatureAlgorithm.Algorithm.String()]
+	if !ok {
+		return "", time.Time{}, fmt.Errorf("unsupported OCSP signature algorithm %s", basic.SignatureAlgorithm.Algorithm)
+	}
+	signer := issuer
+	for _, raw := range basic.Certificates {
+		cert, err := x509.ParseCertificate(raw.FullBytes)
+		if err != nil {
+			continue
+		}
+		if cert.CheckSignatureFrom(issuer) == nil && hasOCSPSigning(cert) {
+			signer = cert
+			break
+		}
+	}
+	if err := signer.CheckSignature(algo, basic.TBSResponseData.FullBytes, basic.Signature.RightAlign()); err != nil {
+		return "", time.Time{}, fmt.Errorf("OCSP signature invalid: %w", err)
+	}
+
+	for _, r := range tbs.Responses {
+		if !matchesCertID(r.CertID, leaf, issuer) {
+			continue
+		}
+		if err := checkFreshness("OCSP response", r.ThisUpdate, r.NextUpdate, time.Now()); err != nil {
+			return "", time.Time{}, err
+		}
+		switch {
+		case bool(r.Good):
+			return RevocationGood, time.Time{}, nil
+		case bool(r.Unknown):
+			return RevocationUnknown, time.Time{}, nil
+		default:
+			return RevocationRevoked, r.Revoked.RevocationTime, nil
+		}
+	}
+	return "", time.Time{}, errors.New("OCSP response does not cover the certificate")
+}
+
+func hasOCSPSigning(cert *x509.Certificate) bool {
+	for _, u := range cert.ExtKeyUsage {
+		if u == x509.ExtKeyUsageOCSPSigning {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCRL downloads a CRL, verifies it was signed by issuer and is
+// current, and looks up leaf's serial number.
+func checkCRL(ctx context.Context, client *http.Client, url string, leaf, issuer *x509.Certificate) (string, time.Time, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return "", time.Time{}, errors.New("unsupported CRL URL scheme")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	body, err := fetch(client, req)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	crl, err := x509.ParseRevocationList(body)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("malformed CRL: %w", err)
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return "", time.Time{}, fmt.Errorf("CRL signature invalid: %w", err)
+	}
+	if err := checkFreshness("CRL", crl.ThisUpdate, crl.NextUpdate, time.Now()); err != nil {
+		return "", time.Time{}, err
+	}
+
+	for _, entry := range crl.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(leaf.SerialNumber) == 0 {
+			return RevocationRevoked, entry.RevocationTime, nil
+		}
+	}
+	return RevocationGood, time.Time{}, nil
+}
+
+func fetch(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxRevocationBody))
+}
+
+// issuerOf returns the certificate that issued leaf, from the verified chain
+// when available and otherwise from the presented chain.
+func issuerOf(verified [][]*x509.Certificate, peers []*x509.Certificate) *x509.Certificate {
+	for _, chain := range verified {
+		if len(chain) > 1 {
+			return chain[1]
+		}
+	}
+	if len(peers) > 1 && peers[0].CheckSignatureFrom(peers[1]) == nil {
+		return peers[1]
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package tlsaudit
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// ticketWait bounds how long to read after a TLS 1.3 handshake so the
+// client processes the NewSessionTicket the server sends post-handshake.
+const ticketWait = 300 * time.Millisecond
+
+// ResumptionCheck is the outcome of a resumption test for one TLS version.
+type ResumptionCheck struct {
+	Tested       bool `json:"tested"`        // First handshake succeeded
+	TicketIssued bool `json:"ticket_issued"` // Server handed out a session ticket
+	Resumed      bool `json:"resumed"`       // Second handshake resumed the session
+}
+
+// SessionResumption reports session ticket (TLS 1.2) and PSK (TLS 1.3)
+// resumption support.
+type SessionResumption struct {
+	TLS12 ResumptionCheck `json:"tls12"`
+	TLS13 ResumptionCheck `json:"tls13"`
+}
+
+// checkALPN offers h2 and http/1.1 and records the negotiated protocol.
+func (a *Auditor) checkALPN(host string, port int, result *Result) {
+	addr := fmt.Sprintf("%s:%d", host, port)
+	dialer := &net.Dialer{Timeout: a.config.Timeout / 2}
+
+	cfg := &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true,
+		NextProtos:         []string{"h2", "http/1.1"},
+	}
+	conn, err := a.dial(dialer, addr, cfg)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	result.ALPN = conn.ConnectionState().NegotiatedProtocol
+	result.HTTP2 = result.ALPN == "h2"
+}
+
+// checkResumption handshakes twice per TLS version with a shared session
+// cache and records whether the second handshake resumed.
+func (a *Auditor) checkResumption(host string, port int, result *Result) {
+	result.Resumption.TLS12 = a.testResumption(host, port, tls.VersionTLS12)
+	result.Resumption.TLS13 = a.testResumption(host, port, tls.VersionTLS13)
+}
+
+func (a *Auditor) testResumption(host string, port int, version uint16) ResumptionCheck {
+	var check ResumptionCheck
+	addr := fmt.Sprintf("%s:%d", host, port)
+	dialer := &net.Dialer{Timeout: a.config.Timeout / 2}
+	cache := &recordingCache{ClientSessionCache: tls.NewLRUClientSessionCache(1)}
+
+	cfg := &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true,
+		MinVersion:         version,
+		MaxVersion:         version,
+		ClientSessionCache: cache,
+	}
+
+	conn, err := a.dial(dialer, addr, cfg)
+	if err != nil {
+		return check
+	}
+	check.Tested = true
+	if version == tls.VersionTLS13 {
+		// TLS 1.3 tickets arrive after the handshake; a read processes them.
+		conn.SetReadDeadline(time.Now().Add(ticketWait))
+		conn.Read(make([]byte, 1))
+	}
+	conn.Close()
+
+	check.TicketIssued = cache.stored
+	if !check.TicketIssued {
+		return check
+	}
+
+	conn, err = a.dial(dialer, addr, cfg)
+	if err != nil {
+		return check
+	}
+	check.Resumed = conn.ConnectionState().DidResume
+	conn.Close()
+	return check
+}
+
+// recordingCache notes whether the server issued a resumable session.
+type recordingCache struct {
+	tls.ClientSessionCache
+	stored bool
+}
+
+func (c *recordingCache) Put(key string, cs *tls.ClientSessionState) {
+	if cs != nil {
+		c.stored = true
+	}
+	c.ClientSessionCache.Put(key, cs)
+}
+
+// isForwardSecret reports whether a cipher suite uses an ephemeral key
+// exchange. All TLS 1.3 suites do.
+func isForwardSecret(name string) bool {
+	if strings.HasPrefix(name, "TLS_AES_") || strings.HasPrefix(name, "TLS_CHACHA20_") {
+		return true
+	}
+	return strings.Contains(name, "ECDHE") || strings.Contains(name, "DHE_")
+}
@@ -8,10 +8,14 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/JedizLaPulga/NNS/internal/starttls"
 )
 
 // Severity levels for issues.
@@ -42,6 +46,11 @@ func (s Severity) String() string {
 	}
 }
 
+// MarshalJSON encodes the severity by name.
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
 // Icon returns an emoji for the severity.
 func (s Severity) Icon() string {
 	switch s {
@@ -62,88 +71,131 @@ func (s Severity) Icon() string {
 
 // Issue represents a security finding.
 type Issue struct {
-	Severity    Severity
-	Category    string
-	Title       string
-	Description string
-	Remediation string
+	Severity    Severity `json:"severity"`
+	Category    string   `json:"category"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Remediation string   `json:"remediation"`
 }
 
 // ProtocolSupport tracks which TLS versions are supported.
 type ProtocolSupport struct {
-	SSLv3   bool
-	TLS10   bool
-	TLS11   bool
-	TLS12   bool
-	TLS13   bool
-	Current string // Currently negotiated version
+	SSLv3   bool   `json:"sslv3"`
+	TLS10   bool   `json:"tls10"`
+	TLS11   bool   `json:"tls11"`
+	TLS12   bool   `json:"tls12"`
+	TLS13   bool   `json:"tls13"`
+	Current string `json:"current"` // Currently negotiated version
 }
 
 // CipherSupport tracks cipher suite information.
 type CipherSupport struct {
-	Current     string   // Currently negotiated cipher
-	Weak        []string // Weak ciphers detected
-	Recommended []string // Recommended ciphers available
+	Current     string   `json:"current"`     // Currently negotiated cipher
+	Weak        []string `json:"weak"`        // Weak ciphers detected
+	Recommended []string `json:"recommended"` // Recommended ciphers available
 }
 
 // CertInfo holds certificate details.
 type CertInfo struct {
-	Subject       string
-	Issuer        string
-	NotBefore     time.Time
-	NotAfter      time.Time
-	DaysRemaining int
-	SANs          []string
-	KeyType       string
-	KeySize       int
-	SignatureAlg  string
-	Fingerprint   string
-	IsCA          bool
-	IsSelfSigned  bool
-	Version       int
+	Subject       string    `json:"subject"`
+	Issuer        string    `json:"issuer"`
+	NotBefore     time.Time `json:"not_before"`
+	NotAfter      time.Time `json:"not_after"`
+	DaysRemaining int       `json:"days_remaining"`
+	SANs          []string  `json:"sans"`
+	KeyType       string    `json:"key_type"`
+	KeySize       int       `json:"key_size"`
+	SignatureAlg  string    `json:"signature_algorithm"`
+	Fingerprint   string    `json:"fingerprint_sha256"`
+	IsCA          bool      `json:"is_ca"`
+	IsSelfSigned  bool      `json:"is_self_signed"`
+	Version       int       `json:"version"`
 }
 
 // VulnerabilityCheck tracks specific vulnerability tests.
 type VulnerabilityCheck struct {
-	Name        string
-	Description string
-	Vulnerable  bool
-	Tested      bool
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Vulnerable  bool   `json:"vulnerable"`
+	Tested      bool   `json:"tested"`
 }
 
 // Result holds the complete audit results.
 type Result struct {
-	Host            string
-	Port            int
-	Connected       bool
-	Error           error
-	ConnectTime     time.Duration
-	Grade           string
-	Score           int
-	Protocol        ProtocolSupport
-	Cipher          CipherSupport
-	Certificate     CertInfo
-	ChainLength     int
-	ChainValid      bool
-	Issues          []Issue
-	Vulnerabilities []VulnerabilityCheck
+	Host            string               `json:"host"`
+	Port            int                  `json:"port"`
+	Connected       bool                 `json:"connected"`
+	Error           error                `json:"-"`
+	ConnectTime     time.Duration        `json:"connect_time"`
+	Grade           string               `json:"grade"`
+	Score           int                  `json:"score"`
+	Protocol        ProtocolSupport      `json:"protocols"`
+	Cipher          CipherSupport        `json:"ciphers"`
+	Certificate     CertInfo             `json:"certificate"`
+	ChainLength     int                  `json:"chain_length"`
+	ChainValid      bool                 `json:"chain_valid"`
+	Revocation      RevocationStatus     `json:"revocation"`
+	ALPN            string               `json:"alpn,omitempty"` // Negotiated application protocol
+	HTTP2           bool                 `json:"http2"`
+	Resumption      SessionResumption    `json:"resumption"`
+	Issues          []Issue              `json:"issues"`
+	Vulnerabilities []VulnerabilityCheck `json:"vulnerabilities"`
+}
+
+// ToJSON returns the audit result as indented JSON.
+func (r *Result) ToJSON() (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// MarshalJSON encodes the result with its error as a string.
+func (r Result) MarshalJSON() ([]byte, error) {
+	type result Result
+	out := struct {
+		result
+		Error string `json:"error,omitempty"`
+	}{result: result(r)}
+	if r.Error != nil {
+		out.Error = r.Error.Error()
+	}
+	if out.Issues == nil {
+		out.Issues = []Issue{}
+	}
+	if out.Vulnerabilities == nil {
+		out.Vulnerabilities = []VulnerabilityCheck{}
+	}
+	return json.Marshal(out)
 }
 
 // Config configures the auditor.
 type Config struct {
-	Timeout        time.Duration
-	CheckProtocols bool // Test all protocol versions
-	CheckVulns     bool // Check for known vulnerabilities
-	SkipCertVerify bool // Continue even with cert errors
+	Timeout         time.Duration
+	CheckProtocols  bool   // Test all protocol versions
+	CheckVulns      bool   // Check for known vulnerabilities
+	CheckCiphers    bool   // Enumerate every accepted cipher suite
+	CheckRevocation bool   // Query OCSP/CRL for the leaf certificate
+	CheckALPN       bool   // Report the negotiated ALPN protocol
+	CheckResumption bool   // Test TLS 1.2 ticket and TLS 1.3 PSK resumption
+	SkipCertVerify  bool   // Continue even with cert errors
+	Concurrency     int    // Hosts audited in parallel by AuditMany
+	StartTLS        string // Upgrade via STARTTLS first: smtp, imap, pop3, ldap or postgres
 }
 
 // DefaultConfig returns default configuration.
 func DefaultConfig() Config {
 	return Config{
-		Timeout:        10 * time.Second,
-		CheckProtocols: true,
-		CheckVulns:     true,
-		SkipCertVerify: true,
+		Timeout:         10 * time.Second,
+		CheckProtocols:  true,
+		CheckVulns:      true,
+		CheckCiphers:    true,
+		CheckRevocation: true,
+		CheckALPN:       true,
+		CheckResumption: true,
+		SkipCertVerify:  true,
+		Concurrency:     5,
 	}
 }
 
@@ -157,9 +209,46 @@ func New(cfg Config) *Auditor {
 	if cfg.Timeout <= 0 {
 		cfg.Timeout = 10 * time.Second
 	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 5
+	}
 	return &Auditor{config: cfg}
 }
 
+// AuditMany audits hosts concurrently. Each entry is host or host:port, as
+// accepted by ParseHostPort, and keys the returned map.
+func (a *Auditor) AuditMany(hosts []string) map[string]*Result {
+	results := make(map[string]*Result, len(hosts))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, a.config.Concurrency)
+
+	seen := make(map[string]bool, len(hosts))
+	for _, entry := range hosts {
+		if seen[entry] {
+			continue
+		}
+		seen[entry] = true
+
+		wg.Add(1)
+		go func(entry string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			host, port := ParseHostPort(entry)
+			r := a.Audit(host, port)
+
+			mu.Lock()
+			results[entry] = r
+			mu.Unlock()
+		}(entry)
+	}
+
+	wg.Wait()
+	return results
+}
+
 // Audit performs a comprehensive TLS audit on a host.
 func (a *Auditor) Audit(host string, port int) *Result {
 	result := &Result{
@@ -180,7 +269,7 @@ func (a *Auditor) Audit(host string, port int) *Result {
 
 	dialer := &net.Dialer{Timeout: a.config.Timeout}
 	start := time.Now()
-	conn, err := tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	conn, err := a.dial(dialer, addr, tlsConfig)
 	result.ConnectTime = time.Since(start)
 
 	if err != nil {
@@ -209,6 +298,10 @@ func (a *Auditor) Audit(host string, port int) *Result {
 		result.Certificate = parseCertInfo(cert)
 		result.ChainLength = len(state.PeerCertificates)
 		result.ChainValid = len(state.VerifiedChains) > 0
+
+		if a.config.CheckRevocation {
+			a.checkRevocation(cert, issuerOf(state.VerifiedChains, state.PeerCertificates), result)
+		}
 	}
 
 	// Check protocols if enabled
@@ -216,6 +309,19 @@ func (a *Auditor) Audit(host string, port int) *Result {
 		a.checkProtocols(host, port, result)
 	}
 
+	// Enumerate cipher suites if enabled
+	if a.config.CheckCiphers {
+		a.enumerateCiphers(host, port, result)
+	}
+
+	// Handshake features
+	if a.config.CheckALPN {
+		a.checkALPN(host, port, result)
+	}
+	if a.config.CheckResumption {
+		a.checkResumption(host, port, result)
+	}
+
 	// Check for vulnerabilities
 	if a.config.CheckVulns {
 		a.checkVulnerabilities(host, port, result)
@@ -227,6 +333,31 @@ func (a *Auditor) Audit(host string, port int) *Result {
 	return result
 }
 
+// dial connects to addr and performs a TLS handshake, negotiating the
+// configured STARTTLS upgrade first. dialer.Timeout bounds the whole exchange.
+func (a *Auditor) dial(dialer *net.Dialer, addr string, cfg *tls.Config) (*tls.Conn, error) {
+	if a.config.StartTLS == "" {
+		return tls.DialWithDialer(dialer, "tcp", addr, cfg)
+	}
+
+	raw, err := dialer.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	raw.SetDeadline(time.Now().Add(dialer.Timeout))
+	if err := starttls.Negotiate(raw, a.config.StartTLS); err != nil {
+		raw.Close()
+		return nil, fmt.Errorf("STARTTLS: %w", err)
+	}
+	conn := tls.Client(raw, cfg)
+	if err := conn.Handshake(); err != nil {
+		raw.Close()
+		return nil, err
+	}
+	raw.SetDeadline(time.Time{})
+	return conn, nil
+}
+
 // checkProtocols tests which TLS versions are supported.
 func (a *Auditor) checkProtocols(host string, port int, result *Result) {
 	addr := fmt.Sprintf("%s:%d", host, port)
@@ -249,7 +380,7 @@ func (a *Auditor) checkProtocols(host string, port int, result *Result) {
 			MinVersion:         p.version,
 			MaxVersion:         p.version,
 		}
-		conn, err := tls.DialWithDialer(dialer, "tcp", addr, cfg)
+		conn, err := a.dial(dialer, addr, cfg)
 		if err == nil {
 			*p.field = true
 			conn.Close()
@@ -257,6 +388,88 @@ func (a *Auditor) checkProtocols(host string, port int, result *Result) {
 	}
 }
 
+// enumerateCiphers handshakes once per cipher suite, offering only that
+// suite, and records every suite the server accepts. TLS 1.3 suites cannot
+// be restricted by the client, so a single TLS 1.3 handshake records the
+// suite the server prefers.
+func (a *Auditor) enumerateCiphers(host string, port int, result *Result) {
+	addr := fmt.Sprintf("%s:%d", host, port)
+	dialer := &net.Dialer{Timeout: a.config.Timeout / 2}
+
+	accept := func(id uint16, insecure bool) {
+		name := tls.CipherSuiteName(id)
+		if insecure || isWeakCipher(name) {
+			result.Cipher.Weak = appendUnique(result.Cipher.Weak, name)
+		} else {
+			result.Cipher.Recommended = appendUnique(result.Cipher.Recommended, name)
+		}
+	}
+
+	suites := append(tls.CipherSuites(), tls.InsecureCipherSuites()...)
+	for _, suite := range suites {
+		if !supportsPreTLS13(suite) {
+			continue
+		}
+		cfg := &tls.Config{
+			ServerName:         host,
+			InsecureSkipVerify: true,
+			MinVersion:         tls.VersionTLS10,
+			MaxVersion:         tls.VersionTLS12,
+			CipherSuites:       []uint16{suite.ID},
+		}
+		conn, err := a.dial(dialer, addr, cfg)
+		if err != nil {
+			continue
+		}
+		accept(conn.ConnectionState().CipherSuite, suite.Insecure)
+		conn.Close()
+	}
+
+	cfg := &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true,
+		MinVersion:         tls.VersionTLS13,
+		MaxVersion:         tls.VersionTLS13,
+	}
+	if conn, err := a.dial(dialer, addr, cfg); err == nil {
+		accept(conn.ConnectionState().CipherSuite, false)
+		conn.Close()
+	}
+}
+
+// supportsPreTLS13 reports whether suite can be negotiated below TLS 1.3.
+func supportsPreTLS13(suite *tls.CipherSuite) bool {
+	for _, v := range suite.SupportedVersions {
+		if v < tls.VersionTLS13 {
+			return true
+		}
+	}
+	return false
+}
+
+// weakCiphers are name fragments of cipher suites considered weak.
+var weakCiphers = []string{"RC4", "DES", "3DES", "NULL", "EXPORT", "ANON", "MD5"}
+
+// isWeakCipher reports whether a cipher suite name matches a weak cipher.
+func isWeakCipher(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, weak := range weakCiphers {
+		if strings.Contains(upper, weak) {
+			return true
+		}
+	}
+	return false
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
 // checkVulnerabilities checks for known TLS vulnerabilities.
 func (a *Auditor) checkVulnerabilities(host string, port int, result *Result) {
 	// Check for weak protocols (BEAST, POODLE vulnerability indicators)
@@ -279,16 +492,19 @@ func (a *Auditor) checkVulnerabilities(host string, port int, result *Result) {
 	}
 
 	// Check cipher suite for known weak ciphers
-	weakCiphers := []string{"RC4", "DES", "3DES", "NULL", "EXPORT", "anon", "MD5"}
-	for _, weak := range weakCiphers {
-		if strings.Contains(strings.ToUpper(result.Cipher.Current), weak) {
-			result.Cipher.Weak = append(result.Cipher.Weak, result.Cipher.Current)
+	if isWeakCipher(result.Cipher.Current) {
+		result.Cipher.Weak = appendUnique(result.Cipher.Weak, result.Cipher.Current)
+	}
+
+	// Sweet32 check (3DES), against every accepted weak suite
+	sweet32 := false
+	for _, c := range result.Cipher.Weak {
+		if strings.Contains(c, "3DES") {
+			sweet32 = true
 			break
 		}
 	}
-
-	// Sweet32 check (3DES)
-	if strings.Contains(result.Cipher.Current, "3DES") {
+	if sweet32 {
 		result.Vulnerabilities = append(result.Vulnerabilities, VulnerabilityCheck{
 			Name:        "Sweet32",
 			Description: "64-bit block cipher vulnerability (3DES)",
@@ -394,6 +610,22 @@ func (a *Auditor) analyzeAndScore(result *Result) {
 		result.Score -= 10
 	}
 
+	if result.Revocation.Status == RevocationRevoked {
+		desc := fmt.Sprintf("Certificate has been revoked (%s)", result.Revocation.Method)
+		if !result.Revocation.RevokedAt.IsZero() {
+			desc = fmt.Sprintf("Certificate was revoked on %s (%s)",
+				result.Revocation.RevokedAt.Format("2006-01-02"), result.Revocation.Method)
+		}
+		result.Issues = append(result.Issues, Issue{
+			Severity:    Critical,
+			Category:    "Certificate",
+			Title:       "Certificate Revoked",
+			Description: desc,
+			Remediation: "Replace the certificate with a newly issued one",
+		})
+		result.Score -= 100
+	}
+
 	// Key size issues
 	if result.Certificate.KeyType == "RSA" && result.Certificate.KeySize < 2048 {
 		result.Issues = append(result.Issues, Issue{
@@ -412,12 +644,60 @@ func (a *Auditor) analyzeAndScore(result *Result) {
 			Severity:    High,
 			Category:    "Cipher",
 			Title:       "Weak Cipher Suite",
-			Description: fmt.Sprintf("Using weak cipher: %s", strings.Join(result.Cipher.Weak, ", ")),
+			Description: fmt.Sprintf("Server accepts weak cipher: %s", strings.Join(result.Cipher.Weak, ", ")),
 			Remediation: "Configure server to use only strong cipher suites",
 		})
 		result.Score -= 20
 	}
 
+	// Forward secrecy
+	if result.Cipher.Current != "" && !isForwardSecret(result.Cipher.Current) {
+		result.Issues = append(result.Issues, Issue{
+			Severity:    Medium,
+			Category:    "Cipher",
+			Title:       "No Forward Secrecy",
+			Description: fmt.Sprintf("Negotiated cipher %s uses static RSA key exchange", result.Cipher.Current),
+			Remediation: "Prefer ECDHE cipher suites",
+		})
+		result.Score -= 10
+	} else {
+		var static []string
+		for _, c := range append(append([]string{}, result.Cipher.Recommended...), result.Cipher.Weak...) {
+			if !isForwardSecret(c) {
+				static = append(static, c)
+			}
+		}
+		if len(static) > 0 {
+			result.Issues = append(result.Issues, Issue{
+				Severity:    Low,
+				Category:    "Cipher",
+				Title:       "Non-Forward-Secret Ciphers Accepted",
+				Description: fmt.Sprintf("Server also accepts static key exchange: %s", strings.Join(static, ", ")),
+				Remediation: "Disable TLS_RSA_* cipher suites",
+			})
+			result.Score -= 5
+		}
+	}
+
+	// Session resumption
+	for _, r := range []struct {
+		version string
+		check   ResumptionCheck
+	}{
+		{"TLS 1.2", result.Resumption.TLS12},
+		{"TLS 1.3", result.Resumption.TLS13},
+	} {
+		if r.check.TicketIssued && !r.check.Resumed {
+			result.Issues = append(result.Issues, Issue{
+				Severity:    Info,
+				Category:    "Session",
+				Title:       "Session Resumption Failing",
+				Description: fmt.Sprintf("Server issues %s session tickets but does not accept them", r.version),
+				Remediation: "Share session ticket keys across all servers behind the endpoint",
+			})
+		}
+	}
+
 	// Signature algorithm issues
 	weakSigAlgs := []string{"MD5", "SHA1"}
 	for _, weak := range weakSigAlgs {
@@ -1,10 +1,48 @@
 package tlsaudit
 
 import (
+	"bufio"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 )
 
+// newTLSServer starts a local TLS server with the given configuration.
+// setup, when non-nil, runs on the server before it starts.
+func newTLSServer(t *testing.T, cfg *tls.Config, setup ...func(*httptest.Server)) (string, int) {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = cfg
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	for _, fn := range setup {
+		fn(srv)
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	host, portStr, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	return host, port
+}
+
 func TestNew(t *testing.T) {
 	a := New(DefaultConfig())
 	if a == nil {
@@ -96,3 +134,445 @@ func TestAuditor_InvalidHost(t *testing.T) {
 		t.Errorf("Grade = %q", result.Grade)
 	}
 }
+
+func TestEnumerateCiphers(t *testing.T) {
+	host, port := newTLSServer(t, &tls.Config{
+		MaxVersion: tls.VersionTLS12,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA,
+		},
+	})
+
+	a := New(Config{Timeout: 2 * time.Second})
+	result := &Result{}
+	a.enumerateCiphers(host, port, result)
+
+	if len(result.Cipher.Recommended) != 1 || result.Cipher.Recommended[0] != "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256" {
+		t.Errorf("Recommended = %v", result.Cipher.Recommended)
+	}
+	if len(result.Cipher.Weak) != 1 || result.Cipher.Weak[0] != "TLS_RSA_WITH_3DES_EDE_CBC_SHA" {
+		t.Errorf("Weak = %v", result.Cipher.Weak)
+	}
+
+	// The negotiated suite is strong, but the accepted 3DES suite must
+	// still be reported.
+	a.checkVulnerabilities(host, port, result)
+	found := false
+	for _, v := range result.Vulnerabilities {
+		if v.Name == "Sweet32" && v.Vulnerable {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Sweet32 not reported for accepted 3DES suite")
+	}
+}
+
+func TestIsWeakCipher(t *testing.T) {
+	if !isWeakCipher("TLS_ECDHE_RSA_WITH_RC4_128_SHA") {
+		t.Error("RC4 should be weak")
+	}
+	if isWeakCipher("TLS_AES_128_GCM_SHA256") {
+		t.Error("AES-GCM should not be weak")
+	}
+}
+
+// testPKI is a CA and a leaf it issued, with revocation endpoints on srvURL.
+type testPKI struct {
+	ca     *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	leaf   *x509.Certificate
+	srvURL string
+}
+
+func newTestPKI(t *testing.T, srvURL string) *testPKI {
+	t.Helper()
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+
+	leafKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	leafTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(4242),
+		Subject:               pkix.Name{CommonName: "leaf.test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		OCSPServer:            []string{srvURL + "/ocsp"},
+		CRLDistributionPoints: []string{srvURL + "/crl"},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTmpl, ca, &leafKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, _ := x509.ParseCertificate(leafDER)
+	return &testPKI{ca: ca, caKey: caKey, leaf: leaf, srvURL: srvURL}
+}
+
+// ocspSingle returns a current OCSP status for the leaf.
+func (p *testPKI) ocspSingle(t *testing.T, revoked bool) ocspSingleResponse {
+	t.Helper()
+	nameHash, keyHash, err := issuerHashes(p.ca, crypto.SHA1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	single := ocspSingleResponse{
+		CertID: ocspCertID{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA1, Parameters: asn1.NullRawValue},
+			NameHash:      nameHash,
+			IssuerKeyHash: keyHash,
+			SerialNumber:  p.leaf.SerialNumber,
+		},
+		ThisUpdate: time.Now().UTC().Truncate(time.Second),
+		NextUpdate: time.Now().UTC().Add(time.Hour).Truncate(time.Second),
+	}
+	if revoked {
+		single.Revoked = ocspRevokedInfo{RevocationTime: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}
+	} else {
+		single.Good = true
+	}
+	return single
+}
+
+// ocspResponse builds a CA-signed OCSP response carrying single.
+func (p *testPKI) ocspResponse(t *testing.T, single ocspSingleResponse) []byte {
+	t.Helper()
+	keyHash, _ := asn1.Marshal(make([]byte, 20))
+	tbs, err := asn1.Marshal(ocspResponseData{
+		ResponderID: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 2, IsCompound: true, Bytes: keyHash},
+		ProducedAt:  time.Now().UTC().Truncate(time.Second),
+		Responses:   []ocspSingleResponse{single},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(tbs)
+	sig, _ := ecdsa.SignASN1(rand.Reader, p.caKey, digest[:])
+
+	basic, err := asn1.Marshal(ocspBasicResponse{
+		TBSResponseData:    asn1.RawValue{FullBytes: tbs},
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}},
+		Signature:          asn1.BitString{Bytes: sig, BitLength: 8 * len(sig)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp ocspResponse
+	resp.Response.ResponseType = oidOCSPBasic
+	resp.Response.Response = basic
+	out, err := asn1.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// crl builds a current CA-signed CRL, optionally listing the leaf.
+func (p *testPKI) crl(t *testing.T, revoked bool) []byte {
+	t.Helper()
+	return p.crlWindow(t, revoked, time.Now().Add(-time.Minute), time.Now().Add(time.Hour))
+}
+
+// crlWindow builds a CA-signed CRL valid from thisUpdate to nextUpdate.
+func (p *testPKI) crlWindow(t *testing.T, revoked bool, thisUpdate, nextUpdate time.Time) []byte {
+	t.Helper()
+	tmpl := &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: thisUpdate,
+		NextUpdate: nextUpdate,
+	}
+	if revoked {
+		tmpl.RevokedCertificateEntries = []x509.RevocationListEntry{
+			{SerialNumber: p.leaf.SerialNumber, RevocationTime: time.Now().Add(-time.Minute)},
+		}
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, tmpl, p.ca, p.caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func TestCheckRevocation(t *testing.T) {
+	tests := []struct {
+		name       string
+		ocsp       string // good, revoked or fail
+		crlRevoked bool
+		wantStatus string
+		wantMethod string
+	}{
+		{"ocsp good", "good", true, RevocationGood, "OCSP"},
+		{"ocsp revoked", "revoked", false, RevocationRevoked, "OCSP"},
+		{"crl fallback", "fail", true, RevocationRevoked, "CRL"},
+		{"crl good", "fail", false, RevocationGood, "CRL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pki *testPKI
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/ocsp" && tt.ocsp != "fail":
+					w.Write(pki.ocspResponse(t, pki.ocspSingle(t, tt.ocsp == "revoked")))
+				case r.URL.Path == "/crl":
+					w.Write(pki.crl(t, tt.crlRevoked))
+				default:
+					http.Error(w, "unavailable", http.StatusInternalServerError)
+				}
+			}))
+			defer srv.Close()
+			pki = newTestPKI(t, srv.URL)
+
+			a := New(Config{Timeout: 2 * time.Second})
+			result := &Result{}
+			a.checkRevocation(pki.leaf, pki.ca, result)
+
+			if result.Revocation.Status != tt.wantStatus || result.Revocation.Method != tt.wantMethod {
+				t.Errorf("Revocation = %+v, want %s via %s", result.Revocation, tt.wantStatus, tt.wantMethod)
+			}
+		})
+	}
+}
+
+func TestRevocationFreshness(t *testing.T) {
+	pki := newTestPKI(t, "http://127.0.0.1")
+	now := time.Now().UTC().Truncate(time.Second)
+
+	stale := pki.ocspSingle(t, false)
+	stale.ThisUpdate = now.Add(-48 * time.Hour)
+	stale.NextUpdate = now.Add(-24 * time.Hour)
+	future := pki.ocspSingle(t, false)
+	future.ThisUpdate = now.Add(24 * time.Hour)
+	otherIssuer := pki.ocspSingle(t, false)
+	otherIssuer.CertID.NameHash = make([]byte, 20)
+
+	for name, single := range map[string]ocspSingleResponse{
+		"stale": stale, "future": future, "other issuer": otherIssuer,
+	} {
+		if status, _, err := parseOCSPResponse(pki.ocspResponse(t, single), pki.leaf, pki.ca); err == nil {
+			t.Errorf("parseOCSPResponse(%s) = %s, want error", name, status)
+		}
+	}
+	if status, _, err := parseOCSPResponse(pki.ocspResponse(t, pki.ocspSingle(t, false)), pki.leaf, pki.ca); err != nil || status != RevocationGood {
+		t.Errorf("parseOCSPResponse(current) = %s, %v, want good", status, err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(pki.crlWindow(t, false, now.Add(-48*time.Hour), now.Add(-24*time.Hour)))
+	}))
+	defer srv.Close()
+	if status, _, err := checkCRL(context.Background(), srv.Client(), srv.URL, pki.leaf, pki.ca); err == nil {
+		t.Errorf("checkCRL(expired) = %s, want error", status)
+	}
+}
+
+func TestRevokedGradesF(t *testing.T) {
+	result := &Result{
+		Score:       100,
+		Protocol:    ProtocolSupport{TLS12: true, TLS13: true},
+		Certificate: CertInfo{DaysRemaining: 90},
+		Revocation:  RevocationStatus{Checked: true, Status: RevocationRevoked, Method: "OCSP"},
+	}
+	New(DefaultConfig()).analyzeAndScore(result)
+
+	if result.Grade != "F" {
+		t.Errorf("Grade = %q, want F", result.Grade)
+	}
+	if CountBySeverity(result.Issues)[Critical] != 1 {
+		t.Errorf("Issues = %+v", result.Issues)
+	}
+}
+
+func TestCheckALPN(t *testing.T) {
+	host, port := newTLSServer(t, nil, func(srv *httptest.Server) { srv.EnableHTTP2 = true })
+
+	result := &Result{}
+	New(Config{Timeout: 2 * time.Second}).checkALPN(host, port, result)
+	if result.ALPN != "h2" || !result.HTTP2 {
+		t.Errorf("ALPN = %q, HTTP2 = %v", result.ALPN, result.HTTP2)
+	}
+}
+
+func TestCheckResumption(t *testing.T) {
+	host, port := newTLSServer(t, &tls.Config{})
+	a := New(Config{Timeout: 2 * time.Second})
+
+	result := &Result{}
+	a.checkResumption(host, port, result)
+	for name, c := range map[string]ResumptionCheck{"TLS12": result.Resumption.TLS12, "TLS13": result.Resumption.TLS13} {
+		if !c.Tested || !c.TicketIssued || !c.Resumed {
+			t.Errorf("%s = %+v, want resumed", name, c)
+		}
+	}
+
+	// Rotating ticket keys per connection issues tickets that never resume.
+	var srv *httptest.Server
+	host, port = newTLSServer(t, &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cfg := srv.TLS.Clone()
+			cfg.GetConfigForClient = nil
+			var key [32]byte
+			rand.Read(key[:])
+			cfg.SetSessionTicketKeys([][32]byte{key})
+			return cfg, nil
+		},
+	}, func(s *httptest.Server) { srv = s })
+
+	result = &Result{Score: 100}
+	a.checkResumption(host, port, result)
+	if !result.Resumption.TLS13.TicketIssued || result.Resumption.TLS13.Resumed {
+		t.Errorf("TLS13 = %+v, want ticket issued but not resumed", result.Resumption.TLS13)
+	}
+	a.analyzeAndScore(result)
+	found := false
+	for _, issue := range result.Issues {
+		if issue.Title == "Session Resumption Failing" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("resumption misconfiguration not reported")
+	}
+}
+
+func TestIsForwardSecret(t *testing.T) {
+	if !isForwardSecret("TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256") || !isForwardSecret("TLS_AES_256_GCM_SHA384") {
+		t.Error("ECDHE and TLS 1.3 suites are forward secret")
+	}
+	if isForwardSecret("TLS_RSA_WITH_AES_128_GCM_SHA256") {
+		t.Error("static RSA is not forward secret")
+	}
+}
+
+func TestResultToJSON(t *testing.T) {
+	result := &Result{
+		Host:        "example.com",
+		Port:        443,
+		Error:       errors.New("handshake failed"),
+		ConnectTime: 25 * time.Millisecond,
+		Grade:       "F",
+		Issues:      []Issue{{Severity: Critical, Title: "Certificate Revoked"}},
+	}
+	out, err := result.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if decoded["error"] != "handshake failed" || decoded["connect_time"] != float64(25*time.Millisecond) {
+		t.Errorf("error/connect_time = %v/%v", decoded["error"], decoded["connect_time"])
+	}
+	issues := decoded["issues"].([]any)
+	if issues[0].(map[string]any)["severity"] != "CRITICAL" {
+		t.Errorf("issues = %v", issues)
+	}
+	if _, ok := decoded["vulnerabilities"].([]any); !ok {
+		t.Errorf("vulnerabilities should encode as an array: %v", decoded["vulnerabilities"])
+	}
+}
+
+func TestAuditMany(t *testing.T) {
+	host, port := newTLSServer(t, nil)
+	live := net.JoinHostPort(host, strconv.Itoa(port))
+
+	a := New(Config{Timeout: 2 * time.Second, Concurrency: 2, SkipCertVerify: true})
+	results := a.AuditMany([]string{live, live, "invalid.nowhere"})
+
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if r := results[live]; r == nil || !r.Connected {
+		t.Errorf("results[%s] = %+v", live, r)
+	}
+	if r := results["invalid.nowhere"]; r == nil || r.Connected {
+		t.Errorf("results[invalid.nowhere] = %+v", r)
+	}
+}
+
+// selfSignedCert returns a throwaway server certificate.
+func selfSignedCert(t *testing.T) tls.Certificate {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "mail.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestAuditStartTLS(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	serverCfg := &tls.Config{Certificates: []tls.Certificate{selfSignedCert(t)}}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				io.WriteString(conn, "220 mail.test ESMTP\r\n")
+				r.ReadString('\n')
+				io.WriteString(conn, "250-mail.test\r\n250 STARTTLS\r\n")
+				r.ReadString('\n')
+				io.WriteString(conn, "220 Go ahead\r\n")
+				tlsConn := tls.Server(conn, serverCfg)
+				if tlsConn.Handshake() == nil {
+					tlsConn.Read(make([]byte, 1))
+				}
+			}(conn)
+		}
+	}()
+
+	host, portStr, _ := net.SplitHostPort(ln.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	cfg := DefaultConfig()
+	cfg.Timeout = 2 * time.Second
+	cfg.CheckRevocation = false
+	cfg.StartTLS = "smtp"
+	result := New(cfg).Audit(host, port)
+
+	if !result.Connected {
+		t.Fatalf("Audit() error = %v", result.Error)
+	}
+	if result.Certificate.Subject != "CN=mail.test" {
+		t.Errorf("Subject = %q", result.Certificate.Subject)
+	}
+	if !result.Protocol.TLS13 || len(result.Cipher.Recommended) == 0 {
+		t.Errorf("Protocol = %+v, Cipher = %+v", result.Protocol, result.Cipher)
+	}
+
+	// Without the upgrade the plaintext greeting fails the handshake.
+	cfg.StartTLS = ""
+	if New(cfg).Audit(host, port).Connected {
+		t.Error("implicit TLS against a STARTTLS port should fail")
+	}
+}
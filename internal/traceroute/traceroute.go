@@ -11,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/JedizLaPulga/NNS/internal/srcaddr"
 	"golang.org/x/net/icmp"
 	"golang.org/x/net/ipv4"
 )
@@ -35,6 +36,7 @@ type Config struct {
 	Queries   int // Probes per hop
 	Timeout   time.Duration
 	ResolveAS bool
+	SourceIP  string // Local address to send from ("" = default route)
 }
 
 // Tracer executes the traceroute.
@@ -71,7 +73,7 @@ func (t *Tracer) Run(ctx context.Context, callback func(h *Hop)) error {
 		return fmt.Errorf("resolve failed: %w", err)
 	}
 
-	c, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+	c, err := icmp.ListenPacket("ip4:icmp", srcaddr.ListenAddr(t.cfg.SourceIP))
 	if err != nil {
 		return fmt.Errorf("listen failed (needs admin): %w", err)
 	}
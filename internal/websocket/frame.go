@@ -0,0 +1,155 @@
+package websocket
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// acceptGUID is appended to Sec-WebSocket-Key to derive the accept value
+// (RFC 6455 section 1.3).
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Opcodes (RFC 6455 section 5.2).
+const (
+	OpContinuation byte = 0x0
+	OpText         byte = 0x1
+	OpBinary       byte = 0x2
+	OpClose        byte = 0x8
+	OpPing         byte = 0x9
+	OpPong         byte = 0xA
+)
+
+// Frame is a single WebSocket frame as seen on the wire.
+type Frame struct {
+	Fin     bool
+	Opcode  byte
+	Masked  bool
+	Payload []byte // unmasked payload
+	Raw     []byte // exact bytes read, for relaying the frame unchanged
+}
+
+// AcceptKey returns the Sec-WebSocket-Accept value for a Sec-WebSocket-Key.
+func AcceptKey(key string) string {
+	h := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// ReadFrame reads one frame from r, rejecting payloads larger than
+// maxPayload bytes.
+func ReadFrame(r io.Reader, maxPayload uint64) (*Frame, error) {
+	var hdr [2]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+	raw := append([]byte{}, hdr[:]...)
+
+	f := &Frame{
+		Fin:    hdr[0]&0x80 != 0,
+		Opcode: hdr[0] & 0x0F,
+		Masked: hdr[1]&0x80 != 0,
+	}
+
+	length := uint64(hdr[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return nil, err
+		}
+		raw = append(raw, ext[:]...)
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return nil, err
+		}
+		raw = append(raw, ext[:]...)
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > maxPayload {
+		return nil, fmt.Errorf("websocket frame too large: %d bytes", length)
+	}
+
+	var mask [4]byte
+	if f.Masked {
+		if _, err := io.ReadFull(r, mask[:]); err != nil {
+			return nil, err
+		}
+		raw = append(raw, mask[:]...)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	raw = append(raw, payload...)
+
+	if f.Masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	f.Payload = payload
+	f.Raw = raw
+	return f, nil
+}
+
+// WriteFrame writes payload as a single final frame. Clients must set mask;
+// servers must not.
+func WriteFrame(w io.Writer, opcode byte, payload []byte, mask bool) error {
+	frame := []byte{0x80 | opcode} // FIN
+
+	maskBit := byte(0)
+	if mask {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+
+	if mask {
+		var key [4]byte
+		if _, err := rand.Read(key[:]); err != nil {
+			return err
+		}
+		frame = append(frame, key[:]...)
+		for i, b := range payload {
+			frame = append(frame, b^key[i%4])
+		}
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	_, err := w.Write(frame)
+	return err
+}
+
+// OpcodeName returns a human-readable name for an opcode.
+func OpcodeName(opcode byte) string {
+	switch opcode {
+	case OpContinuation:
+		return "continuation"
+	case OpText:
+		return "text"
+	case OpBinary:
+		return "binary"
+	case OpClose:
+		return "close"
+	case OpPing:
+		return "ping"
+	case OpPong:
+		return "pong"
+	}
+	return fmt.Sprintf("opcode-%d", opcode)
+}
@@ -1,6 +1,7 @@
 package websocket
 
 import (
+	"bytes"
 	"context"
 	"net/http/httptest"
 	"strings"
@@ -244,3 +245,56 @@ func TestResultError(t *testing.T) {
 		t.Error("expected error to be set")
 	}
 }
+
+func TestAcceptKey(t *testing.T) {
+	// Example from RFC 6455 section 1.3
+	if got := AcceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("AcceptKey = %q", got)
+	}
+}
+
+func TestReadFrame(t *testing.T) {
+	// Masked "Hello" from RFC 6455 section 5.7.
+	masked := []byte{0x81, 0x85, 0x37, 0xfa, 0x21, 0x3d, 0x7f, 0x9f, 0x4d, 0x51, 0x58}
+	f, err := ReadFrame(bytes.NewReader(masked), 1024)
+	if err != nil {
+		t.Fatalf("ReadFrame() error = %v", err)
+	}
+	if !f.Fin || f.Opcode != OpText || !f.Masked || string(f.Payload) != "Hello" {
+		t.Errorf("frame = %+v, want final masked text Hello", f)
+	}
+	if !bytes.Equal(f.Raw, masked) {
+		t.Error("raw bytes should match the input exactly")
+	}
+
+	// 256-byte unmasked binary frame with a 16-bit extended length.
+	long := append([]byte{0x82, 126, 0x01, 0x00}, make([]byte, 256)...)
+	f, err = ReadFrame(bytes.NewReader(long), 1024)
+	if err != nil || f.Opcode != OpBinary || len(f.Payload) != 256 || OpcodeName(f.Opcode) != "binary" {
+		t.Errorf("extended frame = %+v, err %v", f, err)
+	}
+
+	if _, err := ReadFrame(bytes.NewReader(long), 255); err == nil {
+		t.Error("frame over the payload limit should return error")
+	}
+	if _, err := ReadFrame(bytes.NewReader([]byte{0x81, 0x05, 'H'}), 1024); err == nil {
+		t.Error("truncated frame should return error")
+	}
+}
+
+func TestWriteFrameRoundTrip(t *testing.T) {
+	payload := bytes.Repeat([]byte("x"), 70000) // 64-bit length
+	for _, mask := range []bool{true, false} {
+		var buf bytes.Buffer
+		if err := WriteFrame(&buf, OpBinary, payload, mask); err != nil {
+			t.Fatalf("WriteFrame() error = %v", err)
+		}
+		f, err := ReadFrame(&buf, 1<<20)
+		if err != nil {
+			t.Fatalf("ReadFrame() error = %v", err)
+		}
+		if !f.Fin || f.Masked != mask || !bytes.Equal(f.Payload, payload) {
+			t.Errorf("mask=%v: round trip mismatch (fin=%v masked=%v len=%d)", mask, f.Fin, f.Masked, len(f.Payload))
+		}
+	}
+}
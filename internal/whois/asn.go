@@ -0,0 +1,32 @@
+package whois
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/JedizLaPulga/NNS/internal/asn"
+)
+
+// lookupASN resolves the origin AS of an IP via Team Cymru's DNS service.
+// It is a variable so tests can avoid the network.
+var lookupASN = func(ctx context.Context, ip string, timeout time.Duration) (*asn.ASInfo, error) {
+	return asn.Lookup(ctx, asn.LookupOptions{Target: ip, Timeout: timeout})
+}
+
+// enrichASN adds the origin ASN, AS name and announcing prefix to an IP
+// result. Registration data says who was allocated a block; the routing
+// origin says who is actually announcing it. Failures are ignored, since
+// unrouted or private addresses have no origin.
+func (c *Client) enrichASN(ctx context.Context, result *Result) {
+	if !c.ResolveASN || net.ParseIP(result.Query) == nil {
+		return
+	}
+	info, err := lookupASN(ctx, result.Query, c.Timeout)
+	if err != nil || info.ASN == 0 {
+		return
+	}
+	result.ASN = info.ASN
+	result.ASName = info.Name
+	result.Prefix = info.Prefix
+}
@@ -0,0 +1,133 @@
+package whois
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Method selects which protocols a lookup uses.
+type Method int
+
+const (
+	MethodAuto  Method = iota // RDAP, falling back to WHOIS
+	MethodRDAP                // RDAP only
+	MethodWHOIS               // Legacy WHOIS (port 43) only
+)
+
+// BulkResult is the outcome of one target in a bulk lookup.
+type BulkResult struct {
+	Query  string  `json:"query"`
+	Result *Result `json:"result,omitempty"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// ToJSON returns the result as indented JSON.
+func (r *Result) ToJSON() (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// LookupWith looks up target using method. Each protocol attempt gets its
+// own Timeout, so a slow RDAP server does not eat into the WHOIS fallback.
+func (c *Client) LookupWith(ctx context.Context, target string, method Method) (*Result, error) {
+	var rdapErr error
+	if method != MethodWHOIS {
+		rctx, cancel := context.WithTimeout(ctx, c.Timeout)
+		result, err := c.LookupRDAP(rctx, target)
+		cancel()
+		if err == nil || method == MethodRDAP {
+			return result, err
+		}
+		rdapErr = err
+	}
+
+	wctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+	result, err := c.Lookup(wctx, target)
+	if err != nil && rdapErr != nil {
+		return nil, fmt.Errorf("RDAP: %v; WHOIS: %w", rdapErr, err)
+	}
+	return result, err
+}
+
+// LookupMany looks up targets concurrently with at most concurrency queries
+// in flight. Results are returned in the order of targets; failed lookups
+// carry the error text instead of a result. Set ServerDelay to space out
+// queries that land on the same server.
+func (c *Client) LookupMany(ctx context.Context, targets []string, method Method, concurrency int) []BulkResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]BulkResult, len(targets))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, target := range targets {
+		results[i].Query = target
+
+		select {
+		case <-ctx.Done():
+			results[i].Error = ctx.Err().Error()
+			continue
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(i int, target string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result, err := c.LookupWith(ctx, target, method)
+			if err != nil {
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Result = result
+		}(i, target)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// throttle blocks until a query to server is allowed by ServerDelay. Slots
+// are reserved under the lock, so concurrent callers queue up in turn.
+func (c *Client) throttle(ctx context.Context, server string) error {
+	if c.ServerDelay <= 0 {
+		return nil
+	}
+	key := strings.ToLower(server)
+
+	c.mu.Lock()
+	if c.nextQuery == nil {
+		c.nextQuery = make(map[string]time.Time)
+	}
+	now := time.Now()
+	at := c.nextQuery[key]
+	if at.Before(now) {
+		at = now
+	}
+	c.nextQuery[key] = at.Add(c.ServerDelay)
+	c.mu.Unlock()
+
+	wait := time.Until(at)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
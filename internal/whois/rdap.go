@@ -0,0 +1,431 @@
+package whois
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultRDAPBootstrapURL is the IANA RDAP bootstrap registry (RFC 7484).
+const DefaultRDAPBootstrapURL = "https://data.iana.org/rdap/"
+
+// rdapBootstrap is an IANA bootstrap file (dns.json, ipv4.json, ipv6.json).
+type rdapBootstrap struct {
+	Services [][][]string `json:"services"`
+}
+
+// rdapResponse covers the domain and IP network objects of RFC 9083.
+type rdapResponse struct {
+	ObjectClassName string           `json:"objectClassName"`
+	LDHName         string           `json:"ldhName"`
+	Handle          string           `json:"handle"`
+	Name            string           `json:"name"`
+	StartAddress    string           `json:"startAddress"`
+	EndAddress      string           `json:"endAddress"`
+	Country         string           `json:"country"`
+	Status          []string         `json:"status"`
+	Events          []rdapEvent      `json:"events"`
+	Entities        []rdapEntity     `json:"entities"`
+	Nameservers     []rdapNameserver `json:"nameservers"`
+	SecureDNS       *struct {
+		DelegationSigned bool `json:"delegationSigned"`
+	} `json:"secureDNS"`
+	CIDRs []struct {
+		V4Prefix string `json:"v4prefix"`
+		V6Prefix string `json:"v6prefix"`
+		Length   int    `json:"length"`
+	} `json:"cidr0_cidrs"`
+	ErrorCode   int      `json:"errorCode"`
+	Title       string   `json:"title"`
+	Description []string `json:"description"`
+}
+
+type rdapEvent struct {
+	Action string `json:"eventAction"`
+	Date   string `json:"eventDate"`
+}
+
+type rdapNameserver struct {
+	LDHName string `json:"ldhName"`
+}
+
+type rdapEntity struct {
+	Handle     string            `json:"handle"`
+	Roles      []string          `json:"roles"`
+	VCardArray []json.RawMessage `json:"vcardArray"`
+	Entities   []rdapEntity      `json:"entities"`
+}
+
+// LookupRDAP queries the authoritative RDAP server (RFC 7482) for a domain
+// or IP address and maps the structured JSON response into a Result. The
+// server is found through the IANA bootstrap registry unless RDAPServer is
+// set.
+func (c *Client) LookupRDAP(ctx context.Context, target string) (*Result, error) {
+	start := time.Now()
+	result := &Result{
+		Query:       target,
+		Protocol:    "rdap",
+		NameServers: make([]string, 0),
+		Status:      make([]string, 0),
+	}
+
+	kind, path := "domain", "domain/"+url.PathEscape(strings.TrimSuffix(strings.ToLower(target), "."))
+	if ip := net.ParseIP(target); ip != nil {
+		kind, path = "ip", "ip/"+ip.String()
+	}
+	result.Type = kind
+
+	base := c.RDAPServer
+	if base == "" {
+		var err error
+		base, err = c.rdapServerFor(ctx, target, kind)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+
+	body, err := c.fetchRDAP(ctx, base+path, "application/rdap+json")
+	if err != nil {
+		return nil, err
+	}
+
+	var resp rdapResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("invalid RDAP response: %w", err)
+	}
+	if resp.ErrorCode != 0 {
+		return nil, fmt.Errorf("RDAP error %d: %s", resp.ErrorCode, resp.Title)
+	}
+
+	var pretty bytes.Buffer
+	if json.Indent(&pretty, body, "", "  ") == nil {
+		result.Raw = pretty.String()
+	} else {
+		result.Raw = string(body)
+	}
+	if u, err := url.Parse(base); err == nil {
+		result.Server = u.Host
+	}
+
+	applyRDAP(result, &resp)
+	if kind == "ip" {
+		c.enrichASN(ctx, result)
+	}
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// applyRDAP maps an RDAP object onto Result fields.
+func applyRDAP(result *Result, resp *rdapResponse) {
+	for _, st := range resp.Status {
+		result.Status = append(result.Status, rdapStatus(st))
+	}
+	result.Country = resp.Country
+	if resp.SecureDNS != nil {
+		result.DNSSEC = "unsigned"
+		if resp.SecureDNS.DelegationSigned {
+			result.DNSSEC = "signed"
+		}
+	}
+
+	for _, ev := range resp.Events {
+		switch ev.Action {
+		case "registration":
+			result.CreatedDate = ev.Date
+		case "last changed":
+			result.UpdatedDate = ev.Date
+		case "expiration":
+			result.ExpiresDate = ev.Date
+		}
+	}
+
+	for _, ns := range resp.Nameservers {
+		if ns.LDHName != "" {
+			result.NameServers = append(result.NameServers, strings.ToLower(ns.LDHName))
+		}
+	}
+
+	if result.Type == "ip" {
+		result.NetName = resp.Name
+		if resp.StartAddress != "" && resp.EndAddress != "" {
+			result.NetRange = resp.StartAddress + " - " + resp.EndAddress
+		}
+		cidrs := make([]string, 0, len(resp.CIDRs))
+		for _, c := range resp.CIDRs {
+			prefix := c.V4Prefix
+			if prefix == "" {
+				prefix = c.V6Prefix
+			}
+			if prefix != "" {
+				cidrs = append(cidrs, fmt.Sprintf("%s/%d", prefix, c.Length))
+			}
+		}
+		result.CIDR = strings.Join(cidrs, ", ")
+	}
+
+	applyRDAPEntities(result, resp.Entities)
+}
+
+// rdapContactRoles maps RDAP entity roles to Contact roles.
+var rdapContactRoles = map[string]string{
+	"registrant":     "registrant",
+	"administrative": "admin",
+	"technical":      "tech",
+}
+
+// applyRDAPEntities maps registrar, contact and abuse entities, including
+// those nested inside other entities (e.g. the registrar's abuse contact).
+func applyRDAPEntities(result *Result, entities []rdapEntity) {
+	for _, e := range entities {
+		v := parseVCard(e.VCardArray)
+		if hasRole(e, "registrar") && result.Registrar == "" {
+			result.Registrar = v.name()
+		}
+		if hasRole(e, "registrant") {
+			if result.Organization == "" {
+				result.Organization = v.name()
+			}
+			if result.Country == "" {
+				result.Country = v.country
+			}
+		}
+		if hasRole(e, "abuse") {
+			setOnce(&result.AbuseEmail, v.email)
+			setOnce(&result.AbusePhone, v.tel)
+		}
+		for _, r := range e.Roles {
+			if role, ok := rdapContactRoles[r]; ok {
+				mergeContact(result, Contact{
+					Role:         role,
+					Name:         v.fn,
+					Organization: v.org,
+					Email:        v.email,
+					Phone:        v.tel,
+					Country:      v.country,
+				})
+			}
+		}
+		applyRDAPEntities(result, e.Entities)
+	}
+}
+
+// rdapServerFor finds the RDAP base URL for target in the IANA bootstrap.
+func (c *Client) rdapServerFor(ctx context.Context, target, kind string) (string, error) {
+	file := "dns.json"
+	ip := net.ParseIP(target)
+	if kind == "ip" {
+		file = "ipv6.json"
+		if ip.To4() != nil {
+			file = "ipv4.json"
+		}
+	}
+
+	boot, err := c.bootstrap(ctx, file)
+	if err != nil {
+		return "", err
+	}
+
+	best, bestLen := "", -1
+	for _, svc := range boot.Services {
+		if len(svc) < 2 || len(svc[1]) == 0 {
+			continue
+		}
+		for _, entry := range svc[0] {
+			n := -1
+			if kind == "ip" {
+				if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(ip) {
+					n, _ = network.Mask.Size()
+				}
+			} else {
+				name := strings.ToLower(strings.TrimSuffix(target, "."))
+				entry = strings.ToLower(entry)
+				if name == entry || strings.HasSuffix(name, "."+entry) {
+					n = strings.Count(entry, ".") + 1
+				}
+			}
+			if n > bestLen {
+				best, bestLen = preferHTTPS(svc[1]), n
+			}
+		}
+	}
+
+	if best == "" {
+		return "", fmt.Errorf("no RDAP server found for %s", target)
+	}
+	return best, nil
+}
+
+// bootstrap fetches and caches an IANA bootstrap file.
+func (c *Client) bootstrap(ctx context.Context, file string) (*rdapBootstrap, error) {
+	c.mu.Lock()
+	if b, ok := c.bootstrapCache[file]; ok {
+		c.mu.Unlock()
+		return b, nil
+	}
+	c.mu.Unlock()
+
+	base := c.RDAPBootstrapURL
+	if base == "" {
+		base = DefaultRDAPBootstrapURL
+	}
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+
+	body, err := c.fetchRDAP(ctx, base+file, "application/json")
+	if err != nil {
+		return nil, fmt.Errorf("RDAP bootstrap: %w", err)
+	}
+	var b rdapBootstrap
+	if err := json.Unmarshal(body, &b); err != nil {
+		return nil, fmt.Errorf("invalid RDAP bootstrap %s: %w", file, err)
+	}
+
+	c.mu.Lock()
+	if c.bootstrapCache == nil {
+		c.bootstrapCache = make(map[string]*rdapBootstrap)
+	}
+	c.bootstrapCache[file] = &b
+	c.mu.Unlock()
+	return &b, nil
+}
+
+// fetchRDAP performs a GET and returns the body. RDAP servers answer
+// unknown objects with 404 and a JSON error body.
+func (c *Client) fetchRDAP(ctx context.Context, rawURL, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	if err := c.throttle(ctx, req.URL.Host); err != nil {
+		return nil, err
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: c.Timeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("RDAP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read RDAP response: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("RDAP: object not found")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("RDAP server returned %s", resp.Status)
+	}
+	return body, nil
+}
+
+// preferHTTPS picks the first https URL from a bootstrap service entry.
+func preferHTTPS(urls []string) string {
+	for _, u := range urls {
+		if strings.HasPrefix(u, "https://") {
+			return u
+		}
+	}
+	return urls[0]
+}
+
+func hasRole(e rdapEntity, role string) bool {
+	for _, r := range e.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// vcard holds the jCard (RFC 7095) properties used by the parser.
+type vcard struct {
+	fn      string
+	org     string
+	country string
+	email   string
+	tel     string
+}
+
+// name prefers the organization over the formatted name.
+func (v vcard) name() string {
+	if v.org != "" {
+		return v.org
+	}
+	return v.fn
+}
+
+// parseVCard decodes a jCard array: ["vcard", [[name, params, type, value], ...]].
+func parseVCard(raw []json.RawMessage) vcard {
+	var v vcard
+	if len(raw) < 2 {
+		return v
+	}
+	var props [][]json.RawMessage
+	if err := json.Unmarshal(raw[1], &props); err != nil {
+		return v
+	}
+
+	for _, p := range props {
+		if len(p) < 4 {
+			continue
+		}
+		var name string
+		if json.Unmarshal(p[0], &name) != nil {
+			continue
+		}
+		var params struct {
+			CC    string `json:"cc"`
+			Label string `json:"label"`
+		}
+		json.Unmarshal(p[1], &params)
+
+		switch name {
+		case "fn":
+			json.Unmarshal(p[3], &v.fn)
+		case "org":
+			if json.Unmarshal(p[3], &v.org) != nil {
+				var parts []string
+				if json.Unmarshal(p[3], &parts) == nil && len(parts) > 0 {
+					v.org = parts[0]
+				}
+			}
+		case "email":
+			if v.email == "" {
+				json.Unmarshal(p[3], &v.email)
+			}
+		case "tel":
+			if v.tel == "" {
+				json.Unmarshal(p[3], &v.tel)
+				v.tel = strings.TrimPrefix(v.tel, "tel:")
+			}
+		case "adr":
+			if params.CC != "" {
+				v.country = params.CC
+				continue
+			}
+			// Structured address: the last component is the country name.
+			var parts []json.RawMessage
+			if json.Unmarshal(p[3], &parts) == nil && len(parts) >= 7 {
+				json.Unmarshal(parts[6], &v.country)
+			}
+		}
+	}
+	return v
+}
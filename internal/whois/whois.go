@@ -7,41 +7,78 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"net/http"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 )
 
 // Result holds the parsed WHOIS response.
 type Result struct {
-	Query        string
-	Type         string // "domain" or "ip"
-	Server       string
-	Registrar    string
-	Organization string
-	CreatedDate  string
-	UpdatedDate  string
-	ExpiresDate  string
-	NameServers  []string
-	Status       []string
-	CIDR         string
-	NetName      string
-	NetRange     string
-	Country      string
-	Raw          string
-	Duration     time.Duration
+	Query        string        `json:"query"`
+	Type         string        `json:"type"`     // "domain" or "ip"
+	Protocol     string        `json:"protocol"` // "whois" or "rdap"
+	Server       string        `json:"server"`
+	Referral     string        `json:"referral,omitempty"` // Registrar/RIR server the query was referred to
+	Registrar    string        `json:"registrar,omitempty"`
+	Organization string        `json:"organization,omitempty"`
+	CreatedDate  string        `json:"created,omitempty"`
+	UpdatedDate  string        `json:"updated,omitempty"`
+	ExpiresDate  string        `json:"expires,omitempty"`
+	NameServers  []string      `json:"name_servers,omitempty"`
+	Status       []string      `json:"status,omitempty"`
+	CIDR         string        `json:"cidr,omitempty"`
+	NetName      string        `json:"net_name,omitempty"`
+	NetRange     string        `json:"net_range,omitempty"`
+	Country      string        `json:"country,omitempty"`
+	ASN          int           `json:"asn,omitempty"`      // Origin AS announcing the IP
+	ASName       string        `json:"as_name,omitempty"`  // Origin AS name
+	Prefix       string        `json:"prefix,omitempty"`   // BGP prefix announcing the IP
+	DNSSEC       string        `json:"dnssec,omitempty"`   // "signed", "unsigned" or "" if not reported
+	Contacts     []Contact     `json:"contacts,omitempty"` // Registrant, admin and tech contacts
+	AbuseEmail   string        `json:"abuse_email,omitempty"`
+	AbusePhone   string        `json:"abuse_phone,omitempty"`
+	Raw          string        `json:"-"`
+	Duration     time.Duration `json:"duration"`
+}
+
+// Contact is a registrant, administrative or technical contact.
+type Contact struct {
+	Role         string `json:"role"` // "registrant", "admin" or "tech"
+	Name         string `json:"name,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	Email        string `json:"email,omitempty"`
+	Phone        string `json:"phone,omitempty"`
+	Country      string `json:"country,omitempty"`
 }
 
 // Client performs WHOIS lookups.
 type Client struct {
-	Timeout time.Duration
-	Server  string // Custom WHOIS server (optional)
+	Timeout        time.Duration
+	Server         string // Custom WHOIS server (optional)
+	FollowReferral bool   // Re-query the referred registrar/RIR server
+	ResolveASN     bool   // Add the origin ASN and prefix to IP results
+
+	RDAPServer       string       // Custom RDAP base URL (optional, skips bootstrap)
+	RDAPBootstrapURL string       // IANA bootstrap location (default DefaultRDAPBootstrapURL)
+	HTTPClient       *http.Client // HTTP client for RDAP (optional)
+
+	// ServerDelay is the minimum gap between queries to the same WHOIS or
+	// RDAP server, to stay under registry rate limits in bulk lookups.
+	ServerDelay time.Duration
+
+	mu             sync.Mutex
+	bootstrapCache map[string]*rdapBootstrap
+	nextQuery      map[string]time.Time
 }
 
 // NewClient creates a new WHOIS client with defaults.
 func NewClient() *Client {
 	return &Client{
-		Timeout: 10 * time.Second,
+		Timeout:        10 * time.Second,
+		FollowReferral: true,
+		ResolveASN:     true,
 	}
 }
 
@@ -50,6 +87,7 @@ func (c *Client) Lookup(ctx context.Context, target string) (*Result, error) {
 	start := time.Now()
 	result := &Result{
 		Query:       target,
+		Protocol:    "whois",
 		NameServers: make([]string, 0),
 		Status:      make([]string, 0),
 	}
@@ -57,7 +95,13 @@ func (c *Client) Lookup(ctx context.Context, target string) (*Result, error) {
 	// Determine if target is IP or domain
 	if ip := net.ParseIP(target); ip != nil {
 		result.Type = "ip"
-		return c.lookupIP(ctx, target, result, start)
+		result, err := c.lookupIP(ctx, target, result, start)
+		if err != nil {
+			return nil, err
+		}
+		c.enrichASN(ctx, result)
+		result.Duration = time.Since(start)
+		return result, nil
 	}
 
 	result.Type = "domain"
@@ -79,14 +123,77 @@ func (c *Client) lookupDomain(ctx context.Context, domain string, result *Result
 		return nil, err
 	}
 	result.Raw = raw
+
+	// Thin registries (e.g. Verisign for .com) only point at the registrar's
+	// server, which holds the full record. Parse the most specific response
+	// first so its values win, then fill gaps from the thinner ones.
+	responses := []string{raw}
+	if c.FollowReferral {
+		seen := map[string]bool{strings.ToLower(server): true}
+		for hop := 0; hop < maxReferrals; hop++ {
+			ref := findReferral(responses[len(responses)-1])
+			if ref == "" || seen[strings.ToLower(ref)] {
+				break
+			}
+			seen[strings.ToLower(ref)] = true
+
+			refRaw, err := c.query(ctx, ref, domain)
+			if err != nil || strings.TrimSpace(refRaw) == "" {
+				break
+			}
+			result.Referral = ref
+			result.Raw += "\n# " + ref + "\n\n" + refRaw
+			responses = append(responses, refRaw)
+		}
+	}
 	result.Duration = time.Since(start)
 
-	// Parse response
-	parseDomainWhois(result, raw)
+	for i := len(responses) - 1; i >= 0; i-- {
+		parseDomainWhois(result, responses[i])
+	}
+	result.NameServers = dedupe(result.NameServers)
+	result.Status = dedupe(result.Status)
 
 	return result, nil
 }
 
+// maxReferrals bounds how many referral hops are followed
+// (e.g. IANA -> registry -> registrar).
+const maxReferrals = 2
+
+// referralPattern matches the referral lines used by IANA ("refer:",
+// "whois:"), thin registries ("Registrar WHOIS Server:") and ARIN
+// ("ReferralServer:").
+var referralPattern = regexp.MustCompile(`(?im)^\s*(?:refer|whois|Registrar WHOIS Server|ReferralServer):\s*(\S+)\s*$`)
+
+// findReferral returns the WHOIS server a response refers to, or "".
+func findReferral(raw string) string {
+	for _, m := range referralPattern.FindAllStringSubmatch(raw, -1) {
+		server := strings.TrimPrefix(strings.TrimSpace(m[1]), "whois://")
+		server = strings.TrimSuffix(server, "/")
+		// Skip web URLs and rwhois:// referrals, which are not port-43 WHOIS.
+		if server == "" || strings.Contains(server, "://") || strings.Contains(server, "/") {
+			continue
+		}
+		return server
+	}
+	return ""
+}
+
+// dedupe removes case-insensitive duplicates while preserving order.
+func dedupe(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		key := strings.ToLower(v)
+		if !seen[key] {
+			seen[key] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
 // lookupIP performs WHOIS lookup for an IP address.
 func (c *Client) lookupIP(ctx context.Context, ip string, result *Result, start time.Time) (*Result, error) {
 	// Use ARIN as default for IP lookups
@@ -104,7 +211,14 @@ func (c *Client) lookupIP(ctx context.Context, ip string, result *Result, start
 	result.Duration = time.Since(start)
 
 	// Check for referral to other RIR
-	if strings.Contains(raw, "whois.ripe.net") {
+	if ref := findReferral(raw); c.FollowReferral && ref != "" && !strings.EqualFold(ref, server) {
+		raw2, _ := c.query(ctx, ref, ip)
+		if raw2 != "" {
+			result.Raw = raw2
+			result.Server = ref
+			result.Referral = ref
+		}
+	} else if strings.Contains(raw, "whois.ripe.net") {
 		raw2, _ := c.query(ctx, "whois.ripe.net", ip)
 		if raw2 != "" {
 			result.Raw = raw2
@@ -128,6 +242,9 @@ func (c *Client) query(ctx context.Context, server, query string) (string, error
 	if !strings.Contains(server, ":") {
 		server = server + ":43"
 	}
+	if err := c.throttle(ctx, server); err != nil {
+		return "", err
+	}
 
 	d := net.Dialer{Timeout: c.Timeout}
 	conn, err := d.DialContext(ctx, "tcp", server)
@@ -268,7 +385,9 @@ func parseDomainWhois(result *Result, raw string) {
 				case "nameserver":
 					result.NameServers = append(result.NameServers, strings.ToLower(value))
 				case "status":
-					result.Status = append(result.Status, value)
+					if status := normalizeStatus(value); status != "" {
+						result.Status = append(result.Status, status)
+					}
 				case "country":
 					if result.Country == "" {
 						result.Country = value
@@ -277,6 +396,191 @@ func parseDomainWhois(result *Result, raw string) {
 			}
 		}
 	}
+
+	parseExtendedFields(result, raw)
+}
+
+// contactRoles maps the role prefixes used by different registries to a
+// canonical role. Longer prefixes are tried first.
+var contactRoles = []struct {
+	prefix string
+	role   string
+}{
+	{"administrative contact ", "admin"},
+	{"technical contact ", "tech"},
+	{"administrative ", "admin"},
+	{"technical ", "tech"},
+	{"registrant ", "registrant"},
+	{"admin ", "admin"},
+	{"tech ", "tech"},
+}
+
+// contactFields normalizes contact attribute labels.
+var contactFields = map[string]string{
+	"name":         "name",
+	"organization": "organization",
+	"organisation": "organization",
+	"org":          "organization",
+	"email":        "email",
+	"e-mail":       "email",
+	"phone":        "phone",
+	"phone number": "phone",
+	"country":      "country",
+	"country code": "country",
+}
+
+// fieldAliases normalizes labels for DNSSEC and abuse contacts across
+// registries and RIRs.
+var fieldAliases = map[string]string{
+	"dnssec":                        "dnssec",
+	"dnssec signed":                 "dnssec",
+	"signed delegation":             "dnssec",
+	"orgabuseemail":                 "abuse_email",
+	"abuse-mailbox":                 "abuse_email",
+	"abuse email":                   "abuse_email",
+	"abuse e-mail":                  "abuse_email",
+	"registrar abuse contact email": "abuse_email",
+	"orgabusephone":                 "abuse_phone",
+	"abuse phone":                   "abuse_phone",
+	"registrar abuse contact phone": "abuse_phone",
+}
+
+// ripeAbusePattern matches the RIPE/APNIC abuse comment line.
+var ripeAbusePattern = regexp.MustCompile(`(?i)abuse contact for '[^']*' is '([^']+)'`)
+
+// parseExtendedFields extracts DNSSEC, contacts and abuse details using the
+// normalization tables above.
+func parseExtendedFields(result *Result, raw string) {
+	contacts := make(map[string]*Contact)
+	order := make([]string, 0)
+
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if m := ripeAbusePattern.FindStringSubmatch(line); m != nil && result.AbuseEmail == "" {
+			result.AbuseEmail = m[1]
+			continue
+		}
+
+		label, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		label = strings.ToLower(strings.TrimSpace(label))
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		switch fieldAliases[label] {
+		case "dnssec":
+			if result.DNSSEC == "" {
+				result.DNSSEC = normalizeDNSSEC(value)
+			}
+			continue
+		case "abuse_email":
+			if result.AbuseEmail == "" {
+				result.AbuseEmail = value
+			}
+			continue
+		case "abuse_phone":
+			if result.AbusePhone == "" {
+				result.AbusePhone = value
+			}
+			continue
+		}
+
+		for _, cr := range contactRoles {
+			if !strings.HasPrefix(label, cr.prefix) {
+				continue
+			}
+			field, ok := contactFields[strings.TrimPrefix(label, cr.prefix)]
+			if !ok {
+				break
+			}
+			c, exists := contacts[cr.role]
+			if !exists {
+				c = &Contact{Role: cr.role}
+				contacts[cr.role] = c
+				order = append(order, cr.role)
+			}
+			switch field {
+			case "name":
+				setOnce(&c.Name, value)
+			case "organization":
+				setOnce(&c.Organization, value)
+			case "email":
+				setOnce(&c.Email, value)
+			case "phone":
+				setOnce(&c.Phone, value)
+			case "country":
+				setOnce(&c.Country, value)
+			}
+			break
+		}
+	}
+
+	for _, role := range order {
+		mergeContact(result, *contacts[role])
+	}
+}
+
+// mergeContact adds c, filling gaps in an existing contact with the same role.
+func mergeContact(result *Result, c Contact) {
+	for i := range result.Contacts {
+		existing := &result.Contacts[i]
+		if existing.Role == c.Role {
+			setOnce(&existing.Name, c.Name)
+			setOnce(&existing.Organization, c.Organization)
+			setOnce(&existing.Email, c.Email)
+			setOnce(&existing.Phone, c.Phone)
+			setOnce(&existing.Country, c.Country)
+			return
+		}
+	}
+	result.Contacts = append(result.Contacts, c)
+}
+
+func setOnce(dst *string, value string) {
+	if *dst == "" {
+		*dst = value
+	}
+}
+
+// normalizeStatus reduces a status line to its EPP code, dropping the
+// ICANN explanation URL ("clientTransferProhibited https://icann.org/epp#...").
+func normalizeStatus(value string) string {
+	value = strings.TrimSpace(value)
+	if i := strings.Index(value, "http"); i > 0 {
+		value = strings.TrimSpace(value[:i])
+		value = strings.TrimSpace(strings.TrimSuffix(value, "("))
+	}
+	return value
+}
+
+// rdapStatus converts an RDAP status phrase ("client transfer prohibited")
+// to the EPP code used by WHOIS ("clientTransferProhibited").
+func rdapStatus(value string) string {
+	words := strings.Fields(value)
+	if len(words) == 0 {
+		return ""
+	}
+	out := strings.ToLower(words[0])
+	for _, w := range words[1:] {
+		out += strings.ToUpper(w[:1]) + strings.ToLower(w[1:])
+	}
+	return out
+}
+
+// normalizeDNSSEC maps the many registry spellings to "signed" or "unsigned".
+func normalizeDNSSEC(value string) string {
+	v := strings.ToLower(value)
+	switch {
+	case strings.Contains(v, "unsigned"), v == "no", v == "false", v == "inactive":
+		return "unsigned"
+	case strings.Contains(v, "signed"), v == "yes", v == "true", v == "active":
+		return "signed"
+	}
+	return value
 }
 
 // parseIPWhois extracts fields from IP WHOIS response.
@@ -331,6 +635,8 @@ func parseIPWhois(result *Result, raw string) {
 			}
 		}
 	}
+
+	parseExtendedFields(result, raw)
 }
 
 // IsExpired checks if a domain is expired based on WHOIS data.
@@ -1,7 +1,17 @@
 package whois
 
 import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/JedizLaPulga/NNS/internal/asn"
 )
 
 func TestGetWhoisServer(t *testing.T) {
@@ -100,3 +110,362 @@ func TestResultIsExpired(t *testing.T) {
 		t.Error("Date 2099-01-01 should not be expired")
 	}
 }
+
+const testRDAPDomain = `{
+  "objectClassName": "domain",
+  "ldhName": "EXAMPLE.COM",
+  "status": ["client transfer prohibited", "active"],
+  "secureDNS": {"delegationSigned": false},
+  "events": [
+    {"eventAction": "registration", "eventDate": "1995-08-14T04:00:00Z"},
+    {"eventAction": "last changed", "eventDate": "2023-08-14T07:01:38Z"},
+    {"eventAction": "expiration", "eventDate": "2099-08-13T04:00:00Z"}
+  ],
+  "nameservers": [{"ldhName": "A.IANA-SERVERS.NET"}, {"ldhName": "B.IANA-SERVERS.NET"}],
+  "entities": [
+    {"roles": ["registrar"], "vcardArray": ["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", "RESERVED-Internet Assigned Numbers Authority"]]]},
+    {"roles": ["registrant"], "vcardArray": ["vcard", [["fn", {}, "text", "Jane Doe"], ["org", {}, "text", "Example Org"], ["adr", {"cc": "US"}, "text", ["", "", "", "", "", "", ""]]]]}
+  ]
+}`
+
+const testRDAPIP = `{
+  "objectClassName": "ip network",
+  "name": "GOGL",
+  "startAddress": "8.8.8.0",
+  "endAddress": "8.8.8.255",
+  "cidr0_cidrs": [{"v4prefix": "8.8.8.0", "length": 24}],
+  "events": [{"eventAction": "registration", "eventDate": "2014-03-14T15:52:05-04:00"}],
+  "entities": [
+    {"roles": ["registrant"], "vcardArray": ["vcard", [["fn", {}, "text", "Google LLC"], ["adr", {}, "text", ["", "", "1600 Amphitheatre Parkway", "Mountain View", "CA", "94043", "United States"]]]]}
+  ]
+}`
+
+func newRDAPTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bootstrap/dns.json":
+			fmt.Fprintf(w, `{"services": [[["com", "net"], ["%s/rdap/"]], [["org"], ["%s/other/"]]]}`, srv.URL, srv.URL)
+		case "/bootstrap/ipv4.json":
+			// The more specific /16 must win over the /8.
+			fmt.Fprintf(w, `{"services": [[["8.0.0.0/8"], ["http://example.invalid/"]], [["8.8.0.0/16"], ["%s/rdap/"]]]}`, srv.URL)
+		case "/rdap/domain/example.com":
+			w.Header().Set("Content-Type", "application/rdap+json")
+			w.Write([]byte(testRDAPDomain))
+		case "/rdap/ip/8.8.8.8":
+			w.Header().Set("Content-Type", "application/rdap+json")
+			w.Write([]byte(testRDAPIP))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errorCode": 404, "title": "Not Found"}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestLookupRDAPDomain(t *testing.T) {
+	srv := newRDAPTestServer(t)
+	client := NewClient()
+	client.RDAPBootstrapURL = srv.URL + "/bootstrap/"
+
+	result, err := client.LookupRDAP(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("LookupRDAP() error = %v", err)
+	}
+	if result.Protocol != "rdap" || result.Type != "domain" {
+		t.Errorf("Protocol/Type = %s/%s, want rdap/domain", result.Protocol, result.Type)
+	}
+	if result.Registrar != "RESERVED-Internet Assigned Numbers Authority" {
+		t.Errorf("Registrar = %q", result.Registrar)
+	}
+	if result.Organization != "Example Org" || result.Country != "US" {
+		t.Errorf("Organization/Country = %q/%q, want Example Org/US", result.Organization, result.Country)
+	}
+	if result.CreatedDate != "1995-08-14T04:00:00Z" || result.ExpiresDate != "2099-08-13T04:00:00Z" {
+		t.Errorf("dates = %q/%q", result.CreatedDate, result.ExpiresDate)
+	}
+	if result.DaysUntilExpiry() < 0 {
+		t.Error("DaysUntilExpiry() should parse the RDAP expiration date")
+	}
+	if len(result.NameServers) != 2 || result.NameServers[0] != "a.iana-servers.net" {
+		t.Errorf("NameServers = %v", result.NameServers)
+	}
+	if len(result.Status) != 2 || result.Status[0] != "clientTransferProhibited" {
+		t.Errorf("Status = %v, want [clientTransferProhibited active]", result.Status)
+	}
+	if result.DNSSEC != "unsigned" {
+		t.Errorf("DNSSEC = %q, want unsigned", result.DNSSEC)
+	}
+	if len(result.Contacts) != 1 || result.Contacts[0].Role != "registrant" || result.Contacts[0].Name != "Jane Doe" {
+		t.Errorf("Contacts = %+v, want registrant Jane Doe", result.Contacts)
+	}
+
+	if _, err := client.LookupRDAP(context.Background(), "missing.com"); err == nil {
+		t.Error("LookupRDAP() expected error for unknown domain")
+	}
+	if _, err := client.LookupRDAP(context.Background(), "example.xyz"); err == nil {
+		t.Error("LookupRDAP() expected error for TLD without RDAP service")
+	}
+}
+
+func TestLookupRDAPIP(t *testing.T) {
+	stubASN(t, &asn.ASInfo{ASN: 15169, Name: "GOOGLE - Google LLC, US", Prefix: "8.8.8.0/24"})
+
+	srv := newRDAPTestServer(t)
+	client := NewClient()
+	client.RDAPBootstrapURL = srv.URL + "/bootstrap/"
+
+	result, err := client.LookupRDAP(context.Background(), "8.8.8.8")
+	if err != nil {
+		t.Fatalf("LookupRDAP() error = %v", err)
+	}
+	if result.Type != "ip" || result.NetName != "GOGL" {
+		t.Errorf("Type/NetName = %s/%s", result.Type, result.NetName)
+	}
+	if result.NetRange != "8.8.8.0 - 8.8.8.255" || result.CIDR != "8.8.8.0/24" {
+		t.Errorf("NetRange/CIDR = %q/%q", result.NetRange, result.CIDR)
+	}
+	if result.Organization != "Google LLC" || result.Country != "United States" {
+		t.Errorf("Organization/Country = %q/%q", result.Organization, result.Country)
+	}
+	if result.ASN != 15169 || result.ASName != "GOOGLE - Google LLC, US" || result.Prefix != "8.8.8.0/24" {
+		t.Errorf("ASN/ASName/Prefix = %d/%q/%q", result.ASN, result.ASName, result.Prefix)
+	}
+
+	client.ResolveASN = false
+	result, err = client.LookupRDAP(context.Background(), "8.8.8.8")
+	if err != nil {
+		t.Fatalf("LookupRDAP() error = %v", err)
+	}
+	if result.ASN != 0 {
+		t.Errorf("ResolveASN=false still set ASN %d", result.ASN)
+	}
+}
+
+// stubASN replaces the Team Cymru lookup with a fixed answer.
+func stubASN(t *testing.T, info *asn.ASInfo) {
+	t.Helper()
+	orig := lookupASN
+	lookupASN = func(ctx context.Context, ip string, timeout time.Duration) (*asn.ASInfo, error) {
+		return info, nil
+	}
+	t.Cleanup(func() { lookupASN = orig })
+}
+
+// startWhoisServer serves a fixed response on a local port-43 style listener.
+func startWhoisServer(t *testing.T, response string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			bufio.NewReader(conn).ReadString('\n')
+			conn.Write([]byte(response))
+			conn.Close()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestFindReferral(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"   Registrar WHOIS Server: whois.markmonitor.com\r\n", "whois.markmonitor.com"},
+		{"refer:        whois.verisign-grs.com\n", "whois.verisign-grs.com"},
+		{"ReferralServer:  whois://whois.ripe.net\n", "whois.ripe.net"},
+		{"ReferralServer:  rwhois://rwhois.example.net:4321\n", ""},
+		{"Registrar WHOIS Server: http://www.example-registrar.com/whois\n", ""},
+		{"Domain Name: EXAMPLE.COM\n", ""},
+	}
+	for _, tt := range tests {
+		if got := findReferral(tt.raw); got != tt.want {
+			t.Errorf("findReferral(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestLookupFollowsReferral(t *testing.T) {
+	registrar := startWhoisServer(t, "Domain Name: EXAMPLE.COM\r\nRegistrar: Example Registrar LLC\r\nRegistrant Organization: Example Corp\r\nRegistrant Country: NZ\r\nName Server: ns1.example.com\r\n")
+	registry := startWhoisServer(t, "Domain Name: EXAMPLE.COM\r\nRegistrar WHOIS Server: "+registrar+
+		"\r\nRegistrar: EXAMPLE REGISTRAR\r\nCreation Date: 1995-08-14T04:00:00Z\r\nName Server: NS1.EXAMPLE.COM\r\nName Server: NS2.EXAMPLE.COM\r\n")
+
+	client := NewClient()
+	client.Timeout = 2 * time.Second
+	client.Server = registry
+
+	result, err := client.Lookup(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if result.Referral != registrar {
+		t.Errorf("Referral = %q, want %q", result.Referral, registrar)
+	}
+	if result.Registrar != "Example Registrar LLC" {
+		t.Errorf("Registrar = %q, want the registrar's value", result.Registrar)
+	}
+	if result.Organization != "Example Corp" || result.Country != "NZ" {
+		t.Errorf("Organization/Country = %q/%q, want registrant data from the referral", result.Organization, result.Country)
+	}
+	if result.CreatedDate != "1995-08-14T04:00:00Z" {
+		t.Errorf("CreatedDate = %q, want value merged from the registry", result.CreatedDate)
+	}
+	if len(result.NameServers) != 2 {
+		t.Errorf("NameServers = %v, want 2 de-duplicated entries", result.NameServers)
+	}
+
+	client.FollowReferral = false
+	result, err = client.Lookup(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if result.Referral != "" || result.Organization != "" {
+		t.Errorf("FollowReferral=false still followed: Referral=%q Organization=%q", result.Referral, result.Organization)
+	}
+}
+
+func TestParseDomainWhoisExtended(t *testing.T) {
+	raw := `
+Domain Name: EXAMPLE.COM
+Registrar: Example Registrar Inc.
+Registrar Abuse Contact Email: abuse@registrar.example
+Registrar Abuse Contact Phone: +1.5555550100
+Domain Status: clientTransferProhibited https://icann.org/epp#clientTransferProhibited
+Domain Status: serverDeleteProhibited (https://icann.org/epp#serverDeleteProhibited)
+Registrant Name: REDACTED FOR PRIVACY
+Registrant Organization: Example Corp
+Registrant Country: US
+Admin Email: admin@example.com
+Administrative Contact Phone: +1.5555550101
+Tech Name: Tech Person
+Technical Contact Email: tech@example.com
+DNSSEC: signedDelegation
+`
+	result := &Result{}
+	parseDomainWhois(result, raw)
+
+	wantStatus := []string{"clientTransferProhibited", "serverDeleteProhibited"}
+	if len(result.Status) != 2 || result.Status[0] != wantStatus[0] || result.Status[1] != wantStatus[1] {
+		t.Errorf("Status = %v, want %v", result.Status, wantStatus)
+	}
+	if result.DNSSEC != "signed" {
+		t.Errorf("DNSSEC = %q, want signed", result.DNSSEC)
+	}
+	if result.AbuseEmail != "abuse@registrar.example" || result.AbusePhone != "+1.5555550100" {
+		t.Errorf("Abuse = %q/%q", result.AbuseEmail, result.AbusePhone)
+	}
+	if len(result.Contacts) != 3 {
+		t.Fatalf("Contacts = %+v, want registrant, admin and tech", result.Contacts)
+	}
+	if c := result.Contacts[0]; c.Role != "registrant" || c.Organization != "Example Corp" || c.Country != "US" {
+		t.Errorf("registrant = %+v", c)
+	}
+	if c := result.Contacts[1]; c.Role != "admin" || c.Email != "admin@example.com" || c.Phone != "+1.5555550101" {
+		t.Errorf("admin = %+v", c)
+	}
+	if c := result.Contacts[2]; c.Role != "tech" || c.Name != "Tech Person" || c.Email != "tech@example.com" {
+		t.Errorf("tech = %+v", c)
+	}
+}
+
+func TestParseIPWhoisAbuse(t *testing.T) {
+	arin := `
+OrgName:        Google LLC
+OrgAbuseEmail:  network-abuse@google.com
+OrgAbusePhone:  +1-650-253-0000
+`
+	result := &Result{}
+	parseIPWhois(result, arin)
+	if result.AbuseEmail != "network-abuse@google.com" || result.AbusePhone != "+1-650-253-0000" {
+		t.Errorf("ARIN abuse = %q/%q", result.AbuseEmail, result.AbusePhone)
+	}
+
+	ripe := `
+% Abuse contact for '193.0.0.0 - 193.0.7.255' is 'abuse@ripe.net'
+
+inetnum:        193.0.0.0 - 193.0.7.255
+netname:        RIPE-NCC
+`
+	result = &Result{}
+	parseIPWhois(result, ripe)
+	if result.AbuseEmail != "abuse@ripe.net" {
+		t.Errorf("RIPE abuse = %q, want abuse@ripe.net", result.AbuseEmail)
+	}
+}
+
+func TestNormalizeDNSSEC(t *testing.T) {
+	tests := map[string]string{
+		"unsigned":                        "unsigned",
+		"signedDelegation":                "signed",
+		"yes":                             "signed",
+		"no":                              "unsigned",
+		"Unsigned delegation, no records": "unsigned",
+	}
+	for in, want := range tests {
+		if got := normalizeDNSSEC(in); got != want {
+			t.Errorf("normalizeDNSSEC(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestResultToJSON(t *testing.T) {
+	r := &Result{Query: "example.com", Type: "domain", Protocol: "rdap", Server: "rdap.example", Raw: "raw", Duration: time.Second}
+	out, err := r.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v", err)
+	}
+	if !strings.Contains(out, `"query": "example.com"`) || !strings.Contains(out, `"duration": 1000000000`) {
+		t.Errorf("ToJSON() = %s", out)
+	}
+	if strings.Contains(out, "raw") || strings.Contains(out, "registrar") {
+		t.Errorf("ToJSON() included raw text or empty fields: %s", out)
+	}
+}
+
+func TestLookupMany(t *testing.T) {
+	server := startWhoisServer(t, "Domain Name: EXAMPLE.COM\r\nRegistrar: Example Registrar LLC\r\n")
+
+	client := NewClient()
+	client.Timeout = 2 * time.Second
+	client.Server = server
+	client.ServerDelay = 50 * time.Millisecond
+
+	targets := []string{"a.com", "b.com", "c.com"}
+	start := time.Now()
+	results := client.LookupMany(context.Background(), targets, MethodWHOIS, 3)
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("3 queries to one server took %v, want >= 2x ServerDelay", elapsed)
+	}
+
+	if len(results) != len(targets) {
+		t.Fatalf("got %d results, want %d", len(results), len(targets))
+	}
+	for i, br := range results {
+		if br.Query != targets[i] {
+			t.Errorf("results[%d].Query = %q, want %q", i, br.Query, targets[i])
+		}
+		if br.Error != "" || br.Result == nil || br.Result.Registrar != "Example Registrar LLC" {
+			t.Errorf("results[%d] = %+v", i, br)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results = client.LookupMany(ctx, targets, MethodWHOIS, 1)
+	for i, br := range results {
+		if br.Error == "" {
+			t.Errorf("results[%d] succeeded after cancel", i)
+		}
+	}
+}